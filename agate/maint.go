@@ -6,8 +6,8 @@ package agate
 
 import (
 	"github.com/emer/axon/axon"
-	"github.com/emer/axon/chans"
 	"github.com/emer/axon/interinhib"
+	"github.com/emer/emergent/emer"
 	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
@@ -22,11 +22,24 @@ func (pc *PulseClearParams) Defaults() {
 	pc.GABAB = 2
 }
 
+// MaintParams are parameters for NMDA-based active maintenance
+type MaintParams struct {
+	NMDAGbar float32 `def:"0.3" desc:"NMDA conductance for the MaintLayer -- stronger than the standard value, so that the lateral NMDA-only maintenance projections (see AddMaintLayer) can sustain activity across trials"`
+}
+
+func (mp *MaintParams) Defaults() {
+	mp.NMDAGbar = 0.3
+}
+
 ///////////////////////////////////////////////////////////////////////////
 // MaintLayer is a layer with NMDA channels that supports active maintenance
-// in frontal cortex, via NMDA channels (in an NMDAMaintPrjn).
+// in frontal cortex, via lateral projections that send conductance only into
+// the NMDA channels (Com.Target = GNMDA), and no decay of activity or NMDA
+// between trials, so maintained activity persists until it is cleared by
+// PulseClearNMDA, e.g., by an OutLayer when it gates.
 type MaintLayer struct {
-	chans.Layer
+	axon.Layer
+	Maint      MaintParams           `view:"inline" desc:"parameters for NMDA-based active maintenance"`
 	PulseClear PulseClearParams      `desc:"parameters for the synchronous pulse of activation / inhibition that clears NMDA maintenance."`
 	InterInhib interinhib.InterInhib `desc:"inhibition from output layer"`
 }
@@ -35,13 +48,28 @@ var KiT_MaintLayer = kit.Types.AddType(&MaintLayer{}, axon.LayerProps)
 
 func (ly *MaintLayer) Defaults() {
 	ly.Layer.Defaults()
-	ly.NMDA.Gbar = 0.02
+	ly.Maint.Defaults()
 	ly.PulseClear.Defaults()
 	ly.InterInhib.Defaults()
 	ly.InterInhib.Gi = 0.1
 	ly.InterInhib.Add = true
-	ly.Act.Init.Decay = 0
+	ly.Act.NMDA.Gbar = ly.Maint.NMDAGbar
+	ly.Act.Decay.Act = 0
+	ly.Act.Decay.Glong = 0
 	ly.Inhib.Pool.On = true
+	for _, pji := range ly.RcvPrjns {
+		pj := pji.(axon.AxonPrjn).AsAxon()
+		if pj.Typ == emer.Lateral && pj.Send == ly.AxonLay {
+			pj.Com.Target = axon.GNMDA
+		}
+	}
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *MaintLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Act.NMDA.Gbar = ly.Maint.NMDAGbar
 }
 
 // InhibFmGeAct computes inhibition Gi from Ge and Act averages within relevant Pools
@@ -49,12 +77,13 @@ func (ly *MaintLayer) InhibFmGeAct(ltime *axon.Time) {
 	lpl := &ly.Pools[0]
 	mxact := ly.InterInhibMaxAct(ltime)
 	lpl.Inhib.Act.Avg = mat32.Max(ly.InterInhib.Gi*mxact, lpl.Inhib.Act.Avg)
-	ly.Inhib.Layer.Inhib(&lpl.Inhib)
+	ly.Inhib.Layer.Inhib(&lpl.Inhib, ly.ActAvg.GiMult)
 	ly.PoolInhibFmGeAct(ltime)
 	ly.InhibFmPool(ltime)
 }
 
-// InterInhibMaxAct returns the AlphaMax activation for source layers
+// InterInhibMaxAct returns the maximum activation over the InterInhib
+// source layers
 func (ly *MaintLayer) InterInhibMaxAct(ltime *axon.Time) float32 {
 	mxact := float32(0)
 	for _, lnm := range ly.InterInhib.Lays {
@@ -62,11 +91,8 @@ func (ly *MaintLayer) InterInhibMaxAct(ltime *axon.Time) float32 {
 		if oli == nil {
 			continue
 		}
-		ol, ok := oli.(*OutLayer)
-		if ok {
-			mxact = ol.MaxAlphaMax()
-		}
-		// todo: anything else?
+		ol := oli.(axon.AxonLayer).AsAxon()
+		mxact = mat32.Max(mxact, ol.Pools[0].Inhib.Act.Max)
 	}
 	return mxact
 }
@@ -75,21 +101,22 @@ func (ly *MaintLayer) InterInhibMaxAct(ltime *axon.Time) float32 {
 // clears the NMDA and puts the layer into a refractory state by
 // activating the GABAB currents.
 func (ly *MaintLayer) PulseClearNMDA() {
-	for ni := range ly.GlNeurs {
+	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
 		nrn.Act = ly.Act.Init.Act
-		nrn.ActLrn = nrn.Act
 		nrn.Ge = ly.Act.Init.Ge
 		nrn.GeRaw = 0
 		nrn.Vm = ly.Act.Init.Vm
-
-		gnr := &ly.GlNeurs[ni]
-		gnr.VmEff = nrn.Vm
-		gnr.Gnmda = 0
-		gnr.NMDA = 0
-		gnr.NMDASyn = 0
-		gnr.GABAB = ly.PulseClear.GABAB
-		gnr.GABABx = gnr.GABAB
+		nrn.VmDend = nrn.Vm
+		nrn.Gnmda = 0
+		nrn.NMDA = 0
+		nrn.NMDASyn = 0
+		nrn.NMDARaw = 0
+		nrn.GABAB = ly.PulseClear.GABAB
+		nrn.GABABx = nrn.GABAB
 	}
 }
 
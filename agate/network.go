@@ -6,7 +6,6 @@ package agate
 
 import (
 	"github.com/emer/axon/axon"
-	"github.com/emer/axon/chans"
 	"github.com/emer/axon/deep"
 	"github.com/emer/axon/pcore"
 	"github.com/emer/emergent/emer"
@@ -43,7 +42,7 @@ func (nt *Network) UnitVarNames() []string {
 
 // UnitVarProps returns properties for variables
 func (nt *Network) UnitVarProps() map[string]string {
-	return chans.NeuronVarProps
+	return axon.NeuronVarProps
 }
 
 // SynVarNames returns the names of all the variables on the synapses in this network.
@@ -81,11 +80,13 @@ func (nt *Network) AddPFC(name string, nPoolsY, nPoolsX, nNeurY, nNeurX int, pul
 //         for mixing in to other models
 
 // AddMaintLayer adds a MaintLayer using 4D shape with pools,
-// and lateral NMDAMaint PoolOneToOne connectivity.
+// and lateral PoolOneToOne connectivity (class NMDAMaint), which
+// sends conductance only into the NMDA channels (see MaintLayer).
 func AddMaintLayer(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int) *MaintLayer {
 	ly := &MaintLayer{}
 	nt.AddLayerInit(ly, name, []int{nPoolsY, nPoolsX, nNeurY, nNeurX}, emer.Hidden)
-	chans.ConnectNMDA(nt, ly, ly, prjn.NewPoolOneToOne())
+	pj := nt.ConnectLayers(ly, ly, prjn.NewPoolOneToOne(), emer.Lateral)
+	pj.SetClass("NMDAMaint")
 	return ly
 }
 
@@ -5,22 +5,19 @@
 package agate
 
 import (
-	"github.com/emer/axon/chans"
 	"github.com/emer/axon/deep"
 	"github.com/emer/axon/pcore"
 )
 
 var (
-	// NeuronVarsAll is the agate collection of all neuron-level vars (deep, chans, pcore)
+	// NeuronVarsAll is the agate collection of all neuron-level vars (deep, pcore)
 	NeuronVarsAll []string
 )
 
 func init() {
 	dln := len(deep.NeuronVarsAll)
-	gln := len(chans.NeuronVars)
 	pln := len(pcore.NeuronVars)
-	NeuronVarsAll = make([]string, dln+gln+pln)
+	NeuronVarsAll = make([]string, dln+pln)
 	copy(NeuronVarsAll, deep.NeuronVarsAll)
-	copy(NeuronVarsAll[dln:], chans.NeuronVars)
-	copy(NeuronVarsAll[dln+gln:], pcore.NeuronVars)
+	copy(NeuronVarsAll[dln:], pcore.NeuronVars)
 }
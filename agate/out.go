@@ -15,11 +15,13 @@ import (
 // OutParams determine the behavior of OutLayer
 type OutParams struct {
 	ResetThr  float32       `desc:"threshold on activation, above which the ClearLays will be reset"`
+	ResetCyc  int           `def:"30" min:"0" desc:"cycle within the trial after which activation above ResetThr clears the ClearLays -- avoids clearing from the initial transient activity"`
 	ClearLays emer.LayNames `desc:"name of corresponding layers that are reset when this layer gets activated"`
 }
 
 func (np *OutParams) Defaults() {
 	np.ResetThr = 0.5
+	np.ResetCyc = 30
 }
 
 // OutLayer is a frontal cortex output layer (L5 PM), which typically is interconnected
@@ -45,6 +47,7 @@ func (ly *OutLayer) ClearLays() ([]PulseClearer, error) {
 		tly, err = ly.Network.LayerByNameTry(nm)
 		if err != nil {
 			log.Printf("OutLayer %s, ClearLay: %v\n", ly.Name(), err)
+			continue
 		}
 		lays = append(lays, tly.(PulseClearer))
 	}
@@ -60,7 +63,7 @@ func (ly *OutLayer) CyclePost(ltime *axon.Time) {
 // PulseClear sends a simulated synchronous pulse of activation / inhibition
 // to clear ClearLays
 func (ly *OutLayer) PulseClear(ltime *axon.Time) {
-	if ltime.Cycle < ly.NMDA.AlphaMaxCyc {
+	if ltime.Cycle < ly.Out.ResetCyc {
 		return
 	}
 	pl := ly.Pools[0]
@@ -5,13 +5,11 @@
 package axon
 
 import (
-	"math/rand"
-
 	"github.com/emer/axon/chans"
 	"github.com/emer/axon/knadapt"
-	"github.com/emer/emergent/erand"
 	"github.com/emer/etable/minmax"
 	"github.com/goki/ki/ints"
+	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
 
@@ -37,6 +35,9 @@ type ActParams struct {
 	NMDA    chans.NMDAParams  `view:"inline" desc:"NMDA channel parameters plus more general params"`
 	GABAB   chans.GABABParams `view:"inline" desc:"GABA-B / GIRK channel parameters"`
 	Attn    AttnParams        `view:"inline" desc:"Attentional modulation parameters: how Attn modulates Ge"`
+
+	ActNoise ActNoiseParams `view:"inline" desc:"noise added directly to Ge, Vm, or spiking on every cycle, drawn from a selectable distribution"`
+	Hetero   HeteroParams   `view:"inline" desc:"heterogeneity of intrinsic parameters (leak, threshold, adaptation) across neurons, sampled at InitWts"`
 }
 
 func (ac *ActParams) Defaults() {
@@ -57,6 +58,8 @@ func (ac *ActParams) Defaults() {
 	ac.NMDA.Gbar = 0.15 // .15 now -- was 0.3 best.
 	ac.GABAB.Defaults()
 	ac.Attn.Defaults()
+	ac.ActNoise.Defaults()
+	ac.Hetero.Defaults()
 	ac.Update()
 }
 
@@ -74,6 +77,8 @@ func (ac *ActParams) Update() {
 	ac.NMDA.Update()
 	ac.GABAB.Update()
 	ac.Attn.Update()
+	ac.ActNoise.Update()
+	ac.Hetero.Update()
 }
 
 ///////////////////////////////////////////////////////////////////////
@@ -106,6 +111,9 @@ func (ac *ActParams) DecayState(nrn *Neuron, decay float32) {
 
 		nrn.GiSyn -= decay * nrn.GiSyn
 		nrn.GiSelf -= decay * nrn.GiSelf
+		nrn.GiPrjn -= decay * nrn.GiPrjn
+		nrn.GeDend -= decay * nrn.GeDend
+		nrn.Mod -= decay * nrn.Mod
 	}
 
 	nrn.VmDend -= ac.Decay.Glong * (nrn.VmDend - ac.Init.Vm)
@@ -126,6 +134,11 @@ func (ac *ActParams) DecayState(nrn *Neuron, decay float32) {
 	nrn.Inet = 0
 	nrn.GeRaw = 0
 	nrn.GiRaw = 0
+	nrn.GiPrjnRaw = 0
+	nrn.ErevPrjnRaw = 0
+	nrn.GeDendRaw = 0
+	nrn.NMDARaw = 0
+	nrn.ModRaw = 0
 }
 
 // InitActs initializes activation state in neuron -- called during InitWts but otherwise not
@@ -170,7 +183,20 @@ func (ac *ActParams) InitActs(nrn *Neuron) {
 	nrn.GgabaB = 0
 	nrn.GABAB = 0
 	nrn.GABABx = 0
+
+	nrn.GiPrjn = 0
+	nrn.ErevPrjn = ac.Erev.I
+	nrn.GiPrjnRaw = 0
+	nrn.ErevPrjnRaw = 0
+
+	nrn.GeDend = 0
+	nrn.GeDendRaw = 0
+	nrn.NMDARaw = 0
+	nrn.Mod = 0
+	nrn.ModRaw = 0
+
 	nrn.Attn = 1
+	nrn.ClampMult = 1
 
 	ac.InitLongActs(nrn)
 }
@@ -193,31 +219,57 @@ func (ac *ActParams) InitLongActs(nrn *Neuron) {
 //  Cycle
 
 // GeFmRaw integrates Ge excitatory conductance from GeRaw value into GeSyn
-// geExt is extra conductance to add to the final Ge value
+// geExt is extra conductance to add to the final Ge value.
+// cyc is the cycle within the state (or phase for Target layers), used for
+// ramping of clamped inputs.
 func (ac *ActParams) GeFmRaw(nrn *Neuron, geRaw, geExt float32, cyc int, actm float32) {
-	if ac.Clamp.Add && nrn.HasFlag(NeurHasExt) {
-		geRaw += nrn.Ext * ac.Clamp.Ge
+	if ac.Clamp.Add && ac.Clamp.Type == ClampGe && nrn.HasFlag(NeurHasExt) {
+		geRaw += ac.Clamp.ClampGe(nrn.Ext, cyc, nrn.ClampMult)
 	}
 	geRaw = ac.Attn.ModVal(geRaw, nrn.Attn)
 
-	if !ac.Clamp.Add && nrn.HasFlag(NeurHasExt) {
-		nrn.GeSyn = nrn.Ext * ac.Clamp.Ge
+	if !ac.Clamp.Add && ac.Clamp.Type == ClampGe && nrn.HasFlag(NeurHasExt) {
+		nrn.GeSyn = ac.Clamp.ClampGe(nrn.Ext, cyc, nrn.ClampMult)
 		geExt = 0 // no extra in this case
+	} else if ac.Clamp.Type == ClampSoft && nrn.HasFlag(NeurHasExt) {
+		ac.Dt.GeSynFmRaw(geRaw, &nrn.GeSyn, ac.Init.Ge)
+		cge := ac.Clamp.ClampGe(nrn.Ext, cyc, nrn.ClampMult)
+		nrn.GeSyn += ac.Clamp.Soft * (cge - nrn.GeSyn)
 	} else {
 		ac.Dt.GeSynFmRaw(geRaw, &nrn.GeSyn, ac.Init.Ge)
 	}
 
-	nrn.Ge = nrn.GeSyn + geExt
+	nrn.Ge = nrn.GeSyn + geExt + nrn.GeBias
+
+	// dendritic compartment input: separate from Ge if TwoComp
+	ac.Dt.GeSynFmRaw(nrn.GeDendRaw, &nrn.GeDend, 0)
+	nrn.GeDendRaw = 0
+	if !ac.Dend.TwoComp {
+		nrn.Ge += nrn.GeDend
+	}
+	ac.Dt.GeSynFmRaw(nrn.ModRaw, &nrn.Mod, 0)
+	nrn.ModRaw = 0
 
 	if ac.Noise.On && ac.Noise.Ge > 0 {
 		ge := ac.Noise.PGe(&nrn.GeNoiseP)
 		ac.Dt.GeSynFmRaw(ge, &nrn.GeNoise, 0)
 		nrn.Ge += nrn.GeNoise
 	}
+	ac.ActNoise.Ge(&nrn.Ge)
+}
+
+// NMDAFmRaw updates the NMDA activation and Gnmda conductance from geRaw
+// excitatory input, plus the dendritic GeDendRaw, the NMDA-only NMDARaw
+// (which is reset), and NMDASyn inputs.  Must be called prior to GeFmRaw.
+func (ac *ActParams) NMDAFmRaw(nrn *Neuron, geRaw float32) {
+	nrn.NMDA = ac.NMDA.NMDA(nrn.NMDA, geRaw+nrn.GeDendRaw, nrn.NMDASyn+nrn.NMDARaw)
+	nrn.NMDARaw = 0
+	nrn.Gnmda = ac.NMDA.Gnmda(nrn.NMDA, nrn.VmDend)
 }
 
 // GiFmRaw integrates GiSyn inhibitory synaptic conductance from GiRaw value
-// (can add other terms to geRaw prior to calling this)
+// (can add other terms to geRaw prior to calling this).
+// Also updates GiPrjn and ErevPrjn from their raw accumulators, which are reset.
 func (ac *ActParams) GiFmRaw(nrn *Neuron, giRaw float32) {
 	ac.Dt.GiSynFmRaw(giRaw, &nrn.GiSyn, ac.Init.Gi)
 	if ac.Noise.On && ac.Noise.Gi > 0 {
@@ -227,11 +279,20 @@ func (ac *ActParams) GiFmRaw(nrn *Neuron, giRaw float32) {
 	if nrn.GiSyn < 0 { // negative inhib G doesn't make any sense
 		nrn.GiSyn = 0
 	}
+	nrn.GiPrjn = nrn.GiPrjnRaw
+	if nrn.GiPrjnRaw > 0 {
+		nrn.ErevPrjn = nrn.ErevPrjnRaw / nrn.GiPrjnRaw
+	} else {
+		nrn.ErevPrjn = ac.Erev.I
+	}
+	nrn.GiPrjnRaw = 0
+	nrn.ErevPrjnRaw = 0
 }
 
-// InetFmG computes net current from conductances and Vm
-func (ac *ActParams) InetFmG(vm, ge, gl, gi, gk float32) float32 {
-	inet := ge*(ac.Erev.E-vm) + gl*ac.Gbar.L*(ac.Erev.L-vm) + gi*(ac.Erev.I-vm) + gk*(ac.Erev.K-vm)
+// InetFmG computes net current from conductances and Vm.
+// gp is the per-projection inhibitory conductance (GiPrjn) with reversal potential ep.
+func (ac *ActParams) InetFmG(vm, ge, gl, gi, gk, gp, ep float32) float32 {
+	inet := ge*(ac.Erev.E-vm) + gl*ac.Gbar.L*(ac.Erev.L-vm) + gi*(ac.Erev.I-vm) + gk*(ac.Erev.K-vm) + gp*(ep-vm)
 	if inet > ac.Dt.VmTau {
 		inet = ac.Dt.VmTau
 	} else if inet < -ac.Dt.VmTau {
@@ -247,12 +308,12 @@ func (ac *ActParams) VmFmInet(vm, dt, inet float32) float32 {
 
 // VmInteg integrates Vm over VmSteps to obtain a more stable value
 // Returns the new Vm and inet values.
-func (ac *ActParams) VmInteg(vm, dt, ge, gl, gi, gk float32) (float32, float32) {
+func (ac *ActParams) VmInteg(vm, dt, ge, gl, gi, gk, gp, ep float32) (float32, float32) {
 	dt *= ac.Dt.DtStep
 	nvm := vm
 	var inet float32
 	for i := 0; i < ac.Dt.VmSteps; i++ {
-		inet = ac.InetFmG(nvm, ge, gl, gi, gk)
+		inet = ac.InetFmG(nvm, ge, gl, gi, gk, gp, ep)
 		nvm = ac.VmFmInet(nvm, dt, inet)
 	}
 	return nvm, inet
@@ -270,19 +331,26 @@ func (ac *ActParams) VmFmG(nrn *Neuron) {
 	ge := nrn.Ge * ac.Gbar.E
 	gi := nrn.Gi * ac.Gbar.I
 	gk := nrn.Gk * ac.Gbar.K
+	gp := nrn.GiPrjn * ac.Gbar.I
 	var expi float32
 	if updtVm {
-		nvm, inet := ac.VmInteg(nrn.Vm, ac.Dt.VmDt, ge, 1, gi, gk)
+		nvm, inet := ac.VmInteg(nrn.Vm, ac.Dt.VmDt, ge, 1+nrn.HetGl, gi, gk, gp, nrn.ErevPrjn)
 		if updtVm && ac.Spike.Exp { // add spike current if relevant
 			exVm := 0.5 * (nvm + nrn.Vm) // midpoint for this
 			expi = ac.Gbar.L * ac.Spike.ExpSlope *
-				mat32.FastExp((exVm-ac.Spike.Thr)/ac.Spike.ExpSlope)
+				mat32.FastExp((exVm-(ac.Spike.Thr+nrn.HetThr))/ac.Spike.ExpSlope)
 			if expi > ac.Dt.VmTau {
 				expi = ac.Dt.VmTau
 			}
 			inet += expi
 			nvm = ac.VmFmInet(nvm, ac.Dt.VmDt, expi)
 		}
+		ac.ActNoise.Vm(&nvm)
+		if ac.Dend.TwoComp { // coupling current from dendrite
+			ic := ac.Dend.GcSoma * (nrn.VmDend - nrn.Vm)
+			inet += ic
+			nvm = ac.VmFmInet(nvm, ac.Dt.VmDt, ic)
+		}
 		nrn.Vm = nvm
 		nrn.Inet = inet
 	} else { // decay back to VmR
@@ -297,11 +365,15 @@ func (ac *ActParams) VmFmG(nrn *Neuron) {
 	}
 
 	{ // always update VmDend
-		glEff := float32(1)
+		glEff := 1 + nrn.HetGl
 		if !updtVm {
 			glEff += ac.Dend.GbarR
 		}
-		nvm, _ := ac.VmInteg(nrn.VmDend, ac.Dt.VmDendDt, ge, glEff, gi, gk)
+		dge := ge
+		if ac.Dend.TwoComp {
+			dge += nrn.GeDend * ac.Gbar.E
+		}
+		nvm, _ := ac.VmInteg(nrn.VmDend, ac.Dt.VmDendDt, dge, glEff, gi, gk, gp, nrn.ErevPrjn)
 		if updtVm {
 			nvm = ac.VmFmInet(nvm, ac.Dt.VmDendDt, ac.Dend.GbarExp*expi)
 		}
@@ -317,7 +389,26 @@ func (ac *ActParams) ActFmG(nrn *Neuron) {
 	} else {
 		thr = ac.Spike.Thr
 	}
-	if nrn.Vm >= thr {
+	thr += nrn.HetThr
+	ac.ActFmSpike(nrn, nrn.Vm >= thr || ac.ActNoise.Spike())
+}
+
+// ClampActFmG computes the spiking and rate-code activation for a neuron
+// with external input under the ClampHard or ClampPoisson Clamp types,
+// with given cycle within the state (or plus phase for Target layers).
+// For ClampHard, Act is set directly to Ext.
+func (ac *ActParams) ClampActFmG(nrn *Neuron, cyc int, rnd Rand) {
+	ac.ActFmSpike(nrn, ac.Clamp.Spike(nrn.Ext, cyc, nrn.ClampMult, rnd))
+	if ac.Clamp.Type == ClampHard {
+		nrn.ActDel = nrn.Ext - nrn.Act
+		nrn.Act = nrn.Ext
+	}
+}
+
+// ActFmSpike updates the spiking state and rate-code activation of the neuron
+// given whether it spikes on this cycle
+func (ac *ActParams) ActFmSpike(nrn *Neuron, spike bool) {
+	if spike {
 		nrn.Spike = 1
 		if nrn.ISIAvg == -1 {
 			nrn.ISIAvg = -2
@@ -344,7 +435,7 @@ func (ac *ActParams) ActFmG(nrn *Neuron) {
 	nrn.Act = nwAct
 	if ac.KNa.On {
 		ac.KNa.GcFmSpike(&nrn.GknaFast, &nrn.GknaMed, &nrn.GknaSlow, nrn.Spike > .5)
-		nrn.Gk = nrn.GknaFast + nrn.GknaMed + nrn.GknaSlow
+		nrn.Gk = (1 + nrn.HetKNa) * (nrn.GknaFast + nrn.GknaMed + nrn.GknaSlow)
 	}
 }
 
@@ -425,11 +516,16 @@ func (sk *SpikeParams) AvgFmISI(avg *float32, isi float32) {
 type DendParams struct {
 	GbarExp float32 `def:"0.2" desc:"dendrite-specific strength multiplier of the exponential spiking drive on Vm -- e.g., .5 makes it half as strong as at the soma (which uses Gbar.L as a strength multiplier per the AdEx standard model)"`
 	GbarR   float32 `def:"3" desc:"dendrite-specific conductance of Kdr delayed rectifier currents, used to reset membrane potential for dendrite -- applied for Tr msec"`
+
+	TwoComp bool    `desc:"use a separate apical dendrite compartment: projections with Com.Target = GDend drive only the VmDend dendritic membrane potential through their own GeDend conductance, and reach the soma only via the GcSoma coupling current -- the NMDA channels gated by VmDend provide the dendritic nonlinearity.  If false, GeDend is just added to the somatic Ge."`
+	GcSoma  float32 `viewif:"TwoComp" def:"0.2" min:"0" desc:"coupling conductance from dendrite to soma, driving a current GcSoma * (VmDend - Vm) into the soma"`
 }
 
 func (dp *DendParams) Defaults() {
 	dp.GbarExp = 0.2
 	dp.GbarR = 3
+	dp.TwoComp = false
+	dp.GcSoma = 0.2
 }
 
 func (dp *DendParams) Update() {
@@ -589,6 +685,8 @@ type SpikeNoiseParams struct {
 
 	GeExpInt float32 `view:"-" json:"-" xml:"-" desc:"Exp(-Interval) which is the threshold for GeNoiseP as it is updated"`
 	GiExpInt float32 `view:"-" json:"-" xml:"-" desc:"Exp(-Interval) which is the threshold for GiNoiseP as it is updated"`
+
+	rnd Rand // random number source, set by Layer.SetRand -- nil = global
 }
 
 func (an *SpikeNoiseParams) Update() {
@@ -607,7 +705,7 @@ func (an *SpikeNoiseParams) Defaults() {
 // PGe updates the GeNoiseP probability, multiplying a uniform random number [0-1]
 // and returns Ge from spiking if a spike is triggered
 func (an *SpikeNoiseParams) PGe(p *float32) float32 {
-	*p *= rand.Float32()
+	*p *= RandOrGlobal(an.rnd).Float32()
 	if *p <= an.GeExpInt {
 		*p = 1
 		return an.Ge
@@ -618,7 +716,7 @@ func (an *SpikeNoiseParams) PGe(p *float32) float32 {
 // PGi updates the GiNoiseP probability, multiplying a uniform random number [0-1]
 // and returns Gi from spiking if a spike is triggered
 func (an *SpikeNoiseParams) PGi(p *float32) float32 {
-	*p *= rand.Float32()
+	*p *= RandOrGlobal(an.rnd).Float32()
 	if *p <= an.GiExpInt {
 		*p = 1
 		return an.Gi
@@ -629,13 +727,57 @@ func (an *SpikeNoiseParams) PGi(p *float32) float32 {
 //////////////////////////////////////////////////////////////////////////////////////
 //  ClampParams
 
-// ClampParams specify how external inputs drive excitatory conductances
-// (like a current clamp) -- either adds or overwrites existing conductances.
-// Noise is added in either case.
+// ClampTypes are the ways in which external inputs (Ext) drive the
+// neurons of a layer -- see ClampParams
+type ClampTypes int32
+
+//go:generate stringer -type=ClampTypes
+
+var KiT_ClampTypes = kit.Enums.AddEnum(ClampTypesN, kit.NotBitFlag, nil)
+
+func (ev ClampTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *ClampTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The clamping types
+const (
+	// ClampGe drives the excitatory conductance Ge in proportion to Ext
+	// (like a current clamp), either overwriting or adding to the synaptic
+	// input according to Add -- the neurons then spike according to
+	// their normal dynamics
+	ClampGe ClampTypes = iota
+
+	// ClampSoft drives Ge as a blend of the clamped Ge and the synaptic
+	// input, with the proportion of clamped Ge given by Soft
+	ClampSoft
+
+	// ClampHard directly sets the activation Act to Ext, and generates
+	// regularly-spaced spikes at a rate of Ext * MaxHz, bypassing the
+	// membrane potential dynamics
+	ClampHard
+
+	// ClampPoisson generates Poisson spike trains with a rate of Ext * MaxHz,
+	// bypassing the membrane potential dynamics, with Act computed from the
+	// spikes as usual
+	ClampPoisson
+
+	ClampTypesN
+)
+
+// ClampParams specify how external inputs drive the neurons: by default
+// they drive excitatory conductances (like a current clamp) -- either
+// adding or overwriting existing conductances, and noise is added in
+// either case -- and other options are set by Type.  The same clamping
+// applies to Target layers in the plus phase, when Targ is copied to Ext.
 type ClampParams struct {
-	Ge     float32 `def:"0.6,1" desc:"amount of Ge driven for clamping -- generally use 0.6 for Target layers, 1.0 for Input layers"`
-	Add    bool    `def:"false" view:"add external conductance on top of any existing -- generally this is not a good idea for target layers (creates a main effect that learning can never match), but may be ok for input layers"`
-	ErrThr float32 `def:"0.5" desc:"threshold on neuron Act activity to count as active for computing error relative to target in PctErr method"`
+	Ge      float32 `def:"0.6,1" desc:"amount of Ge driven for clamping -- generally use 0.6 for Target layers, 1.0 for Input layers"`
+	Add     bool    `def:"false" view:"add external conductance on top of any existing -- generally this is not a good idea for target layers (creates a main effect that learning can never match), but may be ok for input layers"`
+	ErrThr  float32 `def:"0.5" desc:"threshold on neuron Act activity to count as active for computing error relative to target in PctErr method"`
+	RampCyc int     `def:"0" min:"0" desc:"number of cycles over which clamped Ge ramps up linearly from 0 to full strength, at the start of each new state (or start of plus phase for Target layers) -- 0 = no ramp, full strength from the first cycle.  Ramping better approximates the onset dynamics of sensory inputs, and prevents artifactual synchrony of all clamped units spiking on the same first cycles."`
+	Var     float32 `def:"0" min:"0" desc:"standard deviation of gaussian noise on the clamped Ge amplitude, as a proportion of the amplitude, which is sampled once per neuron at the start of each new state (trial) and held constant over that state -- stored in Neuron.ClampMult -- 0 = no noise"`
+
+	Type  ClampTypes `desc:"how external inputs drive the neurons: Ge conductance (default), Soft blend with synaptic Ge, Hard activation clamp, or Poisson spike trains"`
+	Soft  float32    `viewif:"Type=ClampSoft" def:"0.5" min:"0" max:"1" desc:"for ClampSoft: proportion of the clamped Ge in the blend with the synaptic Ge"`
+	MaxHz float32    `def:"100" min:"0" desc:"for ClampHard and ClampPoisson: spiking rate for an Ext value of 1"`
 }
 
 func (cp *ClampParams) Update() {
@@ -644,6 +786,62 @@ func (cp *ClampParams) Update() {
 func (cp *ClampParams) Defaults() {
 	cp.Ge = 0.6
 	cp.ErrThr = 0.5
+	cp.RampCyc = 0
+	cp.Var = 0
+	cp.Type = ClampGe
+	cp.Soft = 0.5
+	cp.MaxHz = 100
+}
+
+// IsSpike returns true if the clamping directly generates the spikes
+// (ClampHard, ClampPoisson), bypassing the membrane potential dynamics
+func (cp *ClampParams) IsSpike() bool {
+	return cp.Type == ClampHard || cp.Type == ClampPoisson
+}
+
+// SpikeRate returns the per-cycle (1 msec) probability of spiking for given
+// external input value, for the ClampHard and ClampPoisson types
+func (cp *ClampParams) SpikeRate(ext float32, mult float32) float32 {
+	return mat32.Clamp(ext*mult*cp.MaxHz*.001, 0, 1)
+}
+
+// Spike returns true if the neuron should spike on this cycle for given
+// external input value and cycle within the state (or plus phase for Target
+// layers), for the ClampHard (regular spikes) and ClampPoisson types.
+func (cp *ClampParams) Spike(ext float32, cyc int, mult float32, rnd Rand) bool {
+	p := cp.SpikeRate(ext, mult)
+	if p <= 0 {
+		return false
+	}
+	if cp.Type == ClampPoisson {
+		return RandOrGlobal(rnd).Float32() < p
+	}
+	// regular spikes: whenever the integrated count crosses a whole number
+	return int(float32(cyc+1)*p) > int(float32(cyc)*p)
+}
+
+// ClampGe returns the excitatory conductance for given external input value,
+// at given cycle within the state or phase (for ramping), and with given
+// trial-wise amplitude multiplier (from ClampMult).
+func (cp *ClampParams) ClampGe(ext float32, cyc int, mult float32) float32 {
+	ge := ext * cp.Ge * mult
+	if cp.RampCyc > 0 && cyc < cp.RampCyc {
+		ge *= float32(cyc+1) / float32(cp.RampCyc)
+	}
+	return ge
+}
+
+// ClampMult returns a new trial-wise multiplier for the clamped Ge amplitude,
+// based on the Var noise parameter -- returns 1 if Var == 0.
+func (cp *ClampParams) ClampMult(rnd Rand) float32 {
+	if cp.Var == 0 {
+		return 1
+	}
+	mult := 1 + cp.Var*float32(rnd.NormFloat64())
+	if mult < 0 {
+		mult = 0
+	}
+	return mult
 }
 
 //////////////////////////////////////////////////////////////////////////////////////
@@ -674,20 +872,111 @@ func (at *AttnParams) ModVal(val float32, attn float32) float32 {
 //////////////////////////////////////////////////////////////////////////////////////
 //  SynComParams
 
+// GTargets are the postsynaptic targets of the conductance sent by a projection
+type GTargets int32
+
+//go:generate stringer -type=GTargets
+
+var KiT_GTargets = kit.Enums.AddEnum(GTargetsN, kit.NotBitFlag, nil)
+
+func (ev GTargets) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *GTargets) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The conductance targets
+const (
+	// GDefault sends excitatory conductance into GeRaw, or inhibitory
+	// into GiRaw for Inhib projections, as determined by Prjn.Typ
+	GDefault GTargets = iota
+
+	// GDend sends excitatory conductance into the dendritic compartment
+	// GeDendRaw -- see Act.Dend.TwoComp
+	GDend
+
+	// GExc sends excitatory AMPA conductance into GeRaw, regardless of Prjn.Typ
+	GExc
+
+	// GInh sends inhibitory GABA-A conductance into GiRaw (or GiPrjnRaw if
+	// GABA.On), regardless of Prjn.Typ
+	GInh
+
+	// GNMDA sends conductance only into the NMDA channels, via NMDARaw,
+	// e.g., for active maintenance projections
+	GNMDA
+
+	// GMod sends a modulatory signal into ModRaw, integrated into Mod,
+	// which does not directly affect the membrane potential but is
+	// available for specialized layers (e.g., as a dopamine-like signal)
+	GMod
+
+	GTargetsN
+)
+
 /// SynComParams are synaptic communication parameters: delay and probability of failure
 type SynComParams struct {
-	Delay    int     `min:"0" def:"2" desc:"additional synaptic delay for inputs arriving at this projection -- IMPORTANT: if you change this, you must call InitWts() on Network!  Delay = 0 means a spike reaches receivers in the next Cycle, which is the minimum time.  Biologically, subtract 1 from synaptic delay values to set corresponding Delay value."`
-	PFail    float32 `desc:"probability of synaptic transmission failure -- if > 0, then weights are turned off at random as a function of PFail (times 1-SWt if PFailSwt)"`
-	PFailSWt bool    `desc:"if true, then probability of failure is inversely proportional to SWt structural / slow weight value (i.e., multiply PFail * (1-SWt)))"`
+	Delay    int       `min:"0" def:"2" desc:"additional synaptic delay for inputs arriving at this projection -- IMPORTANT: if you change this, you must call InitWts() on Network!  Delay = 0 means a spike reaches receivers in the next Cycle, which is the minimum time.  Biologically, subtract 1 from synaptic delay values to set corresponding Delay value."`
+	PFail    float32   `desc:"probability of synaptic transmission failure -- if > 0, then weights are turned off at random as a function of PFail (times 1-SWt if PFailSwt) -- ignored if Rel.On"`
+	PFailSWt bool      `desc:"if true, then probability of failure is inversely proportional to SWt structural / slow weight value (i.e., multiply PFail * (1-SWt)))"`
+	STP      STPParams `view:"inline" desc:"short-term plasticity: depression and facilitation of synaptic efficacy as a function of recent presynaptic spiking"`
+	Target   GTargets  `desc:"postsynaptic target of the conductance sent by this projection -- GDefault is determined by Prjn.Typ"`
+
+	Rel ReleaseParams `view:"inline" desc:"stochastic, activity-dependent neurotransmitter release with a per-synapse release probability Prjn.SynPr, replacing the fixed PFail failure model when On"`
+
+	Event SpikeEventParams `view:"inline" desc:"event-driven spike delivery, which queues spike events instead of using a dense conductance buffer per receiver, for sparse activity regimes"`
+
+	DelayVar bool    `desc:"draw a separate Delay for each synapse, uniformly between DelayMin and DelayMax, or Gaussian around Delay with DelaySD clipped to that range -- drawn at InitWts, with the conductance buffer sized to DelayMax"`
+	DelayMin int     `viewif:"DelayVar" min:"0" def:"1" desc:"minimum per-synapse delay"`
+	DelayMax int     `viewif:"DelayVar" min:"0" def:"4" desc:"maximum per-synapse delay"`
+	DelaySD  float32 `viewif:"DelayVar" min:"0" def:"0" desc:"if > 0, per-synapse delays are Gaussian with this standard deviation around Delay, clipped to DelayMin..DelayMax -- otherwise uniform"`
+
+	Gather bool `desc:"integrate spikes on the receiving side: SendSpike only records which senders spiked, and RecvGInc sums the weights over each receiver's contiguous connections, which vectorizes better than scattering into the conductance buffer for dense activity -- only used for projections with a fixed fan-in (RFanIn > 0), and ignored if STP, Rel, DelayVar or Event delivery is in use"`
 }
 
 func (sc *SynComParams) Defaults() {
 	sc.Delay = 2
 	sc.PFail = 0 // 0.5 works?
 	sc.PFailSWt = false
+	sc.STP.Defaults()
+	sc.Target = GDefault
+	sc.Rel.Defaults()
+	sc.Event.Defaults()
+	sc.DelayVar = false
+	sc.DelayMin = 1
+	sc.DelayMax = 4
+	sc.DelaySD = 0
+	sc.Gather = false
 }
 
 func (sc *SynComParams) Update() {
+	sc.STP.Update()
+	sc.Rel.Update()
+	sc.Event.Update()
+}
+
+// MaxDelay returns the maximum delay across synapses, which determines
+// the size of the conductance buffer
+func (sc *SynComParams) MaxDelay() int {
+	if sc.DelayVar && sc.DelayMax > sc.Delay {
+		return sc.DelayMax
+	}
+	return sc.Delay
+}
+
+// RndDelay returns a random per-synapse delay according to the DelayVar params
+func (sc *SynComParams) RndDelay(rnd Rand) int {
+	mx := sc.MaxDelay()
+	var del int
+	if sc.DelaySD > 0 {
+		del = int(mat32.Round(float32(sc.Delay) + sc.DelaySD*float32(rnd.NormFloat64())))
+	} else {
+		del = sc.DelayMin + rnd.Intn(mx-sc.DelayMin+1)
+	}
+	if del < sc.DelayMin {
+		del = sc.DelayMin
+	}
+	if del > mx {
+		del = mx
+	}
+	return del
 }
 
 // WtFailP returns probability of weight (synapse) failure given current SWt value
@@ -699,23 +988,158 @@ func (sc *SynComParams) WtFailP(swt float32) float32 {
 }
 
 // WtFail returns true if synapse should fail, as function of SWt value (optionally)
-func (sc *SynComParams) WtFail(swt float32) bool {
+func (sc *SynComParams) WtFail(swt float32, rnd Rand) bool {
 	fp := sc.WtFailP(swt)
 	if fp == 0 {
 		return false
 	}
-	return erand.BoolP(fp)
+	return rnd.Float32() < fp
 }
 
 // Fail updates failure status of given weight, given SWt value
-func (sc *SynComParams) Fail(wt *float32, swt float32) {
-	if sc.PFail > 0 {
-		if sc.WtFail(swt) {
+func (sc *SynComParams) Fail(wt *float32, swt float32, rnd Rand) {
+	if sc.PFail > 0 && !sc.Rel.On {
+		if sc.WtFail(swt, rnd) {
 			*wt = 0
 		}
 	}
 }
 
+// GiThr returns the inhibitory conductance that would put a neuron with
+// given excitatory conductance exactly at the spiking threshold -- used for kWTA.
+func (ac *ActParams) GiThr(ge float32) float32 {
+	thr := ac.Spike.Thr
+	return (ge*ac.Gbar.E*(ac.Erev.E-thr) + ac.Gbar.L*(ac.Erev.L-thr)) / (ac.Gbar.I * (thr - ac.Erev.I))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  STPParams
+
+// STPParams are Tsodyks-Markram style short-term plasticity parameters,
+// where each presynaptic spike uses up a fraction Fac of the available
+// resources Rec, which recover with time constant TauRec (depression),
+// while Fac increases with each spike by U * (1 - Fac) and decays back
+// toward 0 with time constant TauFac (facilitation).  The synaptic efficacy
+// of each spike is Fac * Rec / U, so the first spike after a long pause
+// has the standard weight, and subsequent ones are depressed and / or facilitated.
+// Depression dominates for high U and long TauRec, facilitation for low U and long TauFac.
+type STPParams struct {
+	On     bool    `desc:"use short-term plasticity -- if false, all spikes have the same efficacy"`
+	U      float32 `viewif:"On" def:"0.5" min:"0" max:"1" desc:"baseline utilization: proportion of available resources used by a spike after a long pause -- higher = more depression"`
+	TauRec float32 `viewif:"On" def:"200" min:"1" desc:"time constant in cycles (msec) for recovery of resources after use (depression)"`
+	TauFac float32 `viewif:"On" def:"0" min:"0" desc:"time constant in cycles (msec) for decay of facilitation -- 0 = no facilitation, so utilization is always U"`
+}
+
+func (sp *STPParams) Defaults() {
+	sp.On = false
+	sp.U = 0.5
+	sp.TauRec = 200
+	sp.TauFac = 0
+}
+
+func (sp *STPParams) Update() {
+}
+
+// Init initializes the synaptic STP state to fully recovered, unfacilitated
+func (sp *STPParams) Init(rec, fac *float32) {
+	*rec = 1
+	*fac = 0
+}
+
+// Spike updates the STP state for a presynaptic spike, given number of cycles
+// since the previous spike (isi), and returns the efficacy multiplier for the spike
+func (sp *STPParams) Spike(rec, fac *float32, isi int32) float32 {
+	if isi > 0 {
+		*rec = 1 - (1-*rec)*mat32.FastExp(-float32(isi)/sp.TauRec)
+		if sp.TauFac > 0 {
+			*fac *= mat32.FastExp(-float32(isi) / sp.TauFac)
+		}
+	}
+	u := sp.U
+	if sp.TauFac > 0 {
+		*fac += sp.U * (1 - *fac)
+		u = *fac
+	}
+	rel := u * *rec
+	*rec -= rel
+	return rel / sp.U
+}
+
+// Recover updates the STP state for a presynaptic spike that did not
+// release any transmitter (see ReleaseParams), given number of cycles since
+// the previous spike (isi): resources recover but are not used, while
+// facilitation still increments.
+func (sp *STPParams) Recover(rec, fac *float32, isi int32) {
+	if isi > 0 {
+		*rec = 1 - (1-*rec)*mat32.FastExp(-float32(isi)/sp.TauRec)
+		if sp.TauFac > 0 {
+			*fac *= mat32.FastExp(-float32(isi) / sp.TauFac)
+		}
+	}
+	if sp.TauFac > 0 {
+		*fac += sp.U * (1 - *fac)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  ReleaseParams
+
+// ReleaseParams are parameters for stochastic neurotransmitter release,
+// where each presynaptic spike is transmitted with a probability given by
+// the per-synapse Prjn.SynPr value, modulated by the recent firing rate of
+// the sending neuron (its rate-code Act value).  Pr can also adapt in
+// WtFmDWt in the direction of the weight changes, as in presynaptic forms
+// of long-term plasticity.  This replaces the uniform PFail failure model.
+type ReleaseParams struct {
+	On       bool    `desc:"use stochastic per-spike release with per-synapse release probability Prjn.SynPr"`
+	Init     float32 `viewif:"On" def:"0.5" min:"0" max:"1" desc:"initial release probability for all synapses, set at InitWts"`
+	Min      float32 `viewif:"On" def:"0.05" min:"0" max:"1" desc:"minimum release probability, for both the effective probability and the adapted Pr value"`
+	Max      float32 `viewif:"On" def:"1" min:"0" max:"1" desc:"maximum release probability, for both the effective probability and the adapted Pr value"`
+	RateGain float32 `viewif:"On" def:"0" desc:"gain on the sending neuron rate-code activation Act relative to RateMid, added to Pr to get the effective release probability -- positive = facilitation at higher rates, negative = depression"`
+	RateMid  float32 `viewif:"On" def:"0.1" min:"0" desc:"sending activation level at which the effective release probability is equal to Pr"`
+	Lrate    float32 `viewif:"On" def:"0" min:"0" desc:"learning rate for adapting Pr in WtFmDWt, in the same direction as the weight change DWt, with soft bounding between Min and Max -- 0 = no adaptation"`
+}
+
+func (rp *ReleaseParams) Defaults() {
+	rp.On = false
+	rp.Init = 0.5
+	rp.Min = 0.05
+	rp.Max = 1
+	rp.RateGain = 0
+	rp.RateMid = 0.1
+	rp.Lrate = 0
+}
+
+func (rp *ReleaseParams) Update() {
+}
+
+// P returns the effective release probability for given synapse Pr
+// and sending neuron activation
+func (rp *ReleaseParams) P(pr, sact float32) float32 {
+	p := pr + rp.RateGain*(sact-rp.RateMid)
+	return mat32.Clamp(p, rp.Min, rp.Max)
+}
+
+// Release returns true if a spike is released (transmitted), for given
+// synapse Pr and sending neuron activation
+func (rp *ReleaseParams) Release(pr, sact float32, rnd Rand) bool {
+	return rnd.Float32() < rp.P(pr, sact)
+}
+
+// Adapt updates the release probability pr from the weight change dwt,
+// with soft bounding -- does nothing if Lrate = 0
+func (rp *ReleaseParams) Adapt(pr *float32, dwt float32) {
+	if rp.Lrate == 0 || dwt == 0 {
+		return
+	}
+	if dwt > 0 {
+		*pr += rp.Lrate * dwt * (rp.Max - *pr)
+	} else {
+		*pr += rp.Lrate * dwt * (*pr - rp.Min)
+	}
+	*pr = mat32.Clamp(*pr, rp.Min, rp.Max)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  PrjnScaleParams
 
@@ -730,6 +1154,9 @@ type PrjnScaleParams struct {
 	ScaleLrate float32 `viewif:"Adapt" def:"0.5" desc:"learning rate for adapting the GScale value, as function of target value -- lrate is also multiplied by the GScale.Orig to compensate for significant differences in overall scale of these scaling factors -- fastest value with some smoothing at .5 works well."`
 	HiTol      float32 `def:"0" viewif:"Adapt" desc:"tolerance for higher than target AvgMaxGeM / GiM as a proportion of that target value (0 = exactly the target, 0.2 = 20% higher than target) -- only once activations move outside this tolerance are scale values adapted"`
 	LoTol      float32 `def:"0.8" viewif:"Adapt" desc:"tolerance for lower than target AvgMaxGeM / GiM as a proportion of that target value (0 = exactly the target, 0.8 = 80% lower than target) -- only once activations move outside this tolerance are scale values adapted"`
+	MinRatio   float32 `def:"0.1" min:"0" viewif:"Adapt" desc:"minimum adapted Scale as a proportion of GScale.Orig"`
+	MaxRatio   float32 `def:"0" min:"0" viewif:"Adapt" desc:"maximum adapted Scale as a proportion of GScale.Orig -- limits runaway adaptation when the target cannot be reached through this projection -- 0 = no limit"`
+	Stop       int     `def:"0" min:"0" viewif:"Adapt" desc:"epoch (as counted by Network.EpochInc) at and after which Scale adaptation is frozen, e.g., once it has stabilized early in training -- 0 = never"`
 	AvgTau     float32 `def:"500" desc:"time constant for integrating projection-level averages for this scaling process: Prjn.GScale.AvgAvg, AvgMax (tau is roughly how long it takes for value to change significantly) -- these are updated at the cycle level and thus require a much slower rate constant compared to other such variables integrated at the AlphaCycle level."`
 
 	AvgDt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / tau"`
@@ -742,6 +1169,9 @@ func (ws *PrjnScaleParams) Defaults() {
 	ws.ScaleLrate = 0.5
 	ws.HiTol = 0
 	ws.LoTol = 0.8
+	ws.MinRatio = 0.1
+	ws.MaxRatio = 0
+	ws.Stop = 0
 	ws.AvgTau = 500
 	ws.Update()
 }
@@ -750,6 +1180,25 @@ func (ws *PrjnScaleParams) Update() {
 	ws.AvgDt = 1 / ws.AvgTau
 }
 
+// AdaptOn returns true if Scale adaptation is on for given epoch
+func (ws *PrjnScaleParams) AdaptOn(epoch int) bool {
+	return ws.Adapt && (ws.Stop == 0 || epoch < ws.Stop)
+}
+
+// ClipScale returns the adapted scale clipped to the MinRatio, MaxRatio
+// range relative to given original scale
+func (ws *PrjnScaleParams) ClipScale(scale, orig float32) float32 {
+	if min := ws.MinRatio * orig; scale < min {
+		return min
+	}
+	if ws.MaxRatio > 0 {
+		if max := ws.MaxRatio * orig; scale > max {
+			return max
+		}
+	}
+	return scale
+}
+
 // SLayActScale computes scaling factor based on sending layer activity level (savg), number of units
 // in sending layer (snu), and number of recv connections (ncon).
 // Uses a fixed sem_extra standard-error-of-the-mean (SEM) extra value of 2
@@ -781,3 +1230,52 @@ func (ws *PrjnScaleParams) SLayActScale(savg, snu, ncon float32) float32 {
 func (ws *PrjnScaleParams) FullScale(savg, snu, ncon float32) float32 {
 	return ws.Abs * ws.Rel * ws.SLayActScale(savg, snu, ncon)
 }
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  GABAPrjnParams
+
+// GABAPrjnParams are per-projection inhibitory conductance parameters for
+// Inhib projections: if On, the projection's conductance is split into
+// fast GABA-A and slow GABA-B components, each with its own kinetics and
+// reversal potential, instead of being pooled into the neuron's GiRaw.
+// This allows slow inhibition from specific pathways (e.g., SST-like
+// interneurons) to be modeled per projection.  The resulting conductance
+// goes into the neuron's GiPrjn, applied with its own effective reversal ErevPrjn.
+type GABAPrjnParams struct {
+	On     bool    `desc:"use per-projection GABA-A / GABA-B conductances for this Inhib projection -- otherwise conductance is added to the receiver's GiRaw as usual"`
+	A      float32 `viewif:"On" def:"1" min:"0" desc:"proportion of projection conductance driving fast GABA-A channels"`
+	B      float32 `viewif:"On" def:"0" min:"0" desc:"proportion of projection conductance driving slow GABA-B channels"`
+	ErevA  float32 `viewif:"On" def:"0.1" desc:"reversal potential for the GABA-A component (normalized units: Erev.I = .1)"`
+	ErevB  float32 `viewif:"On" def:"0.1" desc:"reversal potential for the GABA-B component (normalized units: Erev.K = .1)"`
+	ATau   float32 `viewif:"On" def:"7" min:"1" desc:"decay time constant for the GABA-A component, in cycles (msec)"`
+	BRise  float32 `viewif:"On" def:"45" min:"1" desc:"rise time constant for bi-exponential GABA-B component, in cycles (msec)"`
+	BDecay float32 `viewif:"On" def:"50" min:"1" desc:"decay time constant for bi-exponential GABA-B component, in cycles (msec) -- must be different from BRise"`
+
+	ADt     float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / ATau"`
+	TauFact float32 `view:"-" json:"-" xml:"-" desc:"GABA-B time constant factor used in integration: (Decay / Rise) ^ (Rise / (Decay - Rise))"`
+}
+
+func (gp *GABAPrjnParams) Defaults() {
+	gp.On = false
+	gp.A = 1
+	gp.B = 0
+	gp.ErevA = 0.1
+	gp.ErevB = 0.1
+	gp.ATau = 7
+	gp.BRise = 45
+	gp.BDecay = 50
+	gp.Update()
+}
+
+func (gp *GABAPrjnParams) Update() {
+	gp.ADt = 1 / gp.ATau
+	gp.TauFact = mat32.Pow(gp.BDecay/gp.BRise, gp.BRise/(gp.BDecay-gp.BRise))
+}
+
+// GFmRaw integrates the GABA-A (ga) and bi-exponential GABA-B (gb, gbx)
+// conductances from raw projection conductance g
+func (gp *GABAPrjnParams) GFmRaw(g float32, ga, gb, gbx *float32) {
+	*ga += gp.A*g - gp.ADt*(*ga)
+	*gb += (gp.TauFact*(*gbx) - *gb) / gp.BRise
+	*gbx += gp.B*g - (*gbx)/gp.BDecay
+}
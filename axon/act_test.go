@@ -35,7 +35,7 @@ func TestActUpdt(t *testing.T) {
 
 	for i := range geinc {
 		nrn.GeRaw += geinc[i]
-		ac.GeFmRaw(nrn, nrn.GeRaw, 1, 0.5)
+		ac.GeFmRaw(nrn, nrn.GeRaw, 0, 1, 0.5)
 		ac.GiFmRaw(nrn, nrn.GiRaw)
 		ac.VmFmG(nrn)
 		ac.ActFmG(nrn)
@@ -70,3 +70,71 @@ func TestActUpdt(t *testing.T) {
 	// fmt.Printf("vm vals: %v\n", vm)
 	// fmt.Printf("act vals: %v\n", act)
 }
+
+func TestSTP(t *testing.T) {
+	sp := STPParams{}
+	sp.Defaults()
+	var rec, fac float32
+	sp.Init(&rec, &fac)
+	eff := sp.Spike(&rec, &fac, STPInitISI)
+	if mat32.Abs(eff-1) > 1.0e-6 {
+		t.Errorf("STP first spike efficacy: %v != 1\n", eff)
+	}
+	eff2 := sp.Spike(&rec, &fac, 10)
+	if eff2 >= eff {
+		t.Errorf("STP depression: second spike efficacy %v not < first %v\n", eff2, eff)
+	}
+	sp.U = 0.1
+	sp.TauRec = 50
+	sp.TauFac = 500
+	sp.Init(&rec, &fac)
+	eff = sp.Spike(&rec, &fac, STPInitISI)
+	eff2 = sp.Spike(&rec, &fac, 10)
+	if eff2 <= eff {
+		t.Errorf("STP facilitation: second spike efficacy %v not > first %v\n", eff2, eff)
+	}
+}
+
+func TestClampSpike(t *testing.T) {
+	cp := ClampParams{}
+	cp.Defaults()
+	rnd := NewRand(1)
+	for _, typ := range []ClampTypes{ClampHard, ClampPoisson} {
+		cp.Type = typ
+		nspk := 0
+		for cyc := 0; cyc < 1000; cyc++ {
+			if cp.Spike(0.5, cyc, 1, rnd) {
+				nspk++
+			}
+		}
+		if typ == ClampHard && nspk != 50 {
+			t.Errorf("ClampHard: expected 50 spikes at 50 Hz over 1000 cycles, got: %d\n", nspk)
+		}
+		if typ == ClampPoisson && (nspk < 30 || nspk > 70) {
+			t.Errorf("ClampPoisson: expected about 50 spikes at 50 Hz over 1000 cycles, got: %d\n", nspk)
+		}
+	}
+}
+
+func TestPoissonInput(t *testing.T) {
+	pp := PoissonInputParams{}
+	pp.Defaults()
+	pp.MaxHz = 500
+	rnd := NewRand(1)
+	isi := float32(-1)
+	nspk := 0
+	for cyc := 0; cyc < 1000; cyc++ {
+		if pp.Spike(1, isi, rnd) {
+			if isi >= 0 && isi < float32(pp.Refract) {
+				t.Errorf("PoissonInput: spike within refractory period, isi: %g\n", isi)
+			}
+			nspk++
+			isi = 0
+		} else if isi >= 0 {
+			isi++
+		}
+	}
+	if nspk < 200 || nspk > 320 {
+		t.Errorf("PoissonInput: expected about 250 spikes with refractory period, got: %d\n", nspk)
+	}
+}
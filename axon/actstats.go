@@ -0,0 +1,206 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goki/mat32"
+)
+
+///////////////////////////////////////////////////////////////////////
+//  ActStatsParams
+
+// ActStatsParams control the accumulation of per-epoch activity
+// diagnostics for a layer, reported by ActStats -- these are useful for
+// detecting representational collapse: dead units that are never active,
+// hog units that are active for everything, and overall sparsity.
+type ActStatsParams struct {
+	On     bool    `def:"true" desc:"accumulate minus-phase activity statistics every trial, for ActStats"`
+	Thr    float32 `viewif:"On" def:"0.1" desc:"threshold on ActM for a unit to count as active on a trial"`
+	HogThr float32 `viewif:"On" def:"4" desc:"a unit is a hog if its average ActM is greater than this multiple of the layer average"`
+}
+
+func (as *ActStatsParams) Defaults() {
+	as.On = true
+	as.Thr = 0.1
+	as.HogThr = 4
+}
+
+func (as *ActStatsParams) Update() {
+}
+
+// ActStats are per-epoch activity diagnostics for a layer, computed from
+// minus-phase ActM values accumulated over trials -- see Layer.ActStats
+type ActStats struct {
+	N        int     `inactive:"+" desc:"number of trials accumulated"`
+	NeverAct float32 `inactive:"+" desc:"fraction of units that were never active (ActM > Stats.Thr) on any trial"`
+	Entropy  float32 `inactive:"+" desc:"entropy of the distribution of average activity across units, normalized by its maximum (uniform) value: 1 = all units equally active, 0 = only one unit active"`
+	Gini     float32 `inactive:"+" desc:"Gini coefficient of the distribution of average activity across units: 0 = all units equally active, 1 = only one unit active"`
+	HogIdx   float32 `inactive:"+" desc:"hog index: maximum unit average activity divided by the layer average -- values much greater than 1 indicate hog units"`
+	HogPct   float32 `inactive:"+" desc:"fraction of units whose average activity is greater than Stats.HogThr times the layer average"`
+	ActAvg   float32 `inactive:"+" desc:"average ActM over units and trials"`
+	GeAvg    float32 `inactive:"+" desc:"average over trials of the layer-average minus-phase GeM"`
+	GeMax    float32 `inactive:"+" desc:"maximum over trials of the layer-maximum minus-phase GeM"`
+	GiAvg    float32 `inactive:"+" desc:"average over trials of the layer-average minus-phase GiM"`
+	GiMax    float32 `inactive:"+" desc:"maximum over trials of the layer-maximum minus-phase GiM"`
+}
+
+// String returns a one-line summary of the stats
+func (as *ActStats) String() string {
+	return fmt.Sprintf("N: %d\tNeverAct: %.3f\tEntropy: %.3f\tGini: %.3f\tHogIdx: %.2f\tHogPct: %.3f\tActAvg: %.3f\tGe: %.3f / %.3f\tGi: %.3f / %.3f", as.N, as.NeverAct, as.Entropy, as.Gini, as.HogIdx, as.HogPct, as.ActAvg, as.GeAvg, as.GeMax, as.GiAvg, as.GiMax)
+}
+
+// actStatsAcc accumulates values over trials for ActStats
+type actStatsAcc struct {
+	N      int
+	ActSum []float32
+	ActMax []float32
+	GeSum  float32
+	GiSum  float32
+	GeMax  float32
+	GiMax  float32
+}
+
+func (aa *actStatsAcc) Reset(nn int) {
+	aa.N = 0
+	aa.GeSum, aa.GiSum, aa.GeMax, aa.GiMax = 0, 0, 0, 0
+	if len(aa.ActSum) != nn {
+		aa.ActSum = make([]float32, nn)
+		aa.ActMax = make([]float32, nn)
+		return
+	}
+	for i := range aa.ActSum {
+		aa.ActSum[i] = 0
+		aa.ActMax[i] = 0
+	}
+}
+
+///////////////////////////////////////////////////////////////////////
+//  Layer methods
+
+// ActStatsAccum accumulates the minus-phase activity stats for ActStats.
+// Called in MinusPhase if Stats.On.
+func (ly *Layer) ActStatsAccum() {
+	aa := &ly.actAcc
+	if len(aa.ActSum) != len(ly.Neurons) {
+		aa.Reset(len(ly.Neurons))
+	}
+	aa.N++
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		aa.ActSum[ni] += nrn.ActM
+		aa.ActMax[ni] = mat32.Max(aa.ActMax[ni], nrn.ActM)
+	}
+	lpl := &ly.Pools[0]
+	aa.GeSum += lpl.GeM.Avg
+	aa.GiSum += lpl.GiM.Avg
+	aa.GeMax = mat32.Max(aa.GeMax, lpl.GeM.Max)
+	aa.GiMax = mat32.Max(aa.GiMax, lpl.GiM.Max)
+}
+
+// ActStatsReset resets the accumulated activity stats
+func (ly *Layer) ActStatsReset() {
+	ly.actAcc.Reset(len(ly.Neurons))
+}
+
+// ActStatsEpoch saves the current ActStats into EpcStats and resets the
+// accumulated values.  Called in Network.EpochInc.
+func (ly *Layer) ActStatsEpoch() {
+	ly.EpcStats = ly.ActStats()
+	ly.ActStatsReset()
+}
+
+// ActStats returns the activity diagnostics computed from values
+// accumulated since the last reset (typically the current epoch) --
+// see EpcStats for the stats from the last completed epoch.
+func (ly *Layer) ActStats() ActStats {
+	aa := &ly.actAcc
+	as := ActStats{N: aa.N}
+	if aa.N == 0 || len(aa.ActSum) != len(ly.Neurons) {
+		return as
+	}
+	fn := float32(aa.N)
+	as.GeAvg = aa.GeSum / fn
+	as.GiAvg = aa.GiSum / fn
+	as.GeMax = aa.GeMax
+	as.GiMax = aa.GiMax
+
+	avgs := make([]float32, 0, len(ly.Neurons))
+	nnever := 0
+	var sum, mx float32
+	for ni := range ly.Neurons {
+		if ly.Neurons[ni].IsOff() {
+			continue
+		}
+		if aa.ActMax[ni] <= ly.Stats.Thr {
+			nnever++
+		}
+		av := aa.ActSum[ni] / fn
+		avgs = append(avgs, av)
+		sum += av
+		mx = mat32.Max(mx, av)
+	}
+	n := len(avgs)
+	if n == 0 {
+		return as
+	}
+	nf := float32(n)
+	as.NeverAct = float32(nnever) / nf
+	as.ActAvg = sum / nf
+	if sum <= 0 {
+		return as
+	}
+	as.HogIdx = mx / as.ActAvg
+	hthr := ly.Stats.HogThr * as.ActAvg
+	nhog := 0
+	var ent float32
+	for _, av := range avgs {
+		if av > hthr {
+			nhog++
+		}
+		if av > 0 {
+			p := av / sum
+			ent -= p * mat32.Log(p)
+		}
+	}
+	as.HogPct = float32(nhog) / nf
+	if n > 1 {
+		as.Entropy = ent / mat32.Log(nf)
+	}
+	sort.Slice(avgs, func(i, j int) bool { return avgs[i] < avgs[j] })
+	var wsum float32
+	for i, av := range avgs {
+		wsum += float32(i+1) * av
+	}
+	as.Gini = (2*wsum)/(nf*sum) - (nf+1)/nf
+	return as
+}
+
+///////////////////////////////////////////////////////////////////////
+//  Network methods
+
+// StatsReport returns a string with the ActStats for each layer from the
+// last completed epoch (EpcStats), or from the current epoch if cur is true.
+func (nt *Network) StatsReport(cur bool) string {
+	var b strings.Builder
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		if ly.IsOff() || !ly.Stats.On {
+			continue
+		}
+		as := ly.EpcStats
+		if cur {
+			as = ly.ActStats()
+		}
+		fmt.Fprintf(&b, "Layer: %s\t%s\n", ly.Nm, as.String())
+	}
+	return b.String()
+}
@@ -249,4 +249,18 @@ type AxonPrjn interface {
 	// SynFail updates synaptic weight failure only -- normally done as part of DWt
 	// and WtFmDWt, but this call can be used during testing to update failing synapses.
 	SynFail()
+
+	// SetSynVal1D sets value of given variable index (from SynVarIdx) on given SynIdx.
+	// Returns false on invalid index.  Derived types with additional synapse
+	// variables must override this along with SynVal1D.
+	SetSynVal1D(varIdx int, synIdx int, val float32) bool
+
+	// WtsSynVars returns the names of additional synapse variables, beyond
+	// Wt and SWt, that are saved in weights files.
+	WtsSynVars() []string
+
+	// SynTrOn returns true if the SynTr per-synapse eligibility trace is in use,
+	// so that AllocSynOpt allocates it.  Derived types that use SynTr for
+	// their own learning rules must override this.
+	SynTrOn() bool
 }
@@ -5,12 +5,17 @@
 package axon
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"testing"
+	"unsafe"
 
 	"github.com/emer/emergent/emer"
 	"github.com/emer/emergent/params"
 	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/weights"
+	"github.com/emer/etable/etable"
 	"github.com/emer/etable/etensor"
 	"github.com/goki/mat32"
 )
@@ -106,6 +111,71 @@ func TestSynVals(t *testing.T) {
 	// fmt.Printf("SynVals: before wt: %v, lwt: %v  after wt: %v, lwt: %v\n", bfWt, bfLWt, afWt, afLWt)
 }
 
+func TestWtsBinary(t *testing.T) {
+	TestNet.InitWts()
+	hidLay := TestNet.LayerByName("Hidden").(*Layer)
+	fmIn := hidLay.RcvPrjns.SendName("Input").(*Prjn)
+	fmIn.SetSynVal("Wt", 1, 1, .15)
+
+	var buf bytes.Buffer
+	err := TestNet.WriteWtsBinary(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	TestNet.InitWts()
+	err = TestNet.ReadWtsBinary(&buf)
+	if err != nil {
+		t.Error(err)
+	}
+	afWt := fmIn.SynVal("Wt", 1, 1)
+	afLWt := fmIn.SynVal("LWt", 1, 1)
+	CmprFloats([]float32{afWt, afLWt}, []float32{0.15, 0.42822415}, "binary weights round trip", t)
+}
+
+func TestWtsDiff(t *testing.T) {
+	TestNet.InitWts()
+	hidLay := TestNet.LayerByName("Hidden").(*Layer)
+	fmIn := hidLay.RcvPrjns.SendName("Input").(*Prjn)
+
+	var bufA, bufB bytes.Buffer
+	if err := TestNet.WriteWtsJSON(&bufA); err != nil {
+		t.Error(err)
+	}
+	wtA := fmIn.SynVal("Wt", 1, 1)
+	fmIn.SetSynVal("Wt", 1, 1, wtA+.1)
+	if err := TestNet.WriteWtsJSON(&bufB); err != nil {
+		t.Error(err)
+	}
+	na, err := weights.NetReadJSON(&bufA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb, err := weights.NetReadJSON(&bufB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt := &etable.Table{}
+	detail := &etable.Table{}
+	if err := WtsDiffNets(dt, na, nb, detail); err != nil {
+		t.Error(err)
+	}
+	if detail.Rows != 1 {
+		t.Errorf("WtsDiff: expected 1 differing synapse, got: %d\n", detail.Rows)
+	}
+	for row := 0; row < dt.Rows; row++ {
+		lay := dt.CellString("Layer", row)
+		from := dt.CellString("From", row)
+		l2 := dt.CellFloat("L2", row)
+		if lay == "Hidden" && from == "Input" {
+			if math.Abs(l2-.1) > 1.0e-4 {
+				t.Errorf("WtsDiff: Hidden from Input L2 should be .1, got: %g\n", l2)
+			}
+		} else if l2 != 0 || dt.CellFloat("Cos", row) != 1 {
+			t.Errorf("WtsDiff: %v from %v should be unchanged, got L2: %g\n", lay, from, l2)
+		}
+	}
+}
+
 func TestInPats(t *testing.T) {
 	InPats = etensor.NewFloat32([]int{4, 4, 1}, nil, []string{"pat", "Y", "X"})
 	for pi := 0; pi < 4; pi++ {
@@ -140,38 +210,46 @@ func TestSpikeProp(t *testing.T) {
 	pat := etensor.NewFloat32([]int{1, 1}, nil, []string{"Y", "X"})
 	pat.Set([]int{0, 0}, 1)
 
-	for del := 0; del <= 4; del++ {
-		prj.Com.Delay = del
-		net.InitWts()  // resets Gbuf
-		net.NewState() // get GScale
+	prj.Com.Event.RateThr = 1
+	for _, evt := range []bool{false, true} {
+		prj.Com.Event.On = evt
+		for del := 0; del <= 4; del++ {
+			prj.Com.Delay = del
+			net.InitWts()  // resets Gbuf
+			net.NewState() // get GScale
 
-		inLay.ApplyExt(pat)
+			if prj.UseEvents != evt {
+				t.Errorf("SpikeProp error -- UseEvents: %v  should be: %v\n", prj.UseEvents, evt)
+			}
 
-		net.NewState()
-		ltime.NewState()
+			inLay.ApplyExt(pat)
 
-		inCyc := 0
-		hidCyc := 0
-		for cyc := 0; cyc < 100; cyc++ {
-			net.Cycle(ltime)
-			ltime.CycleInc()
+			net.NewState()
+			ltime.NewState()
 
-			if inLay.Neurons[0].Spike > 0 {
-				inCyc = cyc
-			}
+			inCyc := 0
+			hidCyc := 0
+			for cyc := 0; cyc < 100; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
 
-			// if inCyc > 0 {
-			// 	fmt.Printf("del: %d   cyc %d   inCyc: %d   Zi: %d  gbuf: %v\n", del, cyc, inCyc, prj.Gidx.Zi, prj.Gbuf)
-			// }
-			ge := hidLay.Neurons[0].Ge
-			if ge > 0 {
-				hidCyc = cyc
-				break
+				if inLay.Neurons[0].Spike > 0 {
+					inCyc = cyc
+				}
+
+				// if inCyc > 0 {
+				// 	fmt.Printf("del: %d   cyc %d   inCyc: %d   Zi: %d  gbuf: %v\n", del, cyc, inCyc, prj.Gidx.Zi, prj.Gbuf)
+				// }
+				ge := hidLay.Neurons[0].Ge
+				if ge > 0 {
+					hidCyc = cyc
+					break
+				}
+			}
+			// fmt.Printf("del: %d   inCyc: %d   hidCyc: %d\n", del, inCyc, hidCyc)
+			if hidCyc-inCyc != del+1 {
+				t.Errorf("SpikeProp error -- events: %v  delay: %d  actual: %d\n", evt, del+1, hidCyc-inCyc)
 			}
-		}
-		// fmt.Printf("del: %d   inCyc: %d   hidCyc: %d\n", del, inCyc, hidCyc)
-		if hidCyc-inCyc != del+1 {
-			t.Errorf("SpikeProp error -- delay: %d  actual: %d\n", del+1, hidCyc-inCyc)
 		}
 	}
 }
@@ -566,3 +644,1101 @@ func TestInhibAct(t *testing.T) {
 		}
 	}
 }
+
+func TestPoolInhib(t *testing.T) {
+	net := NewNetwork("PoolNet")
+	inLay := net.AddLayer("Input", []int{1, 2, 2, 2}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{1, 2, 2, 2}, emer.Hidden).(*Layer)
+
+	net.ConnectLayers(inLay, hidLay, prjn.NewOneToOne(), emer.Forward)
+
+	net.Defaults()
+	hidLay.Inhib.Pool.On = true
+	hidLay.Inhib.Layer.On = false
+	net.Build()
+	net.InitWts()
+	net.InitExt()
+
+	ltime := NewTime()
+	pat := etensor.NewFloat32([]int{1, 2, 2, 2}, nil, nil)
+	for i := 0; i < 4; i++ { // only first pool is active
+		pat.Values[i] = 1
+	}
+	inLay.ApplyExt(pat)
+	net.NewState()
+	ltime.NewState()
+	for cyc := 0; cyc < 50; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	if len(hidLay.Pools) != 3 {
+		t.Fatalf("PoolInhib: expected 3 pools, got: %d\n", len(hidLay.Pools))
+	}
+	p1 := hidLay.Pools[1].Inhib.Gi
+	p2 := hidLay.Pools[2].Inhib.Gi
+	if p1 <= p2 {
+		t.Errorf("PoolInhib: active pool Gi %g should be > inactive pool Gi %g\n", p1, p2)
+	}
+	if lgi := hidLay.Pools[0].Inhib.Gi; lgi != p1 {
+		t.Errorf("PoolInhib: layer Gi %g should be max of pool Gi %g without layer inhib\n", lgi, p1)
+	}
+}
+
+// poolTrainWts trains a network with given number of pool workers for a few
+// trials, returning all of the weights after each trial
+func poolTrainWts(t *testing.T, nworkers int) []float32 {
+	net := NewNetwork("PoolNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+	net.ConnectLayers(hidLay, outLay, prjn.NewFull(), emer.Forward)
+	fmOut := net.ConnectLayers(outLay, hidLay, prjn.NewFull(), emer.Back).(*Prjn)
+
+	net.Defaults()
+	net.ApplyParams(ParamSets[0].Sheets["Network"], false)
+	fmOut.Learn.WtNoise.On = true // draws random numbers: not chunked
+	net.RandSeed = 10
+	net.WorkPool.NWorkers = nworkers
+	net.WorkPool.ChunkSize = 1
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	defer net.Pool.Stop()
+
+	var wts, vals []float32
+	ltime := NewTime()
+	for pi := 0; pi < 4; pi++ {
+		inpat := InPats.SubSpace([]int{pi})
+		net.InitExt()
+		inLay.ApplyExt(inpat)
+		outLay.ApplyExt(inpat)
+		net.NewState()
+		ltime.NewState()
+		for qtr := 0; qtr < 4; qtr++ {
+			for cyc := 0; cyc < 50; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			if qtr == 2 {
+				net.MinusPhase(ltime)
+				ltime.NewPhase()
+			}
+		}
+		net.PlusPhase(ltime)
+		net.DWt()
+		net.WtFmDWt()
+		for _, ly := range net.Layers {
+			for _, p := range *ly.RecvPrjns() {
+				p.SynVals(&vals, "Wt")
+				wts = append(wts, vals...)
+			}
+		}
+	}
+	return wts
+}
+
+func TestPoolDeterminism(t *testing.T) {
+	serial := poolTrainWts(t, 1)
+	pool := poolTrainWts(t, 4)
+	if len(pool) != len(serial) {
+		t.Fatalf("PoolDeterminism: got %d weights, expected %d\n", len(pool), len(serial))
+	}
+	for i := range serial {
+		if pool[i] != serial[i] {
+			t.Errorf("PoolDeterminism: weight %d with 4 workers: %v != serial: %v\n", i, pool[i], serial[i])
+		}
+	}
+}
+
+func TestInferenceMode(t *testing.T) {
+	hidLay := TestNet.LayerByName("Hidden").(*Layer)
+	outLay := TestNet.LayerByName("Output").(*Layer)
+
+	runTrial := func() (hidActs, outActs, initWts, wts []float32) {
+		TestNet.InitWts()
+		TestNet.InitExt()
+		inpat, err := InPats.SubSpaceTry([]int{1})
+		if err != nil {
+			t.Error(err)
+		}
+		TestNet.LayerByName("Input").(*Layer).ApplyExt(inpat)
+		outLay.ApplyExt(inpat)
+
+		ltime := NewTime()
+		TestNet.NewState()
+		ltime.NewState()
+		for qtr := 0; qtr < 4; qtr++ {
+			for cyc := 0; cyc < 50; cyc++ {
+				TestNet.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			if qtr == 2 {
+				TestNet.MinusPhase(ltime)
+				ltime.NewPhase()
+			}
+		}
+		TestNet.PlusPhase(ltime)
+		hidLay.SndPrjns[0].SynVals(&initWts, "Wt")
+		TestNet.DWt()
+		TestNet.WtFmDWt()
+		hidLay.UnitVals(&hidActs, "ActM")
+		outLay.UnitVals(&outActs, "ActM")
+		hidLay.SndPrjns[0].SynVals(&wts, "Wt")
+		return
+	}
+
+	trnHid, trnOut, _, _ := runTrial()
+	TestNet.InferenceMode(true)
+	infHid, infOut, infInitWts, infWts := runTrial()
+	infAvgS := hidLay.Neurons[1].AvgS
+	TestNet.InferenceMode(false)
+
+	CmprFloats(infHid, trnHid, "InferenceMode hidden ActM", t)
+	CmprFloats(infOut, trnOut, "InferenceMode output ActM", t)
+	CmprFloats(infWts, infInitWts, "InferenceMode Wt", t)
+	if infAvgS != hidLay.Learn.ActAvg.Init {
+		t.Errorf("InferenceMode: hidden AvgS should not be updated, got: %g\n", infAvgS)
+	}
+}
+
+func TestRocAUC(t *testing.T) {
+	if auc := rocAUC([]float32{.9, .8}, []float32{.1, .2, .3}); auc != 1 {
+		t.Errorf("rocAUC: perfect ranking should be 1, got: %g\n", auc)
+	}
+	if auc := rocAUC([]float32{.1}, []float32{.9, .8}); auc != 0 {
+		t.Errorf("rocAUC: inverted ranking should be 0, got: %g\n", auc)
+	}
+	if auc := rocAUC([]float32{.5}, []float32{.5, .1}); auc != 0.75 {
+		t.Errorf("rocAUC: tie should count 1/2, expected 0.75, got: %g\n", auc)
+	}
+}
+
+func TestThetaCyc(t *testing.T) {
+	net := NewNetwork("ThetaNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	net.ConnectLayers(inLay, outLay, prjn.NewFull(), emer.Forward)
+	net.Defaults()
+	net.Theta.NPhases = 2 // beta-frequency: 2 x 50
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+
+	inpat := InPats.SubSpace([]int{0})
+	inLay.ApplyExt(inpat)
+	outLay.ApplyExt(inpat)
+	ltime := NewTime()
+	nminus := 0
+	net.ThetaCyc(ltime, func(ltime *Time) {
+		if !ltime.PlusPhase {
+			nminus++
+		}
+	})
+	if ltime.Cycle != 100 || nminus != 50 || ltime.Phase != 1 || !ltime.PlusPhase {
+		t.Errorf("ThetaCyc: Cycle: %d, minus cycles: %d, Phase: %d", ltime.Cycle, nminus, ltime.Phase)
+	}
+	if net.Theta.TotCycles() != 100 || net.Theta.LrnPhaseIdx() != 0 {
+		t.Errorf("ThetaParams: TotCycles: %d, LrnPhaseIdx: %d", net.Theta.TotCycles(), net.Theta.LrnPhaseIdx())
+	}
+}
+
+func TestContLrn(t *testing.T) {
+	net := NewNetwork("ContNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	pj := net.ConnectLayers(inLay, outLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	net.Defaults()
+	net.ContLrn.On = true
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	var initWts, wts []float32
+	pj.SynVals(&initWts, "Wt")
+
+	ltime := NewTime()
+	for pi := 0; pi < 4; pi++ { // change inputs without any phases
+		net.InitExt()
+		inLay.ApplyExt(InPats.SubSpace([]int{pi}))
+		outLay.ApplyExt(InPats.SubSpace([]int{(pi + 1) % 4}))
+		net.TargToExt()
+		for cyc := 0; cyc < 100; cyc++ {
+			net.Cycle(ltime)
+			ltime.CycleInc()
+		}
+	}
+	pj.SynVals(&wts, "Wt")
+	nchg := 0
+	for i := range wts {
+		if wts[i] != initWts[i] {
+			nchg++
+		}
+	}
+	if nchg == 0 {
+		t.Errorf("ContLrn: no weights changed after %d cycles\n", ltime.CycleTot)
+	}
+}
+
+func TestUpdtWaves(t *testing.T) {
+	net := NewNetwork("WaveNet")
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	hid2Lay := net.AddLayer("Hidden2", []int{4, 1}, emer.Hidden).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+	net.ConnectLayers(inLay, hid2Lay, prjn.NewFull(), emer.Forward)
+	net.ConnectLayers(hidLay, outLay, prjn.NewFull(), emer.Forward)
+	net.ConnectLayers(hid2Lay, outLay, prjn.NewFull(), emer.Forward)
+	net.ConnectLayers(outLay, hidLay, prjn.NewFull(), emer.Back)
+	net.Defaults()
+	net.UpdtOrder = UpdtWaves
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+
+	wn := fmt.Sprint(net.WaveNames())
+	if wn != "[[Input] [Hidden2 Hidden] [Output]]" {
+		t.Errorf("UpdtWaves: got waves: %v\n", wn)
+	}
+	net.UpdtOrder = UpdtSeq
+	net.ConfigWaves()
+	wn = fmt.Sprint(net.WaveNames())
+	if wn != "[[Input] [Hidden2] [Hidden] [Output]]" {
+		t.Errorf("UpdtSeq: got waves: %v\n", wn)
+	}
+
+	inLay.ApplyExt(InPats.SubSpace([]int{0}))
+	ltime := NewTime()
+	for cyc := 0; cyc < 50; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	if inLay.Pools[0].Inhib.Act.Max == 0 {
+		t.Errorf("UpdtSeq: no Input activity after 50 cycles\n")
+	}
+}
+
+func TestStateRand(t *testing.T) {
+	sr := NewStateRand(5)
+	for i := 0; i < 10; i++ {
+		sr.NormFloat64()
+		sr.Intn(7)
+	}
+	st := sr.State()
+	var vals [5]float64
+	for i := range vals {
+		vals[i] = sr.Float64()
+	}
+	rs := NewStateRand(1)
+	rs.SetState(st)
+	for i := range vals {
+		if v := rs.Float64(); v != vals[i] {
+			t.Errorf("StateRand: restored value %d: %v != %v\n", i, v, vals[i])
+		}
+	}
+}
+
+func TestSurgeryProf(t *testing.T) {
+	net := NewNetwork("SurgNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	net.SetProfile(true, false)
+
+	outLay, err := net.AddLayerDynamic("Output", []int{4, 1}, emer.Target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.ConnectLayersDynamic(hidLay, outLay, prjn.NewFull(), emer.Forward); err != nil {
+		t.Fatal(err)
+	}
+	ltime := NewTime()
+	inLay.ApplyExt(InPats.SubSpace([]int{0}))
+	for cyc := 0; cyc < 10; cyc++ { // would panic on out-of-range Prof timers
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	if len(net.Prof.Timers(ProfRecvGInc)) != len(net.Layers) {
+		t.Errorf("Surgery: Prof timers not resized for new layer\n")
+	}
+}
+
+func TestSurgeryWaves(t *testing.T) {
+	net := NewNetwork("SurgNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+	net.Defaults()
+	net.UpdtOrder = UpdtWaves
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+
+	ltime := NewTime()
+	inLay.ApplyExt(InPats.SubSpace([]int{0}))
+	net.Cycle(ltime) // configures Waves
+
+	outLay, err := net.AddLayerDynamic("Output", []int{4, 1}, emer.Target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.ConnectLayersDynamic(hidLay, outLay, prjn.NewFull(), emer.Forward); err != nil {
+		t.Fatal(err)
+	}
+	net.Cycle(ltime)
+	wn := fmt.Sprint(net.WaveNames())
+	if wn != "[[Input] [Hidden] [Output]]" {
+		t.Errorf("Surgery: got waves after AddLayerDynamic: %v\n", wn)
+	}
+
+	if err := net.DeleteLayer("Hidden"); err != nil {
+		t.Fatal(err)
+	}
+	net.Cycle(ltime)
+	wn = fmt.Sprint(net.WaveNames())
+	if wn != "[[Input Output]]" {
+		t.Errorf("Surgery: got waves after DeleteLayer: %v\n", wn)
+	}
+}
+
+// surgeryTrain trains the network for a few trials on InPats
+func surgeryTrain(net *Network, inLay, outLay *Layer) {
+	ltime := NewTime()
+	for pi := 0; pi < 4; pi++ {
+		inpat := InPats.SubSpace([]int{pi})
+		net.InitExt()
+		inLay.ApplyExt(inpat)
+		outLay.ApplyExt(inpat)
+		net.NewState()
+		ltime.NewState()
+		for qtr := 0; qtr < 4; qtr++ {
+			for cyc := 0; cyc < 50; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			if qtr == 2 {
+				net.MinusPhase(ltime)
+				ltime.NewPhase()
+			}
+		}
+		net.PlusPhase(ltime)
+		net.DWt()
+		net.WtFmDWt()
+	}
+}
+
+func TestSurgery(t *testing.T) {
+	net := NewNetwork("SurgNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	fmIn := net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	fmHid := net.ConnectLayers(hidLay, outLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	net.ConnectLayers(outLay, hidLay, prjn.NewFull(), emer.Back)
+	net.Defaults()
+	net.ApplyParams(ParamSets[0].Sheets["Network"], false)
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	surgeryTrain(net, inLay, outLay)
+
+	inSyns := append([]Synapse{}, fmIn.Syns...)
+	hidSyns := append([]Synapse{}, fmHid.Syns...)
+	checkSyns := func(msg string) {
+		for _, c := range []struct {
+			pj   *Prjn
+			syns []Synapse
+		}{{fmIn, inSyns}, {fmHid, hidSyns}} {
+			if len(c.pj.Syns) != len(c.syns) {
+				t.Fatalf("Surgery %s: prjn %s has %d synapses, expected %d\n", msg, c.pj.Name(), len(c.pj.Syns), len(c.syns))
+			}
+			for si := range c.syns {
+				if c.pj.Syns[si] != c.syns[si] {
+					t.Errorf("Surgery %s: prjn %s synapse %d changed: %v != %v\n", msg, c.pj.Name(), si, c.pj.Syns[si], c.syns[si])
+					break
+				}
+			}
+		}
+	}
+
+	hid2Lay, err := net.AddLayerDynamic("Hidden2", []int{4, 1}, emer.Hidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSyns("AddLayerDynamic")
+	if _, err := net.ConnectLayersDynamic(inLay, hid2Lay, prjn.NewFull(), emer.Forward); err != nil {
+		t.Fatal(err)
+	}
+	fm2, err := net.ConnectLayersDynamic(hid2Lay, outLay, prjn.NewFull(), emer.Forward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSyns("ConnectLayersDynamic")
+	if n := len(fm2.(*Prjn).Syns); n != 16 {
+		t.Errorf("Surgery: new prjn has %d synapses, expected 16\n", n)
+	}
+	if err := net.DeletePrjn("Output", "Hidden"); err != nil {
+		t.Fatal(err)
+	}
+	checkSyns("DeletePrjn")
+	if len(hidLay.RcvPrjns) != 1 || len(outLay.SndPrjns) != 0 {
+		t.Errorf("Surgery: DeletePrjn did not remove Output -> Hidden prjn\n")
+	}
+	if err := net.DeleteLayer("Hidden2"); err != nil {
+		t.Fatal(err)
+	}
+	checkSyns("DeleteLayer")
+	if len(net.Layers) != 3 || len(outLay.RcvPrjns) != 1 || len(inLay.SndPrjns) != 1 {
+		t.Errorf("Surgery: DeleteLayer did not remove layer and its prjns\n")
+	}
+
+	ltime := NewTime()
+	inLay.ApplyExt(InPats.SubSpace([]int{0}))
+	for cyc := 0; cyc < 50; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	if hidLay.Pools[0].Inhib.Act.Max == 0 {
+		t.Errorf("Surgery: no Hidden activity after surgery\n")
+	}
+	surgeryTrain(net, inLay, outLay)
+}
+
+func TestSurgeryRandSeed(t *testing.T) {
+	var wts [2][]Synapse
+	for ni := range wts {
+		net := NewNetwork("SurgSeedNet")
+		inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+		net.RandSeed = 42
+		net.Defaults()
+		if err := net.Build(); err != nil {
+			t.Fatal(err)
+		}
+		net.InitWts()
+		hidLay, err := net.AddLayerDynamic("Hidden", []int{4, 1}, emer.Hidden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hidLay.(*Layer).Rand == nil {
+			t.Errorf("SurgeryRandSeed: AddLayerDynamic layer has nil Rand with RandSeed\n")
+		}
+		pj, err := net.ConnectLayersDynamic(inLay, hidLay, prjn.NewFull(), emer.Forward)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pj.(*Prjn).Rand == nil {
+			t.Errorf("SurgeryRandSeed: ConnectLayersDynamic prjn has nil Rand with RandSeed\n")
+		}
+		wts[ni] = append([]Synapse{}, pj.(*Prjn).Syns...)
+	}
+	for si := range wts[0] {
+		if wts[0][si] != wts[1][si] {
+			t.Errorf("SurgeryRandSeed: synapse %d not reproducible: %v != %v\n", si, wts[0][si], wts[1][si])
+			break
+		}
+	}
+}
+
+func TestWtScStale(t *testing.T) {
+	net := NewNetwork("WtScNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	pj := net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	net.InferenceMode(true) // SynFail without WtFmDWt
+
+	checkWtSc := func(msg string) {
+		inLay.ApplyExt(InPats.SubSpace([]int{0}))
+		ltime := NewTime()
+		for cyc := 0; cyc < 50; cyc++ {
+			net.Cycle(ltime)
+			ltime.CycleInc()
+		}
+		for si := range pj.Syns {
+			if wsc := pj.GScale.Scale * pj.Syns[si].Wt; pj.WtSc[si] != wsc {
+				t.Errorf("WtSc %s: synapse %d WtSc: %g != Scale * Wt: %g\n", msg, si, pj.WtSc[si], wsc)
+				return
+			}
+		}
+	}
+	checkWtSc("init")
+	pj.Com.PFail = 1
+	net.SynFail()
+	checkWtSc("SynFail")
+	pj.SetWtsFunc(func(si, ri int, send, recv *etensor.Shape) float32 { return 0.3 })
+	checkWtSc("SetWtsFunc")
+}
+
+func TestBatchPrune(t *testing.T) {
+	nb, err := NewNetworkBatch("BatchNet", 2, func(net *Network) {
+		inLay := net.AddLayer("Input", []int{8, 1}, emer.Input)
+		hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+		net.ConnectLayers(inLay, hidLay, prjn.NewPoolUnifRnd(), emer.Forward)
+		net.Defaults()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb.InitWts()
+	pjs := make([]*Prjn, nb.Len())
+	for b, net := range nb.Nets {
+		pjs[b] = net.LayerByName("Hidden").(*Layer).RcvPrjns[0].(*Prjn)
+	}
+	rcon := append([]int32{}, pjs[1].RConIdx...)
+	rsyn := append([]int32{}, pjs[1].RSynIdx...)
+
+	pj := pjs[0]
+	pj.Prune.On = true
+	pj.Prune.Thr = 2 // all synapses below
+	pj.Prune.NSlow = 1
+	pj.Prune.MaxPct = 1
+	pj.PruneSyns()
+	if pj.PruneStats.Grown == 0 {
+		t.Fatalf("BatchPrune: no synapses regrown\n")
+	}
+	cmprInts := func(out, cor []int32, msg string) {
+		for i := range cor {
+			if out[i] != cor[i] {
+				t.Errorf("BatchPrune: %s index %d changed: %d != %d\n", msg, i, out[i], cor[i])
+				return
+			}
+		}
+	}
+	cmprInts(pjs[1].RConIdx, rcon, "other network RConIdx")
+	cmprInts(pjs[1].RSynIdx, rsyn, "other network RSynIdx")
+}
+
+func TestBatchCycle(t *testing.T) {
+	config := func(net *Network) {
+		inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+		hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+		outLay := net.AddLayer("Output", []int{4, 1}, emer.Target)
+		net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+		net.BidirConnectLayers(hidLay, outLay, prjn.NewFull())
+		net.Defaults()
+		net.ApplyParams(ParamSets[0].Sheets["Network"], false)
+	}
+	nb, err := NewNetworkBatch("BatchNet", 2, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb.InitWts()
+	if !nb.BatchSendOK() {
+		t.Fatalf("BatchCycle: BatchSendOK is false\n")
+	}
+	n0 := nb.Nets[0].LayerByName("Hidden").(*Layer).Neurons
+	n1 := nb.Nets[1].LayerByName("Hidden").(*Layer).Neurons
+	if uintptr(unsafe.Pointer(&n0[len(n0)-1]))+unsafe.Sizeof(Neuron{}) != uintptr(unsafe.Pointer(&n1[0])) {
+		t.Errorf("BatchCycle: Hidden Neurons are not stacked across the batch\n")
+	}
+	refs := make([]*Network, nb.Len())
+	for b := range refs {
+		net := NewNetwork("RefNet")
+		config(net)
+		if err := net.Build(); err != nil {
+			t.Fatal(err)
+		}
+		net.InitWts()
+		refs[b] = net
+	}
+	for pi := 0; pi < 4; pi++ {
+		for b := range refs {
+			pat := InPats.SubSpace([]int{(pi + b) % 4})
+			for _, net := range []*Network{nb.Nets[b], refs[b]} {
+				net.LayerByName("Input").(*Layer).ApplyExt(pat)
+				net.LayerByName("Output").(*Layer).ApplyExt(pat)
+			}
+		}
+		ltime := NewTime()
+		rtimes := []*Time{NewTime(), NewTime()}
+		nb.NewState()
+		ltime.NewState()
+		for b, net := range refs {
+			net.NewState()
+			rtimes[b].NewState()
+		}
+		for cyc := 0; cyc < 200; cyc++ {
+			nb.Cycle(ltime)
+			ltime.CycleInc()
+			for b, net := range refs {
+				net.Cycle(rtimes[b])
+				rtimes[b].CycleInc()
+			}
+			if cyc == 149 {
+				nb.MinusPhase(ltime)
+				ltime.NewPhase()
+				for b, net := range refs {
+					net.MinusPhase(rtimes[b])
+					rtimes[b].NewPhase()
+				}
+			}
+		}
+		nb.PlusPhase(ltime)
+		nb.DWt()
+		nb.WtFmDWt()
+		for b, net := range refs {
+			net.PlusPhase(rtimes[b])
+			net.DWt()
+			net.WtFmDWt()
+		}
+		for b, net := range refs {
+			for _, lnm := range []string{"Hidden", "Output"} {
+				bl := nb.Nets[b].LayerByName(lnm).(*Layer)
+				rl := net.LayerByName(lnm).(*Layer)
+				for ni := range rl.Neurons {
+					if bl.Neurons[ni].Ge != rl.Neurons[ni].Ge || bl.Neurons[ni].ActM != rl.Neurons[ni].ActM {
+						t.Errorf("BatchCycle: pat %d net %d %s neuron %d Ge, ActM batch: %g, %g != single: %g, %g\n", pi, b, lnm, ni, bl.Neurons[ni].Ge, bl.Neurons[ni].ActM, rl.Neurons[ni].Ge, rl.Neurons[ni].ActM)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCompactIdxs(t *testing.T) {
+	net := NewNetwork("CompactNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target)
+	full := net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	rnd := prjn.NewUnifRnd()
+	rnd.PCon = 0.5
+	sparse := net.ConnectLayers(hidLay, outLay, rnd, emer.Forward).(*Prjn)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	ss := full.Sparsity()
+	if ss.RFanIn != 4 || ss.SFanOut != 4 || full.RConN != nil || full.SConIdxSt != nil || ss.SavedBytes == 0 {
+		t.Errorf("CompactIdxs: Full prjn not compact: %+v\n", ss)
+	}
+	for ri := 0; ri < 4; ri++ {
+		if nc, st := full.RConNSt(ri); nc != 4 || st != ri*4 {
+			t.Errorf("CompactIdxs: RConNSt(%d) = %d, %d\n", ri, nc, st)
+		}
+	}
+	nsyn := 0
+	for si := 0; si < 4; si++ {
+		nc, _ := sparse.SConNSt(si)
+		nsyn += nc
+	}
+	if nsyn != len(sparse.Syns) {
+		t.Errorf("CompactIdxs: sparse prjn SConNSt total: %d != %d\n", nsyn, len(sparse.Syns))
+	}
+}
+
+func TestGather(t *testing.T) {
+	nets := make([]*Network, 2)
+	for gi := range nets {
+		net := NewNetwork("GatherNet")
+		inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+		hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+		outLay := net.AddLayer("Output", []int{4, 1}, emer.Target)
+		net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+		net.BidirConnectLayers(hidLay, outLay, prjn.NewFull())
+		net.Defaults()
+		net.ApplyParams(ParamSets[0].Sheets["Network"], false)
+		if err := net.Build(); err != nil {
+			t.Fatal(err)
+		}
+		net.InitWts()
+		for _, ly := range net.Layers {
+			for _, p := range ly.(AxonLayer).AsAxon().RcvPrjns {
+				pj := p.(*Prjn)
+				pj.Com.Gather = gi == 1
+				if pj.GatherOK() != (gi == 1) {
+					t.Fatalf("Gather: GatherOK not %v for %v\n", gi == 1, pj.Name())
+				}
+			}
+		}
+		nets[gi] = net
+	}
+	for pi := 0; pi < 4; pi++ {
+		for gi, net := range nets {
+			net.LayerByName("Input").(*Layer).ApplyExt(InPats.SubSpace([]int{pi}))
+			net.LayerByName("Output").(*Layer).ApplyExt(InPats.SubSpace([]int{pi}))
+			ltime := NewTime()
+			net.NewState()
+			ltime.NewState()
+			for cyc := 0; cyc < 150; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+				if cyc == 149 {
+					net.MinusPhase(ltime)
+					ltime.NewPhase()
+				}
+			}
+			for cyc := 0; cyc < 50; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			net.PlusPhase(ltime)
+			net.DWt()
+			net.WtFmDWt()
+			if gi == 1 {
+				for _, lnm := range []string{"Hidden", "Output"} {
+					sl := nets[0].LayerByName(lnm).(*Layer)
+					gl := net.LayerByName(lnm).(*Layer)
+					for ni := range sl.Neurons {
+						if sl.Neurons[ni].Ge != gl.Neurons[ni].Ge || sl.Neurons[ni].ActM != gl.Neurons[ni].ActM {
+							t.Errorf("Gather: pat %d %s neuron %d Ge, ActM scatter: %g, %g != gather: %g, %g\n", pi, lnm, ni, sl.Neurons[ni].Ge, sl.Neurons[ni].ActM, gl.Neurons[ni].Ge, gl.Neurons[ni].ActM)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// overrideLayer counts the calls to its layer-level methods, to test that
+// the worker pool respects overrides of these methods
+type overrideLayer struct {
+	Layer
+	NSend, NDWt, NWtFmDWt int
+}
+
+func (ly *overrideLayer) SendSpike(ltime *Time) {
+	ly.NSend++
+	ly.Layer.SendSpike(ltime)
+}
+
+func (ly *overrideLayer) DWt() {
+	ly.NDWt++
+	ly.Layer.DWt()
+}
+
+func (ly *overrideLayer) WtFmDWt() {
+	ly.NWtFmDWt++
+	ly.Layer.WtFmDWt()
+}
+
+func TestPoolOverrides(t *testing.T) {
+	net := NewNetwork("PoolNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input).(*Layer)
+	hidLay := &overrideLayer{}
+	net.AddLayerInit(hidLay, "Hidden", []int{4, 1}, emer.Hidden)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target).(*Layer)
+	net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+	net.BidirConnectLayers(hidLay, outLay, prjn.NewFull())
+	net.Defaults()
+	net.WorkPool.NWorkers = 4
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	defer net.Pool.Stop()
+
+	inLay.ApplyExt(InPats.SubSpace([]int{0}))
+	outLay.ApplyExt(InPats.SubSpace([]int{0}))
+	ltime := NewTime()
+	net.NewState()
+	ltime.NewState()
+	ncyc := 50
+	for cyc := 0; cyc < ncyc; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	net.DWt()
+	net.WtFmDWt()
+	if hidLay.NSend != ncyc || hidLay.NDWt != 1 || hidLay.NWtFmDWt != 1 {
+		t.Errorf("PoolOverrides: SendSpike, DWt, WtFmDWt calls: %d, %d, %d != %d, 1, 1\n", hidLay.NSend, hidLay.NDWt, hidLay.NWtFmDWt, ncyc)
+	}
+}
+
+// checkpointNet returns a network for TestCheckpoint, with pruning, per-synapse
+// delays, synaptic failure, lesioned synapses and noise, so that all of
+// the state that affects subsequent training is exercised
+func checkpointNet(t *testing.T) *Network {
+	net := NewNetwork("CkptNet")
+	inLay := net.AddLayer("Input", []int{8, 1}, emer.Input)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden).(*Layer)
+	outLay := net.AddLayer("Output", []int{4, 1}, emer.Target)
+	rnd := prjn.NewUnifRnd()
+	rnd.PCon = 0.5
+	fmIn := net.ConnectLayers(inLay, hidLay, rnd, emer.Forward).(*Prjn)
+	fmHid := net.ConnectLayers(hidLay, outLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	fmOut := net.ConnectLayers(outLay, hidLay, prjn.NewFull(), emer.Back).(*Prjn)
+	net.Defaults()
+	net.ApplyParams(ParamSets[0].Sheets["Network"], false)
+	net.RandSeed = 5
+	net.SlowInterval = 1
+	fmIn.Prune.On = true
+	fmIn.Prune.Thr = 2 // all synapses below
+	fmIn.Prune.NSlow = 1
+	fmIn.Prune.MaxPct = 0.25
+	fmIn.Com.STP.On = true
+	fmIn.Learn.Opt.Type = Adam
+	fmHid.Com.DelayVar = true
+	fmHid.Com.PFail = 0.1
+	hidLay.Act.Noise.On = true
+	hidLay.Act.Noise.Ge = 0.01
+	hidLay.Act.Noise.Gi = 0.01
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	fmOut.Lesion(0.25)
+	return net
+}
+
+// checkpointTrain trains given network on patterns [st, ed)
+func checkpointTrain(net *Network, ltime *Time, st, ed int) {
+	inLay := net.LayerByName("Input").(*Layer)
+	outLay := net.LayerByName("Output").(*Layer)
+	inpat := etensor.NewFloat32([]int{8, 1}, nil, nil)
+	for pi := st; pi < ed; pi++ {
+		for i := range inpat.Values {
+			inpat.Values[i] = float32((i + pi) % 2)
+		}
+		net.InitExt()
+		inLay.ApplyExt(inpat)
+		outLay.ApplyExt(InPats.SubSpace([]int{pi % 4}))
+		net.NewState()
+		ltime.NewState()
+		for cyc := 0; cyc < 200; cyc++ {
+			net.Cycle(ltime)
+			ltime.CycleInc()
+			if cyc == 149 {
+				net.MinusPhase(ltime)
+				ltime.NewPhase()
+			}
+		}
+		net.PlusPhase(ltime)
+		net.DWt()
+		net.WtFmDWt()
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	net := checkpointNet(t)
+	ltime := NewTime()
+	checkpointTrain(net, ltime, 0, 3)
+	fmIn := net.LayerByName("Hidden").(*Layer).RcvPrjns[0].(*Prjn)
+	if fmIn.PruneStats.Grown == 0 {
+		t.Fatalf("Checkpoint: no synapses regrown before saving\n")
+	}
+	var buf bytes.Buffer
+	if err := net.WriteCheckpoint(&buf, ltime); err != nil {
+		t.Fatal(err)
+	}
+	checkpointTrain(net, ltime, 3, 6)
+
+	rnet := checkpointNet(t)
+	rtime := NewTime()
+	checkpointTrain(rnet, rtime, 4, 5) // different state, to be overwritten
+	if err := rnet.ReadCheckpoint(&buf, rtime); err != nil {
+		t.Fatal(err)
+	}
+	checkpointTrain(rnet, rtime, 3, 6)
+
+	if *rtime != *ltime {
+		t.Errorf("Checkpoint: restored Time: %+v != %+v\n", *rtime, *ltime)
+	}
+	for li, l := range net.Layers {
+		ly := l.(*Layer)
+		rly := rnet.Layers[li].(*Layer)
+		for ni := range ly.Neurons {
+			if nrn, rnrn := &ly.Neurons[ni], &rly.Neurons[ni]; nrn.Act != rnrn.Act || nrn.Vm != rnrn.Vm || nrn.Ge != rnrn.Ge {
+				t.Errorf("Checkpoint: layer %s neuron %d Act, Vm, Ge: %g, %g, %g != %g, %g, %g\n", ly.Nm, ni, rnrn.Act, rnrn.Vm, rnrn.Ge, nrn.Act, nrn.Vm, nrn.Ge)
+				break
+			}
+		}
+		for pi, p := range ly.RcvPrjns {
+			pj := p.(*Prjn)
+			rpj := rly.RcvPrjns[pi].(*Prjn)
+			for si := range pj.Syns {
+				if pj.Syns[si] != rpj.Syns[si] {
+					t.Errorf("Checkpoint: prjn %s synapse %d: %v != %v\n", pj.Name(), si, rpj.Syns[si], pj.Syns[si])
+					break
+				}
+			}
+			for oi, ov := range SynOptVars {
+				vals, rvals := *pj.SynOptVals(oi), *rpj.SynOptVals(oi)
+				if len(vals) != len(rvals) {
+					t.Errorf("Checkpoint: prjn %s Syn%s len: %d != %d\n", pj.Name(), ov, len(rvals), len(vals))
+					continue
+				}
+				for si := range vals {
+					if vals[si] != rvals[si] {
+						t.Errorf("Checkpoint: prjn %s Syn%s %d: %g != %g\n", pj.Name(), ov, si, rvals[si], vals[si])
+						break
+					}
+				}
+			}
+			for ci := range pj.RConIdx {
+				if pj.RConIdx[ci] != rpj.RConIdx[ci] || pj.RSynIdx[ci] != rpj.RSynIdx[ci] {
+					t.Errorf("Checkpoint: prjn %s connection %d differs\n", pj.Name(), ci)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCheckpointValidate(t *testing.T) {
+	net := checkpointNet(t)
+	ltime := NewTime()
+	cp, err := net.Checkpoint(ltime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inLay := net.LayerByName("Input").(*Layer)
+	cp.Layers[0].Neurons[0].Act = 0.5
+	cp.Layers[2].Prjns[0].Syns = cp.Layers[2].Prjns[0].Syns[1:]
+	if err := net.SetCheckpoint(cp, ltime); err == nil {
+		t.Errorf("CheckpointValidate: no error for mismatched synapses\n")
+	}
+	if inLay.Neurons[0].Act == 0.5 {
+		t.Errorf("CheckpointValidate: layer state was restored despite error\n")
+	}
+}
+
+// ckptLayer has additional state that is saved in a Checkpoint
+type ckptLayer struct {
+	Layer
+	Cnt  int
+	Hist []float32
+}
+
+func (ly *ckptLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.Cnt, &ly.Hist}
+}
+
+func TestCheckpointExtra(t *testing.T) {
+	mknet := func() (*Network, *ckptLayer) {
+		net := NewNetwork("CkptExtraNet")
+		inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+		hidLay := &ckptLayer{}
+		net.AddLayerInit(hidLay, "Hidden", []int{4, 1}, emer.Hidden)
+		net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward)
+		net.Defaults()
+		if err := net.Build(); err != nil {
+			t.Fatal(err)
+		}
+		net.InitWts()
+		return net, hidLay
+	}
+	net, hidLay := mknet()
+	hidLay.Cnt = 3
+	hidLay.Hist = []float32{0.5, 0.25}
+	ltime := NewTime()
+	cp, err := net.Checkpoint(ltime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Layers[1].Extra) == 0 || len(cp.Layers[0].Extra) != 0 {
+		t.Errorf("CheckpointExtra: Extra state only expected for the Checkpointer layer\n")
+	}
+	rnet, rhidLay := mknet()
+	if err := rnet.SetCheckpoint(cp, ltime); err != nil {
+		t.Fatal(err)
+	}
+	if rhidLay.Cnt != 3 || len(rhidLay.Hist) != 2 || rhidLay.Hist[1] != 0.25 {
+		t.Errorf("CheckpointExtra: restored Cnt, Hist: %d, %v != 3, [0.5 0.25]\n", rhidLay.Cnt, rhidLay.Hist)
+	}
+
+	cp.Layers[0].Neurons[0].Act = 0.5
+	cp.Layers[1].Extra = nil
+	if err := rnet.SetCheckpoint(cp, ltime); err == nil {
+		t.Errorf("CheckpointExtra: no error for missing Extra state\n")
+	}
+	if rnet.LayerByName("Input").(*Layer).Neurons[0].Act == 0.5 {
+		t.Errorf("CheckpointExtra: layer state was restored despite error\n")
+	}
+}
+
+func TestSynOpt(t *testing.T) {
+	net := NewNetwork("SynOptNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+	pj := net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	for oi, ov := range SynOptVars {
+		if *pj.SynOptVals(oi) != nil {
+			t.Errorf("SynOpt: Syn%s allocated with feature off\n", ov)
+		}
+	}
+	if lr := pj.SynVal("Lrate", 1, 2); lr != 1 {
+		t.Errorf("SynOpt: unallocated Lrate: %g != 1\n", lr)
+	}
+	if err := pj.SetSynVal("Lrate", 1, 2, 0.5); err != nil {
+		t.Error(err)
+	}
+	if len(pj.SynLrate) != len(pj.Syns) || pj.SynVal("Lrate", 1, 2) != 0.5 || pj.SynVal("Lrate", 2, 1) != 1 {
+		t.Errorf("SynOpt: SetSynVal Lrate did not allocate SynLrate\n")
+	}
+	if err := pj.SetSynVal("M", 1, 2, 0.5); err == nil {
+		t.Errorf("SynOpt: no error setting unused M\n")
+	}
+
+	pj.Com.STP.On = true
+	pj.Com.Rel.On = true
+	pj.Learn.Opt.Type = Adam
+	pj.WtFmDWt()
+	for oi, ov := range SynOptVars {
+		if oi == synOptTr {
+			continue
+		}
+		if len(*pj.SynOptVals(oi)) != len(pj.Syns) {
+			t.Errorf("SynOpt: Syn%s not allocated with feature on\n", ov)
+		}
+	}
+	if pj.SynPr[0] != pj.Com.Rel.Init || pj.SynVal("Pr", 0, 0) != pj.Com.Rel.Init {
+		t.Errorf("SynOpt: SynPr not initialized: %g\n", pj.SynPr[0])
+	}
+	pj.Com.STP.On = false
+	pj.Com.Rel.On = false
+	pj.Learn.Opt.Type = NoOpt
+	net.InitWts()
+	for oi, ov := range SynOptVars {
+		if *pj.SynOptVals(oi) != nil {
+			t.Errorf("SynOpt: Syn%s not freed by InitWts with feature off\n", ov)
+		}
+	}
+}
+
+func TestDSWtDecay(t *testing.T) {
+	net := NewNetwork("DSWtNet")
+	inLay := net.AddLayer("Input", []int{4, 1}, emer.Input)
+	hidLay := net.AddLayer("Hidden", []int{4, 1}, emer.Hidden)
+	pj := net.ConnectLayers(inLay, hidLay, prjn.NewFull(), emer.Forward).(*Prjn)
+	net.Defaults()
+	pj.Learn.XCal.SubMean = 0
+	pj.Learn.Decay.On = true
+	pj.Learn.Decay.L1 = 0.1
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		sy.DWt = 0
+		sy.DSWt = 0
+	}
+	pj.WtFmDWt()
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		if sy.LWt > 0 && sy.DSWt >= 0 {
+			t.Errorf("DSWt: syn %d does not include the Decay change: %g\n", si, sy.DSWt)
+		}
+	}
+}
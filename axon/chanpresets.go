@@ -0,0 +1,72 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import "github.com/goki/ki/kit"
+
+// ChanPresets are presets for the kinetics and strength of the longer
+// time-scale NMDA and GABA-B channels (Act.NMDA, Act.GABAB), for different
+// types of cortical and thalamic neurons.  They set the parameters
+// coherently, and can then be further adjusted individually.
+type ChanPresets int32
+
+//go:generate stringer -type=ChanPresets
+
+var KiT_ChanPresets = kit.Enums.AddEnum(ChanPresetsN, kit.NotBitFlag, nil)
+
+func (ev ChanPresets) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *ChanPresets) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The channel presets
+const (
+	// ChanDefault is the standard default set of parameters,
+	// from Brunel & Wang (2001) and Sanders et al (2013)
+	ChanDefault ChanPresets = iota
+
+	// ChanL23 is for superficial layer 2/3 pyramidal neurons, which are the
+	// standard default parameters
+	ChanL23
+
+	// ChanL5 is for layer 5 intrinsic bursting (5IB) pyramidal neurons, with
+	// stronger NMDA to support dendritic plateau potentials, and weaker GABA-B
+	ChanL5
+
+	// ChanL6 is for layer 6 corticothalamic (CT) neurons, with slower and
+	// stronger NMDA to sustain the temporally-delayed context state
+	// between bursts.  See deep.CTLayer, which drives NMDA from its CtxtGe
+	// context input in addition to the standard GeRaw.
+	ChanL6
+
+	// ChanTRC is for thalamic relay cells (pulvinar, deep.TRCLayer), with
+	// strong NMDA, as found empirically in the deep_fsa model
+	ChanTRC
+
+	ChanPresetsN
+)
+
+// SetChanPreset sets the NMDA and GABA-B parameters according to given preset.
+// Update must be called after this.
+func (ac *ActParams) SetChanPreset(ps ChanPresets) {
+	ac.NMDA.Defaults()
+	ac.GABAB.Defaults()
+	switch ps {
+	case ChanL5:
+		ac.NMDA.Gbar = 0.25
+		ac.GABAB.Gbar = 0.15
+	case ChanL6:
+		ac.NMDA.Tau = 200
+		ac.NMDA.Gbar = 0.3
+	case ChanTRC:
+		ac.NMDA.Gbar = 0.6
+		ac.GABAB.Gbase = 0.1
+	}
+}
+
+// SetChanPreset sets the NMDA and GABA-B parameters for this layer according
+// to given preset, and updates the params.
+func (ly *Layer) SetChanPreset(ps ChanPresets) {
+	ly.Act.SetChanPreset(ps)
+	ly.Act.Update()
+}
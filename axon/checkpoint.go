@@ -0,0 +1,525 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/emer/emergent/ringidx"
+	"github.com/emer/etable/minmax"
+	"github.com/goki/gi/gi"
+)
+
+// CheckpointVersion is the current version of the checkpoint format
+const CheckpointVersion = 3
+
+// Checkpoint captures the full dynamic training state of a Network, so that
+// a long training run can be resumed exactly where it left off: all Neuron
+// and Synapse state (including DWt, LWt and the running averages), Pools,
+// layer ActAvg and CosDiff stats, projection conductance ring buffers and
+// GScale adaptation state, the optimizer step and learning rate schedule,
+// the states of the Layer and Prjn random number sources, lesioned synapses, per-synapse delays, the pruning state and
+// pruned connectivity, the network SlowAdapt counters, and the Time.
+// The additional state of derived Network, Layer and Prjn types is only
+// included if they implement the Checkpointer interface, and is otherwise
+// lost.  Parameters are not included -- they must be configured identically
+// by the sim prior to loading.  Resuming is bit-exact if Network.RandSeed
+// is set, so that each Layer and Prjn has its own *StateRand (the global
+// math/rand state cannot be saved), and all derived types with additional
+// state implement Checkpointer.
+type Checkpoint struct {
+	Version  int               `desc:"checkpoint format version"`
+	Network  string            `desc:"name of the network"`
+	Time     Time              `desc:"timing state at the point of saving"`
+	RandSeed int64             `desc:"Network.RandSeed"`
+	SlowCtr  int               `desc:"Network.SlowCtr"`
+	SlowTot  int               `desc:"Network.SlowTot"`
+	Epoch    int               `desc:"Network.Epoch"`
+	Layers   []LayerCheckpoint `desc:"state for each layer"`
+	Extra    []byte            `desc:"additional state of a derived Network type that implements Checkpointer"`
+}
+
+// Checkpointer is an optional interface for derived Network, Layer and Prjn
+// types that have additional training state beyond that of the base axon
+// types, so that it is saved in the Extra field of the Checkpoint,
+// LayerCheckpoint or PrjnCheckpoint, gob-encoded.
+type Checkpointer interface {
+	// CheckpointVals returns pointers to the additional state values to save
+	// and restore, in a fixed order.  Types that embed another Checkpointer
+	// type should append their own values to those of the embedded type.
+	CheckpointVals() []interface{}
+}
+
+// LayerCheckpoint is the checkpointed state of one Layer
+type LayerCheckpoint struct {
+	Name    string           `desc:"name of the layer"`
+	Neurons []Neuron         `desc:"all neuron state"`
+	Pools   []Pool           `desc:"pool state"`
+	ActAvg  ActAvgVals       `desc:"running-average activation levels"`
+	CosDiff CosDiffStats     `desc:"cosine difference stats"`
+	Rand    *RandState       `desc:"state of the layer Rand, if it is a *StateRand -- nil if using the global source"`
+	Prjns   []PrjnCheckpoint `desc:"state for each receiving projection, in RecvPrjns order"`
+	Extra   []byte           `desc:"additional state of a derived Layer type that implements Checkpointer"`
+}
+
+// PrjnCheckpoint is the checkpointed state of one Prjn
+type PrjnCheckpoint struct {
+	From   string      `desc:"name of the sending layer"`
+	Syns   []Synapse   `desc:"all synapse state"`
+	GScale GScaleVals  `desc:"conductance scaling and adaptation state"`
+	Gidx   ringidx.FIx `desc:"conductance ring buffer index"`
+	Gbuf   []float32   `desc:"conductance ring buffer"`
+	GiA    []float32   `desc:"per-projection GABA-A state"`
+	GiB    []float32   `desc:"per-projection GABA-B state"`
+	GiBx   []float32   `desc:"per-projection GABA-B drive state"`
+
+	OptT    int     `desc:"optimizer step counter (Learn.Opt.T)"`
+	LrSched float32 `desc:"scheduled learning rate multiplier (Learn.Lrate.Sched)"`
+	LrMod   float32 `desc:"learning rate modulation (Learn.Lrate.Mod)"`
+
+	STPCyc  int32   `desc:"short-term plasticity cycle counter"`
+	STPLast []int32 `desc:"short-term plasticity last spike cycle per sending neuron"`
+
+	Events    [][]SpikeEvent `desc:"queued spike events, for event-driven delivery"`
+	GatherSpk []float32      `desc:"sending neuron spikes not yet integrated, with Com.Gather"`
+
+	Rand    *RandState  `desc:"state of the projection Rand, if it is a *StateRand -- nil if using the global source"`
+	LesSyns []int32     `desc:"indexes of lesioned synapses"`
+	SynDel  []uint8     `desc:"per-synapse delays, with Com.DelayVar"`
+	SynOpt  [][]float32 `desc:"optional per-synapse state, in SynOptVars order -- empty if not in use"`
+
+	PruneCnt   []int32    `desc:"pruning counter per synapse -- if non-empty, the connectivity below is also saved, as pruning changes it"`
+	PruneStats PruneStats `desc:"pruning stats"`
+	RConIdx    []int32    `desc:"receiving connection indexes, if pruned"`
+	RSynIdx    []int32    `desc:"receiving synapse indexes, if pruned"`
+	SConN      []int32    `desc:"sending connection numbers, if pruned and not compact"`
+	SConIdxSt  []int32    `desc:"sending connection start indexes, if pruned and not compact"`
+	SConIdx    []int32    `desc:"sending connection indexes, if pruned"`
+	SFanOut    int32      `desc:"fixed fan-out of the compact sending layout, if pruned"`
+
+	SConNAvgMax minmax.AvgMax32 `desc:"sending connection number stats, if pruned"`
+
+	Extra []byte `desc:"additional state of a derived Prjn type that implements Checkpointer"`
+}
+
+// SaveCheckpoint saves the full training state of the network, along with
+// given Time state, to given file.  If filename has .gz extension, then file is
+// gzip compressed.  See Checkpoint for what is saved.
+func (nt *Network) SaveCheckpoint(filename gi.FileName, ltime *Time) error {
+	fp, err := os.Create(string(filename))
+	defer fp.Close()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	ext := filepath.Ext(string(filename))
+	if ext == ".gz" {
+		gzr := gzip.NewWriter(fp)
+		err = nt.WriteCheckpoint(gzr, ltime)
+		gzr.Close()
+	} else {
+		bw := bufio.NewWriter(fp)
+		err = nt.WriteCheckpoint(bw, ltime)
+		bw.Flush()
+	}
+	return err
+}
+
+// LoadCheckpoint loads the full training state of the network, and
+// the saved Time state into ltime, from given file.  If filename has .gz
+// extension, then file is gzip uncompressed.  Network must already be built
+// with the same structure and parameters as when it was saved.
+func (nt *Network) LoadCheckpoint(filename gi.FileName, ltime *Time) error {
+	fp, err := os.Open(string(filename))
+	defer fp.Close()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	ext := filepath.Ext(string(filename))
+	if ext == ".gz" {
+		gzr, err := gzip.NewReader(fp)
+		defer gzr.Close()
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		return nt.ReadCheckpoint(gzr, ltime)
+	} else {
+		return nt.ReadCheckpoint(bufio.NewReader(fp), ltime)
+	}
+}
+
+// WriteCheckpoint writes the full training state to given writer -- see SaveCheckpoint
+func (nt *Network) WriteCheckpoint(w io.Writer, ltime *Time) error {
+	cp, err := nt.Checkpoint(ltime)
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(w).Encode(cp)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// ReadCheckpoint reads the full training state from given reader -- see LoadCheckpoint
+func (nt *Network) ReadCheckpoint(r io.Reader, ltime *Time) error {
+	cp := &Checkpoint{}
+	err := gob.NewDecoder(r).Decode(cp)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return nt.SetCheckpoint(cp, ltime)
+}
+
+// Checkpoint returns a Checkpoint with a copy of the current training state,
+// including the additional state of any Checkpointer types
+func (nt *Network) Checkpoint(ltime *Time) (*Checkpoint, error) {
+	cp := &Checkpoint{Version: CheckpointVersion, Network: nt.Nm, RandSeed: nt.RandSeed, SlowCtr: nt.SlowCtr, SlowTot: nt.SlowTot, Epoch: nt.Epoch}
+	if ltime != nil {
+		cp.Time = *ltime
+	}
+	var err error
+	if cp.Extra, err = checkpointExtra(nt.EmerNet); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	cp.Layers = make([]LayerCheckpoint, len(nt.Layers))
+	for li, ly := range nt.Layers {
+		lc := &cp.Layers[li]
+		ly.(AxonLayer).AsAxon().Checkpoint(lc)
+		if lc.Extra, err = checkpointExtra(ly); err != nil {
+			err = fmt.Errorf("Layer %v: %v", ly.Name(), err)
+			log.Println(err)
+			return nil, err
+		}
+		for pi, p := range *ly.RecvPrjns() {
+			if lc.Prjns[pi].Extra, err = checkpointExtra(p); err != nil {
+				err = fmt.Errorf("Prjn %v: %v", p.Name(), err)
+				log.Println(err)
+				return nil, err
+			}
+		}
+	}
+	return cp, nil
+}
+
+// SetCheckpoint restores the training state from given Checkpoint,
+// copying the saved Time into ltime if non-nil.  The structure of all
+// layers, and the additional state of Checkpointer types, is validated
+// first, so nothing is changed if there is an error.
+func (nt *Network) SetCheckpoint(cp *Checkpoint, ltime *Time) error {
+	if cp.Version != CheckpointVersion {
+		err := fmt.Errorf("Network %v: unsupported checkpoint version: %d", nt.Nm, cp.Version)
+		log.Println(err)
+		return err
+	}
+	if len(cp.Layers) != len(nt.Layers) {
+		err := fmt.Errorf("Network %v: checkpoint has %d layers, network has %d", nt.Nm, len(cp.Layers), len(nt.Layers))
+		log.Println(err)
+		return err
+	}
+	var extras []*ckptExtra
+	ex, err := decodeCheckpointExtra(nt.EmerNet, cp.Extra)
+	if err != nil {
+		err = fmt.Errorf("Network %v: %v", nt.Nm, err)
+		log.Println(err)
+		return err
+	}
+	extras = append(extras, ex)
+	for li, ly := range nt.Layers {
+		lc := &cp.Layers[li]
+		if err := ly.(AxonLayer).AsAxon().CheckCheckpoint(lc); err != nil {
+			log.Println(err)
+			return err
+		}
+		ex, err := decodeCheckpointExtra(ly, lc.Extra)
+		if err != nil {
+			err = fmt.Errorf("Layer %v: %v", ly.Name(), err)
+			log.Println(err)
+			return err
+		}
+		extras = append(extras, ex)
+		for pi, p := range *ly.RecvPrjns() {
+			ex, err := decodeCheckpointExtra(p, lc.Prjns[pi].Extra)
+			if err != nil {
+				err = fmt.Errorf("Prjn %v: %v", p.Name(), err)
+				log.Println(err)
+				return err
+			}
+			extras = append(extras, ex)
+		}
+	}
+	for li, ly := range nt.Layers {
+		ly.(AxonLayer).AsAxon().SetCheckpoint(&cp.Layers[li])
+	}
+	for _, ex := range extras {
+		ex.set()
+	}
+	nt.RandSeed = cp.RandSeed
+	nt.SlowCtr = cp.SlowCtr
+	nt.SlowTot = cp.SlowTot
+	nt.Epoch = cp.Epoch
+	if ltime != nil {
+		*ltime = cp.Time
+	}
+	return nil
+}
+
+// Checkpoint copies the current training state of this layer
+// and its receiving projections into lc
+func (ly *Layer) Checkpoint(lc *LayerCheckpoint) {
+	lc.Name = ly.Nm
+	lc.Neurons = append([]Neuron(nil), ly.Neurons...)
+	lc.Pools = append([]Pool(nil), ly.Pools...)
+	lc.ActAvg = ly.ActAvg
+	lc.CosDiff = ly.CosDiff
+	lc.Rand = randState(ly.Rand)
+	lc.Prjns = make([]PrjnCheckpoint, len(ly.RcvPrjns))
+	for pi, p := range ly.RcvPrjns {
+		p.(AxonPrjn).AsAxon().Checkpoint(&lc.Prjns[pi])
+	}
+}
+
+// CheckCheckpoint returns an error if lc does not match the structure
+// of this layer and its receiving projections
+func (ly *Layer) CheckCheckpoint(lc *LayerCheckpoint) error {
+	if lc.Name != ly.Nm || len(lc.Neurons) != len(ly.Neurons) || len(lc.Pools) != len(ly.Pools) || len(lc.Prjns) != len(ly.RcvPrjns) {
+		return fmt.Errorf("Layer %v: checkpoint for layer %v does not match structure", ly.Nm, lc.Name)
+	}
+	for pi, p := range ly.RcvPrjns {
+		if err := p.(AxonPrjn).AsAxon().CheckCheckpoint(&lc.Prjns[pi]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCheckpoint restores the training state of this layer and its
+// receiving projections from lc, which must have been validated
+// with CheckCheckpoint.
+func (ly *Layer) SetCheckpoint(lc *LayerCheckpoint) {
+	for pi, p := range ly.RcvPrjns {
+		p.(AxonPrjn).AsAxon().SetCheckpoint(&lc.Prjns[pi])
+	}
+	copy(ly.Neurons, lc.Neurons)
+	copy(ly.Pools, lc.Pools)
+	ly.ActAvg = lc.ActAvg
+	ly.CosDiff = lc.CosDiff
+	if lc.Rand != nil {
+		ly.SetRand(stateRand(*lc.Rand))
+	}
+}
+
+// Checkpoint copies the current training state of this projection into pc
+func (pj *Prjn) Checkpoint(pc *PrjnCheckpoint) {
+	pc.From = pj.Send.Name()
+	pc.Syns = append([]Synapse(nil), pj.Syns...)
+	pc.GScale = pj.GScale
+	pc.Gidx = pj.Gidx
+	pc.Gbuf = append([]float32(nil), pj.Gbuf...)
+	pc.GiA = append([]float32(nil), pj.GiA...)
+	pc.GiB = append([]float32(nil), pj.GiB...)
+	pc.GiBx = append([]float32(nil), pj.GiBx...)
+	pc.OptT = pj.Learn.Opt.T
+	pc.LrSched = pj.Learn.Lrate.Sched
+	pc.LrMod = pj.Learn.Lrate.Mod
+	pc.STPCyc = pj.STPCyc
+	pc.STPLast = append([]int32(nil), pj.STPLast...)
+	pc.Events = make([][]SpikeEvent, len(pj.Events))
+	for i, evs := range pj.Events {
+		pc.Events[i] = append([]SpikeEvent(nil), evs...)
+	}
+	pc.GatherSpk = append([]float32(nil), pj.gspk...)
+	pc.Rand = randState(pj.Rand)
+	pc.LesSyns = append([]int32(nil), pj.LesSyns...)
+	pc.SynDel = append([]uint8(nil), pj.SynDel...)
+	pc.SynOpt = make([][]float32, len(SynOptVars))
+	for oi := range pc.SynOpt {
+		pc.SynOpt[oi] = append([]float32(nil), *pj.SynOptVals(oi)...)
+	}
+	pc.PruneStats = pj.PruneStats
+	if len(pj.PruneCnt) == 0 {
+		return
+	}
+	pc.PruneCnt = append([]int32(nil), pj.PruneCnt...)
+	pc.RConIdx = append([]int32(nil), pj.RConIdx...)
+	pc.RSynIdx = append([]int32(nil), pj.RSynIdx...)
+	pc.SConN = append([]int32(nil), pj.SConN...)
+	pc.SConIdxSt = append([]int32(nil), pj.SConIdxSt...)
+	pc.SConIdx = append([]int32(nil), pj.SConIdx...)
+	pc.SFanOut = pj.SFanOut
+	pc.SConNAvgMax = pj.SConNAvgMax
+}
+
+// CheckCheckpoint returns an error if pc does not match the structure
+// of this projection
+func (pj *Prjn) CheckCheckpoint(pc *PrjnCheckpoint) error {
+	ns := len(pj.Syns)
+	ok := pc.From == pj.Send.Name() && len(pc.Syns) == ns
+	ok = ok && (len(pc.SynDel) == 0 || len(pc.SynDel) == ns)
+	ok = ok && (len(pc.SynOpt) == 0 || len(pc.SynOpt) == len(SynOptVars))
+	for _, so := range pc.SynOpt {
+		ok = ok && (len(so) == 0 || len(so) == ns)
+	}
+	if ok && len(pc.PruneCnt) > 0 {
+		nsend := pj.Send.Shape().Len()
+		ok = len(pc.PruneCnt) == ns && len(pc.RConIdx) == len(pj.RConIdx) && len(pc.RSynIdx) == len(pj.RSynIdx) && len(pc.SConIdx) == len(pj.SConIdx)
+		if pc.SFanOut > 0 {
+			ok = ok && len(pc.SConN) == 0 && int(pc.SFanOut)*nsend == ns
+		} else {
+			ok = ok && len(pc.SConN) == nsend && len(pc.SConIdxSt) == nsend
+		}
+	}
+	if !ok {
+		return fmt.Errorf("Prjn %v: checkpoint for prjn from %v does not match structure", pj.Name(), pc.From)
+	}
+	return nil
+}
+
+// SetCheckpoint restores the training state of this projection from pc,
+// which must have been validated with CheckCheckpoint.  The saved
+// connectivity of a pruned projection is restored into new slices,
+// as the existing ones may be shared with other networks (see ShareCons).
+func (pj *Prjn) SetCheckpoint(pc *PrjnCheckpoint) {
+	copy(pj.Syns, pc.Syns)
+	pj.GScale = pc.GScale
+	pj.StaleWtSc()
+	pj.Gidx = pc.Gidx
+	pj.Gbuf = append(pj.Gbuf[:0], pc.Gbuf...)
+	pj.GiA = append(pj.GiA[:0], pc.GiA...)
+	pj.GiB = append(pj.GiB[:0], pc.GiB...)
+	pj.GiBx = append(pj.GiBx[:0], pc.GiBx...)
+	pj.Learn.Opt.SetStep(pc.OptT)
+	pj.Learn.Lrate.Sched = pc.LrSched
+	pj.Learn.Lrate.Mod = pc.LrMod
+	pj.Learn.Lrate.Update()
+	pj.STPCyc = pc.STPCyc
+	pj.STPLast = append(pj.STPLast[:0], pc.STPLast...)
+	if len(pc.Events) == len(pj.Events) {
+		for i, evs := range pc.Events {
+			pj.Events[i] = append(pj.Events[i][:0], evs...)
+		}
+	}
+	pj.gspk = append([]float32(nil), pc.GatherSpk...)
+	if pc.Rand != nil {
+		pj.Rand = stateRand(*pc.Rand)
+	}
+	pj.LesSyns = append([]int32(nil), pc.LesSyns...)
+	pj.SynDel = append([]uint8(nil), pc.SynDel...)
+	for oi := range SynOptVars {
+		var so []float32
+		if oi < len(pc.SynOpt) {
+			so = append(so, pc.SynOpt[oi]...)
+		}
+		*pj.SynOptVals(oi) = so
+	}
+	pj.PruneStats = pc.PruneStats
+	if len(pc.PruneCnt) == 0 {
+		pj.PruneCnt = nil
+		return
+	}
+	pj.PruneCnt = append([]int32(nil), pc.PruneCnt...)
+	pj.RConIdx = append([]int32(nil), pc.RConIdx...)
+	pj.RSynIdx = append([]int32(nil), pc.RSynIdx...)
+	pj.SConN = append([]int32(nil), pc.SConN...)
+	pj.SConIdxSt = append([]int32(nil), pc.SConIdxSt...)
+	pj.SConIdx = append([]int32(nil), pc.SConIdx...)
+	pj.SFanOut = pc.SFanOut
+	pj.SConNAvgMax = pc.SConNAvgMax
+	pj.sharedCons = false
+}
+
+// checkpointExtra returns the gob-encoded additional state of given object
+// if it is a Checkpointer, else nil
+func checkpointExtra(obj interface{}) ([]byte, error) {
+	ck, ok := obj.(Checkpointer)
+	if !ok {
+		return nil, nil
+	}
+	var b bytes.Buffer
+	enc := gob.NewEncoder(&b)
+	for _, v := range ck.CheckpointVals() {
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// ckptExtra holds the decoded additional state of a Checkpointer,
+// to be set after all of the checkpoint has been validated
+type ckptExtra struct {
+	vals []interface{} // pointers to the current values
+	dec  []interface{} // pointers to the decoded values
+}
+
+// set sets the current values to the decoded values
+func (ex *ckptExtra) set() {
+	if ex == nil {
+		return
+	}
+	for i, v := range ex.vals {
+		reflect.ValueOf(v).Elem().Set(reflect.ValueOf(ex.dec[i]).Elem())
+	}
+}
+
+// decodeCheckpointExtra decodes the additional state of given object from
+// data, if it is a Checkpointer, into new values that are set by
+// ckptExtra.set -- returns an error if data does not match the object
+func decodeCheckpointExtra(obj interface{}, data []byte) (*ckptExtra, error) {
+	ck, ok := obj.(Checkpointer)
+	if !ok {
+		if len(data) > 0 {
+			return nil, fmt.Errorf("checkpoint has additional state, but this type does not implement Checkpointer")
+		}
+		return nil, nil
+	}
+	ex := &ckptExtra{vals: ck.CheckpointVals()}
+	if len(ex.vals) == 0 {
+		return nil, nil
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("checkpoint has no additional state for this Checkpointer type")
+	}
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	ex.dec = make([]interface{}, len(ex.vals))
+	for i, v := range ex.vals {
+		nv := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+		if err := dec.Decode(nv); err != nil {
+			return nil, fmt.Errorf("decoding additional checkpoint state %d: %v", i, err)
+		}
+		ex.dec[i] = nv
+	}
+	return ex, nil
+}
+
+// randState returns the state of given Rand if it is a *StateRand, else nil
+func randState(rnd Rand) *RandState {
+	sr, ok := rnd.(*StateRand)
+	if !ok {
+		return nil
+	}
+	st := sr.State()
+	return &st
+}
+
+// stateRand returns a new *StateRand restored to given state
+func stateRand(st RandState) *StateRand {
+	sr := NewStateRand(st.Seed)
+	sr.SetState(st)
+	return sr
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+// ContLrnParams control the continuous learning mode, in which learning
+// occurs every Interval cycles from the temporal difference between the
+// fast (AvgS) and slow (AvgM) running averages of activity, without any
+// explicit minus / plus phase structure (MinusPhase, PlusPhase).
+// This is for continuous-time environments such as robotics, where inputs
+// and targets are applied as they occur and a discrete phase structure
+// is artificial.  The standard CHL learning rule already compares these
+// same traces (as AvgSLrn vs. AvgMLrn), so its learning signal reflects
+// whatever changed in the most recent few tens of cycles.
+// Because there is no MinusPhase to clamp Target layers, targets must be
+// driven as external inputs when they occur, e.g., via TargToExt.
+type ContLrnParams struct {
+	On       bool `desc:"learn continuously every Interval cycles within Network.Cycle, instead of only when DWt is called after the plus phase -- MinusPhase and PlusPhase should not be called"`
+	Interval int  `viewif:"On" def:"50" min:"1" desc:"number of cycles between learning steps -- should be on the order of the AvgM time constant (Learn.ActAvg.MTau), so that the slow trace reflects the state prior to the latest changes"`
+	WtUpdt   bool `viewif:"On" def:"true" desc:"update the weights (WtFmDWt) immediately after each learning step, so learning takes effect continuously -- otherwise DWt accumulates until WtFmDWt is called"`
+}
+
+func (cl *ContLrnParams) Defaults() {
+	cl.Interval = 50
+	cl.WtUpdt = true
+}
+
+func (cl *ContLrnParams) Update() {
+	if cl.Interval < 1 {
+		cl.Interval = 1
+	}
+}
+
+// LrnCycle returns true if learning should occur at the end of the given
+// cycle (ltime.CycleTot prior to incrementing)
+func (cl *ContLrnParams) LrnCycle(cycTot int) bool {
+	return cl.On && (cycTot+1)%cl.Interval == 0
+}
+
+// ContLrnActs updates the learning-related neuron variables for a
+// continuous learning step, in place of the phase updates: ActM and ActP
+// are set to the slow (AvgM) and fast (AvgS) traces, and the long-term
+// ActAvg and the RLrate are updated as in PlusPhase, followed by CosDiff.
+func (ly *Layer) ContLrnActs() {
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		nrn.ActM = nrn.AvgM
+		nrn.ActP = nrn.AvgS
+		nrn.ActDif = nrn.ActP - nrn.ActM
+		nrn.ActAvg += ly.Act.Dt.LongAvgDt * (nrn.ActM - nrn.ActAvg)
+		nrn.RLrate = ly.Learn.RLrate.RLrate(nrn.AvgS, nrn.AvgM)
+	}
+	ly.AxonLay.CosDiffFmActs()
+}
+
+// ContLrnStep performs a continuous learning step if ContLrn is On and this
+// is the last cycle of an Interval -- called at the end of Cycle
+func (nt *Network) ContLrnStep(ltime *Time) {
+	if nt.Inference || !nt.ContLrn.LrnCycle(ltime.CycleTot) {
+		return
+	}
+	for _, ly := range nt.Layers {
+		if ly.IsOff() {
+			continue
+		}
+		ly.(AxonLayer).AsAxon().ContLrnActs()
+	}
+	nt.DWt()
+	if nt.ContLrn.WtUpdt {
+		nt.WtFmDWt()
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+
+	"github.com/emer/emergent/prjn"
+	"github.com/goki/ki/kit"
+)
+
+// ConvPrjn is a convolutional projection with weight sharing (tied weights):
+// all receiving pools share a single learned kernel, so each synapse with the
+// same receiving unit position within its pool and the same sending unit
+// position relative to the tile of sending pools has the same weight.
+// The weight changes (DWt) are pooled (averaged) across all of the shared
+// instances of each kernel weight.  Requires a prjn.PoolTile pattern
+// (without Recip) between two 4D layers.  Synapses remain allocated per
+// connection for fast spike sending, with the kernel values applied to all.
+// Use with ConnectLayersPrjn passing a &ConvPrjn{}.
+type ConvPrjn struct {
+	Prjn            // access as .Prjn
+	KernN int       `inactive:"+" desc:"number of distinct kernel weights: receiving units per pool * sending units per tile"`
+	KIdx  []int32   `view:"-" desc:"kernel index for each synapse, in Syns order"`
+	KCnt  []int32   `view:"-" desc:"number of synapses sharing each kernel weight"`
+	KSum  []float32 `view:"-" desc:"temporary sum buffer per kernel weight"`
+	KSum2 []float32 `view:"-" desc:"second temporary sum buffer per kernel weight"`
+}
+
+var KiT_ConvPrjn = kit.Types.AddType(&ConvPrjn{}, PrjnProps)
+
+func (pj *ConvPrjn) UpdateParams() {
+	pj.Prjn.UpdateParams()
+}
+
+// Build calls Prjn.Build and then computes the kernel index of each synapse
+func (pj *ConvPrjn) Build() error {
+	if err := pj.Prjn.Build(); err != nil {
+		return err
+	}
+	return pj.BuildKernel()
+}
+
+// BuildKernel computes the kernel index for each synapse, based on the
+// prjn.PoolTile pattern geometry
+func (pj *ConvPrjn) BuildKernel() error {
+	pt, ok := pj.Pat.(*prjn.PoolTile)
+	if !ok || pt.Recip {
+		return fmt.Errorf("ConvPrjn %v: requires a non-Recip prjn.PoolTile pattern", pj.Name())
+	}
+	ssh := pj.Send.Shape()
+	rsh := pj.Recv.Shape()
+	if ssh.NumDims() != 4 || rsh.NumDims() != 4 {
+		return fmt.Errorf("ConvPrjn %v: requires 4D sending and receiving layers", pj.Name())
+	}
+	spy, spx := ssh.Dim(0), ssh.Dim(1)
+	snu := ssh.Dim(2) * ssh.Dim(3)
+	rpx := rsh.Dim(1)
+	rnu := rsh.Dim(2) * rsh.Dim(3)
+	tsz := pt.Size.Y * pt.Size.X * snu
+	pj.KernN = rnu * tsz
+	pj.KIdx = make([]int32, len(pj.Syns))
+	pj.KCnt = make([]int32, pj.KernN)
+	pj.KSum = make([]float32, pj.KernN)
+	pj.KSum2 = make([]float32, pj.KernN)
+	for ri := 0; ri < pj.Recv.Shape().Len(); ri++ {
+		rpi := ri / rnu
+		rui := ri % rnu
+		sy0 := pt.Start.Y + (rpi/rpx)*pt.Skip.Y
+		sx0 := pt.Start.X + (rpi%rpx)*pt.Skip.X
+		nc, st := pj.RConNSt(ri)
+		for ci := 0; ci < nc; ci++ {
+			si := int(pj.RConIdx[st+ci])
+			spi := si / snu
+			sui := si % snu
+			ky := spi/spx - sy0
+			kx := spi%spx - sx0
+			if pt.Wrap {
+				ky = ((ky % spy) + spy) % spy
+				kx = ((kx % spx) + spx) % spx
+			}
+			if ky < 0 || ky >= pt.Size.Y || kx < 0 || kx >= pt.Size.X {
+				return fmt.Errorf("ConvPrjn %v: sending unit %v outside of tile for receiving unit %v", pj.Name(), si, ri)
+			}
+			ki := rui*tsz + (ky*pt.Size.X+kx)*snu + sui
+			rsi := pj.RSynIdx[st+ci]
+			pj.KIdx[rsi] = int32(ki)
+			pj.KCnt[ki]++
+		}
+	}
+	return nil
+}
+
+// InitWts initializes weights as in Prjn.InitWts, and then sets all
+// shared instances of each kernel weight to the values of the first instance
+func (pj *ConvPrjn) InitWts() {
+	pj.Prjn.InitWts()
+	pj.TieFmFirst()
+}
+
+// DWt computes the weight change as in Prjn.DWt, and then replaces
+// the DWt of each synapse with the average across its kernel instances
+func (pj *ConvPrjn) DWt() {
+	if !pj.Learn.Learning() {
+		return
+	}
+	pj.Prjn.DWt()
+	for ki := range pj.KSum {
+		pj.KSum[ki] = 0
+	}
+	for si := range pj.Syns {
+		pj.KSum[pj.KIdx[si]] += pj.Syns[si].DWt
+	}
+	for si := range pj.Syns {
+		ki := pj.KIdx[si]
+		pj.Syns[si].DWt = pj.KSum[ki] / float32(pj.KCnt[ki])
+	}
+}
+
+// WtFmDWt updates the weights as in Prjn.WtFmDWt, and then re-ties the
+// kernel instances, which can diverge slightly due to receiver-specific
+// mean subtraction
+func (pj *ConvPrjn) WtFmDWt() {
+	pj.Prjn.WtFmDWt()
+	pj.TieWts()
+}
+
+// SlowAdapt does Prjn.SlowAdapt and then re-ties the kernel instances
+func (pj *ConvPrjn) SlowAdapt() {
+	pj.Prjn.SlowAdapt()
+	pj.TieWts()
+}
+
+// TieWts sets the SWt and LWt of all instances of each kernel weight
+// to their average, recomputing Wt
+func (pj *ConvPrjn) TieWts() {
+	for ki := range pj.KSum {
+		pj.KSum[ki] = 0
+		pj.KSum2[ki] = 0
+	}
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		ki := pj.KIdx[si]
+		pj.KSum[ki] += sy.SWt
+		pj.KSum2[ki] += sy.LWt
+	}
+	for ki, n := range pj.KCnt {
+		if n > 0 {
+			pj.KSum[ki] /= float32(n)
+			pj.KSum2[ki] /= float32(n)
+		}
+	}
+	pj.setKernel()
+}
+
+// TieFmFirst sets all instances of each kernel weight to the SWt and LWt
+// values of the first instance
+func (pj *ConvPrjn) TieFmFirst() {
+	for ki := range pj.KCnt {
+		pj.KSum[ki] = -1
+	}
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		ki := pj.KIdx[si]
+		if pj.KSum[ki] < 0 {
+			pj.KSum[ki] = sy.SWt
+			pj.KSum2[ki] = sy.LWt
+		}
+	}
+	pj.setKernel()
+}
+
+// setKernel sets all synapses from the SWt, LWt kernel values in KSum, KSum2
+func (pj *ConvPrjn) setKernel() {
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		ki := pj.KIdx[si]
+		sy.SWt = pj.KSum[ki]
+		sy.LWt = pj.KSum2[ki]
+		sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
+	}
+	pj.ZeroLesioned()
+	pj.StaleWtSc()
+}
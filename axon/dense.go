@@ -0,0 +1,129 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goki/mat32"
+)
+
+// DenseCoverage reports how a dense weight matrix mapped onto the sparse
+// synapses of a projection, in SetWtsFromDense
+type DenseCoverage struct {
+	NSyns   int `desc:"number of synapses in the projection"`
+	NSet    int `desc:"number of synapses set from the dense matrix"`
+	NMiss   int `desc:"number of synapses not set because the dense matrix did not cover them (out of range rows / columns) or had NaN values"`
+	NDrop   int `desc:"number of non-zero dense matrix values that were dropped because there is no corresponding synapse"`
+	NonZero int `desc:"total number of non-zero dense matrix values"`
+}
+
+// String returns a one-line summary of the coverage
+func (dc *DenseCoverage) String() string {
+	pct := float32(0)
+	if dc.NSyns > 0 {
+		pct = 100 * float32(dc.NSet) / float32(dc.NSyns)
+	}
+	return fmt.Sprintf("Set: %d / %d synapses (%.2f%%)\tMissing: %d\tDropped: %d / %d non-zero", dc.NSet, dc.NSyns, pct, dc.NMiss, dc.NDrop, dc.NonZero)
+}
+
+// SetWtsFromDense sets the weights of this projection from a dense matrix
+// indexed as mat[recv unit][send unit], e.g., from a model trained offline.
+// Absent connections are skipped, as are NaN values.  Each weight is
+// absorbed into SWt as far as allowed by SWt.Limit, with the remainder
+// in LWt, so that Wt reproduces the given value as closely as possible.
+// Returns the coverage of the mapping, and an error if the number
+// of rows does not match the number of receiving units.
+func (pj *Prjn) SetWtsFromDense(mat [][]float32) (DenseCoverage, error) {
+	dc := DenseCoverage{NSyns: len(pj.Syns)}
+	nr := pj.Recv.Shape().Len()
+	ns := pj.Send.Shape().Len()
+	for ri := range mat {
+		for _, v := range mat[ri] {
+			if v != 0 && !mat32.IsNaN(v) {
+				dc.NonZero++
+			}
+		}
+	}
+	var err error
+	if len(mat) != nr {
+		err = fmt.Errorf("Prjn %s SetWtsFromDense: number of rows: %d != number of recv units: %d", pj.Name(), len(mat), nr)
+	}
+	nzset := 0
+	for ri := 0; ri < nr; ri++ {
+		nc, st := pj.RConNSt(ri)
+		if ri >= len(mat) {
+			dc.NMiss += nc
+			continue
+		}
+		row := mat[ri]
+		if len(row) != ns && err == nil {
+			err = fmt.Errorf("Prjn %s SetWtsFromDense: row %d length: %d != number of send units: %d", pj.Name(), ri, len(row), ns)
+		}
+		for ci := 0; ci < nc; ci++ {
+			si := int(pj.RConIdx[st+ci])
+			if si >= len(row) || mat32.IsNaN(row[si]) {
+				dc.NMiss++
+				continue
+			}
+			wt := row[si]
+			if wt != 0 {
+				nzset++
+			}
+			sy := &pj.Syns[pj.RSynIdx[st+ci]]
+			sy.SWt = pj.SWt.ClipSWt(wt)
+			sy.LWt = pj.SWt.LWtFmWts(wt, sy.SWt)
+			sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
+			dc.NSet++
+		}
+	}
+	dc.NDrop = dc.NonZero - nzset
+	pj.ZeroLesioned()
+	pj.StaleWtSc()
+	return dc, err
+}
+
+// SetWtsFromDense sets the weights of projections from dense matrices
+// indexed as [recv unit][send unit], in a map keyed by projection name
+// (e.g., InputToHidden), optionally followed by .Wt as written by SaveNPZ.
+// Projections without a matrix are left unchanged.  Returns the coverage
+// for each projection that was set, and the first error encountered.
+func (nt *Network) SetWtsFromDense(mats map[string][][]float32) (map[string]DenseCoverage, error) {
+	cov := make(map[string]DenseCoverage)
+	var err error
+	for _, l := range nt.Layers {
+		for _, p := range *l.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			mat, ok := mats[pj.Name()]
+			if !ok {
+				mat, ok = mats[pj.Name()+".Wt"]
+			}
+			if !ok {
+				continue
+			}
+			dc, er := pj.SetWtsFromDense(mat)
+			if er != nil && err == nil {
+				err = er
+			}
+			cov[pj.Name()] = dc
+		}
+	}
+	return cov, err
+}
+
+// DenseCoverageReport returns a string with the coverage for each
+// projection, as returned by SetWtsFromDense
+func (nt *Network) DenseCoverageReport(cov map[string]DenseCoverage) string {
+	var b strings.Builder
+	for _, l := range nt.Layers {
+		for _, p := range *l.RecvPrjns() {
+			if dc, ok := cov[p.Name()]; ok {
+				fmt.Fprintf(&b, "Prjn: %s\t%s\n", p.Name(), dc.String())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"math"
+
+	"github.com/emer/etable/minmax"
+	"github.com/goki/mat32"
+)
+
+///////////////////////////////////////////////////////////////////////
+//  EIBalParams
+
+// EIBalParams control the tracking of the excitatory / inhibitory balance
+// of each neuron, as the proportion of excitatory conductance out of total
+// E + I conductance (each times its Gbar), integrated over the minus phase.
+// Optionally, a slow homeostatic controller adapts a per-neuron multiplier
+// on inhibition (GiGain) to drive the balance toward a target, at the
+// SlowAdapt interval.  E/I imbalance is typically the proximal cause
+// of runaway or silent dynamics, so the Layer EIBal stats are useful to
+// monitor in any case.
+type EIBalParams struct {
+	On        bool       `desc:"compute the per-neuron EIBal balance values and the layer-level EIBal stats"`
+	Tau       float32    `viewif:"On" def:"200" min:"1" desc:"time constant in cycles (msec) for integrating the per-neuron EIBal running average, over the minus phase only"`
+	Adapt     bool       `viewif:"On" desc:"adapt the per-neuron GiGain multiplier on inhibition to drive EIBal toward Targ, at the SlowAdapt interval"`
+	Targ      float32    `viewif:"Adapt" def:"0" min:"0" max:"1" desc:"target EIBal value -- 0 = use the current layer average, which homogenizes the balance across neurons without changing the overall level"`
+	Lrate     float32    `viewif:"Adapt" def:"0.1" desc:"rate of adaptation of GiGain as a function of EIBal - Targ"`
+	GainRange minmax.F32 `viewif:"Adapt" desc:"range of allowed GiGain values"`
+
+	Dt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / tau"`
+}
+
+func (eb *EIBalParams) Defaults() {
+	eb.On = false
+	eb.Tau = 200
+	eb.Adapt = false
+	eb.Targ = 0
+	eb.Lrate = 0.1
+	eb.GainRange.Set(0.5, 2)
+	eb.Update()
+}
+
+func (eb *EIBalParams) Update() {
+	eb.Dt = 1 / eb.Tau
+}
+
+// EIBal returns the excitatory proportion of total conductance,
+// given the Gbar-scaled excitatory and inhibitory conductances
+func (eb *EIBalParams) EIBal(ge, gi float32) float32 {
+	tot := ge + gi
+	if tot <= 0 {
+		return 0
+	}
+	return ge / tot
+}
+
+// GiGain updates the inhibitory gain from given balance and target values
+func (eb *EIBalParams) GiGain(gain *float32, bal, targ float32) {
+	*gain = eb.GainRange.ClipVal(*gain + eb.Lrate*(bal-targ))
+}
+
+// EIBalStats are layer-level statistics on the distribution of
+// per-neuron EIBal and GiGain values, computed by EIBalStatsFmNeurs
+type EIBalStats struct {
+	Avg     float32 `inactive:"+" desc:"average of neuron EIBal values"`
+	SD      float32 `inactive:"+" desc:"standard deviation of neuron EIBal values"`
+	Min     float32 `inactive:"+" desc:"minimum neuron EIBal value"`
+	Max     float32 `inactive:"+" desc:"maximum neuron EIBal value"`
+	GainAvg float32 `inactive:"+" desc:"average of neuron GiGain values"`
+	GainMin float32 `inactive:"+" desc:"minimum neuron GiGain value"`
+	GainMax float32 `inactive:"+" desc:"maximum neuron GiGain value"`
+}
+
+func (es *EIBalStats) Init() {
+	*es = EIBalStats{GainAvg: 1, GainMin: 1, GainMax: 1}
+}
+
+///////////////////////////////////////////////////////////////////////
+//  Layer methods
+
+// EIBalFmG integrates the per-neuron EIBal values from current Ge and Gi,
+// if Inhib.EIBal.On.  Called in ActFmG during the minus phase.
+func (ly *Layer) EIBalFmG(nrn *Neuron) {
+	eb := &ly.Inhib.EIBal
+	bal := eb.EIBal(nrn.Ge*ly.Act.Gbar.E, nrn.Gi*ly.Act.Gbar.I)
+	nrn.EIBal += eb.Dt * (bal - nrn.EIBal)
+}
+
+// EIBalStatsFmNeurs computes the layer-level EIBal stats from neurons.
+// Called in MinusPhase if Inhib.EIBal.On.
+func (ly *Layer) EIBalStatsFmNeurs() {
+	var sum, ssq, gsum float32
+	es := &ly.EIBal
+	es.Min = math.MaxFloat32
+	es.Max = -math.MaxFloat32
+	es.GainMin = math.MaxFloat32
+	es.GainMax = -math.MaxFloat32
+	n := 0
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		n++
+		sum += nrn.EIBal
+		ssq += nrn.EIBal * nrn.EIBal
+		gsum += nrn.GiGain
+		es.Min = mat32.Min(es.Min, nrn.EIBal)
+		es.Max = mat32.Max(es.Max, nrn.EIBal)
+		es.GainMin = mat32.Min(es.GainMin, nrn.GiGain)
+		es.GainMax = mat32.Max(es.GainMax, nrn.GiGain)
+	}
+	if n == 0 {
+		es.Init()
+		return
+	}
+	fn := float32(n)
+	es.Avg = sum / fn
+	vr := ssq/fn - es.Avg*es.Avg
+	if vr < 0 {
+		vr = 0
+	}
+	es.SD = mat32.Sqrt(vr)
+	es.GainAvg = gsum / fn
+}
+
+// AdaptEIBal adapts the per-neuron GiGain inhibitory multiplier toward the
+// target EIBal balance, if Inhib.EIBal.Adapt.  Called in SlowAdapt.
+func (ly *Layer) AdaptEIBal() {
+	eb := &ly.Inhib.EIBal
+	if !eb.On || !eb.Adapt || ly.AxonLay.IsInput() {
+		return
+	}
+	targ := eb.Targ
+	if targ == 0 {
+		targ = ly.EIBal.Avg
+	}
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		eb.GiGain(&nrn.GiGain, nrn.EIBal, targ)
+	}
+}
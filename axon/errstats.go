@@ -0,0 +1,224 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/mat32"
+)
+
+///////////////////////////////////////////////////////////////////////
+//  ErrStatsParams
+
+// ErrStatsParams control the computation of classification error statistics
+// for Target and Compare layers, reported in Layer ErrStats -- these go
+// beyond the SSE / CosDiff measures, for classification benchmarks.
+type ErrStatsParams struct {
+	On   bool    `def:"false" desc:"compute error statistics at the end of every minus phase, for Target and Compare layers"`
+	TopK int     `viewif:"On" def:"1" min:"1" desc:"a trial counts as correct if the target unit (maximum Targ) is among the TopK most active units (ActM) -- for localist outputs"`
+	Eps  float32 `viewif:"On" def:"0.0001" min:"0" desc:"minimum activation probability used in computing cross-entropy, to avoid infinite values for inactive target units"`
+}
+
+func (ep *ErrStatsParams) Defaults() {
+	ep.On = false
+	ep.TopK = 1
+	ep.Eps = 0.0001
+}
+
+func (ep *ErrStatsParams) Update() {
+	if ep.TopK < 1 {
+		ep.TopK = 1
+	}
+}
+
+// ErrStats are the classification error statistics for a Target or Compare
+// layer, for the last trial and accumulated over the current epoch,
+// computed from the minus-phase ActM vs. the Targ values -- see
+// Layer.ErrStats and EpcErrStats.
+type ErrStats struct {
+	CrossEnt float32 `inactive:"+" desc:"cross-entropy on the last trial, between the Targ and ActM values each normalized to sum to 1 across units"`
+	AUC      float32 `inactive:"+" desc:"area under the ROC curve on the last trial: probability that a unit with Targ > ErrThr has higher ActM than one without -- 1 = perfect ranking, .5 = chance"`
+	Targ     int     `inactive:"+" desc:"index of the target unit (maximum Targ) on the last trial"`
+	Pred     int     `inactive:"+" desc:"index of the predicted unit (maximum ActM) on the last trial"`
+	Correct  bool    `inactive:"+" desc:"true if the target unit was among the TopK most active units on the last trial"`
+
+	N           int       `inactive:"+" desc:"number of trials accumulated in the current epoch"`
+	CrossEntSum float64   `inactive:"+" desc:"sum of CrossEnt over trials"`
+	AUCSum      float64   `inactive:"+" desc:"sum of AUC over trials"`
+	NCorrect    int       `inactive:"+" desc:"number of Correct trials"`
+	NClass      int       `inactive:"+" desc:"number of classes (units) in the Confusion matrix"`
+	Confusion   []float32 `view:"-" desc:"accumulated confusion matrix counts, NClass x NClass, indexed by [Targ * NClass + Pred] -- see ConfusionTensor"`
+}
+
+// Reset resets the accumulated values, for a layer with given number of units
+func (es *ErrStats) Reset(nn int) {
+	es.N = 0
+	es.CrossEntSum = 0
+	es.AUCSum = 0
+	es.NCorrect = 0
+	es.NClass = nn
+	if len(es.Confusion) != nn*nn {
+		es.Confusion = make([]float32, nn*nn)
+		return
+	}
+	for i := range es.Confusion {
+		es.Confusion[i] = 0
+	}
+}
+
+// CrossEntAvg returns the average cross-entropy over accumulated trials
+func (es *ErrStats) CrossEntAvg() float64 {
+	if es.N == 0 {
+		return 0
+	}
+	return es.CrossEntSum / float64(es.N)
+}
+
+// AUCAvg returns the average AUC over accumulated trials
+func (es *ErrStats) AUCAvg() float64 {
+	if es.N == 0 {
+		return 0
+	}
+	return es.AUCSum / float64(es.N)
+}
+
+// TopKPct returns the proportion of accumulated trials that are Correct,
+// with the target among the TopK most active units
+func (es *ErrStats) TopKPct() float64 {
+	if es.N == 0 {
+		return 0
+	}
+	return float64(es.NCorrect) / float64(es.N)
+}
+
+// ConfusionTensor returns the accumulated confusion matrix as a
+// 2D [Targ, Pred] tensor of counts
+func (es *ErrStats) ConfusionTensor() *etensor.Float32 {
+	return etensor.NewFloat32Shape(etensor.NewShape([]int{es.NClass, es.NClass}, nil, []string{"Targ", "Pred"}), append([]float32(nil), es.Confusion...))
+}
+
+// CopyFrom copies the stats from given source, including the Confusion matrix
+func (es *ErrStats) CopyFrom(src *ErrStats) {
+	cf := es.Confusion
+	*es = *src
+	es.Confusion = append(cf[:0], src.Confusion...)
+}
+
+// String returns a one-line summary of the accumulated stats
+func (es *ErrStats) String() string {
+	return fmt.Sprintf("N: %d\tCrossEnt: %.4f\tAUC: %.3f\tTopK: %.3f", es.N, es.CrossEntAvg(), es.AUCAvg(), es.TopKPct())
+}
+
+///////////////////////////////////////////////////////////////////////
+//  Layer methods
+
+// ErrStatsFmActs computes the ErrStats for the current trial from the ActM
+// and Targ values, and accumulates them for the epoch.  Called at the end of
+// MinusPhase if ErrParams.On, for Target and Compare layers.
+func (ly *Layer) ErrStatsFmActs() {
+	es := &ly.ErrStats
+	nn := len(ly.Neurons)
+	if es.NClass != nn || len(es.Confusion) != nn*nn {
+		es.Reset(nn)
+	}
+	ep := &ly.ErrParams
+	thr := ly.Act.Clamp.ErrThr
+	var tsum, asum float32
+	tmax, amax := float32(-1), float32(-1)
+	es.Targ, es.Pred = -1, -1
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		tsum += mat32.Max(nrn.Targ, 0)
+		asum += mat32.Max(nrn.ActM, ep.Eps)
+		if nrn.Targ > tmax {
+			tmax = nrn.Targ
+			es.Targ = ni
+		}
+		if nrn.ActM > amax {
+			amax = nrn.ActM
+			es.Pred = ni
+		}
+	}
+	if es.Targ < 0 || tsum <= 0 {
+		return // no target
+	}
+	var ce float32
+	var pos, neg []float32
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		if nrn.Targ > 0 {
+			ce -= (nrn.Targ / tsum) * mat32.Log(mat32.Max(nrn.ActM, ep.Eps)/asum)
+		}
+		if nrn.Targ > thr {
+			pos = append(pos, nrn.ActM)
+		} else {
+			neg = append(neg, nrn.ActM)
+		}
+	}
+	es.CrossEnt = ce
+	es.AUC = rocAUC(pos, neg)
+
+	tact := ly.Neurons[es.Targ].ActM
+	nabove := 0 // number of units more active than the target unit
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if !nrn.IsOff() && nrn.ActM > tact {
+			nabove++
+		}
+	}
+	es.Correct = nabove < ep.TopK
+
+	es.N++
+	es.CrossEntSum += float64(es.CrossEnt)
+	es.AUCSum += float64(es.AUC)
+	if es.Correct {
+		es.NCorrect++
+	}
+	es.Confusion[es.Targ*nn+es.Pred]++
+}
+
+// rocAUC returns the area under the ROC curve for given positive and
+// negative scores, with ties counting 1/2 -- returns 1 if either is empty
+func rocAUC(pos, neg []float32) float32 {
+	if len(pos) == 0 || len(neg) == 0 {
+		return 1
+	}
+	sort.Slice(neg, func(i, j int) bool { return neg[i] < neg[j] })
+	var sum float32
+	for _, p := range pos {
+		lt := sort.Search(len(neg), func(i int) bool { return neg[i] >= p })
+		le := sort.Search(len(neg), func(i int) bool { return neg[i] > p })
+		sum += float32(lt) + 0.5*float32(le-lt)
+	}
+	return sum / float32(len(pos)*len(neg))
+}
+
+// ErrStatsReset resets the accumulated error stats
+func (ly *Layer) ErrStatsReset() {
+	ly.ErrStats.Reset(len(ly.Neurons))
+}
+
+// ErrStatsEpoch saves the current ErrStats into EpcErrStats and resets the
+// accumulated values.  Called in Network.EpochInc.
+func (ly *Layer) ErrStatsEpoch() {
+	ly.EpcErrStats.CopyFrom(&ly.ErrStats)
+	ly.ErrStatsReset()
+}
+
+// HasErrStats returns true if error stats are computed for this layer:
+// ErrParams.On and a Target or Compare layer
+func (ly *Layer) HasErrStats() bool {
+	return ly.ErrParams.On && (ly.Typ == emer.Target || ly.Typ == emer.Compare)
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"sort"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/mat32"
+)
+
+// EvolveParams are parameters for the evolutionary weight search of Evolver
+type EvolveParams struct {
+	Elite     int     `def:"2" min:"0" desc:"number of the fittest individuals carried over unchanged into the next generation"`
+	TournSize int     `def:"3" min:"1" desc:"number of individuals drawn at random for each tournament selection of a parent, of which the fittest is chosen"`
+	MutProb   float32 `def:"0.1" min:"0" max:"1" desc:"probability that each synapse is mutated in a new individual"`
+	MutSD     float32 `def:"0.05" min:"0" desc:"standard deviation of the Gaussian mutation added to the mutated weight values"`
+	MutLWt    bool    `def:"true" desc:"mutate the fast learning LWt values, clipped to 0..1"`
+	MutSWt    bool    `def:"false" desc:"mutate the structural SWt values, clipped to SWt.Limit"`
+}
+
+func (ep *EvolveParams) Defaults() {
+	ep.Elite = 2
+	ep.TournSize = 3
+	ep.MutProb = 0.1
+	ep.MutSD = 0.05
+	ep.MutLWt = true
+	ep.MutSWt = false
+}
+
+func (ep *EvolveParams) Update() {
+}
+
+// EvolveFitness is a function that returns the fitness of given network
+// at index b in the batch, where higher values are better.  It is
+// typically computed by running the network on a set of inputs or a
+// control task.  It may be called in parallel for different networks
+// if the NetworkBatch is Parallel.
+type EvolveFitness func(b int, net *Network) float64
+
+// Evolver is an evolutionary optimization driver for network weights,
+// as an alternative to the standard error-driven learning, e.g., for small
+// control tasks and for learning-free baselines.  The population is a
+// NetworkBatch, whose networks are evaluated by a user Fitness function,
+// and each new generation is formed from the Elite fittest networks plus
+// mutated copies of parents chosen by tournament selection.  The networks
+// are put in InferenceMode so that no learning takes place.
+type Evolver struct {
+	Batch   *NetworkBatch `desc:"the population of networks"`
+	Params  EvolveParams  `view:"inline" desc:"evolutionary search parameters"`
+	Fitness EvolveFitness `view:"-" json:"-" xml:"-" desc:"user fitness function -- higher is better"`
+	Gen     int           `inactive:"+" desc:"current generation"`
+	Fit     []float64     `inactive:"+" desc:"fitness of each network in the last Evaluate"`
+	Rank    []int         `inactive:"+" desc:"indexes of the networks sorted by descending fitness in the last Evaluate"`
+	Best    float64       `inactive:"+" desc:"best fitness in the last Evaluate"`
+	Log     *etable.Table `desc:"log of the best, average and worst fitness per generation"`
+	Rand    Rand          `view:"-" json:"-" xml:"-" desc:"random number source for selection and mutation -- nil = global math/rand"`
+
+	syns [][][]Synapse // saved synapse state per network, per prjn, for Select
+}
+
+// NewEvolver returns a new Evolver for given population and fitness function,
+// with default params
+func NewEvolver(nb *NetworkBatch, fitness EvolveFitness) *Evolver {
+	ev := &Evolver{Batch: nb, Fitness: fitness}
+	ev.Params.Defaults()
+	ev.Log = &etable.Table{}
+	ev.ConfigLog(ev.Log)
+	return ev
+}
+
+// ConfigLog configures given table for the generation log
+func (ev *Evolver) ConfigLog(dt *etable.Table) {
+	dt.SetMetaData("name", "EvolveLog")
+	sch := etable.Schema{
+		{"Gen", etensor.INT64, nil, nil},
+		{"Best", etensor.FLOAT64, nil, nil},
+		{"Avg", etensor.FLOAT64, nil, nil},
+		{"Worst", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// Init initializes the weights of all networks to random initial values,
+// turns on InferenceMode, and resets the generation counter and log
+func (ev *Evolver) Init() {
+	ev.Batch.InitWts()
+	for _, net := range ev.Batch.Nets {
+		net.InferenceMode(true)
+	}
+	ev.Gen = 0
+	ev.Best = 0
+	ev.Log.SetNumRows(0)
+}
+
+// Evaluate computes the Fitness of each network, and the Rank
+func (ev *Evolver) Evaluate() {
+	nn := ev.Batch.Len()
+	if len(ev.Fit) != nn {
+		ev.Fit = make([]float64, nn)
+	}
+	ev.Batch.Run(func(b int, net *Network) {
+		ev.Fit[b] = ev.Fitness(b, net)
+	})
+	ev.Rank = make([]int, nn)
+	for i := range ev.Rank {
+		ev.Rank[i] = i
+	}
+	sort.SliceStable(ev.Rank, func(i, j int) bool {
+		return ev.Fit[ev.Rank[i]] > ev.Fit[ev.Rank[j]]
+	})
+	if nn > 0 {
+		ev.Best = ev.Fit[ev.Rank[0]]
+	}
+}
+
+// LogGen records the fitness stats of the last Evaluate in the Log
+func (ev *Evolver) LogGen() {
+	nn := len(ev.Fit)
+	if nn == 0 {
+		return
+	}
+	avg := 0.0
+	for _, f := range ev.Fit {
+		avg += f
+	}
+	avg /= float64(nn)
+	dt := ev.Log
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+	dt.SetCellFloat("Gen", row, float64(ev.Gen))
+	dt.SetCellFloat("Best", row, ev.Best)
+	dt.SetCellFloat("Avg", row, avg)
+	dt.SetCellFloat("Worst", row, ev.Fit[ev.Rank[nn-1]])
+}
+
+// Tournament returns the index of a parent chosen by tournament selection
+// among TournSize random individuals, based on the last Evaluate
+func (ev *Evolver) Tournament() int {
+	rnd := RandOrGlobal(ev.Rand)
+	nn := len(ev.Fit)
+	best := rnd.Intn(nn)
+	for i := 1; i < ev.Params.TournSize; i++ {
+		c := rnd.Intn(nn)
+		if ev.Fit[c] > ev.Fit[best] {
+			best = c
+		}
+	}
+	return best
+}
+
+// Select forms the next generation from the last Evaluate: network i gets
+// the weights of the i-th fittest network for i < Elite, and otherwise a
+// mutated copy of a parent chosen by Tournament.  After Select, network 0
+// has the weights of the best network of the last Evaluate (if Elite > 0).
+func (ev *Evolver) Select() {
+	nets := ev.Batch.Nets
+	if len(ev.syns) != len(nets) {
+		ev.syns = make([][][]Synapse, len(nets))
+	}
+	for b, net := range nets {
+		ev.syns[b] = ev.saveSyns(net, ev.syns[b])
+	}
+	for b, net := range nets {
+		var src int
+		if b < ev.Params.Elite {
+			src = ev.Rank[b]
+		} else {
+			src = ev.Tournament()
+		}
+		ev.setSyns(net, ev.syns[src], nets[src], b >= ev.Params.Elite)
+	}
+}
+
+// Step runs one generation: Evaluate, LogGen, and Select
+func (ev *Evolver) Step() {
+	ev.Evaluate()
+	ev.LogGen()
+	ev.Select()
+	ev.Gen++
+}
+
+// Evolve runs given number of generations, followed by a final Evaluate,
+// so that the Rank and Best reflect the final population.
+// Returns the index of the best network.
+func (ev *Evolver) Evolve(ngen int) int {
+	for g := 0; g < ngen; g++ {
+		ev.Step()
+	}
+	ev.Evaluate()
+	ev.LogGen()
+	return ev.Rank[0]
+}
+
+// saveSyns copies the synapse state of all projections of given network
+// into given storage, reusing it if possible
+func (ev *Evolver) saveSyns(net *Network, syns [][]Synapse) [][]Synapse {
+	syns = syns[:0]
+	for _, ly := range net.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			pi := len(syns)
+			if pi < cap(syns) {
+				syns = syns[:pi+1]
+				syns[pi] = append(syns[pi][:0], pj.Syns...)
+			} else {
+				syns = append(syns, append([]Synapse(nil), pj.Syns...))
+			}
+		}
+	}
+	return syns
+}
+
+// setSyns sets the synapse state of all projections of given network from
+// given saved state, and GScale from given source network, mutating the
+// weights if mutate is true
+func (ev *Evolver) setSyns(net *Network, syns [][]Synapse, src *Network, mutate bool) {
+	rnd := RandOrGlobal(ev.Rand)
+	pi := 0
+	for li, ly := range net.Layers {
+		spjs := *src.Layers[li].RecvPrjns()
+		for pji, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			copy(pj.Syns, syns[pi])
+			pi++
+			pj.GScale = spjs[pji].(AxonPrjn).AsAxon().GScale
+			if mutate {
+				ev.Mutate(pj, rnd)
+			}
+			pj.ZeroLesioned()
+			pj.StaleWtSc()
+		}
+	}
+}
+
+// Mutate mutates the weights of given projection according to Params,
+// recomputing Wt from the mutated SWt and LWt values
+func (ev *Evolver) Mutate(pj *Prjn, rnd Rand) {
+	ep := &ev.Params
+	for si := range pj.Syns {
+		if rnd.Float32() >= ep.MutProb {
+			continue
+		}
+		sy := &pj.Syns[si]
+		if ep.MutLWt {
+			sy.LWt = mat32.Clamp(sy.LWt+ep.MutSD*float32(rnd.NormFloat64()), 0, 1)
+		}
+		if ep.MutSWt {
+			sy.SWt = pj.SWt.ClipSWt(sy.SWt + ep.MutSD*float32(rnd.NormFloat64()))
+		}
+		sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
+	}
+}
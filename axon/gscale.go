@@ -0,0 +1,157 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// GScaleTarg returns the target AvgMax conductance for this projection,
+// and the current running-average max conductance of the receiving layer
+// that is compared against it for PrjnScale adaptation
+func (pj *Prjn) GScaleTarg() (targ, avgMax float32) {
+	rlay := pj.Recv.(AxonLayer).AsAxon()
+	if pj.IsInhib() {
+		return rlay.Act.GTarg.GiMax, rlay.ActAvg.AvgMaxGiM
+	}
+	return rlay.Act.GTarg.GeMax, rlay.ActAvg.AvgMaxGeM
+}
+
+// GScaleRatio returns the ratio of the adapted GScale.Scale to the
+// original GScale.Orig, or 1 if Orig is 0
+func (pj *Prjn) GScaleRatio() float32 {
+	if pj.GScale.Orig == 0 {
+		return 1
+	}
+	return pj.GScale.Scale / pj.GScale.Orig
+}
+
+// GScaleAtLimit returns true if the adapted GScale.Scale is at the
+// PrjnScale MinRatio or MaxRatio limit, which typically indicates that
+// the adaptation is unable to reach its target
+func (pj *Prjn) GScaleAtLimit() bool {
+	if !pj.PrjnScale.Adapt || pj.GScale.Orig == 0 {
+		return false
+	}
+	r := pj.GScaleRatio()
+	ps := &pj.PrjnScale
+	return r <= ps.MinRatio || (ps.MaxRatio > 0 && r >= ps.MaxRatio)
+}
+
+// SetGScaleAdapt sets PrjnScale.Adapt for all projections whose Class
+// (as in params selectors) includes given class name, or all projections
+// if cls is empty, returning the number of projections set
+func (nt *Network) SetGScaleAdapt(cls string, on bool) int {
+	n := 0
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			if cls != "" && !hasClass(pj.Class(), cls) {
+				continue
+			}
+			pj.PrjnScale.Adapt = on
+			n++
+		}
+	}
+	return n
+}
+
+// GScaleTable returns an etable.Table with the conductance scaling state of
+// each projection in the network (one row per projection): the original
+// and adapted Scale and their Ratio, whether adaptation is on (Adapt) and
+// whether Scale is at its MinRatio / MaxRatio limit (AtLimit), and the
+// target vs. actual running-average max conductance driving adaptation.
+// If dt is non-nil, it is reconfigured and reused.
+func (nt *Network) GScaleTable(dt *etable.Table) *etable.Table {
+	if dt == nil {
+		dt = &etable.Table{}
+	}
+	sch := etable.Schema{
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Prjn", etensor.STRING, nil, nil},
+		{"Adapt", etensor.INT64, nil, nil},
+		{"Orig", etensor.FLOAT32, nil, nil},
+		{"Scale", etensor.FLOAT32, nil, nil},
+		{"Ratio", etensor.FLOAT32, nil, nil},
+		{"AtLimit", etensor.INT64, nil, nil},
+		{"Rel", etensor.FLOAT32, nil, nil},
+		{"AvgMaxRel", etensor.FLOAT32, nil, nil},
+		{"Targ", etensor.FLOAT32, nil, nil},
+		{"AvgMax", etensor.FLOAT32, nil, nil},
+		{"Err", etensor.FLOAT32, nil, nil},
+	}
+	dt.SetMetaData("name", nt.Nm+"GScale")
+	dt.SetFromSchema(sch, 0)
+	for _, l := range nt.Layers {
+		if l.IsOff() {
+			continue
+		}
+		for _, p := range *l.RecvPrjns() {
+			if p.IsOff() {
+				continue
+			}
+			pj := p.(AxonPrjn).AsAxon()
+			targ, avgMax := pj.GScaleTarg()
+			adapt, atLim := 0.0, 0.0
+			if pj.PrjnScale.AdaptOn(nt.Epoch) {
+				adapt = 1
+			}
+			if pj.GScaleAtLimit() {
+				atLim = 1
+			}
+			row := dt.Rows
+			dt.SetNumRows(row + 1)
+			dt.SetCellFloat("Epoch", row, float64(nt.Epoch))
+			dt.SetCellString("Prjn", row, pj.Name())
+			dt.SetCellFloat("Adapt", row, adapt)
+			dt.SetCellFloat("Orig", row, float64(pj.GScale.Orig))
+			dt.SetCellFloat("Scale", row, float64(pj.GScale.Scale))
+			dt.SetCellFloat("Ratio", row, float64(pj.GScaleRatio()))
+			dt.SetCellFloat("AtLimit", row, atLim)
+			dt.SetCellFloat("Rel", row, float64(pj.GScale.Rel))
+			dt.SetCellFloat("AvgMaxRel", row, float64(pj.GScale.AvgMaxRel))
+			dt.SetCellFloat("Targ", row, float64(targ))
+			dt.SetCellFloat("AvgMax", row, float64(avgMax))
+			dt.SetCellFloat("Err", row, float64(pj.GScale.Err))
+		}
+	}
+	return dt
+}
+
+// GScaleReport returns a report of the original vs. adapted GScale.Scale
+// for each projection in the network, flagging those whose adaptation is
+// frozen (per PrjnScale.Stop) or at the MinRatio / MaxRatio limit --
+// runaway adaptation is otherwise easy to miss in long runs.
+func (nt *Network) GScaleReport() string {
+	var b strings.Builder
+	for _, l := range nt.Layers {
+		if l.IsOff() {
+			continue
+		}
+		for _, p := range *l.RecvPrjns() {
+			if p.IsOff() {
+				continue
+			}
+			pj := p.(AxonPrjn).AsAxon()
+			targ, avgMax := pj.GScaleTarg()
+			fmt.Fprintf(&b, "%-30s Orig: %8.4g  Scale: %8.4g  Ratio: %6.3f  Targ: %6.3f  AvgMax: %6.3f", pj.Name(), pj.GScale.Orig, pj.GScale.Scale, pj.GScaleRatio(), targ, avgMax)
+			switch {
+			case !pj.PrjnScale.Adapt:
+				b.WriteString("  (fixed)")
+			case !pj.PrjnScale.AdaptOn(nt.Epoch):
+				b.WriteString("  (frozen)")
+			}
+			if pj.GScaleAtLimit() {
+				b.WriteString("  AT LIMIT")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -21,7 +21,7 @@ func (pj *HebbPrjn) UpdateParams() {
 
 // DWt computes the hebbian weight change
 func (pj *HebbPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(AxonLayer).AsAxon()
@@ -32,8 +32,7 @@ func (pj *HebbPrjn) DWt() {
 		if sn.AvgS < pj.Learn.XCal.LrnThr && sn.AvgM < pj.Learn.XCal.LrnThr {
 			continue
 		}
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 		for ci := range syns {
@@ -44,7 +43,7 @@ func (pj *HebbPrjn) DWt() {
 			sact := sn.AvgSLrn
 			wt := sy.LWt
 			dwt := ract * (pj.IncGain*sact*(1-wt) - (1-sact)*wt)
-			sy.DWt += lr * dwt
+			sy.DWt += pj.SynLr(st+ci) * lr * dwt
 		}
 	}
 }
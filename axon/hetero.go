@@ -0,0 +1,147 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/goki/mat32"
+)
+
+// HeteroParams control heterogeneity of intrinsic neuron parameters, where
+// each neuron gets its own deviation of the leak conductance, spiking
+// threshold, and KNa adaptation strength, sampled from a distribution at
+// InitWts and stored in the neuron (HetGl, HetThr, HetKNa).  This models
+// biological heterogeneity and can be used to test model robustness.
+// Values are sampled prior to InitActs, so they remain fixed across
+// subsequent InitActs calls.
+type HeteroParams struct {
+	On   bool       `desc:"sample per-neuron deviations of intrinsic parameters"`
+	Dist NoiseDists `viewif:"On" desc:"distribution of the deviations"`
+	Gl   float32    `viewif:"On" def:"0.1" min:"0" desc:"standard deviation (Gaussian) or half-range (Uniform) of the proportional deviation of the leak conductance Gbar.L -- e.g., .1 = 10% variation"`
+	Thr  float32    `viewif:"On" def:"0.02" min:"0" desc:"standard deviation (Gaussian) or half-range (Uniform) of the additive deviation of the spiking threshold, in normalized Vm units"`
+	KNa  float32    `viewif:"On" def:"0.1" min:"0" desc:"standard deviation (Gaussian) or half-range (Uniform) of the proportional deviation of the KNa adaptation conductances"`
+
+	rnd Rand // random number source, set by Layer.SetRand -- nil = global
+}
+
+func (hp *HeteroParams) Defaults() {
+	hp.Dist = NoiseGaussian
+	hp.Gl = 0.1
+	hp.Thr = 0.02
+	hp.KNa = 0.1
+}
+
+func (hp *HeteroParams) Update() {
+}
+
+// Init samples the heterogeneity deviations for given neuron,
+// or sets them to 0 if not On.  Proportional deviations are
+// limited to be > -1 so the resulting values remain positive.
+func (hp *HeteroParams) Init(nrn *Neuron) {
+	if !hp.On {
+		nrn.HetGl = 0
+		nrn.HetThr = 0
+		nrn.HetKNa = 0
+		return
+	}
+	nrn.HetGl = mat32.Max(NoiseVal(hp.Dist, 0, hp.Gl, hp.rnd), -0.9)
+	nrn.HetThr = NoiseVal(hp.Dist, 0, hp.Thr, hp.rnd)
+	nrn.HetKNa = mat32.Max(NoiseVal(hp.Dist, 0, hp.KNa, hp.rnd), -1)
+}
+
+// InitHetero samples the per-neuron intrinsic parameter deviations
+// according to Act.Hetero -- called in InitWts.
+func (ly *Layer) InitHetero() {
+	for ni := range ly.Neurons {
+		ly.Act.Hetero.Init(&ly.Neurons[ni])
+	}
+}
+
+// HeteroDist summarizes the realized distribution of a per-neuron value
+type HeteroDist struct {
+	Mean float32 `desc:"mean value"`
+	SD   float32 `desc:"standard deviation"`
+	Min  float32 `desc:"minimum value"`
+	Max  float32 `desc:"maximum value"`
+}
+
+// String returns a one-line summary
+func (hd *HeteroDist) String() string {
+	return fmt.Sprintf("Mean: %.4g  SD: %.4g  Min: %.4g  Max: %.4g", hd.Mean, hd.SD, hd.Min, hd.Max)
+}
+
+// HeteroStats are the realized distributions of the per-neuron intrinsic
+// parameters in a layer, as effective values (including the base parameters)
+type HeteroStats struct {
+	Gl  HeteroDist `desc:"leak conductance: Gbar.L * (1 + HetGl)"`
+	Thr HeteroDist `desc:"spiking threshold: Spike.Thr + HetThr"`
+	KNa HeteroDist `desc:"KNa adaptation multiplier: 1 + HetKNa"`
+}
+
+// HeteroStats returns the realized distributions of per-neuron intrinsic
+// parameters for this layer
+func (ly *Layer) HeteroStats() HeteroStats {
+	var hs HeteroStats
+	var gl, thr, kna []float32
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		gl = append(gl, ly.Act.Gbar.L*(1+nrn.HetGl))
+		thr = append(thr, ly.Act.Spike.Thr+nrn.HetThr)
+		kna = append(kna, 1+nrn.HetKNa)
+	}
+	heteroDist(&hs.Gl, gl)
+	heteroDist(&hs.Thr, thr)
+	heteroDist(&hs.KNa, kna)
+	return hs
+}
+
+// heteroDist computes the distribution stats for given values
+func heteroDist(hd *HeteroDist, vals []float32) {
+	*hd = HeteroDist{}
+	n := len(vals)
+	if n == 0 {
+		return
+	}
+	hd.Min = math.MaxFloat32
+	hd.Max = -math.MaxFloat32
+	var sum, ssq float64
+	for _, v := range vals {
+		sum += float64(v)
+		ssq += float64(v) * float64(v)
+		hd.Min = mat32.Min(hd.Min, v)
+		hd.Max = mat32.Max(hd.Max, v)
+	}
+	mean := sum / float64(n)
+	vr := ssq/float64(n) - mean*mean
+	if vr < 0 {
+		vr = 0
+	}
+	hd.Mean = float32(mean)
+	hd.SD = float32(math.Sqrt(vr))
+}
+
+// HeteroReport returns a report of the realized distributions of per-neuron
+// intrinsic parameters for all layers with Act.Hetero.On
+func (nt *Network) HeteroReport() string {
+	var b strings.Builder
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		if ly.IsOff() || !ly.Act.Hetero.On {
+			continue
+		}
+		hs := ly.HeteroStats()
+		fmt.Fprintf(&b, "Layer: %s\n", ly.Nm)
+		fmt.Fprintf(&b, "\tGl:  %s\n", hs.Gl.String())
+		fmt.Fprintf(&b, "\tThr: %s\n", hs.Thr.String())
+		fmt.Fprintf(&b, "\tKNa: %s\n", hs.KNa.String())
+	}
+	return b.String()
+}
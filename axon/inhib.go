@@ -5,6 +5,8 @@
 package axon
 
 import (
+	"sort"
+
 	"github.com/emer/axon/fffb"
 	"github.com/goki/mat32"
 )
@@ -20,6 +22,8 @@ type InhibParams struct {
 	Topo   TopoInhibParams `view:"inline" desc:"topographic inhibition computed from a gaussian-weighted circle -- over pools for 4D layers, or units for 2D layers"`
 	Self   SelfInhibParams `view:"inline" desc:"neuron self-inhibition parameters -- can be beneficial for producing more graded, linear response -- not typically used in cortical networks"`
 	ActAvg ActAvgParams    `view:"inline" desc:"layer-level and pool-level average activation initial values and updating / adaptation thereof -- initial values help determine initial scaling factors."`
+	EIBal  EIBalParams     `view:"inline" desc:"tracking of per-neuron excitatory / inhibitory conductance balance, with optional slow adaptation of per-neuron inhibitory gain toward a target balance"`
+	KWTA   KWTAParams      `view:"inline" desc:"k-winners-take-all inhibition, which replaces the FFFB inhibition when On, for precise control over sparsity"`
 }
 
 func (ip *InhibParams) Update() {
@@ -29,6 +33,8 @@ func (ip *InhibParams) Update() {
 	ip.Topo.Update()
 	ip.Self.Update()
 	ip.ActAvg.Update()
+	ip.EIBal.Update()
+	ip.KWTA.Update()
 }
 
 func (ip *InhibParams) Defaults() {
@@ -38,10 +44,67 @@ func (ip *InhibParams) Defaults() {
 	ip.Topo.Defaults()
 	ip.Self.Defaults()
 	ip.ActAvg.Defaults()
+	ip.EIBal.Defaults()
+	ip.KWTA.Defaults()
 	ip.Layer.Gi = 1.1
 	ip.Pool.Gi = 1.1
 }
 
+///////////////////////////////////////////////////////////////////////
+//  KWTAParams
+
+// KWTAParams are k-winners-take-all inhibition parameters: inhibition is set
+// in between the threshold inhibition levels of the k-th and k+1-th most
+// excited neurons, where the threshold inhibition is the amount that would
+// put a neuron exactly at firing threshold given its current excitation,
+// so that only about k neurons can be above threshold.  This replaces the
+// FFFB inhibition computed by Inhib.Layer and Inhib.Pool, for the layer or pools.
+type KWTAParams struct {
+	On   bool    `desc:"use k-winners-take-all inhibition instead of FFFB"`
+	K    int     `viewif:"On" min:"0" desc:"number of neurons allowed to be active, per layer or per pool -- if 0, Pct is used"`
+	Pct  float32 `viewif:"On" min:"0" max:"1" def:"0.15" desc:"proportion of neurons allowed to be active, per layer or per pool, if K = 0"`
+	Pool bool    `viewif:"On" desc:"compute kWTA separately within each pool (for 4D layers), instead of across the whole layer"`
+	Pt   float32 `viewif:"On" def:"0.25" min:"0" max:"1" desc:"position of inhibition between the k-th (0) and k+1-th (1) threshold values -- lower values allow the k-th neuron to be more strongly active"`
+}
+
+func (kp *KWTAParams) Defaults() {
+	kp.On = false
+	kp.K = 0
+	kp.Pct = 0.15
+	kp.Pool = false
+	kp.Pt = 0.25
+}
+
+func (kp *KWTAParams) Update() {
+}
+
+// KFmN returns the number of active neurons allowed out of n, at least 1
+func (kp *KWTAParams) KFmN(n int) int {
+	k := kp.K
+	if k == 0 {
+		k = int(mat32.Round(kp.Pct * float32(n)))
+	}
+	if k < 1 {
+		k = 1
+	}
+	return k
+}
+
+// Gi returns the kWTA inhibition from the threshold inhibition values of
+// each neuron in thr, which is sorted in place in descending order.
+// Returns 0 if all neurons are allowed to be active.
+func (kp *KWTAParams) Gi(thr []float32) float32 {
+	n := len(thr)
+	k := kp.KFmN(n)
+	if k >= n {
+		return 0
+	}
+	sort.Slice(thr, func(i, j int) bool { return thr[i] > thr[j] })
+	gk := thr[k-1]
+	gk1 := thr[k]
+	return gk1 + kp.Pt*(gk-gk1)
+}
+
 ///////////////////////////////////////////////////////////////////////
 //  InhibMiscParams
 
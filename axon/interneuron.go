@@ -0,0 +1,136 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+	"github.com/goki/ki/kit"
+)
+
+// Interneurons are the types of inhibitory interneuron populations
+// supported by InterneuronLayer
+type Interneurons int32
+
+//go:generate stringer -type=Interneurons
+
+var KiT_Interneurons = kit.Enums.AddEnum(InterneuronsN, kit.NotBitFlag, nil)
+
+func (ev Interneurons) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *Interneurons) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The interneuron types
+const (
+	// PV are parvalbumin-positive fast-spiking basket cells, which receive
+	// the same feedforward inputs as the pyramidal cells, and provide fast
+	// perisomatic GABA-A inhibition -- no spike-frequency adaptation.
+	PV Interneurons = iota
+
+	// SST are somatostatin-positive (Martinotti) cells, which are driven by
+	// the pyramidal cells, and provide slower feedback dendritic inhibition
+	// with a GABA-B component -- with spike-frequency adaptation.
+	SST
+
+	InterneuronsN
+)
+
+// InterneuronLayer is a population of inhibitory interneurons (PV or SST style)
+// that inhibits a paired pyramidal layer through InterPrjn Inhib projections,
+// providing an explicit alternative to the computed FFFB inhibition.
+// See Network AddInterneurons for creating and wiring up the standard circuit.
+type InterneuronLayer struct {
+	Layer
+	Inter  Interneurons `desc:"type of interneuron population, which determines the default parameters"`
+	PyrLay string       `desc:"name of the paired pyramidal layer that this layer inhibits"`
+}
+
+var KiT_InterneuronLayer = kit.Types.AddType(&InterneuronLayer{}, LayerProps)
+
+func (ly *InterneuronLayer) Defaults() {
+	ly.Layer.Defaults()
+	ly.Act.NMDA.Gbar = 0
+	ly.Act.GABAB.Gbar = 0
+	ly.Inhib.Layer.Gi = 0.8
+	ly.Inhib.ActAvg.Init = 0.3
+	switch ly.Inter {
+	case PV:
+		ly.Act.KNa.On = false
+	case SST:
+		ly.Act.KNa.On = true
+	}
+}
+
+func (ly *InterneuronLayer) Class() string {
+	return "Interneuron " + ly.Inter.String() + " " + ly.Cls
+}
+
+// InterPrjn is an Inhib projection from an InterneuronLayer to its paired
+// pyramidal layer, with per-projection GABA kinetics (see GABAPrjnParams)
+// set according to the type of interneuron: fast GABA-A for PV,
+// and slower mixed GABA-A / GABA-B for SST.  Not learning by default.
+type InterPrjn struct {
+	Prjn
+	Inter Interneurons `desc:"type of sending interneuron population, which determines the default parameters"`
+}
+
+var KiT_InterPrjn = kit.Types.AddType(&InterPrjn{}, PrjnProps)
+
+func (pj *InterPrjn) Defaults() {
+	pj.Prjn.Defaults()
+	pj.Learn.Learn = false
+	pj.SWt.Init.Mean = 0.8
+	pj.SWt.Init.Var = 0
+	pj.SWt.Init.Sym = false
+	pj.GABA.On = true
+	switch pj.Inter {
+	case PV:
+		pj.Com.Delay = 1
+		pj.GABA.A = 1
+		pj.GABA.B = 0
+		pj.GABA.ATau = 7
+	case SST:
+		pj.GABA.A = 0.5
+		pj.GABA.B = 0.5
+		pj.GABA.ATau = 20
+	}
+	pj.GABA.Update()
+}
+
+func (pj *InterPrjn) PrjnTypeName() string {
+	return "Inter" + pj.Inter.String()
+}
+
+// AddInterneurons adds PV and SST InterneuronLayer populations of given size
+// (named with PV and SST suffixes) paired with given pyramidal layer, and
+// wires the standard micro-circuit: ffSend layers (the feedforward inputs to
+// the pyramidal layer) project to PV, which provides fast feedforward
+// inhibition, and the pyramidal layer projects to SST, which provides slower
+// feedback inhibition -- both through InterPrjn Inhib projections.
+// The pyramidal layer gets the InterPyr class, which can be used in params
+// to turn off its FFFB inhibition (Layer.Inhib.Layer.On = false, and Pool)
+// to use the interneurons as the sole source of inhibition.
+func (nt *Network) AddInterneurons(pyr emer.Layer, nNeurY, nNeurX int, ffSend ...emer.Layer) (pv, sst *InterneuronLayer) {
+	name := pyr.Name()
+	full := prjn.NewFull()
+	pv = &InterneuronLayer{Inter: PV, PyrLay: name}
+	nt.AddLayerInit(pv, name+"PV", []int{nNeurY, nNeurX}, emer.Hidden)
+	pv.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: name, XAlign: relpos.Left, Space: 2})
+	sst = &InterneuronLayer{Inter: SST, PyrLay: name}
+	nt.AddLayerInit(sst, name+"SST", []int{nNeurY, nNeurX}, emer.Hidden)
+	sst.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: name + "PV", YAlign: relpos.Front, Space: 2})
+	for _, sl := range ffSend {
+		nt.ConnectLayers(sl, pv, full, emer.Forward)
+	}
+	nt.ConnectLayers(pyr, sst, full, emer.Forward)
+	nt.ConnectLayersPrjn(pv, pyr, full, emer.Inhib, &InterPrjn{Inter: PV})
+	nt.ConnectLayersPrjn(sst, pyr, full, emer.Inhib, &InterPrjn{Inter: SST})
+	if cls := pyr.(AxonLayer).AsAxon().Cls; cls != "" {
+		pyr.SetClass(cls + " InterPyr")
+	} else {
+		pyr.SetClass("InterPyr")
+	}
+	return
+}
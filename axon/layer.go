@@ -11,13 +11,11 @@ import (
 	"io"
 	"log"
 	"math"
-	"math/rand"
 	"strconv"
 	"strings"
 
 	"github.com/emer/emergent/edge"
 	"github.com/emer/emergent/emer"
-	"github.com/emer/emergent/erand"
 	"github.com/emer/emergent/weights"
 	"github.com/emer/etable/etensor"
 	"github.com/goki/ki/bitflag"
@@ -39,6 +37,23 @@ type Layer struct {
 	Pools   []Pool          `desc:"inhibition and other pooled, aggregate state variables -- flat list has at least of 1 for layer, and one for each sub-pool (unit group) if shape supports that (4D).  You must iterate over index and use pointer to modify values."`
 	ActAvg  ActAvgVals      `view:"inline" desc:"running-average activation levels used for Ge scaling and adaptive inhibition"`
 	CosDiff CosDiffStats    `desc:"cosine difference between ActM, ActP stats"`
+	EIBal   EIBalStats      `view:"inline" desc:"distribution of per-neuron excitatory / inhibitory balance and adapted inhibitory gain -- computed if Inhib.EIBal.On"`
+	Rand    Rand            `view:"-" json:"-" xml:"-" desc:"random number source for this layer, seeded from Network.RandSeed -- nil = global math/rand (see SetRand)"`
+
+	Stats    ActStatsParams `view:"inline" desc:"parameters for accumulating per-epoch activity diagnostics -- see ActStats"`
+	EpcStats ActStats       `inactive:"+" desc:"activity diagnostics from the last completed epoch, computed in Network.EpochInc -- see ActStats"`
+
+	ErrParams   ErrStatsParams `view:"inline" desc:"parameters for classification error statistics for Target and Compare layers -- see ErrStats"`
+	ErrStats    ErrStats       `inactive:"+" desc:"classification error statistics (cross-entropy, AUC, top-k accuracy, confusion matrix) for the last trial and accumulated over the current epoch -- computed if ErrParams.On"`
+	EpcErrStats ErrStats       `inactive:"+" desc:"classification error statistics from the last completed epoch, saved in Network.EpochInc"`
+
+	TrgAvgs []float32 `view:"-" desc:"per-neuron target average activations set from data by SetTrgAvgs, used in place of the uniform TrgRange distribution of TrgAvg values in InitWts -- nil = uniform"`
+
+	Inference bool `inactive:"+" desc:"inference mode, set by Network.InferenceMode: learning-related running averages, EIBal, CosDiff and Ge / Gi scaling stats are not updated during Cycle and phase updates -- activations are unaffected"`
+
+	kwtaBuf []float32   // scratch buffer for kWTA threshold values
+	actAcc  actStatsAcc // accumulated values for ActStats
+	prof    *Profiler   // network profiler, set in Network Build
 }
 
 var KiT_Layer = kit.Types.AddType(&Layer{}, LayerProps)
@@ -47,6 +62,8 @@ func (ly *Layer) Defaults() {
 	ly.Act.Defaults()
 	ly.Inhib.Defaults()
 	ly.Learn.Defaults()
+	ly.Stats.Defaults()
+	ly.ErrParams.Defaults()
 	ly.Inhib.Layer.On = true
 	ly.Inhib.Layer.Gi = 1.0
 	ly.Inhib.Pool.Gi = 1.0
@@ -75,6 +92,8 @@ func (ly *Layer) UpdateParams() {
 	ly.Act.Update()
 	ly.Inhib.Update()
 	ly.Learn.Update()
+	ly.Stats.Update()
+	ly.ErrParams.Update()
 	for _, pj := range ly.RcvPrjns {
 		pj.UpdateParams()
 	}
@@ -203,6 +222,15 @@ func (ly *Layer) UnitVals(vals *[]float32, varNm string) error {
 	return nil
 }
 
+// UnitValsPy returns values of given variable name on unit,
+// for each unit in the layer, as a new float32 slice (NaN on invalid var name).
+// Py is Python version, returning the values instead of filling a slice pointer.
+func (ly *Layer) UnitValsPy(varNm string) []float32 {
+	var vals []float32
+	ly.UnitVals(&vals, varNm)
+	return vals
+}
+
 // UnitValsTensor returns values of given variable name on unit
 // for each unit in the layer, as a float32 tensor in same shape as layer units.
 func (ly *Layer) UnitValsTensor(tsr etensor.Tensor, varNm string) error {
@@ -639,8 +667,14 @@ func (ly *Layer) InitWts() {
 	ly.ActAvg.AvgMaxGiM = ly.Act.GTarg.GiMax
 	ly.ActAvg.GiMult = 1
 	ly.AxonLay.InitActAvg()
+	ly.InitHetero()
 	ly.AxonLay.InitActs()
 	ly.CosDiff.Init()
+	ly.EIBal.Init()
+	ly.EpcStats = ActStats{}
+	ly.ActStatsReset()
+	ly.EpcErrStats = ErrStats{}
+	ly.ErrStatsReset()
 
 	ly.AxonLay.InitGScale()
 
@@ -658,6 +692,9 @@ func (ly *Layer) InitActAvg() {
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		ly.Learn.InitActAvg(nrn)
+		nrn.EIBal = 0
+		nrn.GiGain = 1
+		nrn.GeBias = 0
 	}
 	strg := ly.Learn.TrgAvgAct.TrgRange.Min
 	rng := ly.Learn.TrgAvgAct.TrgRange.Range()
@@ -677,7 +714,7 @@ func (ly *Layer) InitActAvg() {
 		for pi := 1; pi < np; pi++ {
 			pl := &ly.Pools[pi]
 			if ly.Learn.TrgAvgAct.Permute {
-				erand.PermuteInts(porder)
+				PermuteInts(porder, ly.Rnd())
 			}
 			for ni := pl.StIdx; ni < pl.EdIdx; ni++ {
 				nrn := &ly.Neurons[ni]
@@ -702,7 +739,7 @@ func (ly *Layer) InitActAvg() {
 			porder[i] = i
 		}
 		if ly.Learn.TrgAvgAct.Permute {
-			erand.PermuteInts(porder)
+			PermuteInts(porder, ly.Rnd())
 		}
 		for ni := range ly.Neurons {
 			nrn := &ly.Neurons[ni]
@@ -717,6 +754,7 @@ func (ly *Layer) InitActAvg() {
 			nrn.DTrgAvg = 0
 		}
 	}
+	ly.ApplyTrgAvgs()
 }
 
 // InitActs fully initializes activation state -- only called automatically during InitWts
@@ -984,6 +1022,7 @@ func (ly *Layer) NewState() {
 			continue
 		}
 		nrn.ActPrv = nrn.AvgM // nrn.ActP -- this is used in deep learning, makes big diff!
+		nrn.ClampMult = ly.Act.Clamp.ClampMult(ly.Rnd())
 	}
 	ly.AxonLay.DecayState(ly.Act.Decay.Act)
 }
@@ -1009,7 +1048,7 @@ func (ly *Layer) InitGScale() {
 		// if pj.GScale == 0 {
 		// 	continue
 		// }
-		if pj.Typ == emer.Inhib {
+		if pj.IsInhib() {
 			totGiRel += pj.PrjnScale.Rel
 		} else {
 			totGeRel += pj.PrjnScale.Rel
@@ -1018,7 +1057,7 @@ func (ly *Layer) InitGScale() {
 
 	for _, p := range ly.RcvPrjns {
 		pj := p.(AxonPrjn).AsAxon()
-		if pj.Typ == emer.Inhib {
+		if pj.IsInhib() {
 			if totGiRel > 0 {
 				pj.GScale.Rel = pj.PrjnScale.Rel / totGiRel
 				pj.GScale.Scale /= totGiRel
@@ -1053,6 +1092,12 @@ func (ly *Layer) DecayState(decay float32) {
 		pl := &ly.Pools[pi]
 		pl.Inhib.Decay(decay)
 	}
+	for _, p := range ly.RcvPrjns {
+		if p.IsOff() {
+			continue
+		}
+		p.(AxonPrjn).AsAxon().DecayGABA(decay, ly.Act.Decay.Glong)
+	}
 }
 
 // DecayStatePool decays activation state by given proportion in given sub-pool index (0 based)
@@ -1098,21 +1143,22 @@ func (ly *Layer) GFmInc(ltime *Time) {
 // This is called by GFmInc overall method, but separated out for cases that need to
 // do something different.
 func (ly *Layer) RecvGInc(ltime *Time) {
+	tm := ly.prof.StartTimer(ProfRecvGInc, ly.Idx)
 	for _, p := range ly.RcvPrjns {
 		if p.IsOff() {
 			continue
 		}
 		p.(AxonPrjn).RecvGInc(ltime)
 	}
+	if tm != nil {
+		tm.Stop()
+	}
 }
 
 // GFmIncNeur is the neuron-level code for GFmInc that integrates overall Ge, Gi values
 // from their G*Raw accumulators.
 func (ly *Layer) GFmIncNeur(ltime *Time) {
-	cyc := ltime.Cycle // for bursting
-	if ly.AxonLay.IsTarget() {
-		cyc = ltime.PhaseCycle
-	}
+	cyc := ly.ClampCyc(ltime) // for bursting
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
@@ -1120,8 +1166,7 @@ func (ly *Layer) GFmIncNeur(ltime *Time) {
 		}
 
 		// important: add other sources of GeRaw here in NMDA driver
-		nrn.NMDA = ly.Act.NMDA.NMDA(nrn.NMDA, nrn.GeRaw, nrn.NMDASyn)
-		nrn.Gnmda = ly.Act.NMDA.Gnmda(nrn.NMDA, nrn.VmDend)
+		ly.Act.NMDAFmRaw(nrn, nrn.GeRaw)
 		// note: GABAB integrated in ActFmG one timestep behind, b/c depends on integrated Gi inhib
 
 		// note: each step broken out here so other variants can add extra terms to Raw
@@ -1153,6 +1198,9 @@ func (ly *Layer) InhibFmGeAct(ltime *Time) {
 	lpl := &ly.Pools[0]
 	ly.Inhib.Layer.Inhib(&lpl.Inhib, ly.ActAvg.GiMult)
 	ly.PoolInhibFmGeAct(ltime)
+	if ly.Inhib.KWTA.On {
+		ly.KWTAInhib(ltime)
+	}
 	ly.TopoGi(ltime)
 	ly.InhibFmPool(ltime)
 }
@@ -1180,6 +1228,41 @@ func (ly *Layer) PoolInhibFmGeAct(ltime *Time) {
 	}
 }
 
+// KWTAInhib computes k-winners-take-all inhibition, replacing the FFFB
+// inhibition in the layer pool and all sub-pools, or separately per pool
+// if Inhib.KWTA.Pool is set and the layer has pools.
+func (ly *Layer) KWTAInhib(ltime *Time) {
+	np := len(ly.Pools)
+	if ly.Inhib.KWTA.Pool && np > 1 {
+		lpl := &ly.Pools[0]
+		lpl.Inhib.Gi = 0
+		for pi := 1; pi < np; pi++ {
+			pl := &ly.Pools[pi]
+			pl.Inhib.Gi = ly.ActAvg.GiMult * ly.KWTAGi(pl.StIdx, pl.EdIdx)
+			lpl.Inhib.Gi = mat32.Max(lpl.Inhib.Gi, pl.Inhib.Gi)
+		}
+		return
+	}
+	gi := ly.ActAvg.GiMult * ly.KWTAGi(0, len(ly.Neurons))
+	for pi := range ly.Pools {
+		ly.Pools[pi].Inhib.Gi = gi
+	}
+}
+
+// KWTAGi returns the kWTA inhibition for neurons in range [stIdx, edIdx)
+func (ly *Layer) KWTAGi(stIdx, edIdx int) float32 {
+	thr := ly.kwtaBuf[:0]
+	for ni := stIdx; ni < edIdx; ni++ {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		thr = append(thr, ly.Act.GiThr(nrn.Ge))
+	}
+	ly.kwtaBuf = thr
+	return ly.Inhib.KWTA.Gi(thr)
+}
+
 // TopoGi computes topographic Gi inhibition
 func (ly *Layer) TopoGi(ltime *Time) {
 	if !ly.Inhib.Topo.On {
@@ -1254,9 +1337,33 @@ func (ly *Layer) InhibFmPool(ltime *Time) {
 		pl := &ly.Pools[nrn.SubPool]
 		ly.Inhib.Self.Inhib(&nrn.GiSelf, nrn.Act)
 		nrn.Gi = pl.Inhib.Gi + nrn.GiSelf + ly.Inhib.Inhib.GiSyn(nrn.GiSyn+nrn.GiNoise)
+		if ly.Inhib.EIBal.Adapt {
+			nrn.Gi *= nrn.GiGain
+		}
 	}
 }
 
+// ClampCyc returns the cycle used for ramping and spiking of clamped
+// external inputs: within the plus phase for Target layers (where Targ
+// is clamped), and otherwise within the current state
+func (ly *Layer) ClampCyc(ltime *Time) int {
+	if ly.AxonLay.IsTarget() {
+		return ltime.PhaseCycle
+	}
+	return ltime.Cycle
+}
+
+// ActFmGNeur computes the spiking and rate-code activation of given neuron
+// from its conductances, or directly from its external input for the
+// ClampHard and ClampPoisson Act.Clamp.Type, with cyc from ClampCyc
+func (ly *Layer) ActFmGNeur(nrn *Neuron, cyc int) {
+	if ly.Act.Clamp.IsSpike() && nrn.HasFlag(NeurHasExt) {
+		ly.Act.ClampActFmG(nrn, cyc, ly.Rnd())
+		return
+	}
+	ly.Act.ActFmG(nrn)
+}
+
 // ActFmG computes rate-code activation from Ge, Gi, Gl conductances
 // and updates learning running-average activations from that Act
 func (ly *Layer) ActFmG(ltime *Time) {
@@ -1264,28 +1371,41 @@ func (ly *Layer) ActFmG(ltime *Time) {
 	if ltime.PlusPhase {
 		intdt *= 3.0
 	}
+	cyc := ly.ClampCyc(ltime)
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
 		ly.Act.VmFmG(nrn)
-		ly.Act.ActFmG(nrn)
-		ly.Learn.AvgsFmAct(nrn)
+		ly.ActFmGNeur(nrn, cyc)
 		nrn.ActInt += intdt * (nrn.Act - nrn.ActInt) // using reg act here now
+		if ly.Inference {
+			ly.GABABFmGi(nrn)
+			continue
+		}
+		ly.Learn.AvgsFmAct(nrn)
 		if !ltime.PlusPhase {
 			nrn.GeM += ly.Act.Dt.IntDt * (nrn.Ge - nrn.GeM)
 			nrn.GiM += ly.Act.Dt.IntDt * (nrn.GiSyn - nrn.GiM)
+			if ly.Inhib.EIBal.On {
+				ly.EIBalFmG(nrn)
+			}
 		}
+		ly.GABABFmGi(nrn)
+	}
+}
 
-		// note: this is here because it depends on Gi
-		nrn.GABAB, nrn.GABABx = ly.Act.GABAB.GABAB(nrn.GABAB, nrn.GABABx, nrn.Gi)
-		nrn.GgabaB = ly.Act.GABAB.GgabaB(nrn.GABAB, nrn.VmDend)
-		if ly.Act.KNa.On {
-			nrn.Gk += nrn.GgabaB // Gk was set by KNa
-		} else {
-			nrn.Gk = nrn.GgabaB
-		}
+// GABABFmGi updates the GABA-B channel state and the resulting Gk
+// conductance for given neuron -- this is done in ActFmG because it
+// depends on Gi.
+func (ly *Layer) GABABFmGi(nrn *Neuron) {
+	nrn.GABAB, nrn.GABABx = ly.Act.GABAB.GABAB(nrn.GABAB, nrn.GABABx, nrn.Gi)
+	nrn.GgabaB = ly.Act.GABAB.GgabaB(nrn.GABAB, nrn.VmDend)
+	if ly.Act.KNa.On {
+		nrn.Gk += nrn.GgabaB // Gk was set by KNa
+	} else {
+		nrn.Gk = nrn.GgabaB
 	}
 }
 
@@ -1382,6 +1502,15 @@ func (ly *Layer) MinusPhase(ltime *Time) {
 		pl.ActM.CalcAvg()
 	}
 	ly.AvgGeM(ltime)
+	if ly.Inhib.EIBal.On {
+		ly.EIBalStatsFmNeurs()
+	}
+	if ly.Stats.On {
+		ly.ActStatsAccum()
+	}
+	if ly.HasErrStats() {
+		ly.ErrStatsFmActs()
+	}
 }
 
 // PlusPhase does updating at end of the plus phase
@@ -1394,7 +1523,9 @@ func (ly *Layer) PlusPhase(ltime *Time) {
 		nrn.ActP = nrn.ActInt
 		nrn.ActDif = nrn.ActP - nrn.ActM
 		nrn.ActAvg += ly.Act.Dt.LongAvgDt * (nrn.ActM - nrn.ActAvg)
-		nrn.RLrate = ly.Learn.RLrate.RLrate(nrn.AvgS, nrn.AvgM)
+		if !ly.Inference {
+			nrn.RLrate = ly.Learn.RLrate.RLrate(nrn.AvgS, nrn.AvgM)
+		}
 	}
 	for pi := range ly.Pools {
 		pl := &ly.Pools[pi]
@@ -1408,7 +1539,9 @@ func (ly *Layer) PlusPhase(ltime *Time) {
 		}
 		pl.ActP.CalcAvg()
 	}
-	ly.AxonLay.CosDiffFmActs()
+	if !ly.Inference {
+		ly.AxonLay.CosDiffFmActs()
+	}
 }
 
 // TargToExt sets external input Ext from target values Targ
@@ -1638,7 +1771,9 @@ func (ly *Layer) WtFmDWt() {
 func (ly *Layer) SlowAdapt() {
 	ly.AdaptGScale()
 	ly.AdaptInhib()
+	ly.AdaptEIBal()
 	ly.SynScale()
+	ly.AdaptIntrinsic()
 	for _, p := range ly.RcvPrjns {
 		if p.IsOff() {
 			continue
@@ -1664,6 +1799,10 @@ func (ly *Layer) AdaptGScale() {
 	geNormErr := geErr / ly.Act.GTarg.GeMax
 	giErr := ly.Act.GTarg.GiMax - ly.ActAvg.AvgMaxGiM
 	giNormErr := giErr / ly.Act.GTarg.GiMax
+	epoch := 0
+	if net, ok := ly.Network.(AxonNetwork); ok {
+		epoch = net.AsAxon().Epoch
+	}
 	for _, p := range ly.RcvPrjns {
 		if p.IsOff() {
 			continue
@@ -1671,12 +1810,12 @@ func (ly *Layer) AdaptGScale() {
 		pj := p.(AxonPrjn).AsAxon()
 		pj.GScale.AvgMaxRel = pj.GScale.AvgMax / sum
 
-		if !pj.Learn.Learn || !pj.PrjnScale.Adapt {
+		if !pj.Learn.Learn || !pj.PrjnScale.AdaptOn(epoch) {
 			continue
 		}
 
 		var relErr, normErr float32
-		if pj.Typ == emer.Inhib {
+		if pj.IsInhib() {
 			relErr = pj.GScale.Rel * giErr
 			normErr = giNormErr
 		} else {
@@ -1686,10 +1825,7 @@ func (ly *Layer) AdaptGScale() {
 		pj.GScale.Err = relErr
 		if (normErr > 0 && normErr > pj.PrjnScale.LoTol) || (normErr < 0 && -normErr > pj.PrjnScale.HiTol) {
 			pj.GScale.Scale += pj.PrjnScale.ScaleLrate * pj.GScale.Orig * relErr
-			min := 0.1 * pj.GScale.Orig
-			if pj.GScale.Scale < min {
-				pj.GScale.Scale = min
-			}
+			pj.GScale.Scale = pj.PrjnScale.ClipScale(pj.GScale.Scale, pj.GScale.Orig)
 		}
 	}
 }
@@ -1751,6 +1887,21 @@ func (ly *Layer) SynScale() {
 	}
 }
 
+// AdaptIntrinsic adapts the GeBias intrinsic excitability of each neuron
+// based on AvgDif computed in SynScale, if Learn.Intrinsic.On
+func (ly *Layer) AdaptIntrinsic() {
+	if !ly.Learn.Intrinsic.On {
+		return
+	}
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		ly.Learn.Intrinsic.GeBiasFmAvgDif(nrn)
+	}
+}
+
 // SynFail updates synaptic weight failure only -- normally done as part of DWt
 // and WtFmDWt, but this call can be used during testing to update failing synapses.
 func (ly *Layer) SynFail() {
@@ -1881,15 +2032,62 @@ func (ly *Layer) LesionNeurons(prop float32) int {
 	if nn == 0 {
 		return 0
 	}
-	p := rand.Perm(nn)
+	p := ly.Rnd().Perm(nn)
 	nl := int(prop * float32(nn))
 	for i := 0; i < nl; i++ {
 		nrn := &ly.Neurons[p[i]]
+		ly.Act.InitActs(nrn)
 		nrn.SetFlag(NeurOff)
 	}
 	return nl
 }
 
+// LesionUnits lesions (sets the Off flag) for neurons at given indexes,
+// and initializes their activation state so they make no further contribution.
+// Weights are not affected.  Returns number of neurons lesioned.
+func (ly *Layer) LesionUnits(idxs []int) int {
+	nl := 0
+	for _, ni := range idxs {
+		if ni < 0 || ni >= len(ly.Neurons) {
+			log.Printf("LesionUnits: index %v out of range for layer: %v\n", ni, ly.Nm)
+			continue
+		}
+		nrn := &ly.Neurons[ni]
+		ly.Act.InitActs(nrn)
+		nrn.SetFlag(NeurOff)
+		nl++
+	}
+	return nl
+}
+
+// UnLesionUnits unlesions (clears the Off flag) for neurons at given indexes
+func (ly *Layer) UnLesionUnits(idxs []int) {
+	for _, ni := range idxs {
+		if ni < 0 || ni >= len(ly.Neurons) {
+			continue
+		}
+		ly.Neurons[ni].ClearFlag(NeurOff)
+	}
+}
+
+// NLesioned returns the number of lesioned (Off) neurons in the layer
+func (ly *Layer) NLesioned() int {
+	nl := 0
+	for ni := range ly.Neurons {
+		if ly.Neurons[ni].IsOff() {
+			nl++
+		}
+	}
+	return nl
+}
+
+// UnLesionPrjns unlesions the synapses of all receiving projections
+func (ly *Layer) UnLesionPrjns() {
+	for _, p := range ly.RcvPrjns {
+		p.(AxonPrjn).AsAxon().UnLesion()
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Layer props for gui
 
@@ -5,9 +5,10 @@
 package axon
 
 import (
-	"math/rand"
+	"fmt"
 
 	"github.com/emer/etable/minmax"
+	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
 
@@ -20,18 +21,21 @@ type LearnNeurParams struct {
 	ActAvg    LrnActAvgParams `view:"inline" desc:"parameters for computing running average activations that drive learning"`
 	TrgAvgAct TrgAvgActParams `view:"inline" desc:"synaptic scaling parameters for regulating overall average activity compared to neuron's own target level"`
 	RLrate    RLrateParams    `view:"inline" desc:"recv neuron learning rate modulation params -- an additional error-based modulation of learning for receiver side: RLrate = |AvgS - AvgM| / Max(AvgS, AvgM)"`
+	Intrinsic IntrinsicParams `view:"inline" desc:"intrinsic excitability adaptation: per-neuron GeBias homeostasis driven by the same AvgDif as synaptic scaling -- an alternative to synaptic scaling via TrgAvgAct"`
 }
 
 func (ln *LearnNeurParams) Update() {
 	ln.ActAvg.Update()
 	ln.TrgAvgAct.Update()
 	ln.RLrate.Update()
+	ln.Intrinsic.Update()
 }
 
 func (ln *LearnNeurParams) Defaults() {
 	ln.ActAvg.Defaults()
 	ln.TrgAvgAct.Defaults()
 	ln.RLrate.Defaults()
+	ln.Intrinsic.Defaults()
 }
 
 // InitActAvg initializes the running-average activation values that drive learning.
@@ -136,6 +140,35 @@ func (ta *TrgAvgActParams) Defaults() {
 	ta.Update()
 }
 
+//////////////////////////////////////////////////////////////////////////////////////
+//  IntrinsicParams
+
+// IntrinsicParams control adaptation of intrinsic excitability, as a per-neuron
+// GeBias excitatory conductance offset that is adjusted in SlowAdapt to drive
+// the neuron's average activity (AvgPct) toward its target (TrgAvg), using the
+// same AvgDif error signal as synaptic scaling.  This provides homeostasis
+// without changing synaptic weights: set TrgAvgAct.On = false to use it instead
+// of synaptic scaling.
+type IntrinsicParams struct {
+	On    bool       `desc:"adapt the GeBias intrinsic excitability of each neuron"`
+	Lrate float32    `viewif:"On" def:"0.002" desc:"rate of adjustment of GeBias in proportion to AvgDif at each SlowAdapt step"`
+	Range minmax.F32 `viewif:"On" desc:"[default -0.1 to 0.1] range of GeBias values -- GeBias is clipped to this range"`
+}
+
+func (ip *IntrinsicParams) Defaults() {
+	ip.On = false
+	ip.Lrate = 0.002
+	ip.Range.Set(-0.1, 0.1)
+}
+
+func (ip *IntrinsicParams) Update() {
+}
+
+// GeBiasFmAvgDif updates GeBias from the AvgDif activity error
+func (ip *IntrinsicParams) GeBiasFmAvgDif(nrn *Neuron) {
+	nrn.GeBias = ip.Range.ClipVal(nrn.GeBias - ip.Lrate*nrn.AvgDif)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  RLrateParams
 
@@ -350,8 +383,8 @@ func (sp *SWtInitParams) Update() {
 }
 
 // RndVar returns the random variance in weight value (zero mean) based on Var param
-func (sp *SWtInitParams) RndVar() float32 {
-	return sp.Var * 2 * (rand.Float32() - 0.5)
+func (sp *SWtInitParams) RndVar(rnd Rand) float32 {
+	return sp.Var * 2 * (rnd.Float32() - 0.5)
 }
 
 // SWtAdaptParams manages adaptation of SWt values
@@ -374,8 +407,8 @@ func (sp *SWtAdaptParams) Update() {
 }
 
 // RndVar returns the random variance (zero mean) based on DreamVar param
-func (sp *SWtAdaptParams) RndVar() float32 {
-	return sp.DreamVar * 2 * (rand.Float32() - 0.5)
+func (sp *SWtAdaptParams) RndVar(rnd Rand) float32 {
+	return sp.DreamVar * 2 * (rnd.Float32() - 0.5)
 }
 
 ///////////////////////////////////////////////////////////////////////
@@ -386,25 +419,117 @@ type LearnSynParams struct {
 	Learn bool        `desc:"enable learning for this projection"`
 	Lrate LrateParams `desc:"learning rate parameters, supporting two levels of modulation on top of base learning rate."`
 	XCal  XCalParams  `view:"inline" desc:"parameters for the XCal learning rule"`
+	Rule  string      `desc:"optional preset for the error-driven vs. BCM Hebbian terms of the XCal learning rule, applied in Update, overriding XCal MLrn, SetLLrn and LLrn: Hebb = Hebbian only (MLrn = 0, SetLLrn, LLrn = 1), ErrorOnly = error-driven only (MLrn = 1, LLrn = 0), Mixed = error-driven plus a small Hebbian term (MLrn = 1, SetLLrn, LLrn = 0.1) -- empty = use the XCal params as set"`
+	DaMod DaModParams `view:"inline" desc:"dopamine modulation of the learning rate, from the DA value of a layer (e.g., rl.RWDaLayer, TDDaLayer)"`
+	Trace TraceParams `view:"inline" desc:"eligibility trace (three-factor) learning, where the sender x receiver coproduct accumulates in a per-synapse trace, which is multiplied by a modulator (DA or error) -- used instead of XCal when On"`
+
+	BurstGate bool `desc:"gate learning by sender bursting: only the synapses of sending neurons that burst on the current trial learn, for projections from layers that implement the BurstGater interface (e.g., deep.SuperLayer, where 5IB bursting is thought to gate plasticity) -- no effect for other senders"`
+
+	LrSched LrSchedParams `view:"inline" desc:"optional projection-specific learning rate schedule, overriding the Network LrSched schedule -- NoLrSched = use the Network schedule"`
+	Opt     OptParams     `view:"inline" desc:"optional optimizer (momentum or Adam) applied to DWt in WtFmDWt, using per-synapse moment state"`
+	Decay   WtDecayParams `view:"inline" desc:"explicit weight decay (L1, L2) and Oja normalization applied to LWt in WtFmDWt"`
+	WtNoise WtNoiseParams `view:"inline" desc:"optional noise added to the weight changes in WtFmDWt"`
+
+	Enabled LearnEnableParams `view:"inline" desc:"schedule control over when learning and SWt adaptation are enabled for this projection, by epoch and named training stage"`
 }
 
 func (ls *LearnSynParams) Update() {
+	if ls.Rule != "" {
+		ls.SetRule(ls.Rule)
+	}
 	ls.Lrate.Update()
 	ls.XCal.Update()
+	ls.DaMod.Update()
+	ls.Trace.Update()
+	ls.LrSched.Update()
+	ls.Opt.Update()
+	ls.Decay.Update()
+	ls.WtNoise.Update()
+	ls.Enabled.Update()
 }
 
 func (ls *LearnSynParams) Defaults() {
 	ls.Learn = true
+	ls.Rule = ""
+	ls.BurstGate = false
 	ls.Lrate.Defaults()
 	ls.XCal.Defaults()
+	ls.DaMod.Defaults()
+	ls.Trace.Defaults()
+	ls.LrSched.Defaults()
+	ls.Opt.Defaults()
+	ls.Decay.Defaults()
+	ls.WtNoise.Defaults()
+	ls.Enabled.Defaults()
+}
+
+// Learning returns true if learning is on and not currently frozen
+// according to Enabled
+func (ls *LearnSynParams) Learning() bool {
+	return ls.Learn && !ls.Enabled.Frozen
 }
 
 // CHLdWt returns the error-driven weight change component for the
-// temporally eXtended Contrastive Attractor Learning (XCAL), CHL version
+// temporally eXtended Contrastive Attractor Learning (XCAL), CHL version,
+// weighted by XCal.MLrn
 func (ls *LearnSynParams) CHLdWt(suAvgSLrn, suAvgMLrn, ruAvgSLrn, ruAvgMLrn float32) float32 {
+	if ls.XCal.MLrn == 0 {
+		return 0
+	}
 	srs := suAvgSLrn * ruAvgSLrn
 	srm := suAvgMLrn * ruAvgMLrn
-	return ls.XCal.DWt(srs, srm)
+	return ls.XCal.MLrn * ls.XCal.DWt(srs, srm)
+}
+
+// BCMdWt returns the BCM Hebbian weight change component of XCAL,
+// weighted by XCal.LLrn, using the receiver long-term average activation
+// as the floating threshold -- 0 unless XCal.SetLLrn
+func (ls *LearnSynParams) BCMdWt(suAvgSLrn, ruAvgSLrn, ruActAvg float32) float32 {
+	if !ls.XCal.SetLLrn || ls.XCal.LLrn == 0 {
+		return 0
+	}
+	srs := suAvgSLrn * ruAvgSLrn
+	return ls.XCal.LLrn * ls.XCal.DWt(srs, ruActAvg)
+}
+
+// SetRule sets the XCal MLrn, SetLLrn and LLrn params according to given
+// learning rule preset: Hebb, ErrorOnly, or Mixed (see Rule)
+func (ls *LearnSynParams) SetRule(rule string) error {
+	xc := &ls.XCal
+	switch rule {
+	case "Hebb":
+		xc.MLrn = 0
+		xc.SetLLrn = true
+		xc.LLrn = 1
+	case "ErrorOnly":
+		xc.MLrn = 1
+		xc.SetLLrn = false
+		xc.LLrn = 0
+	case "Mixed":
+		xc.MLrn = 1
+		xc.SetLLrn = true
+		xc.LLrn = 0.1
+	default:
+		return fmt.Errorf("Learn.Rule: %q is not a valid learning rule preset: use Hebb, ErrorOnly, or Mixed", rule)
+	}
+	ls.Rule = rule
+	return nil
+}
+
+// Validate returns an error describing any incompatible combination of
+// learning rule parameters, or nil if none
+func (ls *LearnSynParams) Validate() error {
+	if ls.Rule != "" {
+		switch ls.Rule {
+		case "Hebb", "ErrorOnly", "Mixed":
+		default:
+			return fmt.Errorf("Learn.Rule: %q is not a valid learning rule preset: use Hebb, ErrorOnly, or Mixed", ls.Rule)
+		}
+	}
+	if ls.Trace.On && ls.XCal.SetLLrn {
+		return fmt.Errorf("Learn.XCal.SetLLrn: the Hebbian term is not used with Learn.Trace.On")
+	}
+	return ls.XCal.Validate()
 }
 
 // LrateParams manages learning rate parameters
@@ -433,6 +558,226 @@ func (ls *LrateParams) Init() {
 	ls.Update()
 }
 
+//////////////////////////////////////////////////////////////////////////////////////
+//  DaModParams
+
+// DALayer is a layer with a dopamine value, e.g., rl.RWDaLayer, rl.TDDaLayer,
+// or any layer that is sent DA via rl.SendDA -- used for DaModParams
+type DALayer interface {
+	// GetDA returns the dopamine level for layer
+	GetDA() float32
+}
+
+// BurstGater is a layer whose neurons can gate the learning of their
+// sending synapses according to their bursting, e.g., deep.SuperLayer --
+// used for LearnSynParams BurstGate
+type BurstGater interface {
+	// Bursting returns true if given sending neuron burst on the current trial
+	Bursting(ni int) bool
+}
+
+// DaModParams are parameters for dopamine modulation of the learning rate,
+// multiplying DWt by Base + D1Gain * DA for positive DA values (D1-like),
+// and Base + D2Gain * DA for negative DA values (D2-like).  The resulting
+// factor can be negative, which reverses the sign of learning.
+type DaModParams struct {
+	On     bool    `desc:"modulate the learning rate by dopamine"`
+	Layer  string  `viewif:"On" desc:"name of the layer to get the DA value from (must implement GetDA) -- if empty, the receiving layer is used"`
+	Base   float32 `viewif:"On" def:"0,1" desc:"baseline learning rate multiplier when DA = 0 -- 0 = learning is entirely gated by dopamine"`
+	D1Gain float32 `viewif:"On" def:"1" desc:"gain on positive DA values (D1-like) -- negative values produce the opposite effect"`
+	D2Gain float32 `viewif:"On" def:"1" desc:"gain on negative DA values (D2-like) -- negative values cause dips to increase learning"`
+}
+
+func (dm *DaModParams) Defaults() {
+	dm.On = false
+	dm.Base = 1
+	dm.D1Gain = 1
+	dm.D2Gain = 1
+}
+
+func (dm *DaModParams) Update() {
+}
+
+// Lrate returns the learning rate multiplier for given DA value
+func (dm *DaModParams) Lrate(da float32) float32 {
+	if da > 0 {
+		return dm.Base + dm.D1Gain*da
+	}
+	return dm.Base + dm.D2Gain*da
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  TraceParams
+
+// TraceParams are parameters for eligibility trace (three-factor) learning,
+// where the sender x receiver activity coproduct accumulates into a per-synapse
+// trace Tr at each DWt, decaying with time constant Tau (in DWt calls, typically trials),
+// and the weight change is Tr times a modulator: the DA value (see DaMod.Layer
+// for where it is obtained), or the receiver error ActP - ActM if ErrMod.
+// The DaMod learning rate modulation (Base, D1Gain, D2Gain) is not applied
+// on top of this, as DA already enters directly as the modulator.
+// This allows learning from delayed reward, where the synapses that were
+// active prior to the reward are still eligible for learning when it arrives.
+type TraceParams struct {
+	On     bool    `desc:"use eligibility trace learning instead of XCal"`
+	Tau    float32 `viewif:"On" def:"1,5" min:"1" desc:"time constant in DWt calls (typically trials) for the decay of the trace -- 1 = only the current coproduct"`
+	ErrMod bool    `viewif:"On" desc:"use the receiving neuron error (ActP - ActM) as the modulator instead of DA"`
+	Reset  bool    `viewif:"On" desc:"reset the trace after learning when the modulator is non-zero, so each trace is only used once"`
+
+	Dt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / tau"`
+}
+
+func (tp *TraceParams) Defaults() {
+	tp.On = false
+	tp.Tau = 5
+	tp.ErrMod = false
+	tp.Reset = false
+	tp.Update()
+}
+
+func (tp *TraceParams) Update() {
+	tp.Dt = 1 / tp.Tau
+}
+
+// TrFmCoProd updates the trace from the sender x receiver coproduct
+func (tp *TraceParams) TrFmCoProd(tr *float32, coprod float32) {
+	*tr += tp.Dt * (coprod - *tr)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  WtDecayParams
+
+// WtDecayParams are explicit weight decay and normalization parameters, applied
+// to the LWt learned weight values in WtFmDWt, as alternatives or supplements
+// to the SWt renormalization.  All are multiplied by the effective learning rate.
+type WtDecayParams struct {
+	On  bool    `desc:"apply weight decay"`
+	L1  float32 `viewif:"On" min:"0" desc:"L1 decay: constant decrease in LWt, driving weak weights to 0 (sparsifying)"`
+	L2  float32 `viewif:"On" min:"0" desc:"L2 decay: decrease in LWt proportional to LWt"`
+	Oja float32 `viewif:"On" min:"0" desc:"Oja normalization: decrease in LWt proportional to LWt times the squared receiving neuron activity (AvgSLrn), keeping the norm of the receiving weight vector bounded"`
+}
+
+func (dp *WtDecayParams) Defaults() {
+	dp.On = false
+	dp.L1 = 0
+	dp.L2 = 0
+	dp.Oja = 0
+}
+
+func (dp *WtDecayParams) Update() {
+}
+
+// DWt adds the weight decay to the given DWt, for given LWt and receiving
+// neuron activity, scaled by learning rate lr.  Applied after any Opt
+// optimizer, so that decay is not rescaled by Adam.
+func (dp *WtDecayParams) DWt(dwt *float32, lwt, ract, lr float32) {
+	dec := dp.L1 + dp.L2*lwt + dp.Oja*ract*ract*lwt
+	if lwt <= 0 {
+		dec = 0
+	}
+	*dwt -= lr * dec
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  OptParams
+
+// OptTypes are the types of optimizer used to integrate DWt into the weights
+type OptTypes int32
+
+//go:generate stringer -type=OptTypes
+
+var KiT_OptTypes = kit.Enums.AddEnum(OptTypesN, kit.NotBitFlag, nil)
+
+func (ev OptTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *OptTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The optimizer types
+const (
+	// NoOpt applies DWt directly to the weights
+	NoOpt OptTypes = iota
+
+	// Momentum integrates DWt over time with a momentum factor of Beta1
+	Momentum
+
+	// Adam scales the running average of DWt (first moment) by the square root of
+	// the running average of squared DWt (second moment), with bias correction
+	Adam
+
+	OptTypesN
+)
+
+// OptParams are parameters for an optional optimizer stage that transforms
+// the DWt prior to updating the weights in WtFmDWt, using per-synapse first
+// (Prjn.SynM) and second (Prjn.SynV) moment state.  For Adam, the
+// resulting weight change has a magnitude of roughly Lrate.Eff per step.
+type OptParams struct {
+	Type  OptTypes `desc:"type of optimizer -- NoOpt applies DWt directly"`
+	Beta1 float32  `viewif:"Type!=NoOpt" def:"0.9" min:"0" max:"1" desc:"momentum factor, or decay rate of the first moment running average for Adam"`
+	Beta2 float32  `viewif:"Type=Adam" def:"0.999" min:"0" max:"1" desc:"decay rate of the second moment running average for Adam"`
+	Eps   float32  `viewif:"Type=Adam" def:"1e-8" min:"0" desc:"small constant added to the square root of the second moment for numerical stability"`
+
+	T     int     `inactive:"+" desc:"number of optimizer steps since InitWts, for Adam bias correction"`
+	CorrM float32 `view:"-" json:"-" xml:"-" desc:"bias correction factor for the first moment: 1 / (1 - Beta1^T)"`
+	CorrV float32 `view:"-" json:"-" xml:"-" desc:"bias correction factor for the second moment: 1 / (1 - Beta2^T)"`
+}
+
+func (op *OptParams) Defaults() {
+	op.Type = NoOpt
+	op.Beta1 = 0.9
+	op.Beta2 = 0.999
+	op.Eps = 1e-8
+	op.Init()
+}
+
+func (op *OptParams) Update() {
+}
+
+// Init resets the optimizer step counter
+func (op *OptParams) Init() {
+	op.T = 0
+	op.CorrM = 1
+	op.CorrV = 1
+}
+
+// On returns true if an optimizer is in use
+func (op *OptParams) On() bool {
+	return op.Type != NoOpt
+}
+
+// Step increments the step counter and updates the bias correction factors --
+// must be called once prior to each weight update
+func (op *OptParams) Step() {
+	op.SetStep(op.T + 1)
+}
+
+// SetStep sets the step counter to given value and updates the bias
+// correction factors accordingly, e.g., when restoring a Checkpoint
+func (op *OptParams) SetStep(t int) {
+	op.T = t
+	if op.Type != Adam || t == 0 {
+		op.CorrM = 1
+		op.CorrV = 1
+		return
+	}
+	op.CorrM = 1 / (1 - mat32.Pow(op.Beta1, float32(op.T)))
+	op.CorrV = 1 / (1 - mat32.Pow(op.Beta2, float32(op.T)))
+}
+
+// DWt transforms the given DWt according to the optimizer, updating the
+// moments m and v.  lr is the learning rate used to scale the normalized
+// Adam update.
+func (op *OptParams) DWt(dwt, m, v *float32, lr float32) {
+	switch op.Type {
+	case Momentum:
+		*m = op.Beta1*(*m) + *dwt
+		*dwt = *m
+	case Adam:
+		*m = op.Beta1*(*m) + (1-op.Beta1)*(*dwt)
+		*v = op.Beta2*(*v) + (1-op.Beta2)*(*dwt)*(*dwt)
+		*dwt = lr * (*m * op.CorrM) / (mat32.Sqrt(*v*op.CorrV) + op.Eps)
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  XCalParams
 
@@ -444,6 +789,9 @@ type XCalParams struct {
 	DRev    float32 `def:"0.1" min:"0" max:"0.99" desc:"proportional point within LTD range where magnitude reverses to go back down to zero at zero -- err-driven svm component does better with smaller values"`
 	DThr    float32 `def:"0.0001,0.01" min:"0" desc:"minimum LTD threshold value below which no weight change occurs -- this is now *relative* to the threshold"`
 	LrnThr  float32 `def:"0.01" desc:"xcal learning threshold -- don't learn when sending unit activation is below this value in both phases -- due to the nature of the learning function being 0 when the sr coproduct is 0, it should not affect learning in any substantial way -- nonstandard learning algorithms that have different properties should ignore it"`
+	MLrn    float32 `def:"1" min:"0" desc:"amount of error-driven learning: XCal of the short vs. medium time-scale sender-receiver coproducts -- see also Learn.Rule presets"`
+	SetLLrn bool    `def:"false" desc:"include the BCM Hebbian learning term, weighted by LLrn -- otherwise learning is purely error-driven and LLrn is ignored"`
+	LLrn    float32 `viewif:"SetLLrn" def:"0" min:"0" desc:"amount of BCM Hebbian learning: XCal of the short time-scale sender-receiver coproduct vs. the receiver long-term average activation (ActAvg) as a floating threshold"`
 
 	DRevRatio float32 `inactive:"+" view:"-" json:"-" xml:"-" desc:"-(1-DRev)/DRev -- multiplication factor in learning rule -- builds in the minus sign!"`
 }
@@ -462,9 +810,28 @@ func (xc *XCalParams) Defaults() {
 	xc.DRev = 0.1
 	xc.DThr = 0.0001
 	xc.LrnThr = 0.01
+	xc.MLrn = 1
+	xc.SetLLrn = false
+	xc.LLrn = 0
 	xc.Update()
 }
 
+// Validate returns an error describing any incompatible combination of
+// the MLrn, SetLLrn and LLrn params, or nil if none
+func (xc *XCalParams) Validate() error {
+	switch {
+	case xc.MLrn < 0 || xc.LLrn < 0:
+		return fmt.Errorf("Learn.XCal: MLrn = %g and LLrn = %g must not be negative", xc.MLrn, xc.LLrn)
+	case xc.MLrn == 0 && (!xc.SetLLrn || xc.LLrn == 0):
+		return fmt.Errorf("Learn.XCal: MLrn = 0 with no Hebbian term (SetLLrn = %v, LLrn = %g): no learning will occur -- set Learn.Learn = false instead", xc.SetLLrn, xc.LLrn)
+	case !xc.SetLLrn && xc.LLrn > 0:
+		return fmt.Errorf("Learn.XCal: LLrn = %g is ignored unless SetLLrn is true", xc.LLrn)
+	case xc.SetLLrn && xc.LLrn == 0:
+		return fmt.Errorf("Learn.XCal: SetLLrn is true but LLrn = 0: no Hebbian learning will occur")
+	}
+	return nil
+}
+
 // DWt is the XCAL function for weight change -- the "check mark" function -- no DGain, no ThrPMin
 func (xc *XCalParams) DWt(srval, thrP float32) float32 {
 	var dwt float32
@@ -31,3 +31,60 @@ func TestXCal(t *testing.T) {
 	}
 	// fmt.Printf("ny vals: %v\n", ny)
 }
+
+func TestLearnRule(t *testing.T) {
+	ls := LearnSynParams{}
+	ls.Defaults()
+	if err := ls.Validate(); err != nil {
+		t.Errorf("LearnRule: defaults should be valid, got: %v\n", err)
+	}
+	acts := []float32{0, .1, .3, .5, .8, 1}
+	if err := ls.SetRule("Hebb"); err != nil {
+		t.Error(err)
+	}
+	if err := ls.Validate(); err != nil {
+		t.Errorf("LearnRule: Hebb should be valid, got: %v\n", err)
+	}
+	nbcm := 0
+	for _, s := range acts {
+		for _, r := range acts {
+			if err := ls.CHLdWt(s, r, r, s); err != 0 {
+				t.Errorf("LearnRule: Hebb err term should be 0, got: %v for s: %v r: %v\n", err, s, r)
+			}
+			if ls.BCMdWt(s, r, .2) != 0 {
+				nbcm++
+			}
+		}
+	}
+	if nbcm == 0 {
+		t.Errorf("LearnRule: Hebb BCM term should be non-zero\n")
+	}
+	ls.SetRule("ErrorOnly")
+	if bcm := ls.BCMdWt(.8, .8, .2); bcm != 0 {
+		t.Errorf("LearnRule: ErrorOnly BCM term should be 0, got: %v\n", bcm)
+	}
+	ls.SetRule("Mixed")
+	if ls.XCal.LLrn != 0.1 || ls.Validate() != nil {
+		t.Errorf("LearnRule: Mixed should set LLrn = 0.1 and be valid, got LLrn: %v err: %v\n", ls.XCal.LLrn, ls.Validate())
+	}
+	ls.SetRule("Hebb")
+	ls.SetRule("Mixed")
+	if ls.XCal.LLrn != 0.1 {
+		t.Errorf("LearnRule: Mixed after Hebb should set LLrn = 0.1, got: %v\n", ls.XCal.LLrn)
+	}
+	if err := ls.SetRule("Bogus"); err == nil {
+		t.Errorf("LearnRule: invalid rule should return an error\n")
+	}
+
+	bad := []XCalParams{
+		{MLrn: 0, SetLLrn: false, LLrn: 0},
+		{MLrn: 1, SetLLrn: false, LLrn: 0.2},
+		{MLrn: 1, SetLLrn: true, LLrn: 0},
+		{MLrn: -1, SetLLrn: false, LLrn: 0},
+	}
+	for i, xc := range bad {
+		if xc.Validate() == nil {
+			t.Errorf("LearnRule: invalid XCal params %d should return an error: %+v\n", i, xc)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import "strings"
+
+// LearnEnableParams control when learning is enabled for a projection, for
+// staged training protocols (e.g., pretraining vs. fine-tuning), as a
+// function of the Network Epoch counter and the current Network Stage.
+// The Frozen and SWtFrozen states are updated by Network.EpochInc,
+// Network.SetStage and Network.InitWts.
+type LearnEnableParams struct {
+	LWtStart int    `min:"0" desc:"epoch (as counted by Network.EpochInc) at which LWt learning starts -- 0 = from the start"`
+	LWtStop  int    `min:"0" desc:"epoch at and after which LWt learning is frozen -- 0 = never"`
+	SWtStop  int    `min:"0" desc:"epoch at and after which SWt adaptation is frozen for this projection -- 0 = never -- see also Network SlowSched.SWtStop for all projections"`
+	Stages   string `desc:"space-separated list of Network.Stage names during which this projection learns -- empty = all stages"`
+
+	Frozen    bool `inactive:"+" desc:"true if learning (DWt) is currently frozen according to the above params"`
+	SWtFrozen bool `inactive:"+" desc:"true if SWt adaptation is currently frozen according to SWtStop"`
+}
+
+func (le *LearnEnableParams) Defaults() {
+	le.LWtStart = 0
+	le.LWtStop = 0
+	le.SWtStop = 0
+	le.Stages = ""
+}
+
+func (le *LearnEnableParams) Update() {
+}
+
+// InStage returns true if this projection learns in the given stage
+func (le *LearnEnableParams) InStage(stage string) bool {
+	if le.Stages == "" {
+		return true
+	}
+	for _, s := range strings.Fields(le.Stages) {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFrozen updates the Frozen and SWtFrozen states for given epoch and stage
+func (le *LearnEnableParams) SetFrozen(epoch int, stage string) {
+	le.Frozen = epoch < le.LWtStart || (le.LWtStop > 0 && epoch >= le.LWtStop) || !le.InStage(stage)
+	le.SWtFrozen = le.SWtStop > 0 && epoch >= le.SWtStop
+}
+
+// SetStage sets the current named training stage (e.g., "Pretrain",
+// "FineTune"), which determines which projections learn according to
+// their Learn.Enabled.Stages.
+func (nt *Network) SetStage(stage string) {
+	nt.Stage = stage
+	nt.LearnEnableEpoch()
+}
+
+// LearnEnableEpoch updates the Learn.Enabled frozen state of all projections
+// for the current Epoch and Stage -- called by EpochInc and SetStage.
+func (nt *Network) LearnEnableEpoch() {
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			p.(AxonPrjn).AsAxon().Learn.Enabled.SetFrozen(nt.Epoch, nt.Stage)
+		}
+	}
+}
+
+// SetPrjnsLearn sets Learn.Learn for all projections whose Class (as in
+// params selectors) includes given class name, returning the number of
+// projections set -- for programmatic control of learning, e.g., to freeze
+// pretrained projections.
+func (nt *Network) SetPrjnsLearn(cls string, on bool) int {
+	n := 0
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			if !hasClass(pj.Class(), cls) {
+				continue
+			}
+			pj.Learn.Learn = on
+			n++
+		}
+	}
+	return n
+}
+
+// hasClass returns true if space-separated classes includes cls
+func hasClass(classes, cls string) bool {
+	for _, c := range strings.Fields(classes) {
+		if c == cls {
+			return true
+		}
+	}
+	return false
+}
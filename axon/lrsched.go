@@ -0,0 +1,151 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"math"
+
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// LrSchedTypes are the types of learning rate schedules for LrSchedParams
+type LrSchedTypes int32
+
+//go:generate stringer -type=LrSchedTypes
+
+var KiT_LrSchedTypes = kit.Enums.AddEnum(LrSchedTypesN, kit.NotBitFlag, nil)
+
+func (ev LrSchedTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *LrSchedTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The learning rate schedule types
+const (
+	// NoLrSched does not apply any schedule -- for a Prjn, this means that
+	// the Network LrSched schedule applies.
+	NoLrSched LrSchedTypes = iota
+
+	// StepLr multiplies the learning rate by Factor every Epochs epochs
+	StepLr
+
+	// ExpLr multiplies the learning rate by Factor every epoch
+	ExpLr
+
+	// CosineLr anneals the learning rate from 1 down to Min over Epochs
+	// epochs, following a half cosine
+	CosineLr
+
+	// PlateauLr multiplies the learning rate by Factor when the Err
+	// statistic has not improved by at least Thr for Patience epochs
+	PlateauLr
+
+	LrSchedTypesN
+)
+
+// LrSchedParams specify a learning rate schedule, which computes a multiplier
+// at each epoch boundary that is applied to the Lrate.Sched factor of projections.
+type LrSchedParams struct {
+	Type     LrSchedTypes `desc:"type of schedule -- NoLrSched = no schedule"`
+	Epochs   int          `def:"50" min:"1" desc:"for StepLr: number of epochs per step, for CosineLr: total number of epochs over which to anneal"`
+	Factor   float32      `def:"0.5" min:"0" max:"1" desc:"multiplier for StepLr at each step, ExpLr each epoch, and PlateauLr at each plateau"`
+	Min      float32      `def:"0.01" min:"0" desc:"minimum multiplier value -- the schedule never goes below this"`
+	Patience int          `viewif:"Type=PlateauLr" def:"10" min:"1" desc:"for PlateauLr: number of epochs without improvement before reducing the multiplier"`
+	Thr      float32      `viewif:"Type=PlateauLr" def:"0.001" desc:"for PlateauLr: minimum decrease in Err that counts as an improvement"`
+
+	Mult    float32 `inactive:"+" desc:"current schedule multiplier"`
+	Err     float32 `inactive:"+" desc:"for PlateauLr: current error statistic, set by the sim prior to each epoch boundary (e.g., via Network.SetLrSchedErr)"`
+	BestErr float32 `inactive:"+" desc:"for PlateauLr: best (lowest) Err so far"`
+	NoImp   int     `inactive:"+" desc:"for PlateauLr: number of epochs since the last improvement in Err"`
+}
+
+func (ls *LrSchedParams) Defaults() {
+	ls.Type = NoLrSched
+	ls.Epochs = 50
+	ls.Factor = 0.5
+	ls.Min = 0.01
+	ls.Patience = 10
+	ls.Thr = 0.001
+	ls.Init()
+}
+
+func (ls *LrSchedParams) Update() {
+}
+
+// Init resets the schedule state
+func (ls *LrSchedParams) Init() {
+	ls.Mult = 1
+	ls.Err = 0
+	ls.BestErr = math.MaxFloat32
+	ls.NoImp = 0
+}
+
+// On returns true if a schedule is in effect
+func (ls *LrSchedParams) On() bool {
+	return ls.Type != NoLrSched
+}
+
+// EpochMult updates the schedule for the end of given epoch (number of
+// epochs completed), using the current Err for PlateauLr, and returns
+// the resulting Mult
+func (ls *LrSchedParams) EpochMult(epc int) float32 {
+	switch ls.Type {
+	case NoLrSched:
+		ls.Mult = 1
+		return ls.Mult
+	case StepLr:
+		ls.Mult = mat32.Pow(ls.Factor, float32(epc/ls.Epochs))
+	case ExpLr:
+		ls.Mult = mat32.Pow(ls.Factor, float32(epc))
+	case CosineLr:
+		pct := mat32.Min(float32(epc)/float32(ls.Epochs), 1)
+		ls.Mult = ls.Min + (1-ls.Min)*0.5*(1+mat32.Cos(mat32.Pi*pct))
+	case PlateauLr:
+		if ls.Err < ls.BestErr-ls.Thr {
+			ls.BestErr = ls.Err
+			ls.NoImp = 0
+		} else {
+			ls.NoImp++
+			if ls.NoImp >= ls.Patience {
+				ls.Mult *= ls.Factor
+				ls.NoImp = 0
+			}
+		}
+	}
+	if ls.Mult < ls.Min {
+		ls.Mult = ls.Min
+	}
+	return ls.Mult
+}
+
+// SetLrSchedErr sets the error statistic used by PlateauLr schedules,
+// for the Network and all projections with their own schedule --
+// call prior to EpochInc.
+func (nt *Network) SetLrSchedErr(err float32) {
+	nt.LrSched.Err = err
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			p.(AxonPrjn).AsAxon().Learn.LrSched.Err = err
+		}
+	}
+}
+
+// LrSchedEpoch updates the learning rate schedules at the end of an epoch,
+// called by EpochInc.  Projections with their own Learn.LrSched schedule use
+// that, and otherwise the Network LrSched applies.  The resulting multiplier
+// sets Lrate.Sched, so it should not be combined with direct calls to LrateSched.
+func (nt *Network) LrSchedEpoch() {
+	nmult := nt.LrSched.EpochMult(nt.Epoch)
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			switch {
+			case pj.Learn.LrSched.On():
+				pj.LrateSched(pj.Learn.LrSched.EpochMult(nt.Epoch))
+			case nt.LrSched.On():
+				pj.LrateSched(nmult)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/goki/mat32"
+)
+
+// SetCheckNaN turns on or off the NaN / Inf debugging mode, in which all
+// Neuron variables are checked after each Cycle, and all Synapse variables
+// after DWt and WtFmDWt, for non-finite values.  The first such value
+// found is reported with the exact layer, unit, projection and variable
+// where it occurred: if panicOnNaN is true, this causes a panic, and
+// otherwise the error is logged and recorded in NaNErr, and checking
+// stops until NaNErr is reset to nil.  This is expensive and only
+// intended for debugging.
+func (nt *Network) SetCheckNaN(on bool, panicOnNaN bool) {
+	nt.CheckNaN = on
+	nt.NaNPanic = panicOnNaN
+	nt.NaNErr = nil
+}
+
+// nanCheck runs given check function if CheckNaN is on and no error has
+// yet been found, and handles any error according to NaNPanic
+func (nt *Network) nanCheck(fun func(where string) error, where string) {
+	if !nt.CheckNaN || nt.NaNErr != nil {
+		return
+	}
+	err := fun(where)
+	if err == nil {
+		return
+	}
+	nt.NaNErr = err
+	if nt.NaNPanic {
+		panic(err)
+	}
+	log.Println(err)
+}
+
+// CheckNaNNeurons checks all Neuron variables in all layers for NaN or Inf
+// values, returning an error identifying the first one found, with
+// where indicating the point in processing for the error message.
+func (nt *Network) CheckNaNNeurons(where string) error {
+	nvar := len(NeuronVars)
+	for _, l := range nt.Layers {
+		if l.IsOff() {
+			continue
+		}
+		ly := l.(AxonLayer).AsAxon()
+		for ni := range ly.Neurons {
+			nrn := &ly.Neurons[ni]
+			for vi := 0; vi < nvar; vi++ {
+				v := nrn.VarByIndex(vi)
+				if mat32.IsNaN(v) || mat32.IsInf(v, 0) {
+					return fmt.Errorf("axon.Network %s: non-finite value: %g after %s, epoch: %d, in Layer: %s, Unit: %d, Var: %s", nt.Nm, v, where, nt.Epoch, ly.Nm, ni, NeuronVars[vi])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckNaNSynapses checks all Synapse variables in all projections for NaN
+// or Inf values, returning an error identifying the first one found, with
+// where indicating the point in processing for the error message.
+func (nt *Network) CheckNaNSynapses(where string) error {
+	nvar := len(SynapseVarsAll)
+	for _, l := range nt.Layers {
+		if l.IsOff() {
+			continue
+		}
+		for _, p := range *l.RecvPrjns() {
+			if p.IsOff() {
+				continue
+			}
+			pj := p.(AxonPrjn).AsAxon()
+			for si := range pj.Send.(AxonLayer).AsAxon().Neurons {
+				nc, st := pj.SConNSt(si)
+				for ci := 0; ci < nc; ci++ {
+					for vi := 0; vi < nvar; vi++ {
+						v := pj.SynVal1D(vi, st+ci)
+						if mat32.IsNaN(v) || mat32.IsInf(v, 0) {
+							return fmt.Errorf("axon.Network %s: non-finite value: %g after %s, epoch: %d, in Prjn: %s, send unit: %d, recv unit: %d, Var: %s", nt.Nm, v, where, nt.Epoch, pj.Name(), si, pj.SConIdx[st+ci], SynapseVarsAll[vi])
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
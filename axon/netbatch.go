@@ -0,0 +1,479 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/emer/etable/etensor"
+)
+
+// NetworkBatch runs a batch of B networks with the same architecture,
+// which can have different weights and / or receive different inputs,
+// for population-based training, evolutionary search, and hyperparameter
+// sweeps.  All of the networks share the same connectivity index
+// structures in their projections (from the first network), and their
+// neuron and synapse state is stacked: allocated contiguously for each
+// layer and projection across the batch, with the batch as the outer
+// dimension (see ShareCons).  The spike sending step of each Cycle, which
+// dominates the cost, is computed in a single loop over the shared
+// connectivity indexes for the whole batch (see SendSpike).
+// The other steps (neuron updating, inhibition, DWt, etc) loop over the
+// networks, in parallel goroutines (one per network) if Parallel is set.
+// The state of a given variable can be retrieved stacked across the batch
+// via UnitValsTensor.
+type NetworkBatch struct {
+	Nets     []*Network `desc:"the networks in the batch, all with the same architecture, shared connectivity indexes, and stacked neuron and synapse state"`
+	Parallel bool       `desc:"run each network in its own goroutine for each step -- the networks should then each use a single thread (Layer Thread = 0, no WorkPool)"`
+
+	wg sync.WaitGroup
+}
+
+// NewNetworkBatch returns a new NetworkBatch of b networks, each named
+// name_<index>, configured by given config function (which must add the
+// layers and projections, and apply Defaults and any params), and then
+// built, with connectivity shared from the first network.
+func NewNetworkBatch(name string, b int, config func(net *Network)) (*NetworkBatch, error) {
+	nb := &NetworkBatch{}
+	nb.Nets = make([]*Network, b)
+	for i := range nb.Nets {
+		net := &Network{}
+		net.InitName(net, fmt.Sprintf("%s_%d", name, i))
+		config(net)
+		nb.Nets[i] = net
+	}
+	return nb, nb.Build()
+}
+
+// Len returns the number of networks in the batch
+func (nb *NetworkBatch) Len() int {
+	return len(nb.Nets)
+}
+
+// Build builds all of the networks, and shares the connectivity
+// of the first network with all of the others
+func (nb *NetworkBatch) Build() error {
+	for _, net := range nb.Nets {
+		if err := net.Build(); err != nil {
+			return err
+		}
+	}
+	return nb.ShareCons()
+}
+
+// ShareCons sets the connectivity index structures of all projections in
+// all networks to those of the first network, so that they are identical
+// and only stored once, and stacks the neuron and synapse state across
+// the batch (see StackState).  Synapse state is reallocated as needed,
+// so InitWts must be called after this.  Any projection that later changes
+// its connectivity (e.g., by pruning, see Prjn PruneSyns) first makes its
+// own copy of the shared indexes, so the other networks are unaffected.
+func (nb *NetworkBatch) ShareCons() error {
+	if len(nb.Nets) == 0 {
+		return nil
+	}
+	src := nb.Nets[0]
+	for _, net := range nb.Nets[1:] {
+		if len(net.Layers) != len(src.Layers) {
+			err := fmt.Errorf("NetworkBatch ShareCons: network %v has %d layers, not %d as in %v", net.Nm, len(net.Layers), len(src.Layers), src.Nm)
+			log.Println(err)
+			return err
+		}
+		for li, ly := range net.Layers {
+			sly := src.Layers[li]
+			rpj := *ly.RecvPrjns()
+			spj := *sly.RecvPrjns()
+			if len(rpj) != len(spj) || ly.Shape().Len() != sly.Shape().Len() {
+				err := fmt.Errorf("NetworkBatch ShareCons: layer %v in network %v does not match structure of %v", ly.Name(), net.Nm, src.Nm)
+				log.Println(err)
+				return err
+			}
+			for pi, p := range rpj {
+				pj := p.(AxonPrjn).AsAxon()
+				sp := spj[pi].(AxonPrjn).AsAxon()
+				pj.RConN = sp.RConN
+				pj.RConNAvgMax = sp.RConNAvgMax
+				pj.RConIdxSt = sp.RConIdxSt
+				pj.RConIdx = sp.RConIdx
+				pj.RSynIdx = sp.RSynIdx
+				pj.SConN = sp.SConN
+				pj.SConNAvgMax = sp.SConNAvgMax
+				pj.SConIdxSt = sp.SConIdxSt
+				pj.SConIdx = sp.SConIdx
+				pj.RFanIn = sp.RFanIn
+				pj.SFanOut = sp.SFanOut
+				pj.sharedCons = true
+				sp.sharedCons = true
+				if len(pj.Syns) != len(pj.SConIdx) {
+					pj.Syns = make([]Synapse, len(pj.SConIdx))
+				}
+				pj.StaleWtSc()
+			}
+		}
+	}
+	nb.StackState()
+	return nil
+}
+
+// StackState allocates the Neurons of each layer, and the Syns, WtSc and
+// Gbuf of each projection, contiguously across the batch, with the batch
+// as the outer dimension, and sets the slices of each network to its part
+// of this stacked state, copying the existing values.  Anything that later
+// reallocates a slice (e.g., pruning, or a change in Com.Delay) gives that
+// network its own separate copy again, which is still processed correctly.
+func (nb *NetworkBatch) StackState() {
+	nn := len(nb.Nets)
+	if nn == 0 {
+		return
+	}
+	for li, sly := range nb.Nets[0].Layers {
+		n := len(sly.(AxonLayer).AsAxon().Neurons)
+		nrns := make([]Neuron, nn*n)
+		for b, net := range nb.Nets {
+			ly := net.Layers[li].(AxonLayer).AsAxon()
+			st := nrns[b*n : (b+1)*n : (b+1)*n]
+			copy(st, ly.Neurons)
+			ly.Neurons = st
+		}
+		for pi := range *sly.RecvPrjns() {
+			pjs := make([]*Prjn, nn)
+			for b, net := range nb.Nets {
+				pjs[b] = (*net.Layers[li].RecvPrjns())[pi].(AxonPrjn).AsAxon()
+			}
+			ns := len(pjs[0].Syns)
+			syns := make([]Synapse, nn*ns)
+			wscs := make([]float32, nn*ns)
+			for b, pj := range pjs {
+				st := syns[b*ns : (b+1)*ns : (b+1)*ns]
+				copy(st, pj.Syns)
+				pj.Syns = st
+				pj.WtSc = wscs[b*ns : (b+1)*ns : (b+1)*ns]
+				pj.StaleWtSc()
+			}
+			ng := len(pjs[0].Gbuf)
+			if ng == 0 {
+				continue
+			}
+			gbuf := make([]float32, nn*ng)
+			for b, pj := range pjs {
+				if len(pj.Gbuf) != ng {
+					continue
+				}
+				st := gbuf[b*ng : (b+1)*ng : (b+1)*ng]
+				copy(st, pj.Gbuf)
+				pj.Gbuf = st
+			}
+		}
+	}
+}
+
+// Net returns the network at given index in the batch
+func (nb *NetworkBatch) Net(b int) *Network {
+	return nb.Nets[b]
+}
+
+// Run calls given function on each network in the batch, in parallel
+// goroutines if Parallel is set, returning when all are done
+func (nb *NetworkBatch) Run(fun func(b int, net *Network)) {
+	if !nb.Parallel {
+		for b, net := range nb.Nets {
+			fun(b, net)
+		}
+		return
+	}
+	for b, net := range nb.Nets {
+		nb.wg.Add(1)
+		go func(b int, net *Network) {
+			fun(b, net)
+			nb.wg.Done()
+		}(b, net)
+	}
+	nb.wg.Wait()
+}
+
+// Defaults sets all the default parameters for all networks
+func (nb *NetworkBatch) Defaults() {
+	nb.Run(func(b int, net *Network) { net.Defaults() })
+}
+
+// InitWts initializes the synaptic weights and all other state in all
+// networks -- each network draws its own random weights
+func (nb *NetworkBatch) InitWts() {
+	for _, net := range nb.Nets { // not parallel: may use global random source
+		net.InitWts()
+	}
+}
+
+// CopyWtsFrom copies all of the synaptic state and conductance scaling
+// of network src in the batch to network dst
+func (nb *NetworkBatch) CopyWtsFrom(dst, src int) {
+	dnet := nb.Nets[dst]
+	snet := nb.Nets[src]
+	for li, ly := range dnet.Layers {
+		sly := snet.Layers[li]
+		spj := *sly.RecvPrjns()
+		for pi, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			sp := spj[pi].(AxonPrjn).AsAxon()
+			copy(pj.Syns, sp.Syns)
+			pj.GScale = sp.GScale
+			pj.StaleWtSc()
+		}
+	}
+}
+
+// InitExt initializes external input state in all networks
+func (nb *NetworkBatch) InitExt() {
+	nb.Run(func(b int, net *Network) { net.InitExt() })
+}
+
+// ApplyExt applies external input to the layer of given name in each
+// network, from the sub-tensor at the corresponding outer-most index
+// of given tensor, which must have the batch size as its first dimension.
+// If the tensor has only one outer row, the same input is applied to all.
+func (nb *NetworkBatch) ApplyExt(layNm string, ext *etensor.Float32) error {
+	nrows := ext.Dim(0)
+	if nrows != 1 && nrows != len(nb.Nets) {
+		err := fmt.Errorf("NetworkBatch ApplyExt: tensor outer dimension %d does not match batch size %d", nrows, len(nb.Nets))
+		log.Println(err)
+		return err
+	}
+	for b, net := range nb.Nets {
+		ly, err := net.LayerByNameTry(layNm)
+		if err != nil {
+			return err
+		}
+		row := b
+		if nrows == 1 {
+			row = 0
+		}
+		ly.(AxonLayer).ApplyExt(ext.SubSpace([]int{row}))
+	}
+	return nil
+}
+
+// NewState handles all initialization at start of new input state
+// in all networks
+func (nb *NetworkBatch) NewState() {
+	nb.Run(func(b int, net *Network) { net.NewState() })
+}
+
+// Cycle runs one cycle of activation updating in all networks.
+// If BatchSendOK, the spikes are first sent for the whole batch by
+// SendSpike, and the rest of the Cycle is then run for each network.
+func (nb *NetworkBatch) Cycle(ltime *Time) {
+	if nb.BatchSendOK() {
+		nb.SendSpike(ltime)
+	}
+	nb.Run(func(b int, net *Network) { net.Cycle(ltime) })
+}
+
+// BatchSendOK returns true if the spikes can be sent for the whole batch
+// by SendSpike: all networks must be plain *Network types (not derived
+// types that could override CycleImpl), using UpdtSync ordering.
+func (nb *NetworkBatch) BatchSendOK() bool {
+	if len(nb.Nets) == 0 {
+		return false
+	}
+	for _, net := range nb.Nets {
+		if en, ok := net.EmerNet.(*Network); !ok || en != net || net.UpdtOrder != UpdtSync {
+			return false
+		}
+	}
+	return true
+}
+
+// SendSpike sends the spikes of all networks in the batch for the current
+// cycle.  Projections of base *Layer and *Prjn types, with standard spike
+// delivery (SendChunkOK) and connectivity still shared across the batch,
+// are processed in a single loop over the shared sending connectivity
+// indexes, which sends the spikes of each network for each sending neuron
+// in turn.  Other projections and layer types send their spikes separately
+// for each network.  The next Cycle of each network then does only the
+// conductance integration (GFmInc) for its SendSpike step.
+// Must only be called if BatchSendOK, as Cycle does automatically.
+func (nb *NetworkBatch) SendSpike(ltime *Time) {
+	nn := len(nb.Nets)
+	src := nb.Nets[0]
+	lys := make([]*Layer, nn)
+	pjs := make([]*Prjn, nn)
+	for li := range src.Layers {
+		base := true
+		for b, net := range nb.Nets {
+			ly := net.Layers[li]
+			if ly.IsOff() {
+				base = false // off layers must not send for any network
+				break
+			}
+			bly, ok := ly.(*Layer)
+			if !ok {
+				base = false
+			}
+			lys[b] = bly
+		}
+		if !base {
+			for _, net := range nb.Nets {
+				if ly := net.Layers[li]; !ly.IsOff() {
+					ly.(AxonLayer).SendSpike(ltime)
+				}
+			}
+			continue
+		}
+		for pi := range src.Layers[li].(*Layer).SndPrjns {
+			batch := true
+			for b, ly := range lys {
+				sp := ly.SndPrjns[pi]
+				bpj, ok := sp.(*Prjn)
+				if sp.IsOff() || !ok || !bpj.SendChunkOK() {
+					batch = false
+				}
+				pjs[b] = bpj
+			}
+			if batch {
+				batch = nb.sharedSendCons(pjs)
+			}
+			if batch {
+				nb.sendSpikeBatch(lys, pjs)
+				continue
+			}
+			for _, ly := range lys {
+				sp := ly.SndPrjns[pi]
+				if sp.IsOff() {
+					continue
+				}
+				for ni := range ly.Neurons {
+					nrn := &ly.Neurons[ni]
+					if nrn.IsOff() || nrn.Spike == 0 {
+						continue
+					}
+					sp.(AxonPrjn).SendSpike(ni)
+				}
+			}
+		}
+	}
+	for _, net := range nb.Nets {
+		net.spikesSent = true
+	}
+}
+
+// sharedSendCons returns true if the given projections, one for each
+// network, still share the same sending connectivity indexes
+func (nb *NetworkBatch) sharedSendCons(pjs []*Prjn) bool {
+	p0 := pjs[0]
+	if len(p0.SConIdx) == 0 {
+		return false
+	}
+	for _, pj := range pjs[1:] {
+		if len(pj.SConIdx) != len(p0.SConIdx) || &pj.SConIdx[0] != &p0.SConIdx[0] || &pj.SConIdxSt[0] != &p0.SConIdxSt[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// sendSpikeBatch sends the spikes of the sending layers, one for each
+// network, through the given projections, which have shared connectivity,
+// in one loop over the shared sending connectivity indexes.
+// The order of conductance increments in each network is the same as
+// in Prjn SendSpike, so the results are identical.
+func (nb *NetworkBatch) sendSpikeBatch(lys []*Layer, pjs []*Prjn) {
+	nn := len(pjs)
+	szs := make([]int, nn)
+	dis := make([]int, nn)
+	for b, pj := range pjs {
+		if pj.wtScSc != pj.GScale.Scale || len(pj.WtSc) != len(pj.Syns) {
+			pj.UpdateWtSc()
+		}
+		szs[b] = pj.Gidx.Len
+		dis[b] = pj.Gidx.Idx(pj.Com.Delay)
+	}
+	p0 := pjs[0]
+	for si := range lys[0].Neurons {
+		nc, st := p0.SConNSt(si)
+		if nc == 0 {
+			continue
+		}
+		scons := p0.SConIdx[st : st+nc]
+		for b, ly := range lys {
+			nrn := &ly.Neurons[si]
+			if nrn.IsOff() || nrn.Spike == 0 {
+				continue
+			}
+			pj := pjs[b]
+			wscs := pj.WtSc[st : st+nc]
+			gbuf := pj.Gbuf
+			sz := szs[b]
+			di := dis[b]
+			for ci, ri := range scons {
+				gbuf[int(ri)*sz+di] += wscs[ci]
+			}
+		}
+	}
+}
+
+// MinusPhase does updating after end of minus phase in all networks
+func (nb *NetworkBatch) MinusPhase(ltime *Time) {
+	nb.Run(func(b int, net *Network) { net.MinusPhase(ltime) })
+}
+
+// PlusPhase does updating after end of plus phase in all networks
+func (nb *NetworkBatch) PlusPhase(ltime *Time) {
+	nb.Run(func(b int, net *Network) { net.PlusPhase(ltime) })
+}
+
+// DWt computes the weight changes in all networks
+func (nb *NetworkBatch) DWt() {
+	nb.Run(func(b int, net *Network) { net.DWt() })
+}
+
+// WtFmDWt updates the weights from delta-weight changes in all networks
+func (nb *NetworkBatch) WtFmDWt() {
+	nb.Run(func(b int, net *Network) { net.WtFmDWt() })
+}
+
+// EpochInc increments the epoch counter in all networks
+func (nb *NetworkBatch) EpochInc() {
+	nb.Run(func(b int, net *Network) { net.EpochInc() })
+}
+
+// UnitValsTensor returns the values of given variable for all units in
+// the layer of given name, stacked across the batch, with the batch as
+// the outer-most dimension and then the shape of the layer.
+func (nb *NetworkBatch) UnitValsTensor(tsr *etensor.Float32, layNm, varNm string) error {
+	if len(nb.Nets) == 0 {
+		return nil
+	}
+	sly, err := nb.Nets[0].LayerByNameTry(layNm)
+	if err != nil {
+		return err
+	}
+	lshp := sly.Shape()
+	shp := append([]int{len(nb.Nets)}, lshp.Shp...)
+	var nms []string
+	if len(lshp.Nms) == len(lshp.Shp) {
+		nms = append([]string{"Batch"}, lshp.Nms...)
+	}
+	tsr.SetShape(shp, nil, nms)
+	n := lshp.Len()
+	var vals []float32
+	for b, net := range nb.Nets {
+		ly := net.LayerByName(layNm).(AxonLayer).AsAxon()
+		if err := ly.UnitVals(&vals, varNm); err != nil {
+			return err
+		}
+		copy(tsr.Values[b*n:(b+1)*n], vals)
+	}
+	return nil
+}
+
+// UnitValsTensorPy returns the stacked values of given variable for all
+// units in the layer of given name (see UnitValsTensor) -- for Python.
+func (nb *NetworkBatch) UnitValsTensorPy(layNm, varNm string) *etensor.Float32 {
+	tsr := &etensor.Float32{}
+	nb.UnitValsTensor(tsr, layNm, varNm)
+	return tsr
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/params"
+	"github.com/emer/emergent/prjn"
+	"gopkg.in/yaml.v3"
+)
+
+// NetConfig is a declarative specification of a network architecture:
+// layers, projections and parameters, which can be saved in YAML or JSON
+// format so that the model architecture can be versioned outside of
+// Go code and swept programmatically.  See ConfigNetworkFromFile and
+// Network SaveNetConfig.  Only the standard Layer and Prjn types are
+// supported -- specialized types must be configured in code.
+type NetConfig struct {
+	Name   string        `json:"Name" yaml:"Name" desc:"name of the network"`
+	Layers []LayerConfig `json:"Layers" yaml:"Layers" desc:"layers, in order"`
+	Prjns  []PrjnConfig  `json:"Prjns" yaml:"Prjns" desc:"projections, in order"`
+	Params []ParamConfig `json:"Params,omitempty" yaml:"Params,omitempty" desc:"params applied after Defaults, as a params.Sheet"`
+}
+
+// LayerConfig is the NetConfig specification for one layer
+type LayerConfig struct {
+	Name  string `json:"Name" yaml:"Name" desc:"name of the layer"`
+	Type  string `json:"Type" yaml:"Type" desc:"emer.LayerType: Hidden, Input, Target, Compare"`
+	Shape []int  `json:"Shape" yaml:"Shape,flow" desc:"shape of the layer: 2D (Y, X) or 4D (PoolsY, PoolsX, NeurY, NeurX)"`
+	Class string `json:"Class,omitempty" yaml:"Class,omitempty" desc:"additional class names for params"`
+	Off   bool   `json:"Off,omitempty" yaml:"Off,omitempty" desc:"layer is turned off"`
+}
+
+// PrjnConfig is the NetConfig specification for one projection
+type PrjnConfig struct {
+	From    string  `json:"From" yaml:"From" desc:"name of the sending layer"`
+	To      string  `json:"To" yaml:"To" desc:"name of the receiving layer"`
+	Type    string  `json:"Type" yaml:"Type" desc:"emer.PrjnType: Forward, Back, Lateral, Inhib"`
+	Pattern string  `json:"Pattern" yaml:"Pattern" desc:"connectivity pattern: Full, OneToOne, PoolOneToOne, UnifRnd"`
+	PCon    float32 `json:"PCon,omitempty" yaml:"PCon,omitempty" desc:"probability of connection for the UnifRnd pattern"`
+	Class   string  `json:"Class,omitempty" yaml:"Class,omitempty" desc:"additional class names for params"`
+}
+
+// ParamConfig is the NetConfig specification for one params.Sel
+type ParamConfig struct {
+	Sel    string            `json:"Sel" yaml:"Sel" desc:"selector for what to apply the parameters to"`
+	Desc   string            `json:"Desc,omitempty" yaml:"Desc,omitempty" desc:"description of these parameter values"`
+	Params map[string]string `json:"Params" yaml:"Params" desc:"parameter values to apply"`
+}
+
+// OpenNetConfig opens a NetConfig from given file, in YAML format
+// for .yaml or .yml extensions, and JSON otherwise
+func OpenNetConfig(filename string) (*NetConfig, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	cfg := &NetConfig{}
+	if isYAML(filename) {
+		err = yaml.Unmarshal(b, cfg)
+	} else {
+		err = json.Unmarshal(b, cfg)
+	}
+	if err != nil {
+		err = fmt.Errorf("OpenNetConfig: %v: %v", filename, err)
+		log.Println(err)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Save saves the NetConfig to given file, in YAML format
+// for .yaml or .yml extensions, and JSON otherwise
+func (nc *NetConfig) Save(filename string) error {
+	var b []byte
+	var err error
+	if isYAML(filename) {
+		b, err = yaml.Marshal(nc)
+	} else {
+		b, err = json.MarshalIndent(nc, "", "  ")
+	}
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	err = ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+func isYAML(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Sheet returns the Params as a params.Sheet
+func (nc *NetConfig) Sheet() *params.Sheet {
+	sh := &params.Sheet{}
+	for _, pc := range nc.Params {
+		*sh = append(*sh, &params.Sel{Sel: pc.Sel, Desc: pc.Desc, Params: params.Params(pc.Params)})
+	}
+	return sh
+}
+
+// SetSheet sets the Params from given params.Sheet
+func (nc *NetConfig) SetSheet(sh *params.Sheet) {
+	nc.Params = nil
+	if sh == nil {
+		return
+	}
+	for _, sl := range *sh {
+		nc.Params = append(nc.Params, ParamConfig{Sel: sl.Sel, Desc: sl.Desc, Params: map[string]string(sl.Params)})
+	}
+}
+
+// ConfigNetworkFromFile configures given network from the NetConfig in given
+// file (YAML for .yaml or .yml, JSON otherwise) -- see ConfigNetwork.
+func ConfigNetworkFromFile(net *Network, filename string) error {
+	cfg, err := OpenNetConfig(filename)
+	if err != nil {
+		return err
+	}
+	return ConfigNetwork(net, cfg)
+}
+
+// ConfigNetwork configures given network from NetConfig: adds the layers
+// and projections, calls Defaults, applies the Params, and calls Build.
+// The network must have been initialized (e.g., with NewNetwork or InitName)
+// and should be empty.  Weights must still be initialized with InitWts.
+func ConfigNetwork(net *Network, cfg *NetConfig) error {
+	if cfg.Name != "" {
+		net.Nm = cfg.Name
+	}
+	for _, lc := range cfg.Layers {
+		var typ emer.LayerType
+		if err := typ.FromString(lc.Type); err != nil {
+			return fmt.Errorf("ConfigNetwork: layer %v: %v", lc.Name, err)
+		}
+		if len(lc.Shape) != 2 && len(lc.Shape) != 4 {
+			return fmt.Errorf("ConfigNetwork: layer %v: shape must be 2D or 4D: %v", lc.Name, lc.Shape)
+		}
+		ly := net.AddLayer(lc.Name, lc.Shape, typ)
+		if lc.Class != "" {
+			ly.SetClass(lc.Class)
+		}
+		if lc.Off {
+			ly.SetOff(true)
+		}
+	}
+	for _, pc := range cfg.Prjns {
+		var typ emer.PrjnType
+		if err := typ.FromString(pc.Type); err != nil {
+			return fmt.Errorf("ConfigNetwork: prjn %v -> %v: %v", pc.From, pc.To, err)
+		}
+		pat, err := PatternFmConfig(pc.Pattern, pc.PCon)
+		if err != nil {
+			return fmt.Errorf("ConfigNetwork: prjn %v -> %v: %v", pc.From, pc.To, err)
+		}
+		_, _, pj, err := net.ConnectLayerNames(pc.From, pc.To, pat, typ)
+		if err != nil {
+			return fmt.Errorf("ConfigNetwork: prjn %v -> %v: %v", pc.From, pc.To, err)
+		}
+		if pc.Class != "" {
+			pj.(AxonPrjn).AsAxon().Cls = pc.Class
+		}
+	}
+	net.Defaults()
+	if len(cfg.Params) > 0 {
+		if _, err := net.ApplyParams(cfg.Sheet(), false); err != nil {
+			return err
+		}
+	}
+	return net.Build()
+}
+
+// PatternFmConfig returns a new prjn.Pattern of given name, using pcon
+// for patterns that have a probability of connection
+func PatternFmConfig(name string, pcon float32) (prjn.Pattern, error) {
+	switch name {
+	case "Full":
+		return prjn.NewFull(), nil
+	case "OneToOne":
+		return prjn.NewOneToOne(), nil
+	case "PoolOneToOne":
+		return prjn.NewPoolOneToOne(), nil
+	case "UnifRnd":
+		pat := prjn.NewUnifRnd()
+		if pcon > 0 {
+			pat.PCon = pcon
+		}
+		return pat, nil
+	}
+	return nil, fmt.Errorf("pattern not supported in NetConfig: %v", name)
+}
+
+// NetConfig returns a NetConfig describing the layers and projections of
+// this network, with given params (can be nil) -- params cannot be recovered
+// from the network itself.  Projections with patterns not supported by
+// PatternFmConfig are included with their pattern name, but cannot be reloaded.
+func (nt *Network) NetConfig(pars *params.Sheet) *NetConfig {
+	cfg := &NetConfig{Name: nt.Nm}
+	for _, ly := range nt.Layers {
+		aly := ly.(AxonLayer).AsAxon()
+		cfg.Layers = append(cfg.Layers, LayerConfig{Name: ly.Name(), Type: ly.Type().String(), Shape: append([]int(nil), ly.Shape().Shp...), Class: aly.Cls, Off: ly.IsOff()})
+	}
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			pc := PrjnConfig{From: pj.Send.Name(), To: pj.Recv.Name(), Type: pj.Typ.String(), Pattern: pj.Pat.Name(), Class: pj.Cls}
+			if ur, ok := pj.Pat.(*prjn.UnifRnd); ok {
+				pc.PCon = ur.PCon
+			}
+			cfg.Prjns = append(cfg.Prjns, pc)
+		}
+	}
+	cfg.SetSheet(pars)
+	return cfg
+}
+
+// SaveNetConfig saves the NetConfig for this network, with given params
+// (can be nil), to given file (YAML for .yaml or .yml, JSON otherwise)
+func (nt *Network) SaveNetConfig(filename string, pars *params.Sheet) error {
+	return nt.NetConfig(pars).Save(filename)
+}
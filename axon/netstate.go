@@ -0,0 +1,143 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+)
+
+// NetState is a snapshot of the run state of a Network and its Time:
+// the states of all the Layer and Prjn random number sources, the cycle
+// counters and phase flags, and the network epoch and slow-adaptation
+// counters.  Together with the weights (SaveWtsJSON) saved at the same
+// point, typically at the start of a trial, it allows a sim to be replayed
+// from mid-run, e.g., to debug a specific trial that produced bad behavior.
+// Neuron activation state is not included, and is reset at the start of
+// each trial to the extent of the Act.Decay parameters.
+type NetState struct {
+	Time     Time                 `desc:"the time state: cycle counters and phase flags"`
+	RandSeed int64                `desc:"Network RandSeed"`
+	Layers   map[string]RandState `desc:"random source states of the layers, by name"`
+	Prjns    map[string]RandState `desc:"random source states of the projections, by name"`
+	Epoch    int                  `desc:"Network Epoch counter"`
+	SlowCtr  int                  `desc:"Network SlowCtr counter"`
+	SlowTot  int                  `desc:"Network SlowTot counter"`
+	Stage    string               `desc:"Network training Stage"`
+}
+
+// SaveState returns a snapshot of the current run state of the network
+// and given time state (which can be nil) -- see NetState.  Returns an
+// error if RandSeed is 0, as the global math/rand state cannot be saved,
+// or a layer or projection has a Rand that is not a *StateRand.
+func (nt *Network) SaveState(ltime *Time) (*NetState, error) {
+	if nt.RandSeed == 0 {
+		err := fmt.Errorf("SaveState: network %v: RandSeed must be set to save the random state", nt.Nm)
+		log.Println(err)
+		return nil, err
+	}
+	st := &NetState{RandSeed: nt.RandSeed, Epoch: nt.Epoch, SlowCtr: nt.SlowCtr, SlowTot: nt.SlowTot, Stage: nt.Stage}
+	if ltime != nil {
+		st.Time = *ltime
+	}
+	st.Layers = make(map[string]RandState)
+	st.Prjns = make(map[string]RandState)
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		sr, ok := ly.Rand.(*StateRand)
+		if !ok {
+			err := fmt.Errorf("SaveState: layer %v: Rand does not support saving state", ly.Nm)
+			log.Println(err)
+			return nil, err
+		}
+		st.Layers[ly.Nm] = sr.State()
+		for _, p := range ly.RcvPrjns {
+			pj := p.(AxonPrjn).AsAxon()
+			sr, ok := pj.Rand.(*StateRand)
+			if !ok {
+				err := fmt.Errorf("SaveState: prjn %v: Rand does not support saving state", pj.Name())
+				log.Println(err)
+				return nil, err
+			}
+			st.Prjns[pj.Name()] = sr.State()
+		}
+	}
+	return st, nil
+}
+
+// RestoreState restores the run state of the network, and of given time
+// state if non-nil, from a snapshot saved by SaveState.  The weights must
+// be restored separately (e.g., OpenWtsJSON).  Returns an error for any
+// layers or projections not found in the snapshot, which retain their
+// current state.
+func (nt *Network) RestoreState(st *NetState, ltime *Time) error {
+	if st.RandSeed != nt.RandSeed {
+		nt.SetRandSeed(st.RandSeed)
+	}
+	nt.Epoch = st.Epoch
+	nt.SlowCtr = st.SlowCtr
+	nt.SlowTot = st.SlowTot
+	nt.SetStage(st.Stage)
+	if ltime != nil {
+		*ltime = st.Time
+	}
+	var err error
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		if rs, has := st.Layers[ly.Nm]; has {
+			sr := NewStateRand(rs.Seed)
+			sr.SetState(rs)
+			ly.SetRand(sr)
+		} else {
+			err = fmt.Errorf("RestoreState: layer %v not found in state", ly.Nm)
+			log.Println(err)
+		}
+		for _, p := range ly.RcvPrjns {
+			pj := p.(AxonPrjn).AsAxon()
+			if rs, has := st.Prjns[pj.Name()]; has {
+				sr := NewStateRand(rs.Seed)
+				sr.SetState(rs)
+				pj.Rand = sr
+			} else {
+				err = fmt.Errorf("RestoreState: prjn %v not found in state", pj.Name())
+				log.Println(err)
+			}
+		}
+	}
+	return err
+}
+
+// Save saves the NetState to given file, in JSON format
+func (st *NetState) Save(filename string) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	err = ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// OpenNetState opens a NetState from given JSON file, as saved by Save
+func OpenNetState(filename string) (*NetState, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	st := &NetState{}
+	err = json.Unmarshal(b, st)
+	if err != nil {
+		err = fmt.Errorf("OpenNetState: %v: %v", filename, err)
+		log.Println(err)
+		return nil, err
+	}
+	return st, nil
+}
@@ -24,8 +24,50 @@ import (
 // axon.Network has parameters for running a basic rate-coded Axon network
 type Network struct {
 	NetworkStru
-	SlowInterval int `def:"100" desc:"how frequently to perform slow adaptive processes such as synaptic scaling, inhibition adaptation -- in SlowAdapt method-- long enough for meaningful changes"`
-	SlowCtr      int `inactive:"+" desc:"counter for how long it has been since last SlowAdapt step"`
+	SlowInterval int                    `def:"100" desc:"how frequently to perform slow adaptive processes such as synaptic scaling, inhibition adaptation -- in SlowAdapt method-- long enough for meaningful changes -- in units of SlowSched.Unit (Trial by default)"`
+	SlowSched    SlowSchedParams        `view:"inline" desc:"schedule for slow adaptive processes: units of SlowInterval, burn-in before starting, and freezing of SWt adaptation after a given number of epochs"`
+	LrSched      LrSchedParams          `view:"inline" desc:"learning rate schedule applied to Lrate.Sched of all projections at each epoch boundary (EpochInc), except those with their own Learn.LrSched"`
+	Theta        ThetaParams            `view:"inline" desc:"phase schedule of each theta cycle trial, as run by ThetaCyc: cycles per phase, number of phases, and which minus phase drives learning"`
+	ContLrn      ContLrnParams          `view:"inline" desc:"continuous learning mode: learn every Interval cycles from the fast vs. slow activity traces, without minus / plus phases"`
+	UpdtOrder    UpdtOrders             `desc:"ordering of layer updates within each Cycle: synchronous (default), sequential in feedforward dependency order, or in parallel waves of independent layers -- call ConfigWaves after changing"`
+	Waves        [][]AxonLayer          `view:"-" json:"-" xml:"-" desc:"layer update waves for the UpdtSeq and UpdtWaves orderings, computed by ConfigWaves"`
+	RandSeed     int64                  `desc:"if non-zero, seed for the random number sources of each layer and projection, which are reset at the start of each InitWts, so that runs are exactly reproducible -- 0 = use the global math/rand source -- see SetRandSeed"`
+	SlowCtr      int                    `inactive:"+" desc:"counter for how long it has been since last SlowAdapt step"`
+	SlowTot      int                    `inactive:"+" desc:"total number of SlowSched.Unit steps (trials or epochs) since InitWts -- used for the SlowSched.BurnIn period"`
+	Epoch        int                    `inactive:"+" desc:"epoch counter, incremented by EpochInc, which must be called by the sim at the end of each epoch -- used for SlowSched.Unit = Epoch and SlowSched.SWtStop, and Prjn Learn.Enabled"`
+	Stage        string                 `desc:"current named training stage (e.g., Pretrain, FineTune), which determines which projections learn according to their Learn.Enabled.Stages -- see SetStage"`
+	WorkPool     WorkPoolParams         `view:"inline" desc:"worker pool parameters -- if WorkPool.NWorkers > 1, a pool of goroutines partitions computation by projection and neuron chunks instead of using per-layer Thread assignments"`
+	Pool         WorkPool               `view:"-" json:"-" xml:"-" desc:"the worker pool, started as needed"`
+	Streams      map[string]InputStream `view:"-" json:"-" xml:"-" desc:"input streams that apply within-trial inputs to layers (by name) at the start of each Cycle -- see SetInputStream"`
+	SpikeRec     *SpikeRecorder         `view:"-" json:"-" xml:"-" desc:"spike recorder, which records spike events in Cycle when On -- see RecordSpikes"`
+	VarMons      []*VarMonitor          `view:"-" json:"-" xml:"-" desc:"unit variable monitors, which record into etable.Tables in Cycle -- see AddVarMonitor"`
+
+	CheckNaN bool  `desc:"debugging mode: check all Neuron variables after each Cycle, and Synapse variables after DWt and WtFmDWt, for NaN / Inf values -- see SetCheckNaN"`
+	NaNPanic bool  `viewif:"CheckNaN" desc:"panic on the first NaN / Inf value found by CheckNaN, instead of logging it and recording it in NaNErr"`
+	NaNErr   error `view:"-" json:"-" xml:"-" desc:"first NaN / Inf error found by CheckNaN -- further checking is suspended until this is reset to nil"`
+
+	Inference bool `inactive:"+" desc:"inference mode, set by InferenceMode: DWt and WtFmDWt do nothing, and layers skip learning-related averages and stats during Cycle"`
+
+	spikesSent bool // spikes for the current Cycle were already sent across the batch by NetworkBatch SendSpike
+}
+
+// SlowSchedParams control the schedule of slow adaptive processes
+// (synaptic scaling, GScale adaptation, inhibition adaptation, and SWt updating)
+// performed in Network.SlowAdapt, so that this timing is consistently
+// specified as params instead of through sim-side calls.
+type SlowSchedParams struct {
+	Unit    TimeScales `def:"Trial" desc:"time scale for SlowInterval and BurnIn counts: Trial = each call to WtFmDWt, Epoch = each call to Network.EpochInc -- other values are treated as Trial"`
+	BurnIn  int        `def:"0" min:"0" desc:"number of Unit steps (trials or epochs) after InitWts before slow adaptation starts -- allows fast learning to establish initial structure before SWt and scaling constraints kick in"`
+	SWtStop int        `def:"0" min:"0" desc:"epoch number (as counted by EpochInc) at and after which SWt adaptation is frozen -- synaptic scaling, GScale and inhibition adaptation continue -- 0 = never freeze"`
+}
+
+func (ss *SlowSchedParams) Defaults() {
+	ss.Unit = Trial
+	ss.BurnIn = 0
+	ss.SWtStop = 0
+}
+
+func (ss *SlowSchedParams) Update() {
 }
 
 var KiT_Network = kit.Types.AddType(&Network{}, NetworkProps)
@@ -54,7 +96,14 @@ func (nt *Network) NewPrjn() emer.Prjn {
 // Defaults sets all the default parameters for all layers and projections
 func (nt *Network) Defaults() {
 	nt.SlowInterval = 100
+	nt.SlowSched.Defaults()
+	nt.LrSched.Defaults()
+	nt.Theta.Defaults()
+	nt.ContLrn.Defaults()
+	nt.WorkPool.Defaults()
 	nt.SlowCtr = 0
+	nt.SlowTot = 0
+	nt.Epoch = 0
 	for li, ly := range nt.Layers {
 		ly.Defaults()
 		ly.SetIndex(li)
@@ -64,6 +113,8 @@ func (nt *Network) Defaults() {
 // UpdateParams updates all the derived parameters if any have changed, for all layers
 // and projections
 func (nt *Network) UpdateParams() {
+	nt.Theta.Update()
+	nt.ContLrn.Update()
 	for _, ly := range nt.Layers {
 		ly.UpdateParams()
 	}
@@ -85,14 +136,65 @@ func (nt *Network) UnitVarProps() map[string]string {
 // SynVarNames returns the names of all the variables on the synapses in this network.
 // Not all projections need to support all variables, but must safely return 0's for
 // unsupported ones.  The order of this list determines NetView variable display order.
+// Includes any additional variables registered by derived projection types
+// via their SynVarNames, in the order first encountered.
 // This is typically a global list so do not modify!
 func (nt *Network) SynVarNames() []string {
-	return SynapseVars
+	var vars []string
+	has := map[string]bool{}
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pvars := p.SynVarNames()
+			if len(pvars) <= len(SynapseVarsAll) {
+				continue
+			}
+			if vars == nil {
+				vars = append(vars, SynapseVarsAll...)
+				for _, v := range SynapseVarsAll {
+					has[v] = true
+				}
+			}
+			for _, v := range pvars {
+				if !has[v] {
+					vars = append(vars, v)
+					has[v] = true
+				}
+			}
+		}
+	}
+	if vars == nil {
+		return SynapseVarsAll
+	}
+	return vars
 }
 
-// SynVarProps returns properties for variables
+// SynVarProps returns properties for variables, including any
+// additional variables from derived projection types
 func (nt *Network) SynVarProps() map[string]string {
-	return SynapseVarProps
+	var props map[string]string
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			pprops := p.SynVarProps()
+			if len(pprops) == len(SynapseVarProps) {
+				continue
+			}
+			if props == nil {
+				props = make(map[string]string, len(SynapseVarProps))
+				for k, v := range SynapseVarProps {
+					props[k] = v
+				}
+			}
+			for k, v := range pprops {
+				if _, has := props[k]; !has {
+					props[k] = v
+				}
+			}
+		}
+	}
+	if props == nil {
+		return SynapseVarProps
+	}
+	return props
 }
 
 //////////////////////////////////////////////////////////////////////////////////////
@@ -112,6 +214,7 @@ func (nt *Network) NewState() {
 }
 
 // Cycle runs one cycle of activation updating:
+// * Applies any within-trial inputs from Streams (see SetInputStream)
 // * Sends Ge increments from sending to receiving layers
 // * Average and Max Ge stats
 // * Inhibition based on Ge stats and Act Stats (computed at end of Cycle)
@@ -120,8 +223,34 @@ func (nt *Network) NewState() {
 // This basic version doesn't use the time info, but more specialized types do, and we
 // want to keep a consistent API for end-user code.
 func (nt *Network) Cycle(ltime *Time) {
+	if len(nt.Streams) > 0 {
+		nt.ApplyStreams(ltime)
+	}
 	nt.EmerNet.(AxonNetwork).CycleImpl(ltime)
 	nt.EmerNet.(AxonNetwork).CyclePostImpl(ltime) // always call this after std cycle..
+	if nt.SpikeRec != nil && nt.SpikeRec.On {
+		nt.SpikeRec.Record(nt, ltime)
+	}
+	if len(nt.VarMons) > 0 {
+		nt.RecordVarMonitors(ltime)
+	}
+	if nt.CheckNaN {
+		nt.nanCheck(nt.CheckNaNNeurons, fmt.Sprintf("Cycle: %d", ltime.CycleTot))
+	}
+	if nt.ContLrn.On {
+		nt.ContLrnStep(ltime)
+	}
+}
+
+// CyclesPy runs given number of Cycle updates, incrementing the ltime
+// cycle counters after each one.
+// Py is Python version, which avoids the overhead of calling Cycle
+// from Python for each cycle.
+func (nt *Network) CyclesPy(ltime *Time, ncyc int) {
+	for cyc := 0; cyc < ncyc; cyc++ {
+		nt.Cycle(ltime)
+		ltime.CycleInc()
+	}
 }
 
 // CyclePost is called after the standard Cycle update, and calls CyclePost
@@ -187,13 +316,42 @@ func (nt *Network) ActSt2(ltime *Time) {
 
 // DWt computes the weight change (learning) based on current running-average activation values
 func (nt *Network) DWt() {
+	if nt.Inference {
+		return
+	}
 	nt.EmerNet.(AxonNetwork).DWtImpl()
+	if nt.CheckNaN {
+		nt.nanCheck(nt.CheckNaNSynapses, "DWt")
+	}
 }
 
 // WtFmDWt updates the weights from delta-weight changes.
 // Also calls SynScale every Interval times
 func (nt *Network) WtFmDWt() {
+	if nt.Inference {
+		return
+	}
 	nt.EmerNet.(AxonNetwork).WtFmDWtImpl()
+	nt.StaleWtSc()
+	if nt.CheckNaN {
+		nt.nanCheck(nt.CheckNaNSynapses, "WtFmDWt")
+	}
+}
+
+// InferenceMode turns inference mode on or off, for deployment or testing
+// phases where no learning takes place: DWt and WtFmDWt do nothing, and
+// layers skip the learning-related running averages (AvgsFmAct, RLrate),
+// EIBal, CosDiff and the Ge / Gi stats used for GScale adaptation, during
+// Cycle and phase updates.  Activations are identical to those computed
+// with InferenceMode off.
+func (nt *Network) InferenceMode(on bool) {
+	nt.Inference = on
+	for _, ly := range nt.Layers {
+		if ly.IsOff() {
+			continue
+		}
+		ly.(AxonLayer).AsAxon().Inference = on
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////////////////
@@ -203,6 +361,14 @@ func (nt *Network) WtFmDWt() {
 // including running-average state values (e.g., layer running average activations etc)
 func (nt *Network) InitWts() {
 	nt.SlowCtr = 0
+	nt.SlowTot = 0
+	nt.Epoch = 0
+	nt.LrSched.Init()
+	nt.LearnEnableEpoch()
+	nt.Waves = nil // recomputed at first Cycle
+	if nt.RandSeed != 0 {
+		nt.SetRandSeed(nt.RandSeed)
+	}
 	for _, ly := range nt.Layers {
 		if ly.IsOff() {
 			continue
@@ -219,6 +385,23 @@ func (nt *Network) InitWts() {
 	}
 	// dur := time.Now().Sub(st)
 	// fmt.Printf("sym: %v\n", dur)
+	nt.StaleWtSc()
+}
+
+// StaleWtSc marks the premultiplied WtSc effective weights of all
+// projections as needing to be updated on the next spike -- see Prjn StaleWtSc.
+func (nt *Network) StaleWtSc() {
+	for _, ly := range nt.Layers {
+		if ly.IsOff() {
+			continue
+		}
+		for _, p := range *ly.RecvPrjns() {
+			if p.IsOff() {
+				continue
+			}
+			p.(AxonPrjn).AsAxon().StaleWtSc()
+		}
+	}
 }
 
 // InitTopoSWts initializes SWt structural weight parameters from
@@ -334,7 +517,13 @@ func (nt *Network) NewStateImpl() {
 // * Average and Max Act stats
 // This basic version doesn't use the time info, but more specialized types do, and we
 // want to keep a consistent API for end-user code.
+// For UpdtOrder other than UpdtSync, these steps are done per layer in
+// CycleWaves.
 func (nt *Network) CycleImpl(ltime *Time) {
+	if nt.UpdtOrder != UpdtSync {
+		nt.CycleWaves(ltime)
+		return
+	}
 	nt.SendSpike(ltime) // also does integ
 	nt.AvgMaxGe(ltime)
 	nt.InhibFmGeAct(ltime)
@@ -343,8 +532,23 @@ func (nt *Network) CycleImpl(ltime *Time) {
 }
 
 // SendSpike sends change in activation since last sent, if above thresholds
-// and integrates sent deltas into GeRaw and time-integrated Ge values
+// and integrates sent deltas into GeRaw and time-integrated Ge values.
+// If the spikes were already sent by NetworkBatch SendSpike, only the
+// integration is done.
 func (nt *Network) SendSpike(ltime *Time) {
+	if nt.spikesSent {
+		nt.spikesSent = false
+		if nt.UsePool() {
+			nt.PoolLayFun(func(ly AxonLayer) { ly.GFmInc(ltime) })
+		} else {
+			nt.ThrLayFun(func(ly AxonLayer) { ly.GFmInc(ltime) }, "GFmInc   ")
+		}
+		return
+	}
+	if nt.UsePool() {
+		nt.PoolSendSpike(ltime)
+		return
+	}
 	nt.ThrLayFun(func(ly AxonLayer) { ly.SendSpike(ltime) }, "SendSpike")
 	nt.ThrLayFun(func(ly AxonLayer) { ly.GFmInc(ltime) }, "GFmInc   ")
 }
@@ -392,18 +596,42 @@ func (nt *Network) PlusPhaseImpl(ltime *Time) {
 
 // DWtImpl computes the weight change (learning) based on current running-average activation values
 func (nt *Network) DWtImpl() {
+	if nt.UsePool() {
+		nt.PoolDWt()
+		return
+	}
 	nt.ThrLayFun(func(ly AxonLayer) { ly.DWt() }, "DWt     ")
 }
 
 // WtFmDWtImpl updates the weights from delta-weight changes.
 func (nt *Network) WtFmDWtImpl() {
-	nt.ThrLayFun(func(ly AxonLayer) { ly.WtFmDWt() }, "WtFmDWt")
+	if nt.UsePool() {
+		nt.PoolWtFmDWt()
+	} else {
+		nt.ThrLayFun(func(ly AxonLayer) { ly.WtFmDWt() }, "WtFmDWt")
+	}
 	nt.EmerNet.(AxonNetwork).SlowAdapt()
 }
 
 // SlowAdapt is the layer-level slow adaptation functions: Synaptic scaling,
-// GScale conductance scaling, and adapting inhibition
+// GScale conductance scaling, and adapting inhibition.
+// Called every trial from WtFmDWt -- only counts trials if SlowSched.Unit
+// is not Epoch (see EpochInc).
 func (nt *Network) SlowAdapt() {
+	if nt.SlowSched.Unit == Epoch {
+		return
+	}
+	nt.SlowStep()
+}
+
+// SlowStep increments the slow adaptation counters by one SlowSched.Unit
+// step, and calls SlowAdapt on the layers if past the BurnIn period
+// and SlowInterval steps have accumulated.
+func (nt *Network) SlowStep() {
+	nt.SlowTot++
+	if nt.SlowTot <= nt.SlowSched.BurnIn {
+		return
+	}
 	nt.SlowCtr++
 	if nt.SlowCtr >= nt.SlowInterval {
 		nt.SlowCtr = 0
@@ -411,6 +639,33 @@ func (nt *Network) SlowAdapt() {
 	}
 }
 
+// EpochInc increments the Epoch counter -- must be called by the sim at the
+// end of each epoch for SlowSched epoch-based scheduling to work.
+// If SlowSched.Unit == Epoch, this drives the SlowAdapt schedule.
+// Also saves the per-epoch Prjn PruneStats counts, and updates the
+// learning rate schedules (see LrSchedEpoch) and Learn.Enabled state
+// (see LearnEnableEpoch).
+func (nt *Network) EpochInc() {
+	nt.Epoch++
+	nt.LrSchedEpoch()
+	nt.LearnEnableEpoch()
+	for _, ly := range nt.Layers {
+		for _, p := range *ly.RecvPrjns() {
+			p.(AxonPrjn).AsAxon().PruneStats.EpochInc()
+		}
+		ly.(AxonLayer).AsAxon().ActStatsEpoch()
+		ly.(AxonLayer).AsAxon().ErrStatsEpoch()
+	}
+	if nt.SlowSched.Unit == Epoch {
+		nt.SlowStep()
+	}
+}
+
+// SWtFrozen returns true if SWt adaptation is frozen according to SlowSched.SWtStop
+func (nt *Network) SWtFrozen() bool {
+	return nt.SlowSched.SWtStop > 0 && nt.Epoch >= nt.SlowSched.SWtStop
+}
+
 // SynFail updates synaptic failure
 func (nt *Network) SynFail() {
 	nt.ThrLayFun(func(ly AxonLayer) { ly.SynFail() }, "SynFail   ")
@@ -461,6 +716,44 @@ func (nt *Network) UnLesionNeurons() {
 	}
 }
 
+// UnLesionPrjns unlesions the synapses of all projections in the network
+func (nt *Network) UnLesionPrjns() {
+	for _, ly := range nt.Layers {
+		ly.(AxonLayer).AsAxon().UnLesionPrjns()
+	}
+}
+
+// LesionReport returns a string summarizing everything that is currently
+// off or lesioned in the network: layers and projections with the Off flag,
+// lesioned neurons per layer, and lesioned synapses per projection.
+func (nt *Network) LesionReport() string {
+	var b strings.Builder
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		if ly.IsOff() {
+			fmt.Fprintf(&b, "Layer: %s\tOff\n", ly.Nm)
+			continue
+		}
+		if nl := ly.NLesioned(); nl > 0 {
+			fmt.Fprintf(&b, "Layer: %s\tNeurons: %d / %d lesioned (%.2f%%)\n", ly.Nm, nl, len(ly.Neurons), 100*float64(nl)/float64(len(ly.Neurons)))
+		}
+		for _, p := range ly.RcvPrjns {
+			pj := p.(AxonPrjn).AsAxon()
+			if pj.IsOff() {
+				fmt.Fprintf(&b, "Prjn: %s\tOff\n", pj.Name())
+				continue
+			}
+			if nl := pj.NLesioned(); nl > 0 {
+				fmt.Fprintf(&b, "Prjn: %s\tSynapses: %d / %d lesioned (%.2f%%)\n", pj.Name(), nl, len(pj.Syns), 100*float64(nl)/float64(len(pj.Syns)))
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return "Network: " + nt.Nm + ": nothing lesioned\n"
+	}
+	return b.String()
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Methods used in MPI computation, which don't depend on MPI specifically
 
@@ -589,7 +882,7 @@ func (nt *Network) SizeReport() string {
 			pj := pji.(AxonPrjn).AsAxon()
 			ns := len(pj.Syns)
 			syn += ns
-			pmem := ns*int(unsafe.Sizeof(Synapse{})) + len(pj.Gbuf)*4
+			pmem := ns*int(unsafe.Sizeof(Synapse{})) + len(pj.Gbuf)*4 + len(pj.evG)*4
 			synMem += pmem
 			fmt.Fprintf(&b, "\t%14s:\t Syns: %d\t SynnMem: %v\n", pj.Recv.Name(), ns, (datasize.ByteSize)(pmem).HumanReadable())
 		}
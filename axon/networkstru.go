@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/emer/emergent/emer"
@@ -50,6 +51,8 @@ type NetworkStru struct {
 	ThrTimes    []timer.Time           `view:"-" desc:"timers for each thread, so you can see how evenly the workload is being distributed"`
 	FunTimes    map[string]*timer.Time `view:"-" desc:"timers for each major function (step of processing)"`
 	WaitGp      sync.WaitGroup         `view:"-" desc:"network-level wait group for synchronizing threaded layer calls"`
+
+	Prof Profiler `view:"-" desc:"per-layer function profiler, recording time in each major step of processing if Prof.On -- see SetProfile, ProfileTable"`
 }
 
 // InitName MUST be called to initialize the network's pointer to itself as an emer.Network
@@ -111,7 +114,9 @@ func (nt *NetworkStru) BuildThreads() {
 	nt.ThrChans = make([]LayFunChan, nt.NThreads)
 	nt.ThrTimes = make([]timer.Time, nt.NThreads)
 	nt.FunTimes = make(map[string]*timer.Time)
+	nt.Prof.Init(len(nt.Layers))
 	for _, ly := range nt.Layers {
+		ly.(AxonLayer).AsAxon().prof = &nt.Prof
 		if ly.IsOff() {
 			continue
 		}
@@ -311,6 +316,20 @@ func (nt *NetworkStru) ConnectLayerNames(send, recv string, pat prjn.Pattern, ty
 	return
 }
 
+// ConnectLayerNamesPy establishes a projection between two layers, referenced by name
+// adding to the recv and send projection lists on each side of the connection.
+// Returns nil if not successful (error is logged).
+// Does not yet actually connect the units within the layers -- that requires Build.
+// Py is Python version, returning only the projection.
+func (nt *NetworkStru) ConnectLayerNamesPy(send, recv string, pat prjn.Pattern, typ emer.PrjnType) emer.Prjn {
+	_, _, pj, err := nt.ConnectLayerNames(send, recv, pat, typ)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return pj
+}
+
 // ConnectLayers establishes a projection between two layers,
 // adding to the recv and send projection lists on each side of the connection.
 // Does not yet actually connect the units within the layers -- that
@@ -426,6 +445,7 @@ func (nt *NetworkStru) DeleteAll() {
 	nt.ThrChans = nil
 	nt.ThrTimes = nil
 	nt.FunTimes = nil
+	nt.Prof.Init(0)
 }
 
 //////////////////////////////////////////////////////////////////////////////////////
@@ -668,6 +688,9 @@ func (nt *NetworkStru) ThrWorker(tt int) {
 // and otherwise just iterates over layers in the current thread.
 func (nt *NetworkStru) ThrLayFun(fun func(ly AxonLayer), funame string) {
 	nt.FunTimerStart(funame)
+	if nt.Prof.On {
+		fun = nt.Prof.LayFun(fun, strings.TrimSpace(funame))
+	}
 	if nt.NThreads <= 1 {
 		for _, ly := range nt.Layers {
 			if ly.IsOff() {
@@ -685,7 +708,8 @@ func (nt *NetworkStru) ThrLayFun(fun func(ly AxonLayer), funame string) {
 	nt.FunTimerStop(funame)
 }
 
-// TimerReport reports the amount of time spent in each function, and in each thread
+// TimerReport reports the amount of time spent in each function, and in each thread,
+// followed by the ProfileReport per layer if Prof.On
 func (nt *NetworkStru) TimerReport() {
 	fmt.Printf("TimerReport: %v, NThreads: %v\n", nt.Nm, nt.NThreads)
 	fmt.Printf("\tFunction Name\tTotal Secs\tPct\n")
@@ -707,6 +731,9 @@ func (nt *NetworkStru) TimerReport() {
 		fmt.Printf("\t%v \t%6.4g\t%6.4g\n", fn, pcts[i], 100*(pcts[i]/tot))
 	}
 	fmt.Printf("\tTotal   \t%6.4g\n", tot)
+	if nt.Prof.On {
+		fmt.Printf("\n%s", nt.ProfileReport(20))
+	}
 
 	if nt.NThreads <= 1 {
 		return
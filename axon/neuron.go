@@ -22,6 +22,9 @@ const NeuronVarStart = 8
 // axon.Neuron holds all of the neuron (unit) level variables -- this is the most basic version with
 // rate-code only and no optional features at all.
 // All variables accessible via Unit interface must be float32 and start at the top, in contiguous order
+// Neurons are stored as a slice of structs in Layer.Neurons -- a structure-of-arrays
+// layout is not supported, because all of the per-neuron update code (ActParams
+// and the derived layer types) operates directly on *Neuron.
 type Neuron struct {
 	Flags   NeurFlags `desc:"bit flags for binary state variables"`
 	SubPool int32     `desc:"index of the sub-level inhibitory pool that this neuron is in (only for 4D shapes, the pool (unit-group / hypercolumn) structure level) -- indicies start at 1 -- 0 is layer-level pool (is 0 if no sub-pools)."`
@@ -84,6 +87,28 @@ type Neuron struct {
 	GgabaB   float32 `desc:"net GABA-B conductance, after Vm gating and Gbar + Gbase -- applies to Gk, not Gi, for GIRK, with .1 reversal potential."`
 	GABAB    float32 `desc:"GABA-B / GIRK activation -- time-integrated value with rise and decay time constants"`
 	GABABx   float32 `desc:"GABA-B / GIRK internal drive variable -- gets the raw activation and decays"`
+
+	GiPrjn      float32 `desc:"inhibitory conductance from Inhib projections with their own GABA-A / GABA-B kinetics and reversal potentials (Prjn.GABA.On) -- applied separately from Gi, using ErevPrjn"`
+	ErevPrjn    float32 `desc:"conductance-weighted average reversal potential for GiPrjn"`
+	GiPrjnRaw   float32 `desc:"raw GiPrjn conductance accumulated from projections each cycle"`
+	ErevPrjnRaw float32 `desc:"raw sum of conductance * reversal potential accumulated from projections each cycle, normalized by GiPrjnRaw to get ErevPrjn"`
+
+	ClampMult float32 `desc:"trial-wise multiplier on the amplitude of clamped external input Ge, sampled at the start of each new state according to Act.Clamp.Var -- 1 if no clamp noise"`
+
+	EIBal  float32 `desc:"excitatory / inhibitory balance, as the running-average proportion of excitatory conductance out of total E + I conductance over the minus phase -- computed if Inhib.EIBal.On"`
+	GiGain float32 `desc:"multiplier on inhibitory conductance Gi, adapted by Inhib.EIBal.Adapt to drive EIBal toward the target balance -- 1 if not adapting"`
+
+	GeBias float32 `desc:"intrinsic excitability bias: excitatory conductance added to Ge, adapted by Learn.Intrinsic to drive average activity toward TrgAvg -- 0 if not adapting"`
+
+	GeDend    float32 `desc:"excitatory conductance into the dendritic compartment, from projections with Com.Target = GDend -- drives VmDend separately from Ge if Act.Dend.TwoComp, else added to Ge"`
+	GeDendRaw float32 `desc:"raw dendritic excitatory conductance received from GDend projections, integrated into GeDend"`
+	NMDARaw   float32 `desc:"raw NMDA-only conductance received from GNMDA projections, added to the NMDA activation"`
+	Mod       float32 `desc:"modulatory input from GMod projections, integrated with the Ge time constant -- does not directly affect Vm, but is available for specialized layers (e.g., as a dopamine-like signal)"`
+	ModRaw    float32 `desc:"raw modulatory input received from GMod projections, integrated into Mod"`
+
+	HetGl  float32 `desc:"per-neuron proportional deviation of the leak conductance, sampled according to Act.Hetero: effective leak = Gbar.L * (1 + HetGl)"`
+	HetThr float32 `desc:"per-neuron additive deviation of the spiking threshold, sampled according to Act.Hetero"`
+	HetKNa float32 `desc:"per-neuron proportional deviation of the KNa adaptation conductances, sampled according to Act.Hetero: Gk = (1 + HetKNa) * KNa sum"`
 }
 
 var NeuronVars = []string{}
@@ -113,6 +138,8 @@ var NeuronVarProps = map[string]string{
 	"GgabaB":   `auto-scale:"+"`,
 	"GABAB":    `auto-scale:"+"`,
 	"GABABx":   `auto-scale:"+"`,
+	"GeDend":   `auto-scale:"+"`,
+	"Mod":      `auto-scale:"+"`,
 }
 
 func init() {
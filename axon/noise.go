@@ -0,0 +1,157 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  NoiseDists
+
+// NoiseDists are the distributions from which noise values are drawn
+type NoiseDists int32
+
+//go:generate stringer -type=NoiseDists
+
+var KiT_NoiseDists = kit.Enums.AddEnum(NoiseDistsN, kit.NotBitFlag, nil)
+
+func (ev NoiseDists) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *NoiseDists) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The noise distributions
+const (
+	// NoiseGaussian draws from a Gaussian with given Mean and standard deviation Var
+	NoiseGaussian NoiseDists = iota
+
+	// NoiseUniform draws uniformly from Mean +/- Var
+	NoiseUniform
+
+	NoiseDistsN
+)
+
+// NoiseVal returns a noise value drawn from given distribution with
+// given mean and variance parameter (SD for Gaussian, half-range for Uniform)
+func NoiseVal(dist NoiseDists, mean, vr float32, rnd Rand) float32 {
+	rnd = RandOrGlobal(rnd)
+	switch dist {
+	case NoiseUniform:
+		return mean + vr*(2*rnd.Float32()-1)
+	default:
+		return mean + vr*float32(rnd.NormFloat64())
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  ActNoiseParams
+
+// NoiseTargets are the neural variables that ActNoiseParams noise is applied to
+type NoiseTargets int32
+
+//go:generate stringer -type=NoiseTargets
+
+var KiT_NoiseTargets = kit.Enums.AddEnum(NoiseTargetsN, kit.NotBitFlag, nil)
+
+func (ev NoiseTargets) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *NoiseTargets) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The noise targets
+const (
+	// NoiseGe adds noise to the total excitatory conductance Ge every cycle
+	NoiseGe NoiseTargets = iota
+
+	// NoiseVm adds noise to the membrane potential Vm every cycle,
+	// outside of the refractory period
+	NoiseVm
+
+	// NoiseSpike triggers a spike with probability given by the noise value
+	// on each cycle where the neuron would not otherwise spike
+	NoiseSpike
+
+	NoiseTargetsN
+)
+
+// ActNoiseParams are parameters for noise added directly to a neural
+// state variable on every cycle, complementing the background spiking
+// noise of SpikeNoiseParams.  Values are drawn from the Layer Rand,
+// so they are reproducible given Network.RandSeed.
+type ActNoiseParams struct {
+	On     bool         `desc:"add noise to the Target variable"`
+	Target NoiseTargets `viewif:"On" desc:"variable that noise is added to"`
+	Dist   NoiseDists   `viewif:"On" desc:"distribution of noise values -- for NoiseSpike the value is a per-cycle spike probability"`
+	Mean   float32      `viewif:"On" desc:"mean of the noise"`
+	Var    float32      `viewif:"On" min:"0" def:"0.01" desc:"standard deviation for Gaussian, or half-range for Uniform"`
+
+	rnd Rand // random number source, set by Layer.SetRand -- nil = global
+}
+
+func (an *ActNoiseParams) Defaults() {
+	an.Target = NoiseGe
+	an.Dist = NoiseGaussian
+	an.Var = 0.01
+}
+
+func (an *ActNoiseParams) Update() {
+}
+
+// Val returns a new noise value
+func (an *ActNoiseParams) Val() float32 {
+	return NoiseVal(an.Dist, an.Mean, an.Var, an.rnd)
+}
+
+// Ge adds noise to the given Ge conductance if Target = NoiseGe
+func (an *ActNoiseParams) Ge(ge *float32) {
+	if !an.On || an.Target != NoiseGe {
+		return
+	}
+	*ge += an.Val()
+	if *ge < 0 {
+		*ge = 0
+	}
+}
+
+// Vm adds noise to the given Vm if Target = NoiseVm
+func (an *ActNoiseParams) Vm(vm *float32) {
+	if !an.On || an.Target != NoiseVm {
+		return
+	}
+	*vm += an.Val()
+}
+
+// Spike returns true if a noise-driven spike should be triggered,
+// if Target = NoiseSpike
+func (an *ActNoiseParams) Spike() bool {
+	if !an.On || an.Target != NoiseSpike {
+		return false
+	}
+	p := mat32.Clamp(an.Val(), 0, 1)
+	return RandOrGlobal(an.rnd).Float32() < p
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  WtNoiseParams
+
+// WtNoiseParams are parameters for noise added to the DWt weight changes
+// in WtFmDWt, prior to updating LWt.  Values are drawn from the Prjn Rand,
+// so they are reproducible given Network.RandSeed.
+type WtNoiseParams struct {
+	On   bool       `desc:"add noise to the weight changes at each WtFmDWt"`
+	Dist NoiseDists `viewif:"On" desc:"distribution of noise values"`
+	Var  float32    `viewif:"On" min:"0" def:"0.001" desc:"standard deviation for Gaussian, or half-range for Uniform -- not scaled by the learning rate"`
+}
+
+func (wn *WtNoiseParams) Defaults() {
+	wn.Dist = NoiseGaussian
+	wn.Var = 0.001
+}
+
+func (wn *WtNoiseParams) Update() {
+}
+
+// DWt adds zero-mean noise to the given DWt
+func (wn *WtNoiseParams) DWt(dwt *float32, rnd Rand) {
+	*dwt += NoiseVal(wn.Dist, 0, wn.Var, rnd)
+}
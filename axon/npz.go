@@ -0,0 +1,265 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/goki/gi/gi"
+)
+
+// NumPy .npz export: a zip archive of .npy arrays (format version 1.0),
+// which can be loaded directly with numpy.load for analysis in Python
+// (PCA, RSA, etc) or conversion to other frameworks -- ONNX export
+// would require an external protobuf dependency and is not provided.
+// The archive contains, for each layer, an int64 array named
+// "<Layer>.shape" with the layer shape, and for each projection and
+// requested synapse variable a float32 matrix named "<Prjn>.<Var>" of
+// shape (recv units, send units) in receiver order (row = receiving unit),
+// with 0 for unconnected units.
+
+// SaveNPZ saves the network weights in NumPy .npz format to given file,
+// for given synapse variables (Wt if none given) -- see WriteNPZ
+func (nt *Network) SaveNPZ(filename gi.FileName, vars ...string) error {
+	fp, err := os.Create(string(filename))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer fp.Close()
+	err = nt.WriteNPZ(fp, vars...)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// WriteNPZ writes the network weights in NumPy .npz format to given writer,
+// for given synapse variables (Wt if none given)
+func (nt *Network) WriteNPZ(w io.Writer, vars ...string) error {
+	if len(vars) == 0 {
+		vars = []string{"Wt"}
+	}
+	zw := zip.NewWriter(w)
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		sh64 := make([]int64, len(ly.Shp.Shp))
+		for i, s := range ly.Shp.Shp {
+			sh64[i] = int64(s)
+		}
+		if err := writeNpy(zw, ly.Nm+".shape", "<i8", []int{len(sh64)}, sh64); err != nil {
+			return err
+		}
+	}
+	for _, l := range nt.Layers {
+		for _, p := range *l.RecvPrjns() {
+			pj := p.(AxonPrjn).AsAxon()
+			for _, vn := range vars {
+				mat, err := pj.RecvMatrix(vn)
+				if err != nil {
+					return err
+				}
+				nr := pj.Recv.Shape().Len()
+				ns := pj.Send.Shape().Len()
+				if err := writeNpy(zw, pj.Name()+"."+vn, "<f4", []int{nr, ns}, mat); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// RecvMatrix returns a dense matrix of the values of given synapse
+// variable, in receiver order: element [ri * nsend + si] is the value for
+// the synapse from sending unit si to receiving unit ri, with 0 for
+// unconnected units
+func (pj *Prjn) RecvMatrix(varNm string) ([]float32, error) {
+	vi, err := pj.AxonPrj.SynVarIdx(varNm)
+	if err != nil {
+		return nil, err
+	}
+	nr := pj.Recv.Shape().Len()
+	ns := pj.Send.Shape().Len()
+	mat := make([]float32, nr*ns)
+	for ri := 0; ri < nr; ri++ {
+		nc, st := pj.RConNSt(ri)
+		for ci := 0; ci < nc; ci++ {
+			si := int(pj.RConIdx[st+ci])
+			mat[ri*ns+si] = pj.AxonPrj.SynVal1D(vi, int(pj.RSynIdx[st+ci]))
+		}
+	}
+	return mat, nil
+}
+
+// writeNpy writes given data as a .npy file entry in the zip archive,
+// with given dtype descriptor and shape
+func writeNpy(zw *zip.Writer, name, dtype string, shape []int, data interface{}) error {
+	fw, err := zw.Create(name + ".npy")
+	if err != nil {
+		return err
+	}
+	shs := make([]string, len(shape))
+	for i, s := range shape {
+		shs[i] = fmt.Sprintf("%d", s)
+	}
+	shstr := strings.Join(shs, ", ")
+	if len(shape) == 1 {
+		shstr += ","
+	}
+	hdr := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", dtype, shstr)
+	// total of magic (6), version (2), header len (2), header and newline must be multiple of 64
+	pad := 64 - (10+len(hdr)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	hdr += strings.Repeat(" ", pad) + "\n"
+	var b bytes.Buffer
+	b.WriteString("\x93NUMPY")
+	b.Write([]byte{1, 0})
+	binary.Write(&b, binary.LittleEndian, uint16(len(hdr)))
+	b.WriteString(hdr)
+	if _, err := fw.Write(b.Bytes()); err != nil {
+		return err
+	}
+	return binary.Write(fw, binary.LittleEndian, data)
+}
+
+// OpenWtsNPZ sets the network weights from 2D float32 or float64 arrays in
+// a NumPy .npz file, named by projection (e.g., InputToHidden or
+// InputToHidden.Wt as written by SaveNPZ), with shape (recv units, send
+// units) -- see SetWtsFromDense.  Other arrays are ignored.
+// Returns the coverage for each projection that was set.
+func (nt *Network) OpenWtsNPZ(filename gi.FileName) (map[string]DenseCoverage, error) {
+	zr, err := zip.OpenReader(string(filename))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer zr.Close()
+	mats := make(map[string][][]float32)
+	for _, zf := range zr.File {
+		name := strings.TrimSuffix(zf.Name, ".npy")
+		if strings.HasSuffix(name, ".shape") {
+			continue
+		}
+		fr, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		shape, data, err := readNpy(fr)
+		fr.Close()
+		if err != nil {
+			err = fmt.Errorf("OpenWtsNPZ: array %s: %v", name, err)
+			log.Println(err)
+			return nil, err
+		}
+		if len(shape) != 2 {
+			continue
+		}
+		mat := make([][]float32, shape[0])
+		for ri := range mat {
+			mat[ri] = data[ri*shape[1] : (ri+1)*shape[1]]
+		}
+		mats[name] = mat
+	}
+	cov, err := nt.SetWtsFromDense(mats)
+	if err != nil {
+		log.Println(err)
+	}
+	return cov, err
+}
+
+// readNpy reads a .npy array of little-endian float32 or float64 values
+// in C order, returning the shape and the values as float32
+func readNpy(r io.Reader) ([]int, []float32, error) {
+	var pre [10]byte
+	if _, err := io.ReadFull(r, pre[:]); err != nil {
+		return nil, nil, err
+	}
+	if string(pre[:6]) != "\x93NUMPY" {
+		return nil, nil, fmt.Errorf("not a .npy file")
+	}
+	var hlen int
+	switch pre[6] {
+	case 1:
+		hlen = int(binary.LittleEndian.Uint16(pre[8:10]))
+	case 2, 3:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, nil, err
+		}
+		hlen = int(binary.LittleEndian.Uint32(append(pre[8:10:10], ext[:]...)))
+	default:
+		return nil, nil, fmt.Errorf("unsupported .npy version: %d", pre[6])
+	}
+	hb := make([]byte, hlen)
+	if _, err := io.ReadFull(r, hb); err != nil {
+		return nil, nil, err
+	}
+	hdr := string(hb)
+	if strings.Contains(hdr, "'fortran_order': True") {
+		return nil, nil, fmt.Errorf("fortran_order arrays not supported")
+	}
+	dtype := npyHdrVal(hdr, "'descr':")
+	dtype = strings.Trim(dtype, "' ")
+	shs := npyHdrVal(hdr, "'shape':")
+	shs = strings.Trim(shs, "() ")
+	var shape []int
+	n := 1
+	for _, s := range strings.Split(shs, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		var d int
+		if _, err := fmt.Sscanf(s, "%d", &d); err != nil {
+			return nil, nil, fmt.Errorf("invalid shape: %s", shs)
+		}
+		shape = append(shape, d)
+		n *= d
+	}
+	data := make([]float32, n)
+	switch dtype {
+	case "<f4":
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, nil, err
+		}
+	case "<f8":
+		d64 := make([]float64, n)
+		if err := binary.Read(r, binary.LittleEndian, d64); err != nil {
+			return nil, nil, err
+		}
+		for i, v := range d64 {
+			data[i] = float32(v)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported dtype: %s -- must be float32 or float64", dtype)
+	}
+	return shape, data, nil
+}
+
+// npyHdrVal returns the value for given key in a .npy header dictionary
+func npyHdrVal(hdr, key string) string {
+	i := strings.Index(hdr, key)
+	if i < 0 {
+		return ""
+	}
+	v := hdr[i+len(key):]
+	if j := strings.Index(v, ")"); j >= 0 && strings.HasPrefix(strings.TrimSpace(v), "(") {
+		return v[:j+1]
+	}
+	if j := strings.Index(v, ","); j >= 0 {
+		return v[:j]
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// PoissonInputParams are the parameters for the stochastic spike trains
+// generated by PoissonInputLayer from the applied rate pattern
+type PoissonInputParams struct {
+	MaxHz   float32 `def:"100" min:"0" desc:"spiking rate for an input value of 1 -- input values are clipped to 0..1"`
+	Refract int     `def:"2" min:"0" desc:"absolute refractory period in cycles after each spike, during which no spike can occur -- 0 = none (pure Poisson process)"`
+	RelTau  float32 `def:"0" min:"0" desc:"time constant in cycles for recovery from relative refractoriness after the absolute Refract period: the spiking probability is multiplied by 1 - exp(-t / RelTau) for t cycles after Refract -- 0 = none"`
+}
+
+func (pp *PoissonInputParams) Defaults() {
+	pp.MaxHz = 100
+	pp.Refract = 2
+	pp.RelTau = 0
+}
+
+func (pp *PoissonInputParams) Update() {
+}
+
+// Spike returns true if the neuron should spike on this cycle, for given
+// input rate value and inter-spike-interval count since the last spike
+// (-1 if it has not spiked since initialization)
+func (pp *PoissonInputParams) Spike(rate, isi float32, rnd Rand) bool {
+	p := mat32.Clamp(rate, 0, 1) * pp.MaxHz * .001
+	if p <= 0 {
+		return false
+	}
+	if isi >= 0 {
+		t := isi + 1 - float32(pp.Refract) // cycles since end of refractory period
+		if t <= 0 {
+			return false
+		}
+		if pp.RelTau > 0 {
+			p *= 1 - mat32.FastExp(-t/pp.RelTau)
+		}
+	}
+	return RandOrGlobal(rnd).Float32() < p
+}
+
+// PoissonInputLayer is an input layer whose neurons emit stochastic spike
+// trains on each cycle, with rates given by the applied input pattern (Ext)
+// times Poisson.MaxHz, subject to refractory constraints, instead of being
+// driven by a clamped excitatory conductance.  This produces the irregular
+// spike timing of real sensory inputs, which matters for studies of spiking
+// dynamics, whereas a clamped Ge produces highly regular spiking.
+// Use ApplyExt to set the rates as usual.  The layer has no membrane
+// potential dynamics, and is not intended to receive projections.
+type PoissonInputLayer struct {
+	Layer
+	Poisson PoissonInputParams `view:"inline" desc:"parameters for the generated spike trains"`
+}
+
+var KiT_PoissonInputLayer = kit.Types.AddType(&PoissonInputLayer{}, LayerProps)
+
+func (ly *PoissonInputLayer) Defaults() {
+	ly.Layer.Defaults()
+	ly.Poisson.Defaults()
+}
+
+func (ly *PoissonInputLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Poisson.Update()
+}
+
+func (ly *PoissonInputLayer) Class() string {
+	return "PoissonInput " + ly.Cls
+}
+
+// ActFmG generates the spikes from the input rates, and computes the
+// rate-code activation from them
+func (ly *PoissonInputLayer) ActFmG(ltime *Time) {
+	intdt := ly.Act.Dt.IntDt
+	if ltime.PlusPhase {
+		intdt *= 3.0
+	}
+	rnd := ly.Rnd()
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		spike := false
+		if nrn.HasFlag(NeurHasExt) {
+			spike = ly.Poisson.Spike(nrn.Ext*nrn.ClampMult, nrn.ISI, rnd)
+		}
+		ly.Act.ActFmSpike(nrn, spike)
+		nrn.ActInt += intdt * (nrn.Act - nrn.ActInt)
+		if !ly.Inference {
+			ly.Learn.AvgsFmAct(nrn)
+		}
+	}
+}
+
+// AddPoissonInputLayer adds a PoissonInputLayer of given name and shape
+// to the network, as an Input layer
+func (nt *Network) AddPoissonInputLayer(name string, shape []int) *PoissonInputLayer {
+	ly := &PoissonInputLayer{}
+	nt.AddLayerInit(ly, name, shape, emer.Input)
+	return ly
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/emer/emergent/emer"
+)
+
+// PopStats tracks population-level statistics of layer activity over epochs,
+// for comparison with population-recording data.
+//
+// PR is the participation ratio = (sum eigenvalues)^2 / sum (eigenvalues^2) of the
+// covariance of activity patterns across trials within an epoch -- an effective
+// dimensionality measure that ranges from 1 (all variance along one dimension)
+// to the number of units (isotropic).  Computed from traces, without eigen decomposition.
+//
+// TuneCorr measures representational drift, as the average across units of the
+// correlation between each unit's tuning (mean activity per condition) in this
+// epoch vs. the previous epoch -- 1 = no drift.
+//
+// Call Init with the layers to track, Record every trial with the current
+// condition label (e.g., the trial or stimulus name), and EpochEnd at the
+// end of every epoch, which computes and saves the per-epoch summaries.
+type PopStats struct {
+	Var     string          `desc:"neuron variable to record -- ActM by default"`
+	Layers  []*LayPopStats  `desc:"stats for each tracked layer"`
+	History []PopStatsEpoch `desc:"per-epoch summaries, for all layers"`
+}
+
+// PopStatsEpoch is the per-epoch summary of PopStats for one layer
+type PopStatsEpoch struct {
+	Epoch    int     `desc:"epoch number"`
+	Layer    string  `desc:"layer name"`
+	NTrials  int     `desc:"number of trials recorded in this epoch"`
+	PR       float32 `desc:"participation ratio (effective dimensionality) of activity across trials"`
+	TuneCorr float32 `desc:"average correlation of unit tuning with previous epoch (NaN for the first epoch)"`
+}
+
+// LayPopStats holds the recorded data for one layer within an epoch
+type LayPopStats struct {
+	Name     string               `desc:"name of the layer"`
+	Pats     [][]float32          `desc:"activity patterns recorded on each trial this epoch"`
+	Conds    []string             `desc:"condition label for each trial this epoch"`
+	PrvTune  map[string][]float32 `desc:"mean activity per condition for each unit from the previous epoch"`
+	PR       float32              `desc:"participation ratio computed at the last EpochEnd"`
+	TuneCorr float32              `desc:"tuning correlation computed at the last EpochEnd"`
+}
+
+// Init initializes stats for given layer names, resetting all data
+func (ps *PopStats) Init(layers ...string) {
+	if ps.Var == "" {
+		ps.Var = "ActM"
+	}
+	ps.Layers = make([]*LayPopStats, len(layers))
+	for li, nm := range layers {
+		ps.Layers[li] = &LayPopStats{Name: nm}
+	}
+	ps.History = nil
+}
+
+// Record records the current activity of all tracked layers in given network,
+// under given condition label.  Call every trial after the minus phase.
+func (ps *PopStats) Record(net emer.Network, cond string) error {
+	for _, ls := range ps.Layers {
+		ly, err := net.LayerByNameTry(ls.Name)
+		if err != nil {
+			return err
+		}
+		var vals []float32
+		if err := ly.UnitVals(&vals, ps.Var); err != nil {
+			return err
+		}
+		ls.Pats = append(ls.Pats, vals)
+		ls.Conds = append(ls.Conds, cond)
+	}
+	return nil
+}
+
+// EpochEnd computes the per-epoch statistics for all layers, adds them
+// to the History, and resets the recorded patterns for the next epoch.
+func (ps *PopStats) EpochEnd(epoch int) {
+	for _, ls := range ps.Layers {
+		ls.EpochEnd()
+		ps.History = append(ps.History, PopStatsEpoch{Epoch: epoch, Layer: ls.Name, NTrials: len(ls.Conds), PR: ls.PR, TuneCorr: ls.TuneCorr})
+		ls.Pats = ls.Pats[:0]
+		ls.Conds = ls.Conds[:0]
+	}
+}
+
+// LayerHistory returns the History for given layer name
+func (ps *PopStats) LayerHistory(lay string) []PopStatsEpoch {
+	var hs []PopStatsEpoch
+	for _, h := range ps.History {
+		if h.Layer == lay {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+// String returns a summary of the most recent epoch for each layer
+func (ps *PopStats) String() string {
+	str := ""
+	nl := len(ps.Layers)
+	st := len(ps.History) - nl
+	if st < 0 {
+		st = 0
+	}
+	for _, h := range ps.History[st:] {
+		str += fmt.Sprintf("Epoch: %d\tLayer: %s\tPR: %g\tTuneCorr: %g\n", h.Epoch, h.Layer, h.PR, h.TuneCorr)
+	}
+	return str
+}
+
+// EpochEnd computes PR and TuneCorr from the recorded patterns
+func (ls *LayPopStats) EpochEnd() {
+	ls.PR = PartRatio(ls.Pats)
+	tune := ls.Tuning()
+	ls.TuneCorr = float32(math.NaN())
+	if ls.PrvTune != nil {
+		ls.TuneCorr = TuningCorr(ls.PrvTune, tune)
+	}
+	ls.PrvTune = tune
+}
+
+// Tuning returns the mean activity pattern for each condition
+func (ls *LayPopStats) Tuning() map[string][]float32 {
+	tune := make(map[string][]float32)
+	ns := make(map[string]int)
+	for ti, pat := range ls.Pats {
+		cnd := ls.Conds[ti]
+		tv, ok := tune[cnd]
+		if !ok {
+			tv = make([]float32, len(pat))
+			tune[cnd] = tv
+		}
+		for ui, v := range pat {
+			tv[ui] += v
+		}
+		ns[cnd]++
+	}
+	for cnd, tv := range tune {
+		n := float32(ns[cnd])
+		for ui := range tv {
+			tv[ui] /= n
+		}
+	}
+	return tune
+}
+
+// PartRatio returns the participation ratio (tr C)^2 / tr(C^2) of the
+// covariance C of given patterns (one per trial), computed via the trial x trial
+// Gram matrix of the mean-centered patterns.  Returns 0 if fewer than 2 patterns.
+func PartRatio(pats [][]float32) float32 {
+	nt := len(pats)
+	if nt < 2 {
+		return 0
+	}
+	nu := len(pats[0])
+	mean := make([]float64, nu)
+	for _, pat := range pats {
+		for ui, v := range pat {
+			mean[ui] += float64(v)
+		}
+	}
+	for ui := range mean {
+		mean[ui] /= float64(nt)
+	}
+	ctr := make([][]float64, nt)
+	for ti, pat := range pats {
+		cv := make([]float64, nu)
+		for ui, v := range pat {
+			cv[ui] = float64(v) - mean[ui]
+		}
+		ctr[ti] = cv
+	}
+	var tr, tr2 float64 // normalization by 1/(nt-1) cancels in the ratio
+	for i := 0; i < nt; i++ {
+		for j := i; j < nt; j++ {
+			var g float64
+			for ui := 0; ui < nu; ui++ {
+				g += ctr[i][ui] * ctr[j][ui]
+			}
+			if i == j {
+				tr += g
+				tr2 += g * g
+			} else {
+				tr2 += 2 * g * g
+			}
+		}
+	}
+	if tr2 == 0 {
+		return 0
+	}
+	return float32(tr * tr / tr2)
+}
+
+// TuningCorr returns the average across units of the correlation of each unit's
+// tuning (mean activity across conditions) between prv and cur, using only
+// conditions present in both.  Units with no variance across conditions are skipped.
+// Returns NaN if there are fewer than 2 shared conditions.
+func TuningCorr(prv, cur map[string][]float32) float32 {
+	var conds []string
+	for cnd := range cur {
+		if _, ok := prv[cnd]; ok {
+			conds = append(conds, cnd)
+		}
+	}
+	if len(conds) < 2 {
+		return float32(math.NaN())
+	}
+	sort.Strings(conds) // deterministic order
+	nu := len(cur[conds[0]])
+	nc := float64(len(conds))
+	var sum float64
+	n := 0
+	for ui := 0; ui < nu; ui++ {
+		var pm, cm float64
+		for _, cnd := range conds {
+			pm += float64(prv[cnd][ui])
+			cm += float64(cur[cnd][ui])
+		}
+		pm /= nc
+		cm /= nc
+		var cv, pv, ccv float64
+		for _, cnd := range conds {
+			pd := float64(prv[cnd][ui]) - pm
+			cd := float64(cur[cnd][ui]) - cm
+			cv += pd * cd
+			pv += pd * pd
+			ccv += cd * cd
+		}
+		if pv == 0 || ccv == 0 {
+			continue
+		}
+		sum += cv / math.Sqrt(pv*ccv)
+		n++
+	}
+	if n == 0 {
+		return float32(math.NaN())
+	}
+	return float32(sum / float64(n))
+}
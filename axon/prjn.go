@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -28,12 +30,45 @@ type Prjn struct {
 	PrjnScale PrjnScaleParams `view:"inline" desc:"projection scaling parameters: modulates overall strength of projection, using both absolute and relative factors, with adaptation option to maintain target max conductances"`
 	SWt       SWtParams       `view:"add-fields" desc:"slowly adapting structural weight value parameters, which control initial weight values and slower outer-loop adjustments, to differentiate."`
 	Learn     LearnSynParams  `view:"add-fields" desc:"synaptic-level learning parameters for learning in the fast LWt values."`
+	GABA      GABAPrjnParams  `view:"inline" desc:"for Inhib projections: optional per-projection GABA-A / GABA-B proportions, kinetics and reversal potentials, instead of pooling into GiRaw"`
+	Prune     PruneParams     `view:"inline" desc:"structural plasticity: pruning of persistently weak synapses and regrowth of new random connections, in SlowAdapt"`
 	Syns      []Synapse       `desc:"synaptic state values, ordered by the sending layer units which owns them -- one-to-one with SConIdx array"`
 
 	// misc state variables below:
 	GScale GScaleVals  `view:"inline" desc:"conductance scaling values"`
 	Gidx   ringidx.FIx `inactive:"+" desc:"ring (circular) index for Gbuf buffer of synaptically delayed conductance increments.  The current time is always at the zero index, which is read and then shifted.  Len is delay+1."`
 	Gbuf   []float32   `desc:"conductance ring buffer for each neuron * Gidx.Len, accessed through Gidx, and length Gidx.Len in size per neuron -- weights are added with conductance delay offsets."`
+	GiA    []float32   `view:"-" desc:"for Inhib projections with GABA.On: GABA-A conductance per receiving neuron"`
+	GiB    []float32   `view:"-" desc:"for Inhib projections with GABA.On: GABA-B conductance per receiving neuron"`
+	GiBx   []float32   `view:"-" desc:"for Inhib projections with GABA.On: GABA-B internal drive variable per receiving neuron"`
+
+	STPCyc  int32   `view:"-" desc:"with Com.STP.On: cycle counter, incremented in RecvGInc, for computing time since last spike"`
+	STPLast []int32 `view:"-" desc:"with Com.STP.On: STPCyc of the last spike for each sending neuron"`
+	SynDel  []uint8 `view:"-" desc:"with Com.DelayVar: per-synapse delay, in Syns order -- drawn at InitWts"`
+
+	SynRec   []float32 `view:"-" desc:"with Com.STP.On: short-term plasticity available resources (0-1) per synapse, in Syns order -- depleted by presynaptic spikes and recovers over time (depression)"`
+	SynFac   []float32 `view:"-" desc:"with Com.STP.On: short-term plasticity utilization (facilitation) per synapse, in Syns order -- increases with presynaptic spikes and decays back over time, if TauFac > 0"`
+	SynTr    []float32 `view:"-" desc:"with SynTrOn (Learn.Trace.On, or a derived-type trace such as rl.TDRewPredPrjn with TD.Lambda > 0): eligibility trace per synapse, in Syns order, which is multiplied by a modulator (DA or error) to drive learning"`
+	SynLrate []float32 `view:"-" desc:"per-synapse learning rate multiplier, in Syns order -- set to 0 to freeze individual synapses, e.g., for curriculum learning -- nil = all 1, allocated when first set (see SetSynLrate), and reset to nil by InitWts"`
+	SynM     []float32 `view:"-" desc:"with Learn.Opt on: optimizer first moment (running average of DWt, or momentum) per synapse, in Syns order"`
+	SynV     []float32 `view:"-" desc:"with Learn.Opt on: optimizer second moment (running average of squared DWt, for Adam) per synapse, in Syns order"`
+	SynPr    []float32 `view:"-" desc:"with Com.Rel.On: probability of neurotransmitter release for each presynaptic spike per synapse, in Syns order -- initialized to Com.Rel.Init and optionally adapted in WtFmDWt"`
+
+	WtSc   []float32 `view:"-" desc:"premultiplied effective weights GScale.Scale * Wt for each synapse, in Syns order, used in SendSpike to avoid a multiply per synapse per spike -- recomputed on the next spike after GScale.Scale changes or after StaleWtSc is called, e.g., in Network WtFmDWt -- see UpdateWtSc"`
+	wtScSc float32   // GScale.Scale value that WtSc was computed with -- -1 = stale
+	WtScR  []float32 `view:"-" desc:"with Com.Gather: WtSc in receiving order (RSynIdx), for the receiver-side integration in GatherSpikes"`
+	gspk   []float32 // with Com.Gather: 1 for each sending neuron that spiked since the last GatherSpikes, else 0
+
+	UseEvents bool           `inactive:"+" desc:"event-driven spike delivery is in use, according to Com.Event -- Events are used instead of Gbuf"`
+	Events    [][]SpikeEvent `view:"-" desc:"with UseEvents: queued spike events for each slot of the Gidx ring buffer"`
+	evG       []float32      // with UseEvents: per-receiver conductance scratch for computing stats
+	evRis     []int32        // with UseEvents: receivers with non-zero evG
+
+	LesSyns []int32 `view:"-" desc:"indexes into Syns of lesioned synapses, which have their Wt held at 0 while preserving SWt and LWt -- see Lesion"`
+	Rand    Rand    `view:"-" json:"-" xml:"-" desc:"random number source for this projection, seeded from Network.RandSeed -- nil = global math/rand"`
+
+	PruneStats PruneStats `view:"inline" desc:"with Prune.On: counts of synapses pruned and regrown, for logging"`
+	PruneCnt   []int32    `view:"-" desc:"with Prune.On: number of consecutive SlowAdapt steps that each synapse (in Syns order) has had SWt below Prune.Thr"`
 }
 
 var KiT_Prjn = kit.Types.AddType(&Prjn{}, PrjnProps)
@@ -50,6 +85,8 @@ func (pj *Prjn) Defaults() {
 	pj.SWt.Defaults()
 	pj.PrjnScale.Defaults()
 	pj.Learn.Defaults()
+	pj.GABA.Defaults()
+	pj.Prune.Defaults()
 	if pj.Typ == emer.Inhib {
 		pj.SWt.Adapt.On = false
 	}
@@ -61,6 +98,8 @@ func (pj *Prjn) UpdateParams() {
 	pj.PrjnScale.Update()
 	pj.SWt.Update()
 	pj.Learn.Update()
+	pj.GABA.Update()
+	pj.Prune.Update()
 }
 
 // GScaleVals holds the conductance scaling and associated values needed for adapting scale
@@ -102,11 +141,20 @@ func (pj *Prjn) AllParams() string {
 	str += "SWt: {\n " + JsonToParams(b)
 	b, _ = json.MarshalIndent(&pj.Learn, "", " ")
 	str += "Learn: {\n " + strings.Replace(JsonToParams(b), " Lrate: {", "\n  Lrate: {", -1)
+	if pj.Typ == emer.Inhib {
+		b, _ = json.MarshalIndent(&pj.GABA, "", " ")
+		str += "GABA: {\n " + JsonToParams(b)
+	}
 	return str
 }
 
+// SynVarNames returns the names of all the variables on the synapses in this prjn,
+// including the optional SynOptVars (see SynapseVarsAll).
+// Derived types that add per-synapse state should return the full list,
+// with the extra vars after the standard SynapseVarsAll (see SynapseVarsExtend),
+// and also override SynVarIdx, SynVarNum, SynVal1D and SetSynVal1D.
 func (pj *Prjn) SynVarNames() []string {
-	return SynapseVars
+	return SynapseVarsAll
 }
 
 // SynVarProps returns properties for variables
@@ -118,11 +166,10 @@ func (pj *Prjn) SynVarProps() map[string]string {
 // (1D, flat indexes). Returns -1 if synapse not found between these two neurons.
 // Requires searching within connections for receiving unit.
 func (pj *Prjn) SynIdx(sidx, ridx int) int {
-	if sidx >= len(pj.SConN) {
+	if sidx >= pj.Send.Shape().Len() {
 		return -1
 	}
-	nc := int(pj.SConN[sidx])
-	st := int(pj.SConIdxSt[sidx])
+	nc, st := pj.SConNSt(sidx)
 	for ci := 0; ci < nc; ci++ {
 		ri := int(pj.SConIdx[st+ci])
 		if ri != ridx {
@@ -137,13 +184,65 @@ func (pj *Prjn) SynIdx(sidx, ridx int) int {
 // according to *this prjn's* SynVarNames() list (using a map to lookup index),
 // or -1 and error message if not found.
 func (pj *Prjn) SynVarIdx(varNm string) (int, error) {
+	if oi, has := SynOptVarsMap[varNm]; has {
+		return len(SynapseVars) + oi, nil
+	}
 	return SynapseVarByName(varNm)
 }
 
 // SynVarNum returns the number of synapse-level variables
 // for this prjn.  This is needed for extending indexes in derived types.
 func (pj *Prjn) SynVarNum() int {
-	return len(SynapseVars)
+	return len(SynapseVarsAll)
+}
+
+// SynOptVals returns a pointer to the per-synapse slice for optional
+// synapse variable index oi in SynOptVars (nil slice if not in use)
+func (pj *Prjn) SynOptVals(oi int) *[]float32 {
+	switch oi {
+	case synOptRec:
+		return &pj.SynRec
+	case synOptFac:
+		return &pj.SynFac
+	case synOptTr:
+		return &pj.SynTr
+	case synOptLrate:
+		return &pj.SynLrate
+	case synOptM:
+		return &pj.SynM
+	case synOptV:
+		return &pj.SynV
+	case synOptPr:
+		return &pj.SynPr
+	}
+	return nil
+}
+
+// SynTrOn returns true if the SynTr eligibility trace is in use:
+// Learn.Trace.On for the base Prjn -- see AxonPrjn
+func (pj *Prjn) SynTrOn() bool {
+	return pj.Learn.Trace.On
+}
+
+// SynLr returns the per-synapse learning rate multiplier for given synapse
+// index: 1 if SynLrate is not allocated
+func (pj *Prjn) SynLr(si int) float32 {
+	if pj.SynLrate == nil {
+		return 1
+	}
+	return pj.SynLrate[si]
+}
+
+// SetSynLrate sets the per-synapse learning rate multiplier for given
+// synapse index, allocating SynLrate (initialized to 1) if needed
+func (pj *Prjn) SetSynLrate(si int, lr float32) {
+	if len(pj.SynLrate) != len(pj.Syns) {
+		pj.SynLrate = make([]float32, len(pj.Syns))
+		for i := range pj.SynLrate {
+			pj.SynLrate[i] = 1
+		}
+	}
+	pj.SynLrate[si] = lr
 }
 
 // SynVal1D returns value of given variable index (from SynVarIdx) on given SynIdx.
@@ -157,10 +256,57 @@ func (pj *Prjn) SynVal1D(varIdx int, synIdx int) float32 {
 	if varIdx < 0 || varIdx >= pj.SynVarNum() {
 		return mat32.NaN()
 	}
+	if oi := varIdx - len(SynapseVars); oi >= 0 {
+		vals := *pj.SynOptVals(oi)
+		if len(vals) != len(pj.Syns) { // not in use
+			if oi == synOptLrate {
+				return 1
+			}
+			return 0
+		}
+		return vals[synIdx]
+	}
 	sy := &pj.Syns[synIdx]
 	return sy.VarByIndex(varIdx)
 }
 
+// SetSynVal1D sets value of given variable index (from SynVarIdx) on given SynIdx.
+// Returns false on invalid index.
+// This is the core synapse var setting method used by other methods,
+// so it is the only one that needs to be updated for derived layer types.
+func (pj *Prjn) SetSynVal1D(varIdx int, synIdx int, val float32) bool {
+	if synIdx < 0 || synIdx >= len(pj.Syns) {
+		return false
+	}
+	if varIdx < 0 || varIdx >= len(SynapseVarsAll) {
+		return false
+	}
+	if oi := varIdx - len(SynapseVars); oi >= 0 {
+		if oi == synOptLrate {
+			pj.SetSynLrate(synIdx, val)
+			return true
+		}
+		vals := *pj.SynOptVals(oi)
+		if len(vals) != len(pj.Syns) { // not in use
+			return false
+		}
+		vals[synIdx] = val
+		return true
+	}
+	sy := &pj.Syns[synIdx]
+	sy.SetVarByIndex(varIdx, val)
+	return true
+}
+
+// WtsSynVars returns the names of additional synapse variables, beyond
+// Wt and SWt, that are saved in weights files -- nil for the base Prjn.
+// Derived types with persistent per-synapse state (e.g., slowly-changing
+// traces) can return their names here, and the values are saved in the
+// MetaVals of the projection, in receiver-based order.
+func (pj *Prjn) WtsSynVars() []string {
+	return nil
+}
+
 // SynVals sets values of given variable name for each synapse, using the natural ordering
 // of the synapses (sender based for Axon),
 // into given float32 slice (only resized if not big enough).
@@ -182,6 +328,16 @@ func (pj *Prjn) SynVals(vals *[]float32, varNm string) error {
 	return nil
 }
 
+// SynValsPy returns values of given variable name for each synapse, using the
+// natural ordering of the synapses (sender based for Axon), as a new float32
+// slice (nil on invalid var name).
+// Py is Python version, returning the values instead of filling a slice pointer.
+func (pj *Prjn) SynValsPy(varNm string) []float32 {
+	var vals []float32
+	pj.SynVals(&vals, varNm)
+	return vals
+}
+
 // SynVal returns value of given variable name on the synapse
 // between given send, recv unit indexes (1D, flat indexes).
 // Returns mat32.NaN() for access errors (see SynValTry for error message)
@@ -206,13 +362,16 @@ func (pj *Prjn) SetSynVal(varNm string, sidx, ridx int, val float32) error {
 	if synIdx < 0 || synIdx >= len(pj.Syns) {
 		return err
 	}
+	if !pj.AxonPrj.SetSynVal1D(vidx, synIdx, val) {
+		return fmt.Errorf("Prjn SetSynVal: variable name: %v not valid for setting", varNm)
+	}
 	sy := &pj.Syns[synIdx]
-	sy.SetVarByIndex(vidx, val)
 	if varNm == "Wt" {
 		if sy.SWt == 0 {
 			sy.SWt = sy.Wt
 		}
 		sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt)
+		pj.StaleWtSc()
 	}
 	return nil
 }
@@ -240,6 +399,9 @@ func (pj *Prjn) WriteWtsJSON(w io.Writer, depth int) {
 	depth--
 	w.Write(indent.TabBytes(depth))
 	w.Write([]byte("},\n"))
+	if wvars := pj.AxonPrj.WtsSynVars(); len(wvars) > 0 {
+		pj.writeWtsSynVarsJSON(w, depth, wvars)
+	}
 	// w.Write(indent.TabBytes(depth))
 	// w.Write([]byte(fmt.Sprintf("\"MetaVals\": {\n")))
 	// depth++
@@ -260,8 +422,7 @@ func (pj *Prjn) WriteWtsJSON(w io.Writer, depth int) {
 	w.Write([]byte(fmt.Sprintf("\"Rs\": [\n")))
 	depth++
 	for ri := 0; ri < nr; ri++ {
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		w.Write(indent.TabBytes(depth))
 		w.Write([]byte("{\n"))
 		depth++
@@ -323,6 +484,39 @@ func (pj *Prjn) WriteWtsJSON(w io.Writer, depth int) {
 	w.Write([]byte("}")) // note: leave unterminated as outer loop needs to add , or just \n depending
 }
 
+// writeWtsSynVarsJSON writes the values of given additional synapse variables
+// as MetaVals, in receiver-based order (same as the Rs Wt values)
+func (pj *Prjn) writeWtsSynVarsJSON(w io.Writer, depth int, wvars []string) {
+	w.Write(indent.TabBytes(depth))
+	w.Write([]byte("\"MetaVals\": {\n"))
+	depth++
+	nv := len(wvars)
+	nr := pj.Recv.Shape().Len()
+	for vi, vnm := range wvars {
+		vidx, _ := pj.AxonPrj.SynVarIdx(vnm) // invalid = NaN values
+		w.Write(indent.TabBytes(depth))
+		w.Write([]byte(fmt.Sprintf("%q: [ ", vnm)))
+		for ri := 0; ri < nr; ri++ {
+			nc, st := pj.RConNSt(ri)
+			for ci := 0; ci < nc; ci++ {
+				rsi := int(pj.RSynIdx[st+ci])
+				w.Write([]byte(strconv.FormatFloat(float64(pj.AxonPrj.SynVal1D(vidx, rsi)), 'g', weights.Prec, 32)))
+				if ri < nr-1 || ci < nc-1 {
+					w.Write([]byte(", "))
+				}
+			}
+		}
+		if vi == nv-1 {
+			w.Write([]byte(" ]\n"))
+		} else {
+			w.Write([]byte(" ],\n"))
+		}
+	}
+	depth--
+	w.Write(indent.TabBytes(depth))
+	w.Write([]byte("},\n"))
+}
+
 // ReadWtsJSON reads the weights from this projection from the receiver-side perspective
 // in a JSON text format.  This is for a set of weights that were saved *for one prjn only*
 // and is not used for the network-level ReadWtsJSON, which reads into a separate
@@ -360,6 +554,45 @@ func (pj *Prjn) SetWts(pw *weights.Prjn) error {
 			}
 		}
 	}
+	if er := pj.setWtsSynVars(pw); er != nil {
+		err = er
+	}
+	pj.ZeroLesioned()
+	return err
+}
+
+// setWtsSynVars sets the additional synapse variables in WtsSynVars
+// from the MetaVals of weights.Prjn decoded values, in receiver-based order
+func (pj *Prjn) setWtsSynVars(pw *weights.Prjn) error {
+	if pw.MetaVals == nil {
+		return nil
+	}
+	var err error
+	for _, vnm := range pj.AxonPrj.WtsSynVars() {
+		vals, ok := pw.MetaVals[vnm]
+		if !ok {
+			continue
+		}
+		vidx, er := pj.AxonPrj.SynVarIdx(vnm)
+		if er != nil {
+			err = er
+			continue
+		}
+		vi := 0
+		for i := range pw.Rs {
+			pr := &pw.Rs[i]
+			for si := range pr.Si {
+				if vi >= len(vals) {
+					break
+				}
+				synIdx := pj.SynIdx(pr.Si[si], pr.Ri)
+				if !pj.AxonPrj.SetSynVal1D(vidx, synIdx, vals[vi]) {
+					err = fmt.Errorf("Prjn %v SetWts: could not set synapse variable: %v", pj.Name(), vnm)
+				}
+				vi++
+			}
+		}
+	}
 	return err
 }
 
@@ -370,15 +603,27 @@ func (pj *Prjn) Build() error {
 		return err
 	}
 	pj.Syns = make([]Synapse, len(pj.SConIdx))
+	pj.WtSc = nil
+	pj.WtScR = nil
+	pj.gspk = nil
+	pj.wtScSc = -1
 	pj.BuildGbuf()
+	pj.BuildGABA()
 	return nil
 }
 
-// BuildGbuf builds Gbuf with current Com Delay values, if not correct size
+// BuildGbuf builds Gbuf with current Com Delay values (MaxDelay), if not correct size
 func (pj *Prjn) BuildGbuf() {
 	rlen := pj.Recv.Shape().Len()
-	dl := pj.Com.Delay + 1
-	if pj.Gidx.Len == dl && len(pj.Gbuf) == dl {
+	dl := pj.Com.MaxDelay() + 1
+	pj.UseEvents = pj.EventsOK()
+	if pj.UseEvents {
+		pj.BuildEvents(dl, rlen)
+		return
+	}
+	pj.Events = nil
+	pj.evG = nil
+	if pj.Gidx.Len == dl && len(pj.Gbuf) == dl*rlen {
 		return
 	}
 	pj.Gidx.Len = dl
@@ -386,6 +631,20 @@ func (pj *Prjn) BuildGbuf() {
 	pj.Gbuf = make([]float32, dl*rlen)
 }
 
+// BuildGABA allocates the per-neuron GABA-A / GABA-B state for Inhib projections
+func (pj *Prjn) BuildGABA() {
+	if !pj.IsInhib() {
+		return
+	}
+	rlen := pj.Recv.Shape().Len()
+	if len(pj.GiA) == rlen {
+		return
+	}
+	pj.GiA = make([]float32, rlen)
+	pj.GiB = make([]float32, rlen)
+	pj.GiBx = make([]float32, rlen)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Init methods
 
@@ -420,8 +679,7 @@ func (pj *Prjn) SetSWtsRPool(swts etensor.Tensor) {
 						ri = rsh.Offset([]int{rpy, rpx, ruy, rux})
 					}
 					scst := (ruy*rNuX + rux) * rfsz
-					nc := int(pj.RConN[ri])
-					st := int(pj.RConIdxSt[ri])
+					nc, st := pj.RConNSt(ri)
 					for ci := 0; ci < nc; ci++ {
 						// si := int(pj.RConIdx[st+ci]) // could verify coords etc
 						rsi := pj.RSynIdx[st+ci]
@@ -435,6 +693,7 @@ func (pj *Prjn) SetSWtsRPool(swts etensor.Tensor) {
 			}
 		}
 	}
+	pj.StaleWtSc()
 }
 
 // SetWtsFunc initializes synaptic Wt value using given function
@@ -446,8 +705,7 @@ func (pj *Prjn) SetWtsFunc(wtFun func(si, ri int, send, recv *etensor.Shape) flo
 	ssh := pj.Send.Shape()
 
 	for ri := 0; ri < rn; ri++ {
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		for ci := 0; ci < nc; ci++ {
 			si := int(pj.RConIdx[st+ci])
 			rsi := pj.RSynIdx[st+ci]
@@ -458,6 +716,7 @@ func (pj *Prjn) SetWtsFunc(wtFun func(si, ri int, send, recv *etensor.Shape) flo
 			sy.LWt = 0.5
 		}
 	}
+	pj.StaleWtSc()
 }
 
 // SetSWtsFunc initializes structural SWt values using given function
@@ -468,8 +727,7 @@ func (pj *Prjn) SetSWtsFunc(swtFun func(si, ri int, send, recv *etensor.Shape) f
 	ssh := pj.Send.Shape()
 
 	for ri := 0; ri < rn; ri++ {
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		for ci := 0; ci < nc; ci++ {
 			si := int(pj.RConIdx[st+ci])
 			swt := swtFun(si, ri, ssh, rsh)
@@ -480,13 +738,71 @@ func (pj *Prjn) SetSWtsFunc(swtFun func(si, ri int, send, recv *etensor.Shape) f
 			sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt)
 		}
 	}
+	pj.StaleWtSc()
+}
+
+// SetWtsGaussTopo initializes SWt and Wt values with a Gaussian fall-off as a
+// function of the distance between the relative positions of the sending and
+// receiving units within their respective layers, mapped into the SWt.Limit range.
+// Positions are normalized to 0-1 over each layer, so layers of different sizes
+// are aligned, and sigma is in these normalized units (e.g., .2 = 20% of layer size).
+// For 4D layers, the position combines the pool position and the unit position
+// within the pool.  If wrap is true, distances wrap around the layer edges.
+// Call after InitWts.
+func (pj *Prjn) SetWtsGaussTopo(sigma float32, wrap bool) {
+	if sigma <= 0 {
+		return
+	}
+	lim := pj.SWt.Limit
+	s2 := 2 * sigma * sigma
+	pj.SetSWtsFunc(func(si, ri int, send, recv *etensor.Shape) float32 {
+		sy, sx := TopoPos(si, send)
+		ry, rx := TopoPos(ri, recv)
+		dy := TopoDist(sy, ry, wrap)
+		dx := TopoDist(sx, rx, wrap)
+		g := mat32.FastExp(-(dy*dy + dx*dx) / s2)
+		return lim.Min + g*lim.Range()
+	})
+}
+
+// TopoPos returns the normalized (0-1) Y, X position of the unit at given
+// index within a layer of given shape, for 2D or 4D (pool-aware) shapes
+func TopoPos(idx int, sh *etensor.Shape) (y, x float32) {
+	switch sh.NumDims() {
+	case 4:
+		nuy, nux := sh.Dim(2), sh.Dim(3)
+		npy, npx := float32(sh.Dim(0)), float32(sh.Dim(1))
+		pi := idx / (nuy * nux)
+		ui := idx % (nuy * nux)
+		y = (float32(pi/sh.Dim(1)) + (float32(ui/nux)+0.5)/float32(nuy)) / npy
+		x = (float32(pi%sh.Dim(1)) + (float32(ui%nux)+0.5)/float32(nux)) / npx
+	case 2:
+		ny, nx := sh.Dim(0), sh.Dim(1)
+		y = (float32(idx/nx) + 0.5) / float32(ny)
+		x = (float32(idx%nx) + 0.5) / float32(nx)
+	default:
+		n := sh.Len()
+		y = 0.5
+		x = (float32(idx) + 0.5) / float32(n)
+	}
+	return
+}
+
+// TopoDist returns the absolute distance between two normalized positions,
+// wrapping around the 0-1 range if wrap is true
+func TopoDist(a, b float32, wrap bool) float32 {
+	d := mat32.Abs(a - b)
+	if wrap && d > 0.5 {
+		d = 1 - d
+	}
+	return d
 }
 
 // InitWtsSyn initializes weight values based on WtInit randomness parameters
 // for an individual synapse.
 // It also updates the linear weight value based on the sigmoidal weight value.
 func (pj *Prjn) InitWtsSyn(sy *Synapse, mean, spct float32) {
-	wtv := pj.SWt.Init.RndVar()
+	wtv := pj.SWt.Init.RndVar(pj.Rnd())
 	sy.Wt = mean + wtv
 	sy.SWt = pj.SWt.ClipSWt(mean + spct*wtv)
 	sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt)
@@ -494,10 +810,74 @@ func (pj *Prjn) InitWtsSyn(sy *Synapse, mean, spct float32) {
 	sy.DSWt = 0
 }
 
+// InitSynOpt initializes the optional per-synapse state (see SynOptVars)
+// for the features in use, freeing any others -- called in InitWts,
+// after InitGbuf, which initializes the STP state.
+func (pj *Prjn) InitSynOpt() {
+	pj.SynTr = nil
+	pj.SynLrate = nil
+	pj.SynM = nil
+	pj.SynV = nil
+	pj.SynPr = nil
+	pj.AllocSynOpt()
+}
+
+// AllocSynOpt allocates the optional per-synapse state (see SynOptVars)
+// for the features currently in use, if not already allocated, with the
+// initial values as set in InitWts.  Called at the start of DWt, WtFmDWt
+// and SendSpike with STP or Rel, so features can be turned on after InitWts.
+func (pj *Prjn) AllocSynOpt() {
+	ns := len(pj.Syns)
+	if pj.Com.STP.On && (len(pj.SynRec) != ns || len(pj.STPLast) != pj.Send.Shape().Len()) {
+		pj.InitSTP()
+	}
+	if pj.AxonPrj.SynTrOn() && len(pj.SynTr) != ns {
+		pj.SynTr = make([]float32, ns)
+	}
+	if pj.Learn.Opt.On() && len(pj.SynM) != ns {
+		pj.SynM = make([]float32, ns)
+		pj.SynV = make([]float32, ns)
+	}
+	if pj.Com.Rel.On && len(pj.SynPr) != ns {
+		pj.SynPr = make([]float32, ns)
+		for si := range pj.SynPr {
+			pj.SynPr[si] = pj.Com.Rel.Init
+		}
+	}
+}
+
+// InitSynOptIdx initializes the optional per-synapse state (see SynOptVars)
+// of given synapse index, e.g., for a regrown synapse (see PruneSyns)
+func (pj *Prjn) InitSynOptIdx(si int) {
+	if pj.SynRec != nil {
+		pj.Com.STP.Init(&pj.SynRec[si], &pj.SynFac[si])
+	}
+	if pj.SynTr != nil {
+		pj.SynTr[si] = 0
+	}
+	if pj.SynLrate != nil {
+		pj.SynLrate[si] = 1
+	}
+	if pj.SynM != nil {
+		pj.SynM[si] = 0
+		pj.SynV[si] = 0
+	}
+	if pj.SynPr != nil {
+		pj.SynPr[si] = pj.Com.Rel.Init
+	}
+}
+
 // InitWts initializes weight values according to SWt params,
 // enforcing current constraints.
 func (pj *Prjn) InitWts() {
+	if pj.Learn.Learn {
+		if err := pj.Learn.Validate(); err != nil {
+			log.Printf("Prjn %v: %v\n", pj.Name(), err)
+		}
+	}
 	pj.Learn.Lrate.Init()
+	pj.Learn.LrSched.Init()
+	pj.Learn.Opt.Init()
 	pj.AxonPrj.InitGbuf()
 	rlay := pj.Recv.(AxonLayer).AsAxon()
 	spct := pj.SWt.Init.SPct
@@ -511,8 +891,7 @@ func (pj *Prjn) InitWts() {
 		if nrn.IsOff() {
 			continue
 		}
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		rsidxs := pj.RSynIdx[st : st+nc]
 		for _, rsi := range rsidxs {
 			sy := &pj.Syns[rsi]
@@ -522,6 +901,11 @@ func (pj *Prjn) InitWts() {
 	if pj.SWt.Adapt.On && !rlay.AxonLay.IsTarget() {
 		pj.SWtRescale()
 	}
+	pj.InitSynOpt()
+	pj.PruneCnt = nil
+	pj.PruneStats.Init()
+	pj.ZeroLesioned()
+	pj.StaleWtSc()
 }
 
 // SWtRescale rescales the SWt values to preserve the target overall mean value,
@@ -534,8 +918,7 @@ func (pj *Prjn) SWtRescale() {
 		if nrn.IsOff() {
 			continue
 		}
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		rsidxs := pj.RSynIdx[st : st+nc]
 
 		var nmin, nmax int
@@ -583,6 +966,7 @@ func (pj *Prjn) SWtRescale() {
 			}
 		}
 	}
+	pj.StaleWtSc()
 }
 
 // InitWtSym initializes weight symmetry -- is given the reciprocal projection where
@@ -592,22 +976,22 @@ func (pj *Prjn) InitWtSym(rpjp AxonPrjn) {
 	slay := pj.Send.(AxonLayer).AsAxon()
 	ns := int32(len(slay.Neurons))
 	for si := int32(0); si < ns; si++ {
-		nc := pj.SConN[si]
-		st := pj.SConIdxSt[si]
+		snc, sst := pj.SConNSt(int(si))
+		nc, st := int32(snc), int32(sst)
 		for ci := int32(0); ci < nc; ci++ {
 			sy := &pj.Syns[st+ci]
 			ri := pj.SConIdx[st+ci]
 			// now we need to find the reciprocal synapse on rpj!
 			// look in ri for sending connections
 			rsi := ri
-			if len(rpj.SConN) == 0 {
+			if len(rpj.SConIdx) == 0 {
 				continue
 			}
-			rsnc := rpj.SConN[rsi]
+			rnc, rst := rpj.SConNSt(int(rsi))
+			rsnc, rsst := int32(rnc), int32(rst)
 			if rsnc == 0 {
 				continue
 			}
-			rsst := rpj.SConIdxSt[rsi]
 			rist := rpj.SConIdx[rsst]        // starting index in recv prjn
 			ried := rpj.SConIdx[rsst+rsnc-1] // ending index
 			if si < rist || si > ried {      // fast reject -- prjns are always in order!
@@ -656,6 +1040,7 @@ func (pj *Prjn) InitWtSym(rpjp AxonPrjn) {
 			}
 		}
 	}
+	rpj.StaleWtSc()
 }
 
 // InitGbuf initializes the G buffer values to 0
@@ -665,77 +1050,411 @@ func (pj *Prjn) InitGbuf() {
 	for ri := range pj.Gbuf {
 		pj.Gbuf[ri] = 0
 	}
+	pj.InitEvents()
+	pj.BuildGABA()
+	for ri := range pj.GiA {
+		pj.GiA[ri] = 0
+		pj.GiB[ri] = 0
+		pj.GiBx[ri] = 0
+	}
+	pj.InitSTP()
+	pj.InitSynDel()
+}
+
+// InitSynDel draws the per-synapse delays if Com.DelayVar, and otherwise
+// clears them
+func (pj *Prjn) InitSynDel() {
+	if !pj.Com.DelayVar {
+		pj.SynDel = nil
+		return
+	}
+	if len(pj.SynDel) != len(pj.Syns) {
+		pj.SynDel = make([]uint8, len(pj.Syns))
+	}
+	rnd := pj.Rnd()
+	for si := range pj.SynDel {
+		pj.SynDel[si] = uint8(pj.Com.RndDelay(rnd))
+	}
+}
+
+// InitSTP initializes the short-term plasticity state, if Com.STP.On,
+// and otherwise frees it
+func (pj *Prjn) InitSTP() {
+	if !pj.Com.STP.On {
+		pj.SynRec = nil
+		pj.SynFac = nil
+		return
+	}
+	slen := pj.Send.Shape().Len()
+	if len(pj.STPLast) != slen {
+		pj.STPLast = make([]int32, slen)
+	}
+	pj.STPCyc = 0
+	for si := range pj.STPLast {
+		pj.STPLast[si] = -STPInitISI
+	}
+	ns := len(pj.Syns)
+	if len(pj.SynRec) != ns {
+		pj.SynRec = make([]float32, ns)
+		pj.SynFac = make([]float32, ns)
+	}
+	for si := range pj.SynRec {
+		pj.Com.STP.Init(&pj.SynRec[si], &pj.SynFac[si])
+	}
 }
 
+// STPInitISI is the number of cycles since the last spike assumed at
+// initialization, long enough for STP state to have fully recovered
+const STPInitISI = 100000
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Act methods
 
 // SendSpike sends a spike from sending neuron index si,
 // to add to buffer on receivers.
 func (pj *Prjn) SendSpike(si int) {
-	sc := pj.GScale.Scale
+	if pj.GatherOK() {
+		if len(pj.gspk) != pj.Send.Shape().Len() {
+			pj.gspk = make([]float32, pj.Send.Shape().Len())
+		}
+		pj.gspk[si] = 1
+		return
+	}
+	if pj.wtScSc != pj.GScale.Scale || len(pj.WtSc) != len(pj.Syns) {
+		pj.UpdateWtSc()
+	}
 	del := pj.Com.Delay
-	sz := del + 1
+	sz := pj.Gidx.Len
 	di := pj.Gidx.Idx(del) // index in buffer to put new values -- end of line
-	nc := pj.SConN[si]
-	st := pj.SConIdxSt[si]
-	syns := pj.Syns[st : st+nc]
+	nc, st := pj.SConNSt(si)
 	scons := pj.SConIdx[st : st+nc]
-	for ci := range syns {
-		ri := scons[ci]
-		pj.Gbuf[int(ri)*sz+di] += sc * syns[ci].Wt // todo: extra mult here -- premultiply is better
+	wscs := pj.WtSc[st : st+nc]
+	if pj.Com.STP.On || pj.Com.Rel.On {
+		pj.AllocSynOpt()
+	}
+	if pj.UseEvents {
+		var dels []uint8
+		if pj.SynDel != nil {
+			dels = pj.SynDel[st : st+nc]
+		}
+		pj.SendSpikeEvent(si, wscs, st, scons, dels)
+		return
+	}
+	if pj.SynDel != nil {
+		pj.SendSpikeDel(si, wscs, sz, st, scons, pj.SynDel[st:st+nc])
+		return
+	}
+	if pj.Com.Rel.On {
+		pj.SendSpikeRel(si, wscs, sz, di, st, scons)
+		return
+	}
+	if pj.Com.STP.On {
+		pj.SendSpikeSTP(si, wscs, sz, di, st, scons)
+		return
+	}
+	for ci, ri := range scons {
+		pj.Gbuf[int(ri)*sz+di] += wscs[ci]
+	}
+}
+
+// SendSpikesRecvRange sends the spikes of all spiking sending neurons,
+// adding only to the conductance buffer of receiving neurons in range
+// [stIdx, edIdx) -- used for chunked parallel computation (see
+// Network.PoolSendSpike), as each chunk then writes only its own part of
+// Gbuf, in the same order as SendSpike.  Only for the standard spike
+// delivery (SendChunkOK), and WtSc must be up to date.  Relies on SConIdx
+// being in ascending receiver order for each sender, as built by BuildStru.
+func (pj *Prjn) SendSpikesRecvRange(stIdx, edIdx int) {
+	slay := pj.Send.(AxonLayer).AsAxon()
+	sz := pj.Gidx.Len
+	di := pj.Gidx.Idx(pj.Com.Delay)
+	for si := range slay.Neurons {
+		nrn := &slay.Neurons[si]
+		if nrn.IsOff() || nrn.Spike == 0 {
+			continue
+		}
+		nc, st := pj.SConNSt(si)
+		scons := pj.SConIdx[st : st+nc]
+		wscs := pj.WtSc[st : st+nc]
+		ci := sort.Search(nc, func(i int) bool { return int(scons[i]) >= stIdx })
+		for ; ci < nc; ci++ {
+			ri := int(scons[ci])
+			if ri >= edIdx {
+				break
+			}
+			pj.Gbuf[ri*sz+di] += wscs[ci]
+		}
+	}
+}
+
+// UpdateWtSc updates the premultiplied GScale.Scale * Wt effective weights
+// in WtSc used in SendSpike.  This is called automatically on the next
+// spike when GScale.Scale changes, or after StaleWtSc has been called.
+func (pj *Prjn) UpdateWtSc() {
+	sc := pj.GScale.Scale
+	if len(pj.WtSc) != len(pj.Syns) {
+		pj.WtSc = make([]float32, len(pj.Syns))
+	}
+	for si := range pj.Syns {
+		pj.WtSc[si] = sc * pj.Syns[si].Wt
+	}
+	if pj.GatherOK() {
+		if len(pj.WtScR) != len(pj.Syns) {
+			pj.WtScR = make([]float32, len(pj.Syns))
+		}
+		for ci, rsi := range pj.RSynIdx {
+			pj.WtScR[ci] = pj.WtSc[rsi]
+		}
+	} else {
+		pj.WtScR = nil
+	}
+	pj.wtScSc = sc
+}
+
+// GatherOK returns true if the receiver-side integration of spikes
+// (Com.Gather) is used for this projection: requires a fixed fan-in
+// and the standard spike delivery without STP, Rel, per-synapse delays
+// or events.
+func (pj *Prjn) GatherOK() bool {
+	return pj.Com.Gather && pj.RFanIn > 0 && !pj.UseEvents && pj.SynDel == nil && !pj.Com.Rel.On && !pj.Com.STP.On
+}
+
+// GatherSpikes integrates the spikes recorded by SendSpike since the last
+// call into the conductance buffer, in Com.Gather mode: for each receiver,
+// the WtScR weights of its contiguous connections are summed, masked by
+// the sender spikes.  The result is identical to SendSpike scattering.
+func (pj *Prjn) GatherSpikes() {
+	if len(pj.gspk) == 0 {
+		return
+	}
+	if pj.wtScSc != pj.GScale.Scale || len(pj.WtScR) != len(pj.Syns) {
+		pj.UpdateWtSc()
+	}
+	spk := pj.gspk
+	sz := pj.Gidx.Len
+	di := pj.Gidx.Idx(pj.Com.Delay)
+	fan := int(pj.RFanIn)
+	rlen := pj.Recv.Shape().Len()
+	for ri := 0; ri < rlen; ri++ {
+		st := ri * fan
+		rcons := pj.RConIdx[st : st+fan]
+		wscs := pj.WtScR[st : st+fan]
+		g := float32(0)
+		for ci, si := range rcons {
+			g += wscs[ci] * spk[si]
+		}
+		pj.Gbuf[ri*sz+di] += g
+	}
+	for si := range spk {
+		spk[si] = 0
+	}
+}
+
+// StaleWtSc marks the premultiplied WtSc effective weights as needing
+// to be updated on the next spike -- must be called after any direct
+// change to synaptic Wt values: all of the Prjn and Network methods that
+// set Wt (InitWts, WtFmDWt, SynFail, SetWtsFunc, weight loading, etc)
+// call it automatically.
+func (pj *Prjn) StaleWtSc() {
+	pj.wtScSc = -1
+}
+
+// SendSpikeSTP sends a spike with short-term plasticity modulating the
+// efficacy of each synapse, and updates the per-synapse STP state,
+// for the synapses starting at index st in Syns order
+func (pj *Prjn) SendSpikeSTP(si int, wscs []float32, sz, di, st int, scons []int32) {
+	isi := pj.STPCyc - pj.STPLast[si]
+	pj.STPLast[si] = pj.STPCyc
+	recs := pj.SynRec[st : st+len(scons)]
+	facs := pj.SynFac[st : st+len(scons)]
+	for ci, ri := range scons {
+		eff := pj.Com.STP.Spike(&recs[ci], &facs[ci], isi)
+		pj.Gbuf[int(ri)*sz+di] += wscs[ci] * eff
+	}
+}
+
+// SendSpikeRel sends a spike with stochastic release according to the
+// per-synapse release probability (Com.Rel), including short-term
+// plasticity if Com.STP.On -- synapses that fail to release only
+// recover their STP resources.  For the synapses starting at index st
+// in Syns order.
+func (pj *Prjn) SendSpikeRel(si int, wscs []float32, sz, di, st int, scons []int32) {
+	stp := pj.Com.STP.On
+	var isi int32
+	var recs, facs []float32
+	if stp {
+		isi = pj.STPCyc - pj.STPLast[si]
+		pj.STPLast[si] = pj.STPCyc
+		recs = pj.SynRec[st : st+len(scons)]
+		facs = pj.SynFac[st : st+len(scons)]
+	}
+	prs := pj.SynPr[st : st+len(scons)]
+	sact := pj.Send.(AxonLayer).AsAxon().Neurons[si].Act
+	rnd := pj.Rnd()
+	for ci, ri := range scons {
+		if !pj.Com.Rel.Release(prs[ci], sact, rnd) {
+			if stp {
+				pj.Com.STP.Recover(&recs[ci], &facs[ci], isi)
+			}
+			continue
+		}
+		g := wscs[ci]
+		if stp {
+			g *= pj.Com.STP.Spike(&recs[ci], &facs[ci], isi)
+		}
+		pj.Gbuf[int(ri)*sz+di] += g
+	}
+}
+
+// SendSpikeDel sends a spike with per-synapse delays (Com.DelayVar),
+// including short-term plasticity if Com.STP.On, and stochastic
+// release if Com.Rel.On, for the synapses starting at index st in Syns order
+func (pj *Prjn) SendSpikeDel(si int, wscs []float32, sz, st int, scons []int32, dels []uint8) {
+	stp := pj.Com.STP.On
+	var isi int32
+	var recs, facs []float32
+	if stp {
+		isi = pj.STPCyc - pj.STPLast[si]
+		pj.STPLast[si] = pj.STPCyc
+		recs = pj.SynRec[st : st+len(scons)]
+		facs = pj.SynFac[st : st+len(scons)]
+	}
+	rel := pj.Com.Rel.On
+	var sact float32
+	var rnd Rand
+	var prs []float32
+	if rel {
+		sact = pj.Send.(AxonLayer).AsAxon().Neurons[si].Act
+		rnd = pj.Rnd()
+		prs = pj.SynPr[st : st+len(scons)]
+	}
+	for ci, ri := range scons {
+		if rel && !pj.Com.Rel.Release(prs[ci], sact, rnd) {
+			if stp {
+				pj.Com.STP.Recover(&recs[ci], &facs[ci], isi)
+			}
+			continue
+		}
+		di := pj.Gidx.Idx(int(dels[ci]))
+		g := wscs[ci]
+		if stp {
+			g *= pj.Com.STP.Spike(&recs[ci], &facs[ci], isi)
+		}
+		pj.Gbuf[int(ri)*sz+di] += g
 	}
 }
 
 // RecvGInc increments the receiver's GeRaw or GiRaw from that of all the projections.
 func (pj *Prjn) RecvGInc(ltime *Time) {
-	if ltime.PlusPhase {
+	pj.STPCyc++
+	if pj.GatherOK() {
+		pj.GatherSpikes()
+	}
+	inf := pj.Recv.(AxonLayer).AsAxon().Inference
+	if pj.UseEvents {
+		pj.RecvGIncEvent(!ltime.PlusPhase && !inf)
+		return
+	}
+	if ltime.PlusPhase || inf {
 		pj.RecvGIncNoStats()
 	} else {
 		pj.RecvGIncStats()
 	}
 }
 
+// DecayGABA decays the per-projection GABA-A state by decayA proportion
+// and the slower GABA-B state by decayB, if GABA.On.
+func (pj *Prjn) DecayGABA(decayA, decayB float32) {
+	if !pj.GABA.On {
+		return
+	}
+	for ri := range pj.GiA {
+		pj.GiA[ri] -= decayA * pj.GiA[ri]
+		pj.GiB[ri] -= decayB * pj.GiB[ri]
+		pj.GiBx[ri] -= decayB * pj.GiBx[ri]
+	}
+}
+
+// GiInc increments the inhibitory conductance of receiving neuron rn (index ri)
+// from raw conductance g -- into GiRaw by default, or integrated through the
+// per-projection GABA-A / GABA-B kinetics into GiPrjnRaw when GABA.On.
+func (pj *Prjn) GiInc(rn *Neuron, ri int, g float32) {
+	if !pj.GABA.On || pj.GiA == nil {
+		rn.GiRaw += g
+		return
+	}
+	ga := &pj.GiA[ri]
+	gb := &pj.GiB[ri]
+	pj.GABA.GFmRaw(g, ga, gb, &pj.GiBx[ri])
+	rn.GiPrjnRaw += *ga + *gb
+	rn.ErevPrjnRaw += *ga*pj.GABA.ErevA + *gb*pj.GABA.ErevB
+}
+
+// GTarget returns the effective postsynaptic conductance target for this
+// projection, resolving GDefault according to Prjn.Typ
+func (pj *Prjn) GTarget() GTargets {
+	if pj.Com.Target != GDefault {
+		return pj.Com.Target
+	}
+	if pj.Typ == emer.Inhib {
+		return GInh
+	}
+	return GExc
+}
+
+// IsInhib returns true if this projection sends inhibitory conductance
+func (pj *Prjn) IsInhib() bool {
+	return pj.GTarget() == GInh
+}
+
+// GInc increments the conductance of receiving neuron rn (index ri) from raw
+// conductance g, according to given target (from GTarget)
+func (pj *Prjn) GInc(rn *Neuron, ri int, g float32, tg GTargets) {
+	switch tg {
+	case GInh:
+		pj.GiInc(rn, ri, g)
+	case GDend:
+		rn.GeDendRaw += g
+	case GNMDA:
+		rn.NMDARaw += g
+	case GMod:
+		rn.ModRaw += g
+	default:
+		rn.GeRaw += g
+	}
+}
+
 // RecvGIncStats is called every cycle during minus phase,
-// to increment GeRaw or GiRaw, and also collect stats about conductances.
+// to increment GeRaw, GiRaw or other conductance targets (see GTarget),
+// and also collect stats about conductances.
 func (pj *Prjn) RecvGIncStats() {
 	rlay := pj.Recv.(AxonLayer).AsAxon()
-	del := pj.Com.Delay
-	sz := del + 1
+	sz := pj.Gidx.Len
 	zi := pj.Gidx.Zi
+	tg := pj.GTarget()
 	var max, avg float32
 	var n int
-	if pj.Typ == emer.Inhib {
-		for ri := range rlay.Neurons {
-			bi := ri*sz + zi
-			rn := &rlay.Neurons[ri]
-			g := pj.Gbuf[bi]
-			rn.GiRaw += g
-			pj.Gbuf[bi] = 0
-			if g > max {
-				max = g
-			}
-			if g > 0 {
-				avg += g
-				n++
-			}
+	for ri := range rlay.Neurons {
+		bi := ri*sz + zi
+		rn := &rlay.Neurons[ri]
+		g := pj.Gbuf[bi]
+		pj.GInc(rn, ri, g, tg)
+		pj.Gbuf[bi] = 0
+		if g > max {
+			max = g
 		}
-	} else {
-		for ri := range rlay.Neurons {
-			bi := ri*sz + zi
-			rn := &rlay.Neurons[ri]
-			g := pj.Gbuf[bi]
-			rn.GeRaw += g
-			pj.Gbuf[bi] = 0
-			if g > max {
-				max = g
-			}
-			if g > 0 {
-				avg += g
-				n++
-			}
+		if g > 0 {
+			avg += g
+			n++
 		}
 	}
+	pj.GScaleStats(max, avg, n)
+	pj.Gidx.Shift(1) // rotate buffer
+}
+
+// GScaleStats updates the GScale Avg, Max and running averages from
+// given max and sum of the n non-zero conductances received on a cycle
+func (pj *Prjn) GScaleStats(max, avg float32, n int) {
 	if n > 0 {
 		avg /= float32(n)
 		pj.GScale.Avg = avg
@@ -751,31 +1470,20 @@ func (pj *Prjn) RecvGIncStats() {
 			pj.GScale.AvgMax += pj.PrjnScale.AvgDt * (max - pj.GScale.AvgMax)
 		}
 	}
-	pj.Gidx.Shift(1) // rotate buffer
 }
 
 // RecvGIncNoStats is plus-phase version without stats
 func (pj *Prjn) RecvGIncNoStats() {
 	rlay := pj.Recv.(AxonLayer).AsAxon()
-	del := pj.Com.Delay
-	sz := del + 1
+	sz := pj.Gidx.Len
 	zi := pj.Gidx.Zi
-	if pj.Typ == emer.Inhib {
-		for ri := range rlay.Neurons {
-			bi := ri*sz + zi
-			rn := &rlay.Neurons[ri]
-			g := pj.Gbuf[bi]
-			rn.GiRaw += g
-			pj.Gbuf[bi] = 0
-		}
-	} else {
-		for ri := range rlay.Neurons {
-			bi := ri*sz + zi
-			rn := &rlay.Neurons[ri]
-			g := pj.Gbuf[bi]
-			rn.GeRaw += g
-			pj.Gbuf[bi] = 0
-		}
+	tg := pj.GTarget()
+	for ri := range rlay.Neurons {
+		bi := ri*sz + zi
+		rn := &rlay.Neurons[ri]
+		g := pj.Gbuf[bi]
+		pj.GInc(rn, ri, g, tg)
+		pj.Gbuf[bi] = 0
 	}
 	pj.Gidx.Shift(1) // rotate buffer
 }
@@ -785,33 +1493,146 @@ func (pj *Prjn) RecvGIncNoStats() {
 
 // DWt computes the weight change (learning) -- on sending projections
 func (pj *Prjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
+		return
+	}
+	pj.AllocSynOpt()
+	pj.DWtRange(0, pj.Send.Shape().Len())
+}
+
+// DWtRange computes the weight change (learning) for sending neurons
+// in range [stIdx, edIdx) -- used by DWt and for chunked parallel computation.
+func (pj *Prjn) DWtRange(stIdx, edIdx int) {
+	if pj.Learn.Trace.On {
+		pj.TraceDWtRange(stIdx, edIdx)
 		return
 	}
 	slay := pj.Send.(AxonLayer).AsAxon()
 	rlay := pj.Recv.(AxonLayer).AsAxon()
-	lr := pj.Learn.Lrate.Eff
-	for si := range slay.Neurons {
+	lr := pj.Learn.Lrate.Eff * pj.DaLrate()
+	if lr == 0 {
+		return
+	}
+	bg := pj.SendBurstGater()
+	for si := stIdx; si < edIdx; si++ {
 		sn := &slay.Neurons[si]
 		if sn.AvgSLrn < pj.Learn.XCal.LrnThr && sn.AvgMLrn < pj.Learn.XCal.LrnThr {
 			continue
 		}
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		if bg != nil && !bg.Bursting(si) {
+			continue
+		}
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 		for ci := range syns {
 			sy := &syns[ci]
 			ri := scons[ci]
 			rn := &rlay.Neurons[ri]
-			err := pj.Learn.CHLdWt(sn.AvgSLrn, sn.AvgMLrn, rn.AvgSLrn, rn.AvgMLrn)
+			err := pj.Learn.CHLdWt(sn.AvgSLrn, sn.AvgMLrn, rn.AvgSLrn, rn.AvgMLrn) + pj.Learn.BCMdWt(sn.AvgSLrn, rn.AvgSLrn, rn.ActAvg)
 			// sb immediately -- enters into zero sum
 			if err > 0 {
 				err *= (1 - sy.LWt)
 			} else {
 				err *= sy.LWt
 			}
-			sy.DWt += rn.RLrate * lr * err
+			sy.DWt += pj.SynLr(st+ci) * rn.RLrate * lr * err
+		}
+	}
+}
+
+// SendBurstGater returns the sending layer as a BurstGater if Learn.BurstGate
+// is set and it implements that interface, and nil otherwise
+func (pj *Prjn) SendBurstGater() BurstGater {
+	if !pj.Learn.BurstGate {
+		return nil
+	}
+	bg, _ := pj.Send.(BurstGater)
+	return bg
+}
+
+// DA returns the dopamine value from the Learn.DaMod.Layer, or the receiving
+// layer if that is empty -- returns false if the layer does not have a DA value.
+func (pj *Prjn) DA() (float32, bool) {
+	var dl DALayer
+	var ok bool
+	if pj.Learn.DaMod.Layer != "" {
+		ly, err := pj.Recv.(AxonLayer).AsAxon().Network.LayerByNameTry(pj.Learn.DaMod.Layer)
+		if err != nil {
+			return 0, false
+		}
+		dl, ok = ly.(DALayer)
+	} else {
+		dl, ok = pj.Recv.(DALayer)
+	}
+	if !ok {
+		return 0, false
+	}
+	return dl.GetDA(), true
+}
+
+// DaLrate returns the dopamine learning rate multiplier according to
+// Learn.DaMod params, using the DA value from the DaMod.Layer or receiving
+// layer -- returns 1 if not On or the layer does not have a DA value.
+func (pj *Prjn) DaLrate() float32 {
+	if !pj.Learn.DaMod.On {
+		return 1
+	}
+	da, ok := pj.DA()
+	if !ok {
+		return 1
+	}
+	return pj.Learn.DaMod.Lrate(da)
+}
+
+// TraceDWt computes the weight change for eligibility trace learning
+// (Learn.Trace.On) -- see TraceParams.
+func (pj *Prjn) TraceDWt() {
+	pj.TraceDWtRange(0, pj.Send.Shape().Len())
+}
+
+// TraceDWtRange computes the trace-based weight change for sending neurons
+// in range [stIdx, edIdx): the trace is updated from the current sender x
+// receiver coproduct, and multiplied by the modulator (DA or receiver error).
+func (pj *Prjn) TraceDWtRange(stIdx, edIdx int) {
+	slay := pj.Send.(AxonLayer).AsAxon()
+	rlay := pj.Recv.(AxonLayer).AsAxon()
+	tp := &pj.Learn.Trace
+	lr := pj.Learn.Lrate.Eff
+	var da float32
+	if !tp.ErrMod {
+		da, _ = pj.DA() // DA is the modulator here, so DaLrate is not applied
+	}
+	bg := pj.SendBurstGater()
+	for si := stIdx; si < edIdx; si++ {
+		sn := &slay.Neurons[si]
+		gated := bg != nil && !bg.Bursting(si)
+		nc, st := pj.SConNSt(si)
+		syns := pj.Syns[st : st+nc]
+		scons := pj.SConIdx[st : st+nc]
+		trs := pj.SynTr[st : st+nc]
+		for ci := range syns {
+			sy := &syns[ci]
+			ri := scons[ci]
+			rn := &rlay.Neurons[ri]
+			tp.TrFmCoProd(&trs[ci], sn.ActM*rn.ActM)
+			mod := da
+			if tp.ErrMod {
+				mod = rn.ActP - rn.ActM
+			}
+			if mod == 0 || gated {
+				continue
+			}
+			err := mod * trs[ci]
+			if err > 0 {
+				err *= (1 - sy.LWt)
+			} else {
+				err *= sy.LWt
+			}
+			sy.DWt += pj.SynLr(st+ci) * lr * err
+			if tp.Reset {
+				trs[ci] = 0
+			}
 		}
 	}
 }
@@ -819,20 +1640,44 @@ func (pj *Prjn) DWt() {
 // WtFmDWt updates the synaptic weight values from delta-weight changes.
 // Computed in receiving direction, does SubMean subtraction first.
 func (pj *Prjn) WtFmDWt() {
+	pj.AllocSynOpt()
+	pj.OptStep()
+	pj.WtFmDWtRange(0, pj.Recv.Shape().Len())
+	pj.ZeroLesioned()
+}
+
+// OptStep increments the Learn.Opt optimizer step, if on --
+// called once prior to each weight update.
+func (pj *Prjn) OptStep() {
+	if pj.Learn.Opt.On() {
+		pj.Learn.Opt.Step()
+	}
+}
+
+// WtFmDWtRange updates the synaptic weight values from delta-weight changes,
+// for receiving neurons in range [stIdx, edIdx) -- used by WtFmDWt and for
+// chunked parallel computation.
+func (pj *Prjn) WtFmDWtRange(stIdx, edIdx int) {
 	rlay := pj.Recv.(AxonLayer).AsAxon()
 	thr := pj.Learn.XCal.DWtThr * pj.Learn.Lrate.Eff
+	opt := pj.Learn.Opt.On()
+	dec := pj.Learn.Decay.On
+	wn := pj.Learn.WtNoise.On
+	rel := pj.Com.Rel.On && pj.Com.Rel.Lrate > 0
+	rnd := pj.Rnd()
+	lr := pj.Learn.Lrate.Eff
 	sm := pj.Learn.XCal.SubMean
 	if rlay.AxonLay.IsTarget() {
 		sm = 0
 	}
 	if sm > 0 {
-		for ri := range rlay.Neurons {
-			nc := int(pj.RConN[ri])
+		for ri := stIdx; ri < edIdx; ri++ {
+			nc, st := pj.RConNSt(ri)
 			if nc < 1 {
 				continue
 			}
-			st := int(pj.RConIdxSt[ri])
 			rsidxs := pj.RSynIdx[st : st+nc]
+			ract := rlay.Neurons[ri].AvgSLrn
 			sumDWt := float32(0)
 			nnz := 0 // non-zero
 			for _, rsi := range rsidxs {
@@ -852,28 +1697,52 @@ func (pj *Prjn) WtFmDWt() {
 				} else {
 					sy.DWt = 0
 				}
-				sy.DSWt += sy.DWt
+				if opt {
+					pj.Learn.Opt.DWt(&sy.DWt, &pj.SynM[rsi], &pj.SynV[rsi], pj.SynLr(int(rsi))*lr)
+				}
+				if dec {
+					pj.Learn.Decay.DWt(&sy.DWt, sy.LWt, ract, pj.SynLr(int(rsi))*lr)
+				}
+				sy.DSWt += sy.DWt // after Opt and Decay, so SWt tracks the actual LWt changes
+				if wn {
+					pj.Learn.WtNoise.DWt(&sy.DWt, rnd)
+				}
+				if rel {
+					pj.Com.Rel.Adapt(&pj.SynPr[rsi], sy.DWt)
+				}
 				pj.SWt.WtFmDWt(&sy.DWt, &sy.Wt, &sy.LWt, sy.SWt)
-				pj.Com.Fail(&sy.Wt, sy.SWt)
+				pj.Com.Fail(&sy.Wt, sy.SWt, rnd)
 			}
 		}
 
 	} else {
-		for ri := range rlay.Neurons {
-			nc := int(pj.RConN[ri])
+		for ri := stIdx; ri < edIdx; ri++ {
+			nc, st := pj.RConNSt(ri)
 			if nc < 1 {
 				continue
 			}
-			st := int(pj.RConIdxSt[ri])
 			rsidxs := pj.RSynIdx[st : st+nc]
+			ract := rlay.Neurons[ri].AvgSLrn
 			for _, rsi := range rsidxs {
 				sy := &pj.Syns[rsi]
 				if sy.DWt <= thr && sy.DWt >= -thr {
 					sy.DWt = 0
 				}
-				sy.DSWt += sy.DWt
+				if opt {
+					pj.Learn.Opt.DWt(&sy.DWt, &pj.SynM[rsi], &pj.SynV[rsi], pj.SynLr(int(rsi))*lr)
+				}
+				if dec {
+					pj.Learn.Decay.DWt(&sy.DWt, sy.LWt, ract, pj.SynLr(int(rsi))*lr)
+				}
+				sy.DSWt += sy.DWt // after Opt and Decay, so SWt tracks the actual LWt changes
+				if wn {
+					pj.Learn.WtNoise.DWt(&sy.DWt, rnd)
+				}
+				if rel {
+					pj.Com.Rel.Adapt(&pj.SynPr[rsi], sy.DWt)
+				}
 				pj.SWt.WtFmDWt(&sy.DWt, &sy.Wt, &sy.LWt, sy.SWt)
-				pj.Com.Fail(&sy.Wt, sy.SWt)
+				pj.Com.Fail(&sy.Wt, sy.SWt, rnd)
 			}
 		}
 	}
@@ -883,29 +1752,36 @@ func (pj *Prjn) WtFmDWt() {
 func (pj *Prjn) SlowAdapt() {
 	pj.SWtFmWt()
 	pj.SynScale()
+	pj.PruneSyns()
+	pj.ZeroLesioned()
 }
 
 // SWtFmWt updates structural, slowly-adapting SWt value based on
 // accumulated DSWt values, which are zero-summed with additional soft bounding
-// relative to SWt limits.
+// relative to SWt limits.  Does nothing if the network SWt adaptation
+// is frozen according to Network.SlowSched.SWtStop, or for this projection
+// according to Learn.Enabled.SWtStop.
 func (pj *Prjn) SWtFmWt() {
-	if !pj.Learn.Learn || !pj.SWt.Adapt.On {
+	if !pj.Learn.Learn || !pj.SWt.Adapt.On || pj.Learn.Enabled.SWtFrozen {
 		return
 	}
 	rlay := pj.Recv.(AxonLayer).AsAxon()
 	if rlay.AxonLay.IsTarget() {
 		return
 	}
+	if net, ok := rlay.Network.(AxonNetwork); ok && net.AsAxon().SWtFrozen() {
+		return
+	}
 	max := pj.SWt.Limit.Max
 	min := pj.SWt.Limit.Min
 	lr := pj.SWt.Adapt.Lrate
 	dvar := pj.SWt.Adapt.DreamVar
+	rnd := pj.Rnd()
 	for ri := range rlay.Neurons {
-		nc := int(pj.RConN[ri])
+		nc, st := pj.RConNSt(ri)
 		if nc < 1 {
 			continue
 		}
-		st := int(pj.RConIdxSt[ri])
 		rsidxs := pj.RSynIdx[st : st+nc]
 		avgDWt := float32(0)
 		for _, rsi := range rsidxs {
@@ -926,7 +1802,7 @@ func (pj *Prjn) SWtFmWt() {
 				if sy.Wt == 0 { // restore failed wts
 					sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
 				}
-				sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt) + pj.SWt.Adapt.RndVar()
+				sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt) + pj.SWt.Adapt.RndVar(rnd)
 				sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
 			}
 		} else {
@@ -942,6 +1818,7 @@ func (pj *Prjn) SWtFmWt() {
 			}
 		}
 	}
+	pj.StaleWtSc()
 }
 
 // SynScale performs synaptic scaling based on running average activation vs. targets
@@ -960,8 +1837,7 @@ func (pj *Prjn) SynScale() {
 			continue
 		}
 		adif := -lr * nrn.AvgDif
-		nc := int(pj.RConN[ri])
-		st := int(pj.RConIdxSt[ri])
+		nc, st := pj.RConNSt(ri)
 		rsidxs := pj.RSynIdx[st : st+nc]
 		for _, rsi := range rsidxs {
 			sy := &pj.Syns[rsi]
@@ -973,24 +1849,27 @@ func (pj *Prjn) SynScale() {
 			sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
 		}
 	}
+	pj.StaleWtSc()
 }
 
 // SynFail updates synaptic weight failure only -- normally done as part of DWt
 // and WtFmDWt, but this call can be used during testing to update failing synapses.
 func (pj *Prjn) SynFail() {
 	slay := pj.Send.(AxonLayer).AsAxon()
+	rnd := pj.Rnd()
 	for si := range slay.Neurons {
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		for ci := range syns {
 			sy := &syns[ci]
 			if sy.Wt == 0 { // restore failed wts
 				sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
 			}
-			pj.Com.Fail(&sy.Wt, sy.SWt)
+			pj.Com.Fail(&sy.Wt, sy.SWt, rnd)
 		}
 	}
+	pj.ZeroLesioned()
+	pj.StaleWtSc()
 }
 
 // LrateMod sets the Lrate modulation parameter for Prjns, which is
@@ -1008,3 +1887,58 @@ func (pj *Prjn) LrateSched(sched float32) {
 	pj.Learn.Lrate.Sched = sched
 	pj.Learn.Lrate.Update()
 }
+
+///////////////////////////////////////////////////////////////////////
+//  Lesion
+
+// Lesion lesions given proportion (0-1) of synapses in the projection,
+// chosen at random, by holding their Wt at 0 -- the SWt and LWt values
+// are preserved so UnLesion restores the learned weights.
+// Any existing lesion is first removed.  Returns number of synapses lesioned.
+// Emits error if prop > 1 as indication that percent might have been passed.
+func (pj *Prjn) Lesion(prop float32) int {
+	pj.UnLesion()
+	if prop > 1 {
+		log.Printf("Prjn Lesion got a proportion > 1 -- must be 0-1 as *proportion* (not percent) of synapses to lesion: %v\n", prop)
+		return 0
+	}
+	ns := len(pj.Syns)
+	if ns == 0 {
+		return 0
+	}
+	p := pj.Rnd().Perm(ns)
+	nl := int(prop * float32(ns))
+	pj.LesSyns = make([]int32, nl)
+	for i := 0; i < nl; i++ {
+		pj.LesSyns[i] = int32(p[i])
+	}
+	sort.Slice(pj.LesSyns, func(i, j int) bool { return pj.LesSyns[i] < pj.LesSyns[j] })
+	pj.ZeroLesioned()
+	pj.StaleWtSc()
+	return nl
+}
+
+// UnLesion restores all lesioned synapses, recomputing Wt from SWt and LWt
+func (pj *Prjn) UnLesion() {
+	for _, si := range pj.LesSyns {
+		sy := &pj.Syns[si]
+		sy.Wt = pj.SWt.WtVal(sy.SWt, sy.LWt)
+	}
+	pj.LesSyns = nil
+	pj.StaleWtSc()
+}
+
+// ZeroLesioned holds the Wt and DWt of lesioned synapses at 0 -- called
+// after any step that recomputes Wt from SWt and LWt.
+func (pj *Prjn) ZeroLesioned() {
+	for _, si := range pj.LesSyns {
+		sy := &pj.Syns[si]
+		sy.Wt = 0
+		sy.DWt = 0
+	}
+}
+
+// NLesioned returns the number of lesioned synapses
+func (pj *Prjn) NLesioned() int {
+	return len(pj.LesSyns)
+}
@@ -38,6 +38,10 @@ type PrjnStru struct {
 	SConNAvgMax minmax.AvgMax32 `inactive:"+" desc:"average and maximum number of sending connections in the sending layer"`
 	SConIdxSt   []int32         `view:"-" desc:"starting index into ConIdx list for each neuron in sending layer -- just a list incremented by ConN"`
 	SConIdx     []int32         `view:"-" desc:"index of other neuron on receiving side of projection, ordered by the sending layer's order of units as the outer loop (each start is in ConIdxSt), and then by the sending layer's units within that"`
+	RFanIn      int32           `inactive:"+" desc:"if > 0, every receiving neuron has exactly this number of connections (fixed fan-in), and the compact uniform-stride layout is used: RConN and RConIdxSt are not allocated, and the connections of receiving neuron ri start at ri * RFanIn -- use RConNSt to access"`
+	SFanOut     int32           `inactive:"+" desc:"if > 0, every sending neuron has exactly this number of connections (fixed fan-out), and the compact uniform-stride layout is used: SConN and SConIdxSt are not allocated, and the connections of sending neuron si start at si * SFanOut -- use SConNSt to access"`
+
+	sharedCons bool // connectivity index slices are shared with other projections (NetworkBatch ShareCons) -- see unshareCons
 }
 
 // emer.Prjn interface
@@ -115,6 +119,9 @@ func (ps *PrjnStru) BuildStru() error {
 	rlen := rsh.Len()
 	tcons := ps.SetNIdxSt(&ps.SConN, &ps.SConNAvgMax, &ps.SConIdxSt, sendn)
 	tconr := ps.SetNIdxSt(&ps.RConN, &ps.RConNAvgMax, &ps.RConIdxSt, recvn)
+	ps.RFanIn = 0
+	ps.SFanOut = 0
+	ps.sharedCons = false
 	if tconr != tcons {
 		log.Printf("%v programmer error: total recv cons %v != total send cons %v\n", ps.String(), tconr, tcons)
 	}
@@ -153,6 +160,7 @@ func (ps *PrjnStru) BuildStru() error {
 			rci++
 		}
 	}
+	ps.compactIdxs()
 	return nil
 }
 
@@ -176,6 +184,110 @@ func (ps *PrjnStru) SetNIdxSt(n *[]int32, avgmax *minmax.AvgMax32, idxst *[]int3
 	return idx
 }
 
+// FixedFanIn returns the number of connections if all entries in conN
+// are the same and > 0, otherwise 0
+func FixedFanIn(conN []int32) int32 {
+	if len(conN) == 0 {
+		return 0
+	}
+	n := conN[0]
+	for _, nc := range conN {
+		if nc != n {
+			return 0
+		}
+	}
+	return n
+}
+
+// compactIdxs switches to the compact uniform-stride layout for the
+// receiving and / or sending connections, if they have a fixed number of
+// connections per neuron, so that the per-neuron *ConN and *ConIdxSt
+// slices are not needed -- see RFanIn, SFanOut.  A side that is already
+// compact is left as is.
+func (ps *PrjnStru) compactIdxs() {
+	if ps.RFanIn == 0 {
+		ps.RFanIn = FixedFanIn(ps.RConN)
+	}
+	if ps.RFanIn > 0 {
+		ps.RConN = nil
+		ps.RConIdxSt = nil
+	}
+	if ps.SFanOut == 0 {
+		ps.SFanOut = FixedFanIn(ps.SConN)
+	}
+	if ps.SFanOut > 0 {
+		ps.SConN = nil
+		ps.SConIdxSt = nil
+	}
+}
+
+// RConNSt returns the number of connections and their starting index in
+// RConIdx and RSynIdx for receiving neuron ri, using the uniform stride
+// RFanIn in the compact layout.
+func (ps *PrjnStru) RConNSt(ri int) (nc, st int) {
+	if ps.RFanIn > 0 {
+		return int(ps.RFanIn), ri * int(ps.RFanIn)
+	}
+	return int(ps.RConN[ri]), int(ps.RConIdxSt[ri])
+}
+
+// SConNSt returns the number of connections and their starting index in
+// SConIdx and Syns for sending neuron si, using the uniform stride
+// SFanOut in the compact layout.
+func (ps *PrjnStru) SConNSt(si int) (nc, st int) {
+	if ps.SFanOut > 0 {
+		return int(ps.SFanOut), si * int(ps.SFanOut)
+	}
+	return int(ps.SConN[si]), int(ps.SConIdxSt[si])
+}
+
+// unshareCons makes this projection's own copy of the receiving-side
+// connectivity indexes that are modified in place (RConIdx, RSynIdx),
+// if they are shared with other projections -- must be called before
+// any such modification.  The sending-side indexes are always
+// reallocated when rebuilt (see rebuildSendIdxs).
+func (ps *PrjnStru) unshareCons() {
+	if !ps.sharedCons {
+		return
+	}
+	ps.RConIdx = append([]int32(nil), ps.RConIdx...)
+	ps.RSynIdx = append([]int32(nil), ps.RSynIdx...)
+	ps.sharedCons = false
+}
+
+// SparsityStats are connectivity and memory statistics for a projection
+type SparsityStats struct {
+	NSyns      int     `desc:"total number of synapses"`
+	Density    float32 `desc:"proportion of all possible sending x receiving connections that are present"`
+	RFanIn     int     `desc:"fixed fan-in (number of connections per receiver) if using the compact receiving layout, else 0"`
+	SFanOut    int     `desc:"fixed fan-out (number of connections per sender) if using the compact sending layout, else 0"`
+	IdxBytes   int     `desc:"number of bytes used by the connection index slices of this projection"`
+	SavedBytes int     `desc:"number of bytes of per-neuron connection number and start index slices that are not allocated under the compact uniform-stride layouts"`
+}
+
+// Sparsity returns connectivity and memory statistics for this projection,
+// to verify the memory savings of the compact fixed fan-in / fan-out layouts
+func (ps *PrjnStru) Sparsity() SparsityStats {
+	var ss SparsityStats
+	ss.NSyns = len(ps.SConIdx)
+	if ps.Send != nil && ps.Recv != nil {
+		np := ps.Send.Shape().Len() * ps.Recv.Shape().Len()
+		if np > 0 {
+			ss.Density = float32(ss.NSyns) / float32(np)
+		}
+	}
+	ss.RFanIn = int(ps.RFanIn)
+	ss.SFanOut = int(ps.SFanOut)
+	ss.IdxBytes = 4 * (len(ps.RConN) + len(ps.RConIdxSt) + len(ps.RConIdx) + len(ps.RSynIdx) + len(ps.SConN) + len(ps.SConIdxSt) + len(ps.SConIdx))
+	if ps.RFanIn > 0 {
+		ss.SavedBytes += 8 * ps.Recv.Shape().Len()
+	}
+	if ps.SFanOut > 0 {
+		ss.SavedBytes += 8 * ps.Send.Shape().Len()
+	}
+	return ss
+}
+
 // String satisfies fmt.Stringer for prjn
 func (ps *PrjnStru) String() string {
 	str := ""
@@ -0,0 +1,219 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/pprof"
+	"sort"
+	"strings"
+
+	"github.com/emer/emergent/timer"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+)
+
+// ProfRecvGInc is the Profiler function name for the RecvGInc step,
+// which is recorded within (and included in) the GFmInc time.
+const ProfRecvGInc = "RecvGInc"
+
+// Profiler records the time spent in each major step of the network
+// computation (SendSpike, GFmInc, RecvGInc, InhibFmGeAct, ActFmG, DWt,
+// WtFmDWt, etc), separately for each layer, as a structured extension of
+// the overall function timers reported by TimerReport.  Every function run
+// through ThrLayFun is recorded under its name.  Layers that are computed
+// in a WorkPool (Network.WorkPool.NWorkers > 1) are not recorded per layer.
+// The results are available as an etable.Table via ProfileTable, and if
+// Labels is set, pprof labels "fun" and "layer" are attached to each call
+// so that CPU profiles can be broken down in the same way.
+type Profiler struct {
+	On     bool                    `desc:"record per-layer function timing -- adds a small overhead per layer per function call"`
+	Labels bool                    `viewif:"On" desc:"add pprof labels for the function name (fun) and layer name (layer) to each call, for use with runtime/pprof CPU profiles -- adds more overhead than the timers"`
+	Funs   []string                `inactive:"+" desc:"names of the recorded functions, in order of first call"`
+	Times  map[string][]timer.Time `view:"-" desc:"per-layer timers for each function, indexed by layer Index"`
+
+	nlay int // number of layers
+}
+
+// Init initializes the timers for given number of layers
+func (pr *Profiler) Init(nlay int) {
+	pr.nlay = nlay
+	pr.Funs = nil
+	pr.Times = make(map[string][]timer.Time)
+	pr.Timers(ProfRecvGInc) // called within threads, so must already exist
+}
+
+// Reset resets all of the timers
+func (pr *Profiler) Reset() {
+	for _, ts := range pr.Times {
+		for li := range ts {
+			ts[li].Reset()
+		}
+	}
+}
+
+// Timers returns the per-layer timers for given function name, creating
+// them if they do not yet exist.  This must not be called from multiple
+// threads at the same time.
+func (pr *Profiler) Timers(fun string) []timer.Time {
+	ts, ok := pr.Times[fun]
+	if !ok {
+		ts = make([]timer.Time, pr.nlay)
+		pr.Times[fun] = ts
+		pr.Funs = append(pr.Funs, fun)
+	}
+	return ts
+}
+
+// StartTimer starts and returns the timer for given function name and
+// layer index, if profiling is On and the function timers already exist
+// -- otherwise returns nil.  Can be called on a nil Profiler.
+func (pr *Profiler) StartTimer(fun string, li int) *timer.Time {
+	if pr == nil || !pr.On {
+		return nil
+	}
+	ts := pr.Times[fun]
+	if li >= len(ts) {
+		return nil
+	}
+	tm := &ts[li]
+	tm.Start()
+	return tm
+}
+
+// LayFun returns a version of given layer function that records its
+// time per layer under given function name, with pprof labels if Labels.
+func (pr *Profiler) LayFun(fun func(ly AxonLayer), funame string) func(ly AxonLayer) {
+	ts := pr.Timers(funame)
+	labels := pr.Labels
+	return func(ly AxonLayer) {
+		tm := &ts[ly.Index()]
+		tm.Start()
+		if labels {
+			pprof.Do(context.Background(), pprof.Labels("fun", funame, "layer", ly.Name()), func(context.Context) {
+				fun(ly)
+			})
+		} else {
+			fun(ly)
+		}
+		tm.Stop()
+	}
+}
+
+// SetProfile turns per-layer function profiling on or off, with pprof
+// labels if labels is true, and resets the timers.  See Profiler.
+func (nt *NetworkStru) SetProfile(on, labels bool) {
+	nt.Prof.On = on
+	nt.Prof.Labels = labels
+	nt.Prof.Reset()
+}
+
+// ProfileReset resets the Profiler timers
+func (nt *NetworkStru) ProfileReset() {
+	nt.Prof.Reset()
+}
+
+// ProfileTable returns an etable.Table with the Profiler timing for each
+// function and layer (one row per function x layer), sorted by total
+// time.  Pct is the percent of the total time across all functions,
+// where RecvGInc is not counted in the total as it is included in GFmInc.
+// If dt is non-nil, it is reconfigured and reused.
+func (nt *NetworkStru) ProfileTable(dt *etable.Table) *etable.Table {
+	if dt == nil {
+		dt = &etable.Table{}
+	}
+	sch := etable.Schema{
+		{"Fun", etensor.STRING, nil, nil},
+		{"Layer", etensor.STRING, nil, nil},
+		{"N", etensor.INT64, nil, nil},
+		{"Secs", etensor.FLOAT64, nil, nil},
+		{"AvgUSecs", etensor.FLOAT64, nil, nil},
+		{"Pct", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetMetaData("name", nt.Nm+"Profile")
+	dt.SetFromSchema(sch, 0)
+
+	type profRow struct {
+		fun  string
+		li   int
+		n    int
+		secs float64
+	}
+	var rows []profRow
+	tot := 0.0
+	for _, fn := range nt.Prof.Funs {
+		ts := nt.Prof.Times[fn]
+		for li := range ts {
+			tm := &ts[li]
+			if tm.N == 0 || li >= len(nt.Layers) {
+				continue
+			}
+			secs := tm.TotalSecs()
+			rows = append(rows, profRow{fun: fn, li: li, n: tm.N, secs: secs})
+			if fn != ProfRecvGInc {
+				tot += secs
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].secs > rows[j].secs
+	})
+	if tot == 0 {
+		tot = 1
+	}
+	dt.SetNumRows(len(rows))
+	for row, pr := range rows {
+		dt.SetCellString("Fun", row, pr.fun)
+		dt.SetCellString("Layer", row, nt.Layers[pr.li].Name())
+		dt.SetCellFloat("N", row, float64(pr.n))
+		dt.SetCellFloat("Secs", row, pr.secs)
+		dt.SetCellFloat("AvgUSecs", row, 1.0e6*pr.secs/float64(pr.n))
+		dt.SetCellFloat("Pct", row, 100*pr.secs/tot)
+	}
+	return dt
+}
+
+// SaveProfileCSV saves the ProfileTable for the network to given
+// file name in CSV format
+func (nt *NetworkStru) SaveProfileCSV(filename string) error {
+	dt := nt.ProfileTable(nil)
+	err := dt.SaveCSV(gi.FileName(filename), etable.Comma, etable.Headers)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// ProfileReport returns a report of the Profiler timing for each function
+// summed across layers, followed by the given number of top-ranked
+// function x layer entries (all if <= 0).
+func (nt *NetworkStru) ProfileReport(top int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ProfileReport: %v\n", nt.Nm)
+	fmt.Fprintf(&b, "\tFunction Name\tTotal Secs\n")
+	fnms := make([]string, len(nt.Prof.Funs))
+	copy(fnms, nt.Prof.Funs)
+	sort.Strings(fnms)
+	for _, fn := range fnms {
+		tot := 0.0
+		for li := range nt.Prof.Times[fn] {
+			tot += nt.Prof.Times[fn][li].TotalSecs()
+		}
+		fmt.Fprintf(&b, "\t%v \t%6.4g\n", fn, tot)
+	}
+	dt := nt.ProfileTable(nil)
+	n := dt.Rows
+	if top > 0 && top < n {
+		n = top
+	}
+	fmt.Fprintf(&b, "\n\tFunction Name\tLayer\tN\tTotal Secs\tAvg uSecs\tPct\n")
+	for row := 0; row < n; row++ {
+		fmt.Fprintf(&b, "\t%v \t%v\t%v\t%6.4g\t%6.4g\t%6.4g\n", dt.CellString("Fun", row), dt.CellString("Layer", row), int(dt.CellFloat("N", row)), dt.CellFloat("Secs", row), dt.CellFloat("AvgUSecs", row), dt.CellFloat("Pct", row))
+	}
+	return b.String()
+}
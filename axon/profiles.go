@@ -0,0 +1,180 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/emer/emergent/params"
+)
+
+// Profiles are named parameter sheets that set coordinated defaults across
+// Act, Learn, Inhib and PrjnScale parameters for common regimes, as a starting
+// point instead of copying long param sheets between projects.  They are
+// applied on top of the standard Defaults via Network ApplyProfile, prior to
+// any project-specific params.  The Rate profiles configure the spiking neurons
+// for a more rate-code-like regime: no exponential spike initiation, minimal
+// refractory period, no adaptation or long-time-scale channels, and smoother
+// rate estimation.  Small profiles are tuned for networks with layers of roughly
+// 25-100 neurons (e.g., ra25), Large for layers of many hundreds or more,
+// with stronger inhibition and slower, more constrained learning.
+// Projects can add their own profiles to this map.
+var Profiles = map[string]*params.Sheet{
+	"SpikingSmall": {
+		{Sel: "Layer", Desc: "small spiking networks",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "1.2",
+				"Layer.Inhib.ActAvg.Init": "0.04",
+				"Layer.Inhib.Layer.Bg":    "0.3",
+				"Layer.Act.Decay.Glong":   "0.6",
+				"Layer.Act.Dend.GbarExp":  "0.2",
+				"Layer.Act.Dend.GbarR":    "3",
+				"Layer.Act.NMDA.Gbar":     "0.15",
+				"Layer.Act.GABAB.Gbar":    "0.2",
+			}},
+		{Sel: ".Input", Desc: "inputs need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "0.9",
+				"Layer.Inhib.ActAvg.Init": "0.15",
+				"Layer.Act.Clamp.Ge":      "1.0",
+			}},
+		{Sel: ".Target", Desc: "small output layers need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi": "0.9",
+				"Layer.Act.Clamp.Ge":   "0.6",
+			}},
+		{Sel: "Prjn", Desc: "faster learning for small networks",
+			Params: params.Params{
+				"Prjn.Learn.Lrate.Base": "0.2",
+				"Prjn.SWt.Adapt.Lrate":  "0.1",
+				"Prjn.SWt.Init.SPct":    "0.5",
+			}},
+		{Sel: ".Back", Desc: "top-down back-projections must have lower relative scale",
+			Params: params.Params{
+				"Prjn.PrjnScale.Rel": "0.3",
+			}},
+	},
+	"SpikingLarge": {
+		{Sel: "Layer", Desc: "large spiking networks",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "1.1",
+				"Layer.Inhib.Pool.Gi":     "1.1",
+				"Layer.Inhib.ActAvg.Init": "0.04",
+				"Layer.Inhib.Layer.Bg":    "0.3",
+				"Layer.Act.Decay.Glong":   "0.6",
+				"Layer.Act.Dend.GbarExp":  "0.2",
+				"Layer.Act.Dend.GbarR":    "3",
+				"Layer.Act.NMDA.Gbar":     "0.15",
+				"Layer.Act.GABAB.Gbar":    "0.2",
+			}},
+		{Sel: ".Input", Desc: "inputs need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "0.9",
+				"Layer.Inhib.Pool.Gi":     "0.9",
+				"Layer.Inhib.ActAvg.Init": "0.15",
+				"Layer.Act.Clamp.Ge":      "1.0",
+			}},
+		{Sel: "Prjn", Desc: "slower, more constrained learning for large networks",
+			Params: params.Params{
+				"Prjn.Learn.Lrate.Base": "0.04",
+				"Prjn.SWt.Adapt.Lrate":  "0.1",
+				"Prjn.SWt.Init.SPct":    "1",
+				"Prjn.PrjnScale.Adapt":  "true",
+				"Prjn.PrjnScale.LoTol":  "0.8",
+				"Prjn.PrjnScale.HiTol":  "0",
+			}},
+		{Sel: ".Back", Desc: "top-down back-projections must have lower relative scale",
+			Params: params.Params{
+				"Prjn.PrjnScale.Rel": "0.2",
+			}},
+	},
+	"RateSmall": {
+		{Sel: "Layer", Desc: "rate-code-like regime for small networks",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "1.2",
+				"Layer.Inhib.ActAvg.Init": "0.04",
+				"Layer.Act.Spike.Exp":     "false",
+				"Layer.Act.Spike.Tr":      "1",
+				"Layer.Act.Spike.ISITau":  "10",
+				"Layer.Act.KNa.On":        "false",
+				"Layer.Act.NMDA.Gbar":     "0",
+				"Layer.Act.GABAB.Gbar":    "0",
+			}},
+		{Sel: ".Input", Desc: "inputs need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "0.9",
+				"Layer.Inhib.ActAvg.Init": "0.15",
+				"Layer.Act.Clamp.Ge":      "1.0",
+			}},
+		{Sel: ".Target", Desc: "small output layers need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi": "0.9",
+				"Layer.Act.Clamp.Ge":   "0.6",
+			}},
+		{Sel: "Prjn", Desc: "faster learning for small networks",
+			Params: params.Params{
+				"Prjn.Learn.Lrate.Base": "0.2",
+				"Prjn.SWt.Init.SPct":    "0.5",
+			}},
+		{Sel: ".Back", Desc: "top-down back-projections must have lower relative scale",
+			Params: params.Params{
+				"Prjn.PrjnScale.Rel": "0.3",
+			}},
+	},
+	"RateLarge": {
+		{Sel: "Layer", Desc: "rate-code-like regime for large networks",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "1.1",
+				"Layer.Inhib.Pool.Gi":     "1.1",
+				"Layer.Inhib.ActAvg.Init": "0.04",
+				"Layer.Act.Spike.Exp":     "false",
+				"Layer.Act.Spike.Tr":      "1",
+				"Layer.Act.Spike.ISITau":  "10",
+				"Layer.Act.KNa.On":        "false",
+				"Layer.Act.NMDA.Gbar":     "0",
+				"Layer.Act.GABAB.Gbar":    "0",
+			}},
+		{Sel: ".Input", Desc: "inputs need lower inhibition",
+			Params: params.Params{
+				"Layer.Inhib.Layer.Gi":    "0.9",
+				"Layer.Inhib.Pool.Gi":     "0.9",
+				"Layer.Inhib.ActAvg.Init": "0.15",
+				"Layer.Act.Clamp.Ge":      "1.0",
+			}},
+		{Sel: "Prjn", Desc: "slower, more constrained learning for large networks",
+			Params: params.Params{
+				"Prjn.Learn.Lrate.Base": "0.04",
+				"Prjn.SWt.Init.SPct":    "1",
+				"Prjn.PrjnScale.Adapt":  "true",
+			}},
+		{Sel: ".Back", Desc: "top-down back-projections must have lower relative scale",
+			Params: params.Params{
+				"Prjn.PrjnScale.Rel": "0.2",
+			}},
+	},
+}
+
+// ProfileNames returns the sorted names of the available Profiles
+func ProfileNames() []string {
+	nms := make([]string, 0, len(Profiles))
+	for nm := range Profiles {
+		nms = append(nms, nm)
+	}
+	sort.Strings(nms)
+	return nms
+}
+
+// ApplyProfile applies the named parameter profile from Profiles to all layers
+// and projections -- call after Defaults and before any project-specific params.
+// Returns an error if the profile is not found.
+func (nt *Network) ApplyProfile(name string) error {
+	ps, ok := Profiles[name]
+	if !ok {
+		return fmt.Errorf("Network %v ApplyProfile: profile named: %v not found -- available: %v", nt.Nm, name, ProfileNames())
+	}
+	_, err := nt.ApplyParams(ps, false)
+	return err
+}
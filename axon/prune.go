@@ -0,0 +1,218 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/emer/etable/minmax"
+)
+
+// PruneParams are structural plasticity parameters for pruning synapses
+// whose SWt remains weak for a sustained period, and regrowing the same
+// number of new random connections on the same receiving neuron, so that
+// the connectivity density (fan-in) is maintained.  Performed in SlowAdapt.
+// Because pruning changes the connectivity away from the projection Pattern,
+// saved weights can only be reloaded into a network with the same pruning history.
+type PruneParams struct {
+	On     bool    `desc:"enable pruning and regrowth of synapses"`
+	Thr    float32 `viewif:"On" def:"0.2" min:"0" desc:"SWt threshold below which a synapse is a candidate for pruning"`
+	NSlow  int     `viewif:"On" def:"5" min:"1" desc:"number of consecutive SlowAdapt steps (epochs if Network.SlowSched.Unit = Epoch) that SWt must remain below Thr before the synapse is pruned"`
+	MaxPct float32 `viewif:"On" def:"0.1" min:"0" max:"1" desc:"maximum proportion of each receiving neuron's synapses that can be pruned in one SlowAdapt step"`
+	Tries  int     `viewif:"On" def:"20" min:"1" desc:"number of random sending neurons to try when searching for a new, unconnected sender to regrow a pruned synapse -- if none is found, the synapse is retained"`
+}
+
+func (pp *PruneParams) Defaults() {
+	pp.On = false
+	pp.Thr = 0.2
+	pp.NSlow = 5
+	pp.MaxPct = 0.1
+	pp.Tries = 20
+}
+
+func (pp *PruneParams) Update() {
+}
+
+// PruneStats records the number of synapses pruned and regrown
+type PruneStats struct {
+	Pruned    int `inactive:"+" desc:"number of synapses pruned so far in the current epoch"`
+	Grown     int `inactive:"+" desc:"number of synapses regrown so far in the current epoch"`
+	EpcPruned int `inactive:"+" desc:"number of synapses pruned in the previous epoch -- set by Network.EpochInc, for logging"`
+	EpcGrown  int `inactive:"+" desc:"number of synapses regrown in the previous epoch -- set by Network.EpochInc, for logging"`
+}
+
+// Init resets all stats
+func (ps *PruneStats) Init() {
+	ps.Pruned = 0
+	ps.Grown = 0
+	ps.EpcPruned = 0
+	ps.EpcGrown = 0
+}
+
+// EpochInc saves the current epoch counts to the Epc values and resets them
+func (ps *PruneStats) EpochInc() {
+	ps.EpcPruned = ps.Pruned
+	ps.EpcGrown = ps.Grown
+	ps.Pruned = 0
+	ps.Grown = 0
+}
+
+// PruneSyns prunes synapses whose SWt has been below Prune.Thr for Prune.NSlow
+// consecutive calls, regrowing each one to a new random sending neuron on the
+// same receiving neuron, with synaptic state initialized as in InitWts.
+// The receiving connectivity counts are unchanged, and the sending-side
+// connectivity is rebuilt only if any synapses were regrown.
+// Pruning is suspended while any synapses are lesioned (see Lesion).
+func (pj *Prjn) PruneSyns() {
+	if !pj.Prune.On || pj.Off || len(pj.LesSyns) > 0 {
+		return
+	}
+	ns := len(pj.Syns)
+	if len(pj.PruneCnt) != ns {
+		pj.PruneCnt = make([]int32, ns)
+	}
+	for si := range pj.Syns {
+		if pj.Syns[si].SWt < pj.Prune.Thr {
+			pj.PruneCnt[si]++
+		} else {
+			pj.PruneCnt[si] = 0
+		}
+	}
+	rlay := pj.Recv.(AxonLayer).AsAxon()
+	slen := pj.Send.Shape().Len()
+	self := pj.Send == pj.Recv
+	smn := pj.SWt.Init.Mean
+	rnd := pj.Rnd()
+	spct := pj.SWt.Init.SPct
+	ngrown := 0
+	var conn map[int32]bool
+	for ri := range rlay.Neurons {
+		nc, st := pj.RConNSt(ri)
+		if nc == 0 || nc >= slen {
+			continue
+		}
+		maxn := int(pj.Prune.MaxPct * float32(nc))
+		npr := 0
+		conn = nil
+		for ci := 0; ci < nc && npr < maxn; ci++ {
+			rsi := pj.RSynIdx[st+ci]
+			if int(pj.PruneCnt[rsi]) < pj.Prune.NSlow {
+				continue
+			}
+			if conn == nil {
+				conn = make(map[int32]bool, nc)
+				for _, si := range pj.RConIdx[st : st+nc] {
+					conn[si] = true
+				}
+			}
+			nsi := int32(-1)
+			for t := 0; t < pj.Prune.Tries; t++ {
+				si := int32(rnd.Intn(slen))
+				if conn[si] || (self && int(si) == ri) {
+					continue
+				}
+				nsi = si
+				break
+			}
+			pj.PruneCnt[rsi] = 0
+			if nsi < 0 {
+				continue
+			}
+			delete(conn, pj.RConIdx[st+ci])
+			conn[nsi] = true
+			pj.unshareCons()
+			pj.RConIdx[st+ci] = nsi
+			sy := &pj.Syns[rsi]
+			*sy = Synapse{}
+			pj.InitWtsSyn(sy, smn, spct)
+			pj.InitSynOptIdx(int(rsi))
+			npr++
+		}
+		ngrown += npr
+	}
+	pj.PruneStats.Pruned += ngrown
+	pj.PruneStats.Grown += ngrown
+	if ngrown > 0 {
+		pj.rebuildSendIdxs()
+	}
+}
+
+// rebuildSendIdxs rebuilds the sending-side connectivity (SConN, SConIdxSt,
+// SConIdx) from the receiving-side RConIdx, moving the synapses in Syns into
+// sending-neuron order and updating RSynIdx accordingly.  The compact
+// layout is used again if the fan-out remains fixed (see SFanOut).
+func (pj *Prjn) rebuildSendIdxs() {
+	pj.unshareCons()
+	slen := pj.Send.Shape().Len()
+	rlen := pj.Recv.Shape().Len()
+	sconN := make([]int32, slen)
+	for ri := 0; ri < rlen; ri++ {
+		nc, st := pj.RConNSt(ri)
+		for _, si := range pj.RConIdx[st : st+nc] {
+			sconN[si]++
+		}
+	}
+	sconSt := make([]int32, slen)
+	idx := int32(0)
+	var avgmax minmax.AvgMax32
+	avgmax.Init()
+	for si := 0; si < slen; si++ {
+		sconSt[si] = idx
+		idx += sconN[si]
+		avgmax.UpdateVal(float32(sconN[si]), si)
+	}
+	avgmax.CalcAvg()
+
+	ns := len(pj.Syns)
+	syns := make([]Synapse, ns)
+	scidx := make([]int32, ns)
+	pcnt := make([]int32, ns)
+	var sdel []uint8
+	if pj.SynDel != nil {
+		sdel = make([]uint8, ns)
+	}
+	sopt := make([][]float32, len(SynOptVars))
+	for oi := range sopt {
+		if *pj.SynOptVals(oi) != nil {
+			sopt[oi] = make([]float32, ns)
+		}
+	}
+	cur := make([]int32, slen)
+	for ri := 0; ri < rlen; ri++ {
+		nc, st := pj.RConNSt(ri)
+		for ci := 0; ci < nc; ci++ {
+			si := pj.RConIdx[st+ci]
+			osi := pj.RSynIdx[st+ci]
+			nsi := sconSt[si] + cur[si]
+			cur[si]++
+			syns[nsi] = pj.Syns[osi]
+			pcnt[nsi] = pj.PruneCnt[osi]
+			if sdel != nil {
+				sdel[nsi] = pj.SynDel[osi]
+			}
+			for oi, so := range sopt {
+				if so != nil {
+					so[nsi] = (*pj.SynOptVals(oi))[osi]
+				}
+			}
+			scidx[nsi] = int32(ri)
+			pj.RSynIdx[st+ci] = nsi
+		}
+	}
+	pj.SConN = sconN
+	pj.SConIdxSt = sconSt
+	pj.SFanOut = 0 // regrowth generally breaks a fixed fan-out
+	pj.SConNAvgMax = avgmax
+	pj.SConIdx = scidx
+	pj.Syns = syns
+	pj.PruneCnt = pcnt
+	if sdel != nil {
+		pj.SynDel = sdel
+	}
+	for oi, so := range sopt {
+		if so != nil {
+			*pj.SynOptVals(oi) = so
+		}
+	}
+	pj.compactIdxs()
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Rand is the source of random numbers used by all stochastic components
+// (weight initialization, synaptic failure, noise, permutations, lesioning),
+// which is satisfied by *rand.Rand from math/rand.  Each Layer and Prjn has
+// its own Rand, seeded from Network.RandSeed, so that runs are exactly
+// reproducible independent of the number of threads.  If no seed is set,
+// the global math/rand source is used.
+type Rand interface {
+	Float32() float32
+	Float64() float64
+	NormFloat64() float64
+	Intn(n int) int
+	Perm(n int) []int
+}
+
+// NewRand returns a new Rand with given seed, which is a *StateRand
+// whose state can be saved and restored
+func NewRand(seed int64) Rand {
+	return NewStateRand(seed)
+}
+
+// RandState is the state of a StateRand: its seed and the number of
+// values drawn from its source since seeding
+type RandState struct {
+	Seed int64  `desc:"seed of the random source"`
+	N    uint64 `desc:"number of values drawn from the source since seeding"`
+}
+
+// StateRand is a *rand.Rand whose source counts the number of values drawn,
+// so that its state can be saved and restored (see State, SetState), e.g.,
+// to replay a sim from a given point -- the math/rand sources do not
+// otherwise provide access to their state.
+type StateRand struct {
+	*rand.Rand
+	src countSource
+}
+
+// NewStateRand returns a new StateRand with given seed
+func NewStateRand(seed int64) *StateRand {
+	sr := &StateRand{}
+	sr.src.Seed(seed)
+	sr.Rand = rand.New(&sr.src)
+	return sr
+}
+
+// State returns the current state
+func (sr *StateRand) State() RandState {
+	return RandState{Seed: sr.src.seed, N: sr.src.n}
+}
+
+// SetState restores given state, by reseeding and drawing st.N values
+// from the source -- this takes time proportional to st.N.
+func (sr *StateRand) SetState(st RandState) {
+	sr.Rand.Seed(st.Seed) // calls src.Seed, resets n
+	for sr.src.n < st.N {
+		sr.src.Uint64()
+	}
+}
+
+// countSource is a rand.Source64 that counts the values drawn
+type countSource struct {
+	src  rand.Source64
+	seed int64
+	n    uint64
+}
+
+func (cs *countSource) Seed(seed int64) {
+	cs.src = rand.NewSource(seed).(rand.Source64)
+	cs.seed = seed
+	cs.n = 0
+}
+
+func (cs *countSource) Int63() int64 {
+	cs.n++
+	return cs.src.Int63()
+}
+
+func (cs *countSource) Uint64() uint64 {
+	cs.n++
+	return cs.src.Uint64()
+}
+
+// GlobalRand is a Rand that uses the global math/rand source
+type GlobalRand struct{}
+
+func (gr GlobalRand) Float32() float32     { return rand.Float32() }
+func (gr GlobalRand) Float64() float64     { return rand.Float64() }
+func (gr GlobalRand) NormFloat64() float64 { return rand.NormFloat64() }
+func (gr GlobalRand) Intn(n int) int       { return rand.Intn(n) }
+func (gr GlobalRand) Perm(n int) []int     { return rand.Perm(n) }
+
+// RandOrGlobal returns given Rand if non-nil, and otherwise GlobalRand
+func RandOrGlobal(rnd Rand) Rand {
+	if rnd == nil {
+		return GlobalRand{}
+	}
+	return rnd
+}
+
+// RandSubSeed returns a seed derived from given seed and name, so that
+// each named component gets its own independent random sequence
+func RandSubSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
+}
+
+// PermuteInts permutes (shuffles) the order of elements in given slice
+// using given Rand
+func PermuteInts(ins []int, rnd Rand) {
+	for i := len(ins) - 1; i > 0; i-- {
+		j := rnd.Intn(i + 1)
+		ins[i], ins[j] = ins[j], ins[i]
+	}
+}
+
+// SetRandSeed sets the RandSeed and initializes the Rand of each Layer and Prjn
+// with a seed derived from it and their names.  A seed of 0 reverts to
+// using the global math/rand source.  Called in InitWts if RandSeed != 0,
+// so that each call to InitWts starts the same random sequences.
+func (nt *Network) SetRandSeed(seed int64) {
+	nt.RandSeed = seed
+	for _, l := range nt.Layers {
+		ly := l.(AxonLayer).AsAxon()
+		if seed == 0 {
+			ly.SetRand(nil)
+		} else {
+			ly.SetRand(NewRand(RandSubSeed(seed, ly.Nm)))
+		}
+		for _, p := range ly.RcvPrjns {
+			pj := p.(AxonPrjn).AsAxon()
+			if seed == 0 {
+				pj.Rand = nil
+			} else {
+				pj.Rand = NewRand(RandSubSeed(seed, pj.Name()))
+			}
+		}
+	}
+}
+
+// SetRand sets the Rand for this layer, including its noise parameters
+func (ly *Layer) SetRand(rnd Rand) {
+	ly.Rand = rnd
+	ly.Act.Noise.rnd = rnd
+	ly.Act.ActNoise.rnd = rnd
+	ly.Act.Hetero.rnd = rnd
+}
+
+// Rnd returns the Rand for this layer, or GlobalRand if not set
+func (ly *Layer) Rnd() Rand {
+	return RandOrGlobal(ly.Rand)
+}
+
+// Rnd returns the Rand for this projection, or GlobalRand if not set
+func (pj *Prjn) Rnd() Rand {
+	return RandOrGlobal(pj.Rand)
+}
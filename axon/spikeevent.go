@@ -0,0 +1,162 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+// SpikeEventParams determine whether a projection uses event-driven
+// spike delivery: instead of adding conductances into the dense Gbuf
+// ring buffer of Gidx.Len slots for every receiving neuron, which must
+// then be read and cleared for every receiver on every cycle, each
+// spike is queued as a list of (receiver, conductance) events in the
+// ring slot for its delay, and only those events are delivered in
+// RecvGInc.  This saves memory and time when very few sending neurons
+// spike on any given cycle, e.g., in very large, sparsely active networks,
+// but is slower than the dense buffer for typical activity levels.
+// Event delivery is selected at Build / InitWts when the expected
+// activity of the sending layer is below RateThr, and is not used for
+// projections with GABA.On, which update their state every cycle.
+type SpikeEventParams struct {
+	On      bool    `desc:"use event-driven spike delivery for this projection when the expected sending activity is below RateThr -- IMPORTANT: if you change this, you must call InitWts() on Network!"`
+	RateThr float32 `viewif:"On" def:"0.02" min:"0" desc:"threshold on the expected proportion of active sending neurons (sending layer Inhib.ActAvg.Init) below which event-driven delivery is used -- use 1 to always use events when On"`
+}
+
+func (se *SpikeEventParams) Defaults() {
+	se.RateThr = 0.02
+}
+
+func (se *SpikeEventParams) Update() {
+}
+
+// Use returns true if event-driven delivery should be used for
+// given expected proportion of active sending neurons
+func (se *SpikeEventParams) Use(rate float32) bool {
+	return se.On && rate < se.RateThr
+}
+
+// SpikeEvent is one queued conductance increment for a receiving neuron
+type SpikeEvent struct {
+	Ri int32   `desc:"index of the receiving neuron"`
+	G  float32 `desc:"conductance to deliver"`
+}
+
+// EventsOK returns true if this projection should use event-driven spike
+// delivery according to its Com.Event params and the sending layer
+// expected activity level
+func (pj *Prjn) EventsOK() bool {
+	if pj.GABA.On {
+		return false
+	}
+	slay := pj.Send.(AxonLayer).AsAxon()
+	return pj.Com.Event.Use(slay.Inhib.ActAvg.Init)
+}
+
+// BuildEvents allocates the event ring of given number of delay slots,
+// and the per-receiver scratch buffer, replacing Gbuf
+func (pj *Prjn) BuildEvents(dl, rlen int) {
+	pj.Gbuf = nil
+	if pj.Gidx.Len != dl || len(pj.Events) != dl {
+		pj.Gidx.Len = dl
+		pj.Gidx.Zi = 0
+		pj.Events = make([][]SpikeEvent, dl)
+	}
+	if len(pj.evG) != rlen {
+		pj.evG = make([]float32, rlen)
+	}
+}
+
+// InitEvents clears any pending spike events
+func (pj *Prjn) InitEvents() {
+	for i := range pj.Events {
+		pj.Events[i] = pj.Events[i][:0]
+	}
+	for ri := range pj.evG {
+		pj.evG[ri] = 0
+	}
+	pj.evRis = pj.evRis[:0]
+}
+
+// SendSpikeEvent sends a spike as queued events, with short-term plasticity
+// if Com.STP.On, stochastic release if Com.Rel.On, and per-synapse delays
+// if dels is non-nil, for the synapses starting at index st in Syns order
+func (pj *Prjn) SendSpikeEvent(si int, wscs []float32, st int, scons []int32, dels []uint8) {
+	stp := pj.Com.STP.On
+	var isi int32
+	var recs, facs []float32
+	if stp {
+		isi = pj.STPCyc - pj.STPLast[si]
+		pj.STPLast[si] = pj.STPCyc
+		recs = pj.SynRec[st : st+len(scons)]
+		facs = pj.SynFac[st : st+len(scons)]
+	}
+	rel := pj.Com.Rel.On
+	var sact float32
+	var rnd Rand
+	var prs []float32
+	if rel {
+		sact = pj.Send.(AxonLayer).AsAxon().Neurons[si].Act
+		rnd = pj.Rnd()
+		prs = pj.SynPr[st : st+len(scons)]
+	}
+	di := pj.Gidx.Idx(pj.Com.Delay)
+	for ci, ri := range scons {
+		if rel && !pj.Com.Rel.Release(prs[ci], sact, rnd) {
+			if stp {
+				pj.Com.STP.Recover(&recs[ci], &facs[ci], isi)
+			}
+			continue
+		}
+		g := wscs[ci]
+		if stp {
+			g *= pj.Com.STP.Spike(&recs[ci], &facs[ci], isi)
+		}
+		if dels != nil {
+			di = pj.Gidx.Idx(int(dels[ci]))
+		}
+		pj.Events[di] = append(pj.Events[di], SpikeEvent{Ri: ri, G: g})
+	}
+}
+
+// RecvGIncEvent delivers the spike events for the current cycle to the
+// receiving neurons, updating the GScale stats if stats is true
+func (pj *Prjn) RecvGIncEvent(stats bool) {
+	rlay := pj.Recv.(AxonLayer).AsAxon()
+	zi := pj.Gidx.Zi
+	tg := pj.GTarget()
+	evs := pj.Events[zi]
+	if !stats {
+		for _, ev := range evs {
+			ri := int(ev.Ri)
+			pj.GInc(&rlay.Neurons[ri], ri, ev.G, tg)
+		}
+	} else {
+		for _, ev := range evs { // sum per receiver, for stats
+			if pj.evG[ev.Ri] == 0 {
+				pj.evRis = append(pj.evRis, ev.Ri)
+			}
+			pj.evG[ev.Ri] += ev.G
+		}
+		var max, avg float32
+		var n int
+		for _, rii := range pj.evRis {
+			ri := int(rii)
+			g := pj.evG[ri]
+			if g == 0 {
+				continue
+			}
+			pj.GInc(&rlay.Neurons[ri], ri, g, tg)
+			pj.evG[ri] = 0
+			if g > max {
+				max = g
+			}
+			if g > 0 {
+				avg += g
+				n++
+			}
+		}
+		pj.evRis = pj.evRis[:0]
+		pj.GScaleStats(max, avg, n)
+	}
+	pj.Events[zi] = evs[:0]
+	pj.Gidx.Shift(1) // rotate buffer
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// SpikeRecorder records spike events (cycle, layer, unit) during Network.Cycle
+// into a compact in-memory buffer, which can be exported in CSV or binary
+// format.  Enable with Network.RecordSpikes.  Events are stored as parallel
+// arrays, with layers identified by index into LayNames.  NWB / HDF5 export
+// would require an HDF5 binding and is left to external conversion of the
+// binary or CSV files.
+type SpikeRecorder struct {
+	On        bool     `desc:"record spikes -- can be toggled without losing recorded events"`
+	Layers    []string `desc:"names of layers to record -- empty = all layers"`
+	MaxEvents int      `desc:"maximum number of events to record -- recording stops when reached -- 0 = no limit"`
+	LayNames  []string `inactive:"+" desc:"names of the layers, indexed by Lays values"`
+	Cycs      []int32  `view:"-" desc:"cycle (Time.CycleTot) of each spike event"`
+	Lays      []uint16 `view:"-" desc:"layer index (into LayNames) of each spike event"`
+	Units     []int32  `view:"-" desc:"unit index within layer of each spike event"`
+
+	layIdx []int // network layer index for each LayNames entry
+}
+
+// RecordSpikes turns spike recording on or off, creating the SpikeRecorder
+// if needed, recording from given layers (all if none), and returns it.
+// Changing the layers resets any recorded events.
+func (nt *Network) RecordSpikes(on bool, layers ...string) *SpikeRecorder {
+	if nt.SpikeRec == nil {
+		nt.SpikeRec = &SpikeRecorder{}
+	}
+	sr := nt.SpikeRec
+	sr.On = on
+	if len(layers) > 0 || sr.layIdx == nil {
+		sr.Layers = layers
+		sr.Config(nt)
+	}
+	return sr
+}
+
+// Config configures the recorder for given network, according to Layers,
+// and resets any recorded events
+func (sr *SpikeRecorder) Config(nt *Network) {
+	sr.LayNames = nil
+	sr.layIdx = nil
+	for li, ly := range nt.Layers {
+		if len(sr.Layers) > 0 && !strInList(ly.Name(), sr.Layers) {
+			continue
+		}
+		sr.LayNames = append(sr.LayNames, ly.Name())
+		sr.layIdx = append(sr.layIdx, li)
+	}
+	sr.Reset()
+}
+
+func strInList(s string, lst []string) bool {
+	for _, l := range lst {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset clears all recorded events, retaining allocated memory
+func (sr *SpikeRecorder) Reset() {
+	sr.Cycs = sr.Cycs[:0]
+	sr.Lays = sr.Lays[:0]
+	sr.Units = sr.Units[:0]
+}
+
+// N returns the number of recorded events
+func (sr *SpikeRecorder) N() int {
+	return len(sr.Cycs)
+}
+
+// Record records the spikes in the current cycle -- called in Network.Cycle
+func (sr *SpikeRecorder) Record(nt *Network, ltime *Time) {
+	cyc := int32(ltime.CycleTot)
+	for ri, li := range sr.layIdx {
+		if li >= len(nt.Layers) {
+			continue
+		}
+		ly := nt.Layers[li].(AxonLayer).AsAxon()
+		if ly.IsOff() {
+			continue
+		}
+		for ni := range ly.Neurons {
+			if ly.Neurons[ni].Spike == 0 {
+				continue
+			}
+			if sr.MaxEvents > 0 && len(sr.Cycs) >= sr.MaxEvents {
+				return
+			}
+			sr.Cycs = append(sr.Cycs, cyc)
+			sr.Lays = append(sr.Lays, uint16(ri))
+			sr.Units = append(sr.Units, int32(ni))
+		}
+	}
+}
+
+// WriteCSV writes the events in CSV format, with a header line:
+// Cycle,Layer,Unit -- layers are written by name
+func (sr *SpikeRecorder) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Cycle,Layer,Unit\n")
+	for i, cyc := range sr.Cycs {
+		fmt.Fprintf(bw, "%d,%s,%d\n", cyc, sr.LayNames[sr.Lays[i]], sr.Units[i])
+	}
+	return bw.Flush()
+}
+
+// SpikeRecMagic identifies the binary spike recording format
+const SpikeRecMagic = "AXSPK1"
+
+// WriteBinary writes the events in a compact little-endian binary format:
+// the SpikeRecMagic string, the number of layers (uint16), each layer name
+// (uint16 length + bytes), the number of events (uint64), and then each
+// event as int32 cycle, uint16 layer, int32 unit.
+func (sr *SpikeRecorder) WriteBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(SpikeRecMagic)
+	binary.Write(bw, binary.LittleEndian, uint16(len(sr.LayNames)))
+	for _, nm := range sr.LayNames {
+		binary.Write(bw, binary.LittleEndian, uint16(len(nm)))
+		bw.WriteString(nm)
+	}
+	binary.Write(bw, binary.LittleEndian, uint64(len(sr.Cycs)))
+	for i, cyc := range sr.Cycs {
+		binary.Write(bw, binary.LittleEndian, cyc)
+		binary.Write(bw, binary.LittleEndian, sr.Lays[i])
+		if err := binary.Write(bw, binary.LittleEndian, sr.Units[i]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadBinary reads events written by WriteBinary, replacing any current events
+func (sr *SpikeRecorder) ReadBinary(r io.Reader) error {
+	br := bufio.NewReader(r)
+	mg := make([]byte, len(SpikeRecMagic))
+	if _, err := io.ReadFull(br, mg); err != nil {
+		return err
+	}
+	if string(mg) != SpikeRecMagic {
+		return fmt.Errorf("SpikeRecorder ReadBinary: not a spike recording file")
+	}
+	var nl uint16
+	if err := binary.Read(br, binary.LittleEndian, &nl); err != nil {
+		return err
+	}
+	sr.LayNames = make([]string, nl)
+	for li := range sr.LayNames {
+		var sl uint16
+		if err := binary.Read(br, binary.LittleEndian, &sl); err != nil {
+			return err
+		}
+		nm := make([]byte, sl)
+		if _, err := io.ReadFull(br, nm); err != nil {
+			return err
+		}
+		sr.LayNames[li] = string(nm)
+	}
+	var ne uint64
+	if err := binary.Read(br, binary.LittleEndian, &ne); err != nil {
+		return err
+	}
+	sr.Cycs = make([]int32, ne)
+	sr.Lays = make([]uint16, ne)
+	sr.Units = make([]int32, ne)
+	for i := range sr.Cycs {
+		binary.Read(br, binary.LittleEndian, &sr.Cycs[i])
+		binary.Read(br, binary.LittleEndian, &sr.Lays[i])
+		if err := binary.Read(br, binary.LittleEndian, &sr.Units[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveCSV saves the events to given file in CSV format
+func (sr *SpikeRecorder) SaveCSV(filename string) error {
+	return sr.save(filename, sr.WriteCSV)
+}
+
+// SaveBinary saves the events to given file in binary format (see WriteBinary)
+func (sr *SpikeRecorder) SaveBinary(filename string) error {
+	return sr.save(filename, sr.WriteBinary)
+}
+
+func (sr *SpikeRecorder) save(filename string, fun func(w io.Writer) error) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer fp.Close()
+	err = fun(fp)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/emer/etable/etensor"
+)
+
+// InputStream is an interface for external inputs that change within a trial,
+// e.g., video frames or audio samples, which are applied to a layer at the
+// start of each Cycle, in addition to the standard once-per-trial ApplyExt.
+// Register with Network.SetInputStream.
+type InputStream interface {
+	// InputAt returns the input pattern to apply to the layer at the current
+	// time (ltime.Cycle counts cycles since NewState), or nil if the input
+	// has not changed since the last call, in which case nothing is applied.
+	InputAt(ltime *Time) etensor.Tensor
+}
+
+// InputBuffer is a basic InputStream that buffers a sequence of input frames,
+// presenting each frame for CycPerFrame cycles, starting at cycle Start
+// within each trial.  Frames are typically added with Push, e.g., as they
+// arrive from an environment.
+type InputBuffer struct {
+	Frames      []etensor.Tensor `desc:"buffered sequence of input frames"`
+	CycPerFrame int              `min:"1" desc:"number of cycles to present each frame"`
+	Start       int              `min:"0" desc:"cycle within each trial to start presenting frames"`
+	Loop        bool             `desc:"loop back to the first frame after the last one -- otherwise the last frame is held"`
+	Cur         int              `inactive:"+" desc:"index of last frame applied, -1 if none"`
+}
+
+// NewInputBuffer returns a new InputBuffer with given number of cycles per frame
+func NewInputBuffer(cycPerFrame int) *InputBuffer {
+	ib := &InputBuffer{CycPerFrame: cycPerFrame}
+	ib.Reset()
+	return ib
+}
+
+// Reset clears all frames
+func (ib *InputBuffer) Reset() {
+	ib.Frames = nil
+	ib.Cur = -1
+}
+
+// Push adds a frame to the end of the buffer
+func (ib *InputBuffer) Push(frame etensor.Tensor) {
+	ib.Frames = append(ib.Frames, frame)
+}
+
+// FrameIdx returns the frame index for given cycle within the trial, -1 if none
+func (ib *InputBuffer) FrameIdx(cyc int) int {
+	nf := len(ib.Frames)
+	if nf == 0 || cyc < ib.Start {
+		return -1
+	}
+	cpf := ib.CycPerFrame
+	if cpf < 1 {
+		cpf = 1
+	}
+	fi := (cyc - ib.Start) / cpf
+	if fi >= nf {
+		if ib.Loop {
+			fi = fi % nf
+		} else {
+			fi = nf - 1
+		}
+	}
+	return fi
+}
+
+// InputAt implements the InputStream interface
+func (ib *InputBuffer) InputAt(ltime *Time) etensor.Tensor {
+	if ltime.Cycle == 0 {
+		ib.Cur = -1 // new trial
+	}
+	fi := ib.FrameIdx(ltime.Cycle)
+	if fi < 0 || fi == ib.Cur {
+		return nil
+	}
+	ib.Cur = fi
+	return ib.Frames[fi]
+}
+
+// SetInputStream sets the InputStream for layer of given name, which is then
+// applied at the start of every Cycle -- nil removes it.
+func (nt *Network) SetInputStream(lay string, st InputStream) {
+	if st == nil {
+		delete(nt.Streams, lay)
+		return
+	}
+	if nt.Streams == nil {
+		nt.Streams = make(map[string]InputStream)
+	}
+	nt.Streams[lay] = st
+}
+
+// ApplyStreams applies any new input from the InputStreams to their layers.
+// Called at the start of every Cycle.
+func (nt *Network) ApplyStreams(ltime *Time) {
+	for lnm, st := range nt.Streams {
+		tsr := st.InputAt(ltime)
+		if tsr == nil {
+			continue
+		}
+		ly, err := nt.LayerByNameTry(lnm)
+		if err != nil || ly.IsOff() {
+			continue
+		}
+		ly.(AxonLayer).ApplyExt(tsr)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+)
+
+// Network surgery methods modify the structure of an already-built network,
+// re-allocating only the affected layers and projections, so that the
+// learned weights in the rest of the network are preserved.  This supports
+// curriculum and growing-network experiments.  Parameters for new layers
+// and projections are set to Defaults -- use ApplyParams to set others.
+
+// AddLayerDynamic adds a new layer to an already-built network, building
+// and initializing only the new layer.  Use ConnectLayersDynamic to
+// connect it to other layers.
+func (nt *Network) AddLayerDynamic(name string, shape []int, typ emer.LayerType) (emer.Layer, error) {
+	if _, has := nt.LayMap[name]; has {
+		return nil, fmt.Errorf("AddLayerDynamic: layer named: %v already exists in network: %v", name, nt.Nm)
+	}
+	nt.StopThreads()
+	ly := nt.AddLayer(name, shape, typ)
+	ly.Defaults()
+	ly.SetIndex(len(nt.Layers) - 1)
+	err := ly.Build()
+	if err == nil {
+		if nt.RandSeed != 0 {
+			ly.(AxonLayer).AsAxon().SetRand(NewRand(RandSubSeed(nt.RandSeed, name)))
+		}
+		ly.(AxonLayer).InitWts()
+	}
+	nt.rebuildAfterSurgery()
+	return ly, err
+}
+
+// ConnectLayersDynamic adds a new projection between two layers in an
+// already-built network, building and initializing only the new projection,
+// and updating the conductance scaling of the receiving layer.
+func (nt *Network) ConnectLayersDynamic(send, recv emer.Layer, pat prjn.Pattern, typ emer.PrjnType) (emer.Prjn, error) {
+	nt.StopThreads()
+	pj := nt.ConnectLayers(send, recv, pat, typ)
+	pj.Defaults()
+	err := pj.Build()
+	if err == nil {
+		if nt.RandSeed != 0 {
+			pj.(AxonPrjn).AsAxon().Rand = NewRand(RandSubSeed(nt.RandSeed, pj.Name()))
+		}
+		pj.(AxonPrjn).InitWts()
+		recv.(AxonLayer).InitGScale()
+	}
+	nt.rebuildAfterSurgery()
+	return pj, err
+}
+
+// DeletePrjn deletes the projection from send to recv layers of given names,
+// removing it from both the receiving and sending layer projection lists,
+// and updating the conductance scaling of the receiving layer.
+// Weights of all other projections are unaffected.
+func (nt *Network) DeletePrjn(send, recv string) error {
+	rlay, err := nt.LayerByNameTry(recv)
+	if err != nil {
+		return err
+	}
+	pj, err := rlay.RecvPrjns().SendNameTry(send)
+	if err != nil {
+		return err
+	}
+	nt.StopThreads()
+	nt.deletePrjn(pj)
+	rlay.(AxonLayer).InitGScale()
+	nt.rebuildAfterSurgery()
+	return nil
+}
+
+// DeleteLayer deletes the layer of given name, along with all of its
+// receiving and sending projections, updating the conductance scaling of
+// the layers it projected to.  Weights of all other projections are unaffected.
+func (nt *Network) DeleteLayer(name string) error {
+	ly, err := nt.LayerByNameTry(name)
+	if err != nil {
+		return err
+	}
+	nt.StopThreads()
+	var rlays []emer.Layer
+	for len(*ly.SendPrjns()) > 0 {
+		pj := (*ly.SendPrjns())[0]
+		if pj.RecvLay() != ly {
+			rlays = append(rlays, pj.RecvLay())
+		}
+		nt.deletePrjn(pj)
+	}
+	for len(*ly.RecvPrjns()) > 0 {
+		nt.deletePrjn((*ly.RecvPrjns())[0])
+	}
+	for li, l := range nt.Layers {
+		if l == ly {
+			nt.Layers = append(nt.Layers[:li], nt.Layers[li+1:]...)
+			break
+		}
+	}
+	for li, l := range nt.Layers {
+		l.SetIndex(li)
+		if l.RelPos().Other == name {
+			l.SetRelPos(relpos.Rel{Rel: relpos.NoRel})
+		}
+	}
+	nt.MakeLayMap()
+	for _, rl := range rlays {
+		rl.(AxonLayer).InitGScale()
+	}
+	nt.rebuildAfterSurgery()
+	return nil
+}
+
+// deletePrjn removes given projection from its recv and send layer lists
+func (nt *Network) deletePrjn(pj emer.Prjn) {
+	deletePrjnFrom(pj.RecvLay().RecvPrjns(), pj)
+	deletePrjnFrom(pj.SendLay().SendPrjns(), pj)
+}
+
+// deletePrjnFrom removes given projection from given list
+func deletePrjnFrom(pjs *emer.Prjns, pj emer.Prjn) {
+	for i, p := range *pjs {
+		if p == pj {
+			*pjs = append((*pjs)[:i], (*pjs)[i+1:]...)
+			return
+		}
+	}
+}
+
+// rebuildAfterSurgery updates the network-level layout, thread
+// allocation, profiler timers, and layer update waves after structural
+// changes, as all of these are indexed by the current set of layers.
+func (nt *Network) rebuildAfterSurgery() {
+	nt.Layout()
+	nt.BuildThreads()
+	nt.Prof.Init(len(nt.Layers))
+	nt.Waves = nil // recomputed at next Cycle
+	nt.StartThreads()
+}
@@ -25,6 +25,32 @@ func (sy *Synapse) VarNames() []string {
 
 var SynapseVars = []string{"Wt", "SWt", "LWt", "DWt", "DSWt"}
 
+// SynOptVars are the names of the optional per-synapse variables used by
+// specific features (short-term plasticity, traces, per-synapse learning
+// rates, optimizers, release probability), which are stored in per-projection
+// slices (Prjn SynRec, SynFac, etc), only allocated when the feature is in use,
+// instead of in every Synapse.  They are accessed as extra synapse variables
+// after the SynapseVars -- see SynapseVarsAll.
+var SynOptVars = []string{"Rec", "Fac", "Tr", "Lrate", "M", "V", "Pr"}
+
+// indexes of the SynOptVars
+const (
+	synOptRec = iota
+	synOptFac
+	synOptTr
+	synOptLrate
+	synOptM
+	synOptV
+	synOptPr
+)
+
+// SynapseVarsAll are all the synapse variables of the base Prjn: the
+// SynapseVars followed by the SynOptVars
+var SynapseVarsAll []string
+
+// SynOptVarsMap maps the SynOptVars names to their index in SynOptVars
+var SynOptVarsMap map[string]int
+
 var SynapseVarProps = map[string]string{
 	"DWt":  `auto-scale:"+"`,
 	"DSWt": `auto-scale:"+"`,
@@ -32,21 +58,46 @@ var SynapseVarProps = map[string]string{
 
 var SynapseVarsMap map[string]int
 
+// SynapseVarsExtend returns a new list of synapse variable names with the
+// standard SynapseVarsAll followed by given extra variables, for use by derived
+// Prjn types that add their own per-synapse state, in SynVarNames.
+// Indexes of the extra vars start at len(SynapseVarsAll) -- see Prjn SynVarNum.
+func SynapseVarsExtend(ext ...string) []string {
+	nb := len(SynapseVars) + len(SynOptVars)
+	vars := make([]string, nb+len(ext))
+	copy(vars, SynapseVars)
+	copy(vars[len(SynapseVars):], SynOptVars)
+	copy(vars[nb:], ext)
+	return vars
+}
+
 func init() {
+	SynapseVarsAll = SynapseVarsExtend()
 	SynapseVarsMap = make(map[string]int, len(SynapseVars))
 	typ := reflect.TypeOf((*Synapse)(nil)).Elem()
 	for i, v := range SynapseVars {
 		SynapseVarsMap[v] = i
-		pstr := SynapseVarProps[v]
 		if fld, has := typ.FieldByName(v); has {
-			if desc, ok := fld.Tag.Lookup("desc"); ok {
-				pstr += ` desc:"` + desc + `"`
-				SynapseVarProps[v] = pstr
-			}
+			synapseVarDesc(v, fld)
+		}
+	}
+	SynOptVarsMap = make(map[string]int, len(SynOptVars))
+	ptyp := reflect.TypeOf((*Prjn)(nil)).Elem()
+	for i, v := range SynOptVars {
+		SynOptVarsMap[v] = i
+		if fld, has := ptyp.FieldByName("Syn" + v); has {
+			synapseVarDesc(v, fld)
 		}
 	}
 }
 
+// synapseVarDesc adds the desc tag of given field to the SynapseVarProps for var v
+func synapseVarDesc(v string, fld reflect.StructField) {
+	if desc, ok := fld.Tag.Lookup("desc"); ok {
+		SynapseVarProps[v] += ` desc:"` + desc + `"`
+	}
+}
+
 // SynapseVarByName returns the index of the variable in the Synapse, or error
 func SynapseVarByName(varNm string) (int, error) {
 	i, ok := SynapseVarsMap[varNm]
@@ -0,0 +1,111 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+// ThetaParams specify the phase schedule of one theta cycle trial, as run
+// by Network.ThetaCyc: a number of phases of PhaseCyc cycles each, the last
+// of which is the plus phase, with the others all in the minus phase.
+// The default is the standard 200 msec theta cycle of 4 quarters (3 minus,
+// 1 plus) -- a beta-frequency cycle is NPhases = 2 with PhaseCyc = 50,
+// and longer settling regimes just use larger values.
+type ThetaParams struct {
+	PhaseCyc int `def:"50" min:"1" desc:"number of cycles per phase"`
+	NPhases  int `def:"4" min:"2" desc:"number of phases per theta cycle -- the last is the plus phase, and all the others are minus phase"`
+	PlusCyc  int `def:"0" min:"0" desc:"number of cycles in the plus phase, if different from PhaseCyc -- 0 = PhaseCyc"`
+	LrnPhase int `def:"-1" desc:"index of the minus phase at the end of which MinusPhase is called, recording the ActM values that drive the learning comparison with the plus phase -- any later minus phases continue settling without affecting learning -- -1 = the last minus phase"`
+}
+
+func (th *ThetaParams) Defaults() {
+	th.PhaseCyc = 50
+	th.NPhases = 4
+	th.PlusCyc = 0
+	th.LrnPhase = -1
+}
+
+func (th *ThetaParams) Update() {
+	if th.PhaseCyc < 1 {
+		th.PhaseCyc = 1
+	}
+	if th.NPhases < 2 {
+		th.NPhases = 2
+	}
+	if th.LrnPhase > th.NPhases-2 {
+		th.LrnPhase = -1
+	}
+}
+
+// LrnPhaseIdx returns the index of the minus phase at the end of which
+// MinusPhase is called, resolving LrnPhase = -1 to the last minus phase
+func (th *ThetaParams) LrnPhaseIdx() int {
+	if th.LrnPhase < 0 || th.LrnPhase > th.NPhases-2 {
+		return th.NPhases - 2
+	}
+	return th.LrnPhase
+}
+
+// IsPlus returns true if given phase index is the plus phase
+func (th *ThetaParams) IsPlus(phase int) bool {
+	return phase == th.NPhases-1
+}
+
+// PhaseCycles returns the number of cycles in given phase index
+func (th *ThetaParams) PhaseCycles(phase int) int {
+	if th.IsPlus(phase) {
+		return th.PlusCycles()
+	}
+	return th.PhaseCyc
+}
+
+// PlusCycles returns the number of cycles in the plus phase
+func (th *ThetaParams) PlusCycles() int {
+	if th.PlusCyc > 0 {
+		return th.PlusCyc
+	}
+	return th.PhaseCyc
+}
+
+// MinusCycles returns the total number of cycles in the minus phases
+func (th *ThetaParams) MinusCycles() int {
+	return (th.NPhases - 1) * th.PhaseCyc
+}
+
+// TotCycles returns the total number of cycles in the theta cycle
+func (th *ThetaParams) TotCycles() int {
+	return th.MinusCycles() + th.PlusCycles()
+}
+
+// ThetaCyc runs one theta cycle trial according to the Theta schedule:
+// NewState, then the minus phases, calling MinusPhase at the end of the
+// LrnPhase, then the plus phase, ending with PlusPhase.
+// If cycFun is non-nil, it is called after every cycle, e.g., to update
+// displays or record data.  Learning (DWt, WtFmDWt) is up to the caller.
+func (nt *Network) ThetaCyc(ltime *Time, cycFun func(ltime *Time)) {
+	nt.ThetaCycParams(&nt.Theta, ltime, cycFun)
+}
+
+// ThetaCycParams runs one theta cycle trial according to given schedule,
+// instead of the Network Theta params -- see ThetaCyc
+func (nt *Network) ThetaCycParams(th *ThetaParams, ltime *Time, cycFun func(ltime *Time)) {
+	nt.NewState()
+	ltime.NewState()
+	lrn := th.LrnPhaseIdx()
+	for ph := 0; ph < th.NPhases; ph++ {
+		if ph > 0 {
+			ltime.PhaseInc(th.IsPlus(ph))
+		}
+		ncyc := th.PhaseCycles(ph)
+		for cyc := 0; cyc < ncyc; cyc++ {
+			nt.Cycle(ltime)
+			ltime.CycleInc()
+			if cycFun != nil {
+				cycFun(ltime)
+			}
+		}
+		if ph == lrn {
+			nt.MinusPhase(ltime)
+		}
+	}
+	nt.PlusPhase(ltime)
+}
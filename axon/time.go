@@ -16,6 +16,7 @@ type Time struct {
 	PhaseCycle int     `desc:"cycle within current phase -- minus or plus"`
 	CycleTot   int     `desc:"total cycle count -- this increments continuously from whenever it was last reset -- typically this is number of milliseconds in simulation time"`
 	PlusPhase  bool    `desc:"true if this is the plus phase, when the outcome / bursting is occurring, driving positive learning -- else minus phase"`
+	Phase      int     `desc:"index of the current phase within the theta cycle, per Network.Theta -- the last one is the plus phase"`
 
 	TimePerCyc float32 `def:"0.001" desc:"amount of time to increment per cycle"`
 }
@@ -39,6 +40,7 @@ func (tm *Time) Reset() {
 	tm.PhaseCycle = 0
 	tm.CycleTot = 0
 	tm.PlusPhase = false
+	tm.Phase = 0
 	if tm.TimePerCyc == 0 {
 		tm.Defaults()
 	}
@@ -49,11 +51,19 @@ func (tm *Time) NewState() {
 	tm.Cycle = 0
 	tm.PhaseCycle = 0
 	tm.PlusPhase = false
+	tm.Phase = 0
 }
 
 // NewPhase updates from minus phase to plus phase and resets PhaseCycle
 func (tm *Time) NewPhase() {
-	tm.PlusPhase = true
+	tm.PhaseInc(true)
+}
+
+// PhaseInc increments to the next Phase, which is the plus phase if plus
+// is true, and resets PhaseCycle
+func (tm *Time) PhaseInc(plus bool) {
+	tm.Phase++
+	tm.PlusPhase = plus
 	tm.PhaseCycle = 0
 }
 
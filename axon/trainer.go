@@ -0,0 +1,502 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/ki/kit"
+)
+
+// TrainStats accumulates the standard error statistics over trials,
+// computed from the StatLays of a Trainer
+type TrainStats struct {
+	N          int       `inactive:"+" desc:"number of trials accumulated"`
+	SumUnitErr float64   `inactive:"+" desc:"sum of proportion of units in error (PctUnitErr)"`
+	SumErr     float64   `inactive:"+" desc:"sum of trials with any error"`
+	SumCosDiff float64   `inactive:"+" desc:"sum of CosDiff between minus and plus phases"`
+	SumMetrics []float64 `inactive:"+" desc:"sums of the custom Trainer Metrics values"`
+}
+
+// Init resets the accumulated values
+func (ts *TrainStats) Init() {
+	*ts = TrainStats{}
+}
+
+// Add accumulates given trial values
+func (ts *TrainStats) Add(unitErr, err, cosDiff float64) {
+	ts.N++
+	ts.SumUnitErr += unitErr
+	ts.SumErr += err
+	ts.SumCosDiff += cosDiff
+}
+
+// AddMetric accumulates given value for metric at given index
+func (ts *TrainStats) AddMetric(idx int, val float64) {
+	for len(ts.SumMetrics) <= idx {
+		ts.SumMetrics = append(ts.SumMetrics, 0)
+	}
+	ts.SumMetrics[idx] += val
+}
+
+// Metric returns the average value of the metric at given index
+func (ts *TrainStats) Metric(idx int) float64 {
+	if ts.N == 0 || idx >= len(ts.SumMetrics) {
+		return 0
+	}
+	return ts.SumMetrics[idx] / float64(ts.N)
+}
+
+// UnitErr returns the average proportion of units in error
+func (ts *TrainStats) UnitErr() float64 {
+	if ts.N == 0 {
+		return 0
+	}
+	return ts.SumUnitErr / float64(ts.N)
+}
+
+// PctErr returns the proportion of trials with any error
+func (ts *TrainStats) PctErr() float64 {
+	if ts.N == 0 {
+		return 0
+	}
+	return ts.SumErr / float64(ts.N)
+}
+
+// PctCor returns the proportion of trials with no error
+func (ts *TrainStats) PctCor() float64 {
+	if ts.N == 0 {
+		return 0
+	}
+	return 1 - ts.PctErr()
+}
+
+// CosDiff returns the average CosDiff
+func (ts *TrainStats) CosDiff() float64 {
+	if ts.N == 0 {
+		return 0
+	}
+	return ts.SumCosDiff / float64(ts.N)
+}
+
+// TrainMetric is a custom per-trial metric computed by a Trainer,
+// which is averaged over the epoch and recorded in the logs
+type TrainMetric struct {
+	Name string                    `desc:"name of the metric, used for the log column"`
+	Fun  func(tr *Trainer) float64 `view:"-" json:"-" xml:"-" desc:"function computing the metric value for the current trial, called after TrialStats"`
+}
+
+// EarlyStopStats are the test stats that can be used for early stopping
+type EarlyStopStats int32
+
+//go:generate stringer -type=EarlyStopStats
+
+var KiT_EarlyStopStats = kit.Enums.AddEnum(EarlyStopStatsN, kit.NotBitFlag, nil)
+
+func (ev EarlyStopStats) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *EarlyStopStats) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The test stats for early stopping
+const (
+	// StopUnitErr uses the average proportion of units in error -- lower is better
+	StopUnitErr EarlyStopStats = iota
+
+	// StopCosDiff uses the average CosDiff -- higher is better
+	StopCosDiff
+
+	EarlyStopStatsN
+)
+
+// EarlyStopParams control early stopping of a training run based on
+// a plateau in test set performance, evaluated each time the test
+// set is run in a Trainer
+type EarlyStopParams struct {
+	On       bool           `desc:"stop the run when the test Stat has not improved by more than MinDelta for Patience test evaluations"`
+	Stat     EarlyStopStats `viewif:"On" desc:"test statistic to evaluate"`
+	Patience int            `viewif:"On" def:"5" min:"1" desc:"number of test evaluations without improvement before stopping"`
+	MinDelta float64        `viewif:"On" def:"0.001" desc:"minimum change in Stat counting as an improvement"`
+
+	Best  float64 `inactive:"+" desc:"best value of Stat so far in this run"`
+	NoImp int     `inactive:"+" desc:"number of test evaluations since the last improvement"`
+	Done  bool    `inactive:"+" desc:"true if the run has been stopped early"`
+}
+
+func (es *EarlyStopParams) Defaults() {
+	es.Patience = 5
+	es.MinDelta = 0.001
+}
+
+// Init resets the tracking state for a new run
+func (es *EarlyStopParams) Init() {
+	es.NoImp = 0
+	es.Done = false
+	if es.Stat == StopCosDiff {
+		es.Best = -1
+	} else {
+		es.Best = 1
+	}
+}
+
+// Update updates tracking given the test stats, returning true
+// if training should stop
+func (es *EarlyStopParams) Update(ts *TrainStats) bool {
+	if !es.On || ts.N == 0 {
+		return false
+	}
+	var imp float64
+	var val float64
+	if es.Stat == StopCosDiff {
+		val = ts.CosDiff()
+		imp = val - es.Best
+	} else {
+		val = ts.UnitErr()
+		imp = es.Best - val
+	}
+	if imp > es.MinDelta {
+		es.Best = val
+		es.NoImp = 0
+	} else {
+		es.NoImp++
+	}
+	if es.NoImp >= es.Patience {
+		es.Done = true
+	}
+	return es.Done
+}
+
+// Trainer implements the standard Trial / Epoch / Run training loops
+// for a Network driven by an env.Env, so that sims do not need to
+// duplicate this code: each trial applies the Env states to the layers
+// of the same name, runs the minus and plus phases, learns, and
+// accumulates error stats, which are recorded in EpcLog at the end of
+// each epoch.  If TestEnv is set, the test set is evaluated every
+// TestInterval epochs with learning off, recording stats in TstEpcLog,
+// which can drive EarlyStop.  Custom per-trial Metrics are averaged and
+// logged in both logs, and custom stats and logging can be added via
+// TrialFun and EpochFun.  The individual steps can also be called
+// directly for finer-grained control.
+type Trainer struct {
+	Net       *Network          `desc:"the network"`
+	Env       env.Env           `desc:"the training environment"`
+	Time      Time              `desc:"the time state"`
+	MinusCyc  int               `def:"0" desc:"if > 0, overrides the Net.Theta schedule with a single minus phase of this number of cycles -- 0 = use Net.Theta"`
+	PlusCyc   int               `viewif:"MinusCyc>0" def:"0" desc:"number of cycles in the plus phase when MinusCyc overrides Net.Theta -- 0 = Net.Theta.PlusCycles()"`
+	MaxEpcs   int               `def:"100" desc:"maximum number of epochs per run"`
+	NZeroStop int               `def:"5" desc:"stop a run after this number of consecutive epochs with zero errors -- 0 = never stop early"`
+	InLays    []string          `desc:"names of layers to apply Env states to (the state of the same name) -- defaults to all Input and Target layers"`
+	StatLays  []string          `desc:"names of layers to compute error stats on, averaged across layers -- defaults to all Target layers"`
+	TrialFun  func(tr *Trainer) `view:"-" json:"-" xml:"-" desc:"optional function called at the end of each trial, after TrialStats, e.g., for custom stats and logging"`
+	EpochFun  func(tr *Trainer) `view:"-" json:"-" xml:"-" desc:"optional function called at the end of each epoch, after the EpcLog row is recorded and before EpcStats is reset"`
+	Metrics   []TrainMetric     `desc:"custom per-trial metrics, averaged over epochs and recorded in EpcLog and TstEpcLog -- add before Config"`
+
+	TestEnv      env.Env           `desc:"optional test environment, evaluated every TestInterval epochs with learning off -- one Env epoch is one pass through the test set"`
+	TestInterval int               `desc:"number of training epochs between test set evaluations -- 0 = never"`
+	TestFun      func(tr *Trainer) `view:"-" json:"-" xml:"-" desc:"optional function called at the end of each test set evaluation, after the TstEpcLog row is recorded"`
+	EarlyStop    EarlyStopParams   `view:"inline" desc:"early stopping based on test set performance"`
+	Testing      bool              `inactive:"+" desc:"true while running the test set, e.g., for TrialFun to distinguish test trials"`
+	TstStats     TrainStats        `inactive:"+" desc:"stats accumulated over the last test set evaluation"`
+	TstEpcLog    *etable.Table     `desc:"log of test set stats, one row per test set evaluation"`
+
+	Run        int           `inactive:"+" desc:"current run"`
+	Epoch      int           `inactive:"+" desc:"current epoch, from the Env Epoch counter"`
+	NZero      int           `inactive:"+" desc:"number of consecutive epochs with zero errors"`
+	TrlUnitErr float64       `inactive:"+" desc:"current trial's proportion of units in error"`
+	TrlErr     float64       `inactive:"+" desc:"1 if current trial had any error, else 0"`
+	TrlCosDiff float64       `inactive:"+" desc:"current trial's CosDiff"`
+	EpcStats   TrainStats    `inactive:"+" desc:"stats accumulated over the current epoch"`
+	EpcLog     *etable.Table `desc:"epoch-level log of stats, one row per epoch"`
+	StopNow    bool          `view:"-" desc:"set to true to stop the current TrainEpoch or TrainRun loop"`
+}
+
+// NewTrainer returns a new Trainer for given network and env,
+// with default settings, configured by Config
+func NewTrainer(net *Network, en env.Env) *Trainer {
+	tr := &Trainer{Net: net, Env: en}
+	tr.Defaults()
+	tr.Config()
+	return tr
+}
+
+func (tr *Trainer) Defaults() {
+	tr.Time.Defaults()
+	tr.MinusCyc = 0
+	tr.PlusCyc = 0
+	tr.MaxEpcs = 100
+	tr.NZeroStop = 5
+	tr.EarlyStop.Defaults()
+}
+
+// Config sets the default InLays and StatLays if not already set,
+// and configures the EpcLog
+func (tr *Trainer) Config() {
+	if tr.InLays == nil {
+		for _, ly := range tr.Net.Layers {
+			if ly.Type() == emer.Input || ly.Type() == emer.Target {
+				tr.InLays = append(tr.InLays, ly.Name())
+			}
+		}
+	}
+	if tr.StatLays == nil {
+		for _, ly := range tr.Net.Layers {
+			if ly.Type() == emer.Target {
+				tr.StatLays = append(tr.StatLays, ly.Name())
+			}
+		}
+	}
+	if tr.EpcLog == nil {
+		tr.EpcLog = &etable.Table{}
+	}
+	tr.ConfigEpcLog(tr.EpcLog, "EpcLog")
+	if tr.TstEpcLog == nil {
+		tr.TstEpcLog = &etable.Table{}
+	}
+	tr.ConfigEpcLog(tr.TstEpcLog, "TstEpcLog")
+}
+
+// ConfigEpcLog configures given table for an epoch log with given name
+func (tr *Trainer) ConfigEpcLog(dt *etable.Table, name string) {
+	dt.SetMetaData("name", name)
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"UnitErr", etensor.FLOAT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+	}
+	for _, mt := range tr.Metrics {
+		sch = append(sch, etable.Column{mt.Name, etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// Init initializes a new run: the Env for given run, the network weights,
+// the time state, and the stats -- EpcLog rows accumulate across runs
+func (tr *Trainer) Init(run int) {
+	tr.Run = run
+	tr.Env.Init(run)
+	tr.Net.InitWts()
+	tr.Time.Reset()
+	tr.Epoch = 0
+	tr.NZero = 0
+	tr.StopNow = false
+	tr.Testing = false
+	tr.EpcStats.Init()
+	tr.TstStats.Init()
+	tr.EarlyStop.Init()
+}
+
+// ApplyInputs applies the current states of given Env to the InLays
+func (tr *Trainer) ApplyInputs(en env.Env) {
+	tr.Net.InitExt()
+	for _, lnm := range tr.InLays {
+		ly := tr.Net.LayerByName(lnm)
+		if ly == nil {
+			continue
+		}
+		pats := en.State(lnm)
+		if pats != nil {
+			ly.(AxonLayer).ApplyExt(pats)
+		}
+	}
+}
+
+// ThetaCyc runs one theta cycle trial of the minus and plus phases,
+// per the Net.Theta schedule (or MinusCyc / PlusCyc if set),
+// with learning if train is true: weights are updated from the prior
+// DWt at the start, so DWt values remain visible at the end.
+func (tr *Trainer) ThetaCyc(train bool) {
+	net := tr.Net
+	ltime := &tr.Time
+	if train {
+		net.WtFmDWt()
+	}
+	if tr.MinusCyc > 0 {
+		th := ThetaParams{PhaseCyc: tr.MinusCyc, NPhases: 2, PlusCyc: tr.PlusCyc, LrnPhase: -1}
+		if th.PlusCyc <= 0 {
+			th.PlusCyc = net.Theta.PlusCycles()
+		}
+		net.ThetaCycParams(&th, ltime, nil)
+	} else {
+		net.ThetaCyc(ltime, nil)
+	}
+	if train {
+		net.DWt()
+	}
+}
+
+// TrialStats computes the trial-level stats from the StatLays,
+// and the custom Metrics, accumulating into given stats if non-nil
+func (tr *Trainer) TrialStats(accum *TrainStats) {
+	tr.TrlUnitErr = 0
+	tr.TrlCosDiff = 0
+	n := 0
+	for _, lnm := range tr.StatLays {
+		ly := tr.Net.LayerByName(lnm)
+		if ly == nil {
+			continue
+		}
+		aly := ly.(AxonLayer).AsAxon()
+		tr.TrlUnitErr += aly.PctUnitErr()
+		tr.TrlCosDiff += float64(aly.CosDiff.Cos)
+		n++
+	}
+	if n > 1 {
+		tr.TrlUnitErr /= float64(n)
+		tr.TrlCosDiff /= float64(n)
+	}
+	tr.TrlErr = 0
+	if tr.TrlUnitErr > 0 {
+		tr.TrlErr = 1
+	}
+	if accum == nil {
+		return
+	}
+	accum.Add(tr.TrlUnitErr, tr.TrlErr, tr.TrlCosDiff)
+	for mi, mt := range tr.Metrics {
+		accum.AddMetric(mi, mt.Fun(tr))
+	}
+}
+
+// TrainTrial runs one training trial, first stepping the Env and
+// handling the end of the epoch if the Env Epoch counter changed.
+// Returns false if the run is done (MaxEpcs, NZeroStop or EarlyStop
+// reached), in which case the trial is not run.
+func (tr *Trainer) TrainTrial() bool {
+	tr.Env.Step()
+	// query counters first because the current state is in the next epoch
+	// if the epoch counter has changed
+	epc, _, chg := tr.Env.Counter(env.Epoch)
+	if chg {
+		tr.EpochEnd()
+		tr.Epoch = epc
+		if tr.TestEnv != nil && tr.TestInterval > 0 && epc%tr.TestInterval == 0 {
+			tr.TestAll()
+		}
+		if tr.RunDone() {
+			return false
+		}
+	}
+	tr.ApplyInputs(tr.Env)
+	tr.ThetaCyc(true)
+	tr.TrialStats(&tr.EpcStats)
+	if tr.TrialFun != nil {
+		tr.TrialFun(tr)
+	}
+	return true
+}
+
+// RunDone returns true if the current run is done, based on MaxEpcs,
+// NZeroStop and EarlyStop
+func (tr *Trainer) RunDone() bool {
+	return tr.Epoch >= tr.MaxEpcs || (tr.NZeroStop > 0 && tr.NZero >= tr.NZeroStop) || tr.EarlyStop.Done
+}
+
+// EpochEnd is called at the end of each epoch: it updates NZero,
+// records the EpcLog row, calls Network.EpochInc and EpochFun,
+// and resets EpcStats
+func (tr *Trainer) EpochEnd() {
+	if tr.EpcStats.N > 0 && tr.EpcStats.SumErr == 0 {
+		tr.NZero++
+	} else {
+		tr.NZero = 0
+	}
+	tr.LogEpoch(tr.EpcLog, &tr.EpcStats)
+	tr.Net.EpochInc()
+	if tr.EpochFun != nil {
+		tr.EpochFun(tr)
+	}
+	tr.EpcStats.Init()
+}
+
+// LogEpoch adds a row to given epoch log table with given stats
+func (tr *Trainer) LogEpoch(dt *etable.Table, ts *TrainStats) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+	dt.SetCellFloat("Run", row, float64(tr.Run))
+	dt.SetCellFloat("Epoch", row, float64(tr.Epoch))
+	dt.SetCellFloat("UnitErr", row, ts.UnitErr())
+	dt.SetCellFloat("PctErr", row, ts.PctErr())
+	dt.SetCellFloat("PctCor", row, ts.PctCor())
+	dt.SetCellFloat("CosDiff", row, ts.CosDiff())
+	for mi, mt := range tr.Metrics {
+		dt.SetCellFloat(mt.Name, row, ts.Metric(mi))
+	}
+}
+
+// TestTrial runs one test trial on the TestEnv with learning off,
+// accumulating TstStats.  Returns false if the TestEnv epoch has
+// ended, in which case the trial is not run.
+func (tr *Trainer) TestTrial() bool {
+	tr.TestEnv.Step()
+	if _, _, chg := tr.TestEnv.Counter(env.Epoch); chg {
+		return false
+	}
+	tr.ApplyInputs(tr.TestEnv)
+	tr.ThetaCyc(false)
+	tr.TrialStats(&tr.TstStats)
+	if tr.TrialFun != nil {
+		tr.TrialFun(tr)
+	}
+	return true
+}
+
+// TestAll runs through the full test set (one TestEnv epoch) with
+// learning off, records the TstEpcLog row, updates EarlyStop and
+// calls TestFun
+func (tr *Trainer) TestAll() {
+	tr.Testing = true
+	tr.TstStats.Init()
+	tr.TestEnv.Init(tr.Run)
+	for tr.TestTrial() {
+		if tr.StopNow {
+			break
+		}
+	}
+	tr.Testing = false
+	tr.LogEpoch(tr.TstEpcLog, &tr.TstStats)
+	tr.EarlyStop.Update(&tr.TstStats)
+	if tr.TestFun != nil {
+		tr.TestFun(tr)
+	}
+}
+
+// TrainEpoch runs training trials for the remainder of the current epoch.
+// Returns false if the run is done.
+func (tr *Trainer) TrainEpoch() bool {
+	tr.StopNow = false
+	curEpc := tr.Epoch
+	for {
+		if !tr.TrainTrial() {
+			return false
+		}
+		if tr.StopNow || tr.Epoch != curEpc {
+			return true
+		}
+	}
+}
+
+// TrainRun runs training trials for the remainder of the current run
+func (tr *Trainer) TrainRun() {
+	tr.StopNow = false
+	for {
+		if !tr.TrainTrial() || tr.StopNow {
+			return
+		}
+	}
+}
+
+// Train runs given number of runs, calling Init for each one
+func (tr *Trainer) Train(nruns int) {
+	for run := 0; run < nruns; run++ {
+		tr.Init(run)
+		tr.TrainRun()
+		if tr.StopNow {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+
+	"github.com/emer/etable/etensor"
+	"github.com/goki/mat32"
+)
+
+// SetTrgAvgs sets per-neuron target average activations (TrgAvg) from given
+// tensor of values, which must have the same number of elements as the layer
+// has neurons.  The values are normalized to a mean of 1 (within each pool
+// if Learn.TrgAvgAct.Pool applies), as TrgAvg is a proportion of the overall
+// layer activity, so they can be raw firing rates, e.g., from an empirically
+// measured (log-normal) distribution.  Values are clipped to
+// Learn.TrgAvgAct.TrgRange, which should be set to cover the distribution.
+// The values are retained in TrgAvgs and used at each InitWts, and are
+// applied to the neurons immediately.
+func (ly *Layer) SetTrgAvgs(tsr etensor.Tensor) error {
+	nn := len(ly.Neurons)
+	if tsr.Len() != nn {
+		return fmt.Errorf("SetTrgAvgs: layer %s has %d neurons but tensor has %d values", ly.Nm, nn, tsr.Len())
+	}
+	if cap(ly.TrgAvgs) >= nn {
+		ly.TrgAvgs = ly.TrgAvgs[:nn]
+	} else {
+		ly.TrgAvgs = make([]float32, nn)
+	}
+	for ni := range ly.TrgAvgs {
+		v := float32(tsr.FloatVal1D(ni))
+		if v < 0 || mat32.IsNaN(v) {
+			ly.TrgAvgs = nil
+			return fmt.Errorf("SetTrgAvgs: layer %s invalid value %g at index %d", ly.Nm, v, ni)
+		}
+		ly.TrgAvgs[ni] = v
+	}
+	if ly.HasPoolInhib() && ly.Learn.TrgAvgAct.Pool {
+		for pi := 1; pi < len(ly.Pools); pi++ {
+			pl := &ly.Pools[pi]
+			normMean(ly.TrgAvgs[pl.StIdx:pl.EdIdx])
+		}
+	} else {
+		normMean(ly.TrgAvgs)
+	}
+	ly.ApplyTrgAvgs()
+	return nil
+}
+
+// SetTrgAvgsLogNormal sets per-neuron target average activations (see
+// SetTrgAvgs) by sampling from a log-normal distribution with given
+// standard deviation of the underlying normal, using the layer Rand.
+func (ly *Layer) SetTrgAvgsLogNormal(sigma float32) error {
+	tsr := etensor.NewFloat32([]int{len(ly.Neurons)}, nil, nil)
+	rnd := ly.Rnd()
+	for ni := range tsr.Values {
+		tsr.Values[ni] = mat32.Exp(sigma * float32(rnd.NormFloat64()))
+	}
+	return ly.SetTrgAvgs(tsr)
+}
+
+// ClearTrgAvgs clears any per-neuron target average activations set by
+// SetTrgAvgs, reverting to the uniform TrgRange distribution at the next InitWts
+func (ly *Layer) ClearTrgAvgs() {
+	ly.TrgAvgs = nil
+}
+
+// ApplyTrgAvgs sets the neuron TrgAvg values (and associated AvgPct, ActAvg)
+// from TrgAvgs, if set -- called in InitActAvg
+func (ly *Layer) ApplyTrgAvgs() {
+	if len(ly.TrgAvgs) != len(ly.Neurons) {
+		return
+	}
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		nrn.TrgAvg = ly.Learn.TrgAvgAct.TrgRange.ClipVal(ly.TrgAvgs[ni])
+		nrn.AvgPct = nrn.TrgAvg
+		nrn.ActAvg = ly.Inhib.ActAvg.Init * nrn.TrgAvg
+		nrn.AvgDif = 0
+		nrn.DTrgAvg = 0
+	}
+}
+
+// normMean normalizes given values to have a mean of 1
+func normMean(vals []float32) {
+	sum := float32(0)
+	for _, v := range vals {
+		sum += v
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(len(vals)) / sum
+	for i := range vals {
+		vals[i] *= norm
+	}
+}
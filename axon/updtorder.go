@@ -0,0 +1,199 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"sync"
+
+	"github.com/emer/emergent/emer"
+	"github.com/goki/ki/kit"
+)
+
+// UpdtOrders are the different orderings of layer updates within Network.Cycle
+type UpdtOrders int32
+
+//go:generate stringer -type=UpdtOrders
+
+var KiT_UpdtOrders = kit.Enums.AddEnum(UpdtOrdersN, kit.NotBitFlag, nil)
+
+func (ev UpdtOrders) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *UpdtOrders) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The layer update orderings
+const (
+	// UpdtSync is synchronous updating (the default): each step of the cycle
+	// (SendSpike, GFmInc, inhibition, ActFmG) is done for all layers before
+	// the next, so all layers receive the spikes sent on the previous cycle,
+	// and the order of layers in the network is irrelevant.
+	UpdtSync UpdtOrders = iota
+
+	// UpdtSeq is sequential updating in topological (feedforward) order:
+	// each layer is fully updated and sends its spikes before the next, so
+	// with a Com.Delay of 0, activity propagates through an entire
+	// feedforward chain of layers in a single cycle (single-pass inference).
+	UpdtSeq
+
+	// UpdtWaves groups layers into waves by their depth in the feedforward
+	// dependency graph: the waves are updated sequentially as in UpdtSeq,
+	// while the mutually independent layers within each wave are updated
+	// in parallel if NThreads > 1.
+	UpdtWaves
+
+	UpdtOrdersN
+)
+
+// UpdtDeps returns the layers that given layer depends on for the
+// UpdtSeq and UpdtWaves orderings: the senders of all its active
+// receiving projections, excluding Back and Lateral projections,
+// which carry the spikes of the previous cycle.
+func (nt *Network) UpdtDeps(ly emer.Layer) []emer.Layer {
+	var deps []emer.Layer
+	for _, p := range *ly.RecvPrjns() {
+		if p.IsOff() || p.Type() == emer.Back || p.Type() == emer.Lateral {
+			continue
+		}
+		sl := p.SendLay()
+		if sl == ly || sl.IsOff() {
+			continue
+		}
+		deps = append(deps, sl)
+	}
+	return deps
+}
+
+// ConfigWaves computes the layer update Waves for the UpdtSeq and
+// UpdtWaves orderings from the dependency graph of UpdtDeps: each layer
+// is placed in the wave after the latest of the layers it depends on.
+// Any cycles in the graph are broken in favor of the layer that comes
+// first in the network.  This is done automatically at the first Cycle
+// after InitWts, and must be called again after changing the projections
+// or UpdtOrder at any other time.
+func (nt *Network) ConfigWaves() {
+	nt.Waves = nil
+	nl := len(nt.Layers)
+	depth := make(map[emer.Layer]int, nl)
+	deps := make([][]emer.Layer, nl)
+	for li, ly := range nt.Layers {
+		if !ly.IsOff() {
+			deps[li] = nt.UpdtDeps(ly)
+		}
+	}
+	var order []int
+	for len(depth) < nl {
+		next := -1
+		forced := -1
+		for li, ly := range nt.Layers {
+			if _, has := depth[ly]; has {
+				continue
+			}
+			if ly.IsOff() {
+				depth[ly] = -1
+				continue
+			}
+			if forced < 0 {
+				forced = li
+			}
+			ready := true
+			for _, dl := range deps[li] {
+				if _, has := depth[dl]; !has {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = li
+				break
+			}
+		}
+		if next < 0 {
+			if forced < 0 {
+				break // only off layers remained
+			}
+			next = forced // cycle: break in favor of first layer
+		}
+		ly := nt.Layers[next]
+		d := 0
+		for _, dl := range deps[next] {
+			if dd, has := depth[dl]; has && dd+1 > d {
+				d = dd + 1
+			}
+		}
+		depth[ly] = d
+		order = append(order, next)
+	}
+	for _, li := range order {
+		ly := nt.Layers[li]
+		if nt.UpdtOrder == UpdtWaves {
+			d := depth[ly]
+			for len(nt.Waves) <= d {
+				nt.Waves = append(nt.Waves, nil)
+			}
+			nt.Waves[d] = append(nt.Waves[d], ly.(AxonLayer))
+		} else {
+			nt.Waves = append(nt.Waves, []AxonLayer{ly.(AxonLayer)})
+		}
+	}
+}
+
+// WaveNames returns the names of the layers in each update wave,
+// for checking the UpdtSeq or UpdtWaves ordering
+func (nt *Network) WaveNames() [][]string {
+	if nt.Waves == nil {
+		nt.ConfigWaves()
+	}
+	wn := make([][]string, len(nt.Waves))
+	for wi, wv := range nt.Waves {
+		for _, ly := range wv {
+			wn[wi] = append(wn[wi], ly.Name())
+		}
+	}
+	return wn
+}
+
+// CycleWaves runs one cycle of updating for the UpdtSeq and UpdtWaves
+// orderings: for each wave in turn, each layer integrates its received
+// spikes and updates its inhibition and activation (in parallel within
+// the wave if NThreads > 1), and then sends its spikes.
+func (nt *Network) CycleWaves(ltime *Time) {
+	if nt.Waves == nil {
+		nt.ConfigWaves()
+	}
+	for _, wv := range nt.Waves {
+		if len(wv) > 1 && nt.NThreads > 1 {
+			nt.waveFun(wv, func(ly AxonLayer) { nt.CycleLayer(ly, ltime) })
+			nt.waveFun(wv, func(ly AxonLayer) { ly.SendSpike(ltime) })
+			continue
+		}
+		for _, ly := range wv {
+			nt.CycleLayer(ly, ltime)
+		}
+		for _, ly := range wv {
+			ly.SendSpike(ltime)
+		}
+	}
+}
+
+// CycleLayer runs the Cycle updates for one layer, prior to SendSpike,
+// for the UpdtSeq and UpdtWaves orderings
+func (nt *Network) CycleLayer(ly AxonLayer, ltime *Time) {
+	ly.GFmInc(ltime)
+	ly.AvgMaxGe(ltime)
+	ly.InhibFmGeAct(ltime)
+	ly.ActFmG(ltime)
+	ly.AvgMaxAct(ltime)
+}
+
+// waveFun calls given function on each layer of a wave in parallel
+func (nt *Network) waveFun(wv []AxonLayer, fun func(ly AxonLayer)) {
+	var wg sync.WaitGroup
+	for _, ly := range wv {
+		wg.Add(1)
+		go func(ly AxonLayer) {
+			fun(ly)
+			wg.Done()
+		}(ly)
+	}
+	wg.Wait()
+}
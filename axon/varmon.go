@@ -0,0 +1,181 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"fmt"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// VarMonitor records selected unit variables from a layer into an
+// etable.Table every Every cycles, called automatically in Network.Cycle.
+// Each variable is a column with the layer shape as its cell shape,
+// plus a Cycle column with Time.CycleTot.  Memory can be bounded with
+// MaxRows, which turns the table into a ring buffer, and Every
+// downsamples in time, with Avg averaging over the intervening cycles
+// instead of taking a single sample.
+type VarMonitor struct {
+	On      bool          `desc:"record values -- can be toggled without losing recorded rows"`
+	Layer   string        `desc:"name of layer to record from"`
+	Vars    []string      `desc:"names of unit variables to record"`
+	Every   int           `min:"1" desc:"record a row every this many cycles -- downsamples in time"`
+	Avg     bool          `desc:"record the average over the Every cycles instead of the value at the last cycle"`
+	MaxRows int           `desc:"if > 0, maximum number of rows -- table becomes a ring buffer with the oldest row overwritten -- see Head"`
+	Table   *etable.Table `desc:"the table holding the recorded values"`
+	Head    int           `inactive:"+" desc:"for ring buffer, row index of the next row to write = oldest row when full"`
+	NRec    int           `inactive:"+" desc:"total number of rows recorded since last Reset"`
+
+	ly     *Layer      // the layer
+	varIdx []int       // var indexes
+	sums   [][]float32 // per var sums for Avg
+	cnt    int         // cycles since last row
+}
+
+// AddVarMonitor adds a monitor that records given unit variables from
+// given layer every everyNCycles into an etable.Table, returning the
+// monitor, which can be further configured (e.g., MaxRows, Avg) --
+// call Reset after changing MaxRows.
+func (nt *Network) AddVarMonitor(layer string, varNames []string, everyNCycles int) (*VarMonitor, error) {
+	vm := &VarMonitor{On: true, Layer: layer, Vars: varNames, Every: everyNCycles}
+	if err := vm.Config(nt); err != nil {
+		return nil, err
+	}
+	nt.VarMons = append(nt.VarMons, vm)
+	return vm, nil
+}
+
+// DeleteVarMonitors removes all var monitors
+func (nt *Network) DeleteVarMonitors() {
+	nt.VarMons = nil
+}
+
+// RecordVarMonitors calls Record on all var monitors that are On --
+// called in Network.Cycle
+func (nt *Network) RecordVarMonitors(ltime *Time) {
+	for _, vm := range nt.VarMons {
+		if vm.On {
+			vm.Record(ltime)
+		}
+	}
+}
+
+// Config configures the monitor for given network, according to
+// Layer and Vars, resetting the table
+func (vm *VarMonitor) Config(nt *Network) error {
+	lyi, err := nt.LayerByNameTry(vm.Layer)
+	if err != nil {
+		return err
+	}
+	vm.ly = lyi.(AxonLayer).AsAxon()
+	if vm.Every < 1 {
+		vm.Every = 1
+	}
+	vm.varIdx = make([]int, len(vm.Vars))
+	for i, vn := range vm.Vars {
+		vi, err := vm.ly.UnitVarIdx(vn)
+		if err != nil {
+			return fmt.Errorf("VarMonitor: layer %s: %v", vm.Layer, err)
+		}
+		vm.varIdx[i] = vi
+	}
+	shp := vm.ly.Shp.Shp
+	dnms := vm.ly.Shp.Nms
+	sch := etable.Schema{{"Cycle", etensor.INT64, nil, nil}}
+	for _, vn := range vm.Vars {
+		sch = append(sch, etable.Column{vn, etensor.FLOAT32, shp, dnms})
+	}
+	vm.Table = &etable.Table{}
+	vm.Table.SetMetaData("name", vm.Layer+"VarMon")
+	vm.Table.SetFromSchema(sch, 0)
+	vm.Reset()
+	return nil
+}
+
+// Reset clears all recorded rows, and any partial averages
+func (vm *VarMonitor) Reset() {
+	vm.Head = 0
+	vm.NRec = 0
+	vm.cnt = 0
+	if vm.Table != nil {
+		vm.Table.SetNumRows(vm.MaxRows)
+	}
+	nn := 0
+	if vm.ly != nil {
+		nn = len(vm.ly.Neurons)
+	}
+	vm.sums = make([][]float32, len(vm.Vars))
+	for i := range vm.sums {
+		vm.sums[i] = make([]float32, nn)
+	}
+}
+
+// Record is called every cycle, accumulating values for Avg and
+// writing a row every Every cycles
+func (vm *VarMonitor) Record(ltime *Time) {
+	if vm.ly == nil || vm.Table == nil {
+		return
+	}
+	nrns := vm.ly.Neurons
+	vm.cnt++
+	if vm.Avg {
+		for i, vi := range vm.varIdx {
+			sm := vm.sums[i]
+			for ni := range nrns {
+				sm[ni] += nrns[ni].VarByIndex(vi)
+			}
+		}
+	}
+	if vm.cnt < vm.Every {
+		return
+	}
+	row := vm.NRec
+	if vm.MaxRows > 0 {
+		row = vm.Head
+		vm.Head = (vm.Head + 1) % vm.MaxRows
+	} else {
+		vm.Table.SetNumRows(row + 1)
+	}
+	vm.Table.SetCellFloat("Cycle", row, float64(ltime.CycleTot))
+	for i, vi := range vm.varIdx {
+		tsr := vm.Table.CellTensor(vm.Vars[i], row)
+		if vm.Avg {
+			sm := vm.sums[i]
+			norm := 1 / float32(vm.cnt)
+			for ni := range nrns {
+				tsr.SetFloat1D(ni, float64(sm[ni]*norm))
+				sm[ni] = 0
+			}
+		} else {
+			for ni := range nrns {
+				tsr.SetFloat1D(ni, float64(nrns[ni].VarByIndex(vi)))
+			}
+		}
+	}
+	vm.cnt = 0
+	vm.NRec++
+}
+
+// OrderedView returns an IdxView of the table with rows in chronological
+// order, taking into account the ring buffer, and only including
+// rows that have been recorded
+func (vm *VarMonitor) OrderedView() *etable.IdxView {
+	ix := etable.NewIdxView(vm.Table)
+	if vm.MaxRows <= 0 {
+		return ix
+	}
+	n := vm.NRec
+	st := 0
+	if n >= vm.MaxRows {
+		n = vm.MaxRows
+		st = vm.Head
+	}
+	ix.Idxs = make([]int, n)
+	for i := range ix.Idxs {
+		ix.Idxs[i] = (st + i) % vm.MaxRows
+	}
+	return ix
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/emer/emergent/emer"
+)
+
+// WorkPool is a pool of persistent worker goroutines that execute a set of
+// independent jobs, with dynamic load balancing: idle workers take the next
+// job from a shared atomic counter, so faster workers end up doing more jobs.
+// Each job must write only to its own memory, so results are independent
+// of which worker runs which job, and identical to sequential execution.
+type WorkPool struct {
+	NWorkers int `desc:"number of worker goroutines"`
+
+	chans []chan *workRun
+	wg    sync.WaitGroup
+}
+
+// workRun is one Run call shared by all workers
+type workRun struct {
+	n   int64
+	ctr int64
+	fun func(ji int)
+}
+
+// Start starts given number of worker goroutines, stopping any existing ones
+func (wp *WorkPool) Start(nworkers int) {
+	wp.Stop()
+	wp.NWorkers = nworkers
+	if nworkers <= 1 {
+		return
+	}
+	wp.chans = make([]chan *workRun, nworkers)
+	for wi := range wp.chans {
+		wp.chans[wi] = make(chan *workRun)
+		go wp.worker(wp.chans[wi])
+	}
+}
+
+// Stop stops the worker goroutines
+func (wp *WorkPool) Stop() {
+	for _, ch := range wp.chans {
+		close(ch)
+	}
+	wp.chans = nil
+}
+
+// IsRunning returns true if the pool has given number of workers running
+func (wp *WorkPool) IsRunning(nworkers int) bool {
+	return wp.NWorkers == nworkers && len(wp.chans) == nworkers
+}
+
+func (wp *WorkPool) worker(ch chan *workRun) {
+	for wr := range ch {
+		for {
+			ji := atomic.AddInt64(&wr.ctr, 1) - 1
+			if ji >= wr.n {
+				break
+			}
+			wr.fun(int(ji))
+		}
+		wp.wg.Done()
+	}
+}
+
+// Run calls fun for each job index in [0, njobs), distributed across the
+// workers, and returns when all jobs are done.  Runs sequentially if
+// there is only one worker.
+func (wp *WorkPool) Run(njobs int, fun func(ji int)) {
+	if len(wp.chans) <= 1 || njobs <= 1 {
+		for ji := 0; ji < njobs; ji++ {
+			fun(ji)
+		}
+		return
+	}
+	wr := &workRun{n: int64(njobs), fun: fun}
+	wp.wg.Add(len(wp.chans))
+	for _, ch := range wp.chans {
+		ch <- wr
+	}
+	wp.wg.Wait()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  Network pool-based computation
+
+// WorkPoolParams configure the Network worker pool, which is used instead
+// of the per-layer Thread assignments when NWorkers > 1
+type WorkPoolParams struct {
+	NWorkers  int `def:"0" desc:"number of workers in the goroutine pool that partitions SendSpike, DWt and WtFmDWt by projection and neuron chunks, and GFmInc by layer -- if > 1 this is used instead of the per-layer Thread assignments -- -1 = use runtime.NumCPU()"`
+	ChunkSize int `def:"256" min:"1" desc:"number of neurons per chunk for partitioning SendSpike and WtFmDWt (receiving neurons) and DWt (sending neurons) within a projection -- chunking is fixed for a given network so results do not depend on the number of workers"`
+}
+
+func (wp *WorkPoolParams) Defaults() {
+	wp.NWorkers = 0
+	wp.ChunkSize = 256
+}
+
+func (wp *WorkPoolParams) Update() {
+}
+
+// Workers returns the effective number of workers
+func (wp *WorkPoolParams) Workers() int {
+	if wp.NWorkers < 0 {
+		return runtime.NumCPU()
+	}
+	return wp.NWorkers
+}
+
+// UsePool returns true if the worker pool should be used, and starts it if needed
+func (nt *Network) UsePool() bool {
+	nw := nt.WorkPool.Workers()
+	if nw <= 1 {
+		return false
+	}
+	if !nt.Pool.IsRunning(nw) {
+		nt.Pool.Start(nw)
+	}
+	return true
+}
+
+// chunkSize returns the effective WorkPool.ChunkSize
+func (nt *Network) chunkSize() int {
+	if nt.WorkPool.ChunkSize < 1 {
+		return 1
+	}
+	return nt.WorkPool.ChunkSize
+}
+
+// poolLayers returns the layers that are not off, split into the base *Layer
+// types, whose projection-level computation is partitioned into chunks, and
+// all other types, which may override the layer-level methods (SendSpike,
+// DWt, WtFmDWt), and are thus run as a single job each through the
+// AxonLayer interface.
+func (nt *Network) poolLayers() (base []*Layer, other []AxonLayer) {
+	for _, ly := range nt.Layers {
+		if ly.IsOff() {
+			continue
+		}
+		if bly, ok := ly.(*Layer); ok {
+			base = append(base, bly)
+		} else {
+			other = append(other, ly.(AxonLayer))
+		}
+	}
+	return
+}
+
+// activePrjns returns the projections that are not off in given list
+func activePrjns(pjs []AxonPrjn, prjns emer.Prjns) []AxonPrjn {
+	for _, p := range prjns {
+		if p.IsOff() {
+			continue
+		}
+		pjs = append(pjs, p.(AxonPrjn))
+	}
+	return pjs
+}
+
+// prjnChunk is one unit of chunked layer or projection-level work
+type prjnChunk struct {
+	ly     AxonLayer // if non-nil, call the whole-layer method
+	pj     AxonPrjn
+	stIdx  int
+	edIdx  int
+	ranged bool // if false, call the whole-projection method
+}
+
+// layerChunks adds one chunk for each of given layers to chs
+func layerChunks(chs []prjnChunk, lays []AxonLayer) []prjnChunk {
+	for _, ly := range lays {
+		chs = append(chs, prjnChunk{ly: ly})
+	}
+	return chs
+}
+
+// ChunkOK returns true if the DWt and WtFmDWt computations of this projection
+// can be partitioned into neuron chunks run in parallel, with results that are
+// bit-identical to sequential execution.  Each chunk writes only its own
+// synapses (contiguous ranges of sending neurons for DWt, and of receiving
+// neurons for WtFmDWt, with the SubMean sum accumulated over each receiver's
+// synapses in a fixed order), so this is true unless the projection draws
+// random numbers while learning (Com.PFail > 0 or Learn.WtNoise.On):
+// the draws from its Rand must then follow the sequential order of synapses.
+func (pj *Prjn) ChunkOK() bool {
+	return pj.Com.PFail == 0 && !pj.Learn.WtNoise.On
+}
+
+// SendChunkOK returns true if the SendSpike computation of this projection
+// can be partitioned into receiving neuron chunks (see SendSpikesRecvRange),
+// which requires the standard spike delivery without STP, Rel, per-synapse
+// delays, events or Com.Gather, as these update sender-side state.
+func (pj *Prjn) SendChunkOK() bool {
+	return !pj.UseEvents && pj.SynDel == nil && !pj.Com.Rel.On && !pj.Com.STP.On && !pj.GatherOK()
+}
+
+// prjnChunks adds the chunks for given projections to chs, with okfun
+// determining if a projection can be chunked, and nfun returning the
+// number of neurons to partition.
+// Only base *Prjn types are chunked -- others are run as a single job,
+// so any specialized method overrides are respected, and random numbers
+// are drawn in the same order as sequential execution, as each projection
+// has its own Rand when Network.RandSeed is set (otherwise, the order of
+// draws from the global random source across projections depends on the
+// scheduling of jobs).
+func (nt *Network) prjnChunks(chs []prjnChunk, pjs []AxonPrjn, okfun func(pj *Prjn) bool, nfun func(pj *Prjn) int) []prjnChunk {
+	csz := nt.chunkSize()
+	for _, pj := range pjs {
+		bpj, ok := pj.(*Prjn)
+		if !ok || !okfun(bpj) {
+			chs = append(chs, prjnChunk{pj: pj})
+			continue
+		}
+		n := nfun(bpj)
+		for st := 0; st < n; st += csz {
+			ed := st + csz
+			if ed > n {
+				ed = n
+			}
+			chs = append(chs, prjnChunk{pj: pj, stIdx: st, edIdx: ed, ranged: true})
+		}
+	}
+	return chs
+}
+
+// PoolSendSpike sends spikes using the worker pool.  For the base *Layer
+// types, the projections that support it (SendChunkOK) are partitioned into
+// chunks of receiving neurons, as the senders accumulate into the same
+// conductance buffers, and others are run as one job per projection.
+// Other layer types are run as one job per layer, calling their SendSpike.
+// Then GFmInc is computed with one job per layer, as multiple projections
+// accumulate into the same receiving neurons.
+func (nt *Network) PoolSendSpike(ltime *Time) {
+	base, other := nt.poolLayers()
+	chs := layerChunks(nil, other)
+	var pjs []AxonPrjn
+	for _, ly := range base {
+		pjs = activePrjns(pjs, ly.SndPrjns)
+	}
+	for _, p := range pjs {
+		pj, ok := p.(*Prjn)
+		if ok && pj.SendChunkOK() && (pj.wtScSc != pj.GScale.Scale || len(pj.WtSc) != len(pj.Syns)) {
+			pj.UpdateWtSc() // before the chunks read it
+		}
+	}
+	chs = nt.prjnChunks(chs, pjs, (*Prjn).SendChunkOK, func(pj *Prjn) int { return pj.Recv.Shape().Len() })
+	nt.Pool.Run(len(chs), func(ji int) {
+		ch := &chs[ji]
+		switch {
+		case ch.ly != nil:
+			ch.ly.SendSpike(ltime)
+		case ch.ranged:
+			ch.pj.(*Prjn).SendSpikesRecvRange(ch.stIdx, ch.edIdx)
+		default:
+			slay := ch.pj.AsAxon().Send.(AxonLayer).AsAxon()
+			for ni := range slay.Neurons {
+				nrn := &slay.Neurons[ni]
+				if nrn.IsOff() || nrn.Spike == 0 {
+					continue
+				}
+				ch.pj.SendSpike(ni)
+			}
+		}
+	})
+	nt.PoolLayFun(func(ly AxonLayer) { ly.GFmInc(ltime) })
+}
+
+// PoolLayFun calls function on each layer that is not off, using the worker pool
+func (nt *Network) PoolLayFun(fun func(ly AxonLayer)) {
+	nt.Pool.Run(len(nt.Layers), func(ji int) {
+		ly := nt.Layers[ji]
+		if ly.IsOff() {
+			return
+		}
+		fun(ly.(AxonLayer))
+	})
+}
+
+// PoolDWt computes the weight changes using the worker pool, partitioning
+// the base *Layer types by projection and sending neuron chunks, and
+// calling DWt on other layer types as one job per layer.
+func (nt *Network) PoolDWt() {
+	base, other := nt.poolLayers()
+	nt.Pool.Run(len(base), func(ji int) { base[ji].DTrgAvgFmErr() })
+	chs := layerChunks(nil, other)
+	var pjs []AxonPrjn
+	for _, ly := range base {
+		pjs = activePrjns(pjs, ly.SndPrjns)
+	}
+	chs = nt.prjnChunks(chs, pjs, (*Prjn).ChunkOK, func(pj *Prjn) int {
+		if !pj.Learn.Learning() {
+			return 0
+		}
+		return pj.Send.Shape().Len()
+	})
+	for _, ch := range chs {
+		if ch.ranged && ch.stIdx == 0 {
+			ch.pj.(*Prjn).AllocSynOpt() // before the chunks use it
+		}
+	}
+	nt.Pool.Run(len(chs), func(ji int) {
+		ch := &chs[ji]
+		switch {
+		case ch.ly != nil:
+			ch.ly.DWt()
+		case ch.ranged:
+			ch.pj.(*Prjn).DWtRange(ch.stIdx, ch.edIdx)
+		default:
+			ch.pj.DWt()
+		}
+	})
+}
+
+// PoolWtFmDWt updates the weights from delta-weight changes using the worker
+// pool, partitioning the base *Layer types by projection and receiving
+// neuron chunks, and calling WtFmDWt on other layer types as one job per layer.
+func (nt *Network) PoolWtFmDWt() {
+	base, other := nt.poolLayers()
+	nt.Pool.Run(len(base), func(ji int) { base[ji].TrgAvgFmD() })
+	chs := layerChunks(nil, other)
+	var pjs []AxonPrjn
+	for _, ly := range base {
+		pjs = activePrjns(pjs, ly.RcvPrjns)
+	}
+	chs = nt.prjnChunks(chs, pjs, (*Prjn).ChunkOK, func(pj *Prjn) int { return pj.Recv.Shape().Len() })
+	for _, ch := range chs {
+		if ch.ranged && ch.stIdx == 0 {
+			pj := ch.pj.(*Prjn)
+			pj.AllocSynOpt()
+			pj.OptStep()
+		}
+	}
+	nt.Pool.Run(len(chs), func(ji int) {
+		ch := &chs[ji]
+		switch {
+		case ch.ly != nil:
+			ch.ly.WtFmDWt()
+		case ch.ranged:
+			ch.pj.(*Prjn).WtFmDWtRange(ch.stIdx, ch.edIdx)
+		default:
+			ch.pj.WtFmDWt()
+		}
+	})
+	for _, pj := range pjs {
+		pj.AsAxon().ZeroLesioned()
+	}
+}
@@ -0,0 +1,426 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/goki/gi/gi"
+)
+
+// Binary weights format: a compact alternative to the JSON weights format
+// for large models, which is much faster to write and read.  JSON remains
+// the interchange format -- the binary format is only guaranteed to be
+// readable by the same network structure that wrote it (projections are
+// matched by sending layer name, and synapses by sending index).
+//
+// All values are little-endian.  Strings are a uint32 length followed by bytes.
+// File layout:
+//	Header:  magic "AXWB", uint32 version, uint32 number of layers
+//	Layer:   name, 5 float32 ActAvg values (ActMAvg, ActPAvg, AvgMaxGeM, AvgMaxGiM, GiMult),
+//	         uint32 number of neurons, uint8 has-units flag,
+//	         [ActAvg, TrgAvg float32 per neuron if has-units], uint32 number of prjns
+//	Prjn:    sending layer name, float32 GScale, uint32 number of recv neurons,
+//	         then per recv neuron: uint32 N, N uint32 Si, N float32 Wt, N float32 SWt
+
+// WtsBinMagic is the magic header identifying binary weights files
+const WtsBinMagic = "AXWB"
+
+// WtsBinVersion is the current version of the binary weights format
+const WtsBinVersion = 1
+
+var wtsBinOrder = binary.LittleEndian
+
+func writeBinString(w io.Writer, s string) error {
+	if err := binary.Write(w, wtsBinOrder, uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readBinString(r io.Reader) (string, error) {
+	var n uint32
+	if err := binary.Read(r, wtsBinOrder, &n); err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// SaveWtsBinary saves network weights (and any other state that adapts with learning)
+// to a compact binary file.  If filename has .gz extension, then file is gzip compressed.
+func (nt *Network) SaveWtsBinary(filename gi.FileName) error {
+	fp, err := os.Create(string(filename))
+	defer fp.Close()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	ext := filepath.Ext(string(filename))
+	if ext == ".gz" {
+		gzr := gzip.NewWriter(fp)
+		err = nt.WriteWtsBinary(gzr)
+		gzr.Close()
+	} else {
+		bw := bufio.NewWriter(fp)
+		err = nt.WriteWtsBinary(bw)
+		bw.Flush()
+	}
+	return err
+}
+
+// OpenWtsBinary opens network weights (and any other state that adapts with learning)
+// from a compact binary file.  If filename has .gz extension, then file is gzip uncompressed.
+func (nt *Network) OpenWtsBinary(filename gi.FileName) error {
+	fp, err := os.Open(string(filename))
+	defer fp.Close()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	ext := filepath.Ext(string(filename))
+	if ext == ".gz" {
+		gzr, err := gzip.NewReader(fp)
+		defer gzr.Close()
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		err = nt.ReadWtsBinary(gzr)
+	} else {
+		err = nt.ReadWtsBinary(bufio.NewReader(fp))
+	}
+	return err
+}
+
+// WriteWtsBinary writes the network weights in the compact binary format,
+// streaming one layer at a time.
+func (nt *Network) WriteWtsBinary(w io.Writer) error {
+	onls := make([]*Layer, 0, len(nt.Layers))
+	for _, ly := range nt.Layers {
+		if !ly.IsOff() {
+			onls = append(onls, ly.(AxonLayer).AsAxon())
+		}
+	}
+	if _, err := io.WriteString(w, WtsBinMagic); err != nil {
+		return err
+	}
+	hdr := []uint32{WtsBinVersion, uint32(len(onls))}
+	if err := binary.Write(w, wtsBinOrder, hdr); err != nil {
+		return err
+	}
+	for _, ly := range onls {
+		if err := ly.WriteWtsBinary(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadWtsBinary reads network weights in the compact binary format,
+// streaming one layer at a time.  Layers are matched by name -- those
+// not found in the network are an error.
+func (nt *Network) ReadWtsBinary(r io.Reader) error {
+	mg := make([]byte, len(WtsBinMagic))
+	if _, err := io.ReadFull(r, mg); err != nil {
+		return err
+	}
+	if string(mg) != WtsBinMagic {
+		err := errors.New("ReadWtsBinary: not an axon binary weights file")
+		log.Println(err)
+		return err
+	}
+	hdr := make([]uint32, 2)
+	if err := binary.Read(r, wtsBinOrder, hdr); err != nil {
+		return err
+	}
+	if hdr[0] != WtsBinVersion {
+		err := fmt.Errorf("ReadWtsBinary: unsupported version: %d", hdr[0])
+		log.Println(err)
+		return err
+	}
+	for li := 0; li < int(hdr[1]); li++ {
+		if err := nt.readLayWtsBinary(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLayWtsBinary reads one layer from the binary stream, looking it up by name
+func (nt *Network) readLayWtsBinary(r io.Reader) error {
+	nm, err := readBinString(r)
+	if err != nil {
+		return err
+	}
+	li, err := nt.LayerByNameTry(nm)
+	if err != nil {
+		log.Println(err)
+		return err // cannot skip over the data without a layer to read it
+	}
+	return li.(AxonLayer).AsAxon().readWtsBinaryBody(r)
+}
+
+// WriteWtsBinary writes the weights from this layer from the receiver-side perspective
+// in the compact binary format, including all receiving projections.
+func (ly *Layer) WriteWtsBinary(w io.Writer) error {
+	if err := writeBinString(w, ly.Nm); err != nil {
+		return err
+	}
+	aa := []float32{ly.ActAvg.ActMAvg, ly.ActAvg.ActPAvg, ly.ActAvg.AvgMaxGeM, ly.ActAvg.AvgMaxGiM, ly.ActAvg.GiMult}
+	if err := binary.Write(w, wtsBinOrder, aa); err != nil {
+		return err
+	}
+	nn := len(ly.Neurons)
+	if err := binary.Write(w, wtsBinOrder, uint32(nn)); err != nil {
+		return err
+	}
+	hasUnits := uint8(0)
+	if ly.IsLearnTrgAvg() {
+		hasUnits = 1
+	}
+	if err := binary.Write(w, wtsBinOrder, hasUnits); err != nil {
+		return err
+	}
+	if hasUnits == 1 {
+		vals := make([]float32, 2*nn)
+		for ni := range ly.Neurons {
+			nrn := &ly.Neurons[ni]
+			vals[ni] = nrn.ActAvg
+			vals[nn+ni] = nrn.TrgAvg
+		}
+		if err := binary.Write(w, wtsBinOrder, vals); err != nil {
+			return err
+		}
+	}
+	onps := make([]*Prjn, 0, len(ly.RcvPrjns))
+	for _, pj := range ly.RcvPrjns {
+		if !pj.IsOff() {
+			onps = append(onps, pj.(AxonPrjn).AsAxon())
+		}
+	}
+	if err := binary.Write(w, wtsBinOrder, uint32(len(onps))); err != nil {
+		return err
+	}
+	for _, pj := range onps {
+		if err := pj.WriteWtsBinary(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadWtsBinary reads the weights for this layer in the compact binary format,
+// as written by Layer.WriteWtsBinary (i.e., including the layer name).
+func (ly *Layer) ReadWtsBinary(r io.Reader) error {
+	nm, err := readBinString(r)
+	if err != nil {
+		return err
+	}
+	if nm != ly.Nm {
+		err = fmt.Errorf("ReadWtsBinary: Layer %v: weights are for layer named: %v", ly.Nm, nm)
+		log.Println(err)
+		return err
+	}
+	return ly.readWtsBinaryBody(r)
+}
+
+// readWtsBinaryBody reads everything after the layer name
+func (ly *Layer) readWtsBinaryBody(r io.Reader) error {
+	aa := make([]float32, 5)
+	if err := binary.Read(r, wtsBinOrder, aa); err != nil {
+		return err
+	}
+	ly.ActAvg.ActMAvg = aa[0]
+	ly.ActAvg.ActPAvg = aa[1]
+	ly.ActAvg.AvgMaxGeM = aa[2]
+	ly.ActAvg.AvgMaxGiM = aa[3]
+	ly.ActAvg.GiMult = aa[4]
+	var nn uint32
+	if err := binary.Read(r, wtsBinOrder, &nn); err != nil {
+		return err
+	}
+	var hasUnits uint8
+	if err := binary.Read(r, wtsBinOrder, &hasUnits); err != nil {
+		return err
+	}
+	if hasUnits == 1 {
+		vals := make([]float32, 2*nn)
+		if err := binary.Read(r, wtsBinOrder, vals); err != nil {
+			return err
+		}
+		for ni := 0; ni < int(nn) && ni < len(ly.Neurons); ni++ {
+			nrn := &ly.Neurons[ni]
+			nrn.ActAvg = vals[ni]
+			nrn.TrgAvg = vals[int(nn)+ni]
+		}
+	}
+	var np uint32
+	if err := binary.Read(r, wtsBinOrder, &np); err != nil {
+		return err
+	}
+	rpjs := ly.RecvPrjns()
+	var err error
+	for pi := 0; pi < int(np); pi++ {
+		from, er := readBinString(r)
+		if er != nil {
+			return er
+		}
+		var pj *Prjn
+		if int(np) == len(*rpjs) { // this is essential if multiple prjns from same layer
+			pj = (*rpjs)[pi].(AxonPrjn).AsAxon()
+		} else if epj := rpjs.SendName(from); epj != nil {
+			pj = epj.(AxonPrjn).AsAxon()
+		}
+		if pj == nil {
+			err = fmt.Errorf("ReadWtsBinary: Layer %v: could not find projection from: %v", ly.Nm, from)
+			log.Println(err)
+			return err // cannot skip over the data without a prjn to read it
+		}
+		if er := pj.readWtsBinaryBody(r); er != nil {
+			return er
+		}
+	}
+	ly.SynScale() // update AvgPct based on loaded ActAvg values
+	return err
+}
+
+// WriteWtsBinary writes the weights from this projection from the receiver-side perspective
+// in the compact binary format.
+func (pj *Prjn) WriteWtsBinary(w io.Writer) error {
+	slay := pj.Send.(AxonLayer).AsAxon()
+	rlay := pj.Recv.(AxonLayer).AsAxon()
+	if err := writeBinString(w, slay.Name()); err != nil {
+		return err
+	}
+	nr := len(rlay.Neurons)
+	hdr := []float32{pj.GScale.Scale}
+	if err := binary.Write(w, wtsBinOrder, hdr); err != nil {
+		return err
+	}
+	if err := binary.Write(w, wtsBinOrder, uint32(nr)); err != nil {
+		return err
+	}
+	var sis []uint32
+	var wts []float32
+	for ri := 0; ri < nr; ri++ {
+		nc, st := pj.RConNSt(ri)
+		if err := binary.Write(w, wtsBinOrder, uint32(nc)); err != nil {
+			return err
+		}
+		sis = sis[:0]
+		wts = wts[:0]
+		for ci := 0; ci < nc; ci++ {
+			sis = append(sis, uint32(pj.RConIdx[st+ci]))
+		}
+		for ci := 0; ci < nc; ci++ {
+			wts = append(wts, pj.Syns[pj.RSynIdx[st+ci]].Wt)
+		}
+		for ci := 0; ci < nc; ci++ {
+			wts = append(wts, pj.Syns[pj.RSynIdx[st+ci]].SWt)
+		}
+		if err := binary.Write(w, wtsBinOrder, sis); err != nil {
+			return err
+		}
+		if err := binary.Write(w, wtsBinOrder, wts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadWtsBinary reads the weights for this projection in the compact binary format,
+// as written by Prjn.WriteWtsBinary (i.e., including the sending layer name).
+func (pj *Prjn) ReadWtsBinary(r io.Reader) error {
+	from, err := readBinString(r)
+	if err != nil {
+		return err
+	}
+	if from != pj.Send.Name() {
+		err = fmt.Errorf("ReadWtsBinary: Prjn %v: weights are from layer named: %v", pj.Name(), from)
+		log.Println(err)
+		return err
+	}
+	return pj.readWtsBinaryBody(r)
+}
+
+// readWtsBinaryBody reads everything after the sending layer name.
+// When the stored connectivity for a receiving neuron matches the current
+// structure, synapses are set directly; otherwise it falls back on SetSynVal.
+func (pj *Prjn) readWtsBinaryBody(r io.Reader) error {
+	hdr := make([]float32, 1)
+	if err := binary.Read(r, wtsBinOrder, hdr); err != nil {
+		return err
+	}
+	pj.GScale.Scale = hdr[0]
+	var nr uint32
+	if err := binary.Read(r, wtsBinOrder, &nr); err != nil {
+		return err
+	}
+	rn := pj.Recv.Shape().Len()
+	var err error
+	var sis []uint32
+	var wts []float32
+	for ri := 0; ri < int(nr); ri++ {
+		var nc uint32
+		if er := binary.Read(r, wtsBinOrder, &nc); er != nil {
+			return er
+		}
+		n := int(nc)
+		if cap(sis) < n {
+			sis = make([]uint32, n)
+			wts = make([]float32, 2*n)
+		}
+		sis = sis[:n]
+		wts = wts[:2*n]
+		if er := binary.Read(r, wtsBinOrder, sis); er != nil {
+			return er
+		}
+		if er := binary.Read(r, wtsBinOrder, wts); er != nil {
+			return er
+		}
+		if ri >= rn {
+			continue
+		}
+		rnc, st := pj.RConNSt(ri)
+		match := rnc == n
+		for ci := 0; match && ci < n; ci++ {
+			if uint32(pj.RConIdx[st+ci]) != sis[ci] {
+				match = false
+			}
+		}
+		if match {
+			for ci := 0; ci < n; ci++ {
+				sy := &pj.Syns[pj.RSynIdx[st+ci]]
+				sy.Wt = wts[ci]
+				sy.SWt = wts[n+ci]
+				sy.LWt = pj.SWt.LWtFmWts(sy.Wt, sy.SWt)
+			}
+			pj.StaleWtSc()
+			continue
+		}
+		for ci := 0; ci < n; ci++ {
+			if er := pj.SetSynVal("SWt", int(sis[ci]), ri, wts[n+ci]); er != nil {
+				err = er
+			}
+			if er := pj.SetSynVal("Wt", int(sis[ci]), ri, wts[ci]); er != nil {
+				err = er
+			}
+		}
+	}
+	return err
+}
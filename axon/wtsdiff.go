@@ -0,0 +1,227 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/emer/emergent/weights"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+)
+
+// OpenWtsFile opens a JSON-formatted weights file (as saved by SaveWtsJSON)
+// into a weights.Network structure, without applying it to any network.
+// If filename has .gz extension, then file is gzip uncompressed.
+func OpenWtsFile(filename gi.FileName) (*weights.Network, error) {
+	fp, err := os.Open(string(filename))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer fp.Close()
+	ext := filepath.Ext(string(filename))
+	if ext == ".gz" {
+		gzr, err := gzip.NewReader(fp)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		defer gzr.Close()
+		return weights.NetReadJSON(gzr)
+	}
+	return weights.NetReadJSON(bufio.NewReader(fp))
+}
+
+// WtsDiff compares the weights in two JSON-formatted weights files, e.g.,
+// to check reproducibility or to measure learning drift between checkpoints,
+// returning a table with one row of summary statistics per projection
+// (see WtsDiffNets).  If detail is non-nil, it is filled with one row per
+// synapse that differs between the two files.
+func WtsDiff(fileA, fileB gi.FileName, detail *etable.Table) (*etable.Table, error) {
+	na, err := OpenWtsFile(fileA)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := OpenWtsFile(fileB)
+	if err != nil {
+		return nil, err
+	}
+	dt := &etable.Table{}
+	err = WtsDiffNets(dt, na, nb, detail)
+	return dt, err
+}
+
+// ConfigWtsDiffTable configures given table for the per-projection
+// summary of WtsDiffNets
+func ConfigWtsDiffTable(dt *etable.Table) {
+	dt.SetMetaData("name", "WtsDiff")
+	dt.SetMetaData("desc", "per-projection differences between two sets of weights")
+	sch := etable.Schema{
+		{"Layer", etensor.STRING, nil, nil},
+		{"From", etensor.STRING, nil, nil},
+		{"N", etensor.INT64, nil, nil},
+		{"NMiss", etensor.INT64, nil, nil},
+		{"L2", etensor.FLOAT64, nil, nil},
+		{"Cos", etensor.FLOAT64, nil, nil},
+		{"MaxAbs", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// ConfigWtsDiffDetailTable configures given table for the per-synapse
+// detail report of WtsDiffNets
+func ConfigWtsDiffDetailTable(dt *etable.Table) {
+	dt.SetMetaData("name", "WtsDiffDetail")
+	dt.SetMetaData("desc", "per-synapse differences between two sets of weights")
+	sch := etable.Schema{
+		{"Layer", etensor.STRING, nil, nil},
+		{"From", etensor.STRING, nil, nil},
+		{"Ri", etensor.INT64, nil, nil},
+		{"Si", etensor.INT64, nil, nil},
+		{"WtA", etensor.FLOAT64, nil, nil},
+		{"WtB", etensor.FLOAT64, nil, nil},
+		{"Diff", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// WtsDiffNets compares the weights of two decoded weights networks,
+// writing one row per projection of network a into table dt:
+// N = number of synapses compared, NMiss = number of synapses (and units)
+// in a that are not present in b, L2 = Euclidean distance between the
+// weight vectors, Cos = cosine similarity between them, and MaxAbs = the
+// maximum absolute weight difference.  Synapses are matched by layer name,
+// sending layer name, and receiving and sending unit indexes.
+// If detail is non-nil, it gets one row for each synapse whose weights
+// differ, including those missing in b (with WtB = NaN).
+// Returns an error for layers or projections of a that are not in b.
+func WtsDiffNets(dt *etable.Table, a, b *weights.Network, detail *etable.Table) error {
+	ConfigWtsDiffTable(dt)
+	if detail != nil {
+		ConfigWtsDiffDetailTable(detail)
+	}
+	var err error
+	for li := range a.Layers {
+		la := &a.Layers[li]
+		lb := wtsDiffLayer(b, la.Layer)
+		for pi := range la.Prjns {
+			pa := &la.Prjns[pi]
+			var pb *weights.Prjn
+			if lb != nil {
+				pb = wtsDiffPrjn(lb, pa.From)
+			}
+			if pb == nil {
+				err = fmt.Errorf("WtsDiff: projection from %v to %v not found in %v", pa.From, la.Layer, b.Network)
+				log.Println(err)
+			}
+			wtsDiffPrjnStats(dt, la.Layer, pa, pb, detail)
+		}
+	}
+	return err
+}
+
+// wtsDiffLayer returns the layer of given name in network, or nil
+func wtsDiffLayer(nw *weights.Network, name string) *weights.Layer {
+	for li := range nw.Layers {
+		if nw.Layers[li].Layer == name {
+			return &nw.Layers[li]
+		}
+	}
+	return nil
+}
+
+// wtsDiffPrjn returns the projection from given sending layer, or nil
+func wtsDiffPrjn(lw *weights.Layer, from string) *weights.Prjn {
+	for pi := range lw.Prjns {
+		if lw.Prjns[pi].From == from {
+			return &lw.Prjns[pi]
+		}
+	}
+	return nil
+}
+
+// wtsDiffPrjnStats computes the diff stats for one projection, adding a row to dt
+// and any differing synapses to detail if non-nil.  pb may be nil.
+func wtsDiffPrjnStats(dt *etable.Table, lay string, pa, pb *weights.Prjn, detail *etable.Table) {
+	rbs := make(map[int]*weights.Recv)
+	if pb != nil {
+		for ri := range pb.Rs {
+			rbs[pb.Rs[ri].Ri] = &pb.Rs[ri]
+		}
+	}
+	var n, nmiss int
+	var ss, aa, bb, ab, max float64
+	for ri := range pa.Rs {
+		ra := &pa.Rs[ri]
+		rb := rbs[ra.Ri]
+		var sbs map[int]float32
+		if rb != nil {
+			sbs = make(map[int]float32, len(rb.Si))
+			for si := range rb.Si {
+				sbs[rb.Si[si]] = rb.Wt[si]
+			}
+		}
+		for si := range ra.Si {
+			wa := float64(ra.Wt[si])
+			wbf, ok := sbs[ra.Si[si]]
+			if !ok {
+				nmiss++
+				if detail != nil {
+					wtsDiffDetailRow(detail, lay, pa.From, ra.Ri, ra.Si[si], wa, math.NaN())
+				}
+				continue
+			}
+			wb := float64(wbf)
+			d := wa - wb
+			n++
+			ss += d * d
+			aa += wa * wa
+			bb += wb * wb
+			ab += wa * wb
+			if math.Abs(d) > max {
+				max = math.Abs(d)
+			}
+			if detail != nil && d != 0 {
+				wtsDiffDetailRow(detail, lay, pa.From, ra.Ri, ra.Si[si], wa, wb)
+			}
+		}
+	}
+	cos := 0.0
+	if aa > 0 && bb > 0 {
+		cos = ab / math.Sqrt(aa*bb)
+	} else if aa == 0 && bb == 0 {
+		cos = 1
+	}
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+	dt.SetCellString("Layer", row, lay)
+	dt.SetCellString("From", row, pa.From)
+	dt.SetCellFloat("N", row, float64(n))
+	dt.SetCellFloat("NMiss", row, float64(nmiss))
+	dt.SetCellFloat("L2", row, math.Sqrt(ss))
+	dt.SetCellFloat("Cos", row, cos)
+	dt.SetCellFloat("MaxAbs", row, max)
+}
+
+// wtsDiffDetailRow adds one synapse row to the detail table
+func wtsDiffDetailRow(dt *etable.Table, lay, from string, ri, si int, wa, wb float64) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+	dt.SetCellString("Layer", row, lay)
+	dt.SetCellString("From", row, from)
+	dt.SetCellFloat("Ri", row, float64(ri))
+	dt.SetCellFloat("Si", row, float64(si))
+	dt.SetCellFloat("WtA", row, wa)
+	dt.SetCellFloat("WtB", row, wb)
+	dt.SetCellFloat("Diff", row, wa-wb)
+}
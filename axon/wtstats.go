@@ -0,0 +1,168 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package axon
+
+import (
+	"log"
+	"math"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+	"github.com/goki/mat32"
+)
+
+// WtSatThr is the distance from the 0 or 1 LWt bounds within which
+// a synapse is counted as saturated in WtStats
+var WtSatThr = float32(0.05)
+
+// WtStats are summary statistics of the weights in a projection, for
+// diagnosing weight pathologies during training -- see Prjn.WtStats.
+// Lesioned synapses are excluded.
+type WtStats struct {
+	N       int       `inactive:"+" desc:"number of synapses included"`
+	Avg     float32   `inactive:"+" desc:"average Wt value"`
+	SD      float32   `inactive:"+" desc:"standard deviation of Wt values"`
+	Min     float32   `inactive:"+" desc:"minimum Wt value"`
+	Max     float32   `inactive:"+" desc:"maximum Wt value"`
+	DWtAbs  float32   `inactive:"+" desc:"mean absolute value of DWt -- only non-zero between DWt and WtFmDWt, which resets DWt"`
+	DSWtAbs float32   `inactive:"+" desc:"mean absolute value of DSWt, the DWt accumulated since the last SWt update"`
+	SatPct  float32   `inactive:"+" desc:"proportion of synapses with LWt within WtSatThr of the 0 or 1 bounds, where the sigmoid saturates and learning is ineffective"`
+	SWtDiv  float32   `inactive:"+" desc:"mean absolute divergence of Wt from SWt, i.e., how far the learned LWt component has moved the effective weight away from the structural SWt"`
+	HistMin float32   `inactive:"+" desc:"minimum of the histogram range"`
+	HistMax float32   `inactive:"+" desc:"maximum of the histogram range"`
+	Hist    []float32 `inactive:"+" desc:"histogram of Wt values, as proportion of synapses in each of equally-spaced bins from HistMin to HistMax -- values outside the range go into the end bins"`
+}
+
+// WtStats returns summary statistics of the weights in this projection,
+// with a histogram of Wt values with nbins bins ranging from 0 to the
+// maximum possible Wt of 2 * SWt.Limit.Max.
+func (pj *Prjn) WtStats(nbins int) WtStats {
+	ws := WtStats{HistMax: 2 * pj.SWt.Limit.Max}
+	if nbins < 1 {
+		nbins = 1
+	}
+	ws.Hist = make([]float32, nbins)
+	bsz := (ws.HistMax - ws.HistMin) / float32(nbins)
+	ws.Min = math.MaxFloat32
+	ws.Max = -math.MaxFloat32
+	var sum, ssq, dsum, dssum, div float32
+	nsat := 0
+	li := 0
+	for si := range pj.Syns {
+		if li < len(pj.LesSyns) && int(pj.LesSyns[li]) == si {
+			li++
+			continue
+		}
+		sy := &pj.Syns[si]
+		ws.N++
+		sum += sy.Wt
+		ssq += sy.Wt * sy.Wt
+		ws.Min = mat32.Min(ws.Min, sy.Wt)
+		ws.Max = mat32.Max(ws.Max, sy.Wt)
+		dsum += mat32.Abs(sy.DWt)
+		dssum += mat32.Abs(sy.DSWt)
+		div += mat32.Abs(sy.Wt - sy.SWt)
+		if sy.LWt < WtSatThr || sy.LWt > 1-WtSatThr {
+			nsat++
+		}
+		bi := int((sy.Wt - ws.HistMin) / bsz)
+		if bi < 0 {
+			bi = 0
+		} else if bi >= nbins {
+			bi = nbins - 1
+		}
+		ws.Hist[bi]++
+	}
+	if ws.N == 0 {
+		ws.Min = 0
+		ws.Max = 0
+		return ws
+	}
+	fn := float32(ws.N)
+	ws.Avg = sum / fn
+	vr := ssq/fn - ws.Avg*ws.Avg
+	if vr < 0 {
+		vr = 0
+	}
+	ws.SD = mat32.Sqrt(vr)
+	ws.DWtAbs = dsum / fn
+	ws.DSWtAbs = dssum / fn
+	ws.SWtDiv = div / fn
+	ws.SatPct = float32(nsat) / fn
+	for i := range ws.Hist {
+		ws.Hist[i] /= fn
+	}
+	return ws
+}
+
+// WtStatsTable returns an etable.Table with the WtStats for each
+// projection in the network (one row per projection), with given number of
+// histogram bins.  If dt is non-nil, it is reconfigured and reused.
+func (nt *Network) WtStatsTable(dt *etable.Table, nbins int) *etable.Table {
+	if dt == nil {
+		dt = &etable.Table{}
+	}
+	if nbins < 1 {
+		nbins = 1
+	}
+	sch := etable.Schema{
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Prjn", etensor.STRING, nil, nil},
+		{"N", etensor.INT64, nil, nil},
+		{"Avg", etensor.FLOAT32, nil, nil},
+		{"SD", etensor.FLOAT32, nil, nil},
+		{"Min", etensor.FLOAT32, nil, nil},
+		{"Max", etensor.FLOAT32, nil, nil},
+		{"DWtAbs", etensor.FLOAT32, nil, nil},
+		{"DSWtAbs", etensor.FLOAT32, nil, nil},
+		{"SatPct", etensor.FLOAT32, nil, nil},
+		{"SWtDiv", etensor.FLOAT32, nil, nil},
+		{"Hist", etensor.FLOAT32, []int{nbins}, []string{"Bin"}},
+	}
+	dt.SetMetaData("name", nt.Nm+"WtStats")
+	dt.SetFromSchema(sch, 0)
+	for _, l := range nt.Layers {
+		if l.IsOff() {
+			continue
+		}
+		for _, p := range *l.RecvPrjns() {
+			if p.IsOff() {
+				continue
+			}
+			pj := p.(AxonPrjn).AsAxon()
+			ws := pj.WtStats(nbins)
+			row := dt.Rows
+			dt.SetNumRows(row + 1)
+			dt.SetCellFloat("Epoch", row, float64(nt.Epoch))
+			dt.SetCellString("Prjn", row, pj.Name())
+			dt.SetCellFloat("N", row, float64(ws.N))
+			dt.SetCellFloat("Avg", row, float64(ws.Avg))
+			dt.SetCellFloat("SD", row, float64(ws.SD))
+			dt.SetCellFloat("Min", row, float64(ws.Min))
+			dt.SetCellFloat("Max", row, float64(ws.Max))
+			dt.SetCellFloat("DWtAbs", row, float64(ws.DWtAbs))
+			dt.SetCellFloat("DSWtAbs", row, float64(ws.DSWtAbs))
+			dt.SetCellFloat("SatPct", row, float64(ws.SatPct))
+			dt.SetCellFloat("SWtDiv", row, float64(ws.SWtDiv))
+			tsr := dt.CellTensor("Hist", row)
+			for i, h := range ws.Hist {
+				tsr.SetFloat1D(i, float64(h))
+			}
+		}
+	}
+	return dt
+}
+
+// SaveWtStatsCSV saves the WtStatsTable for the network to given
+// file name in CSV format, with given number of histogram bins
+func (nt *Network) SaveWtStatsCSV(filename string, nbins int) error {
+	dt := nt.WtStatsTable(nil, nbins)
+	err := dt.SaveCSV(gi.FileName(filename), etable.Comma, etable.Headers)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
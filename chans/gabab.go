@@ -18,6 +18,10 @@ type GABABParams struct {
 	GiSpike  float32 `def:"10" desc:"multiplier for converting Gi to equivalent GABA spikes"`
 	MaxTime  float32 `inactive:"+" desc:"time offset when peak conductance occurs, in msec, computed from RiseTau and DecayTau"`
 	TauFact  float32 `view:"-" desc:"time constant factor used in integration: (Decay / Rise) ^ (Rise / (Decay - Rise))"`
+
+	Erev   float32 `def:"-90" desc:"reversal potential of the GIRK potassium current in biological mV, below which the voltage dependence is clamped"`
+	VOff   float32 `def:"10" desc:"offset in biological mV above Erev of the midpoint of the inward rectification voltage dependence"`
+	VSlope float32 `def:"0.1" min:"0" desc:"slope of the inward rectification voltage dependence, per biological mV -- higher = sharper closing of the channels as the neuron depolarizes"`
 }
 
 func (gp *GABABParams) Defaults() {
@@ -26,6 +30,9 @@ func (gp *GABABParams) Defaults() {
 	gp.Gbar = 0.2
 	gp.Gbase = 0.2
 	gp.GiSpike = 10
+	gp.Erev = -90
+	gp.VOff = 10
+	gp.VSlope = 0.1
 	gp.Update()
 }
 
@@ -37,10 +44,10 @@ func (gp *GABABParams) Update() {
 // GFmV returns the GABA-B conductance as a function of normalized membrane potential
 func (gp *GABABParams) GFmV(v float32) float32 {
 	vbio := VToBio(v)
-	if vbio < -90 {
-		vbio = -90
+	if vbio < gp.Erev {
+		vbio = gp.Erev
 	}
-	return 1.0 / (1.0 + mat32.FastExp(0.1*((vbio+90)+10)))
+	return 1.0 / (1.0 + mat32.FastExp(gp.VSlope*((vbio-gp.Erev)+gp.VOff)))
 }
 
 // GFmS returns the GABA-B conductance as a function of GABA spiking rate,
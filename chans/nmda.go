@@ -12,15 +12,23 @@ type NMDAParams struct {
 	GeTot float32 `desc:"how much of the NMDA is driven by total Ge synaptic input, as opposed to from projections specifically marked as NMDA-communicating type, e.g., for active maintenance, in NMDASyn"`
 	Tau   float32 `def:"100" desc:"decay time constant for NMDA channel activation as a function of mactivation -- rise time is 2 msec and not worth extra effort for biexponential"`
 	Gbar  float32 `def:"0,0.15" desc:"strength of NMDA current"`
+
+	MgC    float32 `def:"1" min:"0" desc:"extracellular magnesium ion concentration in mM, which determines the strength of the voltage-dependent Mg+ block -- 1 = standard Brunel & Wang (2001) value -- lower values remove the block, e.g., in Mg-free slice preparations"`
+	VSlope float32 `def:"0.062" min:"0" desc:"slope of the exponential voltage dependence of the Mg+ block, per biological mV -- higher = sharper transition from blocked to unblocked as the neuron depolarizes"`
+	MgFact float32 `view:"-" json:"-" xml:"-" desc:"MgC / 3.57 Mg+ block factor"`
 }
 
 func (np *NMDAParams) Defaults() {
 	np.GeTot = 1
 	np.Tau = 100
 	np.Gbar = 0.15
+	np.MgC = 1
+	np.VSlope = 0.062
+	np.Update()
 }
 
 func (np *NMDAParams) Update() {
+	np.MgFact = 0.28 * np.MgC // 0.28 = 1 / 3.57
 }
 
 // GFmV returns the NMDA conductance as a function of normalized membrane potential
@@ -29,7 +37,7 @@ func (np *NMDAParams) GFmV(v float32) float32 {
 	if vbio > 0 { // critical to not go past 0
 		vbio = 0
 	}
-	return 1.0 / (1.0 + 0.28*mat32.FastExp(-0.062*vbio))
+	return 1.0 / (1.0 + np.MgFact*mat32.FastExp(-np.VSlope*vbio))
 }
 
 // NMDA returns the updated NMDA activation from current NMDA, GeRaw, and NMDASyn input
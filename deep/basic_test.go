@@ -567,3 +567,282 @@ func TestInhibAct(t *testing.T) {
 		}
 	}
 }
+
+func TestAddDeep(t *testing.T) {
+	net := NewNetwork("DeepNet")
+	super, ct, trc := net.AddDeep2D("Hid", 4, 4)
+	if trc.Driver != "Hid" {
+		t.Errorf("AddDeep2D: TRC Driver: %v\n", trc.Driver)
+	}
+	if n := len(*super.RecvPrjns()); n != 1 {
+		t.Errorf("AddDeep2D: Super recv prjns: %d != 1\n", n)
+	}
+	if n := len(*ct.RecvPrjns()); n != 2 {
+		t.Errorf("AddDeep2D: CT recv prjns: %d != 2\n", n)
+	}
+	if n := len(*trc.RecvPrjns()); n != 1 {
+		t.Errorf("AddDeep2D: TRC recv prjns: %d != 1\n", n)
+	}
+	_, lct, ltrc, trn := net.AddDeepLoop4D("Loop", 2, 2, 2, 2, nil, true)
+	if trn == nil || trn.Name() != "LoopPTRN" {
+		t.Errorf("AddDeepLoop4D: TRN not added\n")
+	}
+	if n := len(*lct.RecvPrjns()); n != 3 {
+		t.Errorf("AddDeepLoop4D: CT recv prjns: %d != 3\n", n)
+	}
+	if pj, err := ltrc.RecvPrjns().SendNameTry("LoopPTRN"); err != nil || pj.Type() != emer.Inhib {
+		t.Errorf("AddDeepLoop4D: TRN -> TRC not Inhib: %v\n", err)
+	}
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTRCDrvPool(t *testing.T) {
+	net := NewNetwork("PoolNet")
+	drv := net.AddLayer4D("Drv", 2, 2, 2, 2, emer.Hidden).(*axon.Layer)
+	trc := net.AddTRCLayer2D("DrvP", 2, 2)
+	trc.Driver = "Drv"
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	for ni := range drv.Neurons {
+		if y, x := Grid2DIdx(drv, ni); Grid2DNeurIdx(drv, y, x) != ni {
+			t.Errorf("Grid2DIdx: neuron %d -> %d, %d -> %d\n", ni, y, x, Grid2DNeurIdx(drv, y, x))
+		}
+		drv.Neurons[ni].Act = float32(ni) / 16
+	}
+	trc.TRC.DrvPool = true
+	for tni := range trc.Neurons {
+		// each TRC unit corresponds to one 2x2 driver pool, max = last unit in pool
+		if act := trc.PoolDriveAct(tni, drv, nil, false); act != float32(tni*4+3)/16 {
+			t.Errorf("PoolDriveAct: unit %d: %v\n", tni, act)
+		}
+	}
+}
+
+func TestBurstSched(t *testing.T) {
+	bs := BurstSchedParams{On: true, Start: 25, Dur: 25, Period: 50} // beta
+	nact, nend := 0, 0
+	for cyc := 0; cyc < 200; cyc++ {
+		if bs.Active(cyc) {
+			nact++
+		}
+		if bs.End(cyc) {
+			nend++
+			if cyc%50 != 49 {
+				t.Errorf("BurstSched: End at cycle: %d\n", cyc)
+			}
+		}
+	}
+	if nact != 100 || nend != 4 {
+		t.Errorf("BurstSched: active cycles: %d != 100, ends: %d != 4\n", nact, nend)
+	}
+}
+
+func TestBurstAdapt(t *testing.T) {
+	bp := BurstParams{}
+	bp.Defaults()
+	mult := float32(1)
+	for i := 0; i < 10; i++ {
+		bp.AdaptMult(&mult, 0.5) // too much bursting: raise thresholds
+	}
+	if mult <= 1 || mult > bp.MaxMult {
+		t.Errorf("BurstAdapt: mult after too much bursting: %v\n", mult)
+	}
+	if thr := bp.Thr(1, 0, mult); thr <= bp.Thr(1, 0, 1) {
+		t.Errorf("BurstAdapt: threshold did not increase: %v\n", thr)
+	}
+	for i := 0; i < 200; i++ {
+		bp.AdaptMult(&mult, 0) // no bursting: lower thresholds
+	}
+	if mult != 1/bp.MaxMult {
+		t.Errorf("BurstAdapt: mult after no bursting: %v != %v\n", mult, 1/bp.MaxMult)
+	}
+}
+
+func TestPredErr(t *testing.T) {
+	net := NewNetwork("PredErrNet")
+	_, _, trc := net.AddDeep2D("Hid", 2, 2)
+	pe := net.AddPredErrLayer("HidErr", trc, true)
+	if shp := pe.Shape().Shp; len(shp) != 4 || shp[0] != 2 || shp[2] != 2 {
+		t.Errorf("AddPredErrLayer: rect shape: %v\n", shp)
+	}
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	pp := &pe.PredErr
+	if d := pp.Drive(0.5, true); d != 0.5 {
+		t.Errorf("Drive: rect pos: %v != 0.5\n", d)
+	}
+	if d := pp.Drive(0.5, false); d != 0 {
+		t.Errorf("Drive: rect neg: %v != 0\n", d)
+	}
+	pp.Rect = false
+	if d := pp.Drive(-1, true); d != 0 {
+		t.Errorf("Drive: signed -1: %v != 0\n", d)
+	}
+	if d := pp.Drive(0.01, true); d != pp.Base {
+		t.Errorf("Drive: signed below Thr: %v != %v\n", d, pp.Base)
+	}
+}
+
+func TestPredStats(t *testing.T) {
+	net := NewNetwork("PredStatsNet")
+	_, _, trc := net.AddDeep4D("Hid", 2, 1, 2, 2)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	for ni := range trc.Neurons {
+		nrn := &trc.Neurons[ni]
+		nrn.ActP = float32(ni%2) * 0.5
+		nrn.ActM = nrn.ActP
+		if ni >= 4 { // second pool: wrong prediction
+			nrn.ActM = 0.5 - nrn.ActP
+		}
+	}
+	trc.PredStatsFmActs()
+	ps := &trc.PredStats
+	if len(ps.Pools) != 2 {
+		t.Fatalf("PredStats: n pools: %d != 2\n", len(ps.Pools))
+	}
+	if ps.Pools[0].Cos != 1 || ps.Pools[0].SSE != 0 {
+		t.Errorf("PredStats: correct pool: %+v\n", ps.Pools[0])
+	}
+	if ps.Pools[1].Cos != 0 || ps.Pools[1].SSE != 1 {
+		t.Errorf("PredStats: wrong pool: %+v\n", ps.Pools[1])
+	}
+	if ps.SSE != 1 || ps.N != 1 {
+		t.Errorf("PredStats: layer SSE: %v N: %d\n", ps.SSE, ps.N)
+	}
+	net.EpochInc()
+	if trc.EpcPredStats.N != 1 || trc.PredStats.N != 0 {
+		t.Errorf("PredStats: EpochInc: epc N: %d cur N: %d\n", trc.EpcPredStats.N, trc.PredStats.N)
+	}
+}
+
+func TestTRCExtDrive(t *testing.T) {
+	net := NewNetwork("ExtNet")
+	_, _, trc := net.AddDeep2D("Hid", 2, 2)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	ext := etensor.NewFloat32([]int{2, 2}, nil, nil)
+	ext.Values[1] = 1
+	trc.InitExt()
+	trc.ApplyExt(ext)
+	if !trc.ExtDrive {
+		t.Errorf("TRC ApplyExt: ExtDrive not set\n")
+	}
+	nrn := &trc.Neurons[1]
+	if nrn.Targ != 1 || nrn.Ext != 0 || nrn.HasFlag(axon.NeurHasExt) {
+		t.Errorf("TRC ApplyExt: Targ: %v Ext: %v\n", nrn.Targ, nrn.Ext)
+	}
+	trc.InitExt()
+	if trc.ExtDrive {
+		t.Errorf("TRC InitExt: ExtDrive not cleared\n")
+	}
+}
+
+func TestDeep4D(t *testing.T) {
+	net := NewNetwork("Deep4D")
+	in := net.AddLayer4D("Input", 2, 2, 2, 2, emer.Input).(*axon.Layer)
+	super, ct, trc, _ := net.AddDeepLoop4D("Hid", 2, 2, 2, 2, nil, false)
+	net.ConnectLayers(in, super, prjn.NewPoolOneToOne(), emer.Forward)
+	drv := net.AddLayer4D("Drv", 4, 4, 3, 3, emer.Hidden).(*axon.Layer)
+	drv2 := net.AddLayer2D("Drv2", 2, 2, emer.Hidden).(*axon.Layer)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if !super.Inhib.Pool.On || !ct.Inhib.Pool.On || !trc.Inhib.Pool.On {
+		t.Errorf("Deep4D: pool inhibition not on for 4D layers\n")
+	}
+	if pj, err := ct.RecvPrjns().SendNameTry("HidCT"); err != nil {
+		t.Error(err)
+	} else if pt, ok := pj.Pattern().(*prjn.PoolTile); !ok || pt.Size.X != 3 {
+		t.Errorf("Deep4D: CT self context is not pool-topographic\n")
+	}
+	// last TRC pool (1,1), unit (1,1) -> driver pool (2,2), unit (1,1)
+	if dni := trc.DriverNeurIdx(15, drv); dni != 94 {
+		t.Errorf("DriverNeurIdx: 4D pool-aligned: %d != 94\n", dni)
+	}
+	if dni := trc.DriverNeurIdx(5, drv2); dni != 1 {
+		t.Errorf("DriverNeurIdx: 2D driver replicated: %d != 1\n", dni)
+	}
+	if dni := trc.DriverNeurIdx(7, super.AsAxon()); dni != 7 {
+		t.Errorf("DriverNeurIdx: same shape: %d != 7\n", dni)
+	}
+
+	net.InitWts()
+	ltime := axon.NewTime()
+	inpat := etensor.NewFloat32([]int{2, 2, 2, 2}, nil, nil)
+	for trl := 0; trl < 2; trl++ {
+		for i := range inpat.Values {
+			inpat.Values[i] = 0
+		}
+		inpat.Values[trl*4] = 1 // one unit in one pool
+		net.InitExt()
+		in.ApplyExt(inpat)
+		net.NewState()
+		ltime.NewState()
+		for qtr := 0; qtr < 4; qtr++ {
+			for cyc := 0; cyc < 50; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			if qtr == 2 {
+				net.MinusPhase(ltime)
+				ltime.NewPhase()
+			}
+		}
+		net.PlusPhase(ltime)
+		for pi := 1; pi < len(trc.Pools); pi++ {
+			if act := trc.Pools[pi].Inhib.Act.Max; mat32.IsNaN(act) {
+				t.Errorf("Deep4D: trial %d TRC pool %d: NaN act\n", trl, pi)
+			}
+		}
+	}
+	if len(trc.PredStats.Pools) != 4 {
+		t.Errorf("Deep4D: TRC PredStats pools: %d != 4\n", len(trc.PredStats.Pools))
+	}
+}
+
+func TestBurstGate(t *testing.T) {
+	net := NewNetwork("BurstGateNet")
+	super := net.AddSuperLayer2D("Hid", 2, 2)
+	out := net.AddLayer2D("Out", 2, 2, emer.Target).(*axon.Layer)
+	pj := net.ConnectLayers(super, out, prjn.NewFull(), emer.Forward).(*axon.Prjn)
+	net.Defaults()
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	pj.Learn.BurstGate = true
+	for ni := range super.Neurons {
+		super.Neurons[ni].AvgSLrn, super.Neurons[ni].AvgMLrn = 0.8, 0.2
+		out.Neurons[ni].AvgSLrn, out.Neurons[ni].AvgMLrn = 0.8, 0.2
+		out.Neurons[ni].RLrate = 1
+	}
+	super.SuperNeurs[0].Burst = 0.8 // only the first unit burst
+	pj.DWt()
+	for si := range super.Neurons {
+		nc, st := pj.SConNSt(si)
+		for ci := st; ci < st+nc; ci++ {
+			dwt := pj.Syns[ci].DWt
+			if si == 0 && dwt == 0 {
+				t.Errorf("BurstGate: bursting sender %d did not learn\n", si)
+			}
+			if si > 0 && dwt != 0 {
+				t.Errorf("BurstGate: non-bursting sender %d learned: %v\n", si, dwt)
+			}
+		}
+	}
+}
@@ -12,6 +12,41 @@ import (
 	"github.com/goki/mat32"
 )
 
+// CtxtParams control how the CTLayer CtxtGe context conductance integrates
+// new Burst input at each context update (CtxtFmGe), and decays in between,
+// for exploring longer temporal-context dynamics
+type CtxtParams struct {
+	Integ    bool    `def:"false" desc:"integrate new context input as a leaky running average with time constant Tau, instead of replacing the previous context"`
+	Tau      float32 `viewif:"Integ" def:"2" min:"1" desc:"time constant for integrating new context input, in number of context updates (CtxtFmGe calls, typically one per trial)"`
+	DecayTau float32 `def:"0" min:"0" desc:"time constant in cycles for decay of CtxtGe between context updates -- 0 = no decay"`
+
+	Dt      float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / Tau"`
+	DecayDt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / DecayTau, 0 if DecayTau = 0"`
+}
+
+func (cp *CtxtParams) Update() {
+	cp.Dt = 1 / cp.Tau
+	cp.DecayDt = 0
+	if cp.DecayTau > 0 {
+		cp.DecayDt = 1 / cp.DecayTau
+	}
+}
+
+func (cp *CtxtParams) Defaults() {
+	cp.Integ = false
+	cp.Tau = 2
+	cp.DecayTau = 0
+	cp.Update()
+}
+
+// CtxtFmNew returns the new context value given previous and new input
+func (cp *CtxtParams) CtxtFmNew(prv, nw float32) float32 {
+	if !cp.Integ {
+		return nw
+	}
+	return prv + cp.Dt*(nw-prv)
+}
+
 // CTLayer implements the corticothalamic projecting layer 6 deep neurons
 // that project to the TRC pulvinar neurons, to generate the predictions.
 // They receive phasic input representing 5IB bursting via CTCtxtPrjn inputs
@@ -20,6 +55,9 @@ type CTLayer struct {
 	axon.Layer           // access as .Layer
 	CtxtGeGain float32   `def:"0.2" desc:"gain factor for context excitatory input, which is constant as compared to the spiking input from other projections, so it must be downscaled accordingly"`
 	CtxtGes    []float32 `desc:"slice of context (temporally delayed) excitatory conducances."`
+
+	Ctxt    CtxtParams `view:"inline" desc:"how CtxtGes integrate new context input and decay between updates"`
+	ctxtPrv []float32  `view:"-" desc:"previous CtxtGes, for Ctxt.Integ"`
 }
 
 var KiT_CTLayer = kit.Types.AddType(&CTLayer{}, LayerProps)
@@ -31,6 +69,17 @@ func (ly *CTLayer) Defaults() {
 	ly.Act.Decay.KNa = 0
 	ly.Typ = CT
 	ly.CtxtGeGain = 0.2
+	ly.Ctxt.Defaults()
+	if ly.Is4D() {
+		ly.Inhib.Pool.On = true
+	}
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *CTLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Ctxt.Update()
 }
 
 func (ly *CTLayer) Class() string {
@@ -47,6 +96,12 @@ func (ly *CTLayer) Build() error {
 	return nil
 }
 
+// CheckpointVals returns the context state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *CTLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.CtxtGes, &ly.ctxtPrv}
+}
+
 func (ly *CTLayer) InitActs() {
 	ly.Layer.InitActs()
 	for ni := range ly.CtxtGes {
@@ -61,16 +116,21 @@ func (ly *CTLayer) GFmInc(ltime *axon.Time) {
 		cyc = ltime.PhaseCycle
 	}
 	ly.RecvGInc(ltime)
+	ddt := ly.Ctxt.DecayDt
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
+		if ddt > 0 {
+			ly.CtxtGes[ni] -= ddt * ly.CtxtGes[ni]
+		}
 
 		geRaw := nrn.GeRaw + ly.CtxtGeGain*ly.CtxtGes[ni]
 
-		nrn.NMDA = ly.Act.NMDA.NMDA(nrn.NMDA, geRaw, nrn.NMDASyn)
-		nrn.Gnmda = ly.Act.NMDA.Gnmda(nrn.NMDA, nrn.VmDend)
+		// note: NMDA is driven by context input too, so Act.NMDA determines how long
+		// context is sustained -- see axon.ChanL6 preset
+		ly.Act.NMDAFmRaw(nrn, geRaw)
 		// note: GABAB integrated in ActFmG one timestep behind, b/c depends on integrated Gi inhib
 
 		// note: each step broken out here so other variants can add extra terms to Raw
@@ -111,9 +171,14 @@ func (ly *CTLayer) SendCtxtGe(ltime *axon.Time) {
 }
 
 // CtxtFmGe integrates new CtxtGe excitatory conductance from projections, and computes
-// overall Ctxt value, only on Deep layers.
+// overall Ctxt value, only on Deep layers, replacing or integrating with the
+// previous value according to Ctxt params.
 // This should be called at the end of the 5IB Bursting phase via Network.CTCtxt
 func (ly *CTLayer) CtxtFmGe(ltime *axon.Time) {
+	integ := ly.Ctxt.Integ
+	if integ {
+		ly.ctxtPrv = append(ly.ctxtPrv[:0], ly.CtxtGes...)
+	}
 	for ni := range ly.CtxtGes {
 		ly.CtxtGes[ni] = 0
 	}
@@ -131,6 +196,11 @@ func (ly *CTLayer) CtxtFmGe(ltime *axon.Time) {
 		}
 		pj.RecvCtxtGeInc()
 	}
+	if integ {
+		for ni := range ly.CtxtGes {
+			ly.CtxtGes[ni] = ly.Ctxt.CtxtFmNew(ly.ctxtPrv[ni], ly.CtxtGes[ni])
+		}
+	}
 }
 
 // UnitVarNames returns a list of variable names available on the units in this layer
@@ -22,6 +22,25 @@ type CtxtSender interface {
 	SendCtxtGe(ltime *axon.Time)
 }
 
+// CtxtLearnParams control the prediction-error learning rule for CTCtxtPrjn,
+// which trains the context weights on prediction performance: the weight
+// change is the sender's prior-burst activity (BurstPrv) times the receiving
+// CT neuron's subsequent prediction error, instead of the standard CHL rule.
+type CtxtLearnParams struct {
+	On      bool    `desc:"use the prediction-error learning rule instead of the standard CHL rule"`
+	TRCErr  bool    `viewif:"On" def:"true" desc:"the receiver prediction error is the TRC error (ActP - ActM) of the TRC layers that the CT layer projects to, averaged over its connections weighted by their weights -- otherwise it is the CT neuron's own ActP - ActM"`
+	ErrGain float32 `viewif:"On" def:"1" min:"0" desc:"gain on the prediction error"`
+}
+
+func (cl *CtxtLearnParams) Defaults() {
+	cl.On = false
+	cl.TRCErr = true
+	cl.ErrGain = 1
+}
+
+func (cl *CtxtLearnParams) Update() {
+}
+
 // CTCtxtPrjn is the "context" temporally-delayed projection into CTLayer,
 // (corticothalamic deep layer 6) where the CtxtGe excitatory input
 // is integrated only at end of Burst Quarter.
@@ -30,16 +49,21 @@ type CTCtxtPrjn struct {
 	axon.Prjn           // access as .Prjn
 	FmSuper   bool      `desc:"if true, this is the projection from corresponding Superficial layer -- should be OneToOne prjn, with Learn.Learn = false, WtInit.Var = 0, Mean = 0.8 -- these defaults are set if FmSuper = true"`
 	CtxtGeInc []float32 `desc:"local per-recv unit accumulator for Ctxt excitatory conductance from sending units -- not a delta -- the full value"`
+
+	LearnCtxt CtxtLearnParams `view:"inline" desc:"prediction-error learning rule, trained on the receiver's subsequent prediction error instead of CHL"`
+	RecvErr   []float32       `view:"-" desc:"per-recv unit prediction error for the LearnCtxt rule, computed in DWt"`
 }
 
 var KiT_CTCtxtPrjn = kit.Types.AddType(&CTCtxtPrjn{}, PrjnProps)
 
 func (pj *CTCtxtPrjn) Defaults() {
 	pj.Prjn.Defaults() // note: used to have other defaults
+	pj.LearnCtxt.Defaults()
 }
 
 func (pj *CTCtxtPrjn) UpdateParams() {
 	pj.Prjn.UpdateParams()
+	pj.LearnCtxt.Update()
 }
 
 func (pj *CTCtxtPrjn) Type() emer.PrjnType {
@@ -67,9 +91,16 @@ func (pj *CTCtxtPrjn) Build() error {
 	rsh := pj.Recv.Shape()
 	rlen := rsh.Len()
 	pj.CtxtGeInc = make([]float32, rlen)
+	pj.RecvErr = make([]float32, rlen)
 	return nil
 }
 
+// CheckpointVals returns the context conductance state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (pj *CTCtxtPrjn) CheckpointVals() []interface{} {
+	return []interface{}{&pj.CtxtGeInc}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Init methods
 
@@ -95,8 +126,7 @@ func (pj *CTCtxtPrjn) RecvGInc(ltime *axon.Time) {
 // to integrate CtxtGe excitatory conductance on receivers
 func (pj *CTCtxtPrjn) SendCtxtGe(si int, dburst float32) {
 	scdb := dburst * pj.GScale.Scale
-	nc := pj.SConN[si]
-	st := pj.SConIdxSt[si]
+	nc, st := pj.SConNSt(si)
 	syns := pj.Syns[st : st+nc]
 	scons := pj.SConIdx[st : st+nc]
 	for ci := range syns {
@@ -122,7 +152,11 @@ func (pj *CTCtxtPrjn) RecvCtxtGeInc() {
 
 // DWt computes the weight change (learning) for Ctxt projections
 func (pj *CTCtxtPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
+		return
+	}
+	if pj.LearnCtxt.On {
+		pj.DWtCtxt()
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -136,8 +170,10 @@ func (pj *CTCtxtPrjn) DWt() {
 		} else {
 			sact = slay.Neurons[si].ActPrv
 		}
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		if issuper && pj.Learn.BurstGate && sact == 0 {
+			continue // did not burst: no plasticity
+		}
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 		for ci := range syns {
@@ -158,6 +194,81 @@ func (pj *CTCtxtPrjn) DWt() {
 	}
 }
 
+// DWtCtxt computes the weight change for the LearnCtxt prediction-error rule:
+// sender prior-burst activity times the receiver prediction error (RecvErrFmActs)
+func (pj *CTCtxtPrjn) DWtCtxt() {
+	slay := pj.Send.(axon.AxonLayer).AsAxon()
+	sslay, issuper := pj.Send.(*SuperLayer)
+	pj.RecvErrFmActs()
+	lr := pj.Learn.Lrate.Eff * pj.LearnCtxt.ErrGain
+	for si := range slay.Neurons {
+		sact := float32(0)
+		if issuper {
+			sact = sslay.SuperNeurs[si].BurstPrv
+		} else {
+			sact = slay.Neurons[si].ActPrv
+		}
+		if sact == 0 {
+			continue
+		}
+		nc, st := pj.SConNSt(si)
+		syns := pj.Syns[st : st+nc]
+		scons := pj.SConIdx[st : st+nc]
+		for ci := range syns {
+			sy := &syns[ci]
+			err := sact * pj.RecvErr[scons[ci]]
+			if err > 0 {
+				err *= (1 - sy.LWt)
+			} else {
+				err *= sy.LWt
+			}
+			sy.DWt += lr * err
+		}
+	}
+}
+
+// RecvErrFmActs computes the RecvErr prediction error of each receiving
+// neuron, per LearnCtxt.TRCErr: the TRC layer errors (ActP - ActM) averaged
+// over its sending connections to TRC layers, weighted by their weights,
+// or else its own ActP - ActM
+func (pj *CTCtxtPrjn) RecvErrFmActs() {
+	rlay := pj.Recv.(axon.AxonLayer).AsAxon()
+	for ri := range pj.RecvErr {
+		pj.RecvErr[ri] = 0
+	}
+	if !pj.LearnCtxt.TRCErr {
+		for ri := range rlay.Neurons {
+			rn := &rlay.Neurons[ri]
+			pj.RecvErr[ri] = rn.ActP - rn.ActM
+		}
+		return
+	}
+	for _, sp := range rlay.SndPrjns {
+		if sp.IsOff() {
+			continue
+		}
+		tlay, ok := sp.RecvLay().(*TRCLayer)
+		if !ok {
+			continue
+		}
+		tpj := sp.(axon.AxonPrjn).AsAxon()
+		for ri := range pj.RecvErr {
+			nc, st := tpj.SConNSt(ri)
+			if nc == 0 {
+				continue
+			}
+			syns := tpj.Syns[st : st+nc]
+			scons := tpj.SConIdx[st : st+nc]
+			var err float32
+			for ci := range syns {
+				tn := &tlay.Neurons[scons[ci]]
+				err += syns[ci].Wt * (tn.ActP - tn.ActM)
+			}
+			pj.RecvErr[ri] += err / float32(nc)
+		}
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  PrjnType
 
@@ -49,12 +49,13 @@ Wiring diagram:
 
 Timing:
 
-The alpha-cycle quarter(s) when Burst is updated and broadcast is set in
-BurstQtr (defaults to Q4, can also be e.g., Q2 and Q4 for beta frequency updating).
-During this quarter(s), the Burst value is computed in SuperLayer, and this is
+By default, Burst is updated and broadcast during the plus phase.
+The Network BurstSched can instead specify burst windows in cycles (Start, Dur,
+Period), e.g., a 50 cycle window every 100 cycles for alpha frequency updating.
+During these windows, the Burst value is computed in SuperLayer, and this is
 continuously accessed by TRCLayer neurons to drive plus-phase outcome states.
 
-At the *end* of the burst quarter(s), in the QuarterFinal method,
+At the *end* of the plus phase, or of each burst window (in CyclePostImpl),
 CTCtxt projections convey the Burst signal from Super to CTLayer neurons,
 where it is integrated into the Ctxt value representing the temporally-delayed
 context information.
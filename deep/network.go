@@ -15,6 +15,51 @@ import (
 // deep.Network has parameters for running a DeepAxon network
 type Network struct {
 	axon.Network
+	BurstSched BurstSchedParams `view:"inline" desc:"cycle-level schedule of 5IB bursting windows, for bursting at frequencies that do not align with the plus phase"`
+}
+
+// BurstSchedParams specify a schedule of 5IB bursting windows in cycles,
+// within which SuperLayer Burst is computed, and at the end of which the
+// Burst context is sent to CT layers (CTCtxt).  This allows bursting at
+// beta, alpha or theta frequencies that do not align with the plus phase.
+// If not On, bursting occurs during the plus phase, with context sent at
+// the end of it.
+type BurstSchedParams struct {
+	On     bool `desc:"use this schedule for bursting, instead of the plus phase"`
+	Start  int  `viewif:"On" def:"150" min:"0" desc:"cycle within each Period at which the burst window starts"`
+	Dur    int  `viewif:"On" def:"50" min:"1" desc:"duration of each burst window, in cycles"`
+	Period int  `viewif:"On" def:"200" min:"0" desc:"period of repetition of the burst window in cycles, counting from the start of each trial (Time.Cycle) -- e.g., 200 = theta, 100 = alpha, 50 = beta -- 0 = only once per trial"`
+}
+
+func (bs *BurstSchedParams) Defaults() {
+	bs.Start = 150
+	bs.Dur = 50
+	bs.Period = 200
+}
+
+func (bs *BurstSchedParams) Update() {
+	if bs.Dur < 1 {
+		bs.Dur = 1
+	}
+}
+
+// PerCyc returns the cycle within the current Period for given trial cycle
+func (bs *BurstSchedParams) PerCyc(cyc int) int {
+	if bs.Period > 0 {
+		return cyc % bs.Period
+	}
+	return cyc
+}
+
+// Active returns true if given trial cycle (Time.Cycle) is within a burst window
+func (bs *BurstSchedParams) Active(cyc int) bool {
+	c := bs.PerCyc(cyc)
+	return c >= bs.Start && c < bs.Start+bs.Dur
+}
+
+// End returns true if given trial cycle (Time.Cycle) is the last of a burst window
+func (bs *BurstSchedParams) End(cyc int) bool {
+	return bs.PerCyc(cyc) == bs.Start+bs.Dur-1
 }
 
 var KiT_Network = kit.Types.AddType(&Network{}, NetworkProps)
@@ -31,12 +76,14 @@ func NewNetwork(name string) *Network {
 // Defaults sets all the default parameters for all layers and projections
 func (nt *Network) Defaults() {
 	nt.Network.Defaults()
+	nt.BurstSched.Defaults()
 }
 
 // UpdateParams updates all the derived parameters if any have changed, for all layers
 // and projections
 func (nt *Network) UpdateParams() {
 	nt.Network.UpdateParams()
+	nt.BurstSched.Update()
 }
 
 // UnitVarNames returns a list of variable names available on the units in this layer
@@ -191,6 +238,111 @@ func AddSuperCTTRC4D(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nN
 	return
 }
 
+// AddDeep2D adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections:
+// CTCtxtPrjn OneToOne from Super to CT, CT -> TRC (class CTToPulv), and TRC back to
+// Super and CT (type Back, class FmPulv), all Full except Super -> CT.
+// TRC.Driver is set to Super.  CT is placed Behind Super, and Pulvinar behind CT.
+// Returns the layers with their specific types.
+func AddDeep2D(nt *axon.Network, name string, shapeY, shapeX int) (super *SuperLayer, ct *CTLayer, trc *TRCLayer) {
+	s, c, t := AddSuperCTTRC2D(nt, name, shapeY, shapeX)
+	super, ct, trc = s.(*SuperLayer), c.(*CTLayer), t.(*TRCLayer)
+	ConnectToTRC2D(nt, super, ct, trc)
+	return
+}
+
+// AddDeep4D adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections:
+// CTCtxtPrjn OneToOne from Super to CT, CT -> TRC (class CTToPulv), and TRC back to
+// Super and CT (type Back, class FmPulv), PoolOneToOne except Super -> CT.
+// TRC.Driver is set to Super.  CT is placed Behind Super, and Pulvinar behind CT.
+// Returns the layers with their specific types.
+func AddDeep4D(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int) (super *SuperLayer, ct *CTLayer, trc *TRCLayer) {
+	s, c, t := AddSuperCTTRC4D(nt, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
+	super, ct, trc = s.(*SuperLayer), c.(*CTLayer), t.(*TRCLayer)
+	ConnectToTRC4D(nt, super, ct, trc)
+	return
+}
+
+// CtxtPoolTile returns a pool-topographic PoolTile pattern for context
+// projections among 4D layers with the same pool geometry: each receiving
+// pool is connected to the size x size neighborhood of sending pools
+// centered on it (size should be odd).
+func CtxtPoolTile(size int) *prjn.PoolTile {
+	pt := prjn.NewPoolTile()
+	pt.Size.Set(size, size)
+	pt.Skip.Set(1, 1)
+	pt.Start.Set(-size/2, -size/2)
+	return pt
+}
+
+// ConnectCTSelf adds a CTCtxtPrjn from a CT layer to itself (class CTSelfCtxt),
+// representing the deep-to-deep lateral connections that integrate context
+// over longer time scales.  The pattern is typically topographic -- if pat is
+// nil, CtxtPoolTile(3) is used for 4D layers, and Full for 2D.
+func ConnectCTSelf(nt *axon.Network, ct emer.Layer, pat prjn.Pattern) emer.Prjn {
+	if pat == nil {
+		if ct.Shape().NumDims() == 4 {
+			pat = CtxtPoolTile(3)
+		} else {
+			pat = prjn.NewFull()
+		}
+	}
+	pj := ConnectCtxtToCT(nt, ct, ct, pat)
+	pj.SetClass("CTSelfCtxt")
+	return pj
+}
+
+// AddTRN adds a TRNLayer (TRN suffix on the TRC name) with the same shape as
+// given TRC layer, placed Behind it, that receives the CT predictions
+// (class CTToTRN) and sends inhibition back to the TRC (type Inhib,
+// class TRNToPulv), sharpening the predictions by suppressing the TRC units
+// that are not predicted.  The projections are OneToOne for 2D layers and
+// PoolOneToOne for 4D, with fixed weights per TRNPrjnDefaults.
+func AddTRN(nt *axon.Network, ct, trc emer.Layer) *TRNLayer {
+	var pat prjn.Pattern
+	if trc.Shape().NumDims() == 4 {
+		pat = prjn.NewPoolOneToOne()
+	} else {
+		pat = prjn.NewOneToOne()
+	}
+	trn := &TRNLayer{}
+	nm := trc.Name() + "TRN"
+	shp := make([]int, trc.Shape().NumDims())
+	copy(shp, trc.Shape().Shp)
+	nt.AddLayerInit(trn, nm, shp, emer.Hidden)
+	trn.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: trc.Name(), XAlign: relpos.Left, Space: 2})
+	nt.ConnectLayers(ct, trn, pat, emer.Forward).SetClass("CTToTRN")
+	nt.ConnectLayers(trn, trc, pat, emer.Inhib).SetClass("TRNToPulv")
+	return trn
+}
+
+// AddDeepLoop2D adds a complete corticothalamic loop: the deep triad of
+// AddDeep2D, plus a CT self-context projection with given pattern
+// (see ConnectCTSelf), and if trn is true, a TRNLayer between CT and TRC
+// (see AddTRN) -- trnl is nil otherwise.
+func AddDeepLoop2D(nt *axon.Network, name string, shapeY, shapeX int, selfPat prjn.Pattern, trn bool) (super *SuperLayer, ct *CTLayer, trc *TRCLayer, trnl *TRNLayer) {
+	super, ct, trc = AddDeep2D(nt, name, shapeY, shapeX)
+	ConnectCTSelf(nt, ct, selfPat)
+	if trn {
+		trnl = AddTRN(nt, ct, trc)
+	}
+	return
+}
+
+// AddDeepLoop4D adds a complete corticothalamic loop: the deep triad of
+// AddDeep4D, plus a CT self-context projection with given pattern
+// (see ConnectCTSelf), and if trn is true, a TRNLayer between CT and TRC
+// (see AddTRN) -- trnl is nil otherwise.
+func AddDeepLoop4D(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int, selfPat prjn.Pattern, trn bool) (super *SuperLayer, ct *CTLayer, trc *TRCLayer, trnl *TRNLayer) {
+	super, ct, trc = AddDeep4D(nt, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
+	ConnectCTSelf(nt, ct, selfPat)
+	if trn {
+		trnl = AddTRN(nt, ct, trc)
+	}
+	return
+}
+
 // AddSuperCT2D adds a superficial (SuperLayer) and corresponding CT (CT suffix) layer
 // with CTCtxtPrjn OneToOne projection from Super to CT, and NO TRC Pulvinar.
 // CT is placed Behind Super.
@@ -238,6 +390,58 @@ func AddSuperCTTRC4DPy(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY,
 	return []emer.Layer{super, ct, trc}
 }
 
+// AddDeep2DPy adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections -- see AddDeep2D.
+// Py is Python version, returns layers as a slice
+func AddDeep2DPy(nt *axon.Network, name string, shapeY, shapeX int) []emer.Layer {
+	super, ct, trc := AddDeep2D(nt, name, shapeY, shapeX)
+	return []emer.Layer{super, ct, trc}
+}
+
+// AddDeep4DPy adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections -- see AddDeep4D.
+// Py is Python version, returns layers as a slice
+func AddDeep4DPy(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int) []emer.Layer {
+	super, ct, trc := AddDeep4D(nt, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
+	return []emer.Layer{super, ct, trc}
+}
+
+// AddDeepLoop2DPy adds a complete corticothalamic loop -- see AddDeepLoop2D.
+// Py is Python version, returns layers as a slice, without the TRN if not trn
+func AddDeepLoop2DPy(nt *axon.Network, name string, shapeY, shapeX int, selfPat prjn.Pattern, trn bool) []emer.Layer {
+	super, ct, trc, trnl := AddDeepLoop2D(nt, name, shapeY, shapeX, selfPat, trn)
+	if trnl == nil {
+		return []emer.Layer{super, ct, trc}
+	}
+	return []emer.Layer{super, ct, trc, trnl}
+}
+
+// AddDeepLoop4DPy adds a complete corticothalamic loop -- see AddDeepLoop4D.
+// Py is Python version, returns layers as a slice, without the TRN if not trn
+func AddDeepLoop4DPy(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int, selfPat prjn.Pattern, trn bool) []emer.Layer {
+	super, ct, trc, trnl := AddDeepLoop4D(nt, name, nPoolsY, nPoolsX, nNeurY, nNeurX, selfPat, trn)
+	if trnl == nil {
+		return []emer.Layer{super, ct, trc}
+	}
+	return []emer.Layer{super, ct, trc, trnl}
+}
+
+// AddInputTRC2DPy adds an Input and TRCLayer of given size, with given name.
+// The Input layer is set as the Driver of the TRCLayer
+// Py is Python version, returns layers as a slice
+func AddInputTRC2DPy(nt *axon.Network, name string, nNeurY, nNeurX int) []emer.Layer {
+	in, trc := AddInputTRC2D(nt, name, nNeurY, nNeurX)
+	return []emer.Layer{in, trc}
+}
+
+// AddInputTRC4DPy adds an Input and TRCLayer of given size, with given name.
+// The Input layer is set as the Driver of the TRCLayer
+// Py is Python version, returns layers as a slice
+func AddInputTRC4DPy(nt *axon.Network, name string, nPoolsY, nPoolsX, nNeurY, nNeurX int) []emer.Layer {
+	in, trc := AddInputTRC4D(nt, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
+	return []emer.Layer{in, trc}
+}
+
 // AddSuperCT2DPy adds a superficial (SuperLayer) and corresponding CT (CT suffix) layer
 // with CTCtxtPrjn Full projection from Super to CT, and NO TRC Pulvinar.
 // CT is placed Behind Super.
@@ -275,6 +479,40 @@ func (nt *Network) AddSuperCTTRC4D(name string, nPoolsY, nPoolsX, nNeurY, nNeurX
 	return AddSuperCTTRC4D(&nt.Network, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
 }
 
+// AddDeep2D adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections -- see AddDeep2D.
+func (nt *Network) AddDeep2D(name string, shapeY, shapeX int) (super *SuperLayer, ct *CTLayer, trc *TRCLayer) {
+	return AddDeep2D(&nt.Network, name, shapeY, shapeX)
+}
+
+// AddDeep4D adds a complete deep triad of superficial (SuperLayer), CT (CT suffix)
+// and TRC Pulvinar (P suffix) layers, with the standard projections -- see AddDeep4D.
+func (nt *Network) AddDeep4D(name string, nPoolsY, nPoolsX, nNeurY, nNeurX int) (super *SuperLayer, ct *CTLayer, trc *TRCLayer) {
+	return AddDeep4D(&nt.Network, name, nPoolsY, nPoolsX, nNeurY, nNeurX)
+}
+
+// AddDeepLoop2D adds a complete corticothalamic loop, with CT self-context
+// and optional TRN -- see AddDeepLoop2D.
+func (nt *Network) AddDeepLoop2D(name string, shapeY, shapeX int, selfPat prjn.Pattern, trn bool) (super *SuperLayer, ct *CTLayer, trc *TRCLayer, trnl *TRNLayer) {
+	return AddDeepLoop2D(&nt.Network, name, shapeY, shapeX, selfPat, trn)
+}
+
+// AddDeepLoop4D adds a complete corticothalamic loop, with CT self-context
+// and optional TRN -- see AddDeepLoop4D.
+func (nt *Network) AddDeepLoop4D(name string, nPoolsY, nPoolsX, nNeurY, nNeurX int, selfPat prjn.Pattern, trn bool) (super *SuperLayer, ct *CTLayer, trc *TRCLayer, trnl *TRNLayer) {
+	return AddDeepLoop4D(&nt.Network, name, nPoolsY, nPoolsX, nNeurY, nNeurX, selfPat, trn)
+}
+
+// ConnectCTSelf adds a CTCtxtPrjn from a CT layer to itself -- see ConnectCTSelf.
+func (nt *Network) ConnectCTSelf(ct emer.Layer, pat prjn.Pattern) emer.Prjn {
+	return ConnectCTSelf(&nt.Network, ct, pat)
+}
+
+// AddTRN adds a TRNLayer between given CT and TRC layers -- see AddTRN.
+func (nt *Network) AddTRN(ct, trc emer.Layer) *TRNLayer {
+	return AddTRN(&nt.Network, ct, trc)
+}
+
 // AddSuperCT2D adds a superficial (SuperLayer) and corresponding CT (CT suffix) layer
 // with CTCtxtPrjn OneToOne projection from Super to CT, and NO TRC Pulvinar.
 // CT is placed Behind Super.
@@ -353,13 +591,36 @@ func (nt *Network) AddSuperLayer4D(name string, nPoolsY, nPoolsX, nNeurY, nNeurX
 //////////////////////////////////////////////////////////////////////////////////////
 //  Compute methods
 
-// PlusPhase does updating after end of plus phase
+// CyclePostImpl calls CyclePost on Layers, and then CTCtxt at the
+// end of each burst window if BurstSched.On
+func (nt *Network) CyclePostImpl(ltime *axon.Time) {
+	nt.Network.CyclePostImpl(ltime)
+	if nt.BurstSched.On && nt.BurstSched.End(ltime.Cycle) {
+		nt.CTCtxt(ltime)
+	}
+}
+
+// PlusPhase does updating after end of plus phase,
+// including CTCtxt unless BurstSched.On
 func (nt *Network) PlusPhase(ltime *axon.Time) {
 	nt.EmerNet.(axon.AxonNetwork).PlusPhaseImpl(ltime)
-	nt.CTCtxt(ltime)
+	if !nt.BurstSched.On {
+		nt.CTCtxt(ltime)
+	}
 }
 
-// CTCtxt sends context to CT layers and integrates CtxtGe on CT layers
+// BurstActive returns true if 5IB Burst should be updated on this cycle
+// for layers in given network: per the BurstSched if it is a deep.Network
+// with BurstSched.On, and otherwise during the plus phase
+func BurstActive(net emer.Network, ltime *axon.Time) bool {
+	if dn, ok := net.(*Network); ok && dn.BurstSched.On {
+		return dn.BurstSched.Active(ltime.Cycle)
+	}
+	return ltime.PlusPhase
+}
+
+// CTCtxt sends context to CT layers and integrates CtxtGe on CT layers,
+// and updates the SuperLayer burst stats and threshold adaptation
 func (nt *Network) CTCtxt(ltime *axon.Time) {
 	nt.ThrLayFun(func(ly axon.AxonLayer) {
 		if dl, ok := ly.(CtxtSender); ok {
@@ -374,6 +635,12 @@ func (nt *Network) CTCtxt(ltime *axon.Time) {
 			dl.CtxtFmGe(ltime)
 		}
 	}, "CtxtFmGe")
+
+	nt.ThrLayFun(func(ly axon.AxonLayer) {
+		if sl, ok := ly.(*SuperLayer); ok {
+			sl.BurstAdapt()
+		}
+	}, "BurstAdapt")
 }
 
 // LayerSendCtxtGe sends activation over CTCtxtPrjn projections to integrate
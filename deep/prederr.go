@@ -0,0 +1,201 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deep
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/relpos"
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// PredErrParams provides parameters for how the PredErrLayer activity
+// is computed from the prediction error of its TRC layer
+type PredErrParams struct {
+	TRCLay     string  `desc:"name of the TRCLayer whose prediction error (outcome - prediction) is encoded by this layer"`
+	Rect       bool    `desc:"rectified encoding: the first half of the units (the first half of the pools along Y) encode positive errors (outcome > prediction), and the second half encode negative errors, so the layer has twice as many units as the TRC layer -- otherwise the error is signed, encoded relative to a Base level of activity, with one unit per TRC unit"`
+	Base       float32 `viewif:"!Rect" def:"0.5" min:"0" max:"1" desc:"for signed encoding, the drive level representing zero error: errors of +1 drive 1, and -1 drive 0"`
+	Thr        float32 `def:"0.05" min:"0" desc:"threshold on the absolute error, below which it is treated as zero, filtering out small spiking fluctuations"`
+	DriveScale float32 `def:"0.3" min:"0" desc:"multiplier on the error drive to produce Ge excitatory input to the units"`
+	Hold       bool    `def:"true" desc:"during the minus phase, encode the error from the last plus phase (ActP - ActM) -- otherwise the layer is only driven in the plus phase, from the current TRC activity (Act - ActM)"`
+}
+
+func (pe *PredErrParams) Defaults() {
+	pe.Base = 0.5
+	pe.Thr = 0.05
+	pe.DriveScale = 0.3
+	pe.Hold = true
+}
+
+func (pe *PredErrParams) Update() {
+}
+
+// Drive returns the drive value in [0..1] for given signed prediction error,
+// for a signed unit if !Rect, or for a positive (pos = true) or negative
+// error unit if Rect
+func (pe *PredErrParams) Drive(err float32, pos bool) float32 {
+	if mat32.Abs(err) < pe.Thr {
+		err = 0
+	}
+	err = mat32.Min(1, mat32.Max(-1, err))
+	if pe.Rect {
+		if pos {
+			return mat32.Max(0, err)
+		}
+		return mat32.Max(0, -err)
+	}
+	if err > 0 {
+		return pe.Base + (1-pe.Base)*err
+	}
+	return pe.Base + pe.Base*err
+}
+
+// PredErrLayer encodes the prediction error of a TRCLayer: the difference
+// between the plus-phase outcome and the minus-phase prediction, either
+// signed relative to a baseline, or rectified into separate positive and
+// negative halves (see PredErrParams).  Its units are driven by the error
+// through their Ge conductance and spike normally, so it can serve as the
+// sending layer of projections to other layers (e.g., RL or attention
+// circuits) that consume explicit error signals.
+type PredErrLayer struct {
+	axon.Layer               // access as .Layer
+	PredErr    PredErrParams `view:"inline" desc:"parameters for computing activity from the TRC prediction error"`
+	Errs       []float32     `view:"-" desc:"current signed prediction error, per TRC unit"`
+}
+
+var KiT_PredErrLayer = kit.Types.AddType(&PredErrLayer{}, LayerProps)
+
+func (ly *PredErrLayer) Defaults() {
+	ly.Layer.Defaults()
+	ly.Act.Decay.Act = 1
+	ly.Act.Decay.Glong = 1
+	ly.Act.Decay.KNa = 0
+	ly.PredErr.Defaults()
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *PredErrLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.PredErr.Update()
+}
+
+// CheckpointVals returns the prediction error state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (ly *PredErrLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.Errs}
+}
+
+func (ly *PredErrLayer) Class() string {
+	return "PredErr " + ly.Cls
+}
+
+// TRCLayer returns the PredErr.TRCLay layer
+func (ly *PredErrLayer) TRCLayer() (*axon.Layer, error) {
+	tly, err := ly.Network.LayerByNameTry(ly.PredErr.TRCLay)
+	if err != nil {
+		err = fmt.Errorf("PredErrLayer %s: TRCLay: %v", ly.Name(), err)
+		log.Println(err)
+		return nil, err
+	}
+	return tly.(axon.AxonLayer).AsAxon(), nil
+}
+
+// ErrsFmTRC computes the signed prediction errors of the TRC layer into Errs,
+// returning false if there is no error signal in the current phase
+func (ly *PredErrLayer) ErrsFmTRC(tly *axon.Layer, ltime *axon.Time) bool {
+	nn := len(tly.Neurons)
+	if len(ly.Errs) != nn {
+		ly.Errs = make([]float32, nn)
+	}
+	if !ltime.PlusPhase && !ly.PredErr.Hold {
+		for ni := range ly.Errs {
+			ly.Errs[ni] = 0
+		}
+		return false
+	}
+	for ni := range tly.Neurons {
+		nrn := &tly.Neurons[ni]
+		if ltime.PlusPhase {
+			ly.Errs[ni] = nrn.Act - nrn.ActM
+		} else {
+			ly.Errs[ni] = nrn.ActP - nrn.ActM
+		}
+	}
+	return true
+}
+
+// GFmInc integrates new synaptic conductances from increments sent during last SendGDelta,
+// adding the drive from the TRC prediction error.
+func (ly *PredErrLayer) GFmInc(ltime *axon.Time) {
+	ly.RecvGInc(ltime)
+	tly, err := ly.TRCLayer()
+	if err != nil || !ly.ErrsFmTRC(tly, ltime) {
+		ly.GFmIncNeur(ltime)
+		return
+	}
+	ne := len(ly.Errs)
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		ei := ni
+		pos := true
+		if ly.PredErr.Rect && ni >= ne {
+			ei = ni - ne
+			pos = false
+		}
+		drv := float32(0)
+		if ei < ne {
+			drv = ly.PredErr.DriveScale * ly.PredErr.Drive(ly.Errs[ei], pos)
+		}
+		geRaw := nrn.GeRaw + drv
+		ly.Act.NMDAFmRaw(nrn, geRaw)
+		ly.Act.GeFmRaw(nrn, geRaw, 0, ltime.Cycle, nrn.ActM)
+		nrn.GeRaw = 0
+		ly.Act.GiFmRaw(nrn, nrn.GiRaw)
+		nrn.GiRaw = 0
+	}
+}
+
+// PredErrShape returns the shape of a PredErrLayer for given TRC layer shape:
+// the same shape if !rect, and otherwise a 4D shape with twice the pools
+// along Y (a 2D TRC layer has 2 x 1 pools)
+func PredErrShape(trcShp []int, rect bool) []int {
+	shp := make([]int, len(trcShp))
+	copy(shp, trcShp)
+	if !rect {
+		return shp
+	}
+	if len(shp) == 4 {
+		shp[0] *= 2
+		return shp
+	}
+	return []int{2, 1, shp[0], shp[1]}
+}
+
+// AddPredErrLayer adds a PredErrLayer of given name encoding the prediction
+// error of given TRC layer, rectified into separate positive and negative
+// pools if rect, otherwise signed (see PredErrParams).  It is placed to the
+// right of the TRC layer.
+func AddPredErrLayer(nt *axon.Network, name string, trc emer.Layer, rect bool) *PredErrLayer {
+	ly := &PredErrLayer{}
+	nt.AddLayerInit(ly, name, PredErrShape(trc.Shape().Shp, rect), emer.Hidden)
+	ly.PredErr.TRCLay = trc.Name()
+	ly.PredErr.Rect = rect
+	ly.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: trc.Name(), YAlign: relpos.Front, Space: 2})
+	return ly
+}
+
+// AddPredErrLayer adds a PredErrLayer of given name encoding the prediction
+// error of given TRC layer -- see AddPredErrLayer.
+func (nt *Network) AddPredErrLayer(name string, trc emer.Layer, rect bool) *PredErrLayer {
+	return AddPredErrLayer(&nt.Network, name, trc, rect)
+}
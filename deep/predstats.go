@@ -0,0 +1,196 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deep
+
+import (
+	"fmt"
+
+	"github.com/emer/axon/axon"
+	"github.com/goki/mat32"
+)
+
+// PredVals are the prediction quality values for one trial, for the whole
+// layer or one pool: comparing the minus-phase prediction (ActM) with the
+// plus-phase driven outcome (ActP)
+type PredVals struct {
+	Cos float32 `inactive:"+" desc:"cosine (normalized dot product) between ActM prediction and ActP outcome -- unlike CosDiff, this is not mean-subtracted, so it directly reflects the match of the prediction to the outcome"`
+	SSE float32 `inactive:"+" desc:"sum squared error between ActM prediction and ActP outcome"`
+}
+
+// PredStats are prediction quality statistics for a TRCLayer, computed at
+// the end of every plus phase: the values for the last trial, overall and
+// per pool for 4D layers, running averages, and sums accumulated over the
+// current epoch -- see TRCLayer.PredStats and EpcPredStats.
+type PredStats struct {
+	PredVals
+	CosAvg float32    `inactive:"+" desc:"running average of Cos, computed with the Act.Dt.LongAvgTau time constant"`
+	CosVar float32    `inactive:"+" desc:"running variance of Cos, computed with the Act.Dt.LongAvgTau time constant"`
+	SSEAvg float32    `inactive:"+" desc:"running average of SSE, computed with the Act.Dt.LongAvgTau time constant"`
+	SSEVar float32    `inactive:"+" desc:"running variance of SSE, computed with the Act.Dt.LongAvgTau time constant"`
+	Pools  []PredVals `view:"-" desc:"values for the last trial for each pool of a 4D layer (excluding the layer-level pool)"`
+
+	N      int     `inactive:"+" desc:"number of trials accumulated in the current epoch"`
+	CosSum float64 `inactive:"+" desc:"sum of Cos over trials"`
+	SSESum float64 `inactive:"+" desc:"sum of SSE over trials"`
+}
+
+// Reset resets the accumulated values
+func (ps *PredStats) Reset() {
+	ps.N = 0
+	ps.CosSum = 0
+	ps.SSESum = 0
+}
+
+// Init resets all the values, including the running averages
+func (ps *PredStats) Init() {
+	ps.PredVals = PredVals{}
+	ps.CosAvg, ps.CosVar = 0, 0
+	ps.SSEAvg, ps.SSEVar = 0, 0
+	for i := range ps.Pools {
+		ps.Pools[i] = PredVals{}
+	}
+	ps.Reset()
+}
+
+// CosEpc returns the average Cos over accumulated trials
+func (ps *PredStats) CosEpc() float64 {
+	if ps.N == 0 {
+		return 0
+	}
+	return ps.CosSum / float64(ps.N)
+}
+
+// SSEEpc returns the average SSE over accumulated trials
+func (ps *PredStats) SSEEpc() float64 {
+	if ps.N == 0 {
+		return 0
+	}
+	return ps.SSESum / float64(ps.N)
+}
+
+// CopyFrom copies the stats from given source, including the Pools
+func (ps *PredStats) CopyFrom(src *PredStats) {
+	pls := ps.Pools
+	*ps = *src
+	ps.Pools = append(pls[:0], src.Pools...)
+}
+
+// String returns a one-line summary of the accumulated stats
+func (ps *PredStats) String() string {
+	return fmt.Sprintf("N: %d\tCos: %.4f\tSSE: %.4f", ps.N, ps.CosEpc(), ps.SSEEpc())
+}
+
+// PredValsFmNeurs computes the prediction values over given range of neurons
+func PredValsFmNeurs(neurs []axon.Neuron) PredVals {
+	var pv PredVals
+	var dot, ssm, ssp float32
+	for ni := range neurs {
+		nrn := &neurs[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		dot += nrn.ActM * nrn.ActP
+		ssm += nrn.ActM * nrn.ActM
+		ssp += nrn.ActP * nrn.ActP
+		d := nrn.ActP - nrn.ActM
+		pv.SSE += d * d
+	}
+	if dist := mat32.Sqrt(ssm * ssp); dist != 0 {
+		pv.Cos = dot / dist
+	}
+	return pv
+}
+
+///////////////////////////////////////////////////////////////////////
+//  TRCLayer methods
+
+// PlusPhase does updating at end of the plus phase, including PredStats
+func (ly *TRCLayer) PlusPhase(ltime *axon.Time) {
+	ly.Layer.PlusPhase(ltime)
+	ly.PredStatsFmActs()
+}
+
+// PredStatsFmActs computes the PredStats for the current trial from the
+// ActM and ActP values, and accumulates them for the epoch.
+// Called at the end of PlusPhase.
+func (ly *TRCLayer) PredStatsFmActs() {
+	ps := &ly.PredStats
+	ps.PredVals = PredValsFmNeurs(ly.Neurons)
+	np := len(ly.Pools) - 1
+	if !ly.Is4D() {
+		np = 0
+	}
+	if len(ps.Pools) != np {
+		ps.Pools = make([]PredVals, np)
+	}
+	for pi := range ps.Pools {
+		pl := &ly.Pools[pi+1]
+		ps.Pools[pi] = PredValsFmNeurs(ly.Neurons[pl.StIdx:pl.EdIdx])
+	}
+	ly.Act.Dt.AvgVarUpdt(&ps.CosAvg, &ps.CosVar, ps.Cos)
+	ly.Act.Dt.AvgVarUpdt(&ps.SSEAvg, &ps.SSEVar, ps.SSE)
+	ps.N++
+	ps.CosSum += float64(ps.Cos)
+	ps.SSESum += float64(ps.SSE)
+}
+
+// PredStatsEpoch saves the current PredStats into EpcPredStats and resets
+// the accumulated values.  Called in deep.Network EpochInc.
+func (ly *TRCLayer) PredStatsEpoch() {
+	ly.EpcPredStats.CopyFrom(&ly.PredStats)
+	ly.PredStats.Reset()
+}
+
+// PredPoolCos returns the Cos for the last trial for each pool of a 4D
+// layer, e.g., for logging as a tensor
+func (ly *TRCLayer) PredPoolCos() []float32 {
+	pc := make([]float32, len(ly.PredStats.Pools))
+	for pi := range ly.PredStats.Pools {
+		pc[pi] = ly.PredStats.Pools[pi].Cos
+	}
+	return pc
+}
+
+// PredPoolSSE returns the SSE for the last trial for each pool of a 4D
+// layer, e.g., for logging as a tensor
+func (ly *TRCLayer) PredPoolSSE() []float32 {
+	pc := make([]float32, len(ly.PredStats.Pools))
+	for pi := range ly.PredStats.Pools {
+		pc[pi] = ly.PredStats.Pools[pi].SSE
+	}
+	return pc
+}
+
+///////////////////////////////////////////////////////////////////////
+//  Network methods
+
+// EpochInc increments the Epoch counter -- see axon.Network EpochInc --
+// and also saves the per-epoch PredStats of the TRC layers
+func (nt *Network) EpochInc() {
+	nt.Network.EpochInc()
+	for _, ly := range nt.Layers {
+		if tly, ok := ly.(*TRCLayer); ok {
+			tly.PredStatsEpoch()
+		}
+	}
+}
+
+// PredStatsReport returns a report of the PredStats of all the TRC layers:
+// for the current epoch so far if cur, else the last completed epoch
+func (nt *Network) PredStatsReport(cur bool) string {
+	str := ""
+	for _, ly := range nt.Layers {
+		tly, ok := ly.(*TRCLayer)
+		if !ok || tly.IsOff() {
+			continue
+		}
+		ps := &tly.EpcPredStats
+		if cur {
+			ps = &tly.PredStats
+		}
+		str += fmt.Sprintf("%s:\t%s\n", tly.Nm, ps.String())
+	}
+	return str
+}
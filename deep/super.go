@@ -15,11 +15,46 @@ import (
 type BurstParams struct {
 	ThrRel float32 `max:"1" def:"0.1,0.2,0.5" desc:"Relative component of threshold on superficial activation value, below which it does not drive Burst (and above which, Burst = Act).  This is the distance between the average and maximum activation values within layer (e.g., 0 = average, 1 = max).  Overall effective threshold is MAX of relative and absolute thresholds."`
 	ThrAbs float32 `min:"0" max:"1" def:"0.1,0.2,0.5" desc:"Absolute component of threshold on superficial activation value, below which it does not drive Burst (and above which, Burst = Act).  Overall effective threshold is MAX of relative and absolute thresholds."`
+
+	Adapt     bool    `desc:"adapt the burst thresholds to maintain a target proportion of bursting units: ThrRel and ThrAbs are both multiplied by the layer BurstThrMult, which is adapted at the end of each burst phase"`
+	TargPct   float32 `viewif:"Adapt" def:"0.1" min:"0" max:"1" desc:"target proportion of units with Burst > 0, as a running average (BurstPctAvg) over burst phases"`
+	AvgTau    float32 `viewif:"Adapt" def:"10" min:"1" desc:"time constant in burst phases (typically trials) for the BurstPctAvg running average"`
+	AdaptRate float32 `viewif:"Adapt" def:"0.05" desc:"rate of BurstThrMult adaptation, as function of AdaptRate * (BurstPctAvg - TargPct) / TargPct"`
+	MaxMult   float32 `viewif:"Adapt" def:"5" min:"1" desc:"maximum BurstThrMult, and 1 / minimum"`
+
+	AvgDt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / AvgTau"`
 }
 
 func (db *BurstParams) Defaults() {
 	db.ThrRel = 0.1
 	db.ThrAbs = 0.1
+	db.Adapt = false
+	db.TargPct = 0.1
+	db.AvgTau = 10
+	db.AdaptRate = 0.05
+	db.MaxMult = 5
+	db.Update()
+}
+
+func (db *BurstParams) Update() {
+	db.AvgDt = 1 / db.AvgTau
+}
+
+// Thr returns the effective burst threshold given layer max and avg
+// activity and the adaptive threshold multiplier
+func (db *BurstParams) Thr(actMax, actAvg, mult float32) float32 {
+	thr := actAvg + mat32.Min(db.ThrRel*mult, 1)*(actMax-actAvg)
+	return mat32.Max(thr, db.ThrAbs*mult)
+}
+
+// AdaptMult adapts the threshold multiplier given the running average
+// proportion of bursting units
+func (db *BurstParams) AdaptMult(mult *float32, pctAvg float32) {
+	if db.TargPct <= 0 {
+		return
+	}
+	*mult += db.AdaptRate * (pctAvg - db.TargPct) / db.TargPct * *mult
+	*mult = mat32.Clamp(*mult, 1/db.MaxMult, db.MaxMult)
 }
 
 // SuperLayer is the DeepAxon superficial layer, based on basic rate-coded axon.Layer.
@@ -28,6 +63,10 @@ type SuperLayer struct {
 	axon.Layer               // access as .Layer
 	Burst      BurstParams   `view:"inline" desc:"parameters for computing Burst from act, in Superficial layers (but also needed in Deep layers for deep self connections)"`
 	SuperNeurs []SuperNeuron `desc:"slice of super neuron values -- same size as Neurons"`
+
+	BurstPct     float32 `inactive:"+" desc:"proportion of units with Burst > 0 at the end of the last burst phase"`
+	BurstPctAvg  float32 `inactive:"+" desc:"running average of BurstPct over burst phases, used for Burst.Adapt"`
+	BurstThrMult float32 `inactive:"+" desc:"adaptive multiplier on Burst.ThrRel and ThrAbs, if Burst.Adapt"`
 }
 
 var KiT_SuperLayer = kit.Types.AddType(&SuperLayer{}, LayerProps)
@@ -38,17 +77,28 @@ func (ly *SuperLayer) Defaults() {
 	ly.Act.Decay.Glong = 0.5
 	ly.Act.Decay.KNa = 0
 	ly.Burst.Defaults()
+	if ly.Is4D() {
+		ly.Inhib.Pool.On = true
+	}
 }
 
 // UpdateParams updates all params given any changes that might have been made to individual values
 // including those in the receiving projections of this layer
 func (ly *SuperLayer) UpdateParams() {
 	ly.Layer.UpdateParams()
+	ly.Burst.Update()
 }
 
 //////////////////////////////////////////////////////////////////////////////////////
 //  Init methods
 
+func (ly *SuperLayer) InitWts() {
+	ly.Layer.InitWts()
+	ly.BurstPct = 0
+	ly.BurstPctAvg = ly.Burst.TargPct
+	ly.BurstThrMult = 1
+}
+
 func (ly *SuperLayer) InitActs() {
 	ly.Layer.InitActs()
 	for ni := range ly.SuperNeurs {
@@ -83,6 +133,12 @@ func (ly *SuperLayer) BurstPrv() {
 	}
 }
 
+// Bursting returns true if given neuron burst (Burst > 0) in the last
+// burst phase, for burst-gated learning (axon.BurstGater interface)
+func (ly *SuperLayer) Bursting(ni int) bool {
+	return ly.SuperNeurs[ni].Burst > 0
+}
+
 // CyclePost calls BurstFmAct
 func (ly *SuperLayer) CyclePost(ltime *axon.Time) {
 	ly.Layer.CyclePost(ltime)
@@ -91,16 +147,19 @@ func (ly *SuperLayer) CyclePost(ltime *axon.Time) {
 
 // BurstFmAct updates Burst layer 5IB bursting value from current Act
 // (superficial activation), subject to thresholding.
-// Updated during Time.PlusPhase
+// Updated during Time.PlusPhase, or the burst windows of Network BurstSched.
 func (ly *SuperLayer) BurstFmAct(ltime *axon.Time) {
-	if !ltime.PlusPhase {
+	if !BurstActive(ly.Network, ltime) {
 		return
 	}
 	lpl := &ly.Pools[0]
 	actMax := lpl.Inhib.Act.Max
 	actAvg := lpl.Inhib.Act.Avg
-	thr := actAvg + ly.Burst.ThrRel*(actMax-actAvg)
-	thr = mat32.Max(thr, ly.Burst.ThrAbs)
+	mult := ly.BurstThrMult
+	if mult == 0 {
+		mult = 1
+	}
+	thr := ly.Burst.Thr(actMax, actAvg, mult)
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
@@ -115,6 +174,33 @@ func (ly *SuperLayer) BurstFmAct(ltime *axon.Time) {
 	}
 }
 
+// BurstAdapt updates BurstPct and BurstPctAvg at the end of a burst phase,
+// and adapts BurstThrMult toward the Burst.TargPct if Burst.Adapt.
+// Called in Network.CTCtxt.
+func (ly *SuperLayer) BurstAdapt() {
+	nb, n := 0, 0
+	for ni := range ly.Neurons {
+		if ly.Neurons[ni].IsOff() {
+			continue
+		}
+		n++
+		if ly.SuperNeurs[ni].Burst > 0 {
+			nb++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	ly.BurstPct = float32(nb) / float32(n)
+	ly.BurstPctAvg += ly.Burst.AvgDt * (ly.BurstPct - ly.BurstPctAvg)
+	if ly.Burst.Adapt {
+		if ly.BurstThrMult == 0 {
+			ly.BurstThrMult = 1
+		}
+		ly.Burst.AdaptMult(&ly.BurstThrMult, ly.BurstPctAvg)
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  DeepCtxt -- once after Burst quarter
 
@@ -161,6 +247,12 @@ func (ly *SuperLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the SuperLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *SuperLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.SuperNeurs, &ly.BurstPct, &ly.BurstPctAvg, &ly.BurstThrMult}
+}
+
 // UnitVarNames returns a list of variable names available on the units in this layer
 func (ly *SuperLayer) UnitVarNames() []string {
 	return NeuronVarsAll
@@ -9,7 +9,9 @@ import (
 	"log"
 
 	"github.com/emer/axon/axon"
+	"github.com/emer/etable/etensor"
 	"github.com/goki/ki/bitflag"
+	"github.com/goki/ki/ints"
 	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
@@ -25,6 +27,10 @@ type TRCParams struct {
 	BinThr       float32 `viewif:"Binarize" desc:"Threshold for binarizing in terms of sending Burst activation"`
 	BinOn        float32 `def:"0.3" viewif:"Binarize" desc:"Resulting driver Ge value for units above threshold -- lower value around 0.3 or so seems best (DriveScale is NOT applied -- generally same range as that)."`
 	BinOff       float32 `def:"0" viewif:"Binarize" desc:"Resulting driver Ge value for units below threshold -- typically 0."`
+	DrvMax       bool    `def:"false" desc:"combine multiple Drivers by taking the maximum of their weighted activations, instead of the weighted average"`
+	DrvPool      bool    `def:"false" desc:"drive each unit from the pooled activity of the corresponding group of driver units, so a smaller TRC layer can be driven by a larger driver layer without an exact shape match -- the geometry is interpolated over the 2D unit grid (pools x units for 4D layers) -- otherwise units correspond one-to-one by index"`
+	PoolAvg      bool    `viewif:"DrvPool" def:"false" desc:"use the average over each group of driver units, instead of the max"`
+	PoolInhib    bool    `def:"true" desc:"for 4D layers, scale the inhibition of the non-driver inputs in each pool by the max driver input to that pool relative to the layer as a whole, so pools without driver input retain their predictions -- otherwise all pools are inhibited according to the layer-level driver activation"`
 }
 
 func (tp *TRCParams) Update() {
@@ -37,6 +43,10 @@ func (tp *TRCParams) Defaults() {
 	tp.BinThr = 0.4
 	tp.BinOn = 0.3
 	tp.BinOff = 0
+	tp.DrvMax = false
+	tp.DrvPool = false
+	tp.PoolAvg = false
+	tp.PoolInhib = true
 }
 
 // DriveGe returns effective excitatory conductance to use for given driver input Burst activation
@@ -72,9 +82,25 @@ func (tp *TRCParams) DriveGe(act float32) float32 {
 type TRCLayer struct {
 	axon.Layer           // access as .Layer
 	TRC        TRCParams `view:"inline" desc:"parameters for computing TRC plus-phase (outcome) activations based on Burst activation from corresponding driver neuron"`
-	Driver     string    `desc:"name of SuperLayer that sends 5IB Burst driver inputs to this layer"`
+	Driver     string    `desc:"name of SuperLayer that sends 5IB Burst driver inputs to this layer -- use Drivers for multiple drivers"`
+
+	Drivers  TRCDrivers `desc:"multiple driver layers with weights, whose activations are combined as a weighted average (or max if TRC.DrvMax) to drive this layer, e.g., for higher-order thalamus representing convergent outcomes from several cortical areas -- if set, Driver is ignored -- see AddDriver"`
+	ExtDrive bool       `inactive:"+" desc:"true if the plus-phase outcome is driven by external input applied with ApplyExt, instead of the Drivers -- set by ApplyExt and cleared by InitExt"`
+	drvActs  []float32  `view:"-" desc:"combined driver activations, per neuron"`
+
+	PredStats    PredStats `inactive:"+" desc:"prediction quality statistics (cosine, SSE between the ActM prediction and ActP outcome), overall and per pool, for the last trial, as running averages, and accumulated over the current epoch -- computed in PlusPhase"`
+	EpcPredStats PredStats `inactive:"+" desc:"prediction quality statistics from the last completed epoch, saved in deep.Network EpochInc"`
 }
 
+// TRCDriver is one driver layer of a TRCLayer, with its weight
+type TRCDriver struct {
+	Layer string  `desc:"name of the driver layer: Burst of a SuperLayer, or Act of any other layer"`
+	Wt    float32 `min:"0" desc:"weight of this driver in the combined driver activation"`
+}
+
+// TRCDrivers are multiple driver layers of a TRCLayer
+type TRCDrivers []TRCDriver
+
 var KiT_TRCLayer = kit.Types.AddType(&TRCLayer{}, LayerProps)
 
 func (ly *TRCLayer) Defaults() {
@@ -84,6 +110,14 @@ func (ly *TRCLayer) Defaults() {
 	ly.Act.Decay.KNa = 0
 	ly.TRC.Defaults()
 	ly.Typ = TRC
+	if ly.Is4D() {
+		ly.Inhib.Pool.On = true
+	}
+	for _, p := range ly.RcvPrjns {
+		if _, ok := p.SendLay().(*TRNLayer); ok {
+			TRNPrjnDefaults(p.(axon.AxonPrjn).AsAxon())
+		}
+	}
 }
 
 // UpdateParams updates all params given any changes that might have been made to individual values
@@ -93,6 +127,19 @@ func (ly *TRCLayer) UpdateParams() {
 	ly.TRC.Update()
 }
 
+// InitWts initializes the weights and the PredStats
+func (ly *TRCLayer) InitWts() {
+	ly.Layer.InitWts()
+	ly.PredStats.Init()
+	ly.EpcPredStats.Init()
+}
+
+// CheckpointVals returns the TRCLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *TRCLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.ExtDrive, &ly.PredStats, &ly.EpcPredStats}
+}
+
 func (ly *TRCLayer) Class() string {
 	return "TRC " + ly.Cls
 }
@@ -104,6 +151,19 @@ func (ly *TRCLayer) IsTarget() bool {
 ///////////////////////////////////////////////////////////////////////////////////////
 // Drivers
 
+// AddDriver adds a driver layer of given name with given weight to Drivers
+func (ly *TRCLayer) AddDriver(name string, wt float32) {
+	ly.Drivers = append(ly.Drivers, TRCDriver{Layer: name, Wt: wt})
+}
+
+// DriverList returns the Drivers if set, else the single Driver with weight 1
+func (ly *TRCLayer) DriverList() TRCDrivers {
+	if len(ly.Drivers) > 0 {
+		return ly.Drivers
+	}
+	return TRCDrivers{{Layer: ly.Driver, Wt: 1}}
+}
+
 // DriverLayer returns the driver layer for given Driver
 func (ly *TRCLayer) DriverLayer(drv string) (*axon.Layer, error) {
 	tly, err := ly.Network.LayerByNameTry(drv)
@@ -129,6 +189,103 @@ func DriveAct(dni int, dly *axon.Layer, sly *SuperLayer, issuper bool) float32 {
 	return act
 }
 
+// DriverNeurIdx returns the index of the driver layer neuron corresponding
+// to given TRC neuron index, for the one-to-one mapping (if not TRC.DrvPool),
+// or -1 if there is none.  If both layers are 4D, the pools are aligned,
+// with the driver pool geometry interpolated if it differs, and units
+// within each pool correspond by their Y, X position.  For a 4D TRC layer
+// with a 2D driver, the driver is replicated for each pool.
+// Otherwise, neurons correspond by index.
+func (ly *TRCLayer) DriverNeurIdx(tni int, dly *axon.Layer) int {
+	switch {
+	case ly.Is4D() && dly.Is4D():
+		tnuy, tnux := ly.Shp.Dim(2), ly.Shp.Dim(3)
+		dnuy, dnux := dly.Shp.Dim(2), dly.Shp.Dim(3)
+		pi, ui := tni/(tnuy*tnux), tni%(tnuy*tnux)
+		uy, ux := ui/tnux, ui%tnux
+		if uy >= dnuy || ux >= dnux {
+			return -1
+		}
+		py := (pi / ly.Shp.Dim(1)) * dly.Shp.Dim(0) / ly.Shp.Dim(0)
+		px := (pi % ly.Shp.Dim(1)) * dly.Shp.Dim(1) / ly.Shp.Dim(1)
+		dpi := py*dly.Shp.Dim(1) + px
+		return dpi*dnuy*dnux + uy*dnux + ux
+	case ly.Is4D():
+		ui := tni % (ly.Shp.Dim(2) * ly.Shp.Dim(3))
+		if ui >= len(dly.Neurons) {
+			return -1
+		}
+		return ui
+	}
+	if tni >= len(dly.Neurons) {
+		return -1
+	}
+	return tni
+}
+
+// PoolDriveAct returns the driver activation for given TRC neuron index
+// pooled (max or avg per TRC.PoolAvg) over the corresponding group of
+// driver layer units, mapping the 2D unit grids of the two layers
+func (ly *TRCLayer) PoolDriveAct(tni int, dly *axon.Layer, sly *SuperLayer, issuper bool) float32 {
+	ty, tx := Grid2DShape(&ly.Layer)
+	dy, dx := Grid2DShape(dly)
+	y, x := Grid2DIdx(&ly.Layer, tni)
+	sty, edy := y*dy/ty, ints.MaxInt((y+1)*dy/ty, y*dy/ty+1)
+	stx, edx := x*dx/tx, ints.MaxInt((x+1)*dx/tx, x*dx/tx+1)
+	var max, sum float32
+	n := 0
+	for yi := sty; yi < edy && yi < dy; yi++ {
+		for xi := stx; xi < edx && xi < dx; xi++ {
+			act := DriveAct(Grid2DNeurIdx(dly, yi, xi), dly, sly, issuper)
+			max = mat32.Max(max, act)
+			sum += act
+			n++
+		}
+	}
+	if ly.TRC.PoolAvg {
+		if n == 0 {
+			return 0
+		}
+		return sum / float32(n)
+	}
+	return max
+}
+
+// Grid2DShape returns the overall Y, X size of the 2D unit grid of given
+// layer: for 4D layers, pools x units in each dimension
+func Grid2DShape(ly *axon.Layer) (ny, nx int) {
+	if ly.Shp.NumDims() == 4 {
+		return ly.Shp.Dim(0) * ly.Shp.Dim(2), ly.Shp.Dim(1) * ly.Shp.Dim(3)
+	}
+	return ly.Shp.Dim(0), ly.Shp.Len() / ly.Shp.Dim(0)
+}
+
+// Grid2DIdx returns the Y, X coordinates in the 2D unit grid of given
+// layer (see Grid2DShape) for given neuron index
+func Grid2DIdx(ly *axon.Layer, ni int) (y, x int) {
+	if ly.Shp.NumDims() == 4 {
+		nuy, nux := ly.Shp.Dim(2), ly.Shp.Dim(3)
+		pi := ni / (nuy * nux)
+		ui := ni % (nuy * nux)
+		py, px := pi/ly.Shp.Dim(1), pi%ly.Shp.Dim(1)
+		return py*nuy + ui/nux, px*nux + ui%nux
+	}
+	_, nx := Grid2DShape(ly)
+	return ni / nx, ni % nx
+}
+
+// Grid2DNeurIdx returns the neuron index for given Y, X coordinates in
+// the 2D unit grid of given layer (see Grid2DShape)
+func Grid2DNeurIdx(ly *axon.Layer, y, x int) int {
+	if ly.Shp.NumDims() == 4 {
+		nuy, nux := ly.Shp.Dim(2), ly.Shp.Dim(3)
+		pi := (y/nuy)*ly.Shp.Dim(1) + x/nux
+		return pi*nuy*nux + (y%nuy)*nux + x%nux
+	}
+	_, nx := Grid2DShape(ly)
+	return y*nx + x
+}
+
 // GeFmDriverNeuron sets the driver activation for given Neuron,
 // based on given Ge driving value (use DriveFmMaxAvg) from driver layer (Burst or Act)
 func (ly *TRCLayer) GeFmDriverNeuron(tni int, drvGe, drvInhib float32, cyc int) {
@@ -142,8 +299,7 @@ func (ly *TRCLayer) GeFmDriverNeuron(tni int, drvGe, drvInhib float32, cyc int)
 	actm := nrn.ActM
 	geRaw := (1-drvInhib)*nrn.GeRaw + drvGe
 	nrn.ClearFlag(axon.NeurHasExt)
-	nrn.NMDA = ly.Act.NMDA.NMDA(nrn.NMDA, geRaw, nrn.NMDASyn)
-	nrn.Gnmda = ly.Act.NMDA.Gnmda(nrn.NMDA, nrn.VmDend)
+	ly.Act.NMDAFmRaw(nrn, geRaw)
 	// note: GABAB integrated in ActFmG one timestep behind, b/c depends on integrated Gi inhib
 
 	// note: excluding gnmda during driving phase -- probably could exclude always due to ge context?
@@ -155,29 +311,119 @@ func (ly *TRCLayer) GeFmDriverNeuron(tni int, drvGe, drvInhib float32, cyc int)
 	nrn.GiRaw = 0
 }
 
-// GeFmDrivers computes excitatory conductance from driver neurons
+// GeFmDrivers computes excitatory conductance from driver neurons,
+// combining the activations of multiple Drivers as their weighted average,
+// or weighted max if TRC.DrvMax
 func (ly *TRCLayer) GeFmDrivers(ltime *axon.Time) {
 	cyc := ltime.Cycle // for bursting
 	if ly.IsTarget() {
 		cyc = ltime.PhaseCycle
 	}
-	dly, err := ly.DriverLayer(ly.Driver)
-	if err != nil {
+	if ly.ExtDrive {
+		ly.GeFmExt(cyc)
+		return
+	}
+	nn := len(ly.Neurons)
+	if len(ly.drvActs) != nn {
+		ly.drvActs = make([]float32, nn)
+	}
+	for ni := range ly.drvActs {
+		ly.drvActs[ni] = 0
+	}
+	dmax := ly.TRC.DrvMax
+	var drvMax, wtSum float32
+	for _, drv := range ly.DriverList() {
+		dly, err := ly.DriverLayer(drv.Layer)
+		if err != nil {
+			continue
+		}
+		sly, issuper := dly.AxonLay.(*SuperLayer)
+		for tni := 0; tni < nn; tni++ {
+			var act float32
+			if ly.TRC.DrvPool {
+				act = drv.Wt * ly.PoolDriveAct(tni, dly, sly, issuper)
+			} else {
+				dni := ly.DriverNeurIdx(tni, dly)
+				if dni < 0 {
+					continue
+				}
+				act = drv.Wt * DriveAct(dni, dly, sly, issuper)
+			}
+			if dmax {
+				ly.drvActs[tni] = mat32.Max(ly.drvActs[tni], act)
+			} else {
+				ly.drvActs[tni] += act
+			}
+		}
+		lmax := drv.Wt * dly.Pools[0].Inhib.Act.Max
+		if dmax {
+			drvMax = mat32.Max(drvMax, lmax)
+		} else {
+			drvMax += lmax
+		}
+		wtSum += drv.Wt
+	}
+	if wtSum == 0 {
 		return
 	}
-	sly, issuper := dly.AxonLay.(*SuperLayer)
-	drvMax := dly.Pools[0].Inhib.Act.Max
+	norm := float32(1)
+	if !dmax {
+		norm = 1 / wtSum
+	}
+	drvInhib := mat32.Min(1, norm*drvMax/ly.TRC.FullDriveAct)
+	ly.GeFmDrvActs(norm, drvInhib, cyc)
+}
+
+// GeFmExt computes excitatory conductance from the external input values
+// applied with ApplyExt (stored in Targ), in place of the driver activations
+func (ly *TRCLayer) GeFmExt(cyc int) {
+	nn := len(ly.Neurons)
+	if len(ly.drvActs) != nn {
+		ly.drvActs = make([]float32, nn)
+	}
+	var drvMax float32
+	for ni := range ly.Neurons {
+		ly.drvActs[ni] = ly.Neurons[ni].Targ
+		drvMax = mat32.Max(drvMax, ly.drvActs[ni])
+	}
 	drvInhib := mat32.Min(1, drvMax/ly.TRC.FullDriveAct)
-	for dni := range dly.Neurons {
-		drvAct := DriveAct(dni, dly, sly, issuper)
-		ly.GeFmDriverNeuron(dni, ly.TRC.DriveGe(drvAct), drvInhib, cyc)
+	ly.GeFmDrvActs(1, drvInhib, cyc)
+}
+
+// GeFmDrvActs computes excitatory conductance for all neurons from the
+// combined driver activations (drvActs) times norm, with given inhibition
+// of the non-driver inputs, which is scaled per pool if TRC.PoolInhib
+func (ly *TRCLayer) GeFmDrvActs(norm, drvInhib float32, cyc int) {
+	if !ly.Is4D() || !ly.TRC.PoolInhib {
+		for ni := range ly.Neurons {
+			ly.GeFmDriverNeuron(ni, ly.TRC.DriveGe(norm*ly.drvActs[ni]), drvInhib, cyc)
+		}
+		return
+	}
+	var lmax float32
+	for _, act := range ly.drvActs {
+		lmax = mat32.Max(lmax, act)
+	}
+	for pi := 1; pi < len(ly.Pools); pi++ {
+		pl := &ly.Pools[pi]
+		var pmax float32
+		for ni := pl.StIdx; ni < pl.EdIdx; ni++ {
+			pmax = mat32.Max(pmax, ly.drvActs[ni])
+		}
+		pinhib := float32(0)
+		if lmax > 0 {
+			pinhib = drvInhib * pmax / lmax
+		}
+		for ni := pl.StIdx; ni < pl.EdIdx; ni++ {
+			ly.GeFmDriverNeuron(ni, ly.TRC.DriveGe(norm*ly.drvActs[ni]), pinhib, cyc)
+		}
 	}
 }
 
 // GFmInc integrates new synaptic conductances from increments sent during last SendGDelta.
 func (ly *TRCLayer) GFmInc(ltime *axon.Time) {
 	ly.RecvGInc(ltime)
-	if ly.TRC.DriversOff || !ltime.PlusPhase {
+	if (ly.TRC.DriversOff && !ly.ExtDrive) || !ltime.PlusPhase {
 		ly.GFmIncNeur(ltime) // regular
 		return
 	}
@@ -197,4 +443,47 @@ func (ly *TRCLayer) InitExt() {
 			nrn.SetFlag(axon.NeurHasTarg)
 		}
 	}
+	ly.ExtDrive = false
+}
+
+// ApplyExt applies external input in the form of an etensor.Tensor, which
+// drives the plus-phase outcome directly, instead of the Drivers, e.g., to
+// train predictions against raw next-frame sensory data without modeling
+// the driving cortical layer.  The values are stored in Targ, scaled by
+// TRC.DriveScale as for driver activations (or Binarize), and have no
+// effect in the minus phase.  InitExt must be called before each trial
+// to clear them, reverting to the Drivers if not applied again.
+func (ly *TRCLayer) ApplyExt(ext etensor.Tensor) {
+	ly.Layer.ApplyExt(ext)
+	ly.ExtToDrive()
+}
+
+// ApplyExt1D applies external input in the form of a flat 1-dimensional
+// slice of floats, driving the plus-phase outcome -- see ApplyExt
+func (ly *TRCLayer) ApplyExt1D(ext []float64) {
+	ly.Layer.ApplyExt1D(ext)
+	ly.ExtToDrive()
+}
+
+// ApplyExt1D32 applies external input in the form of a flat 1-dimensional
+// slice of float32s, driving the plus-phase outcome -- see ApplyExt
+func (ly *TRCLayer) ApplyExt1D32(ext []float32) {
+	ly.Layer.ApplyExt1D32(ext)
+	ly.ExtToDrive()
+}
+
+// ExtToDrive moves the Ext input values set by the ApplyExt methods into
+// Targ, where they drive the plus-phase outcome, and sets ExtDrive
+func (ly *TRCLayer) ExtToDrive() {
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if !nrn.HasFlag(axon.NeurHasExt) {
+			continue
+		}
+		nrn.Targ = nrn.Ext
+		nrn.Ext = 0
+		nrn.ClearFlag(axon.NeurHasExt)
+		nrn.SetFlag(axon.NeurHasTarg)
+		ly.ExtDrive = true
+	}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/emer/emergent/emer"
 	"github.com/goki/ki/ints"
 	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
 )
 
 // TRNLayer copies inhibition from pools in CT and TRC layers, and from other
@@ -22,6 +23,20 @@ var KiT_TRNLayer = kit.Types.AddType(&TRNLayer{}, axon.LayerProps)
 
 func (ly *TRNLayer) Defaults() {
 	ly.Layer.Defaults()
+	for _, p := range ly.RcvPrjns {
+		TRNPrjnDefaults(p.(axon.AxonPrjn).AsAxon())
+	}
+}
+
+// TRNPrjnDefaults sets the defaults for the projections into and out of a
+// TRNLayer (see AddTRN): fixed, non-learning topographic weights with
+// SWt.Init Mean = 0.8, Var = 0, so the CT prediction is copied directly into
+// TRN, and TRN inhibition is specific to the predicted TRC units.
+func TRNPrjnDefaults(pj *axon.Prjn) {
+	pj.Learn.Learn = false
+	pj.SWt.Adapt.On = false
+	pj.SWt.Init.Mean = 0.8
+	pj.SWt.Init.Var = 0
 }
 
 // InitActs fully initializes activation state -- only called automatically during InitWts
@@ -36,6 +51,7 @@ func (ly *TRNLayer) InitActs() {
 type SendAttnParams struct {
 	Thr    float32       `desc:"threshold on layer-wide max activation (or average act for pooled 4D layers) for sending attention (below this, sends attn = 1)"`
 	ToLays emer.LayNames `desc:"list of layers to send attentional modulation to"`
+	ErrLay string        `desc:"if set, name of a TRCLayer whose prediction error (abs ActP - ActM from the last plus phase, averaged over pools for 4D layers) drives attention instead of this layer's activation, so more attention goes to areas with larger prediction errors -- must have the same geometry as this layer"`
 }
 
 func (ti *SendAttnParams) Defaults() {
@@ -46,6 +62,11 @@ func (ti *SendAttnParams) Update() {
 }
 
 // TRCALayer is the thalamic relay cell layer for Attention in DeepAxon.
+// It computes an attention signal in [0..1] from its activation (or the
+// prediction error of a TRCLayer, per SendAttn.ErrLay), per pool for 4D
+// layers and per unit for 2D layers, normalized by the max, and sends it
+// to the Attn value of the SendAttn.ToLays layers (e.g., SuperLayers),
+// which multiplicatively modulates their excitatory input if Act.Attn.On.
 type TRCALayer struct {
 	axon.Layer                // access as .Layer
 	SendAttn   SendAttnParams `view:"inline" desc:"sending attention parameters"`
@@ -86,18 +107,70 @@ func (ly *TRCALayer) CyclePost(ltime *axon.Time) {
 	ly.SendAttnLays(ltime)
 }
 
-// AttnFmAct computes our attention signal from activations
+// ErrLayer returns the SendAttn.ErrLay layer, or nil if not set
+func (ly *TRCALayer) ErrLayer() *axon.Layer {
+	if ly.SendAttn.ErrLay == "" {
+		return nil
+	}
+	el, err := ly.Network.LayerByNameTry(ly.SendAttn.ErrLay)
+	if err != nil {
+		return nil
+	}
+	return el.(axon.AxonLayer).AsAxon()
+}
+
+// AttnPoolVal returns the value driving attention for given pool index
+// (0-based, excluding the layer-level pool): average activation, or the
+// average prediction error of the ErrLay layer if non-nil
+func (ly *TRCALayer) AttnPoolVal(pi int, elay *axon.Layer) float32 {
+	if elay == nil {
+		return ly.Pools[pi+1].Inhib.Act.Avg
+	}
+	if pi+1 >= len(elay.Pools) {
+		return 0
+	}
+	pl := &elay.Pools[pi+1]
+	var sum float32
+	n := 0
+	for ni := pl.StIdx; ni < pl.EdIdx; ni++ {
+		nrn := &elay.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		sum += mat32.Abs(nrn.ActDif)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float32(n)
+}
+
+// AttnUnitVal returns the value driving attention for given neuron index:
+// activation, or the prediction error of the ErrLay layer if non-nil
+func (ly *TRCALayer) AttnUnitVal(ni int, elay *axon.Layer) float32 {
+	if elay == nil {
+		return ly.Neurons[ni].Act
+	}
+	if ni >= len(elay.Neurons) {
+		return 0
+	}
+	return mat32.Abs(elay.Neurons[ni].ActDif)
+}
+
+// AttnFmAct computes our attention signal from activations,
+// or the prediction error of SendAttn.ErrLay if set
 func (ly *TRCALayer) AttnFmAct(ltime *axon.Time) {
 	pyn := ly.Shp.Dim(0)
 	pxn := ly.Shp.Dim(1)
+	elay := ly.ErrLayer()
 
 	if ly.Is4D() {
 		var amax float32
 		for py := 0; py < pyn; py++ {
 			for px := 0; px < pxn; px++ {
 				pi := py*pxn + px
-				pl := &ly.Pools[pi+1]
-				act := pl.Inhib.Act.Avg
+				act := ly.AttnPoolVal(pi, elay)
 				if act > amax {
 					amax = act
 				}
@@ -107,7 +180,7 @@ func (ly *TRCALayer) AttnFmAct(ltime *axon.Time) {
 			for px := 0; px < pxn; px++ {
 				pi := py*pxn + px
 				pl := &ly.Pools[pi+1]
-				act := pl.Inhib.Act.Avg
+				act := ly.AttnPoolVal(pi, elay)
 				attn := float32(1)
 				if amax >= ly.SendAttn.Thr {
 					attn = act / amax
@@ -121,11 +194,17 @@ func (ly *TRCALayer) AttnFmAct(ltime *axon.Time) {
 	} else { // 2D
 		lpl := &ly.Pools[0]
 		amax := lpl.Inhib.Act.Max
+		if elay != nil {
+			amax = 0
+			for ni := range ly.Neurons {
+				amax = mat32.Max(amax, ly.AttnUnitVal(ni, elay))
+			}
+		}
 		for py := 0; py < pyn; py++ {
 			for px := 0; px < pxn; px++ {
 				ni := py*pxn + px
 				nrn := &ly.Neurons[ni]
-				act := nrn.Act
+				act := ly.AttnUnitVal(ni, elay)
 				attn := float32(1)
 				if amax >= ly.SendAttn.Thr {
 					attn = act / amax
@@ -0,0 +1,80 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package dwtsync supports data-parallel training of axon networks across
+multiple processes, by averaging the DWt weight changes across all
+processes before WtFmDWt, so that every process applies the same weight
+changes and the weights stay identical.  The activation averages and
+GScale values shared by Network.CollectDWts are synchronized as well,
+so TrgAvg and synaptic scaling also match.  The sum is computed by an
+AllReducer, which can use MPI (MPIReducer) or plain TCP connections
+for simple clusters without MPI (TCPReducer).
+
+	sy := dwtsync.NewSyncer(net, red)
+	...
+	net.DWt()
+	sy.Sync() // average DWt across all processes
+	net.WtFmDWt()
+*/
+package dwtsync
+
+import (
+	"github.com/emer/axon/axon"
+)
+
+// AllReducer sums float32 values across all processes, so that every
+// process receives the same total
+type AllReducer interface {
+	// AllReduceSum sets dst to the element-wise sum of src across all
+	// processes -- dst and src must have the same length on all processes
+	AllReduceSum(dst, src []float32) error
+
+	// Size returns the number of processes
+	Size() int
+}
+
+// Syncer averages DWt values across processes using an AllReducer,
+// along with the other learning-related state shared by
+// Network.CollectDWts: layer and neuron ActAvg, DTrgAvg and GScale.
+// All processes must have the same network structure.
+type Syncer struct {
+	Net     *axon.Network `desc:"the network"`
+	Reducer AllReducer    `desc:"the all-reduce implementation used to sum across processes"`
+	Sum     bool          `desc:"sum the DWt and DTrgAvg values across processes instead of averaging -- averaging keeps the effective learning rate independent of the number of processes.  Activation averages and GScale are always averaged."`
+
+	buf []float32 // local values from CollectDWts
+	tot []float32 // summed values
+}
+
+// NewSyncer returns a new Syncer for given network and reducer
+func NewSyncer(net *axon.Network, red AllReducer) *Syncer {
+	return &Syncer{Net: net, Reducer: red}
+}
+
+// Sync averages (or sums, if Sum) the DWt values of all projections across
+// all processes, using Network.CollectDWts and SetDWts -- call after DWt
+// and before WtFmDWt
+func (sy *Syncer) Sync() error {
+	if sy.Net.CollectDWts(&sy.buf) {
+		sy.tot = make([]float32, len(sy.buf))
+	}
+	if err := sy.Reducer.AllReduceSum(sy.tot, sy.buf); err != nil {
+		return err
+	}
+	nproc := sy.Reducer.Size()
+	if nproc < 1 {
+		nproc = 1
+	}
+	if sy.Sum {
+		sy.Net.SetDWts(sy.tot, nproc) // averages the activation stats, sums the rest
+		return nil
+	}
+	scale := 1 / float32(nproc)
+	for i := range sy.tot {
+		sy.tot[i] *= scale
+	}
+	sy.Net.SetDWts(sy.tot, 1)
+	return nil
+}
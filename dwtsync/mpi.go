@@ -0,0 +1,28 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwtsync
+
+import (
+	"github.com/emer/empi/mpi"
+)
+
+// MPIReducer is an AllReducer using an MPI communicator -- requires
+// building with the mpi tag to actually use MPI (see empi)
+type MPIReducer struct {
+	Comm *mpi.Comm `desc:"the MPI communicator"`
+}
+
+// NewMPIReducer returns a new MPIReducer for given communicator
+func NewMPIReducer(comm *mpi.Comm) *MPIReducer {
+	return &MPIReducer{Comm: comm}
+}
+
+func (mr *MPIReducer) AllReduceSum(dst, src []float32) error {
+	return mr.Comm.AllReduceF32(mpi.OpSum, dst, src)
+}
+
+func (mr *MPIReducer) Size() int {
+	return mr.Comm.Size()
+}
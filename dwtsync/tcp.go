@@ -0,0 +1,154 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwtsync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// TCPReducer is an AllReducer using plain TCP connections, for simple
+// clusters without MPI.  Process rank 0 is the hub: it accepts
+// connections from all other ranks, sums their values with its own,
+// and sends the total back to each of them.  This is simple and adequate
+// for a modest number of processes.
+type TCPReducer struct {
+	Rank  int `desc:"rank of this process -- 0 is the hub"`
+	NProc int `desc:"total number of processes"`
+
+	ln    net.Listener // hub listener
+	conns []*tcpConn   // hub: connections to ranks 1..NProc-1, client: connection to hub
+	rbuf  []float32    // receive buffer
+}
+
+type tcpConn struct {
+	c  net.Conn
+	rd *bufio.Reader
+	wr *bufio.Writer
+}
+
+// NewTCPHub returns the TCPReducer for rank 0, listening on given address
+// (e.g., ":7070") and waiting for the other nproc-1 processes to connect
+func NewTCPHub(addr string, nproc int) (*TCPReducer, error) {
+	tr := &TCPReducer{Rank: 0, NProc: nproc}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	tr.ln = ln
+	tr.conns = make([]*tcpConn, nproc-1)
+	for i := 0; i < nproc-1; i++ {
+		c, err := ln.Accept()
+		if err != nil {
+			tr.Close()
+			return nil, err
+		}
+		tc := newTCPConn(c)
+		var rank uint32
+		if err := binary.Read(tc.rd, binary.LittleEndian, &rank); err != nil {
+			tr.Close()
+			return nil, err
+		}
+		if rank < 1 || int(rank) >= nproc || tr.conns[rank-1] != nil {
+			c.Close()
+			tr.Close()
+			return nil, fmt.Errorf("dwtsync.NewTCPHub: invalid or duplicate rank: %d", rank)
+		}
+		tr.conns[rank-1] = tc
+	}
+	return tr, nil
+}
+
+// NewTCPClient returns the TCPReducer for given rank > 0, connecting to
+// the hub at given address (e.g., "host0:7070")
+func NewTCPClient(addr string, rank, nproc int) (*TCPReducer, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	tc := newTCPConn(c)
+	if err := binary.Write(tc.wr, binary.LittleEndian, uint32(rank)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := tc.wr.Flush(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &TCPReducer{Rank: rank, NProc: nproc, conns: []*tcpConn{tc}}, nil
+}
+
+func newTCPConn(c net.Conn) *tcpConn {
+	return &tcpConn{c: c, rd: bufio.NewReader(c), wr: bufio.NewWriter(c)}
+}
+
+// send writes the length and values
+func (tc *tcpConn) send(vals []float32) error {
+	if err := binary.Write(tc.wr, binary.LittleEndian, uint32(len(vals))); err != nil {
+		return err
+	}
+	if err := binary.Write(tc.wr, binary.LittleEndian, vals); err != nil {
+		return err
+	}
+	return tc.wr.Flush()
+}
+
+// recv reads values into given buffer, which must have the sent length
+func (tc *tcpConn) recv(vals []float32) error {
+	var n uint32
+	if err := binary.Read(tc.rd, binary.LittleEndian, &n); err != nil {
+		return err
+	}
+	if int(n) != len(vals) {
+		return fmt.Errorf("dwtsync.TCPReducer: received length: %d != expected: %d", n, len(vals))
+	}
+	return binary.Read(tc.rd, binary.LittleEndian, vals)
+}
+
+func (tr *TCPReducer) AllReduceSum(dst, src []float32) error {
+	if tr.Rank != 0 {
+		tc := tr.conns[0]
+		if err := tc.send(src); err != nil {
+			return err
+		}
+		return tc.recv(dst)
+	}
+	copy(dst, src)
+	if len(tr.rbuf) != len(src) {
+		tr.rbuf = make([]float32, len(src))
+	}
+	for _, tc := range tr.conns {
+		if err := tc.recv(tr.rbuf); err != nil {
+			return err
+		}
+		for i, v := range tr.rbuf {
+			dst[i] += v
+		}
+	}
+	for _, tc := range tr.conns {
+		if err := tc.send(dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *TCPReducer) Size() int {
+	return tr.NProc
+}
+
+// Close closes all connections
+func (tr *TCPReducer) Close() {
+	for _, tc := range tr.conns {
+		if tc != nil {
+			tc.c.Close()
+		}
+	}
+	if tr.ln != nil {
+		tr.ln.Close()
+	}
+}
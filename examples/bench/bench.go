@@ -33,6 +33,9 @@ var EpcLog *etable.Table
 var Thread = false // much slower for small net
 var Silent = false // non-verbose mode -- just reports result
 
+// Profile turns on per-layer function profiling, saved to bench_prof.csv
+var Profile = false
+
 var ParamSets = params.Sets{
 	{Name: "Base", Desc: "these are the best params", Sheets: params.Sheets{
 		"Network": &params.Sheet{
@@ -241,6 +244,7 @@ func main() {
 	flag.IntVar(&pats, "pats", 10, "number of patterns per epoch")
 	flag.IntVar(&units, "units", 100, "number of units per layer -- uses NxN where N = sqrt(units)")
 	flag.BoolVar(&Silent, "silent", false, "only report the final time")
+	flag.BoolVar(&Profile, "profile", false, "record per-layer function timing, saved to bench_prof.csv")
 	flag.Parse()
 
 	if !Silent {
@@ -249,6 +253,9 @@ func main() {
 
 	Net = &axon.Network{}
 	ConfigNet(Net, threads, units)
+	if Profile {
+		Net.SetProfile(true, false)
+	}
 
 	Pats = &etable.Table{}
 	ConfigPats(Pats, pats, units)
@@ -259,4 +266,7 @@ func main() {
 	TrainNet(Net, Pats, EpcLog, epochs)
 
 	EpcLog.SaveCSV("bench_epc.dat", ',', etable.Headers)
+	if Profile {
+		Net.SaveProfileCSV("bench_prof.csv")
+	}
 }
@@ -20,12 +20,9 @@ import (
 	"github.com/emer/axon/hip"
 	"github.com/emer/emergent/emer"
 	"github.com/emer/emergent/env"
-	"github.com/emer/emergent/evec"
 	"github.com/emer/emergent/netview"
 	"github.com/emer/emergent/params"
 	"github.com/emer/emergent/patgen"
-	"github.com/emer/emergent/prjn"
-	"github.com/emer/emergent/relpos"
 	"github.com/emer/etable/agg"
 	"github.com/emer/etable/eplot"
 	"github.com/emer/etable/etable"
@@ -68,27 +65,6 @@ const LogPrec = 4
 
 // see bottom of file for multi-factor testing params
 
-// HipParams have the hippocampus size and connectivity parameters
-type HipParams struct {
-	ECSize       evec.Vec2i `desc:"size of EC in terms of overall pools (outer dimension)"`
-	ECPool       evec.Vec2i `desc:"size of one EC pool"`
-	CA1Pool      evec.Vec2i `desc:"size of one CA1 pool"`
-	CA3Size      evec.Vec2i `desc:"size of CA3"`
-	DGRatio      float32    `desc:"size of DG / CA3"`
-	DGSize       evec.Vec2i `inactive:"+" desc:"size of DG"`
-	DGPCon       float32    `desc:"percent connectivity into DG"`
-	CA3PCon      float32    `desc:"percent connectivity into CA3"`
-	MossyPCon    float32    `desc:"percent connectivity into CA3 from DG"`
-	ECPctAct     float32    `desc:"percent activation in EC pool"`
-	MossyDel     float32    `desc:"delta in mossy effective strength between minus and plus phase"`
-	MossyDelTest float32    `desc:"delta in mossy strength for testing (relative to base param)"`
-}
-
-func (hp *HipParams) Update() {
-	hp.DGSize.X = int(float32(hp.CA3Size.X) * hp.DGRatio)
-	hp.DGSize.Y = int(float32(hp.CA3Size.Y) * hp.DGRatio)
-}
-
 // PatParams have the pattern parameters
 type PatParams struct {
 	ListSize    int     `desc:"number of A-B, A-C patterns each"`
@@ -105,7 +81,8 @@ type PatParams struct {
 // for the fields which provide hints to how things should be displayed).
 type Sim struct {
 	Net          *axon.Network            `view:"no-inline"`
-	Hip          HipParams                `desc:"hippocampus sizing parameters"`
+	Hip          hip.HipParams            `desc:"hippocampus sizing parameters"`
+	Theta        hip.ThetaPhase           `view:"-" desc:"theta phase switching of the hippocampal projections"`
 	Pat          PatParams                `desc:"parameters for the input patterns"`
 	PoolVocab    patgen.Vocab             `view:"no-inline" desc:"pool patterns vocabulary"`
 	TrainAB      *etable.Table            `view:"no-inline" desc:"AB training patterns to use"`
@@ -249,24 +226,6 @@ func (pp *PatParams) Defaults() {
 	pp.CtxtFlipPct = .25
 }
 
-func (hp *HipParams) Defaults() {
-	// size
-	hp.ECSize.Set(2, 3)
-	hp.ECPool.Set(7, 7)
-	hp.CA1Pool.Set(15, 15) // using MedHip now
-	hp.CA3Size.Set(30, 30) // using MedHip now
-	hp.DGRatio = 2.236     // c.f. Ketz et al., 2013
-
-	// ratio
-	hp.DGPCon = 0.25 // .35 is sig worse, .2 learns faster but AB recall is worse
-	hp.CA3PCon = 0.25
-	hp.MossyPCon = 0.02 // .02 > .05 > .01 (for small net)
-	hp.ECPctAct = 0.2
-
-	hp.MossyDel = 3     // 4 > 2 -- best is 4 del on 4 rel baseline
-	hp.MossyDelTest = 0 // for rel = 4: 3 > 2 > 0 > 4 -- 4 is very bad -- need a small amount..
-}
-
 func (ss *Sim) Defaults() {
 	ss.Hip.Defaults()
 	ss.Pat.Defaults()
@@ -336,82 +295,7 @@ func (ss *Sim) ConfigNet(net *axon.Network) {
 	net.InitName(net, "Hip_bench")
 	hp := &ss.Hip
 	in := net.AddLayer4D("Input", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Input)
-	ecin := net.AddLayer4D("ECin", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Hidden)
-	ecout := net.AddLayer4D("ECout", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Target) // clamped in plus phase
-	ca1 := net.AddLayer4D("CA1", hp.ECSize.Y, hp.ECSize.X, hp.CA1Pool.Y, hp.CA1Pool.X, emer.Hidden)
-	dg := net.AddLayer2D("DG", hp.DGSize.Y, hp.DGSize.X, emer.Hidden)
-	ca3 := net.AddLayer2D("CA3", hp.CA3Size.Y, hp.CA3Size.X, emer.Hidden)
-
-	ecin.SetClass("EC")
-	ecout.SetClass("EC")
-
-	ecin.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "Input", YAlign: relpos.Front, Space: 2})
-	ecout.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "ECin", YAlign: relpos.Front, Space: 2})
-	dg.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "Input", YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
-	ca3.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "DG", YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
-	ca1.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "CA3", YAlign: relpos.Front, Space: 2})
-
-	onetoone := prjn.NewOneToOne()
-	pool1to1 := prjn.NewPoolOneToOne()
-	full := prjn.NewFull()
-
-	net.ConnectLayers(in, ecin, onetoone, emer.Forward)
-	net.ConnectLayers(ecout, ecin, onetoone, emer.Back)
-
-	// EC <-> CA1 encoder pathways
-	if false { // false = actually works better to use the regular projections here
-		pj := net.ConnectLayersPrjn(ecin, ca1, pool1to1, emer.Forward, &hip.EcCa1Prjn{})
-		pj.SetClass("EcCa1Prjn")
-		pj = net.ConnectLayersPrjn(ca1, ecout, pool1to1, emer.Forward, &hip.EcCa1Prjn{})
-		pj.SetClass("EcCa1Prjn")
-		pj = net.ConnectLayersPrjn(ecout, ca1, pool1to1, emer.Back, &hip.EcCa1Prjn{})
-		pj.SetClass("EcCa1Prjn")
-	} else {
-		pj := net.ConnectLayers(ecin, ca1, pool1to1, emer.Forward)
-		pj.SetClass("EcCa1Prjn")
-		pj = net.ConnectLayers(ca1, ecout, pool1to1, emer.Forward)
-		pj.SetClass("EcCa1Prjn")
-		pj = net.ConnectLayers(ecout, ca1, pool1to1, emer.Back)
-		pj.SetClass("EcCa1Prjn")
-	}
-
-	// Perforant pathway
-	ppathDG := prjn.NewUnifRnd()
-	ppathDG.PCon = hp.DGPCon
-	ppathCA3 := prjn.NewUnifRnd()
-	ppathCA3.PCon = hp.CA3PCon
-
-	pj := net.ConnectLayersPrjn(ecin, dg, ppathDG, emer.Forward, &hip.CHLPrjn{})
-	pj.SetClass("HippoCHL")
-
-	if true { // toggle for bcm vs. ppath, zycyc: must use false for orig_param, true for def_param
-		pj = net.ConnectLayersPrjn(ecin, ca3, ppathCA3, emer.Forward, &hip.EcCa1Prjn{})
-		pj.SetClass("PPath")
-		pj = net.ConnectLayersPrjn(ca3, ca3, full, emer.Lateral, &hip.EcCa1Prjn{})
-		pj.SetClass("PPath")
-	} else {
-		// so far, this is sig worse, even with error-driven MinusQ1 case (which is better than off)
-		pj = net.ConnectLayersPrjn(ecin, ca3, ppathCA3, emer.Forward, &hip.CHLPrjn{})
-		pj.SetClass("HippoCHL")
-		pj = net.ConnectLayersPrjn(ca3, ca3, full, emer.Lateral, &hip.CHLPrjn{})
-		pj.SetClass("HippoCHL")
-	}
-
-	// always use this for now:
-	if false { // todo: was true
-		pj = net.ConnectLayersPrjn(ca3, ca1, full, emer.Forward, &hip.CHLPrjn{})
-		pj.SetClass("HippoCHL")
-	} else {
-		// note: this requires lrate = 1.0 or maybe 1.2, doesn't work *nearly* as well
-		pj = net.ConnectLayers(ca3, ca1, full, emer.Forward) // default con
-		// pj.SetClass("HippoCHL")
-	}
-
-	// Mossy fibers
-	mossy := prjn.NewUnifRnd()
-	mossy.PCon = hp.MossyPCon
-	pj = net.ConnectLayersPrjn(dg, ca3, mossy, emer.Forward, &hip.CHLPrjn{}) // no learning
-	pj.SetClass("HippoCHL")
+	_, _, ca1, dg, ca3 := hip.AddHip(net, in, hp, 2)
 
 	// using 4 threads total (rest on 0)
 	dg.SetThread(1)
@@ -431,6 +315,7 @@ func (ss *Sim) ConfigNet(net *axon.Network) {
 		return
 	}
 	net.InitWts()
+	ss.Theta.Config(net, &ss.Hip)
 }
 
 func (ss *Sim) ReConfigNet() {
@@ -538,32 +423,7 @@ func (ss *Sim) ThetaCyc(train bool) {
 		ss.Net.WtFmDWt()
 	}
 
-	ca1 := ss.Net.LayerByName("CA1").(axon.AxonLayer).AsAxon()
-	ca3 := ss.Net.LayerByName("CA3").(axon.AxonLayer).AsAxon()
-	// ecin := ss.Net.LayerByName("ECin").(axon.AxonLayer).AsAxon()
-	ecout := ss.Net.LayerByName("ECout").(axon.AxonLayer).AsAxon()
-	ca1FmECin := ca1.RcvPrjns.SendName("ECin").(axon.AxonPrjn).AsAxon()
-	ca1FmCa3 := ca1.RcvPrjns.SendName("CA3").(axon.AxonPrjn).AsAxon()
-	ca3FmDg := ca3.RcvPrjns.SendName("DG").(axon.AxonPrjn).AsAxon()
-
-	absGain := float32(2)
-
-	// First Quarter: CA1 is driven by ECin, not by CA3 recall
-	// (which is not really active yet anyway)
-	ca1FmECin.PrjnScale.Abs = absGain
-	ca1FmCa3.PrjnScale.Abs = 0
-
-	dgwtscale := ca3FmDg.PrjnScale.Rel
-	ca3FmDg.PrjnScale.Rel = dgwtscale - ss.Hip.MossyDel // turn off DG input to CA3 in first quarter
-
-	if train {
-		ecout.SetType(emer.Target) // clamp a plus phase during testing
-	} else {
-		ecout.SetType(emer.Compare) // don't clamp
-	}
-	ecout.UpdateExtFlags() // call this after updating type
-
-	ss.Net.InitGScale() // update computed scaling factors
+	ss.Theta.Start(ss.Net, train)
 
 	// cycPerQtr := []int{100, 100, 100, 100}
 	cycPerQtr := []int{50, 50, 50, 50} // 100, 25, 25, 50 best so far, vs 75,50 at start, 50,50 instead of 25..
@@ -584,27 +444,13 @@ func (ss *Sim) ThetaCyc(train bool) {
 				ss.UpdateViewTime(train, viewUpdt)
 			}
 		}
+		ss.Theta.Quarter(ss.Net, qtr+1, train)
 		switch qtr + 1 {
-		case 1: // Second, Third Quarters: CA1 is driven by CA3 recall
+		case 1:
 			ss.Net.ActSt1(&ss.Time)
-			ca1FmECin.PrjnScale.Abs = 0
-			ca1FmCa3.PrjnScale.Abs = absGain
-			if train {
-				ca3FmDg.PrjnScale.Rel = dgwtscale // restore after 1st quarter
-			} else {
-				ca3FmDg.PrjnScale.Rel = dgwtscale - ss.Hip.MossyDelTest // testing
-			}
-			ss.Net.InitGScale() // update computed scaling factors
 		case 2:
 			ss.Net.ActSt2(&ss.Time)
-		case 3: // Fourth Quarter: CA1 back to ECin drive only
-			if train { // clamp ECout from ECin
-				ca1FmECin.PrjnScale.Abs = absGain
-				ca1FmCa3.PrjnScale.Abs = 0
-				ss.Net.InitGScale() // update computed scaling factors
-				// ecin.UnitVals(&ss.TmpVals, "Act")
-				// ecout.ApplyExt1D32(ss.TmpVals)
-			}
+		case 3:
 			ss.Net.MinusPhase(&ss.Time)
 			ss.MemStats(train) // must come after QuarterFinal
 		case 4:
@@ -615,8 +461,7 @@ func (ss *Sim) ThetaCyc(train bool) {
 		}
 	}
 
-	ca3FmDg.PrjnScale.Rel = dgwtscale // restore
-	ca1FmCa3.PrjnScale.Abs = absGain
+	ss.Theta.End()
 
 	if train {
 		ss.Net.DWt()
@@ -7,6 +7,7 @@ package main
 import (
 	"math/rand"
 
+	"github.com/emer/axon/protocol"
 	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
@@ -243,64 +244,60 @@ func ClampCa1Fun() {
 	ss.Stopped()
 }
 
+// SpineExec executes protocol.Protocol events on the spine model:
+// PreSpike drives a presynaptic spike, PostSpike and GeClamp drive Ge,
+// and CaPulse clamps Ca (with cytosol at 1/3 of PSD elevation above baseline).
+type SpineExec struct {
+	Ss        *Sim
+	CaClamped bool
+}
+
+// Step implements the protocol.Executor interface
+func (ex *SpineExec) Step(t int, evs []*protocol.Event) bool {
+	ss := ex.Ss
+	if _, has := protocol.EventVal(evs, protocol.PreSpike); has {
+		ss.Spine.States.PreSpike = 1
+	} else {
+		ss.Spine.States.PreSpike = 0
+	}
+	ge, _ := protocol.EventVal(evs, protocol.PostSpike)
+	gc, _ := protocol.EventVal(evs, protocol.GeClamp)
+	ge += gc
+	if ca, has := protocol.EventVal(evs, protocol.CaPulse); has {
+		bca := 0.05
+		pca := float64(ca)
+		ss.Spine.Ca.SetClamp(bca+((pca-bca)/3), pca)
+		ex.CaClamped = true
+	} else if ex.CaClamped {
+		ss.Spine.Ca.Clamp = false
+		ex.CaClamped = false
+	}
+	ss.NeuronUpdt(t, ge, 0)
+	ss.LogDefault()
+	return !ss.StopNow
+}
+
 func STDPFun() {
 	ss := &TheSim
-	toff := 500
-	dur := 1
-	psms := toff + 5 - ss.DeltaT // 5 is lag
-	tott := ss.NReps * 1000
-
-	for msec := 0; msec < tott; msec++ {
-		ims := msec % 1000
-		if ims == psms {
-			ss.Spine.States.PreSpike = 1
-		} else {
-			ss.Spine.States.PreSpike = 0
-		}
-		ge := float32(0.0)
-		if ims >= toff && ims < toff+dur {
-			ge = ss.GeStim
-		}
-		ss.NeuronUpdt(msec, ge, 0)
-		ss.LogDefault()
-		if ss.StopNow {
-			break
-		}
-	}
+	pr := protocol.STDPPairing(ss.NReps, 1000, 500, ss.DeltaT, 5, ss.GeStim) // 5 is lag
+	pr.Run(&SpineExec{Ss: ss})
 	ss.GraphRun(ss.FinalSecs)
 	ss.Stopped()
 }
 
 func STDPSweepFun() {
 	ss := &TheSim
-	toff := 500
-	dur := 1
-	tott := ss.NReps * 1000
 
 	ss.ResetDWtPlot()
 
 	for dt := -ss.DeltaTRange; dt <= ss.DeltaTRange; dt += ss.DeltaTInc {
-		psms := toff + 5 - dt // 5 is lag
 		ss.ResetTimePlots()
 		ss.Init()
 
-		for msec := 0; msec < tott; msec++ {
-			ims := msec % 1000
-			if ims == psms {
-				ss.Spine.States.PreSpike = 1
-			} else {
-				ss.Spine.States.PreSpike = 0
-			}
-			ge := float32(0.0)
-			if ims >= toff && ims < toff+dur {
-				ge = ss.GeStim
-			}
-			ss.NeuronUpdt(msec, ge, 0)
-			ss.LogDefault()
-			if ss.StopNow {
-				ss.Stopped()
-				return
-			}
+		pr := protocol.STDPPairing(ss.NReps, 1000, 500, dt, 5, ss.GeStim) // 5 is lag
+		if !pr.Run(&SpineExec{Ss: ss}) {
+			ss.Stopped()
+			return
 		}
 		ss.GraphRun(ss.FinalSecs)
 		ss.LogDWt(ss.DWtLog, float64(dt), 0)
@@ -10,4 +10,5 @@ require (
 	github.com/goki/gi v1.2.16
 	github.com/goki/ki v1.1.4
 	github.com/goki/mat32 v1.0.9
+	gopkg.in/yaml.v3 v3.0.1
 )
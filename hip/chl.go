@@ -93,7 +93,7 @@ func (pj *CHLPrjn) UpdateParams() {
 // DWt computes the weight change (learning) -- on sending projections
 // CHL version supported if On
 func (pj *CHLPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	if pj.CHL.On {
@@ -113,7 +113,7 @@ func (pj *CHLPrjn) SAvgCor(slay *axon.Layer) float32 {
 
 // DWtCHL computes the weight change (learning) for CHL
 func (pj *CHLPrjn) DWtCHL() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -124,8 +124,7 @@ func (pj *CHLPrjn) DWtCHL() {
 	lr := pj.Learn.Lrate.Eff
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 		snActM := pj.CHL.MinusAct(sn.ActM, sn.ActSt1)
@@ -32,7 +32,7 @@ func (pj *EcCa1Prjn) UpdateParams() {
 // DWt computes the weight change (learning) -- on sending projections
 // Delta version
 func (pj *EcCa1Prjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -40,8 +40,7 @@ func (pj *EcCa1Prjn) DWt() {
 	lr := pj.Learn.Lrate.Eff
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 
@@ -0,0 +1,137 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hip
+
+import (
+	"github.com/emer/axon/axon"
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// MossyParams are parameters for the DG -> CA3 mossy fiber projection:
+// strong, fixed weights with optional detonator-style facilitation.
+type MossyParams struct {
+	Fixed  bool    `def:"true" desc:"if true, weights are fixed at their initial values: no learning (Learn.Learn is turned off) and no SWt adaptation (SWt.Adapt.On is turned off)"`
+	Wt     float32 `def:"0.9" min:"0" max:"1" desc:"initial weight value -- sets SWt.Init.Mean (Var is set to .01 by default)"`
+	Det    bool    `desc:"detonator-style facilitation: each spike from a DG neuron increases the efficacy of its subsequent spikes, so that a short burst from a single DG neuron can drive its CA3 targets"`
+	DetInc float32 `viewif:"Det" def:"0.5" min:"0" desc:"increment in facilitation factor for each spike -- the first spike has a factor of 1"`
+	DetMax float32 `viewif:"Det" def:"4" min:"1" desc:"maximum facilitation factor"`
+	DetTau float32 `viewif:"Det" def:"50" min:"1" desc:"time constant in cycles for decay of the facilitation factor back to 1"`
+
+	DetDt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / tau"`
+}
+
+func (mp *MossyParams) Defaults() {
+	mp.Fixed = true
+	mp.Wt = 0.9
+	mp.DetInc = 0.5
+	mp.DetMax = 4
+	mp.DetTau = 50
+	mp.Update()
+}
+
+func (mp *MossyParams) Update() {
+	mp.DetDt = 1 / mp.DetTau
+}
+
+// DetFac returns the facilitation factor for a spike given the prior
+// factor fac and number of cycles since the last spike, and updates fac
+func (mp *MossyParams) DetFac(fac *float32, isi int32) float32 {
+	f := 1 + (*fac-1)*mat32.Exp(-float32(isi)*mp.DetDt)
+	*fac = mat32.Min(f+mp.DetInc, mp.DetMax)
+	return f
+}
+
+// hip.MossyPrjn is the DG -> CA3 mossy fiber projection: very sparse
+// (use a random connectivity pattern with low PCon), with strong, fixed
+// (non-learning) weights, and optional detonator-style facilitation.
+type MossyPrjn struct {
+	axon.Prjn             // access as .Prjn
+	Mossy     MossyParams `view:"inline" desc:"mossy fiber parameters"`
+	DetFac    []float32   `view:"-" desc:"with Mossy.Det: facilitation factor for each sending neuron"`
+	DetLast   []int32     `view:"-" desc:"with Mossy.Det: STPCyc of the last spike for each sending neuron"`
+}
+
+var KiT_MossyPrjn = kit.Types.AddType(&MossyPrjn{}, axon.PrjnProps)
+
+func (pj *MossyPrjn) Defaults() {
+	pj.Prjn.Defaults()
+	pj.Mossy.Defaults()
+	pj.SWt.Init.SPct = 0
+	pj.SWt.Init.Mean = pj.Mossy.Wt
+	pj.SWt.Init.Var = 0.01
+	pj.SWt.Init.Sym = false
+	pj.SWt.Adapt.On = false
+	pj.Learn.Learn = false
+	pj.PrjnScale.Rel = 4
+}
+
+func (pj *MossyPrjn) UpdateParams() {
+	pj.Mossy.Update()
+	pj.SWt.Init.Mean = pj.Mossy.Wt
+	if pj.Mossy.Fixed {
+		pj.Learn.Learn = false
+		pj.SWt.Adapt.On = false
+	}
+	pj.Prjn.UpdateParams()
+}
+
+// InitWts initializes the weights, and the facilitation state
+func (pj *MossyPrjn) InitWts() {
+	pj.Prjn.InitWts()
+	pj.InitDet()
+}
+
+// InitDet initializes the detonator facilitation state
+func (pj *MossyPrjn) InitDet() {
+	slen := pj.Send.Shape().Len()
+	if len(pj.DetFac) != slen {
+		pj.DetFac = make([]float32, slen)
+		pj.DetLast = make([]int32, slen)
+	}
+	for si := range pj.DetFac {
+		pj.DetFac[si] = 1
+		pj.DetLast[si] = pj.STPCyc - axon.STPInitISI
+	}
+}
+
+// CheckpointVals returns the detonator facilitation state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (pj *MossyPrjn) CheckpointVals() []interface{} {
+	return []interface{}{&pj.DetFac, &pj.DetLast}
+}
+
+// SendSpike sends a spike from sending neuron index si,
+// to add to buffer on receivers, with facilitation if Mossy.Det.
+// Facilitation uses the standard fixed-delay spike delivery, and
+// is not applied with event-driven delivery or per-synapse delays.
+func (pj *MossyPrjn) SendSpike(si int) {
+	if !pj.Mossy.Det || pj.UseEvents || pj.SynDel != nil {
+		pj.Prjn.SendSpike(si)
+		return
+	}
+	if len(pj.DetFac) != pj.Send.Shape().Len() {
+		pj.InitDet()
+	}
+	fac := pj.Mossy.DetFac(&pj.DetFac[si], pj.STPCyc-pj.DetLast[si])
+	pj.DetLast[si] = pj.STPCyc
+	sc := pj.GScale.Scale * fac
+	sz := pj.Gidx.Len
+	di := pj.Gidx.Idx(pj.Com.Delay)
+	nc, st := pj.SConNSt(si)
+	syns := pj.Syns[st : st+nc]
+	scons := pj.SConIdx[st : st+nc]
+	for ci, ri := range scons {
+		pj.Gbuf[int(ri)*sz+di] += sc * syns[ci].Wt
+	}
+}
+
+// DWt computes the weight change (learning) -- none if Mossy.Fixed
+func (pj *MossyPrjn) DWt() {
+	if pj.Mossy.Fixed {
+		return
+	}
+	pj.Prjn.DWt()
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hip
+
+import (
+	"log"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/evec"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+	"github.com/goki/ki/kit"
+)
+
+// hip.Network is an axon.Network that automatically applies the ThetaPhase
+// switching of the hippocampal projections over the quarters of each trial:
+// Start in NewState, Quarter 1 in ActSt1, Quarter 3 in MinusPhase, and End
+// in PlusPhase.  Call ConfigTheta after Build to turn this on, and set
+// Theta.Train according to whether the trial is training or testing.
+type Network struct {
+	axon.Network
+	Theta  ThetaPhase `view:"inline" desc:"theta phase switching of the hippocampal projections"`
+	St1    bool       `inactive:"+" desc:"true if the ActSt1 snapshot has been saved in the current trial"`
+	St2    bool       `inactive:"+" desc:"true if the ActSt2 snapshot has been saved in the current trial"`
+	AutoSt bool       `desc:"if true, any ActSt1 or ActSt2 snapshot not saved in the current trial is saved at the end of the minus phase -- otherwise a warning is logged and learning uses the snapshot from a prior trial"`
+}
+
+var KiT_Network = kit.Types.AddType(&Network{}, NetworkProps)
+
+var NetworkProps = axon.NetworkProps
+
+// NewNetwork returns a new hip Network
+func NewNetwork(name string) *Network {
+	net := &Network{}
+	net.InitName(net, name)
+	return net
+}
+
+// AddHip adds the standard theta-phase hippocampus model -- see AddHip
+func (nt *Network) AddHip(in emer.Layer, hp *HipParams, space float32) (ecin, ecout, ca1, dg, ca3 emer.Layer) {
+	return AddHip(&nt.Network, in, hp, space)
+}
+
+// ConfigTheta configures the automatic Theta phase switching, using
+// given hippocampus params -- must be called after Build
+func (nt *Network) ConfigTheta(hp *HipParams) {
+	nt.Theta.Config(&nt.Network, hp)
+}
+
+// CheckpointVals returns the trial state that is saved in a Checkpoint
+// (see axon.Checkpointer): the ActSt1, ActSt2 snapshot flags, and the
+// Theta phase state, including the current projection scales if configured
+func (nt *Network) CheckpointVals() []interface{} {
+	vals := []interface{}{&nt.St1, &nt.St2, &nt.Theta.DGWtScale, &nt.Theta.Started}
+	if nt.Theta.Hip != nil {
+		vals = append(vals, &nt.Theta.CA1FmECin.PrjnScale.Abs, &nt.Theta.CA1FmCA3.PrjnScale.Abs, &nt.Theta.CA3FmDG.PrjnScale.Rel)
+	}
+	return vals
+}
+
+// NewStateImpl handles all initialization at start of new input pattern,
+// clears the ActSt1, ActSt2 snapshot flags, and Starts the Theta phase switching
+func (nt *Network) NewStateImpl() {
+	nt.Network.NewStateImpl()
+	nt.St1 = false
+	nt.St2 = false
+	if nt.Theta.Hip != nil {
+		nt.Theta.Start(&nt.Network, nt.Theta.Train)
+	}
+}
+
+// ActSt1 saves current acts into ActSt1, and switches CA1 to CA3 input
+func (nt *Network) ActSt1(ltime *axon.Time) {
+	nt.Network.ActSt1(ltime)
+	nt.St1 = true
+	if nt.Theta.Started {
+		nt.Theta.Quarter(&nt.Network, 1, nt.Theta.Train)
+	}
+}
+
+// ActSt2 saves current acts into ActSt2
+func (nt *Network) ActSt2(ltime *axon.Time) {
+	nt.Network.ActSt2(ltime)
+	nt.St2 = true
+}
+
+// QuarterFinal does all the updating at the end of given quarter (1-4)
+// of the theta cycle: saving the ActSt1 snapshot (the minus phase for
+// the EcCa1Prjn and CHLPrjn MinusQ1 learning) after the first quarter,
+// ActSt2 after the second, and calling MinusPhase after the third and
+// PlusPhase after the fourth.  Call this instead of those methods.
+func (nt *Network) QuarterFinal(ltime *axon.Time, qtr int) {
+	switch qtr {
+	case 1:
+		nt.ActSt1(ltime)
+	case 2:
+		nt.ActSt2(ltime)
+	case 3:
+		nt.MinusPhase(ltime)
+	case 4:
+		nt.PlusPhase(ltime)
+	}
+}
+
+// MinusPhaseImpl does updating after end of minus phase, and switches
+// CA1 back to ECin input for the plus phase if training.
+// If the ActSt1 or ActSt2 snapshots were not saved in this trial,
+// they are saved now if AutoSt is set, and otherwise a warning is logged.
+func (nt *Network) MinusPhaseImpl(ltime *axon.Time) {
+	if !nt.St1 {
+		if nt.AutoSt {
+			nt.Network.ActSt1(ltime)
+			nt.St1 = true
+		} else {
+			log.Printf("hip.Network: %v ActSt1 snapshot not saved in this trial -- call ActSt1 or set AutoSt\n", nt.Nm)
+		}
+	}
+	if !nt.St2 {
+		if nt.AutoSt {
+			nt.Network.ActSt2(ltime)
+			nt.St2 = true
+		} else {
+			log.Printf("hip.Network: %v ActSt2 snapshot not saved in this trial -- call ActSt2 or set AutoSt\n", nt.Nm)
+		}
+	}
+	if nt.Theta.Started {
+		nt.Theta.Quarter(&nt.Network, 3, nt.Theta.Train)
+	}
+	nt.Network.MinusPhaseImpl(ltime)
+}
+
+// PlusPhaseImpl does updating after end of plus phase, and Ends the
+// Theta phase switching, restoring the projection scales
+func (nt *Network) PlusPhaseImpl(ltime *axon.Time) {
+	nt.Network.PlusPhaseImpl(ltime)
+	if nt.Theta.Started {
+		nt.Theta.End()
+	}
+}
+
+// HipParams have the hippocampus size and connectivity parameters
+type HipParams struct {
+	ECSize       evec.Vec2i `desc:"size of EC in terms of overall pools (outer dimension)"`
+	ECPool       evec.Vec2i `desc:"size of one EC pool"`
+	CA1Pool      evec.Vec2i `desc:"size of one CA1 pool"`
+	CA3Size      evec.Vec2i `desc:"size of CA3"`
+	DGRatio      float32    `desc:"size of DG / CA3"`
+	DGSize       evec.Vec2i `inactive:"+" desc:"size of DG"`
+	DGPCon       float32    `desc:"percent connectivity into DG"`
+	CA3PCon      float32    `desc:"percent connectivity into CA3"`
+	MossyPCon    float32    `desc:"percent connectivity into CA3 from DG"`
+	ECPctAct     float32    `desc:"percent activation in EC pool"`
+	MossyDel     float32    `desc:"delta in mossy effective strength between minus and plus phase"`
+	MossyDelTest float32    `desc:"delta in mossy strength for testing (relative to base param)"`
+	ThetaGain    float32    `def:"2" desc:"absolute scale of the CA1 input from ECin or CA3, whichever is driving CA1 in the current theta phase (the other is 0) -- see ThetaPhase"`
+}
+
+func (hp *HipParams) Defaults() {
+	// size
+	hp.ECSize.Set(2, 3)
+	hp.ECPool.Set(7, 7)
+	hp.CA1Pool.Set(15, 15)
+	hp.CA3Size.Set(30, 30)
+	hp.DGRatio = 2.236 // c.f. Ketz et al., 2013
+
+	// ratio
+	hp.DGPCon = 0.25 // .35 is sig worse, .2 learns faster but AB recall is worse
+	hp.CA3PCon = 0.25
+	hp.MossyPCon = 0.02 // .02 > .05 > .01 (for small net)
+	hp.ECPctAct = 0.2
+
+	hp.MossyDel = 3     // 4 > 2 -- best is 4 del on 4 rel baseline
+	hp.MossyDelTest = 0 // for rel = 4: 3 > 2 > 0 > 4 -- 4 is very bad -- need a small amount..
+	hp.ThetaGain = 2
+	hp.Update()
+}
+
+func (hp *HipParams) Update() {
+	hp.DGSize.X = int(float32(hp.CA3Size.X) * hp.DGRatio)
+	hp.DGSize.Y = int(float32(hp.CA3Size.Y) * hp.DGRatio)
+}
+
+// AddHip adds the standard theta-phase hippocampus model (Ketz, Morkonda,
+// & O'Reilly, 2013) with given sizes and connectivity: ECin, ECout (Target,
+// clamped from ECin in the plus phase), CA1, DG, and CA3 layers, with the
+// EC <-> CA1 encoder pathways (class EcCa1Prjn, pool one-to-one), the
+// perforant path ECin -> DG (CHLPrjn, class HippoCHL), ECin -> CA3 and
+// CA3 -> CA3 (EcCa1Prjn, class PPath), with random partial connectivity,
+// the strong, sparse DG -> CA3 mossy fibers (MossyPrjn, class Mossy),
+// and the CA3 -> CA1 Schaffer collaterals (standard prjn).
+// If in is non-nil, it is connected one-to-one to ECin, which also receives
+// a one-to-one back projection from ECout, and the layers are positioned
+// relative to it.  Use ThetaPhase to switch the CA1 and mossy fiber inputs
+// over the course of a theta cycle.
+func AddHip(nt *axon.Network, in emer.Layer, hp *HipParams, space float32) (ecin, ecout, ca1, dg, ca3 emer.Layer) {
+	hp.Update()
+	ecin = nt.AddLayer4D("ECin", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Hidden)
+	ecout = nt.AddLayer4D("ECout", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Target) // clamped in plus phase
+	ca1 = nt.AddLayer4D("CA1", hp.ECSize.Y, hp.ECSize.X, hp.CA1Pool.Y, hp.CA1Pool.X, emer.Hidden)
+	dg = nt.AddLayer2D("DG", hp.DGSize.Y, hp.DGSize.X, emer.Hidden)
+	ca3 = nt.AddLayer2D("CA3", hp.CA3Size.Y, hp.CA3Size.X, emer.Hidden)
+
+	ecin.SetClass("EC")
+	ecout.SetClass("EC")
+
+	ecout.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "ECin", YAlign: relpos.Front, Space: space})
+	ca3.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "DG", YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
+	ca1.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "CA3", YAlign: relpos.Front, Space: space})
+
+	onetoone := prjn.NewOneToOne()
+	pool1to1 := prjn.NewPoolOneToOne()
+	full := prjn.NewFull()
+
+	if in != nil {
+		ecin.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: in.Name(), YAlign: relpos.Front, Space: space})
+		dg.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: in.Name(), YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
+		nt.ConnectLayers(in, ecin, onetoone, emer.Forward)
+		nt.ConnectLayers(ecout, ecin, onetoone, emer.Back)
+	} else {
+		dg.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "ECin", YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
+	}
+
+	// EC <-> CA1 encoder pathways
+	pj := nt.ConnectLayers(ecin, ca1, pool1to1, emer.Forward)
+	pj.SetClass("EcCa1Prjn")
+	pj = nt.ConnectLayers(ca1, ecout, pool1to1, emer.Forward)
+	pj.SetClass("EcCa1Prjn")
+	pj = nt.ConnectLayers(ecout, ca1, pool1to1, emer.Back)
+	pj.SetClass("EcCa1Prjn")
+
+	// Perforant pathway
+	ppathDG := prjn.NewUnifRnd()
+	ppathDG.PCon = hp.DGPCon
+	ppathCA3 := prjn.NewUnifRnd()
+	ppathCA3.PCon = hp.CA3PCon
+
+	pj = nt.ConnectLayersPrjn(ecin, dg, ppathDG, emer.Forward, &CHLPrjn{})
+	pj.SetClass("HippoCHL")
+
+	pj = nt.ConnectLayersPrjn(ecin, ca3, ppathCA3, emer.Forward, &EcCa1Prjn{})
+	pj.SetClass("PPath")
+	pj = nt.ConnectLayersPrjn(ca3, ca3, full, emer.Lateral, &EcCa1Prjn{})
+	pj.SetClass("PPath")
+
+	// Schaffer collaterals
+	nt.ConnectLayers(ca3, ca1, full, emer.Forward)
+
+	// Mossy fibers
+	mossy := prjn.NewUnifRnd()
+	mossy.PCon = hp.MossyPCon
+	pj = nt.ConnectLayersPrjn(dg, ca3, mossy, emer.Forward, &MossyPrjn{})
+	pj.SetClass("Mossy")
+	return
+}
+
+// ThetaPhase switches the inputs to CA1 and CA3 over the quarters of a
+// theta cycle, per the ThetaPhase algorithm (see package docs):
+// Q1: CA1 is driven by ECin, with the DG -> CA3 mossy input reduced by MossyDel;
+// Q2, 3: CA1 is driven by CA3 recall, with the full mossy input;
+// Q4 (training only): CA1 is driven by ECin again for the plus phase.
+// Call Config after the network is built, Start at the start of each trial,
+// Quarter after each quarter, and End at the end of the trial -- or use
+// hip.Network, which does this automatically.
+type ThetaPhase struct {
+	Hip       *HipParams  `desc:"hippocampus params, for MossyDel, MossyDelTest and ThetaGain"`
+	CA1FmECin *axon.Prjn  `desc:"ECin -> CA1 projection"`
+	CA1FmCA3  *axon.Prjn  `desc:"CA3 -> CA1 projection"`
+	CA3FmDG   *axon.Prjn  `desc:"DG -> CA3 mossy fiber projection"`
+	ECout     *axon.Layer `desc:"ECout layer"`
+	DGWtScale float32     `inactive:"+" desc:"base DG -> CA3 relative scale, restored at the end of the trial"`
+	Train     bool        `desc:"true if training, for automatic switching by hip.Network -- ECout is only clamped, and CA1 only driven by ECin in the fourth quarter, if training"`
+	Started   bool        `inactive:"+" desc:"true between Start and End"`
+}
+
+// Config finds the projections in given network, with the standard AddHip
+// layer names, using given hippocampus params
+func (tp *ThetaPhase) Config(net *axon.Network, hp *HipParams) {
+	tp.Hip = hp
+	ca1 := net.LayerByName("CA1").(axon.AxonLayer).AsAxon()
+	ca3 := net.LayerByName("CA3").(axon.AxonLayer).AsAxon()
+	tp.ECout = net.LayerByName("ECout").(axon.AxonLayer).AsAxon()
+	tp.CA1FmECin = ca1.RcvPrjns.SendName("ECin").(axon.AxonPrjn).AsAxon()
+	tp.CA1FmCA3 = ca1.RcvPrjns.SendName("CA3").(axon.AxonPrjn).AsAxon()
+	tp.CA3FmDG = ca3.RcvPrjns.SendName("DG").(axon.AxonPrjn).AsAxon()
+}
+
+// Start sets up the first quarter at the start of a trial: CA1 driven by ECin,
+// reduced mossy input, and ECout clamped (Target) only if training.
+// Calls InitGScale on the network.
+func (tp *ThetaPhase) Start(net *axon.Network, train bool) {
+	if tp.Started {
+		tp.End()
+	}
+	tp.Started = true
+	tp.CA1FmECin.PrjnScale.Abs = tp.Hip.ThetaGain
+	tp.CA1FmCA3.PrjnScale.Abs = 0
+
+	tp.DGWtScale = tp.CA3FmDG.PrjnScale.Rel
+	tp.CA3FmDG.PrjnScale.Rel = tp.DGWtScale - tp.Hip.MossyDel // turn off DG input to CA3 in first quarter
+
+	if train {
+		tp.ECout.SetType(emer.Target) // clamp a plus phase during testing
+	} else {
+		tp.ECout.SetType(emer.Compare) // don't clamp
+	}
+	tp.ECout.UpdateExtFlags() // call this after updating type
+	net.InitGScale()          // update computed scaling factors
+}
+
+// Quarter updates the projections after the end of given quarter (1-4)
+func (tp *ThetaPhase) Quarter(net *axon.Network, qtr int, train bool) {
+	switch qtr {
+	case 1: // Second, Third Quarters: CA1 is driven by CA3 recall
+		tp.CA1FmECin.PrjnScale.Abs = 0
+		tp.CA1FmCA3.PrjnScale.Abs = tp.Hip.ThetaGain
+		if train {
+			tp.CA3FmDG.PrjnScale.Rel = tp.DGWtScale // restore after 1st quarter
+		} else {
+			tp.CA3FmDG.PrjnScale.Rel = tp.DGWtScale - tp.Hip.MossyDelTest // testing
+		}
+		net.InitGScale() // update computed scaling factors
+	case 3: // Fourth Quarter: CA1 back to ECin drive only
+		if train {
+			tp.CA1FmECin.PrjnScale.Abs = tp.Hip.ThetaGain
+			tp.CA1FmCA3.PrjnScale.Abs = 0
+			net.InitGScale() // update computed scaling factors
+		}
+	}
+}
+
+// End restores the projection scales at the end of a trial
+func (tp *ThetaPhase) End() {
+	tp.Started = false
+	tp.CA3FmDG.PrjnScale.Rel = tp.DGWtScale // restore
+	tp.CA1FmCA3.PrjnScale.Abs = tp.Hip.ThetaGain
+}
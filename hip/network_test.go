@@ -0,0 +1,109 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hip
+
+import (
+	"testing"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/etable/etensor"
+)
+
+func TestCHLParams(t *testing.T) {
+	ch := CHLParams{}
+	ch.Defaults()
+	if ch.MinusAct(0.2, 0.8) != 0.2 {
+		t.Errorf("MinusAct should be ActM without MinusQ1\n")
+	}
+	ch.MinusQ1 = true
+	if ch.MinusAct(0.2, 0.8) != 0.8 {
+		t.Errorf("MinusAct should be ActSt1 with MinusQ1\n")
+	}
+	if ch.ErrDWt(1, 1, 1, 1, 0.5) != 0 {
+		t.Errorf("ErrDWt should be 0 with no phase difference\n")
+	}
+	if ch.ErrDWt(1, 0.5, 1, 0.5, 0.5) <= 0 {
+		t.Errorf("ErrDWt should be > 0 when plus > minus\n")
+	}
+}
+
+// checkSt checks that the ActSt1 or ActSt2 snapshot of all neurons in
+// given layer is the current AvgSLrn
+func checkSt(t *testing.T, ly *axon.Layer, st2 bool, msg string) {
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		st := nrn.ActSt1
+		if st2 {
+			st = nrn.ActSt2
+		}
+		if st != nrn.AvgSLrn {
+			t.Errorf("%v: %v neuron %d snapshot: %v != AvgSLrn: %v\n", msg, ly.Name(), ni, st, nrn.AvgSLrn)
+		}
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	net := NewNetwork("SnapNet")
+	inLay := net.AddLayer2D("Input", 2, 2, emer.Input).(*axon.Layer)
+	hidLay := net.AddLayer2D("Hidden", 2, 2, emer.Hidden).(*axon.Layer)
+	net.ConnectLayersPrjn(inLay, hidLay, prjn.NewFull(), emer.Forward, &EcCa1Prjn{})
+
+	net.Defaults()
+	net.Build()
+	net.InitWts()
+
+	pat := etensor.NewFloat32([]int{2, 2}, nil, []string{"Y", "X"})
+	pat.Set([]int{0, 0}, 1)
+	pat.Set([]int{1, 1}, 1)
+
+	ltime := axon.NewTime()
+	for trl := 0; trl < 3; trl++ {
+		net.AutoSt = trl == 2 // second trial only warns, third saves missing
+		net.NewState()
+		ltime.NewState()
+		inLay.ApplyExt(pat)
+		if net.St1 || net.St2 {
+			t.Errorf("trial %d: snapshot flags not cleared by NewState\n", trl)
+		}
+		for qtr := 0; qtr < 4; qtr++ {
+			for cyc := 0; cyc < 25; cyc++ {
+				net.Cycle(ltime)
+				ltime.CycleInc()
+			}
+			if trl > 0 && qtr < 2 { // skip snapshots after first trial
+				continue
+			}
+			net.QuarterFinal(ltime, qtr+1)
+			switch qtr + 1 {
+			case 1:
+				if !net.St1 {
+					t.Errorf("St1 not set after first quarter\n")
+				}
+				checkSt(t, hidLay, false, "ActSt1 after first quarter")
+			case 2:
+				if !net.St2 {
+					t.Errorf("St2 not set after second quarter\n")
+				}
+				checkSt(t, hidLay, true, "ActSt2 after second quarter")
+			}
+			if trl == 1 && qtr == 2 {
+				if net.St1 || net.St2 {
+					t.Errorf("missing snapshots saved by MinusPhase without AutoSt\n")
+				}
+			}
+			if trl == 2 && qtr == 2 {
+				if !net.St1 || !net.St2 {
+					t.Errorf("missing snapshots not saved by MinusPhase\n")
+				}
+				checkSt(t, hidLay, false, "ActSt1 saved by MinusPhase")
+				checkSt(t, hidLay, true, "ActSt2 saved by MinusPhase")
+			}
+		}
+		net.DWt()
+		net.WtFmDWt()
+	}
+}
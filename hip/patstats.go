@@ -0,0 +1,204 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hip
+
+import (
+	"math"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// Overlap returns the normalized overlap between two activity patterns:
+// the number of units active (> thr) in both, divided by the geometric
+// mean of the number active in each -- 1 = identical, 0 = no overlap
+// (or either pattern has no active units).
+func Overlap(a, b []float64, thr float64) float64 {
+	na, nb, nab := 0, 0, 0
+	for i := range a {
+		aon := a[i] > thr
+		bon := i < len(b) && b[i] > thr
+		if aon {
+			na++
+		}
+		if bon {
+			nb++
+		}
+		if aon && bon {
+			nab++
+		}
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float64(nab) / math.Sqrt(float64(na*nb))
+}
+
+// patStatsVals returns the values of given column and row as float64s
+func patStatsVals(acts *etable.Table, col string, row int, vals *[]float64) {
+	acts.CellTensor(col, row).Floats(vals)
+}
+
+// ConfigPatSepTable configures given table for PatSep
+func ConfigPatSepTable(dt *etable.Table) {
+	dt.SetMetaData("name", "PatSep")
+	dt.SetMetaData("desc", "pattern separation: overlap between patterns in each layer vs. the input")
+	sch := etable.Schema{
+		{"Layer", etensor.STRING, nil, nil},
+		{"NPairs", etensor.INT64, nil, nil},
+		{"InOverlap", etensor.FLOAT64, nil, nil},
+		{"Overlap", etensor.FLOAT64, nil, nil},
+		{"Sep", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// PatSep computes the pattern separation of each of given layers over a
+// testing set, with one row per trial in the acts table, which has a
+// column of activity patterns named layer + "Act" for each layer, and for
+// the input layer (e.g., ECin).  For every pair of trials, the Overlap
+// of the layer patterns (units > thr) is compared to that of the input
+// patterns, writing one row per layer into dt: InOverlap and Overlap are
+// the mean overlaps over all pairs, and Sep = 1 - Overlap / InOverlap is
+// the proportional reduction in overlap relative to the input --
+// DG and CA3 should have Sep > 0 (Overlap < InOverlap).
+func PatSep(dt *etable.Table, acts *etable.Table, inLay string, lays []string, thr float64) {
+	ConfigPatSepTable(dt)
+	nr := acts.Rows
+	ins := make([][]float64, nr)
+	for r := 0; r < nr; r++ {
+		patStatsVals(acts, inLay+"Act", r, &ins[r])
+	}
+	pats := make([][]float64, nr)
+	dt.SetNumRows(len(lays))
+	for li, lnm := range lays {
+		for r := 0; r < nr; r++ {
+			patStatsVals(acts, lnm+"Act", r, &pats[r])
+		}
+		npair := 0
+		inSum, sum := 0.0, 0.0
+		for r := 0; r < nr; r++ {
+			for o := 0; o < r; o++ {
+				inSum += Overlap(ins[r], ins[o], thr)
+				sum += Overlap(pats[r], pats[o], thr)
+				npair++
+			}
+		}
+		inOv, ov, sep := 0.0, 0.0, 0.0
+		if npair > 0 {
+			inOv = inSum / float64(npair)
+			ov = sum / float64(npair)
+		}
+		if inOv > 0 {
+			sep = 1 - ov/inOv
+		}
+		dt.SetCellString("Layer", li, lnm)
+		dt.SetCellFloat("NPairs", li, float64(npair))
+		dt.SetCellFloat("InOverlap", li, inOv)
+		dt.SetCellFloat("Overlap", li, ov)
+		dt.SetCellFloat("Sep", li, sep)
+	}
+}
+
+// PatCompStats are the pattern completion statistics for one trial,
+// recalling a full target pattern from a partial cue
+type PatCompStats struct {
+	NCmp           int     `desc:"number of target units missing from the cue, which must be completed"`
+	TrgOnWasOffAll float64 `desc:"proportion of target units that were not active in the output"`
+	TrgOnWasOffCmp float64 `desc:"proportion of target units missing from the cue that were not active in the output -- 1 - this is the proportion completed"`
+	TrgOffWasOn    float64 `desc:"proportion of non-target units that were active in the output"`
+	Mem            bool    `desc:"true if the pattern was recalled: both TrgOnWasOffCmp (or TrgOnWasOffAll if nothing to complete) and TrgOffWasOn are < memThr"`
+}
+
+// Compute computes the stats from given cue (e.g., Input), output (e.g.,
+// ECout ActM) and target (full pattern) activities, with units > thr
+// being active, and memThr the threshold for counting as recalled
+func (pc *PatCompStats) Compute(cue, out, trg []float64, thr, memThr float64) {
+	*pc = PatCompStats{}
+	trgOnN, trgOffN := 0, 0
+	for i := range trg {
+		oon := i < len(out) && out[i] > thr
+		if trg[i] <= thr {
+			trgOffN++
+			if oon {
+				pc.TrgOffWasOn++
+			}
+			continue
+		}
+		trgOnN++
+		cmp := i >= len(cue) || cue[i] <= thr
+		if cmp {
+			pc.NCmp++
+		}
+		if !oon {
+			pc.TrgOnWasOffAll++
+			if cmp {
+				pc.TrgOnWasOffCmp++
+			}
+		}
+	}
+	if trgOnN > 0 {
+		pc.TrgOnWasOffAll /= float64(trgOnN)
+	}
+	if trgOffN > 0 {
+		pc.TrgOffWasOn /= float64(trgOffN)
+	}
+	onErr := pc.TrgOnWasOffAll
+	if pc.NCmp > 0 {
+		pc.TrgOnWasOffCmp /= float64(pc.NCmp)
+		onErr = pc.TrgOnWasOffCmp
+	}
+	pc.Mem = onErr < memThr && pc.TrgOffWasOn < memThr
+}
+
+// ConfigPatCompTable configures given table for PatComp
+func ConfigPatCompTable(dt *etable.Table) {
+	dt.SetMetaData("name", "PatComp")
+	dt.SetMetaData("desc", "pattern completion: recall of full patterns from partial cues")
+	sch := etable.Schema{
+		{"NCmp", etensor.INT64, nil, nil},
+		{"TrgOnWasOffAll", etensor.FLOAT64, nil, nil},
+		{"TrgOnWasOffCmp", etensor.FLOAT64, nil, nil},
+		{"TrgOffWasOn", etensor.FLOAT64, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// PatComp computes the pattern completion stats (see PatCompStats) for
+// each trial of a testing set, with one row per trial in the acts table,
+// which has columns of the partial cue, output, and full target patterns
+// with given names, writing one row per trial into dt, and returns the
+// mean proportion completed (1 - TrgOnWasOffCmp) and the proportion of
+// trials recalled (Mem) over the testing set.
+func PatComp(dt *etable.Table, acts *etable.Table, cueCol, outCol, trgCol string, thr, memThr float64) (cmp, mem float64) {
+	ConfigPatCompTable(dt)
+	nr := acts.Rows
+	dt.SetNumRows(nr)
+	var cue, out, trg []float64
+	var pc PatCompStats
+	for r := 0; r < nr; r++ {
+		patStatsVals(acts, cueCol, r, &cue)
+		patStatsVals(acts, outCol, r, &out)
+		patStatsVals(acts, trgCol, r, &trg)
+		pc.Compute(cue, out, trg, thr, memThr)
+		cmp += 1 - pc.TrgOnWasOffCmp
+		memv := 0.0
+		if pc.Mem {
+			memv = 1
+			mem++
+		}
+		dt.SetCellFloat("NCmp", r, float64(pc.NCmp))
+		dt.SetCellFloat("TrgOnWasOffAll", r, pc.TrgOnWasOffAll)
+		dt.SetCellFloat("TrgOnWasOffCmp", r, pc.TrgOnWasOffCmp)
+		dt.SetCellFloat("TrgOffWasOn", r, pc.TrgOffWasOn)
+		dt.SetCellFloat("Mem", r, memv)
+	}
+	if nr > 0 {
+		cmp /= float64(nr)
+		mem /= float64(nr)
+	}
+	return
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hip
+
+import (
+	"testing"
+)
+
+func TestOverlap(t *testing.T) {
+	a := []float64{1, 1, 0, 0}
+	b := []float64{1, 0, 1, 0}
+	if ov := Overlap(a, a, 0.2); ov != 1 {
+		t.Errorf("Overlap of identical patterns should be 1: %v\n", ov)
+	}
+	if ov := Overlap(a, b, 0.2); ov != 0.5 {
+		t.Errorf("Overlap should be 0.5: %v\n", ov)
+	}
+	if ov := Overlap(a, []float64{0, 0, 0, 0}, 0.2); ov != 0 {
+		t.Errorf("Overlap with empty pattern should be 0: %v\n", ov)
+	}
+}
+
+func TestPatCompStats(t *testing.T) {
+	trg := []float64{1, 1, 1, 1, 0, 0, 0, 0}
+	cue := []float64{1, 1, 0, 0, 0, 0, 0, 0}
+	out := []float64{1, 1, 1, 0, 0, 0, 0, 1}
+	var pc PatCompStats
+	pc.Compute(cue, out, trg, 0.2, 0.2)
+	if pc.NCmp != 2 || pc.TrgOnWasOffCmp != 0.5 || pc.TrgOnWasOffAll != 0.25 || pc.TrgOffWasOn != 0.25 || pc.Mem {
+		t.Errorf("partial recall stats wrong: %+v\n", pc)
+	}
+	pc.Compute(cue, trg, trg, 0.2, 0.2)
+	if pc.TrgOnWasOffCmp != 0 || pc.TrgOffWasOn != 0 || !pc.Mem {
+		t.Errorf("full recall stats wrong: %+v\n", pc)
+	}
+}
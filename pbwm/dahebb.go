@@ -29,7 +29,7 @@ func (pj *DaHebbPrjn) Defaults() {
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *DaHebbPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -37,8 +37,7 @@ func (pj *DaHebbPrjn) DWt() {
 	rlay := rlayi.AsAxon()
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 
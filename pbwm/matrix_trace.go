@@ -121,7 +121,7 @@ func (pj *MatrixTracePrjn) InitWts() {
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *MatrixTracePrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -130,8 +130,7 @@ func (pj *MatrixTracePrjn) DWt() {
 	d2r := (rlay.DaR == D2R)
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		trsyns := pj.TrSyns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
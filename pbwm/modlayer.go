@@ -45,14 +45,17 @@ func (ly *ModLayer) ActFmG(ltime *axon.Time) {
 	curGain := ly.Act.XX1.Gain
 	ly.Act.XX1.Gain = ly.DaMod.Gain(ly.DA, curGain, ltime.PlusPhase)
 	ly.Act.XX1.Update()
+	cyc := ly.ClampCyc(ltime)
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
 		ly.Act.VmFmG(nrn)
-		ly.Act.ActFmG(nrn)
-		ly.Learn.AvgsFmAct(nrn)
+		ly.ActFmGNeur(nrn, cyc)
+		if !ly.Inference {
+			ly.Learn.AvgsFmAct(nrn)
+		}
 	}
 	ly.Act.XX1.Gain = curGain
 	ly.Act.XX1.Update()
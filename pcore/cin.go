@@ -51,6 +51,12 @@ func (ly *CINLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the ACh state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *CINLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.ACh}
+}
+
 // MaxAbsRew returns the maximum absolute value of reward layer activations
 func (ly *CINLayer) MaxAbsRew() float32 {
 	mx := float32(0)
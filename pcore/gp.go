@@ -31,12 +31,11 @@ var KiT_GPLayer = kit.Types.AddType(&GPLayer{}, axon.LayerProps)
 // 		"Layer.Inhib.Self.On":      "true",
 // 		"Layer.Inhib.Self.Gi":      "0.4",
 // 		"Layer.Inhib.Self.Tau":     "3.0",
-// 		"Layer.Inhib.ActAvg.Fixed": "true",
 // 		"Layer.Inhib.ActAvg.Init":  "0.25",
-// 		"Layer.Act.XX1.Gain":       "20", // more graded -- still works with 40 but less Rt distrib
 // 		"Layer.Act.Dt.VmTau":       "3.3",
-// 		"Layer.Act.Dt.GTau":        "3", // 5 orig
-// 		"Layer.Act.Init.Decay":     "0",
+// 		"Layer.Act.Dt.GeTau":       "3", // 5 orig
+// 		"Layer.Act.Decay.Act":      "0",
+// 		"Layer.Act.Decay.Glong":    "0",
 // }}
 
 func (ly *GPLayer) Defaults() {
@@ -54,12 +53,11 @@ func (ly *GPLayer) Defaults() {
 	ly.Inhib.Self.On = true
 	ly.Inhib.Self.Gi = 0.4 // 0.4 in localist one
 	ly.Inhib.Self.Tau = 3.0
-	ly.Inhib.ActAvg.Fixed = true
 	ly.Inhib.ActAvg.Init = 0.25
-	ly.Act.XX1.Gain = 20  // more graded -- still works with 40 but less Rt distrib
 	ly.Act.Dt.VmTau = 3.3 // fastest
-	ly.Act.Dt.GTau = 3
-	ly.Act.Init.Decay = 0
+	ly.Act.Dt.GeTau = 3
+	ly.Act.Decay.Act = 0
+	ly.Act.Decay.Glong = 0
 
 	switch ly.GPLay {
 	case GPeIn:
@@ -74,31 +72,30 @@ func (ly *GPLayer) Defaults() {
 		pji := pjii.(axon.AxonPrjn)
 		pj := pji.AsAxon()
 		pj.Learn.Learn = false
-		pj.Learn.Norm.On = false
-		pj.Learn.Momentum.On = false
-		pj.Learn.WtSig.Gain = 1
-		pj.WtInit.Mean = 0.9
-		pj.WtInit.Var = 0
-		pj.WtInit.Sym = false
+		pj.SWt.Adapt.On = false
+		pj.SWt.Adapt.SigGain = 1
+		pj.SWt.Init.Mean = 0.9
+		pj.SWt.Init.Var = 0
+		pj.SWt.Init.Sym = false
 		if _, ok := pj.Send.(*MatrixLayer); ok {
-			pj.WtScale.Abs = 0.5
+			pj.PrjnScale.Abs = 0.5
 		} else if _, ok := pj.Send.(*STNLayer); ok {
-			pj.WtScale.Abs = 0.1 // default level for GPeOut and GPeTA -- weaker to not oppose GPeIn surge
+			pj.PrjnScale.Abs = 0.1 // default level for GPeOut and GPeTA -- weaker to not oppose GPeIn surge
 		}
 		switch ly.GPLay {
 		case GPeIn:
 			if _, ok := pj.Send.(*MatrixLayer); ok { // MtxNoToGPeIn -- primary NoGo pathway
-				pj.WtScale.Abs = 1
+				pj.PrjnScale.Abs = 1
 			} else if _, ok := pj.Send.(*GPLayer); ok { // GPeOutToGPeIn
-				pj.WtScale.Abs = 0.5
+				pj.PrjnScale.Abs = 0.5
 			}
 			if _, ok := pj.Send.(*STNLayer); ok { // STNpToGPeIn -- stronger to drive burst of activity
-				pj.WtScale.Abs = 0.5
+				pj.PrjnScale.Abs = 0.5
 			}
 		case GPeOut:
 		case GPeTA:
 			if _, ok := pj.Send.(*GPLayer); ok { // GPeInToGPeTA
-				pj.WtScale.Abs = 0.9 // just enough to knock down to near-zero at baseline
+				pj.PrjnScale.Abs = 0.9 // just enough to knock down to near-zero at baseline
 			}
 		}
 	}
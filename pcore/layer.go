@@ -8,37 +8,118 @@ import (
 	"fmt"
 
 	"github.com/emer/axon/axon"
-	"github.com/emer/axon/chans"
 	"github.com/goki/ki/kit"
 	"github.com/goki/mat32"
 )
 
 // Layer is the base layer type for PCore framework.
-// Adds a dopamine variable to base Axon layer type.
+// Adds a dopamine variable to base Axon layer type, and records the
+// maximum activation of each neuron over the trial (AlphaMax), after
+// an initial AlphaMaxCyc cycles, which is used for gating and learning.
 type Layer struct {
-	chans.AlphaMaxLayer
-	DA float32 `inactive:"+" desc:"dopamine value for this layer"`
+	axon.Layer
+	AlphaMaxCyc int       `def:"30" min:"0" desc:"cycle within the trial after which the AlphaMax maximum activation is recorded -- avoids the initial transient activity"`
+	DA          float32   `inactive:"+" desc:"dopamine value for this layer"`
+	AlphaMaxs   []float32 `desc:"per-neuron maximum activation over the trial, after AlphaMaxCyc"`
 }
 
 var KiT_Layer = kit.Types.AddType(&Layer{}, axon.LayerProps)
 
+func (ly *Layer) Defaults() {
+	ly.Layer.Defaults()
+	ly.AlphaMaxCyc = 30
+}
+
 // DALayer interface:
 
 func (ly *Layer) GetDA() float32   { return ly.DA }
 func (ly *Layer) SetDA(da float32) { ly.DA = da }
 
+// Build constructs the layer state, including calling Build on the projections.
+func (ly *Layer) Build() error {
+	err := ly.Layer.Build()
+	if err != nil {
+		return err
+	}
+	ly.AlphaMaxs = make([]float32, len(ly.Neurons))
+	return nil
+}
+
+// CheckpointVals returns the DA and AlphaMaxs state that is saved in a
+// Checkpoint (see axon.Checkpointer) -- derived types append their own
+func (ly *Layer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA, &ly.AlphaMaxs}
+}
+
+// InitAlphaMax initializes the AlphaMax to 0
+func (ly *Layer) InitAlphaMax() {
+	for ni := range ly.AlphaMaxs {
+		ly.AlphaMaxs[ni] = 0
+	}
+}
+
+// AlphaMaxFmAct updates the AlphaMax values from the current activations,
+// after AlphaMaxCyc
+func (ly *Layer) AlphaMaxFmAct(ltime *axon.Time) {
+	if ltime.Cycle < ly.AlphaMaxCyc {
+		return
+	}
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		ly.AlphaMaxs[ni] = mat32.Max(ly.AlphaMaxs[ni], nrn.Act)
+	}
+}
+
+// PoolAlphaMax returns the maximum AlphaMax across the neurons in given
+// pool index (0 = whole layer, 1..N = sub-pools, as in Neuron.SubPool)
+func (ly *Layer) PoolAlphaMax(pi int) float32 {
+	pl := &ly.Pools[pi]
+	mx := float32(0)
+	for ni := pl.StIdx; ni < pl.EdIdx; ni++ {
+		mx = mat32.Max(mx, ly.AlphaMaxs[ni])
+	}
+	return mx
+}
+
+// MaxAlphaMax returns the maximum AlphaMax across the layer
+func (ly *Layer) MaxAlphaMax() float32 {
+	return ly.PoolAlphaMax(0)
+}
+
+func (ly *Layer) InitActs() {
+	ly.Layer.InitActs()
+	ly.DA = 0
+	ly.InitAlphaMax()
+}
+
+// NewState handles all initialization at start of new input state,
+// including resetting the AlphaMax
+func (ly *Layer) NewState() {
+	ly.Layer.NewState()
+	ly.InitAlphaMax()
+}
+
+// CyclePost is called after the standard Cycle update, and updates the AlphaMax
+func (ly *Layer) CyclePost(ltime *axon.Time) {
+	ly.Layer.CyclePost(ltime)
+	ly.AlphaMaxFmAct(ltime)
+}
+
 // UnitVarIdx returns the index of given variable within the Neuron,
 // according to UnitVarNames() list (using a map to lookup index),
 // or -1 and error message if not found.
 func (ly *Layer) UnitVarIdx(varNm string) (int, error) {
-	vidx, err := ly.AlphaMaxLayer.UnitVarIdx(varNm)
+	vidx, err := ly.Layer.UnitVarIdx(varNm)
 	if err == nil {
 		return vidx, err
 	}
 	if varNm != "DA" {
 		return -1, fmt.Errorf("pcore.Layer: variable named: %s not found", varNm)
 	}
-	nn := ly.AlphaMaxLayer.UnitVarNum()
+	nn := ly.Layer.UnitVarNum()
 	return nn, nil
 }
 
@@ -47,12 +128,12 @@ func (ly *Layer) UnitVarIdx(varNm string) (int, error) {
 // This is the core unit var access method used by other methods,
 // so it is the only one that needs to be updated for derived layer types.
 func (ly *Layer) UnitVal1D(varIdx int, idx int) float32 {
-	nn := ly.AlphaMaxLayer.UnitVarNum()
+	nn := ly.Layer.UnitVarNum()
 	if varIdx < 0 || varIdx > nn { // nn = DA
 		return mat32.NaN()
 	}
 	if varIdx < nn {
-		return ly.AlphaMaxLayer.UnitVal1D(varIdx, idx)
+		return ly.Layer.UnitVal1D(varIdx, idx)
 	}
 	if idx < 0 || idx >= len(ly.Neurons) {
 		return mat32.NaN()
@@ -63,10 +144,5 @@ func (ly *Layer) UnitVal1D(varIdx int, idx int) float32 {
 // UnitVarNum returns the number of Neuron-level variables
 // for this layer.  This is needed for extending indexes in derived types.
 func (ly *Layer) UnitVarNum() int {
-	return ly.AlphaMaxLayer.UnitVarNum() + 1
-}
-
-func (ly *Layer) InitActs() {
-	ly.Layer.InitActs()
-	ly.DA = 0
+	return ly.Layer.UnitVarNum() + 1
 }
@@ -48,10 +48,11 @@ func (mp *MatrixParams) LrnFactor(act float32) float32 {
 // Go / NoGo gating units in BG.  D1R = Go, D2R = NoGo.
 type MatrixLayer struct {
 	Layer
-	DaR    DaReceptors  `desc:"dominant type of dopamine receptor -- D1R for Go pathway, D2R for NoGo"`
-	Matrix MatrixParams `view:"inline" desc:"matrix parameters"`
-	DALrn  float32      `inactive:"+" desc:"effective learning dopamine value for this layer: reflects DaR and Gains"`
-	ACh    float32      `inactive:"+" desc:"acetylcholine value from CIN cholinergic interneurons reflecting the absolute value of reward or CS predictions thereof -- used for resetting the trace of matrix learning"`
+	DaR     DaReceptors  `desc:"dominant type of dopamine receptor -- D1R for Go pathway, D2R for NoGo"`
+	Matrix  MatrixParams `view:"inline" desc:"matrix parameters"`
+	DALrn   float32      `inactive:"+" desc:"effective learning dopamine value for this layer: reflects DaR and Gains"`
+	ACh     float32      `inactive:"+" desc:"acetylcholine value from CIN cholinergic interneurons reflecting the absolute value of reward or CS predictions thereof -- used for resetting the trace of matrix learning"`
+	ActLrns []float32    `desc:"per-neuron learning activation: LrnFactor of AlphaMax, positive if the corresponding VThal stripe gated (AlphaMax > ThalThr), and negative otherwise"`
 }
 
 var KiT_MatrixLayer = kit.Types.AddType(&MatrixLayer{}, axon.LayerProps)
@@ -66,12 +67,11 @@ var KiT_MatrixLayer = kit.Types.AddType(&MatrixLayer{}, axon.LayerProps)
 // 		"Layer.Inhib.Self.On":      "true",
 // 		"Layer.Inhib.Self.Gi":      "0.3", // 0.6 in localist -- expt
 // 		"Layer.Inhib.Self.Tau":     "3.0",
-// 		"Layer.Inhib.ActAvg.Fixed": "true",
 // 		"Layer.Inhib.ActAvg.Init":  "0.25",
-// 		"Layer.Act.XX1.Gain":       "20", // more graded -- still works with 40 but less Rt distrib
 // 		"Layer.Act.Dt.VmTau":       "3.3",
-// 		"Layer.Act.Dt.GTau":        "3",
-// 		"Layer.Act.Init.Decay":     "0",
+// 		"Layer.Act.Dt.GeTau":       "3",
+// 		"Layer.Act.Decay.Act":      "0",
+// 		"Layer.Act.Decay.Glong":    "0",
 // 	}}
 
 func (ly *MatrixLayer) Defaults() {
@@ -86,12 +86,11 @@ func (ly *MatrixLayer) Defaults() {
 	ly.Inhib.Self.On = true
 	ly.Inhib.Self.Gi = 0.3 // 0.6 in localist one
 	ly.Inhib.Self.Tau = 3.0
-	ly.Inhib.ActAvg.Fixed = true
 	ly.Inhib.ActAvg.Init = 0.25
-	ly.Act.XX1.Gain = 20  // more graded -- still works with 40 but less Rt distrib
 	ly.Act.Dt.VmTau = 3.3 // fastest
-	ly.Act.Dt.GTau = 3
-	ly.Act.Init.Decay = 0
+	ly.Act.Dt.GeTau = 3
+	ly.Act.Decay.Act = 0
+	ly.Act.Decay.Glong = 0
 
 	// important: user needs to adjust wt scale of some PFC inputs vs others:
 	// drivers vs. modulators
@@ -99,21 +98,20 @@ func (ly *MatrixLayer) Defaults() {
 	for _, pji := range ly.RcvPrjns {
 		pj := pji.(axon.AxonPrjn).AsAxon()
 		if _, ok := pj.Send.(*GPLayer); ok { // From GPe TA or In
-			pj.WtScale.Abs = 3
+			pj.PrjnScale.Abs = 3
 			pj.Learn.Learn = false
-			pj.Learn.Norm.On = false
-			pj.Learn.Momentum.On = false
-			pj.Learn.WtSig.Gain = 1
-			pj.WtInit.Mean = 0.9
-			pj.WtInit.Var = 0
-			pj.WtInit.Sym = false
+			pj.SWt.Adapt.On = false
+			pj.SWt.Adapt.SigGain = 1
+			pj.SWt.Init.Mean = 0.9
+			pj.SWt.Init.Var = 0
+			pj.SWt.Init.Sym = false
 			if strings.HasSuffix(pj.Send.Name(), "GPeIn") { // GPeInToMtx
-				pj.WtScale.Abs = 0.3 // counterbalance for GPeTA to reduce oscillations
+				pj.PrjnScale.Abs = 0.3 // counterbalance for GPeTA to reduce oscillations
 			} else if strings.HasSuffix(pj.Send.Name(), "GPeTA") { // GPeTAToMtx
 				if strings.HasSuffix(ly.Nm, "MtxGo") {
-					pj.WtScale.Abs = 0.8
+					pj.PrjnScale.Abs = 0.8
 				} else {
-					pj.WtScale.Abs = 0.3 // GPeTAToMtxNo must be weaker to prevent oscillations, even with GPeIn offset
+					pj.PrjnScale.Abs = 0.3 // GPeTAToMtxNo must be weaker to prevent oscillations, even with GPeIn offset
 				}
 			}
 		}
@@ -141,11 +139,30 @@ func (ly *MatrixLayer) InitActs() {
 	ly.DA = 0
 	ly.DALrn = 0
 	ly.ACh = 0
+	for ni := range ly.ActLrns {
+		ly.ActLrns[ni] = 0
+	}
+}
+
+// Build constructs the layer state, including calling Build on the projections.
+func (ly *MatrixLayer) Build() error {
+	err := ly.Layer.Build()
+	if err != nil {
+		return err
+	}
+	ly.ActLrns = make([]float32, len(ly.Neurons))
+	return nil
+}
+
+// CheckpointVals returns the MatrixLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *MatrixLayer) CheckpointVals() []interface{} {
+	return append(ly.Layer.CheckpointVals(), &ly.DALrn, &ly.ACh, &ly.ActLrns)
 }
 
 // ActFmG computes rate-code activation from Ge, Gi, Gl conductances
 // and updates learning running-average activations from that Act.
-// Matrix extends to call DALrnFmDA and updates AlphaMax -> ActLrn
+// Matrix extends to call DAActLrn and updates AlphaMax -> ActLrns
 func (ly *MatrixLayer) ActFmG(ltime *axon.Time) {
 	ly.Layer.ActFmG(ltime)
 	ly.DAActLrn(ltime)
@@ -153,7 +170,7 @@ func (ly *MatrixLayer) ActFmG(ltime *axon.Time) {
 
 // DAActLrn sets effective learning dopamine value from given raw DA value,
 // applying Burst and Dip Gain factors, and then reversing sign for D2R.
-// Also sets ActLrn based on whether corresponding VThal stripe fired
+// Also sets ActLrns based on whether corresponding VThal stripe fired
 // above ThalThr -- flips sign of learning for stripe firing vs. not.
 func (ly *MatrixLayer) DAActLrn(ltime *axon.Time) {
 	da := ly.DA
@@ -179,11 +196,11 @@ func (ly *MatrixLayer) DAActLrn(ltime *axon.Time) {
 			continue
 		}
 		amax := ly.Matrix.LrnFactor(ly.AlphaMaxs[ni])
-		tact := tly.AlphaMaxs[nrn.SubPool-1]
+		tact := tly.PoolAlphaMax(int(nrn.SubPool))
 		if tact > ly.Matrix.ThalThr {
-			nrn.ActLrn = amax
+			ly.ActLrns[ni] = amax
 		} else {
-			nrn.ActLrn = -amax
+			ly.ActLrns[ni] = -amax
 		}
 	}
 }
@@ -266,10 +283,8 @@ func (pj *MatrixPrjn) Defaults() {
 	pj.Prjn.Defaults()
 	pj.Trace.Defaults()
 	// no additional factors
-	pj.Learn.WtSig.Gain = 1
-	pj.Learn.Norm.On = false
-	pj.Learn.Momentum.On = false
-	pj.Learn.WtBal.On = false
+	pj.SWt.Adapt.On = false
+	pj.SWt.Adapt.SigGain = 1
 }
 
 func (pj *MatrixPrjn) Build() error {
@@ -278,6 +293,12 @@ func (pj *MatrixPrjn) Build() error {
 	return err
 }
 
+// CheckpointVals returns the trace synapse state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (pj *MatrixPrjn) CheckpointVals() []interface{} {
+	return []interface{}{&pj.TrSyns}
+}
+
 func (pj *MatrixPrjn) ClearTrace() {
 	for si := range pj.TrSyns {
 		sy := &pj.TrSyns[si]
@@ -293,7 +314,7 @@ func (pj *MatrixPrjn) InitWts() {
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *MatrixPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
@@ -307,8 +328,7 @@ func (pj *MatrixPrjn) DWt() {
 
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		trsyns := pj.TrSyns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
@@ -317,11 +337,9 @@ func (pj *MatrixPrjn) DWt() {
 			sy := &syns[ci]
 			trsy := &trsyns[ci]
 			ri := scons[ci]
-			rn := &rlay.Neurons[ri]
-
 			tr := trsy.Tr
 
-			ntr := rn.ActLrn * sn.ActLrn
+			ntr := rlay.ActLrns[ri] * sn.ActM
 			dwt := float32(0)
 
 			if pj.Trace.CurTrlDA {
@@ -339,33 +357,7 @@ func (pj *MatrixPrjn) DWt() {
 			trsy.Tr = tr
 			trsy.NTr = ntr
 
-			norm := float32(1)
-			if pj.Learn.Norm.On {
-				norm = pj.Learn.Norm.NormFmAbsDWt(&sy.Norm, mat32.Abs(dwt))
-			} else {
-				sy.Norm = trsy.NTr // store in norm, moment!
-				sy.Moment = trsy.Tr
-			}
-			if pj.Learn.Momentum.On {
-				dwt = norm * pj.Learn.Momentum.MomentFmDWt(&sy.Moment, dwt)
-			} else {
-				dwt *= norm
-			}
-			sy.DWt += pj.Learn.Lrate * dwt
-		}
-		// aggregate max DWtNorm over sending synapses
-		if pj.Learn.Norm.On {
-			maxNorm := float32(0)
-			for ci := range syns {
-				sy := &syns[ci]
-				if sy.Norm > maxNorm {
-					maxNorm = sy.Norm
-				}
-			}
-			for ci := range syns {
-				sy := &syns[ci]
-				sy.Norm = maxNorm
-			}
+			sy.DWt += pj.Learn.Lrate.Eff * dwt
 		}
 	}
 }
@@ -411,6 +403,24 @@ func (pj *MatrixPrjn) SynVal1D(varIdx int, synIdx int) float32 {
 	return sy.VarByIndex(varIdx)
 }
 
+// SetSynVal1D sets value of given variable index (from SynVarIdx) on given SynIdx.
+// Returns false on invalid index.
+func (pj *MatrixPrjn) SetSynVal1D(varIdx int, synIdx int, val float32) bool {
+	nn := pj.Prjn.SynVarNum()
+	if varIdx < nn {
+		return pj.Prjn.SetSynVal1D(varIdx, synIdx, val)
+	}
+	if synIdx < 0 || synIdx >= len(pj.TrSyns) {
+		return false
+	}
+	return pj.TrSyns[synIdx].SetVarByIndex(varIdx-nn, val)
+}
+
+// SynVarNames returns the names of all the variables on the synapses in this prjn
+func (pj *MatrixPrjn) SynVarNames() []string {
+	return SynVarsAll
+}
+
 // SynVarNum returns the number of synapse-level variables
 // for this prjn.  This is needed for extending indexes in derived types.
 func (pj *MatrixPrjn) SynVarNum() int {
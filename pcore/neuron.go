@@ -28,10 +28,7 @@ func init() {
 	copy(NeuronVarsAll, axon.NeuronVars)
 	copy(NeuronVarsAll[ln:], NeuronVars)
 
-	ln = len(axon.SynapseVars)
-	SynVarsAll = make([]string, len(TraceSynVars)+ln)
-	copy(SynVarsAll, axon.SynapseVars)
-	copy(SynVarsAll[ln:], TraceSynVars)
+	SynVarsAll = axon.SynapseVarsExtend(TraceSynVars...)
 }
 
 //////////////////////////////////////////////////////////////////////
@@ -17,18 +17,18 @@ import (
 // and we use their logistic function for computing KCa conductance based on Ca,
 // but we use a simpler approximation with burst and act threshold.
 // KCa are Calcium-gated potassium channels that drive the long
-// afterhyperpolarization of STN neurons.  Auto reset at each AlphaCycle.
-// The conductance is applied to KNa channels to take advantage
-// of the existing infrastructure.
+// afterhyperpolarization of STN neurons.  Optionally reset at the start
+// of each trial (NewState).  The conductance is added to the Gk
+// potassium conductance, along with GABA-B.
 type CaParams struct {
 	BurstThr  float32 `def:"0.9" desc:"activation threshold for bursting that drives strong influx of Ca to turn on KCa channels -- there is a complex de-inactivation dynamic involving the volley of excitation and inhibition from GPe, but we can just use a threshold"`
 	ActThr    float32 `def:"0.7" desc:"activation threshold for increment in activation above baseline that drives lower influx of Ca"`
 	BurstCa   float32 `def:"1" desc:"Ca level for burst level activation"`
 	ActCa     float32 `def:"0.2" desc:"Ca increment from regular sub-burst activation -- drives slower inhibition of firing over time -- for stop-type STN dynamics that initially put hold on GPi and then decay"`
-	GbarKCa   float32 `def:"10" desc:"maximal KCa conductance (actual conductance is applied to KNa channels)"`
-	KCaTau    float32 `def:"20" desc:"KCa conductance time constant -- 40 from Gillies & Willshaw, 2006, but sped up here to fit in AlphaCyc"`
-	CaTau     float32 `def:"50" desc:"Ca time constant of decay to baseline -- 185.7 from Gillies & Willshaw, 2006, but sped up here to fit in AlphaCyc"`
-	AlphaInit bool    `desc:"initialize Ca, KCa values at start of every AlphaCycle"`
+	GbarKCa   float32 `def:"10" desc:"maximal KCa conductance (added to the Gk potassium conductance)"`
+	KCaTau    float32 `def:"20" desc:"KCa conductance time constant -- 40 from Gillies & Willshaw, 2006, but sped up here to fit in a trial"`
+	CaTau     float32 `def:"50" desc:"Ca time constant of decay to baseline -- 185.7 from Gillies & Willshaw, 2006, but sped up here to fit in a trial"`
+	AlphaInit bool    `desc:"initialize Ca, KCa values at start of every trial (NewState)"`
 }
 
 func (kc *CaParams) Defaults() {
@@ -78,12 +78,11 @@ var KiT_STNLayer = kit.Types.AddType(&STNLayer{}, axon.LayerProps)
 // 		"Layer.Inhib.Self.On":      "true",
 // 		"Layer.Inhib.Self.Gi":      "0.4",
 // 		"Layer.Inhib.Self.Tau":     "3.0",
-// 		"Layer.Inhib.ActAvg.Fixed": "true",
 // 		"Layer.Inhib.ActAvg.Init":  "0.25",
-// 		"Layer.Act.XX1.Gain":       "20", // more graded -- still works with 40 but less Rt distrib
 // 		"Layer.Act.Dt.VmTau":       "3.3",
-// 		"Layer.Act.Dt.GTau":        "3",
-// 		"Layer.Act.Init.Decay":     "0",
+// 		"Layer.Act.Dt.GeTau":       "3",
+// 		"Layer.Act.Decay.Act":      "0",
+// 		"Layer.Act.Decay.Glong":    "0",
 // }}
 
 func (ly *STNLayer) Defaults() {
@@ -102,12 +101,11 @@ func (ly *STNLayer) Defaults() {
 	ly.Inhib.Self.On = true
 	ly.Inhib.Self.Gi = 0.4 // 0.4 in localist one
 	ly.Inhib.Self.Tau = 3.0
-	ly.Inhib.ActAvg.Fixed = true
 	ly.Inhib.ActAvg.Init = 0.25
-	ly.Act.XX1.Gain = 20 // more graded -- still works with 40 but less Rt distrib
 	ly.Act.Dt.VmTau = 3.3
-	ly.Act.Dt.GTau = 3 // fastest
-	ly.Act.Init.Decay = 0
+	ly.Act.Dt.GeTau = 3 // fastest
+	ly.Act.Decay.Act = 0
+	ly.Act.Decay.Glong = 0
 
 	if strings.HasSuffix(ly.Nm, "STNp") {
 		ly.Act.Init.Act = 0.48
@@ -116,21 +114,20 @@ func (ly *STNLayer) Defaults() {
 	for _, pji := range ly.RcvPrjns {
 		pj := pji.(axon.AxonPrjn).AsAxon()
 		pj.Learn.Learn = false
-		pj.Learn.Norm.On = false
-		pj.Learn.Momentum.On = false
-		pj.Learn.WtSig.Gain = 1
-		pj.WtInit.Mean = 0.9
-		pj.WtInit.Var = 0
-		pj.WtInit.Sym = false
+		pj.SWt.Adapt.On = false
+		pj.SWt.Adapt.SigGain = 1
+		pj.SWt.Init.Mean = 0.9
+		pj.SWt.Init.Var = 0
+		pj.SWt.Init.Sym = false
 		if strings.HasSuffix(ly.Nm, "STNp") {
 			if _, ok := pj.Send.(*GPLayer); ok { // GPeInToSTNp
-				pj.WtScale.Abs = 0.1
+				pj.PrjnScale.Abs = 0.1
 			}
 		} else { // STNs
 			if _, ok := pj.Send.(*GPLayer); ok { // GPeInToSTNs
-				pj.WtScale.Abs = 0.1 // note: not currently used -- interferes with threshold-based Ca self-inhib dynamics
+				pj.PrjnScale.Abs = 0.1 // note: not currently used -- interferes with threshold-based Ca self-inhib dynamics
 			} else {
-				pj.WtScale.Abs = 0.2 // weaker inputs
+				pj.PrjnScale.Abs = 0.2 // weaker inputs
 			}
 		}
 	}
@@ -152,11 +149,10 @@ func (ly *STNLayer) InitActs() {
 	}
 }
 
-// AlphaCycInit handles all initialization at start of new input pattern, including computing
-// input scaling from running average activation etc.
-// should already have presented the external input to the network at this point.
-func (ly *STNLayer) AlphaCycInit() {
-	ly.Layer.AlphaCycInit()
+// NewState handles all initialization at start of new input state,
+// including resetting Ca and KCa if Ca.AlphaInit.
+func (ly *STNLayer) NewState() {
+	ly.Layer.NewState()
 	if !ly.Ca.AlphaInit {
 		return
 	}
@@ -165,22 +161,22 @@ func (ly *STNLayer) AlphaCycInit() {
 		if nrn.IsOff() {
 			continue
 		}
-		nrn.Gk = 0
 		snr := &ly.STNNeurs[ni]
 		snr.Ca = 0
 		snr.KCa = 0
 	}
 }
 
+// ActFmG computes the standard activation from conductances, and then
+// updates the Ca and KCa state, adding the KCa conductance to Gk
+// for the next cycle.
 func (ly *STNLayer) ActFmG(ltime *axon.Time) {
-	for ni := range ly.Neurons { // note: copied from axon ActFmG, not calling it..
+	ly.Layer.ActFmG(ltime)
+	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
-		ly.Act.VmFmG(nrn)
-		ly.Act.ActFmG(nrn)
-
 		snr := &ly.STNNeurs[ni]
 		snr.KCa += (ly.Ca.KCaGFmCa(snr.Ca) - snr.KCa) / ly.Ca.KCaTau
 		dCa := -snr.Ca / ly.Ca.CaTau
@@ -191,9 +187,7 @@ func (ly *STNLayer) ActFmG(ltime *axon.Time) {
 			dCa += (nrn.Act - ly.Ca.ActThr) * ly.Ca.ActCa
 		}
 		snr.Ca += dCa
-		nrn.Gk = ly.Ca.GbarKCa * snr.KCa
-
-		ly.Learn.AvgsFmAct(nrn)
+		nrn.Gk += ly.Ca.GbarKCa * snr.KCa
 	}
 }
 
@@ -207,6 +201,12 @@ func (ly *STNLayer) Build() error {
 	return nil
 }
 
+// CheckpointVals returns the STNLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *STNLayer) CheckpointVals() []interface{} {
+	return append(ly.Layer.CheckpointVals(), &ly.STNNeurs)
+}
+
 // UnitVarIdx returns the index of given variable within the Neuron,
 // according to UnitVarNames() list (using a map to lookup index),
 // or -1 and error message if not found.
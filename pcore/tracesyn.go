@@ -8,7 +8,7 @@ import "github.com/goki/mat32"
 
 // TraceSyn holds extra synaptic state for trace projections
 type TraceSyn struct {
-	NTr float32 `desc:"new trace = send * recv -- drives updates to trace value: sn.ActLrn * rn.ActLrn (subject to derivative too)"`
+	NTr float32 `desc:"new trace = send * recv -- drives updates to trace value: sn.ActM * rn ActLrns (subject to derivative too)"`
 	Tr  float32 `desc:" current ongoing trace of activations, which drive learning -- adds ntr and clears after ACh-modulated learning on current values"`
 }
 
@@ -34,4 +34,17 @@ func (sy *TraceSyn) VarByIndex(varIdx int) float32 {
 	return mat32.NaN()
 }
 
+// SetVarByIndex sets synapse variable by index, returning false if invalid
+func (sy *TraceSyn) SetVarByIndex(varIdx int, val float32) bool {
+	switch varIdx {
+	case 0:
+		sy.NTr = val
+	case 1:
+		sy.Tr = val
+	default:
+		return false
+	}
+	return true
+}
+
 var TraceSynVars = []string{"NTr", "Tr"}
@@ -13,8 +13,15 @@ import (
 
 // VThalLayer represents the Ventral thalamus: VA / VM / VL,
 // which receives BG gating in the form of inhibitory projection from GPi.
+// It provides the gating signal output of the BG circuit: each pool
+// (stripe) gates when its AlphaMax activation exceeds GateThr,
+// as recorded in Gated and GateCycs, for use in driving PFC
+// maintenance and output gating, or action selection.
 type VThalLayer struct {
 	Layer
+	GateThr  float32 `def:"0.25" desc:"threshold on the AlphaMax activation of a pool (stripe) for it to count as having gated"`
+	Gated    []bool  `inactive:"+" desc:"per pool (stripe, index = SubPool-1): true if the stripe gated on the current trial"`
+	GateCycs []int   `inactive:"+" desc:"per pool (stripe, index = SubPool-1): cycle within the trial when the stripe gated, or -1 if not gated"`
 }
 
 var KiT_VThalLayer = kit.Types.AddType(&VThalLayer{}, axon.LayerProps)
@@ -27,16 +34,16 @@ var KiT_VThalLayer = kit.Types.AddType(&VThalLayer{}, axon.LayerProps)
 // 		"Layer.Inhib.Self.On":      "true",
 // 		"Layer.Inhib.Self.Gi":      "0.4",
 // 		"Layer.Inhib.Self.Tau":     "3.0",
-// 		"Layer.Inhib.ActAvg.Fixed": "true",
 // 		"Layer.Inhib.ActAvg.Init":  "0.25",
-// 		"Layer.Act.XX1.Gain":       "20", // more graded -- still works with 40 but less Rt distrib
 // 		"Layer.Act.Dt.VmTau":       "3.3",
-// 		"Layer.Act.Dt.GTau":        "3", // fastest
-// 		"Layer.Act.Init.Decay":     "0",
+// 		"Layer.Act.Dt.GeTau":       "3", // fastest
+// 		"Layer.Act.Decay.Act":      "0",
+// 		"Layer.Act.Decay.Glong":    "0",
 // }}
 
 func (ly *VThalLayer) Defaults() {
 	ly.Layer.Defaults()
+	ly.GateThr = 0.25
 
 	// note: not tonically active
 
@@ -45,26 +52,124 @@ func (ly *VThalLayer) Defaults() {
 	ly.Inhib.Self.On = true
 	ly.Inhib.Self.Gi = 0.4 // 0.4 in localist one
 	ly.Inhib.Self.Tau = 3.0
-	ly.Inhib.ActAvg.Fixed = true
 	ly.Inhib.ActAvg.Init = 0.25
-	ly.Act.XX1.Gain = 20 // more graded -- still works with 40 but less Rt distrib
 	ly.Act.Dt.VmTau = 3.3
-	ly.Act.Dt.GTau = 3 // fastest
-	ly.Act.Init.Decay = 0
+	ly.Act.Dt.GeTau = 3 // fastest
+	ly.Act.Decay.Act = 0
+	ly.Act.Decay.Glong = 0
 
 	for _, pji := range ly.RcvPrjns {
 		pj := pji.(axon.AxonPrjn).AsAxon()
 		pj.Learn.Learn = false
-		pj.Learn.Norm.On = false
-		pj.Learn.Momentum.On = false
-		pj.Learn.WtSig.Gain = 1
-		pj.WtInit.Mean = 0.9
-		pj.WtInit.Var = 0
-		pj.WtInit.Sym = false
+		pj.SWt.Adapt.On = false
+		pj.SWt.Adapt.SigGain = 1
+		pj.SWt.Init.Mean = 0.9
+		pj.SWt.Init.Var = 0
+		pj.SWt.Init.Sym = false
 		if strings.HasSuffix(pj.Send.Name(), "GPi") { // GPiToVThal
-			pj.WtScale.Abs = 2.5 // 2.5 needed for agate model..
+			pj.PrjnScale.Abs = 2.5 // 2.5 needed for agate model..
 		}
 	}
 
 	ly.UpdateParams()
 }
+
+// NStripes returns the number of gating stripes (pools) in the layer:
+// 1 for a 2D layer, else the number of sub-pools
+func (ly *VThalLayer) NStripes() int {
+	np := len(ly.Pools) - 1
+	if np < 1 {
+		return 1
+	}
+	return np
+}
+
+// Build constructs the layer state, including calling Build on the projections.
+func (ly *VThalLayer) Build() error {
+	err := ly.Layer.Build()
+	if err != nil {
+		return err
+	}
+	ns := ly.NStripes()
+	ly.Gated = make([]bool, ns)
+	ly.GateCycs = make([]int, ns)
+	ly.InitGated()
+	return nil
+}
+
+// CheckpointVals returns the VThalLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *VThalLayer) CheckpointVals() []interface{} {
+	return append(ly.Layer.CheckpointVals(), &ly.Gated, &ly.GateCycs)
+}
+
+// InitGated resets the gating state
+func (ly *VThalLayer) InitGated() {
+	for si := range ly.Gated {
+		ly.Gated[si] = false
+		ly.GateCycs[si] = -1
+	}
+}
+
+func (ly *VThalLayer) InitActs() {
+	ly.Layer.InitActs()
+	ly.InitGated()
+}
+
+// NewState handles all initialization at start of new input state,
+// including resetting the gating state
+func (ly *VThalLayer) NewState() {
+	ly.Layer.NewState()
+	ly.InitGated()
+}
+
+// CyclePost is called after the standard Cycle update, and updates
+// the AlphaMax and gating state
+func (ly *VThalLayer) CyclePost(ltime *axon.Time) {
+	ly.Layer.CyclePost(ltime)
+	ly.GatedFmAlphaMax(ltime)
+}
+
+// GatedFmAlphaMax records gating for each stripe whose AlphaMax
+// exceeds GateThr, at the first cycle when it does so
+func (ly *VThalLayer) GatedFmAlphaMax(ltime *axon.Time) {
+	if ltime.Cycle < ly.AlphaMaxCyc {
+		return
+	}
+	np := len(ly.Pools) - 1
+	for si := range ly.Gated {
+		if ly.Gated[si] {
+			continue
+		}
+		pi := si + 1
+		if np < 1 {
+			pi = 0
+		}
+		if ly.PoolAlphaMax(pi) > ly.GateThr {
+			ly.Gated[si] = true
+			ly.GateCycs[si] = ltime.Cycle
+		}
+	}
+}
+
+// AnyGated returns true if any stripe gated on the current trial
+func (ly *VThalLayer) AnyGated() bool {
+	for _, g := range ly.Gated {
+		if g {
+			return true
+		}
+	}
+	return false
+}
+
+// GatedStripes returns the indexes of the stripes that gated on the
+// current trial, in order
+func (ly *VThalLayer) GatedStripes() []int {
+	var gs []int
+	for si, g := range ly.Gated {
+		if g {
+			gs = append(gs, si)
+		}
+	}
+	return gs
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package popcode provides population-coding encoders and decoders, which
+represent scalar (OneD) or 2D vector (TwoD) values as a Gaussian bump of
+activity over a 1D or 2D layer of units, each with a preferred value
+evenly spaced over the range, and read out estimates of the encoded
+value from layer activity (e.g., ActM) as the activity-weighted average
+of the preferred values.  The Wrap options treat the range as circular
+(e.g., for angles), so that the bump wraps around the ends, and decoding
+uses the circular (vector) average.
+*/
+package popcode
+
+import (
+	"github.com/goki/mat32"
+)
+
+// OneD encodes a scalar value into a 1D population code, and decodes it
+type OneD struct {
+	Min    float32 `desc:"minimum value of the range -- the preferred value of the first unit"`
+	Max    float32 `desc:"maximum value of the range -- the preferred value of the last unit, or, if Wrap, of a virtual unit after the last, equivalent to the first"`
+	Sigma  float32 `def:"0.2" desc:"width of the Gaussian bump, as a proportion of the range"`
+	Wrap   bool    `desc:"range is circular, e.g., for angles: Max is equivalent to Min, and distances wrap around"`
+	Clip   bool    `def:"true" desc:"clip values to the Min-Max range when encoding (does not apply to Wrap)"`
+	Thr    float32 `def:"0.1" desc:"threshold on unit activity for contributing to decoding"`
+	MinSum float32 `def:"0.2" desc:"minimum total above-threshold activity for decoding -- if less, Decode returns NaN"`
+}
+
+func (pc *OneD) Defaults() {
+	pc.Min = -0.5
+	pc.Max = 1.5
+	pc.Sigma = 0.2
+	pc.Clip = true
+	pc.Thr = 0.1
+	pc.MinSum = 0.2
+}
+
+// SetRange sets the range, sigma and wrap parameters
+func (pc *OneD) SetRange(min, max, sigma float32, wrap bool) {
+	pc.Min = min
+	pc.Max = max
+	pc.Sigma = sigma
+	pc.Wrap = wrap
+}
+
+// UnitVal returns the preferred value of unit at given index, out of n units
+func (pc *OneD) UnitVal(idx, n int) float32 {
+	return unitVal(pc.Min, pc.Max, idx, n, pc.Wrap)
+}
+
+// Encode sets given pattern of n units (only resized if not big enough)
+// to the population code for given value
+func (pc *OneD) Encode(pat *[]float32, val float32, n int) {
+	if cap(*pat) < n {
+		*pat = make([]float32, n)
+	} else {
+		*pat = (*pat)[:n]
+	}
+	if pc.Clip && !pc.Wrap {
+		val = mat32.Clamp(val, pc.Min, pc.Max)
+	}
+	rng := pc.Max - pc.Min
+	for i := 0; i < n; i++ {
+		d := dist(val, pc.UnitVal(i, n), rng, pc.Wrap) / (pc.Sigma * rng)
+		(*pat)[i] = mat32.FastExp(-0.5 * d * d)
+	}
+}
+
+// Decode returns the value decoded from given pattern of unit activities,
+// or NaN if the total above-threshold activity is less than MinSum
+func (pc *OneD) Decode(pat []float32) float32 {
+	n := len(pat)
+	var sum, vsum, csum, ssum float32
+	for i, act := range pat {
+		if act < pc.Thr {
+			continue
+		}
+		sum += act
+		uv := pc.UnitVal(i, n)
+		if pc.Wrap {
+			ang := angle(uv, pc.Min, pc.Max)
+			csum += act * mat32.Cos(ang)
+			ssum += act * mat32.Sin(ang)
+		} else {
+			vsum += act * uv
+		}
+	}
+	if sum < pc.MinSum || sum == 0 {
+		return mat32.NaN()
+	}
+	if pc.Wrap {
+		return fmAngle(mat32.Atan2(ssum, csum), pc.Min, pc.Max)
+	}
+	return vsum / sum
+}
+
+// Vals sets given slice (only resized if not big enough) to the
+// preferred values of n units
+func (pc *OneD) Vals(vals *[]float32, n int) {
+	if cap(*vals) < n {
+		*vals = make([]float32, n)
+	} else {
+		*vals = (*vals)[:n]
+	}
+	for i := 0; i < n; i++ {
+		(*vals)[i] = pc.UnitVal(i, n)
+	}
+}
+
+// unitVal returns the preferred value of unit idx of n over min-max range
+func unitVal(min, max float32, idx, n int, wrap bool) float32 {
+	if wrap {
+		return min + (max-min)*float32(idx)/float32(n)
+	}
+	if n <= 1 {
+		return 0.5 * (min + max)
+	}
+	return min + (max-min)*float32(idx)/float32(n-1)
+}
+
+// dist returns the distance from a to b, wrapped into +/- rng/2 if wrap
+func dist(a, b, rng float32, wrap bool) float32 {
+	d := a - b
+	if wrap {
+		d = mat32.Mod(d, rng)
+		if d > 0.5*rng {
+			d -= rng
+		} else if d < -0.5*rng {
+			d += rng
+		}
+	}
+	return d
+}
+
+// angle returns the angle in radians of val within the circular min-max range
+func angle(val, min, max float32) float32 {
+	return 2 * mat32.Pi * (val - min) / (max - min)
+}
+
+// fmAngle returns the value within the circular min-max range for given angle
+func fmAngle(ang, min, max float32) float32 {
+	if ang < 0 {
+		ang += 2 * mat32.Pi
+	}
+	return min + (max-min)*ang/(2*mat32.Pi)
+}
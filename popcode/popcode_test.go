@@ -0,0 +1,46 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package popcode
+
+import (
+	"testing"
+
+	"github.com/goki/mat32"
+)
+
+// difTol is the tolerance for decoded vs. encoded values
+const difTol = float32(1.0e-2)
+
+func TestOneD(t *testing.T) {
+	pc := OneD{}
+	pc.Defaults() // range extends beyond 0-1 so the bump is not truncated
+	pc.Sigma = 0.1
+	var pat []float32
+	for _, val := range []float32{0, 0.1, 0.3, 0.5, 0.7, 0.9, 1} {
+		pc.Encode(&pat, val, 41)
+		dec := pc.Decode(pat)
+		if mat32.Abs(dec-val) > difTol {
+			t.Errorf("OneD val: %v decoded: %v\n", val, dec)
+		}
+	}
+}
+
+func TestOneDWrap(t *testing.T) {
+	pc := OneD{}
+	pc.Defaults()
+	pc.SetRange(0, 360, 0.1, true)
+	var pat []float32
+	for _, val := range []float32{0, 10, 90, 180, 350} {
+		pc.Encode(&pat, val, 36)
+		dec := pc.Decode(pat)
+		dif := mat32.Abs(dec - val)
+		if dif > 180 {
+			dif = 360 - dif
+		}
+		if dif > 1 {
+			t.Errorf("OneD wrap val: %v decoded: %v\n", val, dec)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package popcode
+
+import (
+	"github.com/emer/etable/etensor"
+	"github.com/goki/mat32"
+)
+
+// TwoD encodes a 2D vector value into a 2D population code over a
+// Y x X pattern of units (X = first value, Y = second), and decodes it
+type TwoD struct {
+	Min    mat32.Vec2 `desc:"minimum values of the range, for X and Y"`
+	Max    mat32.Vec2 `desc:"maximum values of the range, for X and Y -- see OneD Max for Wrap case"`
+	Sigma  mat32.Vec2 `def:"{0.2 0.2}" desc:"width of the Gaussian bump, as a proportion of the range, for X and Y"`
+	WrapX  bool       `desc:"X range is circular, e.g., for angles"`
+	WrapY  bool       `desc:"Y range is circular, e.g., for angles"`
+	Clip   bool       `def:"true" desc:"clip values to the Min-Max range when encoding (does not apply to wrapped dimensions)"`
+	Thr    float32    `def:"0.1" desc:"threshold on unit activity for contributing to decoding"`
+	MinSum float32    `def:"0.2" desc:"minimum total above-threshold activity for decoding -- if less, Decode returns NaN values"`
+}
+
+func (pc *TwoD) Defaults() {
+	pc.Min.Set(-0.5, -0.5)
+	pc.Max.Set(1.5, 1.5)
+	pc.Sigma.Set(0.2, 0.2)
+	pc.Clip = true
+	pc.Thr = 0.1
+	pc.MinSum = 0.2
+}
+
+// SetRange sets the range and sigma parameters, the same for X and Y
+func (pc *TwoD) SetRange(min, max, sigma float32) {
+	pc.Min.Set(min, min)
+	pc.Max.Set(max, max)
+	pc.Sigma.Set(sigma, sigma)
+}
+
+// Encode sets given pattern, which must be 2D with shape Y, X, to the
+// population code for given value
+func (pc *TwoD) Encode(pat *etensor.Float32, val mat32.Vec2) {
+	ny := pat.Dim(0)
+	nx := pat.Dim(1)
+	if pc.Clip {
+		if !pc.WrapX {
+			val.X = mat32.Clamp(val.X, pc.Min.X, pc.Max.X)
+		}
+		if !pc.WrapY {
+			val.Y = mat32.Clamp(val.Y, pc.Min.Y, pc.Max.Y)
+		}
+	}
+	rng := pc.Max.Sub(pc.Min)
+	for yi := 0; yi < ny; yi++ {
+		dy := dist(val.Y, unitVal(pc.Min.Y, pc.Max.Y, yi, ny, pc.WrapY), rng.Y, pc.WrapY) / (pc.Sigma.Y * rng.Y)
+		for xi := 0; xi < nx; xi++ {
+			dx := dist(val.X, unitVal(pc.Min.X, pc.Max.X, xi, nx, pc.WrapX), rng.X, pc.WrapX) / (pc.Sigma.X * rng.X)
+			pat.Values[yi*nx+xi] = mat32.FastExp(-0.5 * (dx*dx + dy*dy))
+		}
+	}
+}
+
+// Decode returns the value decoded from given 2D pattern (shape Y, X)
+// of unit activities, or NaN values if the total above-threshold activity
+// is less than MinSum
+func (pc *TwoD) Decode(pat *etensor.Float32) mat32.Vec2 {
+	ny := pat.Dim(0)
+	nx := pat.Dim(1)
+	var sum float32
+	var vsum, csum, ssum mat32.Vec2
+	for yi := 0; yi < ny; yi++ {
+		uy := unitVal(pc.Min.Y, pc.Max.Y, yi, ny, pc.WrapY)
+		for xi := 0; xi < nx; xi++ {
+			act := pat.Values[yi*nx+xi]
+			if act < pc.Thr {
+				continue
+			}
+			sum += act
+			ux := unitVal(pc.Min.X, pc.Max.X, xi, nx, pc.WrapX)
+			if pc.WrapX {
+				ang := angle(ux, pc.Min.X, pc.Max.X)
+				csum.X += act * mat32.Cos(ang)
+				ssum.X += act * mat32.Sin(ang)
+			} else {
+				vsum.X += act * ux
+			}
+			if pc.WrapY {
+				ang := angle(uy, pc.Min.Y, pc.Max.Y)
+				csum.Y += act * mat32.Cos(ang)
+				ssum.Y += act * mat32.Sin(ang)
+			} else {
+				vsum.Y += act * uy
+			}
+		}
+	}
+	if sum < pc.MinSum || sum == 0 {
+		return mat32.Vec2{mat32.NaN(), mat32.NaN()}
+	}
+	var val mat32.Vec2
+	if pc.WrapX {
+		val.X = fmAngle(mat32.Atan2(ssum.X, csum.X), pc.Min.X, pc.Max.X)
+	} else {
+		val.X = vsum.X / sum
+	}
+	if pc.WrapY {
+		val.Y = fmAngle(mat32.Atan2(ssum.Y, csum.Y), pc.Min.Y, pc.Max.Y)
+	} else {
+		val.Y = vsum.Y / sum
+	}
+	return val
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"github.com/emer/axon/axon"
+)
+
+// NetExecutor executes a Protocol on an axon.Network, one Cycle per msec.
+// Events are applied to the layer named in the event Target:
+// PreSpike forces a spike in all neurons of the layer, PostSpike and GeClamp
+// add Val to the excitatory input (GeRaw), and Clamp sets the external input (Ext)
+// to Val, which is removed when the event ends.  Reward events are passed
+// to RewFun.  CaPulse events are not applicable and are ignored.
+type NetExecutor struct {
+	Net     *axon.Network     `desc:"the network"`
+	Time    *axon.Time        `desc:"the time state, incremented each Cycle"`
+	RewFun  func(rew float32) `desc:"function called for Reward events, e.g., to set the reward input"`
+	StepFun func(t int)       `desc:"optional function called after each Cycle, e.g., for logging"`
+	StopFun func() bool       `desc:"optional function that returns true to stop execution"`
+	Clamped map[string]bool   `desc:"layers currently clamped by Clamp events"`
+}
+
+// NewNetExecutor returns a new NetExecutor for given network and time
+func NewNetExecutor(net *axon.Network, ltime *axon.Time) *NetExecutor {
+	return &NetExecutor{Net: net, Time: ltime}
+}
+
+// Step implements the Executor interface
+func (ne *NetExecutor) Step(t int, evs []*Event) bool {
+	if ne.Clamped == nil {
+		ne.Clamped = make(map[string]bool)
+	}
+	clamp := make(map[string]bool)
+	for _, ev := range evs {
+		if ev.Type == Reward {
+			if ne.RewFun != nil {
+				ne.RewFun(ev.Val)
+			}
+			continue
+		}
+		if ev.Type == CaPulse {
+			continue
+		}
+		lyi, err := ne.Net.LayerByNameTry(ev.Target)
+		if err != nil {
+			continue
+		}
+		ly := lyi.(axon.AxonLayer).AsAxon()
+		if ev.Type == Clamp {
+			clamp[ev.Target] = true
+		}
+		for ni := range ly.Neurons {
+			nrn := &ly.Neurons[ni]
+			if nrn.IsOff() {
+				continue
+			}
+			switch ev.Type {
+			case PreSpike:
+				nrn.Spike = 1
+			case PostSpike, GeClamp:
+				nrn.GeRaw += ev.Val
+			case Clamp:
+				nrn.Ext = ev.Val
+				nrn.SetFlag(axon.NeurHasExt)
+			}
+		}
+	}
+	for lnm := range ne.Clamped {
+		if clamp[lnm] {
+			continue
+		}
+		if lyi, err := ne.Net.LayerByNameTry(lnm); err == nil {
+			lyi.(axon.AxonLayer).InitExt()
+		}
+	}
+	ne.Clamped = clamp
+	ne.Net.Cycle(ne.Time)
+	ne.Time.CycleInc()
+	if ne.StepFun != nil {
+		ne.StepFun(t)
+	}
+	if ne.StopFun != nil && ne.StopFun() {
+		return false
+	}
+	return true
+}
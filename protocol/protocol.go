@@ -0,0 +1,230 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package protocol provides a description of timed stimulation events
+(spike trains, Ca pulses, clamp changes, reward deliveries) that can be
+executed by both the biochemical synapse model (examples/urakubo) and
+network-level sims, so that experimental paradigms such as STDP pairing,
+theta-burst stimulation, and trace conditioning are defined once and reused.
+
+A Protocol is a list of Events within a repeating period of Period msec,
+repeated NReps times.  Time is in msec, which is one Cycle in axon.
+An Executor applies the active events at each msec and runs one step
+of its model -- see Run.
+*/
+package protocol
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// EventTypes are the types of stimulation events
+type EventTypes int32
+
+//go:generate stringer -type=EventTypes
+
+var KiT_EventTypes = kit.Enums.AddEnum(EventTypesN, kit.NotBitFlag, nil)
+
+func (ev EventTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *EventTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The event types
+const (
+	// PreSpike is a presynaptic spike (Val is ignored)
+	PreSpike EventTypes = iota
+
+	// PostSpike drives a postsynaptic spike, by an excitatory conductance pulse of Val
+	PostSpike
+
+	// GeClamp sets excitatory conductance to Val for the duration
+	GeClamp
+
+	// CaPulse clamps Ca concentration to Val (uM) for the duration
+	CaPulse
+
+	// Clamp sets external input clamp on the Target (e.g., a layer) to Val for the duration
+	Clamp
+
+	// Reward delivers a reward (or punishment, if negative) of Val
+	Reward
+
+	EventTypesN
+)
+
+// Event is one timed stimulation event
+type Event struct {
+	Type   EventTypes `desc:"type of event"`
+	Start  int        `desc:"start time in msec, relative to the start of each period"`
+	Dur    int        `min:"1" desc:"duration in msec -- event is active for Start <= t < Start + Dur"`
+	Val    float32    `desc:"value of the event: conductance, Ca concentration, clamp value, reward"`
+	Target string     `desc:"target of the event, e.g., layer name for network sims -- empty = default target"`
+}
+
+// IsActive returns true if event is active at given time within the period
+func (ev *Event) IsActive(t int) bool {
+	return t >= ev.Start && t < ev.Start+ev.Dur
+}
+
+// Protocol is a list of timed stimulation events, within a period
+// that is repeated NReps times
+type Protocol struct {
+	Name   string  `desc:"name of the protocol"`
+	Desc   string  `desc:"description of the protocol"`
+	Period int     `desc:"duration of one repetition of the events, in msec"`
+	NReps  int     `min:"1" desc:"number of repetitions of the period"`
+	Events []Event `desc:"the events, sorted by Start time"`
+}
+
+// New returns a new protocol with given name, period and number of repetitions
+func New(name string, period, nreps int) *Protocol {
+	return &Protocol{Name: name, Period: period, NReps: nreps}
+}
+
+// Add adds a new event, keeping events sorted by Start time.
+// A Dur < 1 is set to 1.
+func (pr *Protocol) Add(typ EventTypes, start, dur int, val float32, target string) {
+	if dur < 1 {
+		dur = 1
+	}
+	pr.Events = append(pr.Events, Event{Type: typ, Start: start, Dur: dur, Val: val, Target: target})
+	sort.SliceStable(pr.Events, func(i, j int) bool {
+		return pr.Events[i].Start < pr.Events[j].Start
+	})
+}
+
+// Duration returns the total duration in msec: Period * NReps
+func (pr *Protocol) Duration() int {
+	nr := pr.NReps
+	if nr < 1 {
+		nr = 1
+	}
+	return pr.Period * nr
+}
+
+// ActiveEvents returns the events active at given time t (msec from the
+// start of the protocol), appended to evs, which is reset first
+func (pr *Protocol) ActiveEvents(t int, evs []*Event) []*Event {
+	evs = evs[:0]
+	pt := t
+	if pr.Period > 0 {
+		pt = t % pr.Period
+	}
+	for ei := range pr.Events {
+		ev := &pr.Events[ei]
+		if ev.Start > pt {
+			break
+		}
+		if ev.IsActive(pt) {
+			evs = append(evs, ev)
+		}
+	}
+	return evs
+}
+
+// Executor executes a Protocol for a given model
+type Executor interface {
+	// Step applies the given active events (can be empty) at time t (msec from
+	// start of protocol) and runs one msec of the model.
+	// Returns false to stop execution.
+	Step(t int, evs []*Event) bool
+}
+
+// Run executes the full protocol using given Executor, returning false
+// if it was stopped by the Executor
+func (pr *Protocol) Run(ex Executor) bool {
+	var evs []*Event
+	dur := pr.Duration()
+	for t := 0; t < dur; t++ {
+		evs = pr.ActiveEvents(t, evs)
+		if !ex.Step(t, evs) {
+			return false
+		}
+	}
+	return true
+}
+
+// EventVal returns the summed Val of all events of given type among evs,
+// and true if any were present
+func EventVal(evs []*Event, typ EventTypes) (float32, bool) {
+	var val float32
+	has := false
+	for _, ev := range evs {
+		if ev.Type == typ {
+			val += ev.Val
+			has = true
+		}
+	}
+	return val, has
+}
+
+//////////////////////////////////////////////////////////////////////////////////////
+//  Standard paradigms
+
+// AddTrain adds a regular train of n events of given type at given rate (Hz),
+// starting at start msec, each of duration dur
+func (pr *Protocol) AddTrain(typ EventTypes, start, n int, hz float32, dur int, val float32, target string) {
+	isi := 1000.0 / hz
+	for i := 0; i < n; i++ {
+		pr.Add(typ, start+int(mat32.Round(float32(i)*isi)), dur, val, target)
+	}
+}
+
+// AddPoisson adds a Poisson train of events of given type at mean rate hz,
+// over the interval [start, start+dur) msec, each of duration 1
+func (pr *Protocol) AddPoisson(typ EventTypes, start, dur int, hz float32, val float32, target string) {
+	thr := mat32.Exp(-1000.0 / hz) // same as in examples/urakubo
+	p := float32(1)
+	for t := start; t < start+dur; t++ {
+		p *= rand.Float32()
+		if p <= thr {
+			pr.Add(typ, t, 1, val, target)
+			p = 1
+		}
+	}
+}
+
+// STDPPairing returns a protocol of pre-post spike pairings: one pairing per
+// period, with the postsynaptic spike (Ge pulse of ge) at postMs and the
+// presynaptic spike deltaT msec earlier (positive deltaT = pre before post),
+// accounting for lag msec of delay from Ge pulse to actual post spike.
+func STDPPairing(nreps, period, postMs, deltaT, lag int, ge float32) *Protocol {
+	pr := New("STDPPairing", period, nreps)
+	pr.Desc = "pre-post spike pairing at fixed interval"
+	pr.Add(PostSpike, postMs, 1, ge, "")
+	pr.Add(PreSpike, postMs+lag-deltaT, 1, 0, "")
+	return pr
+}
+
+// ThetaBurst returns a theta-burst stimulation protocol: nbursts bursts at
+// theta frequency (5 Hz = 200 msec apart), each of nspikes presynaptic
+// spikes at burstHz, optionally paired with postsynaptic Ge pulses of ge (if > 0).
+// The protocol is repeated ntrains times, with given period between trains.
+func ThetaBurst(ntrains, period, nbursts, nspikes int, burstHz float32, ge float32) *Protocol {
+	pr := New("ThetaBurst", period, ntrains)
+	pr.Desc = "theta-burst stimulation: bursts of high-frequency spikes at 5 Hz"
+	for bi := 0; bi < nbursts; bi++ {
+		st := bi * 200
+		pr.AddTrain(PreSpike, st, nspikes, burstHz, 1, 0, "")
+		if ge > 0 {
+			pr.AddTrain(PostSpike, st, nspikes, burstHz, 1, ge, "")
+		}
+	}
+	return pr
+}
+
+// TraceConditioning returns a trace conditioning protocol: a conditioned
+// stimulus (CS) clamp on csTarget for csDur msec, followed by a trace
+// interval, then a reward of rew, one trial per period
+func TraceConditioning(ntrials, period int, csTarget string, csStart, csDur, trace int, rew float32) *Protocol {
+	pr := New("TraceConditioning", period, ntrials)
+	pr.Desc = "CS followed by trace interval then reward (US)"
+	pr.Add(Clamp, csStart, csDur, 1, csTarget)
+	pr.Add(Reward, csStart+csDur+trace, 1, rew, "")
+	return pr
+}
@@ -28,10 +28,10 @@ type ISetScalePrjn interface {
 // AmygModPrjn holds parameters and state variables for modulatory projections to amygdala layers
 type AmygModPrjn struct {
 	axon.Prjn
-	SetScale    bool        `desc:"only for Axon algorithm: if initializing the weights, set the connection scaling parameter in addition to intializing the weights -- for specifically-supported specs, this will for example set a gaussian scaling parameter on top of random initial weights, instead of just setting the initial weights to a gaussian weighted value -- for other specs that do not support a custom init_wts function, this will set the scale values to what the random weights would otherwise be set to, and set the initial weight value to a constant (init_wt_val)"`
+	SetScale    bool        `desc:"if initializing the weights, set the weights to a random scale value (see GaussScale) times the constant InitWtVal, instead of the standard random initial weights"`
 	SetScaleMin float32     `desc:"minimum scale value for SetScale projections"`
 	SetScaleMax float32     `desc:"maximum scale value for SetScale projections"`
-	InitWtVal   float32     `desc:"constant initial weight value for specs that do not support a custom init_wts function and have set_scale set: the scale values are set to what the random weights would otherwise be set to, and the initial weight value is set to this constant: the net actual weight value is scale * init_wt_val.."`
+	InitWtVal   float32     `desc:"constant initial weight value for SetScale projections: the scale values are set to what the random weights would otherwise be set to, and the net actual weight value is scale * InitWtVal"`
 	DALRGain    float32     `desc:"gain multiplier on abs(DA) learning rate multiplier"`
 	DALRBase    float32     `desc:"constant baseline amount of learning prior to abs(DA) factor -- should be near zero otherwise offsets in activation will drive learning in the absence of DA significance"`
 	DALrnThr    float32     `desc:"minimum threshold for phasic abs(da) signals to count as non-zero;  useful to screen out spurious da signals due to tiny VSPatch-to-LHb signals on t2 & t4 timesteps that can accumulate over many trials - 0.02 seems to work okay"`
@@ -48,26 +48,18 @@ var _ ISetScalePrjn = (*AmygModPrjn)(nil)
 
 // InitWts sets initial weights, possibly including SetScale calculations
 func (pj *AmygModPrjn) InitWts() {
+	pj.Prjn.InitWts()
 	if pj.SetScale {
-		pj.SetScalesFunc(pj.GaussScale)
-		pj.SetWtsFunc(func(_, _ int, _, _ *etensor.Shape) float32 {
-			return pj.InitWtVal
+		pj.SetWtsFunc(func(si, ri int, send, recv *etensor.Shape) float32 {
+			return pj.GaussScale(si, ri, send, recv) * pj.InitWtVal
 		})
-		for si := range pj.Syns {
-			sy := &pj.Syns[si]
-			sy.DWt = 0
-			sy.Norm = 0
-			sy.Moment = 0
-		}
-	} else {
-		pj.Prjn.InitWts()
 	}
 }
 
-// GaussScale returns gaussian weight value for given unit indexes in
-// given send and recv layers according to Gaussian Sigma and MaxWt.
+// GaussScale returns a random scale value drawn from the SWt.Init
+// Mean and Var, clipped to the SetScaleMin..SetScaleMax range.
 func (pj *AmygModPrjn) GaussScale(_, _ int, _, _ *etensor.Shape) float32 {
-	scale := float32(pj.WtInit.Gen(-1))
+	scale := pj.SWt.Init.Mean + pj.SWt.Init.RndVar(pj.Rnd())
 	scale = mat32.Max(pj.SetScaleMin, scale)
 	scale = mat32.Min(pj.SetScaleMax, scale)
 	return scale
@@ -87,18 +79,17 @@ func (pj *AmygModPrjn) Defaults() {
 
 // DWt computes DA-modulated weight changes for amygdala layers
 func (pj *AmygModPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
 	rlayi := pj.Recv.(IModLayer)
 	rlay := rlayi.AsMod()
-	clRate := pj.Learn.Lrate // * rlay.CosDiff.ModAvgLLrn
+	clRate := pj.Learn.Lrate.Eff // * rlay.CosDiff.ModAvgLLrn
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		snAct := sn.ActQ0
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		snAct := sn.ActPrv
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 
@@ -129,7 +120,7 @@ func (pj *AmygModPrjn) DWt() {
 				lRateEff *= effActLrn
 			}
 
-			rnActDelta := mn.ModAct - rn.ActQ0
+			rnActDelta := mn.ModAct - rn.ActPrv
 			if mat32.Abs(rnActDelta) < pj.ActDeltaThr {
 				rnActDelta = 0
 			}
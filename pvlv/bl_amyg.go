@@ -101,7 +101,7 @@ func (ly *BlAmygLayer) GetMonitorVal(data []string) float64 {
 // InhibiFmGeAct computes inhibition Gi from Ge and Act averages within relevant Pools
 func (ly *BlAmygLayer) InhibFmGeAct(ltime *axon.Time) {
 	lpl := &ly.Pools[0]
-	ly.Inhib.Layer.Inhib(&lpl.Inhib)
+	ly.Inhib.Layer.Inhib(&lpl.Inhib, ly.ActAvg.GiMult)
 	ly.ILI.Inhib(&ly.Layer) // does inter-layer inhibition
 	ly.PoolInhibFmGeAct(ltime)
 	ly.InhibFmPool(ltime)
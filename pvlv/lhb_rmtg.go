@@ -71,8 +71,6 @@ func (ly *LHbRMTgLayer) Defaults() {
 	ly.Gains.VSPatchPosD1 = 1.0
 	ly.Gains.VSPatchPosD2 = 1.0
 	ly.PVNegDiscount = 0.8
-	ly.Act.Clamp.Range.Min = -2.0
-	ly.Act.Clamp.Range.Max = 2.0
 	ly.Gains.VSPatchPosDisinhib = 0.2
 	ly.Gains.VSMatrixPosD1 = 1.0
 	ly.Gains.VSMatrixPosD2 = 1.0
@@ -100,7 +98,7 @@ func (ly *LHbRMTgLayer) Build() error {
 }
 
 func (ly *LHbRMTgLayer) ActFmG(ltime *axon.Time) {
-	if ltime.Quarter != 3 {
+	if !ltime.PlusPhase {
 		return
 	}
 	var vsPatchPosD1, vsPatchPosD2, vsPatchNegD1, vsPatchNegD2, vsMatrixPosD1, vsMatrixPosD2,
@@ -191,7 +189,6 @@ func (ly *LHbRMTgLayer) ActFmG(ltime *axon.Time) {
 
 	for i := range ly.Neurons {
 		ly.Neurons[i].Act = netLHb
-		ly.Neurons[i].ActLrn = netLHb
 		ly.Neurons[i].ActAvg = netLHb
 		ly.Neurons[i].Ext = netLHb
 		ly.Neurons[i].Ge = netLHb
@@ -282,6 +282,12 @@ func (ly *ModLayer) Build() error {
 	return nil
 }
 
+// CheckpointVals returns the modulation state that is saved in a Checkpoint
+// (see axon.Checkpointer) -- derived types append their own
+func (ly *ModLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.ModNeurs, &ly.ModPools, &ly.Modulators}
+}
+
 func (ly *ModLayer) Defaults() {
 	ly.Layer.Defaults()
 	ly.IsPVReceiver = false
@@ -362,7 +368,7 @@ func (ly *ModLayer) SendMods(_ *axon.Time) {
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		mpl := &ly.ModPools[nrn.SubPool]
-		if mat32.Abs(nrn.Act) > ly.Act.OptThresh.Send {
+		if mat32.Abs(nrn.Act) > ly.ModSendThreshold {
 			mpl.ModSent += nrn.Act
 		}
 	}
@@ -440,8 +446,11 @@ func (ly *ModLayer) ClearModActs() {
 	}
 }
 
-// GScaleFmAvgAct sets the value of GScale on incoming projections, based on sending layer subpool activations.
-func (ly *ModLayer) GScaleFmAvgAct() {
+// InitGScale computes the initial scaling factor for synaptic input conductances G,
+// stored in GScale.Scale, based on sending layer initial activation, as in
+// axon.Layer, with an additional relative share for the direct PV input
+// of IsPVReceiver layers.
+func (ly *ModLayer) InitGScale() {
 	totGeRel := float32(0)
 	totGiRel := float32(0)
 	for _, p := range ly.RcvPrjns {
@@ -450,16 +459,15 @@ func (ly *ModLayer) GScaleFmAvgAct() {
 		}
 		pj := p.(axon.AxonPrjn).AsAxon()
 		slay := p.SendLay().(axon.AxonLayer).AsAxon()
-		slpl := &slay.Pools[0]
-		savg := slpl.ActAvg.ActPAvgEff
+		savg := slay.Inhib.ActAvg.Init
 		snu := len(slay.Neurons)
 		ncon := pj.RConNAvgMax.Avg
-		pj.GScale = pj.WtScale.FullScale(savg, float32(snu), ncon)
+		pj.GScale.Scale = pj.PrjnScale.FullScale(savg, float32(snu), ncon)
 		switch pj.Typ {
 		case emer.Inhib:
-			totGiRel += pj.WtScale.Rel
+			totGiRel += pj.PrjnScale.Rel
 		default:
-			totGeRel += pj.WtScale.Rel
+			totGeRel += pj.PrjnScale.Rel
 		}
 		if ly.IsPVReceiver {
 			totGeRel += 1
@@ -474,13 +482,16 @@ func (ly *ModLayer) GScaleFmAvgAct() {
 		switch pj.Typ {
 		case emer.Inhib:
 			if totGiRel > 0 {
-				pj.GScale /= totGiRel
+				pj.GScale.Rel = pj.PrjnScale.Rel / totGiRel
+				pj.GScale.Scale /= totGiRel
 			}
 		default:
 			if totGeRel > 0 {
-				pj.GScale /= totGeRel
+				pj.GScale.Rel = pj.PrjnScale.Rel / totGeRel
+				pj.GScale.Scale /= totGeRel
 			}
 		}
+		pj.GScale.Init()
 	}
 }
 
@@ -539,7 +550,12 @@ func (ly *ModLayer) AvgMaxMod(_ *axon.Time) {
 }
 
 // ActFmG calculates activation from net input, applying modulation values.
-func (ly *ModLayer) ActFmG(_ *axon.Time) {
+func (ly *ModLayer) ActFmG(ltime *axon.Time) {
+	intdt := ly.Act.Dt.IntDt
+	if ltime.PlusPhase {
+		intdt *= 3.0
+	}
+	cyc := ly.ClampCyc(ltime)
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		mnr := &ly.ModNeurs[ni]
@@ -547,7 +563,7 @@ func (ly *ModLayer) ActFmG(_ *axon.Time) {
 			continue
 		}
 		ly.Act.VmFmG(nrn)
-		ly.Act.ActFmG(nrn)
+		ly.ActFmGNeur(nrn, cyc)
 		if ly.IsModReceiver {
 			newAct := nrn.Act * mnr.ModLevel
 			newDel := nrn.Act - newAct
@@ -560,6 +576,17 @@ func (ly *ModLayer) ActFmG(_ *axon.Time) {
 		if mnr.PVAct > 0.01 { //&& ltime.PlusPhase {
 			mnr.ModAct = mnr.PVAct // this gives results that look more like the CEmer model (rather than setting Act directly from PVAct)
 		}
-		ly.Learn.AvgsFmAct(nrn)
+		nrn.ActInt += intdt * (nrn.Act - nrn.ActInt)
+		if !ly.Inference {
+			ly.Learn.AvgsFmAct(nrn)
+			if !ltime.PlusPhase {
+				nrn.GeM += ly.Act.Dt.IntDt * (nrn.Ge - nrn.GeM)
+				nrn.GiM += ly.Act.Dt.IntDt * (nrn.GiSyn - nrn.GiM)
+				if ly.Inhib.EIBal.On {
+					ly.EIBalFmG(nrn)
+				}
+			}
+		}
+		ly.GABABFmGi(nrn)
 	}
 }
@@ -53,7 +53,7 @@ var KiT_StriatalCompartment = kit.Enums.AddEnum(NSComp, kit.NotBitFlag, nil)
 // Delayed inhibition for matrix compartment layers
 type DelayedInhibParams struct {
 	Active bool    `desc:"add in a portion of inhibition from previous time period"`
-	PrvQ   float32 `desc:"proportion of per-unit net input on previous gamma-frequency quarter to add in as inhibition"`
+	PrvQ   float32 `desc:"proportion of per-unit net input on previous phase to add in as inhibition"`
 	PrvTrl float32 `desc:"proportion of per-unit net input on previous trial to add in as inhibition"`
 }
 
@@ -66,7 +66,7 @@ type MSNTraceParams struct {
 
 // DelInhState contains extra variables for MSNLayer neurons -- stored separately
 type DelInhState struct {
-	GePrvQ   float32 `desc:"netin from previous quarter, used for delayed inhibition"`
+	GePrvQ   float32 `desc:"netin from previous phase, used for delayed inhibition"`
 	GePrvTrl float32 `desc:"netin from previous \"trial\" (alpha cycle), used for delayed inhibition"`
 }
 
@@ -137,7 +137,6 @@ func (tp *MSNTraceParams) MSNActLrnFactor(act float32) float32 {
 // 		"Layer.Inhib.Pool.FB":      "0",
 // 		"Layer.Inhib.Self.On":      "true",
 // 		"Layer.Inhib.Self.Gi":      "0.3",
-// 		"Layer.Inhib.ActAvg.Fixed": "true",
 // 		"Layer.Inhib.ActAvg.Init":  "0.2",
 // 	}}
 
@@ -151,7 +150,6 @@ func (ly *MSNLayer) Defaults() {
 	ly.Inhib.Pool.FB = 0
 	ly.Inhib.Self.On = true
 	ly.Inhib.Self.Gi = 0.3
-	ly.Inhib.ActAvg.Fixed = true
 	ly.Inhib.ActAvg.Init = 0.2
 	ly.DIParams.Active = ly.Compartment == MATRIX
 	if ly.DIParams.Active {
@@ -180,10 +178,15 @@ func (ly *MSNLayer) SetDA(da float32) {
 	ly.DA = da
 }
 
-func (ly *MSNLayer) QuarterInitPrvs(ltime *axon.Time) {
+// PhaseInitPrvs records the netin from the previous phase, at the
+// start of each phase, for delayed inhibition
+func (ly *MSNLayer) PhaseInitPrvs(ltime *axon.Time) {
+	if ltime.PhaseCycle != 0 {
+		return
+	}
 	for ni := range ly.DIState {
 		dis := &ly.DIState[ni]
-		if ltime.Quarter == 0 {
+		if ltime.Phase == 0 {
 			dis.GePrvQ = dis.GePrvTrl
 		} else {
 			nrn := &ly.Neurons[ni]
@@ -215,6 +218,12 @@ func (ly *MSNLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the MSNLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *MSNLayer) CheckpointVals() []interface{} {
+	return append(ly.ModLayer.CheckpointVals(), &ly.DIState)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  Init methods
 
@@ -291,12 +300,12 @@ func (ly *MSNLayer) ModsFmInc(_ *axon.Time) {
 func (ly *MSNLayer) InhibFmGeAct(ltime *axon.Time) {
 	if ly.DIParams.Active {
 		lpl := &ly.Pools[0]
-		ly.Inhib.Layer.Inhib(&lpl.Inhib)
+		ly.Inhib.Layer.Inhib(&lpl.Inhib, ly.ActAvg.GiMult)
 		np := len(ly.Pools)
 		if np > 1 {
 			for pi := 1; pi < np; pi++ {
 				pl := &ly.Pools[pi]
-				ly.Inhib.Pool.Inhib(&pl.Inhib)
+				ly.Inhib.Pool.Inhib(&pl.Inhib, ly.ActAvg.GiMult)
 				pl.Inhib.Gi = mat32.Max(pl.Inhib.Gi, lpl.Inhib.Gi)
 				ly.PoolDelayedInhib(pl)
 			}
@@ -308,7 +317,9 @@ func (ly *MSNLayer) InhibFmGeAct(ltime *axon.Time) {
 	}
 }
 
-func (ly *MSNLayer) AlphaCycInit() {
+// NewState records the netin from the previous trial for delayed inhibition,
+// and then handles all initialization at start of new input state
+func (ly *MSNLayer) NewState() {
 	if ly.DIParams.Active {
 		for ni := range ly.DIState {
 			dis := &ly.DIState[ni]
@@ -316,5 +327,5 @@ func (ly *MSNLayer) AlphaCycInit() {
 			dis.GePrvTrl = nrn.Ge
 		}
 	}
-	ly.ModLayer.AlphaCycInit()
+	ly.ModLayer.NewState()
 }
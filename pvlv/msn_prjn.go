@@ -57,10 +57,8 @@ func (pj *MSNPrjn) AsMSNPrjn() *MSNPrjn {
 func (pj *MSNPrjn) Defaults() {
 	pj.Trace.Defaults()
 	pj.Prjn.Defaults()
-	pj.Learn.WtSig.Gain = 1
-	pj.Learn.Norm.On = false
-	pj.Learn.Momentum.On = false
-	pj.Learn.WtBal.On = false
+	pj.SWt.Adapt.On = false
+	pj.SWt.Adapt.SigGain = 1
 	pj.MaxVSActMod = 0.5
 }
 
@@ -70,6 +68,12 @@ func (pj *MSNPrjn) Build() error {
 	return err
 }
 
+// CheckpointVals returns the trace synapse state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (pj *MSNPrjn) CheckpointVals() []interface{} {
+	return []interface{}{&pj.TrSyns}
+}
+
 func (pj *MSNPrjn) ClearTrace() {
 	for si := range pj.TrSyns {
 		sy := &pj.TrSyns[si]
@@ -85,20 +89,20 @@ func (pj *MSNPrjn) InitWts() {
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *MSNPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
 	rlay := pj.Recv.(*MSNLayer)
 	var effLRate float32
+	lr := pj.Learn.Lrate.Eff
 	if rlay.IsOff() {
 		return
 	}
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
 		snAct := sn.ActP
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		trsyns := pj.TrSyns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
@@ -126,11 +130,11 @@ func (pj *MSNPrjn) DWt() {
 			case TraceNoThalVS:
 				tr := trsy.Tr
 				if mn.ModLrn == 0 {
-					effLRate = pj.Learn.Lrate * pj.Trace.GateLRScale
+					effLRate = lr * pj.Trace.GateLRScale
 				} else {
-					effLRate = pj.Learn.Lrate
+					effLRate = lr
 				}
-				//effLRate = pj.Learn.Lrate * mn.ModLrn
+				//effLRate = lr * mn.ModLrn
 				rawDWt = daLrn * tr // multiplied by learning rate below
 
 				newNTr := pj.Trace.MSNActLrnFactor(effRnAct) * snAct
@@ -144,7 +148,7 @@ func (pj *MSNPrjn) DWt() {
 				trsy.NTr = newNTr
 			case DAHebbVS:
 				rawDWt = daLrn * effRnAct * snAct
-				effLRate = pj.Learn.Lrate * mn.ModLrn
+				effLRate = lr * mn.ModLrn
 			}
 			sy.DWt += effLRate * rawDWt
 		}
@@ -162,20 +166,20 @@ var (
 )
 
 func init() {
-	TraceVarsMap = make(map[string]int, len(TraceVars)+len(axon.SynapseVars))
-	for i, v := range axon.SynapseVars {
+	SynapseVarsAll = axon.SynapseVarsExtend(TraceVars...)
+	nb := len(axon.SynapseVarsAll)
+	TraceVarsMap = make(map[string]int, len(SynapseVarsAll))
+	for i, v := range axon.SynapseVarsAll {
 		TraceVarsMap[v] = i
 	}
-	for i, v := range TraceVars {
-		TraceVarsMap[v] = i + len(axon.SynapseVars)
+	for i, v := range TraceVars { // MSNPrjn Tr takes precedence over the unused base Tr
+		TraceVarsMap[v] = i + nb
 	}
 	for k, v := range axon.SynapseVarProps {
-		SynapseVarProps[k] = v
+		if _, has := SynapseVarProps[k]; !has {
+			SynapseVarProps[k] = v
+		}
 	}
-	ln := len(axon.SynapseVars)
-	SynapseVarsAll = make([]string, len(TraceVars)+ln)
-	copy(SynapseVarsAll, axon.SynapseVars)
-	copy(SynapseVarsAll[ln:], TraceVars)
 }
 
 func (tr *TraceSyn) VarNames() []string {
@@ -219,6 +223,10 @@ func (tr *TraceSyn) SetVarByName(varNm string, val float32) error {
 	if err != nil {
 		return err
 	}
+	i -= len(axon.SynapseVarsAll)
+	if i < 0 {
+		return fmt.Errorf("TraceSyn SetVarByName: variable name: %v not a trace variable", varNm)
+	}
 	tr.SetVarByIndex(i, val)
 	return nil
 }
@@ -259,7 +267,7 @@ func (pj *MSNPrjn) SynVal1D(varIdx int, synIdx int) float32 {
 	if varIdx < 0 || varIdx >= len(SynapseVarsAll) {
 		return mat32.NaN()
 	}
-	nn := len(axon.SynapseVars)
+	nn := pj.Prjn.SynVarNum()
 	if varIdx < nn {
 		return pj.Prjn.SynVal1D(varIdx, synIdx)
 	}
@@ -271,6 +279,36 @@ func (pj *MSNPrjn) SynVal1D(varIdx int, synIdx int) float32 {
 	return sy.VarByIndex(varIdx)
 }
 
+// SetSynVal1D sets value of given variable index (from SynVarIdx) on given SynIdx.
+// Returns false on invalid index.
+func (pj *MSNPrjn) SetSynVal1D(varIdx int, synIdx int, val float32) bool {
+	nn := pj.Prjn.SynVarNum()
+	if varIdx < nn {
+		return pj.Prjn.SetSynVal1D(varIdx, synIdx, val)
+	}
+	if varIdx >= len(SynapseVarsAll) || synIdx < 0 || synIdx >= len(pj.TrSyns) {
+		return false
+	}
+	pj.TrSyns[synIdx].SetVarByIndex(varIdx-nn, val)
+	return true
+}
+
+// SynVarNames returns the names of all the variables on the synapses in this prjn
+func (pj *MSNPrjn) SynVarNames() []string {
+	return SynapseVarsAll
+}
+
+// SynVarProps returns properties for variables
+func (pj *MSNPrjn) SynVarProps() map[string]string {
+	return SynapseVarProps
+}
+
+// SynVarNum returns the number of synapse-level variables
+// for this prjn.  This is needed for extending indexes in derived types.
+func (pj *MSNPrjn) SynVarNum() int {
+	return len(SynapseVarsAll)
+}
+
 func (ly *MSNLayer) RecvPrjnVals(vals *[]float32, varNm string, sendLay emer.Layer, sendIdx1D int, prjnType string) error {
 	var err error
 	nn := len(ly.Neurons)
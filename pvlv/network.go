@@ -46,15 +46,12 @@ func (nt *Network) InitActs() {
 	}
 }
 
-func (nt *Network) Cycle(ltime *axon.Time) {
-	nt.CycleImpl(ltime)
-	nt.EmerNet.(axon.AxonNetwork).CyclePostImpl(ltime) // always call this after std cycle..
-}
-
-//
+// CycleImpl runs one cycle of activation updating, including the
+// modulatory sending and receiving, always in the UpdtSync order.
+// axon.Network Cycle calls this via the AxonNetwork interface.
 func (nt *Network) CycleImpl(ltime *axon.Time) {
-	nt.QuarterInitPrvs(ltime)
-	nt.SendGDelta(ltime) // also does integ
+	nt.PhaseInitPrvs(ltime)
+	nt.SendSpike(ltime) // also does integ
 	nt.SendMods(ltime)
 	nt.RecvModInc(ltime)
 	nt.AvgMaxGe(ltime)
@@ -64,12 +61,12 @@ func (nt *Network) CycleImpl(ltime *axon.Time) {
 	nt.AvgMaxAct(ltime)
 }
 
-func (nt *Network) QuarterInitPrvs(ltime *axon.Time) {
+func (nt *Network) PhaseInitPrvs(ltime *axon.Time) {
 	nt.ThrLayFun(func(ly axon.AxonLayer) {
 		if pl, ok := ly.(*MSNLayer); ok {
-			pl.QuarterInitPrvs(ltime)
+			pl.PhaseInitPrvs(ltime)
 		}
-	}, "QuarterInitPrvs")
+	}, "PhaseInitPrvs")
 }
 
 func (nt *Network) SendMods(ltime *axon.Time) {
@@ -117,15 +114,6 @@ func (nt *Network) UnitVarNames() []string {
 	return ModNeuronVarsAll
 }
 
-func (nt *Network) SynVarNames() []string {
-	return SynapseVarsAll
-}
-
-// SynVarProps returns properties for variables
-func (nt *Network) SynVarProps() map[string]string {
-	return SynapseVarProps
-}
-
 // For special layer types
 
 // AddVTALayer adds a positive or negative Valence VTA layer
@@ -36,6 +36,12 @@ func (ly *PPTgLayer) Build() error {
 	return nil
 }
 
+// CheckpointVals returns the PPTgLayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *PPTgLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.Ge, &ly.GePrev, &ly.SendAct, &ly.DA}
+}
+
 func (ly *PPTgLayer) Defaults() {
 	ly.Layer.Defaults()
 }
@@ -51,7 +57,6 @@ func (ly *PPTgLayer) InitActs() {
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		nrn.Act = 0
-		nrn.ActSent = 0
 	}
 }
 
@@ -63,12 +68,12 @@ func (ly *PPTgLayer) SetDA(da float32) {
 	ly.DA = da
 }
 
-func (ly *PPTgLayer) QuarterFinal(ltime *axon.Time) {
-	ly.Layer.QuarterFinal(ltime)
+// PlusPhase does updating at end of the plus phase, recording
+// the Ge to compute the change in input on the next trial
+func (ly *PPTgLayer) PlusPhase(ltime *axon.Time) {
+	ly.Layer.PlusPhase(ltime)
 	ly.Ge = ly.Neurons[0].Ge
-	if ltime.PlusPhase {
-		ly.GePrev = ly.Ge
-	}
+	ly.GePrev = ly.Ge
 }
 
 // GetMonitorVal retrieves a value for a trace of some quantity, possibly more than just a variable
@@ -98,8 +103,9 @@ func (ly *PPTgLayer) ActFmG(_ *axon.Time) {
 	ly.Ge = nrn.Ge
 	ly.SendAct = nrn.Act // mainly for debugging
 	nrn.Act = nrn.Ge
-	nrn.ActLrn = nrn.Act
 	nrn.ActDel = 0.0
 	nrn.Ge = geSave
-	ly.Learn.AvgsFmAct(nrn)
+	if !ly.Inference {
+		ly.Learn.AvgsFmAct(nrn)
+	}
 }
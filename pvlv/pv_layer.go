@@ -15,9 +15,8 @@ import (
 // Primary Value input layer. Sends activation directly to its receivers, bypassing the standard mechanisms.
 type PVLayer struct {
 	axon.Layer
-	Net           *Network
-	SendPVQuarter int
-	PVReceivers   emer.LayNames
+	Net         *Network
+	PVReceivers emer.LayNames
 }
 
 func AddPVLayer(nt *Network, name string, nY, nX int, typ emer.LayerType) *PVLayer {
@@ -32,15 +31,6 @@ func (ly *PVLayer) AddPVReceiver(lyNm string) {
 	rly.IsPVReceiver = true
 }
 
-func (ly *PVLayer) Build() error {
-	err := ly.Layer.Build()
-	if err != nil {
-		return err
-	}
-	ly.SendPVQuarter = int(axon.Q4)
-	return nil
-}
-
 func (ly *PVLayer) SendPVAct() {
 	for li := range ly.PVReceivers {
 		rly := ly.Net.LayerByName(ly.PVReceivers[li]).(IModLayer).AsMod()
@@ -52,8 +42,9 @@ func (ly *PVLayer) SendPVAct() {
 	}
 }
 
+// CyclePost sends the PV activation to the PVReceivers in the plus phase
 func (ly *PVLayer) CyclePost(ltime *axon.Time) {
-	if ltime.Quarter == ly.SendPVQuarter {
+	if ltime.PlusPhase {
 		ly.SendPVAct()
 	}
 }
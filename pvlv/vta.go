@@ -83,12 +83,16 @@ func (ly *VTALayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the VTALayer state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *VTALayer) CheckpointVals() []interface{} {
+	return append(ly.ClampDaLayer.CheckpointVals(), &ly.SendVal)
+}
+
 func (ly *VTALayer) Defaults() {
 	ly.Layer.Defaults()
 	ly.Act.VmRange.Min = -2.0
 	ly.Act.VmRange.Max = 2.0
-	ly.Act.Clamp.Range.Min = -2.0
-	ly.Act.Clamp.Range.Max = 2.0
 
 	ly.TonicDA = 0
 
@@ -154,11 +158,10 @@ func (ly *VTALayer) GetMonitorVal(data []string) float64 {
 }
 
 func (ly *VTALayer) ActFmG(ltime *axon.Time) {
-	if ltime.Quarter == int(axon.Q4) {
+	if ltime.PlusPhase {
 		ly.VTAAct(ltime)
 	} else {
 		nrn := &ly.Neurons[0]
-		nrn.ActLrn = 0
 		nrn.Act = 0
 		nrn.Ge = 0
 		ly.SendVal = 0
@@ -244,7 +247,6 @@ func (ly *VTALayer) VTAActP(_ *axon.Time) {
 
 	ly.DA = netDA
 	nrn.Ext = ly.TonicDA + ly.DA
-	nrn.ActLrn = nrn.Ext
 	nrn.Act = nrn.Ext
 	nrn.Ge = nrn.Ext
 	nrn.ActDel = 0
@@ -306,7 +308,6 @@ func (ly *VTALayer) VTAActN(_ *axon.Time) {
 
 	ly.DA = netDA
 	nrn.Ext = ly.TonicDA + ly.DA
-	nrn.ActLrn = nrn.Ext
 	nrn.Act = nrn.Ext
 	nrn.Ge = nrn.Ext
 	nrn.ActDel = 0
@@ -0,0 +1,284 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pvlv
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+)
+
+// AddCEmLayer adds a medial central amygdala (CEm) output layer with nUS
+// pools of one unit each, which integrates the CEl acquisition (excitatory)
+// and extinction (inhibitory) inputs for one valence -- see AddPVLV.
+func AddCEmLayer(nt *Network, name string, nUS int) emer.Layer {
+	ly := nt.AddLayer4D(name, 1, nUS, 1, 1, emer.Hidden)
+	ly.SetClass("CEmLayer")
+	return ly
+}
+
+// AddPVLV adds the complete bivalent PVLV dopamine system to the network,
+// as described in the README and Mollick et al (2020), receiving from the
+// given input layers: stimIn = the conditioned stimuli (CS), ctxIn = the
+// context inputs (for extinction), and ustimeIn = the 4D timing inputs
+// predicting the upcoming US for the ventral striatum patch layers.
+// nUS is the number of distinct unconditioned stimuli (USs), which sets the
+// number of pools (in X) of all the US-specific layers.
+// The layers have fixed names, which are used to find them in LHbRMTg
+// and the params, with positive (Pos) and negative (Neg) valence versions:
+// PosPV and NegPV are the primary value (US) inputs, where the US is applied;
+// PPTg and LHbRMTg drive the phasic dopamine bursts and dips of VTAp and VTAn,
+// and VTAp sends dopamine to the amygdala and ventral striatum layers;
+// BLAmyg{Pos,Neg}{D1,D2} learn CS - US associations (acquisition) and their
+// context-dependent extinction; CEl{Acq,Ext}{Pos,Neg}D{1,2} and CEm{Pos,Neg}
+// are the central amygdala learned value (LV) pathway driving PPTg; and
+// VS{Patch,Matrix}{Pos,Neg}{D1,D2} are the ventral striatum, with Patch
+// learning to predict (and cancel) the US dopamine, and Matrix the CS.
+// The layers are positioned relative to stimIn.
+// Returns the VTAp and VTAn layers, which provide the dopamine outputs.
+func (nt *Network) AddPVLV(stimIn, ctxIn, ustimeIn emer.Layer, nUS int) (vtaP, vtaN *VTALayer) {
+	var vtaPSendsTo []string
+
+	// Primary value
+	posPV := AddPVLayer(nt, "PosPV", 1, nUS, emer.Input)
+	posPV.SetClass("PVLVLayer PVLayer")
+	negPV := AddPVLayer(nt, "NegPV", 1, nUS, emer.Input)
+	negPV.SetClass("PVLVLayer PVLayer")
+
+	pptg := AddPPTgLayer(nt, "PPTg", 1, 1)
+
+	lhbRmtG := AddLHbRMTgLayer(nt, "LHbRMTg") // Lateral habenula & Rostromedial tegmental nucleus
+
+	cEmPos := AddCEmLayer(nt, "CEmPos", nUS)
+	cEmNeg := AddCEmLayer(nt, "CEmNeg", nUS)
+
+	// Ventral Striatum (VS)
+
+	// Ventral Striatum Patch (delayed, PV), Direct = Positive Valence expectation
+	// (increases + on LHB, causing more dipping unless counteracted with PV+)
+	vsPatchPosD1 := nt.AddMSNLayer("VSPatchPosD1", 1, nUS, 1, 1, PATCH, D1R)
+	vsPatchNegD2 := nt.AddMSNLayer("VSPatchNegD2", 1, nUS, 1, 1, PATCH, D2R)
+
+	// Ventral Striatum Patch (delayed, PV), indirect = Neg valence expectation
+	// (removes + on LHb dipper -- to cancel PV- neg outcome)
+	vsPatchPosD2 := nt.AddMSNLayer("VSPatchPosD2", 1, nUS, 1, 1, PATCH, D2R)
+	vsPatchNegD1 := nt.AddMSNLayer("VSPatchNegD1", 1, nUS, 1, 1, PATCH, D1R)
+
+	// Ventral Striatum Matrix (immediate, LV), Direct = Positive Valence (direct inhib of gpi, removes + on LHb dipper)
+	vsMatrixPosD1 := nt.AddMSNLayer("VSMatrixPosD1", 1, nUS, 1, 1, MATRIX, D1R)
+	vsMatrixNegD2 := nt.AddMSNLayer("VSMatrixNegD2", 1, nUS, 1, 1, MATRIX, D2R)
+
+	// Ventral Striatum Matrix (immediate, LV), Indirect = Negative Valence (increases + on LHb, causing more dipping)
+	vsMatrixPosD2 := nt.AddMSNLayer("VSMatrixPosD2", 1, nUS, 1, 1, MATRIX, D2R)
+	vsMatrixNegD1 := nt.AddMSNLayer("VSMatrixNegD1", 1, nUS, 1, 1, MATRIX, D1R)
+
+	for _, layer := range []*MSNLayer{vsPatchPosD1, vsPatchPosD2, vsPatchNegD1, vsPatchNegD2} {
+		layer.SetClass("VSPatchLayer")
+	}
+	for _, layer := range []*MSNLayer{vsMatrixPosD1, vsMatrixPosD2, vsMatrixNegD1, vsMatrixNegD2} {
+		layer.SetClass("VSMatrixLayer")
+	}
+	for _, layer := range []*MSNLayer{
+		vsPatchPosD1, vsPatchPosD2, vsPatchNegD1, vsPatchNegD2,
+		vsMatrixPosD1, vsMatrixPosD2, vsMatrixNegD1, vsMatrixNegD2} {
+		layer.SetClass("VS")
+		vtaPSendsTo = append(vtaPSendsTo, layer.Name())
+	}
+
+	// Learned Value
+	// Amygdala
+	// Basolateral amygdala (BLA)
+	blAmygPosD1 := nt.AddBlAmygLayer("BLAmygPosD1", 1, nUS, 7, 9, POS, D1R, emer.Hidden)
+	blAmygPosD2 := nt.AddBlAmygLayer("BLAmygPosD2", 1, nUS, 7, 9, POS, D2R, emer.Hidden)
+	blAmygNegD1 := nt.AddBlAmygLayer("BLAmygNegD1", 1, nUS, 7, 9, NEG, D1R, emer.Hidden)
+	blAmygNegD2 := nt.AddBlAmygLayer("BLAmygNegD2", 1, nUS, 7, 9, NEG, D2R, emer.Hidden)
+	for _, layer := range []emer.Layer{blAmygPosD1, blAmygPosD2, blAmygNegD1, blAmygNegD2} {
+		layer.SetClass("BLAmygLayer")
+		vtaPSendsTo = append(vtaPSendsTo, layer.Name())
+	}
+
+	// Centrolateral amygdala
+	celAcqPosD1 := nt.AddCElAmygLayer("CElAcqPosD1", 1, nUS, 1, 1, Acq, POS, D1R)
+	celExtPosD2 := nt.AddCElAmygLayer("CElExtPosD2", 1, nUS, 1, 1, Ext, POS, D2R)
+	celExtNegD1 := nt.AddCElAmygLayer("CElExtNegD1", 1, nUS, 1, 1, Ext, NEG, D1R)
+	celAcqNegD2 := nt.AddCElAmygLayer("CElAcqNegD2", 1, nUS, 1, 1, Acq, NEG, D2R)
+	for _, layer := range []emer.Layer{celAcqPosD1, celExtPosD2, celExtNegD1, celAcqNegD2} {
+		layer.SetClass("CElAmyg")
+		vtaPSendsTo = append(vtaPSendsTo, layer.Name())
+	}
+
+	vtaP = nt.AddVTALayer("VTAp", POS)
+	vtaP.SetClass("PVLVLayer DALayer")
+
+	vtaN = nt.AddVTALayer("VTAn", NEG)
+	vtaN.SetClass("PVLVLayer DALayer")
+
+	for _, rcvr := range vtaPSendsTo {
+		vtaP.SendDA.Add(rcvr)
+	}
+
+	// Connect everything
+	pjFull := prjn.NewFull()
+	pjPools := prjn.NewPoolOneToOne()
+
+	// to BLAmygPosD1
+	pj := nt.ConnectLayersPrjn(posPV, blAmygPosD1, pjPools, emer.Forward, &AmygModPrjn{}) // LR == 0, could probably just be a normal fixed projection
+	pj.SetClass("PVLVLrnCons BLAmygConsUS")
+	pj = nt.ConnectLayersPrjn(stimIn, blAmygPosD1, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("PVLVLrnCons BLAmygConsStim")
+	pj = nt.ConnectLayers(blAmygPosD2, blAmygPosD1, pjFull, emer.Inhib)
+	pj.SetClass("PVLVLrnCons BLAmygConsInhib")
+	blAmygPosD1.ILI.Lays.Add(blAmygNegD2.Name())
+
+	// to BLAmygNegD2
+	pj = nt.ConnectLayersPrjn(negPV, blAmygNegD2, pjPools, emer.Forward, &AmygModPrjn{}) // LR == 0
+	pj.SetClass("PVLVLrnCons BLAmygConsUS")
+	pj = nt.ConnectLayersPrjn(stimIn, blAmygNegD2, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("PVLVLrnCons BLAmygConsStim")
+	pj = nt.ConnectLayers(blAmygNegD1, blAmygNegD2, pjFull, emer.Inhib)
+	pj.SetClass("PVLVLrnCons BLAmygConsInhib")
+	blAmygNegD2.ILI.Lays.Add(blAmygPosD1.Name())
+
+	// to BLAmygPosD2
+	pj = nt.ConnectLayersPrjn(ctxIn, blAmygPosD2, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("PVLVLrnCons BLAmygConsCntxtExt")
+	nt.ConnectLayersActMod(blAmygPosD1, blAmygPosD2, 0.2)
+
+	// to BLAmygNegD1
+	pj = nt.ConnectLayersPrjn(ctxIn, blAmygNegD1, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("PVLVLrnCons BLAmygConsCntxtExt")
+	nt.ConnectLayersActMod(blAmygNegD2, blAmygNegD1, 0.2)
+
+	// to CElAcqPosD1
+	pj = nt.ConnectLayers(celExtPosD2, celAcqPosD1, pjPools, emer.Inhib)
+	pj.SetClass("CElExtToAcqInhib")
+	posPV.AddPVReceiver(celAcqPosD1.Nm)
+	pj = nt.ConnectLayersPrjn(stimIn, celAcqPosD1, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygCons")
+	pj = nt.ConnectLayersPrjn(blAmygPosD1, celAcqPosD1, pjPools, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygConsFmBLA")
+
+	// to CElAcqNegD2
+	pj = nt.ConnectLayers(celExtNegD1, celAcqNegD2, pjPools, emer.Inhib)
+	pj.SetClass("CElExtToAcqInhib")
+	negPV.AddPVReceiver(celAcqNegD2.Nm)
+	pj = nt.ConnectLayersPrjn(stimIn, celAcqNegD2, pjFull, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygCons")
+	pj = nt.ConnectLayersPrjn(blAmygNegD2, celAcqNegD2, pjPools, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygConsFmBLA")
+
+	// to CElExtPosD2
+	pj = nt.ConnectLayers(celAcqPosD1, celExtPosD2, pjPools, emer.Inhib)
+	pj.SetClass("CElAcqToExtInhib")
+	nt.ConnectLayersActMod(celAcqPosD1, celExtPosD2, 1)
+	pj = nt.ConnectLayersPrjn(blAmygPosD2, celExtPosD2, pjPools, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygConsExtFmBLA")
+
+	// to CElExtNegD1
+	pj = nt.ConnectLayers(celAcqNegD2, celExtNegD1, pjPools, emer.Inhib)
+	pj.SetClass("CElAcqToExtInhib")
+	nt.ConnectLayersActMod(celAcqNegD2, celExtNegD1, 1)
+	pj = nt.ConnectLayersPrjn(blAmygNegD1, celExtNegD1, pjPools, emer.Forward, &AmygModPrjn{})
+	pj.SetClass("CElAmygConsExtFmBLA")
+
+	// to CEmPos
+	pj = nt.ConnectLayers(celAcqPosD1, cEmPos, pjPools, emer.Forward)
+	pj.SetClass("CEltoCeMFixed")
+	pj = nt.ConnectLayers(celExtPosD2, cEmPos, pjPools, emer.Inhib)
+	pj.SetClass("CEltoCeMFixed")
+
+	// to CEmNeg
+	pj = nt.ConnectLayers(celAcqNegD2, cEmNeg, pjPools, emer.Forward)
+	pj.SetClass("CEltoCeMFixed")
+	pj = nt.ConnectLayers(celExtNegD1, cEmNeg, pjPools, emer.Inhib)
+	pj.SetClass("CEltoCeMFixed")
+
+	// to VSPatchPosD1
+	nt.ConnectLayersActMod(blAmygPosD1, vsPatchPosD1, 0.2)
+	pj = nt.ConnectLayersPrjn(ustimeIn, vsPatchPosD1, pjFull, emer.Forward, &MSNPrjn{LearningRule: DAHebbVS})
+	pj.SetClass("PVLVLrnCons VSPatchConsToPosD1")
+
+	// to VSPatchPosD2
+	nt.ConnectLayersActMod(blAmygPosD1, vsPatchPosD2, 0.2)
+	pj = nt.ConnectLayersPrjn(ustimeIn, vsPatchPosD2, pjFull, emer.Forward, &MSNPrjn{LearningRule: DAHebbVS})
+	pj.SetClass("PVLVLrnCons VSPatchConsToPosD2")
+
+	// to VSPatchNegD2
+	nt.ConnectLayersActMod(blAmygNegD2, vsPatchNegD2, 0.2)
+	pj = nt.ConnectLayersPrjn(ustimeIn, vsPatchNegD2, pjFull, emer.Forward, &MSNPrjn{LearningRule: DAHebbVS})
+	pj.SetClass("PVLVLrnCons VSPatchConsToNegD2")
+
+	// to VSPatchNegD1
+	nt.ConnectLayersActMod(blAmygNegD2, vsPatchNegD1, 0.2)
+	pj = nt.ConnectLayersPrjn(ustimeIn, vsPatchNegD1, pjFull, emer.Forward, &MSNPrjn{LearningRule: DAHebbVS})
+	pj.SetClass("PVLVLrnCons VSPatchConsToNegD1")
+
+	// to VSMatrixPosD1
+	nt.ConnectLayersActMod(blAmygPosD1, vsMatrixPosD1, 0.015)
+	pj = nt.ConnectLayersPrjn(stimIn, vsMatrixPosD1, pjFull, emer.Forward, &MSNPrjn{LearningRule: TraceNoThalVS})
+	pj.SetClass("PVLVLrnCons VSMatrixConsToPosD1")
+
+	// to VSMatrixPosD2
+	nt.ConnectLayersActMod(vsMatrixPosD1, vsMatrixPosD2, 1)
+	pj = nt.ConnectLayersPrjn(stimIn, vsMatrixPosD2, pjFull, emer.Forward, &MSNPrjn{LearningRule: TraceNoThalVS})
+	pj.SetClass("PVLVLrnCons VSMatrixConsToPosD2")
+
+	// to VSMatrixNegD2
+	nt.ConnectLayersActMod(blAmygNegD2, vsMatrixNegD2, 0.015)
+	pj = nt.ConnectLayersPrjn(stimIn, vsMatrixNegD2, pjFull, emer.Forward, &MSNPrjn{LearningRule: TraceNoThalVS})
+	pj.SetClass("PVLVLrnCons VSMatrixConsToNegD2")
+
+	// to VSMatrixNegD1
+	nt.ConnectLayersActMod(vsMatrixNegD2, vsMatrixNegD1, 1)
+	pj = nt.ConnectLayersPrjn(stimIn, vsMatrixNegD1, pjFull, emer.Forward, &MSNPrjn{LearningRule: TraceNoThalVS})
+	pj.SetClass("PVLVLrnCons VSMatrixConsToNegD1")
+
+	// to PPTg
+	pj = nt.ConnectLayers(cEmPos, pptg, pjFull, emer.Forward)
+	pj.SetClass("PVLVFixedCons")
+
+	// LHBRMTg sources
+	for _, ly := range []emer.Layer{
+		posPV, negPV, vtaP, vtaN,
+		vsPatchPosD1, vsPatchPosD2, vsPatchNegD1, vsPatchNegD2,
+		vsMatrixPosD1, vsMatrixPosD2, vsMatrixNegD1, vsMatrixNegD2} {
+		lhbRmtG.RcvFrom.Add(ly.Name())
+	}
+
+	// Lay out for display
+
+	negPV.SetRelPos(relpos.Rel{Rel: relpos.Below, Other: stimIn.Name(), Scale: 3})
+	posPV.SetRelPos(relpos.Rel{Rel: relpos.LeftOf, Other: negPV.Name(), Space: 4, XAlign: relpos.Left, Scale: 3})
+	pptg.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: negPV.Name(), Space: 3, Scale: 3})
+	vtaP.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: pptg.Name(), Space: 4, Scale: 3})
+	lhbRmtG.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: vtaP.Name(), Space: 4, Scale: 3})
+	vtaN.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: lhbRmtG.Name(), Space: 10, Scale: 3})
+
+	cEmPos.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: posPV.Name(), Space: 8, Scale: 3})
+	cEmNeg.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: negPV.Name(), Space: 8, Scale: 3})
+
+	celAcqPosD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: cEmPos.Name(), Space: 3, Scale: 3})
+	celExtPosD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: celAcqPosD1.Name(), Space: 3, Scale: 3})
+
+	celExtNegD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: cEmNeg.Name(), Space: 3, Scale: 3})
+	celAcqNegD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: celExtNegD1.Name(), Space: 3, Scale: 3})
+
+	vsPatchPosD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: lhbRmtG.Name(), Space: 6, Scale: 3})
+	vsPatchPosD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsPatchPosD1.Name(), Space: 2, Scale: 3})
+	vsMatrixPosD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsPatchPosD2.Name(), Space: 4, Scale: 3})
+	vsMatrixPosD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsMatrixPosD1.Name(), Space: 2, Scale: 3})
+
+	vsPatchNegD1.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: vsPatchPosD1.Name(), Space: 2, Scale: 3})
+	vsPatchNegD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsPatchNegD1.Name(), Space: 2, Scale: 3})
+	vsMatrixNegD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsPatchNegD2.Name(), Space: 4, Scale: 3})
+	vsMatrixNegD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: vsMatrixNegD1.Name(), Space: 2, Scale: 3})
+
+	blAmygPosD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: celExtPosD2.Name(), Space: 10})
+	blAmygPosD2.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: blAmygPosD1.Name(), Space: 3})
+
+	blAmygNegD2.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: blAmygPosD1.Name(), Space: 6})
+	blAmygNegD1.SetRelPos(relpos.Rel{Rel: relpos.Behind, Other: blAmygNegD2.Name(), Space: 3})
+	return
+}
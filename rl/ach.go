@@ -94,6 +94,12 @@ func (ly *ClampAChLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the ACh state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *ClampAChLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.ACh}
+}
+
 // CyclePost is called at end of Cycle
 // We use it to send ACh, which will then be active for the next cycle of processing.
 func (ly *ClampAChLayer) CyclePost(ltime *axon.Time) {
@@ -0,0 +1,205 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rl
+
+import (
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// ActSelTypes are the ways of selecting an action from the ActorLayer values
+type ActSelTypes int32
+
+//go:generate stringer -type=ActSelTypes
+
+var KiT_ActSelTypes = kit.Enums.AddEnum(ActSelTypesN, kit.NotBitFlag, nil)
+
+func (ev ActSelTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *ActSelTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The action selection types
+const (
+	// Softmax selects each action with probability proportional to exp(val / Temp)
+	Softmax ActSelTypes = iota
+
+	// EpsGreedy selects the action with the highest value, except with
+	// probability Eps it selects an action at random
+	EpsGreedy
+
+	ActSelTypesN
+)
+
+// ActorParams are parameters for selecting an action from the ActorLayer
+type ActorParams struct {
+	Sel  ActSelTypes `desc:"how to select the action from the action values"`
+	Temp float32     `viewif:"Sel=Softmax" def:"0.1" min:"0" desc:"softmax temperature -- lower values are more greedy, 0 = always the max"`
+	Eps  float32     `viewif:"Sel=EpsGreedy" def:"0.1" min:"0" max:"1" desc:"probability of selecting a random action instead of the max"`
+}
+
+func (ap *ActorParams) Defaults() {
+	ap.Sel = Softmax
+	ap.Temp = 0.1
+	ap.Eps = 0.1
+}
+
+func (ap *ActorParams) Update() {
+}
+
+// Select returns the index of the action selected from the given action values,
+// using given source of random numbers -- returns -1 if no values
+func (ap *ActorParams) Select(vals []float32, rnd axon.Rand) int {
+	n := len(vals)
+	if n == 0 {
+		return -1
+	}
+	mxi := 0
+	for i, v := range vals {
+		if v > vals[mxi] {
+			mxi = i
+		}
+	}
+	switch ap.Sel {
+	case EpsGreedy:
+		if rnd.Float32() < ap.Eps {
+			return rnd.Intn(n)
+		}
+	case Softmax:
+		if ap.Temp <= 0 {
+			break
+		}
+		ps := make([]float32, n)
+		sum := float32(0)
+		for i, v := range vals {
+			ps[i] = mat32.Exp((v - vals[mxi]) / ap.Temp)
+			sum += ps[i]
+		}
+		r := rnd.Float32() * sum
+		for i, p := range ps {
+			r -= p
+			if r < 0 {
+				return i
+			}
+		}
+	}
+	return mxi
+}
+
+// ActorLayer is the policy (actor) layer of an actor-critic system, which
+// receives dopamine from the critic (e.g., TDDaLayer) and represents the
+// value of each action as the activity of one pool (4D) or unit (2D).
+// All of its receiving projections learn with the DA-modulated three-factor
+// rule: an eligibility trace of the sender x receiver activity, multiplied
+// by the DA value (see axon.TraceParams).  SelectAction reads out the
+// action according to the ActorParams.
+type ActorLayer struct {
+	axon.Layer
+	Actor  ActorParams `view:"inline" desc:"parameters for selecting an action"`
+	DA     float32     `inactive:"+" desc:"dopamine value for this layer"`
+	Action int         `inactive:"+" desc:"index of the last selected action -- -1 if none"`
+}
+
+var KiT_ActorLayer = kit.Types.AddType(&ActorLayer{}, axon.LayerProps)
+
+func (ly *ActorLayer) Defaults() {
+	ly.Layer.Defaults()
+	ly.Actor.Defaults()
+	if ly.Is4D() {
+		ly.Inhib.Pool.On = true
+	}
+	for _, pji := range ly.RcvPrjns {
+		pj := pji.(axon.AxonPrjn).AsAxon()
+		if pj.Typ == emer.Inhib {
+			continue
+		}
+		pj.Learn.Trace.On = true
+	}
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *ActorLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Actor.Update()
+}
+
+// CheckpointVals returns the DA and Action state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (ly *ActorLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA, &ly.Action}
+}
+
+// DALayer interface:
+
+func (ly *ActorLayer) GetDA() float32   { return ly.DA }
+func (ly *ActorLayer) SetDA(da float32) { ly.DA = da }
+
+func (ly *ActorLayer) InitActs() {
+	ly.Layer.InitActs()
+	ly.DA = 0
+	ly.Action = -1
+}
+
+// NActions returns the number of actions: pools for a 4D layer, else units
+func (ly *ActorLayer) NActions() int {
+	if ly.Is4D() {
+		return len(ly.Pools) - 1
+	}
+	return len(ly.Neurons)
+}
+
+// ActionVals returns the value of each action, as the minus phase
+// average activity of each pool (4D) or unit (2D)
+func (ly *ActorLayer) ActionVals() []float32 {
+	vals := make([]float32, ly.NActions())
+	for ai := range vals {
+		if ly.Is4D() {
+			vals[ai] = ly.Pools[ai+1].ActM.Avg
+		} else {
+			vals[ai] = ly.Neurons[ai].ActM
+		}
+	}
+	return vals
+}
+
+// SelectAction selects an action from the ActionVals according to the
+// Actor params, recording it in Action, and returns its index.
+// Typically called after the minus phase, with the selected action then
+// performed in the environment and clamped as the plus-phase outcome.
+func (ly *ActorLayer) SelectAction() int {
+	ly.Action = ly.Actor.Select(ly.ActionVals(), ly.Rnd())
+	return ly.Action
+}
+
+// AddActorCritic adds an actor-critic system: the standard TD critic layers
+// (see AddTDLayers), and an ActorLayer named prefix+"Actor" with nActs pools of
+// poolY x poolX units, each representing one action.  The TD layer sends its
+// DA to RewPred and the Actor.  If in is non-nil, it is connected as the state
+// input to RewPred (with a TDRewPredPrjn) and the Actor (Full).
+func AddActorCritic(nt *axon.Network, prefix string, in emer.Layer, nActs, poolY, poolX int, rel relpos.Relations, space float32) (rew, rp, ri, td axon.AxonLayer, actor *ActorLayer) {
+	rew, rp, ri, td = AddTDLayers(nt, prefix, rel, space)
+	actor = &ActorLayer{}
+	nt.AddLayerInit(actor, prefix+"Actor", []int{1, nActs, poolY, poolX}, emer.Hidden)
+	actor.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: rew.Name(), XAlign: relpos.Left, Space: space})
+	td.(*TDDaLayer).SendDA.Add(actor.Name())
+	if in != nil {
+		pj := nt.ConnectLayersPrjn(in, rp, prjn.NewFull(), emer.Forward, &TDRewPredPrjn{})
+		pj.SetClass("TDRewPredPrjn")
+		pj = nt.ConnectLayers(in, actor, prjn.NewFull(), emer.Forward)
+		pj.SetClass("ActorPrjn")
+	}
+	return
+}
+
+// AddActorCriticPy adds an actor-critic system: the standard TD critic layers
+// and an ActorLayer -- see AddActorCritic.
+// Py is Python version, returns layers as a slice
+func AddActorCriticPy(nt *axon.Network, prefix string, in emer.Layer, nActs, poolY, poolX int, rel relpos.Relations, space float32) []axon.AxonLayer {
+	rew, rp, ri, td, actor := AddActorCritic(nt, prefix, in, nActs, poolY, poolX, rel, space)
+	return []axon.AxonLayer{rew, rp, ri, td, actor}
+}
@@ -19,6 +19,16 @@ type DALayer interface {
 	SetDA(da float32)
 }
 
+// DASender is an interface for a layer that computes a dopamine value and
+// sends it to the layers in its SendDA list, e.g., ClampDaLayer, RWDaLayer,
+// TDDaLayer -- these send DA at the end of every cycle (CyclePost)
+type DASender interface {
+	DALayer
+
+	// SendDAList returns the list of layers to send dopamine to
+	SendDAList() *SendDA
+}
+
 // SendDA is a list of layers to send dopamine to
 type SendDA emer.LayNames
 
@@ -44,6 +54,16 @@ func (sd *SendDA) Add(laynm ...string) {
 	*sd = append(*sd, laynm...)
 }
 
+// Has returns true if given layer name is on the list
+func (sd *SendDA) Has(laynm string) bool {
+	for _, lnm := range *sd {
+		if lnm == laynm {
+			return true
+		}
+	}
+	return false
+}
+
 // AddOne adds one layer name to list -- python version -- doesn't support varargs
 func (sd *SendDA) AddOne(laynm string) {
 	*sd = append(*sd, laynm)
@@ -81,7 +101,7 @@ var KiT_ClampDaLayer = kit.Types.AddType(&ClampDaLayer{}, axon.LayerProps)
 
 func (ly *ClampDaLayer) Defaults() {
 	ly.Layer.Defaults()
-	ly.Act.Clamp.Range.Set(-1, 1)
+	ly.Act.Clamp.Type = axon.ClampHard // Act = Ext directly, allowing negative DA
 }
 
 // DALayer interface:
@@ -89,6 +109,10 @@ func (ly *ClampDaLayer) Defaults() {
 func (ly *ClampDaLayer) GetDA() float32   { return ly.DA }
 func (ly *ClampDaLayer) SetDA(da float32) { ly.DA = da }
 
+// DASender interface:
+
+func (ly *ClampDaLayer) SendDAList() *SendDA { return &ly.SendDA }
+
 // Build constructs the layer state, including calling Build on the projections.
 func (ly *ClampDaLayer) Build() error {
 	err := ly.Layer.Build()
@@ -99,6 +123,12 @@ func (ly *ClampDaLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the DA state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *ClampDaLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA}
+}
+
 // CyclePost is called at end of Cycle
 // We use it to send DA, which will then be active for the next cycle of processing.
 func (ly *ClampDaLayer) CyclePost(ltime *axon.Time) {
@@ -18,6 +18,11 @@ algorithm (RW) and Temporal Differences (TD) learning, and a minimal
   after activation is updated.  Thus, DA lags by 1 cycle,
   which typically should not be a problem.
 
+* `ActorLayer` is a policy layer for actor-critic learning,
+  with DA-modulated three-factor learning in its projections,
+  and Softmax or EpsGreedy action selection over its pools --
+  see AddActorCritic.
+
 * See the separate `pvlv` package for the full biologically-based
   pvlv model on top of this basic DA infrastructure.
 */
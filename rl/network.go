@@ -5,6 +5,9 @@
 package rl
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/emer/axon/axon"
 	"github.com/emer/emergent/emer"
 	"github.com/emer/emergent/prjn"
@@ -23,9 +26,10 @@ func AddClampDaLayer(nt *axon.Network, name string) *ClampDaLayer {
 
 // AddTDLayers adds the standard TD temporal differences layers, generating a DA signal.
 // Projection from Rew to RewInteg is given class TDRewToInteg -- should
-// have no learning and 1 weight.
+// have no learning and 1 weight.  The TD layer sends DA to RewPred.
+// Rew is a RewLayer, which can shape the reward applied with ApplyRew.
 func AddTDLayers(nt *axon.Network, prefix string, rel relpos.Relations, space float32) (rew, rp, ri, td axon.AxonLayer) {
-	rew = nt.AddLayer2D(prefix+"Rew", 1, 1, emer.Input).(axon.AxonLayer)
+	rew = AddRewLayer(nt, prefix+"Rew")
 	rp = &TDRewPredLayer{}
 	nt.AddLayerInit(rp, prefix+"RewPred", []int{1, 1}, emer.Hidden)
 	ri = &TDRewIntegLayer{}
@@ -34,6 +38,7 @@ func AddTDLayers(nt *axon.Network, prefix string, rel relpos.Relations, space fl
 	nt.AddLayerInit(td, prefix+"TD", []int{1, 1}, emer.Hidden)
 	ri.(*TDRewIntegLayer).RewInteg.RewPred = rp.Name()
 	td.(*TDDaLayer).RewInteg = ri.Name()
+	td.(*TDDaLayer).SendDA.Add(rp.Name())
 	rp.SetRelPos(relpos.Rel{Rel: rel, Other: rew.Name(), YAlign: relpos.Front, Space: space})
 	ri.SetRelPos(relpos.Rel{Rel: rel, Other: rp.Name(), YAlign: relpos.Front, Space: space})
 	td.SetRelPos(relpos.Rel{Rel: rel, Other: ri.Name(), YAlign: relpos.Front, Space: space})
@@ -41,15 +46,15 @@ func AddTDLayers(nt *axon.Network, prefix string, rel relpos.Relations, space fl
 	pj := nt.ConnectLayers(rew, ri, prjn.NewFull(), emer.Forward).(axon.AxonPrjn).AsAxon()
 	pj.SetClass("TDRewToInteg")
 	pj.Learn.Learn = false
-	pj.WtInit.Mean = 1
-	pj.WtInit.Var = 0
-	pj.WtInit.Sym = false
+	pj.SWt.Init.Mean = 1
+	pj.SWt.Init.Var = 0
+	pj.SWt.Init.Sym = false
 	// {Sel: ".TDRewToInteg", Desc: "rew to integ",
 	// 	Params: params.Params{
-	// 		"Prjn.Learn.Learn": "false",
-	// 		"Prjn.WtInit.Mean": "1",
-	// 		"Prjn.WtInit.Var":  "0",
-	// 		"Prjn.WtInit.Sym":  "false",
+	// 		"Prjn.Learn.Learn":   "false",
+	// 		"Prjn.SWt.Init.Mean": "1",
+	// 		"Prjn.SWt.Init.Var":  "0",
+	// 		"Prjn.SWt.Init.Sym":  "false",
 	// 	}},
 	return
 }
@@ -57,14 +62,26 @@ func AddTDLayers(nt *axon.Network, prefix string, rel relpos.Relations, space fl
 // AddRWLayers adds simple Rescorla-Wagner (PV only) dopamine system, with a primary
 // Reward layer, a RWPred prediction layer, and a dopamine layer that computes diff.
 // Only generates DA when Rew layer has external input -- otherwise zero.
+// The DA layer sends DA to RWPred.
+// Rew is a RewLayer, which can shape the reward applied with ApplyRew.
 func AddRWLayers(nt *axon.Network, prefix string, rel relpos.Relations, space float32) (rew, rp, da axon.AxonLayer) {
-	rew = nt.AddLayer2D(prefix+"Rew", 1, 1, emer.Input).(axon.AxonLayer)
+	return AddRWDistLayers(nt, prefix, ScalarDist, 1, rel, space)
+}
+
+// AddRWDistLayers adds a Rescorla-Wagner dopamine system as in AddRWLayers,
+// with the RWPred layer predicting the reward distribution of given type
+// with nUnits units (see RWDistParams) -- ScalarDist with 1 unit is the
+// standard RW system.  DA is the reward minus the expected value.
+func AddRWDistLayers(nt *axon.Network, prefix string, dist RWDistTypes, nUnits int, rel relpos.Relations, space float32) (rew, rp, da axon.AxonLayer) {
+	rew = AddRewLayer(nt, prefix+"Rew")
 	rp = &RWPredLayer{}
-	nt.AddLayerInit(rp, prefix+"RWPred", []int{1, 1}, emer.Hidden)
+	nt.AddLayerInit(rp, prefix+"RWPred", []int{1, nUnits}, emer.Hidden)
+	rp.(*RWPredLayer).Dist.Type = dist
 	da = &RWDaLayer{}
 	nt.AddLayerInit(da, prefix+"DA", []int{1, 1}, emer.Hidden)
 	da.(*RWDaLayer).RewLay = rew.Name()
-	da.(*RWDaLayer).RewLay = rew.Name()
+	da.(*RWDaLayer).RWPredLay = rp.Name()
+	da.(*RWDaLayer).SendDA.Add(rp.Name())
 	rp.SetRelPos(relpos.Rel{Rel: rel, Other: rew.Name(), YAlign: relpos.Front, Space: space})
 	da.SetRelPos(relpos.Rel{Rel: rel, Other: rp.Name(), YAlign: relpos.Front, Space: space})
 
@@ -88,3 +105,72 @@ func AddRWLayersPy(nt *axon.Network, prefix string, rel relpos.Relations, space
 	rew, rp, da := AddRWLayers(nt, prefix, rel, space)
 	return []axon.AxonLayer{rew, rp, da}
 }
+
+// AddDAReceivers adds the given layers to the SendDA list of given DA sending
+// layer, so they receive its dopamine every cycle -- returns an error if the
+// sender is not a DASender or a receiver is not a DALayer
+func AddDAReceivers(snd emer.Layer, rcvs ...emer.Layer) error {
+	ds, ok := snd.(DASender)
+	if !ok {
+		err := fmt.Errorf("rl.AddDAReceivers: layer %s is not a DASender", snd.Name())
+		log.Println(err)
+		return err
+	}
+	for _, rly := range rcvs {
+		if _, ok := rly.(DALayer); !ok {
+			err := fmt.Errorf("rl.AddDAReceivers: layer %s is not a DALayer", rly.Name())
+			log.Println(err)
+			return err
+		}
+		ds.SendDAList().Add(rly.Name())
+	}
+	return nil
+}
+
+// AddAllDAReceivers adds all the layers in the network that are DALayer's,
+// but not themselves DASender's, to the SendDA list of given DA sending layer
+func AddAllDAReceivers(net emer.Network, snd DASender) {
+	sd := snd.SendDAList()
+	nl := net.NLayers()
+	for li := 0; li < nl; li++ {
+		ly := net.Layer(li)
+		if _, ok := ly.(DASender); ok {
+			continue
+		}
+		if _, ok := ly.(DALayer); !ok {
+			continue
+		}
+		if sd.Has(ly.Name()) {
+			continue
+		}
+		sd.Add(ly.Name())
+	}
+}
+
+// SendDAAll sends the current DA value of every DASender layer in the network
+// to the layers on its SendDA list.  The senders already do this at the end
+// of every cycle, so this is only needed when the DA of a sender is set
+// directly (e.g., SetDA on a ClampDaLayer from the sim) outside of the cycle
+// updating, such as at the start of a trial.
+func SendDAAll(net emer.Network) {
+	nl := net.NLayers()
+	for li := 0; li < nl; li++ {
+		ly := net.Layer(li)
+		ds, ok := ly.(DASender)
+		if !ok {
+			continue
+		}
+		if aly, ok := ly.(axon.AxonLayer); ok && aly.AsAxon().IsOff() {
+			continue
+		}
+		ds.SendDAList().SendDA(net, ds.GetDA())
+	}
+}
+
+// AddRWDistLayersPy adds a Rescorla-Wagner dopamine system with distributional
+// reward prediction -- see AddRWDistLayers.
+// Py is Python version, returns layers as a slice
+func AddRWDistLayersPy(nt *axon.Network, prefix string, dist RWDistTypes, nUnits int, rel relpos.Relations, space float32) []axon.AxonLayer {
+	rew, rp, da := AddRWDistLayers(nt, prefix, dist, nUnits, rel, space)
+	return []axon.AxonLayer{rew, rp, da}
+}
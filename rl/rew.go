@@ -0,0 +1,121 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rl
+
+import (
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/goki/ki/kit"
+	"github.com/goki/mat32"
+)
+
+// RewParams are parameters for shaping the raw environment reward
+// before it is applied to the RewLayer: scaling by a gain, subtracting
+// a running-average baseline, and adding a count-based novelty bonus.
+type RewParams struct {
+	Gain     float32 `def:"1" desc:"multiplier on the raw reward (after baseline subtraction)"`
+	Baseline bool    `desc:"subtract a running average of the raw reward as a baseline, so that the reward is relative to what is typically received"`
+	BaseTau  float32 `viewif:"Baseline" def:"100" min:"1" desc:"time constant in rewards (trials with reward) for the running average baseline"`
+	Novelty  bool    `desc:"add a count-based novelty bonus: NovGain / sqrt(N) where N is the number of times the state (identified by a hash provided with the reward) has been seen"`
+	NovGain  float32 `viewif:"Novelty" def:"0.1" min:"0" desc:"gain on the novelty bonus"`
+
+	BaseDt float32 `view:"-" json:"-" xml:"-" desc:"rate = 1 / tau"`
+}
+
+func (rp *RewParams) Defaults() {
+	rp.Gain = 1
+	rp.Baseline = false
+	rp.BaseTau = 100
+	rp.Novelty = false
+	rp.NovGain = 0.1
+	rp.Update()
+}
+
+func (rp *RewParams) Update() {
+	rp.BaseDt = 1 / rp.BaseTau
+}
+
+// Shape returns the shaped reward for given raw reward and state count
+// (number of times the state has been seen, including this one), updating
+// the running-average baseline
+func (rp *RewParams) Shape(raw float32, base *float32, cnt int) float32 {
+	rew := raw
+	if rp.Baseline {
+		rew -= *base
+		*base += rp.BaseDt * (raw - *base)
+	}
+	rew *= rp.Gain
+	if rp.Novelty && cnt > 0 {
+		rew += rp.NovGain / mat32.Sqrt(float32(cnt))
+	}
+	return rew
+}
+
+// RewLayer is the reward input layer for the RW and TD dopamine systems,
+// which shapes the raw environment reward according to the RewParams
+// when applied through ApplyRew, so that this shaping is done in the
+// model instead of in each environment.
+type RewLayer struct {
+	axon.Layer
+	Rew     RewParams      `view:"inline" desc:"parameters for shaping the raw reward"`
+	RawRew  float32        `inactive:"+" desc:"last raw reward applied"`
+	ShapRew float32        `inactive:"+" desc:"last shaped reward applied, which is the layer activity"`
+	RewAvg  float32        `inactive:"+" desc:"running average of the raw reward, for the Baseline"`
+	Counts  map[uint64]int `view:"-" desc:"number of times each state (by hash) has been seen, for the Novelty bonus"`
+}
+
+var KiT_RewLayer = kit.Types.AddType(&RewLayer{}, axon.LayerProps)
+
+func (ly *RewLayer) Defaults() {
+	ly.Layer.Defaults()
+	ly.Rew.Defaults()
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *RewLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Rew.Update()
+}
+
+// InitWts initializes the weights, and the reward baseline and state counts
+func (ly *RewLayer) InitWts() {
+	ly.Layer.InitWts()
+	ly.RawRew = 0
+	ly.ShapRew = 0
+	ly.RewAvg = 0
+	ly.Counts = make(map[uint64]int)
+}
+
+// CheckpointVals returns the reward state that is saved in a Checkpoint
+// (see axon.Checkpointer), including the RewAvg baseline and the novelty Counts
+func (ly *RewLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.RawRew, &ly.ShapRew, &ly.RewAvg, &ly.Counts}
+}
+
+// ApplyRew applies the given raw reward as the external input to the layer,
+// after shaping by the Rew params, using given state hash for the Novelty
+// bonus (ignored otherwise).  Only call on trials with a reward, as the RW
+// and TD layers use the presence of external input to detect reward.
+func (ly *RewLayer) ApplyRew(raw float32, state uint64) {
+	cnt := 0
+	if ly.Rew.Novelty {
+		if ly.Counts == nil {
+			ly.Counts = make(map[uint64]int)
+		}
+		ly.Counts[state]++
+		cnt = ly.Counts[state]
+	}
+	ly.RawRew = raw
+	ly.ShapRew = ly.Rew.Shape(raw, &ly.RewAvg, cnt)
+	ly.ApplyExt1D32([]float32{ly.ShapRew})
+}
+
+// AddRewLayer adds a RewLayer of given name
+func AddRewLayer(nt *axon.Network, name string) *RewLayer {
+	rew := &RewLayer{}
+	nt.AddLayerInit(rew, name, []int{1, 1}, emer.Input)
+	return rew
+}
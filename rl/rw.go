@@ -14,15 +14,106 @@ import (
 	"github.com/goki/mat32"
 )
 
+// RWDistTypes are the types of reward prediction represented by the
+// RWPredLayer: a single scalar value, or a distribution over rewards
+type RWDistTypes int32
+
+//go:generate stringer -type=RWDistTypes
+
+var KiT_RWDistTypes = kit.Enums.AddEnum(RWDistTypesN, kit.NotBitFlag, nil)
+
+func (ev RWDistTypes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *RWDistTypes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The reward prediction types
+const (
+	// ScalarDist is the standard single unit predicting the expected reward
+	ScalarDist RWDistTypes = iota
+
+	// QuantileDist has each of the N units predicting one quantile of the
+	// reward distribution: unit i represents quantile (i + .5) / N, learned
+	// by quantile regression
+	QuantileDist
+
+	// CategDist has each of the N units representing the probability of the
+	// reward falling into one of N evenly-spaced bins over the [Min, Max] range,
+	// computed as the softmax of the unit Ge values, and learned by
+	// cross-entropy toward the reward projected onto the two nearest bins
+	CategDist
+
+	RWDistTypesN
+)
+
+// RWDistParams are parameters for distributional reward prediction in the
+// RWPredLayer, in which the layer has N units representing the distribution
+// of rewards, and the RWDaLayer DA is the mismatch of the reward with the
+// expected value of the distribution
+type RWDistParams struct {
+	Type RWDistTypes `desc:"type of reward prediction represented"`
+	Min  float32     `viewif:"Type=CategDist" def:"0" desc:"reward value of the lowest bin for CategDist"`
+	Max  float32     `viewif:"Type=CategDist" def:"1" desc:"reward value of the highest bin for CategDist"`
+}
+
+// Defaults does not set Type, which is typically set when the layer is
+// configured (see AddRWDistLayers)
+func (dp *RWDistParams) Defaults() {
+	dp.Min = 0
+	dp.Max = 1
+}
+
+func (dp *RWDistParams) Update() {
+}
+
+// On returns true if a distribution is represented
+func (dp *RWDistParams) On() bool {
+	return dp.Type != ScalarDist
+}
+
+// BinVal returns the reward value of given bin out of n for CategDist
+func (dp *RWDistParams) BinVal(bi, n int) float32 {
+	if n <= 1 {
+		return dp.Min
+	}
+	return dp.Min + (dp.Max-dp.Min)*float32(bi)/float32(n-1)
+}
+
+// Quantile returns the quantile represented by unit ui out of n for QuantileDist
+func (dp *RWDistParams) Quantile(ui, n int) float32 {
+	return (float32(ui) + 0.5) / float32(n)
+}
+
+// CategTarg returns the target probability of bin bi out of n for given
+// reward, which is projected onto the two nearest bins for CategDist
+func (dp *RWDistParams) CategTarg(rew float32, bi, n int) float32 {
+	if n <= 1 || dp.Max <= dp.Min {
+		return 1
+	}
+	pos := (rew - dp.Min) / (dp.Max - dp.Min) * float32(n-1)
+	pos = mat32.Min(float32(n-1), mat32.Max(0, pos))
+	d := mat32.Abs(pos - float32(bi))
+	if d >= 1 {
+		return 0
+	}
+	return 1 - d
+}
+
 // RWPredLayer computes reward prediction for a simple Rescorla-Wagner
 // learning dynamic (i.e., PV learning in the PVLV framework).
 // Activity is computed as linear function of excitatory conductance
 // (which can be negative -- there are no constraints).
 // Use with RWPrjn which does simple delta-rule learning on minus-plus.
+// If Dist is On, the units represent a distribution of rewards, as
+// quantiles or categorical probabilities (see RWDistParams), for which
+// ExpVal is the expected reward, and Var its variance, for risk-sensitive
+// models.
 type RWPredLayer struct {
 	axon.Layer
-	PredRange minmax.F32 `desc:"default 0.1..0.99 range of predictions that can be represented -- having a truncated range preserves some sensitivity in dopamine at the extremes of good or poor performance"`
-	DA        float32    `inactive:"+" desc:"dopamine value for this layer"`
+	PredRange minmax.F32   `desc:"default 0.1..0.99 range of predictions that can be represented -- having a truncated range preserves some sensitivity in dopamine at the extremes of good or poor performance"`
+	DA        float32      `inactive:"+" desc:"dopamine value for this layer"`
+	Dist      RWDistParams `view:"inline" desc:"distributional reward prediction parameters"`
+
+	Rew    float32 `inactive:"+" desc:"reward value on the current trial, set by the RWDaLayer, for learning the distribution"`
+	HasRew bool    `inactive:"+" desc:"true if there is a reward on the current trial, set by the RWDaLayer"`
 }
 
 var KiT_RWPredLayer = kit.Types.AddType(&RWPredLayer{}, axon.LayerProps)
@@ -30,6 +121,14 @@ var KiT_RWPredLayer = kit.Types.AddType(&RWPredLayer{}, axon.LayerProps)
 func (ly *RWPredLayer) Defaults() {
 	ly.Layer.Defaults()
 	ly.PredRange.Set(0.01, 0.99)
+	ly.Dist.Defaults()
+}
+
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *RWPredLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.Dist.Update()
 }
 
 // DALayer interface:
@@ -37,8 +136,19 @@ func (ly *RWPredLayer) Defaults() {
 func (ly *RWPredLayer) GetDA() float32   { return ly.DA }
 func (ly *RWPredLayer) SetDA(da float32) { ly.DA = da }
 
-// ActFmG computes linear activation for RWPred
+// CheckpointVals returns the DA and reward state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (ly *RWPredLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA, &ly.Rew, &ly.HasRew}
+}
+
+// ActFmG computes linear activation for RWPred, or the softmax
+// probabilities for CategDist
 func (ly *RWPredLayer) ActFmG(ltime *axon.Time) {
+	if ly.Dist.Type == CategDist {
+		ly.CategActFmG()
+		return
+	}
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
@@ -48,6 +158,102 @@ func (ly *RWPredLayer) ActFmG(ltime *axon.Time) {
 	}
 }
 
+// CategActFmG computes the softmax of Ge as the bin probabilities for CategDist
+func (ly *RWPredLayer) CategActFmG() {
+	mx := float32(-mat32.MaxFloat32)
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if !nrn.IsOff() && nrn.Ge > mx {
+			mx = nrn.Ge
+		}
+	}
+	sum := float32(0)
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		nrn.Act = mat32.Exp(nrn.Ge - mx)
+		sum += nrn.Act
+	}
+	if sum == 0 {
+		return
+	}
+	for ni := range ly.Neurons {
+		nrn := &ly.Neurons[ni]
+		if nrn.IsOff() {
+			continue
+		}
+		nrn.Act /= sum
+	}
+}
+
+// UnitVal returns the reward value represented by given unit: its activity
+// for ScalarDist and QuantileDist, and its bin value for CategDist
+func (ly *RWPredLayer) UnitVal(ni int) float32 {
+	if ly.Dist.Type == CategDist {
+		return ly.Dist.BinVal(ni, len(ly.Neurons))
+	}
+	return ly.Neurons[ni].Act
+}
+
+// UnitProb returns the probability of the UnitVal of given unit
+func (ly *RWPredLayer) UnitProb(ni int) float32 {
+	switch ly.Dist.Type {
+	case QuantileDist:
+		return 1 / float32(len(ly.Neurons))
+	case CategDist:
+		return ly.Neurons[ni].Act
+	}
+	return 1
+}
+
+// ExpVal returns the expected reward value of the prediction:
+// the unit activity for ScalarDist, and the distribution mean otherwise
+func (ly *RWPredLayer) ExpVal() float32 {
+	if !ly.Dist.On() {
+		return ly.Neurons[0].Act
+	}
+	ev := float32(0)
+	for ni := range ly.Neurons {
+		ev += ly.UnitProb(ni) * ly.UnitVal(ni)
+	}
+	return ev
+}
+
+// Var returns the variance of the predicted reward distribution
+// (0 for ScalarDist)
+func (ly *RWPredLayer) Var() float32 {
+	if !ly.Dist.On() {
+		return 0
+	}
+	ev := ly.ExpVal()
+	vr := float32(0)
+	for ni := range ly.Neurons {
+		d := ly.UnitVal(ni) - ev
+		vr += ly.UnitProb(ni) * d * d
+	}
+	return vr
+}
+
+// UnitErr returns the learning error signal for given unit of a distribution,
+// for the current Rew: the quantile regression error for QuantileDist, and the
+// cross-entropy error (target - probability) for CategDist
+func (ly *RWPredLayer) UnitErr(ni int) float32 {
+	n := len(ly.Neurons)
+	switch ly.Dist.Type {
+	case QuantileDist:
+		q := ly.Dist.Quantile(ni, n)
+		if ly.Rew < ly.Neurons[ni].Act {
+			return q - 1
+		}
+		return q
+	case CategDist:
+		return ly.Dist.CategTarg(ly.Rew, ni, n) - ly.Neurons[ni].Act
+	}
+	return ly.DA
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  RWDaLayer
 
@@ -55,7 +261,9 @@ func (ly *RWPredLayer) ActFmG(ltime *axon.Time) {
 // learning dynamic (i.e., PV learning in the PVLV framework).
 // It computes difference between r(t) and RWPred values.
 // r(t) is accessed directly from a Rew layer -- if no external input then no
-// DA is computed -- critical for effective use of RW only for PV cases.
+// DA is computed -- critical for effective use of RW only for PV cases --
+// unless PredDA is set, in which case the change in prediction drives DA
+// (e.g., at the onset of a conditioned stimulus), without RWPred learning.
 // RWPred prediction is also accessed directly from Rew layer to avoid any issues.
 type RWDaLayer struct {
 	axon.Layer
@@ -63,6 +271,10 @@ type RWDaLayer struct {
 	RewLay    string  `desc:"name of Reward-representing layer from which this computes DA -- if nothing clamped, no dopamine computed"`
 	RWPredLay string  `desc:"name of RWPredLayer layer that is subtracted from the reward value"`
 	DA        float32 `inactive:"+" desc:"dopamine value for this layer"`
+
+	PredDA     bool    `desc:"on trials without a reward, generate DA from the change in the reward prediction relative to its value at the end of the previous trial (e.g., CS-onset dopamine) -- otherwise DA is only generated when the Rew layer has external input"`
+	PredDAGain float32 `viewif:"PredDA" def:"1" min:"0" desc:"multiplier on the prediction change DA when there is no reward"`
+	PrvPred    float32 `inactive:"+" desc:"expected value of the reward prediction at the end of the previous trial, for PredDA"`
 }
 
 var KiT_RWDaLayer = kit.Types.AddType(&RWDaLayer{}, deep.LayerProps)
@@ -75,6 +287,7 @@ func (ly *RWDaLayer) Defaults() {
 	if ly.RWPredLay == "" {
 		ly.RWPredLay = "RWPred"
 	}
+	ly.PredDAGain = 1
 }
 
 // DALayer interface:
@@ -82,6 +295,10 @@ func (ly *RWDaLayer) Defaults() {
 func (ly *RWDaLayer) GetDA() float32   { return ly.DA }
 func (ly *RWDaLayer) SetDA(da float32) { ly.DA = da }
 
+// DASender interface:
+
+func (ly *RWDaLayer) SendDAList() *SendDA { return &ly.SendDA }
+
 // RWLayers returns the reward and RWPred layers based on names
 func (ly *RWDaLayer) RWLayers() (*axon.Layer, *RWPredLayer, error) {
 	tly, err := ly.Network.LayerByNameTry(ly.RewLay)
@@ -111,6 +328,12 @@ func (ly *RWDaLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the DA and PrvPred state that is saved in a
+// Checkpoint (see axon.Checkpointer)
+func (ly *RWDaLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA, &ly.PrvPred}
+}
+
 func (ly *RWDaLayer) ActFmG(ltime *axon.Time) {
 	rly, ply, _ := ly.RWLayers()
 	if rly == nil || ply == nil {
@@ -122,21 +345,39 @@ func (ly *RWDaLayer) ActFmG(ltime *axon.Time) {
 		hasRew = true
 	}
 	ract := rnrn.Act
-	pnrn := &(ply.Neurons[0])
-	pact := pnrn.Act
+	pact := ply.ExpVal()
+	ply.Rew = ract
+	ply.HasRew = hasRew
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
-		if hasRew {
+		switch {
+		case hasRew:
 			nrn.Act = ract - pact
-		} else {
+		case ly.PredDA:
+			nrn.Act = ly.PredDAGain * (pact - ly.PrvPred)
+		default:
 			nrn.Act = 0 // nothing
 		}
 	}
 }
 
+// PlusPhase does updating at end of the plus phase, including
+// recording the prediction for PredDA on the next trial
+func (ly *RWDaLayer) PlusPhase(ltime *axon.Time) {
+	ly.Layer.PlusPhase(ltime)
+	if _, ply, err := ly.RWLayers(); err == nil {
+		ly.PrvPred = ply.ExpVal()
+	}
+}
+
+func (ly *RWDaLayer) InitActs() {
+	ly.Layer.InitActs()
+	ly.PrvPred = 0
+}
+
 // CyclePost is called at end of Cycle
 // We use it to send DA, which will then be active for the next cycle of processing.
 func (ly *RWDaLayer) CyclePost(ltime *axon.Time) {
@@ -161,20 +402,27 @@ var KiT_RWPrjn = kit.Types.AddType(&RWPrjn{}, deep.PrjnProps)
 func (pj *RWPrjn) Defaults() {
 	pj.Prjn.Defaults()
 	// no additional factors
-	pj.Learn.WtSig.Gain = 1
-	pj.Learn.Norm.On = false
-	pj.Learn.Momentum.On = false
-	pj.Learn.WtBal.On = false
+	pj.SWt.Adapt.On = false
+	pj.SWt.Adapt.SigGain = 1
 }
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *RWPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
 	rlay := pj.Recv.(axon.AxonLayer).AsAxon()
 	lda := pj.Recv.(DALayer).GetDA()
+	if rply, ok := pj.Recv.(*RWPredLayer); ok {
+		if rply.Dist.On() {
+			pj.DistDWt(rply)
+			return
+		}
+		if !rply.HasRew { // e.g., RWDaLayer PredDA without reward
+			return
+		}
+	}
 	if pj.DaTol > 0 {
 		if mat32.Abs(lda) <= pj.DaTol {
 			return // lda = 0 -- no learning
@@ -182,8 +430,7 @@ func (pj *RWPrjn) DWt() {
 	}
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		scons := pj.SConIdx[st : st+nc]
 
@@ -200,39 +447,35 @@ func (pj *RWPrjn) DWt() {
 				da = 0
 			}
 
-			dwt := da * sn.Act // no recv unit activation
-
-			norm := float32(1)
-			if pj.Learn.Norm.On {
-				norm = pj.Learn.Norm.NormFmAbsDWt(&sy.Norm, mat32.Abs(dwt))
-			}
-			if pj.Learn.Momentum.On {
-				dwt = norm * pj.Learn.Momentum.MomentFmDWt(&sy.Moment, dwt)
-			} else {
-				dwt *= norm
-			}
-			sy.DWt += pj.Learn.Lrate * dwt
+			sy.DWt += pj.Learn.Lrate.Eff * da * sn.Act // no recv unit activation
 		}
-		// aggregate max DWtNorm over sending synapses
-		if pj.Learn.Norm.On {
-			maxNorm := float32(0)
-			for ci := range syns {
-				sy := &syns[ci]
-				if sy.Norm > maxNorm {
-					maxNorm = sy.Norm
-				}
-			}
-			for ci := range syns {
-				sy := &syns[ci]
-				sy.Norm = maxNorm
-			}
+	}
+}
+
+// DistDWt computes the weight change for a distributional RWPredLayer,
+// from the per-unit error signal of each receiving unit (see UnitErr),
+// only on trials with a reward
+func (pj *RWPrjn) DistDWt(rply *RWPredLayer) {
+	if !rply.HasRew {
+		return
+	}
+	slay := pj.Send.(axon.AxonLayer).AsAxon()
+	for si := range slay.Neurons {
+		sn := &slay.Neurons[si]
+		nc, st := pj.SConNSt(si)
+		syns := pj.Syns[st : st+nc]
+		scons := pj.SConIdx[st : st+nc]
+		for ci := range syns {
+			sy := &syns[ci]
+			ri := int(scons[ci])
+			sy.DWt += pj.Learn.Lrate.Eff * rply.UnitErr(ri) * sn.Act
 		}
 	}
 }
 
 // WtFmDWt updates the synaptic weight values from delta-weight changes -- on sending projections
 func (pj *RWPrjn) WtFmDWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	for si := range pj.Syns {
@@ -243,4 +486,5 @@ func (pj *RWPrjn) WtFmDWt() {
 			sy.DWt = 0
 		}
 	}
+	pj.StaleWtSc()
 }
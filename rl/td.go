@@ -10,7 +10,6 @@ import (
 	"github.com/emer/axon/axon"
 	"github.com/emer/axon/deep"
 	"github.com/goki/ki/kit"
-	"github.com/goki/mat32"
 )
 
 // TDRewPredLayer is the temporal differences reward prediction layer.
@@ -29,6 +28,12 @@ var KiT_TDRewPredLayer = kit.Types.AddType(&TDRewPredLayer{}, axon.LayerProps)
 func (ly *TDRewPredLayer) GetDA() float32   { return ly.DA }
 func (ly *TDRewPredLayer) SetDA(da float32) { ly.DA = da }
 
+// CheckpointVals returns the DA state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *TDRewPredLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA}
+}
+
 // ActFmG computes linear activation for TDRewPred
 func (ly *TDRewPredLayer) ActFmG(ltime *axon.Time) {
 	for ni := range ly.Neurons {
@@ -36,7 +41,7 @@ func (ly *TDRewPredLayer) ActFmG(ltime *axon.Time) {
 		if nrn.IsOff() {
 			continue
 		}
-		if ltime.Quarter == 3 { // plus phase
+		if ltime.PlusPhase {
 			nrn.Act = nrn.Ge // linear
 		} else {
 			nrn.Act = nrn.ActP // previous actP
@@ -49,8 +54,9 @@ func (ly *TDRewPredLayer) ActFmG(ltime *axon.Time) {
 
 // TDRewIntegParams are params for reward integrator layer
 type TDRewIntegParams struct {
-	Discount float32 `desc:"discount factor -- how much to discount the future prediction from RewPred"`
+	Discount float32 `def:"0.9" min:"0" max:"1" desc:"discount factor (gamma) -- how much to discount the future prediction from RewPred, and each further step of reward for NSteps > 1"`
 	RewPred  string  `desc:"name of TDRewPredLayer to get reward prediction from "`
+	NSteps   int     `def:"1" min:"1" desc:"number of steps (trials) of reward integrated in the return before bootstrapping from the RewPred prediction: the plus phase represents r(t-n+1) + g r(t-n+2) + ... + g^(n-1) r(t) + g^n V(t+1), and the minus phase the prediction V(t-n+1) made n trials earlier -- 1 = standard one-step TD.  Note that TDRewPredPrjn learns from the sending activity on the previous trial, so for n > 1 the prediction should be driven by inputs that persist across the n steps"`
 }
 
 func (tp *TDRewIntegParams) Defaults() {
@@ -58,6 +64,25 @@ func (tp *TDRewIntegParams) Defaults() {
 	if tp.RewPred == "" {
 		tp.RewPred = "RewPred"
 	}
+	tp.NSteps = 1
+}
+
+func (tp *TDRewIntegParams) Update() {
+	if tp.NSteps < 1 {
+		tp.NSteps = 1
+	}
+}
+
+// Return returns the n-step return for given rewards, ordered from oldest
+// to the current one, with given bootstrap prediction of the next value
+func (tp *TDRewIntegParams) Return(rews []float32, pred float32) float32 {
+	ret := float32(0)
+	g := float32(1)
+	for _, r := range rews {
+		ret += g * r
+		g *= tp.Discount
+	}
+	return ret + g*pred
 }
 
 // TDRewIntegLayer is the temporal differences reward integration layer.
@@ -69,6 +94,9 @@ type TDRewIntegLayer struct {
 	axon.Layer
 	RewInteg TDRewIntegParams `desc:"parameters for reward integration"`
 	DA       float32          `desc:"dopamine value for this layer"`
+	RewHist  []float32        `view:"-" desc:"rewards on the last NSteps-1 trials, oldest first, for n-step returns"`
+	PredHist []float32        `view:"-" desc:"RewPred plus phase predictions on the last NSteps trials, oldest first, for n-step returns"`
+	Rews     []float32        `view:"-" desc:"scratch buffer of rewards for computing the n-step return"`
 }
 
 var KiT_TDRewIntegLayer = kit.Types.AddType(&TDRewIntegLayer{}, axon.LayerProps)
@@ -78,6 +106,29 @@ func (ly *TDRewIntegLayer) Defaults() {
 	ly.RewInteg.Defaults()
 }
 
+// UpdateParams updates all params given any changes that might have been made to individual values
+// including those in the receiving projections of this layer
+func (ly *TDRewIntegLayer) UpdateParams() {
+	ly.Layer.UpdateParams()
+	ly.RewInteg.Update()
+}
+
+func (ly *TDRewIntegLayer) InitActs() {
+	ly.Layer.InitActs()
+	ly.InitHist()
+}
+
+// InitHist initializes the reward and prediction histories for n-step returns
+func (ly *TDRewIntegLayer) InitHist() {
+	ns := ly.RewInteg.NSteps
+	if ns < 1 {
+		ns = 1
+	}
+	ly.RewHist = make([]float32, ns-1)
+	ly.PredHist = make([]float32, ns)
+	ly.Rews = make([]float32, ns)
+}
+
 // DALayer interface:
 
 func (ly *TDRewIntegLayer) GetDA() float32   { return ly.DA }
@@ -102,6 +153,12 @@ func (ly *TDRewIntegLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the DA and n-step return history that is saved in
+// a Checkpoint (see axon.Checkpointer)
+func (ly *TDRewIntegLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA, &ly.RewHist, &ly.PredHist}
+}
+
 func (ly *TDRewIntegLayer) ActFmG(ltime *axon.Time) {
 	rply, _ := ly.RewPredLayer()
 	if rply == nil {
@@ -109,19 +166,50 @@ func (ly *TDRewIntegLayer) ActFmG(ltime *axon.Time) {
 	}
 	rpActP := rply.Neurons[0].ActP
 	rpAct := rply.Neurons[0].Act
+	nstep := ly.RewInteg.NSteps > 1
+	if nstep && len(ly.PredHist) != ly.RewInteg.NSteps {
+		ly.InitHist()
+	}
 	for ni := range ly.Neurons {
 		nrn := &ly.Neurons[ni]
 		if nrn.IsOff() {
 			continue
 		}
-		if ltime.Quarter == 3 { // plus phase
+		switch {
+		case ltime.PlusPhase && nstep:
+			copy(ly.Rews, ly.RewHist)
+			ly.Rews[len(ly.RewHist)] = nrn.Ge
+			nrn.Act = ly.RewInteg.Return(ly.Rews, rpAct)
+		case ltime.PlusPhase:
 			nrn.Act = nrn.Ge + ly.RewInteg.Discount*rpAct
-		} else {
+		case nstep:
+			nrn.Act = ly.PredHist[0] // actP from n trials ago
+		default:
 			nrn.Act = rpActP // previous actP
 		}
 	}
 }
 
+// PlusPhase does updating at end of the plus phase, including
+// recording the reward and prediction histories for n-step returns
+func (ly *TDRewIntegLayer) PlusPhase(ltime *axon.Time) {
+	ly.Layer.PlusPhase(ltime)
+	if ly.RewInteg.NSteps <= 1 {
+		return
+	}
+	rply, _ := ly.RewPredLayer()
+	if rply == nil || len(ly.PredHist) != ly.RewInteg.NSteps {
+		return
+	}
+	if nr := len(ly.RewHist); nr > 0 {
+		copy(ly.RewHist, ly.RewHist[1:])
+		ly.RewHist[nr-1] = ly.Neurons[0].Ge
+	}
+	np := len(ly.PredHist)
+	copy(ly.PredHist, ly.PredHist[1:])
+	ly.PredHist[np-1] = rply.Neurons[0].ActP
+}
+
 //////////////////////////////////////////////////////////////////////////////////////
 //  TDDaLayer
 
@@ -138,7 +226,6 @@ var KiT_TDDaLayer = kit.Types.AddType(&TDDaLayer{}, axon.LayerProps)
 
 func (ly *TDDaLayer) Defaults() {
 	ly.Layer.Defaults()
-	ly.Act.Clamp.Range.Set(-100, 100)
 	if ly.RewInteg == "" {
 		ly.RewInteg = "RewInteg"
 	}
@@ -149,6 +236,10 @@ func (ly *TDDaLayer) Defaults() {
 func (ly *TDDaLayer) GetDA() float32   { return ly.DA }
 func (ly *TDDaLayer) SetDA(da float32) { ly.DA = da }
 
+// DASender interface:
+
+func (ly *TDDaLayer) SendDAList() *SendDA { return &ly.SendDA }
+
 func (ly *TDDaLayer) RewIntegLayer() (*TDRewIntegLayer, error) {
 	tly, err := ly.Network.LayerByNameTry(ly.RewInteg)
 	if err != nil {
@@ -172,6 +263,12 @@ func (ly *TDDaLayer) Build() error {
 	return err
 }
 
+// CheckpointVals returns the DA state that is saved in a Checkpoint
+// (see axon.Checkpointer)
+func (ly *TDDaLayer) CheckpointVals() []interface{} {
+	return []interface{}{&ly.DA}
+}
+
 func (ly *TDDaLayer) ActFmG(ltime *axon.Time) {
 	rily, _ := ly.RewIntegLayer()
 	if rily == nil {
@@ -185,7 +282,7 @@ func (ly *TDDaLayer) ActFmG(ltime *axon.Time) {
 		if nrn.IsOff() {
 			continue
 		}
-		if ltime.Quarter == 3 { // plus phase
+		if ltime.PlusPhase {
 			nrn.Act = da
 		} else {
 			nrn.Act = 0
@@ -204,37 +301,68 @@ func (ly *TDDaLayer) CyclePost(ltime *axon.Time) {
 //////////////////////////////////////////////////////////////////////////////////////
 //  TDRewPredPrjn
 
+// TDLambdaParams are parameters for TD(lambda) eligibility traces
+// in the TDRewPredPrjn, which accumulate the sending activity in the
+// per-synapse trace (Prjn.SynTr, shown as Tr), so that the TD error updates the weights from
+// recently-active inputs, not only those active on the previous trial.
+type TDLambdaParams struct {
+	Lambda float32 `def:"0,0.9" min:"0" max:"1" desc:"trace decay parameter lambda -- 0 = standard TD(0) learning from the previous trial activity only, 1 = Monte-Carlo like learning from all prior activity"`
+	Gamma  float32 `def:"0.9" min:"0" max:"1" desc:"discount factor gamma, multiplying Lambda for the trace decay -- should match the RewInteg.Discount of the TDRewIntegLayer"`
+}
+
+func (tl *TDLambdaParams) Defaults() {
+	tl.Lambda = 0
+	tl.Gamma = 0.9
+}
+
+func (tl *TDLambdaParams) Update() {
+}
+
+// TrFmAct updates the trace from the sending activity:
+// tr = Gamma * Lambda * tr + act, returning the new trace value
+func (tl *TDLambdaParams) TrFmAct(tr *float32, act float32) float32 {
+	*tr = tl.Gamma*tl.Lambda*(*tr) + act
+	return *tr
+}
+
 // TDRewPredPrjn does dopamine-modulated learning for reward prediction:
-// DWt = Da * Send.ActQ0 (activity on *previous* timestep)
+// DWt = Da * Send.ActPrv (activity on *previous* timestep), or its TD(lambda)
+// eligibility trace in Prjn.SynTr if TD.Lambda > 0.
 // Use in TDRewPredLayer typically to generate reward predictions.
 // Has no weight bounds or limits on sign etc.
 type TDRewPredPrjn struct {
 	axon.Prjn
+	TD TDLambdaParams `view:"inline" desc:"TD(lambda) eligibility trace parameters"`
 }
 
 var KiT_TDRewPredPrjn = kit.Types.AddType(&TDRewPredPrjn{}, deep.PrjnProps)
 
 func (pj *TDRewPredPrjn) Defaults() {
 	pj.Prjn.Defaults()
+	pj.TD.Defaults()
 	// no additional factors
-	pj.Learn.WtSig.Gain = 1
-	pj.Learn.Norm.On = false
-	pj.Learn.Momentum.On = false
-	pj.Learn.WtBal.On = false
+	pj.SWt.Adapt.On = false
+	pj.SWt.Adapt.SigGain = 1
+}
+
+func (pj *TDRewPredPrjn) UpdateParams() {
+	pj.Prjn.UpdateParams()
+	pj.TD.Update()
 }
 
 // DWt computes the weight change (learning) -- on sending projections.
 func (pj *TDRewPredPrjn) DWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	slay := pj.Send.(axon.AxonLayer).AsAxon()
 	// rlay := pj.Recv.(axon.AxonLayer).AsAxon()
 	da := pj.Recv.(DALayer).GetDA()
+	lr := pj.Learn.Lrate.Eff
+	pj.AllocSynOpt()
 	for si := range slay.Neurons {
 		sn := &slay.Neurons[si]
-		nc := int(pj.SConN[si])
-		st := int(pj.SConIdxSt[si])
+		nc, st := pj.SConNSt(si)
 		syns := pj.Syns[st : st+nc]
 		// scons := pj.SConIdx[st : st+nc]
 
@@ -242,39 +370,23 @@ func (pj *TDRewPredPrjn) DWt() {
 			sy := &syns[ci]
 			// ri := scons[ci]
 
-			dwt := da * sn.ActQ0 // no recv unit activation, prior trial act
-
-			norm := float32(1)
-			if pj.Learn.Norm.On {
-				norm = pj.Learn.Norm.NormFmAbsDWt(&sy.Norm, mat32.Abs(dwt))
-			}
-			if pj.Learn.Momentum.On {
-				dwt = norm * pj.Learn.Momentum.MomentFmDWt(&sy.Moment, dwt)
-			} else {
-				dwt *= norm
-			}
-			sy.DWt += pj.Learn.Lrate * dwt
-		}
-		// aggregate max DWtNorm over sending synapses
-		if pj.Learn.Norm.On {
-			maxNorm := float32(0)
-			for ci := range syns {
-				sy := &syns[ci]
-				if sy.Norm > maxNorm {
-					maxNorm = sy.Norm
-				}
-			}
-			for ci := range syns {
-				sy := &syns[ci]
-				sy.Norm = maxNorm
+			act := sn.ActPrv // no recv unit activation, prior trial act
+			if pj.TD.Lambda > 0 {
+				act = pj.TD.TrFmAct(&pj.SynTr[st+ci], act)
 			}
+			sy.DWt += lr * da * act
 		}
 	}
 }
 
+// SynTrOn returns true if the Prjn.SynTr eligibility trace is in use: TD.Lambda > 0
+func (pj *TDRewPredPrjn) SynTrOn() bool {
+	return pj.TD.Lambda > 0
+}
+
 // WtFmDWt updates the synaptic weight values from delta-weight changes -- on sending projections
 func (pj *TDRewPredPrjn) WtFmDWt() {
-	if !pj.Learn.Learn {
+	if !pj.Learn.Learning() {
 		return
 	}
 	for si := range pj.Syns {
@@ -285,4 +397,5 @@ func (pj *TDRewPredPrjn) WtFmDWt() {
 			sy.DWt = 0
 		}
 	}
+	pj.StaleWtSc()
 }
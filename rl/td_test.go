@@ -0,0 +1,133 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rl
+
+import (
+	"testing"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+	"github.com/goki/mat32"
+)
+
+// tdDelayedRew runs linear TD learning with TDLambdaParams traces on a
+// delayed-reward task: a serial compound of nt one-hot time steps, with
+// reward at the last step, returning the learned value of the first step.
+func tdDelayedRew(lambda float32, nt, nepc int) float32 {
+	tl := TDLambdaParams{}
+	tl.Defaults()
+	tl.Lambda = lambda
+	lr := float32(0.5)
+	w := make([]float32, nt)
+	for epc := 0; epc < nepc; epc++ {
+		tr := make([]float32, nt)
+		for t := 0; t < nt; t++ {
+			for i := range tr {
+				act := float32(0)
+				if i == t {
+					act = 1
+				}
+				tl.TrFmAct(&tr[i], act)
+			}
+			rew, vnext := float32(0), float32(0)
+			if t == nt-1 {
+				rew = 1
+			} else {
+				vnext = w[t+1]
+			}
+			da := rew + tl.Gamma*vnext - w[t]
+			for i := range w {
+				w[i] += lr * da * tr[i]
+			}
+		}
+	}
+	return w[0]
+}
+
+func TestTDLambda(t *testing.T) {
+	tl := TDLambdaParams{}
+	tl.Defaults()
+	var tr float32
+	tl.TrFmAct(&tr, 1)
+	if tl.TrFmAct(&tr, 0.5) != 0.5 {
+		t.Errorf("TD(0) trace should be the current activity: %v\n", tr)
+	}
+	v0 := tdDelayedRew(0, 4, 2)
+	if v0 != 0 {
+		t.Errorf("TD(0) should not propagate reward back 3 steps in 2 epochs: %v\n", v0)
+	}
+	vl := tdDelayedRew(0.9, 4, 2)
+	if vl <= 0 {
+		t.Errorf("TD(lambda) should propagate reward back to the first step: %v\n", vl)
+	}
+	vl = tdDelayedRew(0.9, 4, 50)
+	if vl < 0.6 || vl > 0.85 {
+		t.Errorf("TD(lambda) first step value should approach gamma^3 = 0.729: %v\n", vl)
+	}
+}
+
+// tdTrial runs one trial of the TD network with given input and reward
+func tdTrial(net *axon.Network, ltime *axon.Time, inLay *axon.Layer, rew *RewLayer, inpat []float32) {
+	net.InitExt()
+	inLay.ApplyExt1D32(inpat)
+	rew.ApplyRew(1, 0)
+	net.NewState()
+	ltime.NewState()
+	for cyc := 0; cyc < 200; cyc++ {
+		if cyc == 150 {
+			net.MinusPhase(ltime)
+			ltime.NewPhase()
+		}
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	net.PlusPhase(ltime)
+	net.DWt()
+	net.WtFmDWt()
+}
+
+func TestTDNet(t *testing.T) {
+	net := axon.NewNetwork("TDNet")
+	inLay := net.AddLayer2D("Input", 1, 2, emer.Input).(*axon.Layer)
+	rew, rp, _, td := AddTDLayers(net, "", relpos.Behind, 2)
+	pj := net.ConnectLayersPrjn(inLay, rp, prjn.NewFull(), emer.Forward, &TDRewPredPrjn{}).(*TDRewPredPrjn)
+
+	net.Defaults()
+	pj.TD.Lambda = 0.9
+	if err := net.Build(); err != nil {
+		t.Fatal(err)
+	}
+	net.InitWts()
+	if len(pj.SynTr) != len(pj.Syns) || pj.SynTr[0] != 0 {
+		t.Errorf("TD trace should be allocated and zeroed by InitWts with Lambda > 0\n")
+	}
+	wt0 := pj.Syns[0].Wt
+	wt1 := pj.Syns[1].Wt
+
+	ltime := axon.NewTime()
+	inpat := []float32{1, 0}
+	tdTrial(net, ltime, inLay, rew.(*RewLayer), inpat)
+	if da := td.(*TDDaLayer).DA; da <= 0 {
+		t.Errorf("TD DA should be positive for an unpredicted reward: %v\n", da)
+	}
+	if rp.(*TDRewPredLayer).DA != td.(*TDDaLayer).DA {
+		t.Errorf("TD layer should send DA to RewPred: %v != %v\n", rp.(*TDRewPredLayer).DA, td.(*TDDaLayer).DA)
+	}
+	if len(pj.SynTr) != len(pj.Syns) {
+		t.Fatalf("TD trace should be allocated with Lambda > 0: %d\n", len(pj.SynTr))
+	}
+
+	tdTrial(net, ltime, inLay, rew.(*RewLayer), inpat)
+	if pj.SynTr[0] <= pj.SynTr[1] {
+		t.Errorf("TD trace should be larger for the previously-active input: %v <= %v\n", pj.SynTr[0], pj.SynTr[1])
+	}
+	dw0 := mat32.Abs(pj.Syns[0].Wt - wt0)
+	dw1 := mat32.Abs(pj.Syns[1].Wt - wt1)
+	if dw0 <= dw1 {
+		t.Errorf("active input weight should learn more from the TD error: %v <= %v\n", dw0, dw1)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package serve provides an HTTP / JSON endpoint for running inference on a
+trained axon.Network, so that models can be embedded in robotics and other
+interactive applications.  Each request applies external inputs to named
+layers, runs a configurable number of theta-cycle trials (minus and plus
+phases) with learning off, and returns the requested unit variables
+(ActM, ActP by default) for requested layers.
+*/
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/etable/etensor"
+)
+
+// RunRequest is the JSON request body for the /run endpoint
+type RunRequest struct {
+	Inputs   map[string][]float32 `desc:"external input values for each input layer, by layer name, in the flat layer unit order"`
+	Layers   []string             `desc:"names of layers to return values for"`
+	Vars     []string             `desc:"unit variables to return -- defaults to ActM, ActP"`
+	NTrials  int                  `desc:"number of trials to run with the inputs -- defaults to 1"`
+	MinusCyc int                  `desc:"number of cycles in the minus phase -- 0 = Server default"`
+	PlusCyc  int                  `desc:"number of cycles in the plus phase -- 0 = Server default"`
+}
+
+// LayerVals are the values returned for a layer
+type LayerVals struct {
+	Shape []int                `desc:"shape of the layer"`
+	Vals  map[string][]float32 `desc:"values for each variable, in the flat layer unit order"`
+}
+
+// RunResponse is the JSON response body for the /run endpoint
+type RunResponse struct {
+	Layers map[string]*LayerVals `desc:"values for each requested layer"`
+	Error  string                `desc:"error message if the request failed"`
+}
+
+// LayerInfo describes a layer, as returned by the /layers endpoint
+type LayerInfo struct {
+	Name  string `desc:"layer name"`
+	Type  string `desc:"layer type"`
+	Shape []int  `desc:"shape of the layer"`
+}
+
+// Server serves inference requests on an axon.Network.
+// Requests are serialized, as the network has a single state.
+type Server struct {
+	Net       *axon.Network `desc:"the network, which should be fully built and trained"`
+	Time      axon.Time     `desc:"the time state used for running trials"`
+	MinusCyc  int           `def:"150" desc:"default number of cycles in the minus phase"`
+	PlusCyc   int           `def:"50" desc:"default number of cycles in the plus phase"`
+	MaxTrials int           `def:"100" desc:"maximum number of trials allowed per request"`
+	MaxCycles int           `def:"1000" desc:"maximum number of cycles allowed in each of the minus and plus phases of a request"`
+
+	mu sync.Mutex
+}
+
+// NewServer returns a new Server for given network, with default settings
+func NewServer(net *axon.Network) *Server {
+	sv := &Server{Net: net, MinusCyc: 150, PlusCyc: 50, MaxTrials: 100, MaxCycles: 1000}
+	sv.Time.Defaults()
+	return sv
+}
+
+// Handler returns an http.Handler with the /run and /layers endpoints
+func (sv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/run", sv.HandleRun)
+	mux.HandleFunc("/layers", sv.HandleLayers)
+	return mux
+}
+
+// ListenAndServe serves the Handler on given address, e.g., ":8080"
+func (sv *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, sv.Handler())
+}
+
+// HandleLayers returns the LayerInfo for all layers in the network
+func (sv *Server) HandleLayers(w http.ResponseWriter, r *http.Request) {
+	var lays []LayerInfo
+	for _, ly := range sv.Net.Layers {
+		lays = append(lays, LayerInfo{Name: ly.Name(), Type: ly.Type().String(), Shape: ly.Shape().Shp})
+	}
+	writeJSON(w, http.StatusOK, lays)
+}
+
+// HandleRun handles a POST of a RunRequest, returning a RunResponse
+func (sv *Server) HandleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, &RunResponse{Error: "run requires POST"})
+		return
+	}
+	req := &RunRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &RunResponse{Error: err.Error()})
+		return
+	}
+	resp, err := sv.Run(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &RunResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Run runs the given request on the network, with learning off:
+// the network is put into InferenceMode for the duration of the request,
+// so the running-average learning state is not updated.
+func (sv *Server) Run(req *RunRequest) (*RunResponse, error) {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	vars := req.Vars
+	if len(vars) == 0 {
+		vars = []string{"ActM", "ActP"}
+	}
+	ntrl := req.NTrials
+	if ntrl < 1 {
+		ntrl = 1
+	}
+	if sv.MaxTrials > 0 && ntrl > sv.MaxTrials {
+		return nil, fmt.Errorf("NTrials: %d exceeds MaxTrials: %d", ntrl, sv.MaxTrials)
+	}
+	minusCyc := req.MinusCyc
+	if minusCyc <= 0 {
+		minusCyc = sv.MinusCyc
+	}
+	plusCyc := req.PlusCyc
+	if plusCyc <= 0 {
+		plusCyc = sv.PlusCyc
+	}
+	if sv.MaxCycles > 0 {
+		if minusCyc > sv.MaxCycles {
+			return nil, fmt.Errorf("MinusCyc: %d exceeds MaxCycles: %d", minusCyc, sv.MaxCycles)
+		}
+		if plusCyc > sv.MaxCycles {
+			return nil, fmt.Errorf("PlusCyc: %d exceeds MaxCycles: %d", plusCyc, sv.MaxCycles)
+		}
+	}
+	for _, lnm := range req.Layers {
+		if _, err := sv.Net.LayerByNameTry(lnm); err != nil {
+			return nil, err
+		}
+	}
+
+	if !sv.Net.Inference {
+		sv.Net.InferenceMode(true)
+		defer sv.Net.InferenceMode(false)
+	}
+	sv.Net.InitExt()
+	for lnm, vals := range req.Inputs {
+		ly, err := sv.Net.LayerByNameTry(lnm)
+		if err != nil {
+			return nil, err
+		}
+		shp := ly.Shape()
+		if len(vals) != shp.Len() {
+			return nil, fmt.Errorf("layer %s: number of input values: %d != number of units: %d", lnm, len(vals), shp.Len())
+		}
+		ext := etensor.NewFloat32(shp.Shp, nil, shp.Nms)
+		copy(ext.Values, vals)
+		ly.(axon.AxonLayer).ApplyExt(ext)
+	}
+	for trl := 0; trl < ntrl; trl++ {
+		sv.Trial(minusCyc, plusCyc)
+	}
+
+	resp := &RunResponse{Layers: make(map[string]*LayerVals)}
+	for _, lnm := range req.Layers {
+		ly := sv.Net.LayerByName(lnm).(axon.AxonLayer).AsAxon()
+		lv := &LayerVals{Shape: ly.Shp.Shp, Vals: make(map[string][]float32)}
+		for _, vnm := range vars {
+			var vals []float32
+			if err := ly.UnitVals(&vals, vnm); err != nil {
+				return nil, err
+			}
+			lv.Vals[vnm] = vals
+		}
+		resp.Layers[lnm] = lv
+	}
+	return resp, nil
+}
+
+// Trial runs one theta-cycle trial with given number of minus and plus
+// phase cycles, without any learning -- see Run for InferenceMode
+func (sv *Server) Trial(minusCyc, plusCyc int) {
+	net := sv.Net
+	ltime := &sv.Time
+	net.NewState()
+	ltime.NewState()
+	for cyc := 0; cyc < minusCyc; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	net.MinusPhase(ltime)
+	ltime.NewPhase()
+	for cyc := 0; cyc < plusCyc; cyc++ {
+		net.Cycle(ltime)
+		ltime.CycleInc()
+	}
+	net.PlusPhase(ltime)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
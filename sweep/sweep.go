@@ -0,0 +1,390 @@
+// Copyright (c) 2021, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package sweep provides a hyperparameter sweep harness for axon models:
+a Sweep specifies a set of parameter dimensions (params.Params paths with
+their selectors and value ranges), which are expanded into a list of
+configurations by a grid, random, or Latin-hypercube method.  Each
+configuration is a params.Sheet that is applied to a network built by a
+user-supplied function, which is then trained with an axon.Trainer.  The
+final epoch stats of each run are aggregated into one etable.Table for
+analysis.  Configurations can be run sequentially, in parallel goroutines,
+or in parallel child processes of the same executable (see Child).
+*/
+package sweep
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/emer/axon/axon"
+	"github.com/emer/emergent/params"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/kit"
+)
+
+// Methods are the methods for expanding the sweep Dims into configurations
+type Methods int32
+
+//go:generate stringer -type=Methods
+
+var KiT_Methods = kit.Enums.AddEnum(MethodsN, kit.NotBitFlag, nil)
+
+func (ev Methods) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *Methods) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// The sweep expansion methods
+const (
+	// Grid uses all combinations of the Vals of each Dim
+	Grid Methods = iota
+
+	// Random draws N configurations with each Dim value drawn
+	// independently and uniformly between Min and Max
+	Random
+
+	// LatinHypercube draws N configurations such that each Dim range
+	// is divided into N equal strata, each of which is sampled exactly once
+	LatinHypercube
+
+	MethodsN
+)
+
+// Dim is one dimension of a sweep: a parameter path and its values
+type Dim struct {
+	Sel  string    `desc:"params selector for the objects the parameter applies to, e.g., Layer, .Hidden, #Output, Prjn"`
+	Path string    `desc:"params path of the parameter, e.g., Layer.Inhib.Layer.Gi"`
+	Vals []float64 `desc:"values for the Grid method"`
+	Min  float64   `desc:"minimum value for Random and LatinHypercube methods"`
+	Max  float64   `desc:"maximum value for Random and LatinHypercube methods"`
+	Log  bool      `desc:"sample uniformly in log space between Min and Max for Random and LatinHypercube methods -- Min and Max must be > 0"`
+}
+
+// FmUnit returns the value for given proportion 0..1 of the Min..Max range
+func (dm *Dim) FmUnit(u float64) float64 {
+	if dm.Log {
+		lmin := math.Log(dm.Min)
+		return math.Exp(lmin + u*(math.Log(dm.Max)-lmin))
+	}
+	return dm.Min + u*(dm.Max-dm.Min)
+}
+
+// Config is one configuration of parameter values in a sweep
+type Config struct {
+	Idx   int           `desc:"index of this configuration in the sweep"`
+	Vals  []float64     `desc:"value of each sweep Dim"`
+	Sheet *params.Sheet `desc:"params sheet setting the values, to apply to the network after its Defaults and base params"`
+}
+
+// NewTrainerFunc is a function that returns a new axon.Trainer, with its
+// network configured and built with the given Config Sheet applied,
+// e.g., via Network.ApplyParams(cfg.Sheet, false) prior to Build.
+type NewTrainerFunc func(cfg *Config) (*axon.Trainer, error)
+
+// Sweep specifies a hyperparameter sweep: the parameter Dims and the
+// Method for expanding them into configurations, and how to run them
+type Sweep struct {
+	Dims   []Dim   `desc:"the parameter dimensions of the sweep"`
+	Method Methods `desc:"method for expanding the Dims into configurations"`
+	N      int     `desc:"number of configurations for the Random and LatinHypercube methods"`
+	Seed   int64   `desc:"random seed for the Random and LatinHypercube methods"`
+	NRuns  int     `def:"1" min:"1" desc:"number of Trainer runs for each configuration"`
+	NPar   int     `desc:"number of configurations to run in parallel goroutines -- each must then use a separate network -- 0 or 1 = sequential"`
+	NProcs int     `desc:"if > 0, run configurations in this number of parallel child processes of the current executable, which must call Child at the start of main -- takes precedence over NPar"`
+
+	Results *etable.Table `desc:"aggregated results: the final epoch log row of each run of each configuration"`
+}
+
+// Configs expands the Dims according to the Method into the list of configurations
+func (sw *Sweep) Configs() []*Config {
+	var vals [][]float64
+	switch sw.Method {
+	case Grid:
+		vals = [][]float64{nil}
+		for _, dm := range sw.Dims {
+			var nv [][]float64
+			for _, cv := range vals {
+				for _, v := range dm.Vals {
+					nv = append(nv, append(append([]float64(nil), cv...), v))
+				}
+			}
+			vals = nv
+		}
+	case Random:
+		rnd := rand.New(rand.NewSource(sw.Seed))
+		vals = make([][]float64, sw.N)
+		for ci := range vals {
+			vals[ci] = make([]float64, len(sw.Dims))
+			for di := range sw.Dims {
+				vals[ci][di] = sw.Dims[di].FmUnit(rnd.Float64())
+			}
+		}
+	case LatinHypercube:
+		rnd := rand.New(rand.NewSource(sw.Seed))
+		vals = make([][]float64, sw.N)
+		for ci := range vals {
+			vals[ci] = make([]float64, len(sw.Dims))
+		}
+		for di := range sw.Dims {
+			perm := rnd.Perm(sw.N)
+			for ci := range vals {
+				u := (float64(perm[ci]) + rnd.Float64()) / float64(sw.N)
+				vals[ci][di] = sw.Dims[di].FmUnit(u)
+			}
+		}
+	}
+	cfgs := make([]*Config, len(vals))
+	for ci, cv := range vals {
+		cfgs[ci] = sw.NewConfig(ci, cv)
+	}
+	return cfgs
+}
+
+// NewConfig returns the Config with given index and Dim values,
+// including the params Sheet that sets them
+func (sw *Sweep) NewConfig(idx int, vals []float64) *Config {
+	cfg := &Config{Idx: idx, Vals: vals}
+	sht := params.Sheet{}
+	for di, dm := range sw.Dims {
+		sht = append(sht, &params.Sel{Sel: dm.Sel, Desc: "sweep", Params: params.Params{dm.Path: fmt.Sprintf("%g", vals[di])}})
+	}
+	cfg.Sheet = &sht
+	return cfg
+}
+
+// Sets returns the configurations as params.Sets, with one Set named
+// Sweep_<index> per configuration, having a "Network" Sheet
+func (sw *Sweep) Sets() params.Sets {
+	cfgs := sw.Configs()
+	sets := make(params.Sets, len(cfgs))
+	for ci, cfg := range cfgs {
+		sets[ci] = &params.Set{Name: fmt.Sprintf("Sweep_%d", ci), Desc: "sweep configuration", Sheets: params.Sheets{"Network": cfg.Sheet}}
+	}
+	return sets
+}
+
+// RunConfig runs the Trainer for given configuration, returning a table
+// with the final epoch log row of each run
+func (sw *Sweep) RunConfig(cfg *Config, newTrainer NewTrainerFunc) (*etable.Table, error) {
+	tr, err := newTrainer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	nruns := sw.NRuns
+	if nruns < 1 {
+		nruns = 1
+	}
+	dt := &etable.Table{}
+	sw.ConfigTable(dt, tr.EpcLog)
+	for run := 0; run < nruns; run++ {
+		tr.Init(run)
+		tr.TrainRun()
+		sw.AddResult(dt, cfg, tr.EpcLog)
+		if tr.StopNow {
+			break
+		}
+	}
+	return dt, nil
+}
+
+// ConfigTable configures given results table with columns for the
+// configuration index, each Dim value (named by Path), and each
+// column of given epoch log
+func (sw *Sweep) ConfigTable(dt *etable.Table, epcLog *etable.Table) {
+	sch := etable.Schema{{"Config", etensor.INT64, nil, nil}}
+	for _, dm := range sw.Dims {
+		sch = append(sch, etable.Column{dm.Path, etensor.FLOAT64, nil, nil})
+	}
+	for ci, cl := range epcLog.Cols {
+		if cl.NumDims() != 1 || cl.DataType() == etensor.STRING {
+			continue
+		}
+		sch = append(sch, etable.Column{epcLog.ColNames[ci], etensor.FLOAT64, nil, nil})
+	}
+	dt.SetMetaData("name", "SweepResults")
+	dt.SetFromSchema(sch, 0)
+}
+
+// AddResult adds a row to given results table with the last row of given
+// epoch log, for given configuration
+func (sw *Sweep) AddResult(dt *etable.Table, cfg *Config, epcLog *etable.Table) {
+	if epcLog.Rows == 0 {
+		return
+	}
+	lr := epcLog.Rows - 1
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+	dt.SetCellFloat("Config", row, float64(cfg.Idx))
+	for di, dm := range sw.Dims {
+		dt.SetCellFloat(dm.Path, row, cfg.Vals[di])
+	}
+	for ci := 1 + len(sw.Dims); ci < len(dt.Cols); ci++ {
+		cnm := dt.ColNames[ci]
+		dt.SetCellFloat(cnm, row, epcLog.CellFloat(cnm, lr))
+	}
+}
+
+// AppendTable appends the rows of src to dt, matching columns by name
+func AppendTable(dt, src *etable.Table) {
+	if dt.Rows == 0 && len(dt.Cols) == 0 {
+		dt.SetFromSchema(src.Schema(), 0)
+		dt.SetMetaData("name", "SweepResults")
+	}
+	for r := 0; r < src.Rows; r++ {
+		row := dt.Rows
+		dt.SetNumRows(row + 1)
+		for _, cnm := range dt.ColNames {
+			if src.ColByName(cnm) == nil {
+				continue
+			}
+			dt.SetCellFloat(cnm, row, src.CellFloat(cnm, r))
+		}
+	}
+}
+
+// Run runs all configurations, sequentially, in NPar goroutines, or in
+// NProcs child processes, aggregating the results into Results,
+// which is returned.  If any configurations fail, the first error
+// is returned, along with the results of the others.
+func (sw *Sweep) Run(newTrainer NewTrainerFunc) (*etable.Table, error) {
+	cfgs := sw.Configs()
+	if sw.NProcs > 0 {
+		return sw.RunProcs(cfgs)
+	}
+	res := make([]*etable.Table, len(cfgs))
+	errs := make([]error, len(cfgs))
+	npar := sw.NPar
+	if npar < 1 {
+		npar = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, npar)
+	for ci, cfg := range cfgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ci int, cfg *Config) {
+			res[ci], errs[ci] = sw.RunConfig(cfg, newTrainer)
+			<-sem
+			wg.Done()
+		}(ci, cfg)
+	}
+	wg.Wait()
+	return sw.aggregate(res, errs)
+}
+
+// aggregate collects the given per-configuration results into Results
+func (sw *Sweep) aggregate(res []*etable.Table, errs []error) (*etable.Table, error) {
+	sw.Results = &etable.Table{}
+	var err error
+	for ci, dt := range res {
+		if errs[ci] != nil {
+			log.Printf("sweep: configuration %d: %v\n", ci, errs[ci])
+			if err == nil {
+				err = errs[ci]
+			}
+			continue
+		}
+		if dt != nil {
+			AppendTable(sw.Results, dt)
+		}
+	}
+	return sw.Results, err
+}
+
+// Environment variables used to run one configuration in a child process
+const (
+	// EnvIdx is the environment variable with the configuration index for Child
+	EnvIdx = "AXON_SWEEP_IDX"
+
+	// EnvOut is the environment variable with the results file name for Child
+	EnvOut = "AXON_SWEEP_OUT"
+)
+
+// RunProcs runs given configurations in NProcs parallel child processes of
+// the current executable, which must call Child at the start of main with
+// the same Sweep and NewTrainerFunc.  Each child saves its results to a
+// temporary CSV file, which is then read and aggregated.
+func (sw *Sweep) RunProcs(cfgs []*Config) (*etable.Table, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "axon_sweep")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	res := make([]*etable.Table, len(cfgs))
+	errs := make([]error, len(cfgs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, sw.NProcs)
+	for ci := range cfgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ci int) {
+			defer func() { <-sem; wg.Done() }()
+			fnm := filepath.Join(dir, fmt.Sprintf("sweep_%d.csv", ci))
+			cmd := exec.Command(exe, os.Args[1:]...)
+			cmd.Env = append(os.Environ(), EnvIdx+"="+strconv.Itoa(ci), EnvOut+"="+fnm)
+			cmd.Stderr = os.Stderr
+			if out, err := cmd.Output(); err != nil {
+				errs[ci] = fmt.Errorf("child process failed: %v: %s", err, out)
+				return
+			}
+			dt := &etable.Table{}
+			errs[ci] = dt.OpenCSV(gi.FileName(fnm), etable.Comma)
+			res[ci] = dt
+		}(ci)
+	}
+	wg.Wait()
+	return sw.aggregate(res, errs)
+}
+
+// Child checks whether this process was started by RunProcs to run one
+// configuration, and if so, runs it, saves the results, and exits the
+// process -- otherwise it returns immediately.  It must be called at the
+// start of main, with the same Sweep and NewTrainerFunc as the parent.
+func (sw *Sweep) Child(newTrainer NewTrainerFunc) {
+	idxs, ok := os.LookupEnv(EnvIdx)
+	if !ok {
+		return
+	}
+	ci, err := strconv.Atoi(idxs)
+	cfgs := sw.Configs()
+	if err != nil || ci < 0 || ci >= len(cfgs) {
+		log.Printf("sweep Child: invalid configuration index: %v\n", idxs)
+		os.Exit(1)
+	}
+	dt, err := sw.RunConfig(cfgs[ci], newTrainer)
+	if err == nil {
+		err = dt.SaveCSV(gi.FileName(os.Getenv(EnvOut)), etable.Comma, etable.Headers)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// SaveResultsCSV saves the aggregated Results to given file name in CSV format
+func (sw *Sweep) SaveResultsCSV(filename string) error {
+	if sw.Results == nil {
+		return fmt.Errorf("sweep SaveResultsCSV: no Results -- call Run first")
+	}
+	err := sw.Results.SaveCSV(gi.FileName(filename), etable.Comma, etable.Headers)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}